@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"time"
@@ -23,10 +24,11 @@ func init() {
 }
 
 func newAIListCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List available AI providers",
 		Run: func(cmd *cobra.Command, args []string) {
+			check, _ := cmd.Flags().GetBool("check")
 			names := provider.Names()
 			fmt.Println("Available providers:")
 			for _, name := range names {
@@ -37,10 +39,22 @@ func newAIListCmd() *cobra.Command {
 					continue
 				}
 				info := p.Info()
+				if !check {
+					fmt.Printf("  - %-15s %s (default model: %s)\n",
+						info.Name, info.DisplayName, info.DefaultModel)
+					continue
+				}
 				ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Second)
 				status := "configured"
 				if err := p.Validate(ctx); err != nil {
-					status = "missing credentials"
+					switch {
+					case errors.Is(err, provider.ErrAuthentication):
+						status = "missing credentials"
+					case errors.Is(err, provider.ErrTimeout), errors.Is(err, provider.ErrProviderUnavailable):
+						status = "unreachable"
+					default:
+						status = "validation failed"
+					}
 				}
 				cancel()
 				fmt.Printf("  - %-15s %s [%s] (default model: %s)\n",
@@ -48,6 +62,8 @@ func newAIListCmd() *cobra.Command {
 			}
 		},
 	}
+	cmd.Flags().Bool("check", false, "Validate each provider against its API (network calls)")
+	return cmd
 }
 
 func newAIShowCmd() *cobra.Command {
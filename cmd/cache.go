@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const defaultReviewCacheDir = ".prev/cache"
+
+// reviewCacheKinds lists the cache sub-directories managed under the cache dir.
+var reviewCacheKinds = []string{"completion", "content", "http"}
+
+type reviewCacheStats struct {
+	Kind    string `json:"kind"`
+	Path    string `json:"path"`
+	Entries int    `json:"entries"`
+	Bytes   int64  `json:"bytes"`
+}
+
+func init() {
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Inspect and clear on-disk review caches",
+	}
+
+	cacheCmd.AddCommand(newCacheShowCmd())
+	cacheCmd.AddCommand(newCacheClearCmd())
+	rootCmd.AddCommand(cacheCmd)
+}
+
+func newCacheShowCmd() *cobra.Command {
+	var cacheDir string
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "Show cache entry counts and sizes",
+		Run: func(cmd *cobra.Command, args []string) {
+			dir := resolveReviewCacheDir(resolveMRRepoPath(), cacheDir)
+			stats, err := collectReviewCacheStats(dir, reviewCacheKinds)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if asJSON {
+				out, err := json.MarshalIndent(stats, "", "  ")
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+				fmt.Println(string(out))
+				return
+			}
+			fmt.Printf("Cache dir: %s\n", dir)
+			var totalEntries int
+			var totalBytes int64
+			for _, s := range stats {
+				fmt.Printf("  %-10s entries=%d size=%s\n", s.Kind, s.Entries, formatByteSize(s.Bytes))
+				totalEntries += s.Entries
+				totalBytes += s.Bytes
+			}
+			fmt.Printf("  %-10s entries=%d size=%s\n", "total", totalEntries, formatByteSize(totalBytes))
+		},
+	}
+
+	cmd.Flags().StringVar(&cacheDir, "cache-dir", defaultReviewCacheDir, "Path to the review cache directory")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print cache statistics as JSON")
+	return cmd
+}
+
+func newCacheClearCmd() *cobra.Command {
+	var cacheDir string
+	var kind string
+
+	cmd := &cobra.Command{
+		Use:   "clear",
+		Short: "Purge cached entries (all kinds or a single --kind)",
+		Run: func(cmd *cobra.Command, args []string) {
+			kinds, err := selectReviewCacheKinds(kind)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			dir := resolveReviewCacheDir(resolveMRRepoPath(), cacheDir)
+			removed, err := clearReviewCache(dir, kinds)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Cleared %d cache entries (%s) from %s\n", removed, strings.Join(kinds, ", "), dir)
+		},
+	}
+
+	cmd.Flags().StringVar(&cacheDir, "cache-dir", defaultReviewCacheDir, "Path to the review cache directory")
+	cmd.Flags().StringVar(&kind, "kind", "", "Cache kind to clear: completion, content, http (default: all)")
+	return cmd
+}
+
+func resolveReviewCacheDir(repoPath, configured string) string {
+	dir := strings.TrimSpace(configured)
+	if dir == "" {
+		dir = defaultReviewCacheDir
+	}
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	root := strings.TrimSpace(repoPath)
+	if root == "" {
+		root = "."
+	}
+	return filepath.Join(root, dir)
+}
+
+func selectReviewCacheKinds(kind string) ([]string, error) {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	if kind == "" || kind == "all" {
+		return reviewCacheKinds, nil
+	}
+	for _, k := range reviewCacheKinds {
+		if k == kind {
+			return []string{k}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown cache kind %q (expected one of: %s)", kind, strings.Join(reviewCacheKinds, ", "))
+}
+
+func collectReviewCacheStats(dir string, kinds []string) ([]reviewCacheStats, error) {
+	out := make([]reviewCacheStats, 0, len(kinds))
+	for _, kind := range kinds {
+		s := reviewCacheStats{Kind: kind, Path: filepath.Join(dir, kind)}
+		err := filepath.WalkDir(s.Path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return filepath.SkipDir
+				}
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			s.Entries++
+			s.Bytes += info.Size()
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s cache: %w", kind, err)
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func clearReviewCache(dir string, kinds []string) (int, error) {
+	stats, err := collectReviewCacheStats(dir, kinds)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, s := range stats {
+		if err := os.RemoveAll(s.Path); err != nil {
+			return removed, fmt.Errorf("failed to clear %s cache: %w", s.Kind, err)
+		}
+		removed += s.Entries
+	}
+	return removed, nil
+}
+
+func formatByteSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeCacheFile(t *testing.T, path string, size int) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, make([]byte, size), 0o644))
+}
+
+func TestCollectReviewCacheStats_ReportsEntriesAndSize(t *testing.T) {
+	dir := t.TempDir()
+	writeCacheFile(t, filepath.Join(dir, "completion", "a.json"), 100)
+	writeCacheFile(t, filepath.Join(dir, "completion", "ab", "b.json"), 50)
+	writeCacheFile(t, filepath.Join(dir, "content", "c.txt"), 10)
+
+	stats, err := collectReviewCacheStats(dir, reviewCacheKinds)
+	require.NoError(t, err)
+	require.Len(t, stats, 3)
+	assert.Equal(t, reviewCacheStats{Kind: "completion", Path: filepath.Join(dir, "completion"), Entries: 2, Bytes: 150}, stats[0])
+	assert.Equal(t, 1, stats[1].Entries)
+	assert.Equal(t, int64(10), stats[1].Bytes)
+	assert.Equal(t, 0, stats[2].Entries, "missing kind dir should report zero")
+}
+
+func TestClearReviewCache_SelectiveKind(t *testing.T) {
+	dir := t.TempDir()
+	writeCacheFile(t, filepath.Join(dir, "completion", "a.json"), 10)
+	writeCacheFile(t, filepath.Join(dir, "http", "b.json"), 10)
+
+	kinds, err := selectReviewCacheKinds("http")
+	require.NoError(t, err)
+	removed, err := clearReviewCache(dir, kinds)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	_, err = os.Stat(filepath.Join(dir, "http"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dir, "completion", "a.json"))
+	assert.NoError(t, err)
+
+	_, err = selectReviewCacheKinds("bogus")
+	assert.Error(t, err)
+}
+
+func TestFormatByteSize(t *testing.T) {
+	assert.Equal(t, "512B", formatByteSize(512))
+	assert.Equal(t, "1.5KB", formatByteSize(1536))
+	assert.Equal(t, "2.0MB", formatByteSize(2*1024*1024))
+}
@@ -4,11 +4,16 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/sanix-darker/prev/internal/config"
+	"github.com/sanix-darker/prev/internal/core"
 	"github.com/sanix-darker/prev/internal/provider"
+	"github.com/sanix-darker/prev/internal/reviewcache"
+	"github.com/sanix-darker/prev/internal/vcs"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
@@ -23,6 +28,8 @@ func init() {
 	configCmd.AddCommand(newConfigShowCmd())
 	configCmd.AddCommand(newConfigEffectiveCmd())
 	configCmd.AddCommand(newConfigValidateCmd())
+	configCmd.AddCommand(newConfigGetCmd())
+	configCmd.AddCommand(newConfigSetCmd())
 	rootCmd.AddCommand(configCmd)
 }
 
@@ -134,6 +141,7 @@ func buildEffectiveConfig(conf config.Config) map[string]interface{} {
 	}
 
 	pcfg := provider.ResolveProvider(v)
+	reuse := resolveReuseMatcher(config.Config{Viper: v})
 	pv := pcfg.Viper
 
 	out := map[string]interface{}{
@@ -147,30 +155,94 @@ func buildEffectiveConfig(conf config.Config) map[string]interface{} {
 			"max_interval":     strOrDefault(v.GetString("retry.max_interval"), "30s"),
 			"multiplier":       floatOrDefault(rawValue(v, "retry.multiplier"), 2.0),
 		},
+		"vcs": map[string]interface{}{
+			"max_pages": intOrDefault(v.GetInt("vcs.max_pages"), vcs.DefaultMaxPages),
+			"provider":  strings.TrimSpace(v.GetString("vcs.provider")),
+			"token":     redactSecret(v.GetString("vcs.token")),
+			"url":       strings.TrimSpace(v.GetString("vcs.url")),
+		},
 		"review": map[string]interface{}{
-			"strictness":                strOrDefault(v.GetString("review.strictness"), "normal"),
-			"nitpick":                   intOrDefault(v.GetInt("review.nitpick"), 5),
-			"passes":                    intOrDefault(v.GetInt("review.passes"), 1),
-			"max_comments":              intOrDefault(v.GetInt("review.max_comments"), 0),
-			"filter_mode":               strOrDefault(v.GetString("review.filter_mode"), "diff_context"),
-			"mr_diff_source":            strOrDefault(v.GetString("review.mr_diff_source"), "auto"),
-			"structured_output":         v.GetBool("review.structured_output"),
-			"incremental":               v.GetBool("review.incremental"),
-			"inline_only":               v.GetBool("review.inline_only"),
-			"memory":                    boolOrDefault(rawValue(v, "review.memory"), true),
-			"memory_file":               strOrDefault(v.GetString("review.memory_file"), defaultReviewMemoryFile),
-			"memory_max":                intOrDefault(v.GetInt("review.memory_max"), 12),
-			"native_impact":             boolOrDefault(rawValue(v, "review.native_impact"), true),
-			"native_impact_max_symbols": intOrDefault(v.GetInt("review.native_impact_max_symbols"), 12),
-			"fix_prompt":                strOrDefault(v.GetString("review.fix_prompt"), "off"),
-			"mention_handle":            strOrDefault(resolveMentionHandle(conf), prevMentionHandle),
-			"serena_mode":               strOrDefault(v.GetString("review.serena_mode"), "auto"),
-			"context_lines":             intOrDefault(v.GetInt("review.context_lines"), 10),
-			"max_tokens":                intOrDefault(v.GetInt("review.max_tokens"), 80000),
+			"strictness":                   strOrDefault(v.GetString("review.strictness"), "normal"),
+			"persona":                      v.GetString("review.persona"),
+			"output_language":              v.GetString("review.output_language"),
+			"repo_context_file":            v.GetString("review.repo_context_file"),
+			"repo_context_max_chars":       intOrDefault(v.GetInt("review.repo_context_max_chars"), 4000),
+			"nitpick":                      intOrDefault(v.GetInt("review.nitpick"), 5),
+			"passes":                       intOrDefault(v.GetInt("review.passes"), 1),
+			"pass_mode":                    strOrDefault(v.GetString("review.pass_mode"), "refine"),
+			"early_stop_on_clean":          v.GetBool("review.early_stop_on_clean"),
+			"samples":                      intOrDefault(v.GetInt("review.samples"), 1),
+			"severity_consensus":           strOrDefault(v.GetString("review.severity_consensus"), "max"),
+			"cache":                        v.GetBool("review.cache"),
+			"cache_ttl":                    strOrDefault(v.GetString("review.cache_ttl"), reviewcache.DefaultTTL.String()),
+			"max_comments":                 intOrDefault(v.GetInt("review.max_comments"), 0),
+			"filter_mode":                  strOrDefault(v.GetString("review.filter_mode"), "diff_context"),
+			"mr_diff_source":               strOrDefault(v.GetString("review.mr_diff_source"), "auto"),
+			"use_merge_base":               v.GetBool("review.use_merge_base"),
+			"structured_output":            v.GetBool("review.structured_output"),
+			"collapsible_summary":          v.GetBool("review.collapsible_summary"),
+			"post_order":                   strOrDefault(v.GetString("review.post_order"), "inline_first"),
+			"summary_target":               strOrDefault(v.GetString("review.summary_target"), summaryTargetNote),
+			"post_mode":                    strOrDefault(v.GetString("review.post_mode"), postModeDefault),
+			"max_diff_bytes":               v.GetInt("review.max_diff_bytes"),
+			"commits":                      v.GetBool("review.commits"),
+			"min_confidence":               v.GetFloat64("review.min_confidence"),
+			"max_inline_chars":             intOrDefault(v.GetInt("review.max_inline_chars"), defaultInlineMaxBodyChars),
+			"max_inline_key_points":        intOrDefault(v.GetInt("review.max_inline_key_points"), defaultInlineMaxKeyPoints),
+			"max_inline_key_point_chars":   intOrDefault(v.GetInt("review.max_inline_key_point_chars"), defaultInlineMaxPointChars),
+			"consolidate_recurring":        v.GetInt("review.consolidate_recurring"),
+			"fail_on":                      v.GetString("review.fail_on"),
+			"summary_template":             v.GetString("review.summary_template"),
+			"inline_template":              v.GetString("review.inline_template"),
+			"system_prompt":                v.GetString("review.system_prompt"),
+			"reply_system_prompt":          v.GetString("review.reply_system_prompt"),
+			"cost_per_1k_input":            v.GetFloat64("review.cost_per_1k_input"),
+			"cost_per_1k_output":           v.GetFloat64("review.cost_per_1k_output"),
+			"on_content_filter":            strOrDefault(v.GetString("review.on_content_filter"), "skip"),
+			"max_title_chars":              intOrDefault(v.GetInt("review.max_title_chars"), 0),
+			"max_description_chars":        intOrDefault(v.GetInt("review.max_description_chars"), 0),
+			"incremental":                  v.GetBool("review.incremental"),
+			"reconsider_approval":          v.GetBool("review.reconsider_approval"),
+			"auto_resolve":                 v.GetBool("review.auto_resolve"),
+			"suppress_stale":               v.GetBool("review.suppress_stale"),
+			"include_diff_stats_in_prompt": v.GetBool("review.include_diff_stats_in_prompt"),
+			"report_as_status":             v.GetBool("review.report_as_status"),
+			"inline_only":                  v.GetBool("review.inline_only"),
+			"inline_only_summary_fallback": v.GetBool("review.inline_only_summary_fallback"),
+			"vcs_max_retries":              intSetOrDefault(v, "review.vcs_max_retries", vcs.DefaultMaxRetries),
+			"memory":                       boolOrDefault(rawValue(v, "review.memory"), true),
+			"memory_file":                  strOrDefault(v.GetString("review.memory_file"), defaultReviewMemoryFile),
+			"memory_max":                   intOrDefault(v.GetInt("review.memory_max"), 12),
+			"memory_decay_days":            intSetOrDefault(v, "review.memory_decay_days", defaultReviewMemoryDecayDays),
+			"native_impact":                boolOrDefault(rawValue(v, "review.native_impact"), true),
+			"native_impact_max_symbols":    intOrDefault(v.GetInt("review.native_impact_max_symbols"), 12),
+			"dependency_review":            boolOrDefault(rawValue(v, "review.dependency_review"), true),
+			"fix_prompt":                   strOrDefault(v.GetString("review.fix_prompt"), "off"),
+			"marker_namespace":             strings.TrimSpace(v.GetString("review.marker_namespace")),
+			"mention_handle":               strOrDefault(resolveMentionHandle(conf), prevMentionHandle),
+			"serena_mode":                  strOrDefault(v.GetString("review.serena_mode"), "auto"),
+			"context_lines":                intOrDefault(v.GetInt("review.context_lines"), 10),
+			"max_tokens":                   intOrDefault(v.GetInt("review.max_tokens"), 80000),
+			"budget_strategy":              strOrDefault(v.GetString("review.budget_strategy"), "reduce_context"),
+			"budget_priority_globs":        stringSliceOrDefault(v.GetStringSlice("review.budget_priority_globs"), []string{}),
+			"budget_low_priority_globs":    stringSliceOrDefault(v.GetStringSlice("review.budget_low_priority_globs"), []string{}),
+			"ignore_globs":                 stringSliceSetOrDefault(v, "review.ignore_globs", core.DefaultGeneratedGlobs),
+			"fallback_providers":           stringSliceOrDefault(v.GetStringSlice("review.fallback_providers"), []string{}),
+			"language_guidelines":          v.GetStringMapString("review.language_guidelines"),
 			"conventions": map[string]interface{}{
-				"labels": stringSliceOrDefault(v.GetStringSlice("review.conventions.labels"), []string{"issue", "suggestion", "remark"}),
+				"labels": stringSliceOrDefault(v.GetStringSlice("review.conventions.labels"), defaultConventionLabels),
+			},
+			"reuse": map[string]interface{}{
+				"similarity":       reuse.Similarity,
+				"message_weight":   reuse.MessageWeight,
+				"distance_penalty": reuse.DistancePenalty,
+				"min_score":        reuse.MinScore,
 			},
-			"guidelines": strings.TrimSpace(v.GetString("review.guidelines")),
+			"guidelines":               strings.TrimSpace(v.GetString("review.guidelines")),
+			"target_branch_profiles":   rawValue(v, "review.target_branch_profiles"),
+			"deterministic_rules":      rawValue(v, "review.deterministic_rules"),
+			"secret_entropy_threshold": floatOrDefault(rawValue(v, "review.secret_entropy_threshold"), core.DefaultSecretEntropyThreshold),
+			"secret_allowlist":         strings.TrimSpace(v.GetString("review.secret_allowlist")),
 		},
 		"debug":                        v.GetBool("debug"),
 		"stream":                       boolOrDefault(rawValue(v, "stream"), true),
@@ -198,14 +270,19 @@ func providerBlock(name string, v *config.Store) map[string]interface{} {
 		v = config.NewStore()
 	}
 	out := map[string]interface{}{
-		"api_key":    redactSecret(v.GetString("api_key")),
-		"model":      strings.TrimSpace(v.GetString("model")),
-		"base_url":   strings.TrimSpace(v.GetString("base_url")),
-		"max_tokens": intOrDefault(v.GetInt("max_tokens"), 1024),
-		"timeout":    strOrDefault(v.GetString("timeout"), "30s"),
-	}
-	if name == "azure" {
+		"api_key":         redactSecret(v.GetString("api_key")),
+		"api_key_command": strings.TrimSpace(v.GetString("api_key_command")),
+		"model":           strings.TrimSpace(v.GetString("model")),
+		"base_url":        strings.TrimSpace(v.GetString("base_url")),
+		"max_tokens":      intOrDefault(v.GetInt("max_tokens"), 1024),
+		"timeout":         strOrDefault(v.GetString("timeout"), "30s"),
+	}
+	switch name {
+	case "azure":
 		out["api_version"] = strOrDefault(v.GetString("api_version"), "2024-02-01")
+	case "openai":
+		out["api"] = strOrDefault(v.GetString("api"), "chat")
+		out["reasoning_effort"] = strings.TrimSpace(v.GetString("reasoning_effort"))
 	}
 	return out
 }
@@ -235,6 +312,12 @@ func validateEffectiveConfig(conf config.Config) []string {
 		errs = append(errs, fmt.Sprintf("provider must be one of: %s (got %q)", strings.Join(knownProviders, ", "), pcfg.Name))
 		return errs
 	}
+	for _, name := range v.GetStringSlice("review.fallback_providers") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(knownProviders, name) {
+			errs = append(errs, fmt.Sprintf("review.fallback_providers entries must be one of: %s (got %q)", strings.Join(knownProviders, ", "), name))
+		}
+	}
 	if mt := pv.GetInt("max_tokens"); mt < 0 {
 		errs = append(errs, fmt.Sprintf("providers.%s.max_tokens must be >= 0", pcfg.Name))
 	}
@@ -253,6 +336,10 @@ func validateEffectiveConfig(conf config.Config) []string {
 		if apiKey == "" {
 			errs = append(errs, "providers.gemini.api_key (or GEMINI_API_KEY) is required")
 		}
+	case "mistral":
+		if apiKey == "" {
+			errs = append(errs, "providers.mistral.api_key (or MISTRAL_API_KEY) is required")
+		}
 	case "anthropic", "claude":
 		if apiKey == "" {
 			errs = append(errs, "providers.anthropic.api_key (or ANTHROPIC_API_KEY) is required")
@@ -283,27 +370,94 @@ func validateEffectiveConfig(conf config.Config) []string {
 	if p := v.GetInt("review.passes"); p < 0 || p > 6 {
 		errs = append(errs, "review.passes must be between 0 and 6")
 	}
+	if n := v.GetInt("review.samples"); n < 0 || n > maxReviewSamples {
+		errs = append(errs, fmt.Sprintf("review.samples must be between 0 and %d", maxReviewSamples))
+	}
+	if mode := strings.ToLower(strings.TrimSpace(v.GetString("review.pass_mode"))); mode != "" &&
+		mode != "refine" && mode != "ensemble" {
+		errs = append(errs, "review.pass_mode must be one of: refine, ensemble")
+	}
+	if ttl := strings.TrimSpace(v.GetString("review.cache_ttl")); ttl != "" {
+		if d, err := time.ParseDuration(ttl); err != nil || d < 0 {
+			errs = append(errs, fmt.Sprintf("review.cache_ttl %q is not a valid non-negative duration", ttl))
+		}
+	}
+	if mode := strings.ToLower(strings.TrimSpace(v.GetString("review.severity_consensus"))); mode != "" &&
+		mode != "max" && mode != "majority" {
+		errs = append(errs, "review.severity_consensus must be one of: max, majority")
+	}
+	if r := v.GetInt("review.vcs_max_retries"); r < 0 {
+		errs = append(errs, "review.vcs_max_retries must be >= 0")
+	}
+	if m := v.GetInt("vcs.max_pages"); m < 0 {
+		errs = append(errs, "vcs.max_pages must be >= 0")
+	}
 	if m := v.GetInt("review.max_comments"); m < 0 {
 		errs = append(errs, "review.max_comments must be >= 0")
 	}
+	if m := v.GetInt("review.max_title_chars"); m < 0 {
+		errs = append(errs, "review.max_title_chars must be >= 0")
+	}
+	if m := v.GetInt("review.max_description_chars"); m < 0 {
+		errs = append(errs, "review.max_description_chars must be >= 0")
+	}
 	if mode := strings.ToLower(strings.TrimSpace(v.GetString("review.filter_mode"))); mode != "" &&
 		mode != "added" && mode != "diff_context" && mode != "file" && mode != "nofilter" {
 		errs = append(errs, "review.filter_mode must be one of: added, diff_context, file, nofilter")
 	}
 	if src := strings.ToLower(strings.TrimSpace(v.GetString("review.mr_diff_source"))); src != "" &&
-		src != "auto" && src != "git" && src != "raw" && src != "api" {
-		errs = append(errs, "review.mr_diff_source must be one of: auto, git, raw, api")
+		src != "auto" && src != "git" && src != "compare" && src != "raw" && src != "api" {
+		errs = append(errs, "review.mr_diff_source must be one of: auto, git, compare, raw, api")
 	}
 	if mode := strings.ToLower(strings.TrimSpace(v.GetString("review.fix_prompt"))); mode != "" &&
 		mode != "off" && mode != "auto" && mode != "always" {
 		errs = append(errs, "review.fix_prompt must be one of: off, auto, always")
 	}
+	if mode := strings.ToLower(strings.TrimSpace(v.GetString("review.on_content_filter"))); mode != "" &&
+		mode != "fail" && mode != "skip" && mode != "retry_sanitized" {
+		errs = append(errs, "review.on_content_filter must be one of: fail, skip, retry_sanitized")
+	}
+	if _, err := loadTargetBranchProfiles(config.Config{Viper: v}); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if _, err := loadDeterministicRules(config.Config{Viper: v}); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if tmpl := v.GetString("review.summary_template"); strings.TrimSpace(tmpl) != "" {
+		if _, err := parseSummaryTemplate(tmpl); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if tmpl := v.GetString("review.inline_template"); strings.TrimSpace(tmpl) != "" {
+		if _, err := parseInlineTemplate(tmpl); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if _, err := normalizeSummaryTarget(v.GetString("review.summary_target")); err != nil {
+		errs = append(errs, "review.summary_target must be one of: note, label, description")
+	}
+	if _, err := normalizePostMode(v.GetString("review.post_mode")); err != nil {
+		errs = append(errs, "review.post_mode must be one of: default, comment-only, suggestion-first")
+	}
+	if order := strings.ToLower(strings.TrimSpace(v.GetString("review.post_order"))); order != "" &&
+		order != "inline_first" && order != "summary_first" {
+		errs = append(errs, "review.post_order must be one of: inline_first, summary_first")
+	}
+	if _, err := normalizeFailOnSeverity(v.GetString("review.fail_on")); err != nil {
+		errs = append(errs, "review.fail_on must be one of: critical, high, medium, low")
+	}
+	if ns := strings.TrimSpace(v.GetString("review.marker_namespace")); ns != "" && normalizeMarkerNamespace(ns) == "" {
+		errs = append(errs, "review.marker_namespace must match [a-z0-9][a-z0-9_-]{0,31}")
+	}
 	if mh := strings.TrimSpace(v.GetString("review.mention_handle")); mh != "" && normalizeMentionHandle(mh) == "" {
 		errs = append(errs, "review.mention_handle must match [a-z0-9][a-z0-9_-]{0,38} (leading @ optional)")
 	}
 	if mm := v.GetInt("review.memory_max"); mm < 0 {
 		errs = append(errs, "review.memory_max must be >= 0")
 	}
+	if md := v.GetInt("review.memory_decay_days"); md < 0 {
+		errs = append(errs, "review.memory_decay_days must be >= 0")
+	}
 	if ns := v.GetInt("review.native_impact_max_symbols"); ns < 0 {
 		errs = append(errs, "review.native_impact_max_symbols must be >= 0")
 	}
@@ -317,9 +471,50 @@ func validateEffectiveConfig(conf config.Config) []string {
 	if c := v.GetInt("review.context_lines"); c < 0 {
 		errs = append(errs, "review.context_lines must be >= 0")
 	}
+	if b := v.GetInt("review.max_diff_bytes"); b < 0 {
+		errs = append(errs, "review.max_diff_bytes must be >= 0")
+	}
 	if t := v.GetInt("review.max_tokens"); t < 0 {
 		errs = append(errs, "review.max_tokens must be >= 0")
 	}
+	if mode := strings.ToLower(strings.TrimSpace(v.GetString("review.budget_strategy"))); mode != "" &&
+		mode != "reduce_context" && mode != "drop_lowest_priority_files" && mode != "summarize_large_files" {
+		errs = append(errs, "review.budget_strategy must be one of: reduce_context, drop_lowest_priority_files, summarize_large_files")
+	}
+	if sim := strings.ToLower(strings.TrimSpace(v.GetString("review.reuse.similarity"))); sim != "" &&
+		sim != reuseSimilaritySemantic && sim != reuseSimilarityTrigram {
+		errs = append(errs, "review.reuse.similarity must be one of: semantic, trigram")
+	}
+	if v.IsSet("review.reuse.message_weight") && v.GetFloat64("review.reuse.message_weight") <= 0 {
+		errs = append(errs, "review.reuse.message_weight must be > 0")
+	}
+	if v.IsSet("review.reuse.distance_penalty") && v.GetFloat64("review.reuse.distance_penalty") < 0 {
+		errs = append(errs, "review.reuse.distance_penalty must be >= 0")
+	}
+	if c := v.GetFloat64("review.min_confidence"); c < 0 || c > 1 {
+		errs = append(errs, "review.min_confidence must be between 0 and 1")
+	}
+	for _, key := range []string{"review.max_inline_chars", "review.max_inline_key_points", "review.max_inline_key_point_chars"} {
+		if v.GetInt(key) < 0 {
+			errs = append(errs, key+" must be >= 0")
+		}
+	}
+	if v.GetInt("review.consolidate_recurring") < 0 {
+		errs = append(errs, "review.consolidate_recurring must be >= 0")
+	}
+	if v.IsSet("review.secret_entropy_threshold") && v.GetFloat64("review.secret_entropy_threshold") <= 0 {
+		errs = append(errs, "review.secret_entropy_threshold must be > 0")
+	}
+	if allow := strings.TrimSpace(v.GetString("review.secret_allowlist")); allow != "" {
+		if _, err := regexp.Compile(allow); err != nil {
+			errs = append(errs, fmt.Sprintf("review.secret_allowlist is not a valid regexp: %v", err))
+		}
+	}
+	for _, key := range []string{"review.cost_per_1k_input", "review.cost_per_1k_output"} {
+		if v.IsSet(key) && v.GetFloat64(key) < 0 {
+			errs = append(errs, key+" must be >= 0")
+		}
+	}
 
 	return errs
 }
@@ -331,6 +526,14 @@ func intOrDefault(v, d int) int {
 	return v
 }
 
+// intSetOrDefault is intOrDefault for keys where 0 is a meaningful value.
+func intSetOrDefault(v *config.Store, key string, d int) int {
+	if v == nil || !v.IsSet(key) {
+		return d
+	}
+	return v.GetInt(key)
+}
+
 func strOrDefault(v, d string) string {
 	if strings.TrimSpace(v) == "" {
 		return d
@@ -366,6 +569,15 @@ func boolOrDefault(v interface{}, d bool) bool {
 	}
 }
 
+// stringSliceSetOrDefault is for list keys where an explicit empty list is
+// meaningful and must not fall back to the default.
+func stringSliceSetOrDefault(v *config.Store, key string, d []string) []string {
+	if v == nil || !v.IsSet(key) {
+		return d
+	}
+	return v.GetStringSlice(key)
+}
+
 func stringSliceOrDefault(v, d []string) []string {
 	if len(v) == 0 {
 		return d
@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sanix-darker/prev/internal/config"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func newConfigGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print the effective value of a dotted config key (e.g. review.strictness)",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			conf := config.NewDefaultConfig()
+			value, err := configGetValue(conf.Viper, args[0], os.Stderr)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Print(formatConfigValue(value))
+		},
+	}
+}
+
+func newConfigSetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Write a dotted config key to the user config file (e.g. review.strictness strict)",
+		Long: "Write a dotted config key to ~/.config/prev/config.yml, keeping the rest of the file and its comments.\n" +
+			"Values of known keys are checked against their type and validation rules; lists are comma-separated.",
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			conf := config.NewDefaultConfig()
+			cfgPath, err := config.GetConfigFilePath(conf)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			value, err := configSetValue(cfgPath, conf.Viper, args[0], args[1], os.Stderr)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			shown := strings.TrimSpace(formatConfigValue(value))
+			if items, ok := value.([]string); ok {
+				shown = "[" + strings.Join(items, ", ") + "]"
+			}
+			fmt.Printf("Set %s = %s in %s\n", args[0], shown, cfgPath)
+		},
+	}
+}
+
+// configSchemaValue returns the value buildEffectiveConfig reports for key;
+// its Go type is the key's schema type. providers.<name>.<field> is checked
+// against the provider block of any provider, not just the active one.
+func configSchemaValue(v *config.Store, key string) (interface{}, bool) {
+	parts := strings.Split(strings.TrimSpace(key), ".")
+	if len(parts) == 3 && parts[0] == "providers" {
+		val, ok := providerBlock(parts[1], v.Sub("providers."+parts[1]))[parts[2]]
+		return val, ok
+	}
+	var cur interface{} = buildEffectiveConfig(config.Config{Viper: v})
+	for _, p := range parts {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[p]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// configGetValue resolves key to its effective value. Keys outside the
+// effective-config schema fall back to the raw stored value with a warning.
+func configGetValue(v *config.Store, key string, warn io.Writer) (interface{}, error) {
+	if v == nil {
+		v = config.NewStore()
+	}
+	if val, ok := configSchemaValue(v, key); ok {
+		return val, nil
+	}
+	fmt.Fprintf(warn, "Warning: %s is not a known config key.\n", key)
+	if val, ok := v.Get(key); ok {
+		return val, nil
+	}
+	return nil, fmt.Errorf("%s is not set", key)
+}
+
+// coerceConfigValue parses raw into the type of the schema value.
+func coerceConfigValue(key string, schema interface{}, raw string) (interface{}, error) {
+	raw = strings.TrimSpace(raw)
+	switch schema.(type) {
+	case string:
+		return raw, nil
+	case int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an integer (got %q)", key, raw)
+		}
+		return n, nil
+	case float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number (got %q)", key, raw)
+		}
+		return f, nil
+	case bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false (got %q)", key, raw)
+		}
+		return b, nil
+	case []string:
+		items := []string{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("%s is a structured value; edit the config file instead", key)
+	}
+}
+
+// configSetValue validates raw for key and writes it to the YAML file at
+// path, creating the file and any missing parent mappings. Unknown keys are
+// written with a warning, their type inferred from the YAML scalar.
+func configSetValue(path string, v *config.Store, key, raw string, warn io.Writer) (interface{}, error) {
+	key = strings.TrimSpace(key)
+	if key == "" || strings.Contains(key, "..") || strings.HasPrefix(key, ".") || strings.HasSuffix(key, ".") {
+		return nil, fmt.Errorf("invalid config key %q", key)
+	}
+	if v == nil {
+		v = config.NewStore()
+	}
+
+	var value interface{}
+	if schema, ok := configSchemaValue(v, key); ok {
+		coerced, err := coerceConfigValue(key, schema, raw)
+		if err != nil {
+			return nil, err
+		}
+		value = coerced
+		v.Set(key, value)
+		for _, e := range validateEffectiveConfig(config.Config{Viper: v}) {
+			if strings.HasPrefix(e, key+" ") {
+				return nil, errors.New(e)
+			}
+		}
+	} else {
+		fmt.Fprintf(warn, "Warning: %s is not a known config key; writing it anyway.\n", key)
+		if err := yaml.Unmarshal([]byte(raw), &value); err != nil || value == nil {
+			value = raw
+		}
+	}
+
+	if err := writeConfigFileValue(path, strings.Split(key, "."), value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// writeConfigFileValue sets keys to value in the YAML file at path through
+// the node tree, so comments and the order of other keys survive.
+func writeConfigFileValue(path string, keys []string, value interface{}) error {
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return err
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	node := doc.Content[0]
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s is not a YAML mapping", path)
+	}
+
+	var valueNode yaml.Node
+	if err := valueNode.Encode(value); err != nil {
+		return err
+	}
+	for i, k := range keys {
+		var child *yaml.Node
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == k {
+				child = node.Content[j+1]
+				break
+			}
+		}
+		last := i == len(keys)-1
+		switch {
+		case child == nil && last:
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: k}, &valueNode)
+		case child == nil:
+			child = &yaml.Node{Kind: yaml.MappingNode}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: k}, child)
+		case last:
+			valueNode.HeadComment, valueNode.LineComment = child.HeadComment, child.LineComment
+			*child = valueNode
+		case child.Kind != yaml.MappingNode:
+			return fmt.Errorf("%s is not a mapping in %s", strings.Join(keys[:i+1], "."), path)
+		}
+		node = child
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// The file can hold api_key, so it is created owner-only like prev init's.
+	return os.WriteFile(path, buf.Bytes(), 0o600)
+}
+
+// formatConfigValue renders scalars bare and lists or maps as YAML.
+func formatConfigValue(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}, map[string]string, []string, []interface{}:
+		out, err := yaml.Marshal(value)
+		if err != nil {
+			return fmt.Sprintf("%v\n", value)
+		}
+		return string(out)
+	default:
+		return fmt.Sprintf("%v\n", value)
+	}
+}
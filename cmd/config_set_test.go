@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadConfigFile(t *testing.T, path string) *config.Store {
+	t.Helper()
+	v := config.NewStore()
+	require.NoError(t, v.LoadYAMLFile(path))
+	return v
+}
+
+func TestConfigSetValue_WritesTypedValuesAndKeepsComments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte("# my settings\nprovider: anthropic\nreview:\n  nitpick: 3 # keep it quiet\n"), 0o644))
+	var warn bytes.Buffer
+
+	_, err := configSetValue(path, loadConfigFile(t, path), "review.strictness", "strict", &warn)
+	require.NoError(t, err)
+	_, err = configSetValue(path, loadConfigFile(t, path), "review.nitpick", "7", &warn)
+	require.NoError(t, err)
+	_, err = configSetValue(path, loadConfigFile(t, path), "review.ignore_globs", "gen/**, *.pb.go", &warn)
+	require.NoError(t, err)
+	_, err = configSetValue(path, loadConfigFile(t, path), "providers.anthropic.model", "claude-x", &warn)
+	require.NoError(t, err)
+	assert.Empty(t, warn.String())
+
+	v := loadConfigFile(t, path)
+	assert.Equal(t, "strict", v.GetString("review.strictness"))
+	assert.Equal(t, 7, v.GetInt("review.nitpick"))
+	assert.Equal(t, []string{"gen/**", "*.pb.go"}, v.GetStringSlice("review.ignore_globs"))
+	assert.Equal(t, "claude-x", v.GetString("providers.anthropic.model"))
+	assert.Equal(t, "anthropic", v.GetString("provider"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# my settings")
+	assert.Contains(t, string(data), "nitpick: 7 # keep it quiet")
+}
+
+func TestConfigSetValue_CreatesMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prev", "config.yml")
+
+	value, err := configSetValue(path, config.NewStore(), "review.min_confidence", "0.6", &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.Equal(t, 0.6, value)
+	assert.Equal(t, 0.6, loadConfigFile(t, path).GetFloat64("review.min_confidence"))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm(), "the file can hold api_key")
+}
+
+func TestConfigSetValue_KnownProviderKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte("provider: openai\n"), 0o600))
+	for _, kv := range [][2]string{
+		{"providers.openai.api", "responses"},
+		{"providers.openai.reasoning_effort", "high"},
+		{"providers.anthropic.api_key_command", "pass show anthropic"},
+	} {
+		var warn bytes.Buffer
+		_, err := configSetValue(path, loadConfigFile(t, path), kv[0], kv[1], &warn)
+		require.NoError(t, err)
+		assert.Empty(t, warn.String(), kv[0])
+	}
+	v := loadConfigFile(t, path)
+	assert.Equal(t, "responses", v.GetString("providers.openai.api"))
+	assert.Equal(t, "pass show anthropic", v.GetString("providers.anthropic.api_key_command"))
+}
+
+func TestConfigSetValue_RejectsInvalidValues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	for key, raw := range map[string]string{
+		"review.nitpick":                "lots",
+		"review.strictness":             "brutal",
+		"review.use_merge_base":         "maybe",
+		"review.max_diff_bytes":         "-1",
+		"review.target_branch_profiles": "x",
+	} {
+		_, err := configSetValue(path, config.NewStore(), key, raw, &bytes.Buffer{})
+		assert.Error(t, err, key)
+	}
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "nothing is written for an invalid value")
+}
+
+func TestConfigSetValue_UnknownKeyWarns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	var warn bytes.Buffer
+
+	value, err := configSetValue(path, config.NewStore(), "review.custom_flag", "true", &warn)
+	require.NoError(t, err)
+	assert.Equal(t, true, value)
+	assert.Contains(t, warn.String(), "review.custom_flag is not a known config key")
+	assert.True(t, loadConfigFile(t, path).GetBool("review.custom_flag"))
+}
+
+func TestConfigGetValue(t *testing.T) {
+	v := config.NewStore()
+	v.Set("review.strictness", "lenient")
+	v.Set("custom.key", "x")
+	var warn bytes.Buffer
+
+	got, err := configGetValue(v, "review.strictness", &warn)
+	require.NoError(t, err)
+	assert.Equal(t, "lenient", got)
+
+	got, err = configGetValue(v, "review.context_lines", &warn)
+	require.NoError(t, err)
+	assert.Equal(t, 10, got, "unset known keys report their default")
+	assert.Empty(t, warn.String())
+
+	got, err = configGetValue(v, "custom.key", &warn)
+	require.NoError(t, err)
+	assert.Equal(t, "x", got)
+	assert.Contains(t, warn.String(), "custom.key is not a known config key")
+
+	_, err = configGetValue(v, "custom.missing", &warn)
+	assert.Error(t, err)
+}
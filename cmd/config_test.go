@@ -110,3 +110,15 @@ func TestValidateEffectiveConfig_FlagsInvalidProviderTimeout(t *testing.T) {
 	assert.Len(t, err, 1)
 	assert.Contains(t, err[0], "providers.openai.timeout must be a valid duration")
 }
+
+func TestValidateEffectiveConfig_FlagsUnknownFallbackProvider(t *testing.T) {
+	v := config.NewStore()
+	v.Set("provider", "openai")
+	v.Set("providers.openai.api_key", "sk-test")
+	v.Set("review.fallback_providers", []string{"anthropic", "nope"})
+
+	err := validateEffectiveConfig(config.Config{Viper: v})
+	assert.Len(t, err, 1)
+	assert.Contains(t, err[0], `review.fallback_providers entries must be one of`)
+	assert.Contains(t, err[0], `"nope"`)
+}
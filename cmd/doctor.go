@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/sanix-darker/prev/internal/config"
+	"github.com/sanix-darker/prev/internal/provider"
+	"github.com/sanix-darker/prev/internal/serena"
+	"github.com/sanix-darker/prev/internal/vcs"
+	"github.com/spf13/cobra"
+)
+
+const doctorCheckTimeout = 15 * time.Second
+
+// doctorResult is the outcome of one `prev doctor` check. Critical failures
+// make the command exit nonzero; non-critical ones are reported as warnings.
+type doctorResult struct {
+	Name     string
+	Critical bool
+	Detail   string
+	Err      error
+	Hint     string
+}
+
+func init() {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check AI provider, VCS and Serena configuration",
+		Example: `prev doctor
+prev doctor --vcs github
+prev doctor -P anthropic --skip-vcs`,
+		Run: func(cmd *cobra.Command, args []string) {
+			conf := config.NewDefaultConfig()
+			applyFlags(cmd, &conf)
+
+			var results []doctorResult
+			results = append(results, checkAIProviderConfig(cmd.Context(), conf))
+			if skip, _ := cmd.Flags().GetBool("skip-vcs"); !skip {
+				results = append(results, checkVCSConfig(cmd))
+			}
+			if skip, _ := cmd.Flags().GetBool("skip-serena"); !skip {
+				results = append(results, checkSerena())
+			}
+
+			if printDoctorResults(os.Stdout, results) {
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().String("gitlab-token", "", "GitLab personal access token (or use GITLAB_TOKEN env)")
+	cmd.Flags().String("gitlab-token-file", "", "Read the VCS token from this file (or use GITLAB_TOKEN_FILE/GITHUB_TOKEN_FILE env)")
+	cmd.Flags().String("token-command", "", "Shell command printing the VCS token, like a git credential helper (e.g. \"pass show gitlab\")")
+	cmd.Flags().String("gitlab-url", "", "GitLab instance URL (or use GITLAB_URL env, default: https://gitlab.com)")
+	cmd.Flags().String("vcs", "", "VCS provider (gitlab, github, azuredevops; auto-detected from env)")
+	cmd.Flags().Bool("skip-vcs", false, "Skip the VCS connectivity check")
+	cmd.Flags().Bool("skip-serena", false, "Skip the Serena availability check")
+	rootCmd.AddCommand(cmd)
+}
+
+// checkAIProviderConfig resolves the configured AI provider and validates it.
+func checkAIProviderConfig(ctx context.Context, conf config.Config) doctorResult {
+	p, err := resolveProvider(conf)
+	if err != nil {
+		return doctorResult{
+			Name:     "AI provider",
+			Critical: true,
+			Err:      err,
+			Hint:     "set `provider:` in ~/.config/prev/config.yml, export PREV_PROVIDER, or pass --provider/-P; run `prev ai list` to see available providers",
+		}
+	}
+	return checkAIProvider(ctx, p)
+}
+
+// checkAIProvider calls Validate on p.
+func checkAIProvider(ctx context.Context, p provider.AIProvider) doctorResult {
+	info := p.Info()
+	res := doctorResult{Name: "AI provider", Critical: true, Detail: fmt.Sprintf("%s (model %s)", info.Name, info.DefaultModel)}
+	ctx, cancel := context.WithTimeout(ctx, doctorCheckTimeout)
+	defer cancel()
+	if err := p.Validate(ctx); err != nil {
+		res.Err = err
+		res.Hint = providerErrorHint(info.Name, err)
+	}
+	return res
+}
+
+// providerErrorHint turns a Validate error into a next step based on its
+// ProviderError code.
+func providerErrorHint(name string, err error) string {
+	var pe *provider.ProviderError
+	if !errors.As(err, &pe) {
+		return fmt.Sprintf("check providers.%s in the config file", name)
+	}
+	switch pe.Code {
+	case provider.ErrCodeAuthentication:
+		return fmt.Sprintf("set a valid API key for %s (env var or providers.%s.api_key)", name, name)
+	case provider.ErrCodeTimeout, provider.ErrCodeProviderUnavailable:
+		return fmt.Sprintf("the %s endpoint did not respond; check providers.%s.base_url, network/proxy access, and the provider status page", name, name)
+	case provider.ErrCodeRateLimit:
+		return "the key works but is rate limited or out of quota; check billing/usage limits"
+	case provider.ErrCodeInvalidRequest:
+		return fmt.Sprintf("check providers.%s.base_url and providers.%s.model", name, name)
+	default:
+		return fmt.Sprintf("check providers.%s in the config file", name)
+	}
+}
+
+// checkVCSConfig resolves the VCS provider the same way `prev mr` does and
+// checks its credentials.
+func checkVCSConfig(cmd *cobra.Command) doctorResult {
+	v, err := resolveVCSProvider(cmd)
+	if err != nil {
+		return doctorResult{
+			Name:     "VCS",
+			Critical: true,
+			Err:      err,
+			Hint:     "pass --vcs or set GITLAB_TOKEN, GITHUB_TOKEN or AZURE_DEVOPS_TOKEN",
+		}
+	}
+	return checkVCSProvider(cmd.Context(), v)
+}
+
+// checkVCSProvider validates v and, when supported, makes an authenticated
+// call to confirm the token is accepted.
+func checkVCSProvider(ctx context.Context, v vcs.VCSProvider) doctorResult {
+	info := v.Info()
+	res := doctorResult{Name: "VCS", Critical: true, Detail: fmt.Sprintf("%s (%s)", info.Name, info.BaseURL)}
+	if err := v.Validate(); err != nil {
+		res.Err = err
+		res.Hint = "provide a token with --gitlab-token or the provider's *_TOKEN env var"
+		return res
+	}
+	checker, ok := v.(vcs.AuthChecker)
+	if !ok {
+		res.Detail += "; credentials present, authentication not verified"
+		return res
+	}
+	ctx, cancel := context.WithTimeout(ctx, doctorCheckTimeout)
+	defer cancel()
+	user, err := checker.CurrentUser(ctx)
+	if err != nil {
+		res.Err = err
+		res.Hint = "check that the token is valid, not expired, has API read scope, and that the base URL is correct"
+		return res
+	}
+	if user != "" {
+		res.Detail += "; authenticated as " + user
+	}
+	return res
+}
+
+// checkSerena reports whether Serena can be started. Serena is optional, so
+// a failure is never critical.
+func checkSerena() doctorResult {
+	res := doctorResult{Name: "Serena"}
+	client, err := serena.NewClient("on")
+	if err != nil {
+		res.Err = err
+		res.Hint = "optional: without Serena, reviews use line-based diff context"
+		return res
+	}
+	if client != nil {
+		client.Close()
+	}
+	res.Detail = "available"
+	return res
+}
+
+// printDoctorResults writes one line per check and returns true when any
+// critical check failed.
+func printDoctorResults(w io.Writer, results []doctorResult) bool {
+	failed := false
+	for _, r := range results {
+		switch {
+		case r.Err == nil:
+			fmt.Fprintf(w, "[OK]   %s: %s\n", r.Name, r.Detail)
+			continue
+		case r.Critical:
+			failed = true
+			fmt.Fprintf(w, "[FAIL] %s: %v\n", r.Name, r.Err)
+		default:
+			fmt.Fprintf(w, "[WARN] %s: %v\n", r.Name, r.Err)
+		}
+		if r.Hint != "" {
+			fmt.Fprintf(w, "       -> %s\n", r.Hint)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/config"
+	"github.com/sanix-darker/prev/internal/provider"
+	"github.com/sanix-darker/prev/internal/vcs"
+	"github.com/stretchr/testify/assert"
+)
+
+type doctorVCSProvider struct {
+	vcs.VCSProvider
+	validateErr error
+	user        string
+	userErr     error
+}
+
+func (d *doctorVCSProvider) Info() vcs.ProviderInfo {
+	return vcs.ProviderInfo{Name: "gitlab", BaseURL: "https://gitlab.example.com"}
+}
+
+func (d *doctorVCSProvider) Validate() error { return d.validateErr }
+
+func (d *doctorVCSProvider) CurrentUser(context.Context) (string, error) {
+	return d.user, d.userErr
+}
+
+func TestCheckVCSProvider(t *testing.T) {
+	ok := checkVCSProvider(context.Background(), &doctorVCSProvider{user: "alice"})
+	assert.NoError(t, ok.Err)
+	assert.Contains(t, ok.Detail, "authenticated as alice")
+
+	missing := checkVCSProvider(context.Background(), &doctorVCSProvider{validateErr: errors.New("gitlab: token is required")})
+	assert.EqualError(t, missing.Err, "gitlab: token is required")
+	assert.True(t, missing.Critical)
+
+	rejected := checkVCSProvider(context.Background(), &doctorVCSProvider{userErr: errors.New("gitlab: HTTP 401")})
+	assert.Error(t, rejected.Err)
+	assert.Contains(t, rejected.Hint, "token is valid")
+}
+
+func TestCheckAIProvider_Valid(t *testing.T) {
+	res := checkAIProvider(context.Background(), &scriptedAIProvider{})
+	assert.NoError(t, res.Err)
+	assert.Contains(t, res.Detail, "scripted")
+}
+
+func TestPrintDoctorResults_OnlyCriticalFailuresFail(t *testing.T) {
+	var buf bytes.Buffer
+	failed := printDoctorResults(&buf, []doctorResult{
+		{Name: "AI provider", Critical: true, Detail: "openai"},
+		{Name: "Serena", Err: errors.New("unavailable"), Hint: "optional"},
+	})
+	assert.False(t, failed)
+	assert.Contains(t, buf.String(), "[OK]   AI provider: openai")
+	assert.Contains(t, buf.String(), "[WARN] Serena: unavailable")
+	assert.Contains(t, buf.String(), "-> optional")
+
+	buf.Reset()
+	failed = printDoctorResults(&buf, []doctorResult{
+		{Name: "VCS", Critical: true, Err: errors.New("gitlab: HTTP 401")},
+	})
+	assert.True(t, failed)
+	assert.Contains(t, buf.String(), "[FAIL] VCS: gitlab: HTTP 401")
+}
+
+func TestProviderErrorHint_UsesErrorCode(t *testing.T) {
+	auth := &provider.ProviderError{Code: provider.ErrCodeAuthentication, Provider: "anthropic"}
+	assert.Contains(t, providerErrorHint("anthropic", auth), "providers.anthropic.api_key")
+
+	down := &provider.ProviderError{Code: provider.ErrCodeTimeout, Provider: "ollama"}
+	assert.Contains(t, providerErrorHint("ollama", down), "providers.ollama.base_url")
+}
+
+func TestCheckAIProviderConfig_UnknownProviderHintNamesProviderSettings(t *testing.T) {
+	v := config.NewStore()
+	v.Set("provider", "no-such-provider")
+	res := checkAIProviderConfig(context.Background(), config.Config{Viper: v})
+	assert.Error(t, res.Err)
+	assert.Contains(t, res.Hint, "`provider:`")
+	assert.Contains(t, res.Hint, "PREV_PROVIDER")
+	assert.Contains(t, res.Hint, "--provider/-P")
+	assert.NotContains(t, res.Hint, "ai.provider")
+}
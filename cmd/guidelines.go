@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
+	"github.com/sanix-darker/prev/internal/config"
 	"github.com/sanix-darker/prev/internal/core"
+	"github.com/sanix-darker/prev/internal/diffparse"
 	"github.com/sanix-darker/prev/internal/guidelines"
 )
 
@@ -74,6 +77,60 @@ func repoGuidelineSection(root string) string {
 	return guidelines.BuildPromptSection(root)
 }
 
+// resolveLanguageGuidelines reads review.language_guidelines, a map of
+// language name (as returned by diffparse.DetectLanguage) to guideline text.
+func resolveLanguageGuidelines(conf config.Config) map[string]string {
+	if conf.Viper == nil {
+		return nil
+	}
+	return conf.Viper.GetStringMapString("review.language_guidelines")
+}
+
+// languageGuidelineSection returns the configured guidelines for every
+// language present in changes, dominant language (most changed lines) first.
+// Language keys are matched case-insensitively.
+func languageGuidelineSection(changes []diffparse.FileChange, byLanguage map[string]string) string {
+	if len(byLanguage) == 0 {
+		return ""
+	}
+	normalized := make(map[string]string, len(byLanguage))
+	for lang, text := range byLanguage {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if text = strings.TrimSpace(text); lang != "" && text != "" {
+			normalized[lang] = text
+		}
+	}
+
+	weights := map[string]int{}
+	for _, c := range changes {
+		lang := diffparse.DetectLanguage(changeFileName(c))
+		if _, ok := normalized[lang]; !ok {
+			continue
+		}
+		weights[lang] += c.Stats.Additions + c.Stats.Deletions
+	}
+	if len(weights) == 0 {
+		return ""
+	}
+	langs := make([]string, 0, len(weights))
+	for lang := range weights {
+		langs = append(langs, lang)
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		if weights[langs[i]] != weights[langs[j]] {
+			return weights[langs[i]] > weights[langs[j]]
+		}
+		return langs[i] < langs[j]
+	})
+
+	var b strings.Builder
+	b.WriteString("## Language Guidelines")
+	for _, lang := range langs {
+		fmt.Fprintf(&b, "\n\n### %s\n%s", lang, normalized[lang])
+	}
+	return b.String()
+}
+
 func commitMessageContextBlock(repoPath, commitHash string) string {
 	msg, err := core.GetCommitMessage(repoPath, commitHash)
 	if err != nil || strings.TrimSpace(msg) == "" {
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/diffparse"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLanguageGuidelineSection_DominantLanguageFirst(t *testing.T) {
+	changes := []diffparse.FileChange{
+		{NewName: "main.go", Stats: diffparse.DiffStats{Additions: 3}},
+		{NewName: "tools/build.py", Stats: diffparse.DiffStats{Additions: 10, Deletions: 2}},
+		{NewName: "README.md", Stats: diffparse.DiffStats{Additions: 40}},
+	}
+	got := languageGuidelineSection(changes, map[string]string{
+		"Go":     "Wrap errors with %w.",
+		"python": "Require type hints.",
+		"rust":   "Avoid unwrap.",
+	})
+	assert.Equal(t, "## Language Guidelines\n\n### python\nRequire type hints.\n\n### go\nWrap errors with %w.", got)
+}
+
+func TestLanguageGuidelineSection_NoMatch(t *testing.T) {
+	changes := []diffparse.FileChange{{NewName: "main.go", Stats: diffparse.DiffStats{Additions: 1}}}
+	assert.Empty(t, languageGuidelineSection(changes, nil))
+	assert.Empty(t, languageGuidelineSection(changes, map[string]string{"python": "x", "go": "  "}))
+}
@@ -0,0 +1,352 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sanix-darker/prev/internal/config"
+	"github.com/sanix-darker/prev/internal/provider"
+	"github.com/sanix-darker/prev/internal/vcs"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+)
+
+// initAnswers holds everything the `prev init` wizard collects, either from
+// prompts or from flags in --non-interactive mode.
+type initAnswers struct {
+	Provider      string
+	APIKey        string
+	APIKeyCommand string
+	Model         string
+	VCS           string
+	VCSToken      string
+	VCSURL        string
+	Strictness    string
+}
+
+// initConfigFile is the subset of config.yml written by `prev init`. Field
+// order matches the sample config so the file reads naturally.
+type initConfigFile struct {
+	Provider  string                       `yaml:"provider"`
+	Providers map[string]initProviderBlock `yaml:"providers"`
+	VCS       *initVCSBlock                `yaml:"vcs,omitempty"`
+	Review    initReviewBlock              `yaml:"review"`
+}
+
+type initProviderBlock struct {
+	APIKey        string `yaml:"api_key,omitempty"`
+	APIKeyCommand string `yaml:"api_key_command,omitempty"`
+	Model         string `yaml:"model,omitempty"`
+}
+
+type initVCSBlock struct {
+	Provider string `yaml:"provider,omitempty"`
+	Token    string `yaml:"token,omitempty"`
+	URL      string `yaml:"url,omitempty"`
+}
+
+type initReviewBlock struct {
+	Strictness string `yaml:"strictness"`
+}
+
+func init() {
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Interactive first-time setup: provider, credentials, VCS and strictness",
+		Example: `prev init
+prev init --non-interactive -P anthropic --api-key-command "pass show anthropic" --vcs github --vcs-token "$GITHUB_TOKEN"`,
+		Run: func(cmd *cobra.Command, args []string) {
+			answers := initAnswersFromFlags(cmd)
+			nonInteractive, _ := cmd.Flags().GetBool("non-interactive")
+			skipValidate, _ := cmd.Flags().GetBool("skip-validate")
+			force, _ := cmd.Flags().GetBool("force")
+
+			cfgPath, err := config.GetConfigFilePath(config.NewDefaultConfig())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if _, err := os.Stat(cfgPath); err == nil && !force {
+				fmt.Fprintf(os.Stderr, "Error: config file already exists at %s (use --force to overwrite)\n", cfgPath)
+				os.Exit(1)
+			}
+
+			in := bufio.NewReader(cmd.InOrStdin())
+			out := cmd.OutOrStdout()
+			if !nonInteractive {
+				answers, err = promptInitAnswers(in, out, answers, initSecretReader(cmd.InOrStdin(), in, out))
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+			}
+
+			if !skipValidate {
+				fmt.Fprintln(out, "Checking connectivity...")
+				if printDoctorResults(out, validateInitAnswers(cmd, answers)) {
+					if nonInteractive {
+						fmt.Fprintln(os.Stderr, "Error: validation failed; fix the settings above or pass --skip-validate")
+						os.Exit(1)
+					}
+					if !promptYesNo(in, out, "Validation failed. Write the config anyway?", false) {
+						os.Exit(1)
+					}
+				}
+			}
+
+			if err := runInit(cfgPath, answers, force); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(out, "Config file written to %s\n", cfgPath)
+		},
+	}
+	cmd.Flags().Bool("non-interactive", false, "Do not prompt; take every answer from flags (for scripting)")
+	cmd.Flags().String("api-key", "", "AI provider API key")
+	cmd.Flags().String("api-key-command", "", "Shell command whose output is the API key (e.g. \"pass show openai\")")
+	cmd.Flags().String("vcs", "", "VCS provider (gitlab, github, azuredevops)")
+	cmd.Flags().String("vcs-token", "", "VCS access token")
+	cmd.Flags().String("vcs-url", "", "VCS base URL (self-hosted GitLab or Azure DevOps organization)")
+	cmd.Flags().Bool("skip-validate", false, "Write the config without checking provider/VCS connectivity")
+	cmd.Flags().Bool("force", false, "Overwrite an existing config file")
+	rootCmd.AddCommand(cmd)
+}
+
+// initAnswersFromFlags seeds the wizard from flags. In interactive mode the
+// values become prompt defaults.
+func initAnswersFromFlags(cmd *cobra.Command) initAnswers {
+	get := func(name string) string {
+		v, _ := cmd.Flags().GetString(name)
+		return strings.TrimSpace(v)
+	}
+	a := initAnswers{
+		Provider:      strings.ToLower(get("provider")),
+		APIKey:        get("api-key"),
+		APIKeyCommand: get("api-key-command"),
+		Model:         get("model"),
+		VCS:           strings.ToLower(get("vcs")),
+		VCSToken:      get("vcs-token"),
+		VCSURL:        get("vcs-url"),
+		Strictness:    strings.ToLower(get("strictness")),
+	}
+	if a.Provider == "" {
+		a.Provider = "openai"
+	}
+	if a.Strictness == "" {
+		a.Strictness = "normal"
+	}
+	return a
+}
+
+// promptInitAnswers asks for each setting, offering defaults as the answer
+// to an empty line. readSecret is used for the API key and VCS token so they
+// are not echoed on a terminal.
+func promptInitAnswers(in *bufio.Reader, out io.Writer, defaults initAnswers, readSecret func(string) (string, error)) (initAnswers, error) {
+	a := defaults
+	var err error
+
+	if a.Provider, err = promptLine(in, out, fmt.Sprintf("AI provider (%s)", strings.Join(provider.Names(), ", ")), a.Provider); err != nil {
+		return a, err
+	}
+	a.Provider = strings.ToLower(a.Provider)
+	if a.Model, err = promptLine(in, out, "Model (empty for the provider default)", a.Model); err != nil {
+		return a, err
+	}
+	if a.APIKeyCommand == "" {
+		if a.APIKey, err = readSecret("API key (empty to use a credential command or env var)"); err != nil {
+			return a, err
+		}
+	}
+	if a.APIKey == "" {
+		if a.APIKeyCommand, err = promptLine(in, out, "Credential command printing the API key (optional)", a.APIKeyCommand); err != nil {
+			return a, err
+		}
+	}
+
+	if a.VCS, err = promptLine(in, out, "VCS provider (gitlab, github, azuredevops; empty to skip)", a.VCS); err != nil {
+		return a, err
+	}
+	a.VCS = strings.ToLower(a.VCS)
+	if a.VCS != "" {
+		if a.VCSToken == "" {
+			if a.VCSToken, err = readSecret("VCS token (empty to use the *_TOKEN or *_TOKEN_FILE env var)"); err != nil {
+				return a, err
+			}
+		}
+		if a.VCSURL, err = promptLine(in, out, "VCS base URL (empty for the public instance)", a.VCSURL); err != nil {
+			return a, err
+		}
+	}
+
+	if a.Strictness, err = promptLine(in, out, "Default strictness (strict, normal, lenient)", a.Strictness); err != nil {
+		return a, err
+	}
+	a.Strictness = strings.ToLower(a.Strictness)
+	return a, nil
+}
+
+// promptLine prints label with its default and returns the trimmed answer,
+// or def when the answer is empty.
+func promptLine(in *bufio.Reader, out io.Writer, label, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(out, "%s [%s]: ", label, def)
+	} else {
+		fmt.Fprintf(out, "%s: ", label)
+	}
+	line, err := in.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	if v := strings.TrimSpace(line); v != "" {
+		return v, nil
+	}
+	return def, nil
+}
+
+func promptYesNo(in *bufio.Reader, out io.Writer, label string, def bool) bool {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	answer, err := promptLine(in, out, fmt.Sprintf("%s (%s)", label, hint), "")
+	if err != nil || answer == "" {
+		return def
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes"
+}
+
+// initSecretReader reads secrets without echo when stdin is a terminal and
+// falls back to plain line reads otherwise (pipes, tests).
+func initSecretReader(stdin io.Reader, in *bufio.Reader, out io.Writer) func(string) (string, error) {
+	return func(label string) (string, error) {
+		if f, ok := stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+			fmt.Fprintf(out, "%s: ", label)
+			secret, err := term.ReadPassword(int(f.Fd()))
+			fmt.Fprintln(out)
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimSpace(string(secret)), nil
+		}
+		return promptLine(in, out, label, "")
+	}
+}
+
+// validateInitAnswers checks the answers before they are written, using the
+// same checks as `prev doctor`.
+func validateInitAnswers(cmd *cobra.Command, a initAnswers) []doctorResult {
+	ctx := cmd.Context()
+	store := initAnswersStore(a)
+	conf := config.NewDefaultConfig()
+	conf.Viper = store
+	conf.Provider = ""
+
+	results := []doctorResult{checkAIProviderConfig(ctx, conf)}
+	if a.VCS != "" {
+		token := a.VCSToken
+		if token == "" {
+			// An empty answer means "use the env var or its _FILE variant",
+			// as `prev mr` does.
+			var err error
+			if token, err = resolveVCSToken(cmd, a.VCS); err != nil {
+				return append(results, doctorResult{Name: "VCS", Critical: true, Err: err, Hint: "check " + vcsTokenEnv[a.VCS] + "_FILE"})
+			}
+		}
+		v, err := vcs.Get(a.VCS, token, a.VCSURL)
+		if err != nil {
+			results = append(results, doctorResult{Name: "VCS", Critical: true, Err: err, Hint: "use gitlab, github or azuredevops"})
+		} else {
+			results = append(results, checkVCSProvider(ctx, v))
+		}
+	}
+	return results
+}
+
+// initAnswersStore builds an in-memory config store equivalent to the file
+// runInit would write.
+func initAnswersStore(a initAnswers) *config.Store {
+	store := config.NewStore()
+	store.Set("provider", a.Provider)
+	prefix := "providers." + a.Provider + "."
+	if a.APIKey != "" {
+		store.Set(prefix+"api_key", a.APIKey)
+	}
+	if a.APIKeyCommand != "" {
+		store.Set(prefix+"api_key_command", a.APIKeyCommand)
+	}
+	if a.Model != "" {
+		store.Set(prefix+"model", a.Model)
+	}
+	store.Set("review.strictness", a.Strictness)
+	return store
+}
+
+// checkInitAnswers rejects answers that would produce an unusable config.
+func checkInitAnswers(a initAnswers) error {
+	if a.Provider == "" {
+		return fmt.Errorf("provider is required")
+	}
+	if !slices.Contains(provider.Names(), a.Provider) {
+		return fmt.Errorf("unknown provider %q (available: %s)", a.Provider, strings.Join(provider.Names(), ", "))
+	}
+	switch a.Strictness {
+	case "strict", "normal", "lenient":
+	default:
+		return fmt.Errorf("invalid strictness %q (use strict, normal or lenient)", a.Strictness)
+	}
+	if a.VCS != "" && !slices.Contains(vcs.Names(), a.VCS) {
+		return fmt.Errorf("unknown vcs %q (available: %s)", a.VCS, strings.Join(vcs.Names(), ", "))
+	}
+	return nil
+}
+
+// buildInitConfigYAML renders the answers as config.yml content.
+func buildInitConfigYAML(a initAnswers) ([]byte, error) {
+	file := initConfigFile{
+		Provider: a.Provider,
+		Providers: map[string]initProviderBlock{
+			a.Provider: {APIKey: a.APIKey, APIKeyCommand: a.APIKeyCommand, Model: a.Model},
+		},
+		Review: initReviewBlock{Strictness: a.Strictness},
+	}
+	if a.VCS != "" {
+		file.VCS = &initVCSBlock{Provider: a.VCS, Token: a.VCSToken, URL: a.VCSURL}
+	}
+	body, err := yaml.Marshal(file)
+	if err != nil {
+		return nil, err
+	}
+	header := "# prev configuration (generated by `prev init`).\n" +
+		"# Run `prev config init` elsewhere to see every available setting.\n"
+	return append([]byte(header), body...), nil
+}
+
+// runInit validates the answers and writes them to cfgPath. The file holds
+// credentials, so it is created with owner-only permissions.
+func runInit(cfgPath string, a initAnswers, force bool) error {
+	if err := checkInitAnswers(a); err != nil {
+		return err
+	}
+	if _, err := os.Stat(cfgPath); err == nil && !force {
+		return fmt.Errorf("config file already exists at %s (use --force to overwrite)", cfgPath)
+	}
+	data, err := buildInitConfigYAML(a)
+	if err != nil {
+		return fmt.Errorf("rendering config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(cfgPath), 0o755); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+	if err := os.WriteFile(cfgPath, data, 0o600); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	return nil
+}
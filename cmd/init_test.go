@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunInit_NonInteractiveWritesValidConfig(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), ".config", "prev", "config.yml")
+	answers := initAnswers{
+		Provider:      "anthropic",
+		APIKeyCommand: "pass show anthropic",
+		Model:         "claude-sonnet-4-20250514",
+		VCS:           "github",
+		VCSToken:      "ghp_test",
+		Strictness:    "strict",
+	}
+
+	require.NoError(t, runInit(cfgPath, answers, false))
+
+	info, err := os.Stat(cfgPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	store := config.NewStore()
+	require.NoError(t, store.LoadYAMLFile(cfgPath))
+	assert.Equal(t, "anthropic", store.GetString("provider"))
+	assert.Equal(t, "pass show anthropic", store.GetString("providers.anthropic.api_key_command"))
+	assert.Equal(t, "claude-sonnet-4-20250514", store.GetString("providers.anthropic.model"))
+	assert.False(t, store.IsSet("providers.anthropic.api_key"))
+	assert.Equal(t, "github", store.GetString("vcs.provider"))
+	assert.Equal(t, "ghp_test", store.GetString("vcs.token"))
+	assert.Equal(t, "strict", store.GetString("review.strictness"))
+
+	errs := validateEffectiveConfig(config.Config{Viper: store})
+	assert.Empty(t, errs)
+}
+
+func TestRunInit_RefusesOverwriteWithoutForce(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(cfgPath, []byte("provider: openai\n"), 0o644))
+	answers := initAnswers{Provider: "openai", Strictness: "normal"}
+
+	err := runInit(cfgPath, answers, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already exists")
+
+	require.NoError(t, runInit(cfgPath, answers, true))
+	data, err := os.ReadFile(cfgPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "strictness: normal")
+}
+
+func TestRunInit_RejectsInvalidAnswers(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "config.yml")
+
+	assert.Error(t, runInit(cfgPath, initAnswers{Provider: "nope", Strictness: "normal"}, false))
+	assert.Error(t, runInit(cfgPath, initAnswers{Provider: "openai", Strictness: "harsh"}, false))
+	assert.Error(t, runInit(cfgPath, initAnswers{Provider: "openai", Strictness: "normal", VCS: "svn"}, false))
+	_, err := os.Stat(cfgPath)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestPromptInitAnswers_UsesDefaultsForEmptyLines(t *testing.T) {
+	input := strings.Join([]string{
+		"",               // provider -> default
+		"gpt-4o-mini",    // model
+		"sk-typed",       // api key
+		"gitlab",         // vcs
+		"glpat-typed",    // vcs token
+		"https://gl.lan", // vcs url
+		"",               // strictness -> default
+	}, "\n") + "\n"
+	in := bufio.NewReader(strings.NewReader(input))
+	var out bytes.Buffer
+	readSecret := func(label string) (string, error) { return promptLine(in, &out, label, "") }
+
+	got, err := promptInitAnswers(in, &out, initAnswers{Provider: "openai", Strictness: "normal"}, readSecret)
+	require.NoError(t, err)
+
+	assert.Equal(t, initAnswers{
+		Provider:   "openai",
+		APIKey:     "sk-typed",
+		Model:      "gpt-4o-mini",
+		VCS:        "gitlab",
+		VCSToken:   "glpat-typed",
+		VCSURL:     "https://gl.lan",
+		Strictness: "normal",
+	}, got)
+	assert.Contains(t, out.String(), "AI provider")
+}
+
+func TestValidateInitAnswers_EmptyTokenReadsTokenFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer ghp_from_file" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"login":"octocat"}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("ghp_from_file\n"), 0o600))
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN_FILE", path)
+
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	results := validateInitAnswers(cmd, initAnswers{Provider: "openai", APIKey: "sk-test", VCS: "github", VCSURL: server.URL, Strictness: "normal"})
+	require.Len(t, results, 2)
+	assert.NoError(t, results[1].Err)
+	assert.Contains(t, results[1].Detail, "authenticated as octocat")
+}
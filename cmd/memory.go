@@ -3,8 +3,10 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -20,6 +22,7 @@ func init() {
 
 	memoryCmd.AddCommand(newMemoryShowCmd())
 	memoryCmd.AddCommand(newMemoryExportCmd())
+	memoryCmd.AddCommand(newMemoryStatsCmd())
 	memoryCmd.AddCommand(newMemoryPruneCmd())
 	memoryCmd.AddCommand(newMemoryResetCmd())
 	rootCmd.AddCommand(memoryCmd)
@@ -68,16 +71,32 @@ func newMemoryShowCmd() *cobra.Command {
 
 func newMemoryExportCmd() *cobra.Command {
 	var memoryFile string
+	var outFile string
 	var format string
 
 	cmd := &cobra.Command{
-		Use:   "export <output_path>",
-		Short: "Export review memory as markdown or json",
-		Args:  cobra.ExactArgs(1),
+		Use:   "export [output_path]",
+		Short: "Export review memory as json (stdout) or markdown/json (file)",
+		Example: `prev memory export | jq '.entries[] | select(.status == "open")'
+prev memory export --file ./artifacts/review-memory.json
+prev memory export ./artifacts/review-memory.md --format markdown`,
+		Args: cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			outPath := strings.TrimSpace(args[0])
-			if outPath == "" {
-				fmt.Fprintln(os.Stderr, "Error: output path is required")
+			outPath := strings.TrimSpace(outFile)
+			if len(args) == 1 {
+				if outPath != "" {
+					fmt.Fprintln(os.Stderr, "Error: pass the output path either as an argument or with --file, not both")
+					os.Exit(1)
+				}
+				outPath = strings.TrimSpace(args[0])
+			}
+			format, err := memoryExportFormat(format, outPath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if outPath == "" && format == "markdown" {
+				fmt.Fprintln(os.Stderr, "Error: markdown export needs an output path; use `prev memory show` to print it")
 				os.Exit(1)
 			}
 			repoPath := resolveMRRepoPath()
@@ -87,12 +106,21 @@ func newMemoryExportCmd() *cobra.Command {
 				os.Exit(1)
 			}
 
+			if outPath == "" {
+				raw, err := json.MarshalIndent(mem, "", "  ")
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+				fmt.Println(string(raw))
+				return
+			}
 			if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
 			}
 
-			switch strings.ToLower(strings.TrimSpace(format)) {
+			switch format {
 			case "json":
 				raw, err := json.MarshalIndent(mem, "", "  ")
 				if err != nil {
@@ -114,7 +142,49 @@ func newMemoryExportCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringVar(&memoryFile, "memory-file", defaultReviewMemoryFile, "Path to review memory markdown file")
-	cmd.Flags().StringVar(&format, "format", "markdown", "Export format: markdown, json")
+	cmd.Flags().StringVar(&outFile, "file", "", "Write the export to this path instead of stdout")
+	cmd.Flags().StringVar(&format, "format", "", "Export format: markdown, json (default: markdown for a .md file, json otherwise)")
+	return cmd
+}
+
+// memoryExportFormat resolves --format for memory export. Without it, a .md
+// or .markdown output path gets markdown and everything else gets json.
+func memoryExportFormat(format, outPath string) (string, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(outPath)) {
+		case ".md", ".markdown":
+			return "markdown", nil
+		}
+		return "json", nil
+	}
+	if format != "json" && format != "markdown" {
+		return "", fmt.Errorf("unsupported format %q (use markdown or json)", format)
+	}
+	return format, nil
+}
+
+func newMemoryStatsCmd() *cobra.Command {
+	var memoryFile string
+	var top int
+
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show review memory counts, top recurring rules and most-affected files",
+		Run: func(cmd *cobra.Command, args []string) {
+			repoPath := resolveMRRepoPath()
+			mem, path, err := loadReviewMemory(repoPath, memoryFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Review memory: %s\n", path)
+			printReviewMemoryStats(os.Stdout, computeReviewMemoryStats(mem, top))
+		},
+	}
+
+	cmd.Flags().StringVar(&memoryFile, "memory-file", defaultReviewMemoryFile, "Path to review memory markdown file")
+	cmd.Flags().IntVar(&top, "top", 5, "Number of rules and files to list")
 	return cmd
 }
 
@@ -122,6 +192,7 @@ func newMemoryPruneCmd() *cobra.Command {
 	var memoryFile string
 	var maxEntries int
 	var fixedOlderThanDays int
+	var decayDays int
 	var dryRun bool
 
 	cmd := &cobra.Command{
@@ -135,7 +206,7 @@ func newMemoryPruneCmd() *cobra.Command {
 				os.Exit(1)
 			}
 			before := len(mem.Entries)
-			removed := pruneReviewMemory(&mem, maxEntries, fixedOlderThanDays, time.Now().UTC())
+			removed := pruneReviewMemory(&mem, maxEntries, fixedOlderThanDays, reviewMemoryDecay{HalfLifeDays: decayDays, Now: time.Now().UTC()})
 			after := len(mem.Entries)
 			if dryRun {
 				fmt.Printf("Dry-run prune: removed=%d before=%d after=%d file=%s\n", removed, before, after, path)
@@ -152,6 +223,7 @@ func newMemoryPruneCmd() *cobra.Command {
 	cmd.Flags().StringVar(&memoryFile, "memory-file", defaultReviewMemoryFile, "Path to review memory markdown file")
 	cmd.Flags().IntVar(&maxEntries, "max-entries", 500, "Maximum number of entries to keep after pruning")
 	cmd.Flags().IntVar(&fixedOlderThanDays, "fixed-older-than-days", 30, "Remove fixed entries older than N days (0 disables age-based fixed pruning)")
+	cmd.Flags().IntVar(&decayDays, "decay-days", defaultReviewMemoryDecayDays, "Half-life in days used to rank entries; entries unseen for 4 half-lives are removed (0 disables decay)")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show prune result without writing changes")
 	return cmd
 }
@@ -192,7 +264,7 @@ func newMemoryResetCmd() *cobra.Command {
 	return cmd
 }
 
-func pruneReviewMemory(mem *reviewMemory, maxEntries, fixedOlderThanDays int, now time.Time) int {
+func pruneReviewMemory(mem *reviewMemory, maxEntries, fixedOlderThanDays int, decay reviewMemoryDecay) int {
 	if mem == nil || len(mem.Entries) == 0 {
 		return 0
 	}
@@ -210,14 +282,12 @@ func pruneReviewMemory(mem *reviewMemory, maxEntries, fixedOlderThanDays int, no
 			kept = append(kept, e)
 			continue
 		}
-		if now.Sub(last) <= (time.Duration(fixedOlderThanDays) * 24 * time.Hour) {
+		if decay.Now.Sub(last) <= (time.Duration(fixedOlderThanDays) * 24 * time.Hour) {
 			kept = append(kept, e)
 		}
 	}
 	mem.Entries = kept
-	if maxEntries > 0 {
-		trimReviewMemory(mem, maxEntries)
-	}
+	trimReviewMemory(mem, maxEntries, decay)
 	return orig - len(mem.Entries)
 }
 
@@ -234,3 +304,103 @@ func parseMemoryTime(raw string) time.Time {
 	}
 	return time.Time{}
 }
+
+// memoryStatsItem is a rule or file with how often it appears in memory.
+type memoryStatsItem struct {
+	Key     string
+	Entries int
+	Hits    int
+	Sample  string
+}
+
+// reviewMemoryStats summarizes a review memory for `prev memory stats`.
+type reviewMemoryStats struct {
+	Total   int
+	Open    int
+	Fixed   int
+	Ignored int
+	Rules   []memoryStatsItem
+	Files   []memoryStatsItem
+}
+
+// computeReviewMemoryStats counts entries by status and returns the top
+// rules (by total hits) and files (by entry count), top of each at most.
+func computeReviewMemoryStats(mem reviewMemory, top int) reviewMemoryStats {
+	normalizeReviewMemory(&mem)
+	stats := reviewMemoryStats{Total: len(mem.Entries)}
+	stats.Open, stats.Fixed, stats.Ignored = reviewMemoryCounts(mem)
+
+	rules := map[string]*memoryStatsItem{}
+	files := map[string]*memoryStatsItem{}
+	for _, e := range mem.Entries {
+		for _, tally := range []struct {
+			m   map[string]*memoryStatsItem
+			key string
+		}{{rules, e.RuleID}, {files, e.FilePath}} {
+			if tally.key == "" {
+				continue
+			}
+			item := tally.m[tally.key]
+			if item == nil {
+				item = &memoryStatsItem{Key: tally.key, Sample: strings.TrimSpace(e.Message)}
+				tally.m[tally.key] = item
+			}
+			item.Entries++
+			item.Hits += e.Hits
+		}
+	}
+	stats.Rules = rankMemoryStatsItems(rules, top, func(a, b memoryStatsItem) bool {
+		if a.Hits != b.Hits {
+			return a.Hits > b.Hits
+		}
+		return a.Entries > b.Entries
+	})
+	stats.Files = rankMemoryStatsItems(files, top, func(a, b memoryStatsItem) bool {
+		if a.Entries != b.Entries {
+			return a.Entries > b.Entries
+		}
+		return a.Hits > b.Hits
+	})
+	return stats
+}
+
+func rankMemoryStatsItems(items map[string]*memoryStatsItem, top int, less func(a, b memoryStatsItem) bool) []memoryStatsItem {
+	out := make([]memoryStatsItem, 0, len(items))
+	for _, item := range items {
+		out = append(out, *item)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if less(out[i], out[j]) != less(out[j], out[i]) {
+			return less(out[i], out[j])
+		}
+		return out[i].Key < out[j].Key
+	})
+	if top > 0 && len(out) > top {
+		out = out[:top]
+	}
+	return out
+}
+
+func printReviewMemoryStats(w io.Writer, stats reviewMemoryStats) {
+	fmt.Fprintf(w, "Entries: %d (open=%d fixed=%d ignored=%d)\n", stats.Total, stats.Open, stats.Fixed, stats.Ignored)
+	if len(stats.Rules) > 0 {
+		fmt.Fprintln(w, "\nTop recurring rules:")
+		for _, r := range stats.Rules {
+			fmt.Fprintf(w, "  %s  hits=%d entries=%d  %s\n", r.Key, r.Hits, r.Entries, shortMemoryMessage(r.Sample, 72))
+		}
+	}
+	if len(stats.Files) > 0 {
+		fmt.Fprintln(w, "\nMost-affected files:")
+		for _, f := range stats.Files {
+			fmt.Fprintf(w, "  %s  entries=%d hits=%d\n", f.Key, f.Entries, f.Hits)
+		}
+	}
+}
+
+func shortMemoryMessage(msg string, maxRunes int) string {
+	r := []rune(msg)
+	if len(r) <= maxRunes {
+		return msg
+	}
+	return string(r[:maxRunes-3]) + "..."
+}
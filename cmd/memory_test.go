@@ -1,6 +1,10 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -43,7 +47,7 @@ func TestPruneReviewMemory_RemovesOldFixedAndTrims(t *testing.T) {
 		},
 	}
 
-	removed := pruneReviewMemory(&mem, 1, 30, now)
+	removed := pruneReviewMemory(&mem, 1, 30, reviewMemoryDecay{HalfLifeDays: defaultReviewMemoryDecayDays, Now: now})
 	assert.Equal(t, 2, removed)
 	require.Len(t, mem.Entries, 1)
 	assert.Equal(t, "open", mem.Entries[0].Status)
@@ -59,3 +63,73 @@ func TestParseMemoryTime(t *testing.T) {
 
 	assert.True(t, parseMemoryTime("nope").IsZero())
 }
+
+func TestComputeReviewMemoryStats(t *testing.T) {
+	mem := reviewMemory{Entries: []reviewMemoryEntry{
+		{ID: "1", RuleID: "rule-a", Status: "open", FilePath: "a.go", Line: 1, Message: "nil deref", Hits: 4},
+		{ID: "2", RuleID: "rule-a", Status: "fixed", FilePath: "b.go", Line: 2, Message: "nil deref", Hits: 1},
+		{ID: "3", RuleID: "rule-b", Status: "open", FilePath: "a.go", Line: 9, Message: "unchecked error", Hits: 2},
+		{ID: "4", RuleID: "rule-c", Status: "ignored", FilePath: "a.go", Line: 12, Message: "naming", Hits: 1},
+	}}
+
+	stats := computeReviewMemoryStats(mem, 2)
+	assert.Equal(t, 4, stats.Total)
+	assert.Equal(t, 2, stats.Open)
+	assert.Equal(t, 1, stats.Fixed)
+	assert.Equal(t, 1, stats.Ignored)
+
+	require.Len(t, stats.Rules, 2)
+	assert.Equal(t, "rule-a", stats.Rules[0].Key)
+	assert.Equal(t, 5, stats.Rules[0].Hits)
+	assert.Equal(t, 2, stats.Rules[0].Entries)
+	assert.Equal(t, "rule-b", stats.Rules[1].Key)
+
+	require.Len(t, stats.Files, 2)
+	assert.Equal(t, "a.go", stats.Files[0].Key)
+	assert.Equal(t, 3, stats.Files[0].Entries)
+	assert.Equal(t, "b.go", stats.Files[1].Key)
+
+	var out bytes.Buffer
+	printReviewMemoryStats(&out, stats)
+	assert.Contains(t, out.String(), "Entries: 4 (open=2 fixed=1 ignored=1)")
+	assert.Contains(t, out.String(), "rule-a  hits=5 entries=2  nil deref")
+	assert.Contains(t, out.String(), "a.go  entries=3 hits=7")
+}
+
+func TestMemoryExportFormat(t *testing.T) {
+	for _, tc := range []struct{ format, path, want string }{
+		{"", "", "json"},
+		{"", "./artifacts/review-memory.json", "json"},
+		{"", "./artifacts/review-memory.md", "markdown"},
+		{"", "notes.MARKDOWN", "markdown"},
+		{"", "./artifacts/memory", "json"},
+		{"Markdown", "./artifacts/review-memory.json", "markdown"},
+	} {
+		got, err := memoryExportFormat(tc.format, tc.path)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, got, "%q %q", tc.format, tc.path)
+	}
+	_, err := memoryExportFormat("yaml", "")
+	assert.Error(t, err)
+}
+
+func TestMemoryExportCmd_FileFlagWritesJSON(t *testing.T) {
+	repo := t.TempDir()
+	t.Setenv("CI_PROJECT_DIR", repo)
+	mem := reviewMemory{Version: reviewMemoryVersion, Entries: []reviewMemoryEntry{
+		{ID: "e1", Status: "open", Severity: "HIGH", FilePath: "a.go", Line: 3, Message: "Unchecked error."},
+	}}
+	require.NoError(t, saveReviewMemory(filepath.Join(repo, defaultReviewMemoryFile), mem))
+
+	out := filepath.Join(repo, "artifacts", "review-memory.json")
+	cmd := newMemoryExportCmd()
+	cmd.SetArgs([]string{"--file", out})
+	require.NoError(t, cmd.Execute())
+
+	raw, err := os.ReadFile(out)
+	require.NoError(t, err)
+	var got reviewMemory
+	require.NoError(t, json.Unmarshal(raw, &got), "the documented example exports json")
+	require.Len(t, got.Entries, 1)
+	assert.Equal(t, "Unchecked error.", got.Entries[0].Message)
+}
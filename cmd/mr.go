@@ -5,14 +5,20 @@ import (
 	"crypto/sha1"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"text/template"
 	"time"
+	"unicode"
 
 	"github.com/sanix-darker/prev/internal/config"
 	"github.com/sanix-darker/prev/internal/core"
@@ -20,20 +26,33 @@ import (
 	"github.com/sanix-darker/prev/internal/handlers"
 	"github.com/sanix-darker/prev/internal/provider"
 	"github.com/sanix-darker/prev/internal/renders"
+	"github.com/sanix-darker/prev/internal/reviewcache"
 	"github.com/sanix-darker/prev/internal/serena"
 	"github.com/sanix-darker/prev/internal/vcs"
+	"github.com/sanix-darker/prev/internal/vcs/gitlabmcp"
 	"github.com/spf13/cobra"
 )
 
-const (
-	prevThreadMarker    = "<!-- prev:thread -->"
-	prevCarryOverMarker = "<!-- prev:carry-over -->"
-	prevReplyMarker     = "<!-- prev:reply -->"
-	prevSummaryMarker   = "<!-- prev:summary -->"
-	prevIgnoreMarker    = "<!-- prev:ignore -->"
-	prevReuseMarker     = "<!-- prev:reuse -->"
-	prevBaselinePrefix  = "<!-- prev:baseline "
-	prevMentionHandle   = "prev"
+const prevMentionHandle = "prev"
+
+// Bot markers embedded in posted notes. They are scoped by
+// review.marker_namespace via applyMarkerNamespace.
+var (
+	prevThreadMarker     = "<!-- prev:thread -->"
+	prevCarryOverMarker  = "<!-- prev:carry-over -->"
+	prevReplyMarker      = "<!-- prev:reply -->"
+	prevSummaryMarker    = "<!-- prev:summary -->"
+	prevSummaryEndMarker = "<!-- prev:summary-end -->"
+	prevIgnoreMarker     = "<!-- prev:ignore -->"
+	prevReuseMarker      = "<!-- prev:reuse -->"
+	prevResolvedMarker   = "<!-- prev:resolved -->"
+	prevUnplacedMarker   = "<!-- prev:unplaced -->"
+	prevBaselinePrefix   = "<!-- prev:baseline "
+	prevFPPrefix         = "<!-- prev:fp:"
+	prevReconsiderPrefix = "<!-- prev:reconsider "
+	prevRecurringPrefix  = "<!-- prev:recurring "
+	prevFindingPrefix    = "<!-- prev:finding "
+	prevMarkerNamespace  = ""
 )
 
 func init() {
@@ -43,46 +62,84 @@ func init() {
 	}
 
 	mrCmd.AddCommand(newMRReviewCmd())
+	mrCmd.AddCommand(newMRSummaryCmd())
+	mrCmd.AddCommand(newMRExplainCmd())
 	mrCmd.AddCommand(newMRDiffCmd())
 	mrCmd.AddCommand(newMRListCmd())
 	rootCmd.AddCommand(mrCmd)
 }
 
 func resolveVCSProvider(cmd *cobra.Command) (vcs.VCSProvider, error) {
+	conf := config.NewDefaultConfig()
+	applyFlags(cmd, &conf)
+	configureVCSPagination(conf)
+	configureVCSRetry(conf)
+
 	vcsName, _ := cmd.Flags().GetString("vcs")
 	if vcsName == "" {
 		// Auto-detect from env vars
-		if os.Getenv("GITLAB_TOKEN") != "" {
+		if vcsTokenEnvSet("gitlab") {
 			vcsName = "gitlab"
-		} else if os.Getenv("GITHUB_TOKEN") != "" {
+		} else if vcsTokenEnvSet("github") {
 			vcsName = "github"
+		} else if vcsTokenEnvSet("azuredevops") {
+			vcsName = "azuredevops"
+		} else if configured := strings.TrimSpace(conf.Viper.GetString("vcs.provider")); configured != "" {
+			vcsName = strings.ToLower(configured)
 		} else {
 			vcsName = "gitlab"
 		}
 	}
 
-	token, _ := cmd.Flags().GetString("gitlab-token")
+	token, err := resolveVCSToken(cmd, vcsName)
+	if err != nil {
+		return nil, err
+	}
 	baseURL, _ := cmd.Flags().GetString("gitlab-url")
 
 	// Fall back to env vars
-	if token == "" {
-		switch vcsName {
-		case "gitlab":
-			token = os.Getenv("GITLAB_TOKEN")
-		case "github":
-			token = os.Getenv("GITHUB_TOKEN")
-		}
-	}
 	if baseURL == "" {
 		switch vcsName {
 		case "gitlab":
 			baseURL = os.Getenv("GITLAB_URL")
+		case "azuredevops":
+			baseURL = os.Getenv("AZURE_DEVOPS_ORG_URL")
+			if baseURL == "" {
+				baseURL = os.Getenv("SYSTEM_COLLECTIONURI")
+			}
 		}
 	}
 
+	// Finally fall back to the config file (written by "prev init").
+	if token == "" {
+		token = strings.TrimSpace(conf.Viper.GetString("vcs.token"))
+	}
+	if baseURL == "" {
+		baseURL = strings.TrimSpace(conf.Viper.GetString("vcs.url"))
+	}
+
 	return vcs.Get(vcsName, token, baseURL)
 }
 
+func configureVCSPagination(conf config.Config) {
+	maxPages := 0
+	debug := conf.Debug
+	if conf.Viper != nil {
+		maxPages = conf.Viper.GetInt("vcs.max_pages")
+		debug = debug || conf.Viper.GetBool("debug")
+	}
+	vcs.ConfigurePagination(maxPages, debug)
+}
+
+// configureVCSRetry applies review.vcs_max_retries to VCS API calls.
+func configureVCSRetry(conf config.Config) {
+	maxRetries := -1
+	if conf.Viper != nil && conf.Viper.IsSet("review.vcs_max_retries") {
+		maxRetries = conf.Viper.GetInt("review.vcs_max_retries")
+	}
+	vcs.ConfigureRetry(maxRetries)
+}
+
 func newMRReviewCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "review <project_id> <mr_iid>",
@@ -92,6 +149,7 @@ func newMRReviewCmd() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			conf := config.NewDefaultConfig()
 			applyFlags(cmd, &conf)
+			applyMarkerNamespace(resolveMarkerNamespace(conf))
 
 			projectID := args[0]
 			mrIID, err := strconv.ParseInt(args[1], 10, 64)
@@ -101,138 +159,183 @@ func newMRReviewCmd() *cobra.Command {
 			}
 
 			dryRun, _ := cmd.Flags().GetBool("dry-run")
-			summaryOnly, _ := cmd.Flags().GetBool("summary-only")
-			strictness := resolveMRStringSetting(
-				cmd, "strictness", conf,
-				[]string{"review.strictness", "strictness"},
-				conf.Strictness,
-			)
-			nitpick := resolveMRIntSetting(
-				cmd, "nitpick", conf,
-				[]string{"review.nitpick"},
-				0,
-			)
-			nitpick = normalizeNitpickFromStrictness(nitpick, strictness)
-			maxComments := resolveMRIntSetting(
-				cmd, "max-comments", conf,
-				[]string{"review.max_comments"},
-				0,
-			)
-			if maxComments < 0 {
-				maxComments = 0
-			}
-			reviewPasses := resolveMRIntSetting(
-				cmd, "review-passes", conf,
-				[]string{"review.passes"},
-				0,
-			)
-			if reviewPasses <= 0 {
-				reviewPasses = 1
-			}
-			if reviewPasses > 6 {
-				reviewPasses = 6
-			}
-			incremental := false
-			if conf.Viper != nil {
-				incremental = conf.Viper.GetBool("review.incremental")
-			}
-			if f := cmd.Flags().Lookup("incremental"); f != nil && f.Changed {
-				incremental, _ = cmd.Flags().GetBool("incremental")
-			}
-			filterMode := resolveMRStringSetting(
-				cmd, "filter-mode", conf,
-				[]string{"review.filter_mode"},
-				"diff_context",
-			)
-			filterMode = normalizeInlineFilterMode(filterMode)
-			memoryEnabled := resolveMRBoolSetting(
-				cmd, "memory", conf,
-				[]string{"review.memory"},
-				true,
-			)
-			memoryFile := resolveMRStringSetting(
-				cmd, "memory-file", conf,
-				[]string{"review.memory_file"},
-				defaultReviewMemoryFile,
-			)
-			memoryMax := resolveMRIntSetting(
-				cmd, "memory-max", conf,
-				[]string{"review.memory_max"},
-				12,
-			)
-			if memoryMax <= 0 {
-				memoryMax = 12
-			}
-			nativeImpact := resolveMRBoolSetting(
-				cmd, "native-impact", conf,
-				[]string{"review.native_impact"},
-				true,
-			)
-			nativeImpactMaxSymbols := resolveMRIntSetting(
-				cmd, "native-impact-max-symbols", conf,
-				[]string{"review.native_impact_max_symbols"},
-				12,
-			)
-			fixPromptMode := resolveMRStringSetting(
-				cmd, "fix-prompt", conf,
-				[]string{"review.fix_prompt"},
-				"off",
-			)
-			fixPromptMode = normalizeFixPromptMode(fixPromptMode)
-			structuredOutput := false
-			if conf.Viper != nil {
-				structuredOutput = conf.Viper.GetBool("review.structured_output")
-			}
-			if f := cmd.Flags().Lookup("structured-output"); f != nil && f.Changed {
-				structuredOutput, _ = cmd.Flags().GetBool("structured-output")
-			}
-			inlineOnly := false
-			if conf.Viper != nil {
-				inlineOnly = conf.Viper.GetBool("review.inline_only")
-			}
-			if f := cmd.Flags().Lookup("inline-only"); f != nil && f.Changed {
-				inlineOnly, _ = cmd.Flags().GetBool("inline-only")
+			stream, _ := cmd.Flags().GetBool("stream")
+			dryRunSave, _ := cmd.Flags().GetString("dry-run-save")
+			dryRunSave = strings.TrimSpace(dryRunSave)
+			outputRaw, _ := cmd.Flags().GetString("output")
+			outputFormat, ok := normalizeMROutputFormat(outputRaw)
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Error: invalid --output %q (expected text, json or html)\n", outputRaw)
+				os.Exit(1)
 			}
-			if inlineOnly && incremental {
-				fmt.Println("Incremental mode disabled in inline-only mode (baseline markers require non-inline MR notes).")
-				incremental = false
+			outputFile, _ := cmd.Flags().GetString("output-file")
+			outputFile = strings.TrimSpace(outputFile)
+			if outputFile != "" && outputFormat == mrOutputText {
+				fmt.Fprintln(os.Stderr, "Error: --output-file requires --output json or html")
+				os.Exit(1)
 			}
-			reviewGuidelines := ""
-			if conf.Viper != nil {
-				reviewGuidelines = strings.TrimSpace(conf.Viper.GetString("review.guidelines"))
+			documentOutput := outputFormat != mrOutputText
+			printSettingsJSON, _ := cmd.Flags().GetBool("print-settings-json")
+			// When a document goes to stdout, stdout is reserved for it, so
+			// progress and the rendered review go to stderr instead.
+			jsonStdout := os.Stdout
+			if (documentOutput && outputFile == "") || printSettingsJSON {
+				os.Stdout = os.Stderr
+				defer func() { os.Stdout = jsonStdout }()
 			}
-			reviewGuidelines = mergeGuidelines(
-				reviewGuidelines,
-				repoGuidelineSection(guidelineRootForMR()),
-			)
+			summaryOnly, _ := cmd.Flags().GetBool("summary-only")
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			profiling, _ := cmd.Flags().GetBool("profile")
+			timings := newStopwatch(profiling)
 			mrDiffSource := resolveMRStringSetting(
 				cmd, "mr-diff-source", conf,
 				[]string{"review.mr_diff_source"},
 				"auto",
 			)
+			useMergeBase := resolveMRBoolSetting(
+				cmd, "use-merge-base", conf,
+				[]string{"review.use_merge_base"},
+				false,
+			)
 			repoPath := resolveMRRepoPath()
-			conventions := conf.Viper.GetStringSlice("review.conventions.labels")
-			if len(conventions) == 0 {
-				conventions = []string{"issue", "suggestion", "remark"}
-			}
 
 			vcsProvider, err := resolveVCSProvider(cmd)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
 			}
+			stopExtract := timings.start("extract")
 			review, err := handlers.ExtractMRHandlerWithOptions(
-				cmd.Context(), vcsProvider, projectID, mrIID, strictness,
+				cmd.Context(), vcsProvider, projectID, mrIID, conf.Strictness,
 				handlers.MRExtractOptions{
-					DiffSource: mrDiffSource,
-					RepoPath:   repoPath,
+					DiffSource:    mrDiffSource,
+					RepoPath:      repoPath,
+					UseMergeBase:  useMergeBase,
+					ContextSource: resolveMRContextSource(vcsProvider.Info().Name, os.Getenv),
 				},
 			)
+			stopExtract()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if review.ReportedBaseSHA != "" {
+				fmt.Printf("Merge-base correction: base %s -> %s\n", review.ReportedBaseSHA, review.MR.DiffRefs.BaseSHA)
+			}
+			if review.Context.Fallback != "" {
+				fmt.Fprintf(os.Stderr, "Warning: GitLab MCP request failed (%s); falling back to the standard GitLab API.\n", review.Context.Fallback)
+			}
+			fmt.Println(detectVCSContextStatus(vcsProvider.Info().Name, review.Context, exec.LookPath, os.Getenv))
+
+			// Target-branch profiles override review.* config before the
+			// remaining settings are resolved; explicit flags still win.
+			if profile, ok, perr := applyTargetBranchProfile(conf, review.MR.TargetBranch); perr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: ignoring review.target_branch_profiles: %v\n", perr)
+			} else if ok {
+				fmt.Printf("Target branch profile: %q matched %s (%s)\n",
+					profile.Match, review.MR.TargetBranch, describeProfileOverrides(profile.Overrides))
+			}
+			settings, settingNotes, err := resolveMRReviewSettings(cmd, conf)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
 			}
-			fmt.Println(detectVCSContextStatus(vcsProvider.Info().Name, exec.LookPath, os.Getenv))
+			// Diff source and merge-base were resolved before the profile
+			// applied, since extraction needs them.
+			settings.MRDiffSource = mrDiffSource
+			settings.UseMergeBase = useMergeBase
+			if printSettingsJSON {
+				if err := writeMRReviewSettingsJSON(jsonStdout, settings); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: failed to write settings JSON: %v\n", err)
+					os.Exit(1)
+				}
+				return
+			}
+			for _, note := range settingNotes {
+				fmt.Println(note)
+			}
+			deterministicRules, err := loadDeterministicRules(conf)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v; remaining deterministic rules skipped.\n", err)
+			}
+			secretScanner, err := loadSecretScanner(conf)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v; scanning for secrets without an allowlist.\n", err)
+			}
+			var prevIgnore *core.IgnoreRules
+			review.Changes, prevIgnore = applyPrevIgnore(repoPath, review.Changes)
+			if len(review.Changes) == 0 {
+				fmt.Printf("All changed files are excluded by %s; nothing to review.\n", core.PrevIgnoreFile)
+				return
+			}
+			size, degraded := applyDiffSizeGuard(&settings, review.Changes)
+			if degraded {
+				fmt.Fprintln(os.Stderr, diffSizeGuardWarning(size, settings))
+				summaryOnly = true
+			}
+			strictness := settings.Strictness
+			nitpick := settings.Nitpick
+			maxComments := settings.MaxComments
+			reviewPasses := settings.Passes
+			severityConsensus := settings.SeverityConsensus
+			incremental := settings.Incremental
+			sinceSHA, _ := cmd.Flags().GetString("since")
+			onlyNewCommits, _ := cmd.Flags().GetBool("only-new-commits")
+			if onlyNewCommits && strings.TrimSpace(sinceSHA) != "" {
+				fmt.Fprintln(os.Stderr, "Error: --only-new-commits and --since cannot be combined")
+				os.Exit(1)
+			}
+			filterMode := settings.FilterMode
+			memoryEnabled := settings.Memory
+			memoryFile := settings.MemoryFile
+			memoryMax := settings.MemoryMax
+			memoryDecay := reviewMemoryDecay{HalfLifeDays: settings.MemoryDecayDays, Now: time.Now().UTC()}
+			nativeImpact := settings.NativeImpact
+			nativeImpactMaxSymbols := settings.NativeImpactMaxSymbols
+			dependencyReview := settings.DependencyReview
+			fixPromptMode := settings.FixPrompt
+			inlineLimits := inlineBodyLimits{
+				MaxKeyPoints:  settings.MaxInlineKeyPoints,
+				MaxPointChars: settings.MaxInlineKeyPointChars,
+				MaxBodyChars:  settings.MaxInlineChars,
+			}
+			var inlineTmpl *template.Template
+			if strings.TrimSpace(settings.InlineTemplate) != "" {
+				inlineTmpl, _ = parseInlineTemplate(settings.InlineTemplate)
+			}
+			structuredOutput := settings.StructuredOutput
+			passMode := settings.PassMode
+			passOpts := reviewPassOptions{OnContentFilter: settings.OnContentFilter, Mode: passMode, JSONMode: structuredOutput, EarlyStopOnClean: settings.EarlyStopOnClean, SystemPrompt: settings.SystemPrompt, Usage: &tokenUsage{}, Samples: settings.Samples}
+			if settings.Samples > 1 {
+				passOpts.Choices = &sampledChoices{}
+			}
+			useCache := settings.Cache
+			cacheTTL := settings.cacheTTL
+			collapsibleSummary := settings.CollapsibleSummary
+			postOrder := settings.PostOrder
+			summaryTemplate := settings.SummaryTemplate
+			summaryTarget := settings.SummaryTarget
+			postMode := settings.PostMode
+			consolidateRecurring := settings.ConsolidateRecurring
+			failOn := settings.FailOn
+			inlineOnly := settings.InlineOnly
+			inlineOnlySummaryFallback := settings.InlineOnlySummaryFallback
+			reportAsStatus := settings.ReportAsStatus
+			reconsiderApproval := settings.ReconsiderApproval
+			autoResolve := settings.AutoResolve
+			suppressStale := settings.SuppressStale
+			maxTitleChars := settings.MaxTitleChars
+			maxDescriptionChars := settings.MaxDescriptionChars
+			conventions := settings.Conventions
+			reviewGuidelines := ""
+			if conf.Viper != nil {
+				reviewGuidelines = strings.TrimSpace(conf.Viper.GetString("review.guidelines"))
+			}
+			reviewGuidelines = mergeGuidelines(
+				reviewGuidelines,
+				repoGuidelineSection(guidelineRootForMR()),
+				languageGuidelineSection(review.Changes, resolveLanguageGuidelines(conf)),
+			)
 			mentionHandle := resolveMentionHandle(conf)
 
 			discussions, err := vcsProvider.ListMRDiscussions(cmd.Context(), projectID, mrIID)
@@ -250,7 +353,74 @@ func newMRReviewCmd() *cobra.Command {
 			}
 
 			currentSignatures := buildFileSignatures(review.Changes)
-			if incremental {
+			// Stale-thread detection must see the whole MR diff, not just the
+			// files narrowed by incremental mode.
+			allPositionsByFile := collectValidPositions(review.Changes)
+			if fileGlobs, _ := cmd.Flags().GetStringArray("files"); len(fileGlobs) > 0 {
+				scoped := filterChangesByGlobs(review.Changes, fileGlobs)
+				if len(scoped) == 0 {
+					fmt.Fprintf(os.Stderr, "Error: --files %s matched none of the %d changed files\n", strings.Join(fileGlobs, ", "), len(review.Changes))
+					os.Exit(1)
+				}
+				fmt.Printf("Scoped review to %d of %d files via --files\n", len(scoped), len(review.Changes))
+				review.Changes = scoped
+				currentSignatures = buildFileSignatures(review.Changes)
+			}
+			if since := strings.TrimSpace(sinceSHA); since != "" {
+				filtered, source, serr := scopeChangesSince(cmd.Context(), vcsProvider, repoPath, projectID, since, review.MR.DiffRefs.HeadSHA, review.Changes)
+				if serr != nil {
+					fmt.Fprintf(os.Stderr, "Error: --since: %v\n", serr)
+					os.Exit(1)
+				}
+				if len(filtered) == 0 {
+					fmt.Printf("Since %s: no MR changes overlap the commits since then (via %s); nothing to review.\n", shortSHA(since), source)
+					return
+				}
+				fmt.Printf("Since %s: reviewing %d of %d changed files (via %s):\n", shortSHA(since), len(filtered), len(review.Changes), source)
+				for _, c := range filtered {
+					fmt.Printf("  - %s\n", changeFileName(c))
+				}
+				review.Changes = filtered
+				currentSignatures = buildFileSignatures(review.Changes)
+			} else if onlyNewCommits {
+				head := review.MR.DiffRefs.HeadSHA
+				baseline, ok := latestReviewBaseline(notes)
+				switch {
+				case !ok:
+					fmt.Println("Only new commits: no review baseline yet; reviewing the full MR.")
+				case baseline.HeadSHA == head:
+					fmt.Printf("Only new commits: head %s was already reviewed; nothing to review.\n", shortSHA(head))
+					return
+				default:
+					commits, cerr := newCommitsSinceBaseline(repoPath, baseline, head)
+					if cerr != nil {
+						fmt.Fprintf(os.Stderr, "Warning: could not list commits since %s locally: %v\n", shortSHA(baseline.HeadSHA), cerr)
+					} else if len(commits) == 0 {
+						fmt.Printf("Only new commits: no unreviewed commits since %s; nothing to review.\n", shortSHA(baseline.HeadSHA))
+						return
+					} else {
+						fmt.Printf("Only new commits: %d commits since last reviewed head %s:\n", len(commits), shortSHA(baseline.HeadSHA))
+						for _, c := range commits {
+							fmt.Printf("  - %s %s\n", shortSHA(c.Hash), c.Subject)
+						}
+					}
+					filtered, source, serr := scopeChangesSince(cmd.Context(), vcsProvider, repoPath, projectID, baseline.HeadSHA, head, review.Changes)
+					if serr != nil {
+						// A force-push or rebase leaves the last reviewed head
+						// unreachable; the new commits cannot be told apart, so
+						// the whole MR is reviewed again.
+						fmt.Fprintf(os.Stderr, "Warning: --only-new-commits: %v; the branch was likely force-pushed or rebased, reviewing the full MR.\n", serr)
+						break
+					}
+					if len(filtered) == 0 {
+						fmt.Printf("Only new commits: no MR changes overlap the commits since %s (via %s); nothing to review.\n", shortSHA(baseline.HeadSHA), source)
+						return
+					}
+					fmt.Printf("Only new commits: reviewing %d of %d changed files (via %s).\n", len(filtered), len(review.Changes), source)
+					review.Changes = filtered
+					currentSignatures = buildFileSignatures(review.Changes)
+				}
+			} else if incremental {
 				if baseline, ok := latestReviewBaseline(notes); ok && len(baseline.FileSigs) > 0 {
 					filtered := filterChangesByBaseline(review.Changes, baseline.FileSigs)
 					if len(filtered) == 0 {
@@ -266,7 +436,7 @@ func newMRReviewCmd() *cobra.Command {
 				}
 			}
 			if !hasAnyModifiedLines(review.Changes) {
-				fmt.Fprintf(os.Stderr, "Error: insufficient MR diff context: no added/deleted hunk lines were extracted (source=%s). Try --mr-diff-source git or raw.\n", mrDiffSource)
+				fmt.Fprintf(os.Stderr, "Error: insufficient MR diff context: no added/deleted hunk lines were extracted (source=%s). Try --mr-diff-source git, compare or raw.\n", mrDiffSource)
 				os.Exit(1)
 			}
 			validPositionsByFile := collectValidPositions(review.Changes)
@@ -296,7 +466,7 @@ func newMRReviewCmd() *cobra.Command {
 					if updateReviewMemoryFromDiscussions(&mem, discussions, mentionHandle, mrRef, now) {
 						memoryUpdated = true
 					}
-					reviewGuidelines = appendReviewMemoryGuidelines(reviewGuidelines, mem, review.Changes, memoryMax)
+					reviewGuidelines = appendReviewMemoryGuidelines(reviewGuidelines, mem, review.Changes, memoryMax, memoryDecay)
 				}
 			}
 			reviewGuidelines = appendNativeImpactGuidelines(
@@ -306,33 +476,32 @@ func newMRReviewCmd() *cobra.Command {
 				nativeImpact,
 				nativeImpactMaxSymbols,
 			)
+			reviewGuidelines = appendDependencyReviewGuidelines(reviewGuidelines, review.Changes, dependencyReview)
+			if settings.Commits {
+				commits := fetchMRCommitsForPrompt(cmd.Context(), vcsProvider, projectID, mrIID)
+				reviewGuidelines = appendCommitGuidelines(reviewGuidelines, commits)
+			}
 
-			serenaMode := resolveMRStringSetting(
-				cmd, "serena", conf,
-				[]string{"review.serena_mode", "serena_mode"},
-				"auto",
-			)
-			contextLines := resolveMRIntSetting(
-				cmd, "context", conf,
-				[]string{"review.context_lines"},
-				10,
-			)
-			maxTokens := resolveMRIntSetting(
-				cmd, "max-tokens", conf,
-				[]string{"review.max_tokens"},
-				80000,
-			)
-			fmt.Printf("Review settings: strictness=%s nitpick=%d max_comments=%d passes=%d inline_only=%t incremental=%t filter_mode=%s structured_output=%t mr_diff_source=%s serena=%s context=%d max_tokens=%d\n",
-				strictness, nitpick, maxComments, reviewPasses, inlineOnly, incremental, filterMode, structuredOutput, mrDiffSource, serenaMode, contextLines, maxTokens)
-			formattedDiffs, err := buildMRFormattedDiffs(review, serenaMode, contextLines, maxTokens)
+			serenaMode := settings.Serena
+			contextLines := settings.ContextLines
+			maxTokens := settings.MaxTokens
+			repoContext := repoContextSection(repoPath, settings.RepoContextFile, settings.RepoContextMaxChars)
+			enrichOpts := resolveEnrichOptions(conf, settings.BudgetStrategy)
+			fmt.Printf("Review settings: strictness=%s nitpick=%d max_comments=%d passes=%d inline_only=%t incremental=%t filter_mode=%s structured_output=%t mr_diff_source=%s serena=%s context=%d max_tokens=%d budget_strategy=%s\n",
+				strictness, nitpick, maxComments, reviewPasses, inlineOnly, incremental, filterMode, structuredOutput, mrDiffSource, serenaMode, contextLines, maxTokens, enrichOpts.BudgetStrategy)
+			stopEnrich := timings.start("enrich")
+			formattedDiffs, err := buildMRFormattedDiffs(review, reviewModelName(conf), serenaMode, contextLines, diffTokenBudget(maxTokens, repoContext), enrichOpts, settings.IgnoreGlobs, settings.IncludeDiffStats)
+			stopEnrich()
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
 			}
 
+			// Only the review prompt sees the truncated text; review.MR keeps the
+			// full title/description for reply prompts.
 			review.Prompt = core.BuildMRReviewPromptWithOptions(
-				review.MR.Title,
-				review.MR.Description,
+				truncatePromptText(review.MR.Title, maxTitleChars, "title"),
+				truncatePromptText(review.MR.Description, maxDescriptionChars, "description"),
 				review.MR.SourceBranch,
 				review.MR.TargetBranch,
 				formattedDiffs,
@@ -341,20 +510,78 @@ func newMRReviewCmd() *cobra.Command {
 				conventions,
 				reviewGuidelines,
 			)
+			review.Prompt = core.ApplyReviewPersona(review.Prompt, settings.Persona)
+			review.Prompt = applyRepoContext(review.Prompt, repoContext)
 			review.Prompt = appendLineAnchorInstructions(review.Prompt)
 			if structuredOutput {
 				review.Prompt = appendStructuredOutputInstructions(review.Prompt)
 			}
+			review.Prompt = core.ApplyOutputLanguage(review.Prompt, settings.OutputLanguage)
 
 			fmt.Printf("Reviewing MR !%d: %s (%s -> %s)\n",
 				review.MR.IID, review.MR.Title,
 				review.MR.SourceBranch, review.MR.TargetBranch)
 			fmt.Printf("Files changed: %d\n\n", len(review.Changes))
 
-			if dryRun {
-				runReviewPassesDryRun(conf, review.Prompt, reviewPasses)
+			// finalizeFindings adds the deterministic findings and applies the
+			// suppression filters. Posting and the dry-run gate both use it,
+			// so --fail-on sees the same findings either way.
+			finalizeFindings := func(comments []core.FileComment) []core.FileComment {
+				comments = append(comments, detectDeterministicFindingsWithRules(review.Changes, deterministicRules)...)
+				comments = append(comments, detectSecretLeaks(review.Changes, secretScanner)...)
+				comments = dropPrevIgnoredFindings(comments, prevIgnore)
+				if accepted, aerr := loadAcceptedFindings(repoPath); aerr != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to read %s: %v\n", acceptedFindingsFile, aerr)
+				} else {
+					var acceptedCount int
+					comments, acceptedCount = dropAcceptedFindings(comments, accepted)
+					if acceptedCount > 0 {
+						fmt.Printf("Suppressed %d accepted findings via %s\n", acceptedCount, acceptedFindingsFile)
+					}
+				}
+				comments = filterOutMetaContextFindings(comments)
+				comments = filterLowSignalInlineFindings(comments, validPositionsByFile)
+				var speculativeCount int
+				comments, speculativeCount = filterSpeculativeFindings(comments, settings.MinConfidence)
+				if speculativeCount > 0 {
+					fmt.Printf("Suppressed %d speculative findings below review.min_confidence (%.2f).\n", speculativeCount, settings.MinConfidence)
+				}
+				comments = filterIgnoredFindings(comments, mem, ignoredFindings)
+				if suppressStale {
+					var staleCount int
+					comments, staleCount = suppressStaleFindings(comments, validPositionsByFile)
+					if staleCount > 0 {
+						fmt.Printf("Suppressed %d possibly-stale findings (quoted code no longer matches the diff).\n", staleCount)
+					}
+				}
+				return comments
+			}
+
+			if dryRun && !documentOutput {
+				if stream {
+					passOpts.Stream = os.Stdout
+				}
+				stopPasses := timings.start("passes")
+				content := runReviewPassesDryRun(conf, review.Prompt, reviewPasses, passOpts, dryRunSave)
+				stopPasses()
+				timings.print(os.Stdout)
+				// The gate runs regardless of --dry-run so it can be previewed.
+				if failOn != "" {
+					findings := finalizeFindings(parseReviewContent(content, structuredOutput).FileComments)
+					gateGroups := selectInlineGroups(findings, strictness, nitpick, conventions, validPositionsByFile, filterMode, maxComments).Groups
+					gateGroups, _ = applyPostMode(gateGroups, postMode)
+					if code := severityGateExitCode(os.Stdout, gateGroups, failOn); code != 0 {
+						os.Exit(code)
+					}
+				}
 				return
 			}
+			if stream {
+				fmt.Fprintln(os.Stderr, "Warning: --stream only applies to --dry-run with text output; ignoring.")
+			}
+			if dryRunSave != "" {
+				fmt.Fprintln(os.Stderr, "Warning: --dry-run-save only applies to --dry-run with text output; ignoring.")
+			}
 
 			// Get AI review via blocking call
 			p, err := resolveProvider(conf)
@@ -365,8 +592,9 @@ func newMRReviewCmd() *cobra.Command {
 			info := p.Info()
 			model := resolvedModelForLog(conf, info.DefaultModel)
 			fmt.Printf("Model: provider=%s model=%s\n", info.Name, model)
+			p = withFallbackProviders(conf, p)
 
-			if !inlineOnly {
+			if !inlineOnly && !dryRun {
 				replyCount := processReplyCommands(
 					cmd.Context(), vcsProvider,
 					p,
@@ -376,6 +604,7 @@ func newMRReviewCmd() *cobra.Command {
 					review.Changes,
 					mentionHandle,
 					pausedThreads,
+					settings.ReplySystemPrompt,
 				)
 				if replyCount > 0 {
 					fmt.Printf("Posted %d thread replies.\n", replyCount)
@@ -399,21 +628,49 @@ func newMRReviewCmd() *cobra.Command {
 					review.MR,
 					validPositionsByFile,
 					mentionHandle,
+					settings.ReplySystemPrompt,
 				)
 				if noteReplyCount > 0 {
 					fmt.Printf("Posted %d top-level replies.\n", noteReplyCount)
 				}
 			}
 
-			reviewContent, err := runReviewPasses(cmd.Context(), p, review.Prompt, reviewPasses)
+			if useCache {
+				passOpts.Cache = reviewcache.New(filepath.Join(resolveReviewCacheDir(repoPath, ""), "completion"), cacheTTL)
+				passOpts.CacheKey = reviewcache.Key(info.Name, model, review.Prompt,
+					fmt.Sprintf("passes=%d", reviewPasses), "mode="+passMode, "system="+settings.SystemPrompt,
+					fmt.Sprintf("samples=%d", settings.Samples), fmt.Sprintf("json=%t", structuredOutput))
+				passOpts.CacheSignature = reviewcache.Signature(buildFileSignatures(review.Changes))
+			}
+			stopPasses := timings.start("passes")
+			passOutputs, err := collectReviewPassOutputs(cmd.Context(), p, review.Prompt, reviewPasses, passOpts)
+			stopPasses()
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error from AI provider: %v\n", err)
 				os.Exit(1)
 			}
+			reviewContent := ""
+			if len(passOutputs) > 0 {
+				reviewContent = passOutputs[len(passOutputs)-1]
+			}
 			fmt.Print(renders.RenderMarkdown(reviewContent))
+			printTokenUsage(os.Stdout, passOpts.Usage, tokenPricing{InputPer1K: settings.CostPer1KInput, OutputPer1K: settings.CostPer1KOutput})
 
 			// Post to VCS
 			parsed := parseReviewContent(reviewContent, structuredOutput)
+			if passMode == passModeEnsemble && len(passOutputs) > 1 {
+				passFindings := make([][]core.FileComment, 0, len(passOutputs))
+				for _, out := range passOutputs {
+					passFindings = append(passFindings, parseReviewContent(out, structuredOutput).FileComments)
+				}
+				parsed.FileComments = mergeEnsembleFindings(passFindings)
+				fmt.Printf("Ensemble merge: %d unique findings from %d passes.\n", len(parsed.FileComments), len(passOutputs))
+			}
+			if samples := passOpts.Choices.outputs(); len(samples) > 0 {
+				before := len(parsed.FileComments)
+				parsed.FileComments = unionSampledFindings(parsed.FileComments, samples, structuredOutput)
+				fmt.Printf("Sampling: %d extra findings from %d alternate samples.\n", len(parsed.FileComments)-before, len(samples))
+			}
 			if len(parsed.FileComments) == 0 {
 				recovered, rerr := recoverInlineFindings(p, review.Prompt, reviewContent)
 				if rerr != nil {
@@ -426,11 +683,15 @@ func newMRReviewCmd() *cobra.Command {
 					}
 				}
 			}
-			parsed.FileComments = append(parsed.FileComments, detectDeterministicFindings(review.Changes)...)
-			parsed.FileComments = filterOutMetaContextFindings(parsed.FileComments)
-			parsed.FileComments = filterLowSignalInlineFindings(parsed.FileComments, validPositionsByFile)
-			parsed.FileComments = filterIgnoredFindings(parsed.FileComments, mem, ignoredFindings)
-			if memoryEnabled && strings.TrimSpace(memoryPath) != "" {
+			if severityConsensus != severityConsensusMax && len(passOutputs) > 1 {
+				passFindings := make([][]core.FileComment, 0, len(passOutputs))
+				for _, out := range passOutputs {
+					passFindings = append(passFindings, parseReviewContent(out, structuredOutput).FileComments)
+				}
+				parsed.FileComments = applySeverityConsensus(parsed.FileComments, passFindings, severityConsensus)
+			}
+			parsed.FileComments = finalizeFindings(parsed.FileComments)
+			if memoryEnabled && strings.TrimSpace(memoryPath) != "" && !dryRun {
 				now := time.Now().UTC()
 				mrRef := fmt.Sprintf("%s!%d", projectID, mrIID)
 				updated := memoryUpdated
@@ -438,205 +699,450 @@ func newMRReviewCmd() *cobra.Command {
 					updated = true
 				}
 				if updated {
-					trimReviewMemory(&mem, 500)
-					if err := saveReviewMemory(memoryPath, mem); err != nil {
+					merged, err := mergeReviewMemoryFile(memoryPath, mem, 500, memoryDecay)
+					if err != nil {
 						fmt.Fprintf(os.Stderr, "Warning: failed to persist review memory: %v\n", err)
 					} else {
-						openCount, fixedCount, ignoredCount := reviewMemoryCounts(mem)
+						openCount, fixedCount, ignoredCount := reviewMemoryCounts(merged)
 						fmt.Printf("Review memory updated: %s (open=%d fixed=%d ignored=%d)\n", memoryPath, openCount, fixedCount, ignoredCount)
 					}
 				}
 			}
-			if !inlineOnly && threadHasAnyCommand(discussions, mentionHandle, "summary") {
-				if hasTopLevelMarker(notes, prevSummaryMarker) {
-					fmt.Println("\nSummary already posted; skipping duplicate summary note.")
-				} else {
-					summaryBody := fmt.Sprintf("%s\n## AI Code Review\n\n%s", prevSummaryMarker, reviewContent)
-					if err := vcsProvider.PostSummaryNote(cmd.Context(), projectID, mrIID, summaryBody); err != nil {
-						fmt.Fprintf(os.Stderr, "Warning: failed to post summary note: %v\n", err)
+
+			var selection inlineSelection
+			selected := !summaryOnly && (review.MR.DiffRefs.BaseSHA != "" || documentOutput)
+			if selected {
+				selection = selectInlineGroups(parsed.FileComments, strictness, nitpick, conventions, validPositionsByFile, filterMode, maxComments)
+			}
+			inlineGroups, unplaced := selection.Groups, selection.Unplaced
+			var proseOnly int
+			inlineGroups, proseOnly = applyPostMode(inlineGroups, postMode)
+			if proseOnly > 0 {
+				fmt.Printf("Skipped %d inline findings without a suggestion (post mode %s).\n", proseOnly, postMode)
+			}
+			inlineStatuses := make([]string, len(inlineGroups))
+			for i := range inlineStatuses {
+				inlineStatuses[i] = findingStatusNotPosted
+			}
+
+			renderSummaryBody := func() string {
+				summaryBody := buildSummaryNoteBody(reviewContent, collapsibleSummary)
+				if strings.TrimSpace(summaryTemplate) != "" {
+					data := newSummaryTemplateData(reviewContent, parsed.Summary, info.Name, model, review.Changes, parsed.FileComments)
+					if rendered, terr := renderSummaryTemplate(summaryTemplate, data); terr != nil {
+						fmt.Fprintf(os.Stderr, "Warning: %v; posting the raw review instead.\n", terr)
 					} else {
-						fmt.Println("\nPosted summary comment to MR.")
+						summaryBody = buildTemplatedSummaryNoteBody(rendered, collapsibleSummary)
 					}
 				}
-			} else {
-				if inlineOnly {
-					fmt.Println("\nSummary skipped (inline-only mode).")
-				} else {
-					fmt.Println("\nSummary skipped (no explicit handle summary request).")
-				}
+				return summaryBody
 			}
 
-			// Post inline comments (if not summary-only)
-			if !summaryOnly && review.MR.DiffRefs.BaseSHA != "" {
-				if !inlineOnly {
-					carryPosted := postCarryOverReminders(cmd.Context(), vcsProvider, projectID, mrIID, discussions, carryOver, pausedThreads)
-					if carryPosted > 0 {
-						fmt.Printf("Posted %d carry-over reminders.\n", carryPosted)
+			postSummary := func() {
+				if !inlineOnly && summaryTarget != summaryTargetNote {
+					// Labels and the description are updated in place, so
+					// they do not wait for an explicit summary request.
+					if summaryTarget == summaryTargetDescription && strings.TrimSpace(reviewContent) == "" {
+						fmt.Println("\nSummary skipped (no review content).")
+						return
 					}
+					msg, err := applySummaryTarget(cmd.Context(), vcsProvider, projectID, mrIID, summaryTarget, review.MR, renderSummaryBody(), parsed.FileComments)
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "Warning: failed to write summary to the MR %s: %v\n", summaryTarget, err)
+					} else {
+						fmt.Println("\n" + msg)
+					}
+					return
 				}
-
-				existingInline := existingInlineKeys(discussions)
-				existingSeverity := existingInlineSeverityKeys(discussions)
-				reusableThreads := collectReusableThreads(discussions, mentionHandle, pausedThreads, ignoredThreads)
-				postedInlineKeys := make(map[string]struct{})
-				reusedDiscussionIDs := make(map[string]struct{})
-				rawComments, usedFilterFallback := filterInlineCandidates(
-					parsed.FileComments,
-					strictness,
-					nitpick,
-					conventions,
-					validPositionsByFile,
-					filterMode,
-				)
-				if usedFilterFallback {
-					fmt.Println("Inline filter fallback: severity/kind filtering removed all findings; using parsed findings scoped to changed files.")
-				}
-				fileComments := filterCommentsByFileFocus(rawComments)
-				if len(fileComments) == 0 && len(rawComments) > 0 {
-					fmt.Println("Inline filter fallback: typo-only doc filter removed all findings; using broader findings.")
-					fileComments = rawComments
-				}
-				fileComments = aggregateCommentsByChange(fileComments)
-				inlineGroups, unplaced := aggregateCommentsByLine(fileComments, validPositionsByFile)
-				if len(inlineGroups) == 0 && len(fileComments) > 0 {
-					fallbackGroups, fallbackUnplaced := aggregateCommentsByHunk(fileComments, validPositionsByFile)
-					if len(fallbackGroups) > 0 {
-						fmt.Println("Inline placement fallback: line-level grouping produced no placeable comments; using hunk-level grouping.")
-						inlineGroups = fallbackGroups
+				if summaryNoteRequested(degraded, inlineOnly, discussions, mentionHandle) {
+					if strings.TrimSpace(reviewContent) == "" {
+						fmt.Println("\nSummary skipped (no review content).")
+					} else if hasTopLevelMarker(notes, prevSummaryMarker) {
+						fmt.Println("\nSummary already posted; skipping duplicate summary note.")
+					} else {
+						summaryBody := renderSummaryBody()
+						if err := vcsProvider.PostSummaryNote(cmd.Context(), projectID, mrIID, summaryBody); err != nil {
+							fmt.Fprintf(os.Stderr, "Warning: failed to post summary note: %v\n", err)
+						} else {
+							fmt.Println("\nPosted summary comment to MR.")
+						}
 					}
-					if len(fallbackUnplaced) > 0 {
-						unplaced = append(unplaced, fallbackUnplaced...)
+				} else {
+					if inlineOnly {
+						fmt.Println("\nSummary skipped (inline-only mode).")
+					} else {
+						fmt.Println("\nSummary skipped (no explicit handle summary request).")
 					}
 				}
-				fmt.Printf("Inline findings pipeline: parsed=%d filtered=%d focused=%d grouped=%d\n",
-					len(parsed.FileComments), len(rawComments), len(fileComments), len(inlineGroups))
-				originalCount := len(inlineGroups)
-				inlineGroups = prioritizeAndLimitInlineGroups(inlineGroups, maxComments)
-				if maxComments > 0 && originalCount > len(inlineGroups) {
-					fmt.Printf("Limiting inline comments to top %d by severity (from %d findings).\n", len(inlineGroups), originalCount)
-				}
-				postedInline := 0
-				reusedInline := 0
-				skippedExisting := 0
-				skippedRunDup := 0
-				for _, grp := range inlineGroups {
-					anchorContent := validPositionsByFile[grp.FilePath].content[grp.NewLine]
-					alignedSuggestion := rebaseSuggestionIndentation(grp.Suggestion, anchorContent)
-					body := buildInlineCommentBody(grp.Severity, grp.Message, alignedSuggestion, vcsProvider.FormatSuggestionBlock)
-					if fp := buildAgentFixPrompt(grp, fixPromptMode); fp != "" {
-						body += "\n\n" + buildCollapsibleFixPrompt(fp)
+			}
+
+			// Post inline comments (if not summary-only)
+			postInline := func() {
+				if !summaryOnly && review.MR.DiffRefs.BaseSHA != "" {
+					if !inlineOnly {
+						carryPosted := postCarryOverReminders(cmd.Context(), vcsProvider, projectID, mrIID, discussions, carryOver, pausedThreads)
+						if carryPosted > 0 {
+							fmt.Printf("Posted %d carry-over reminders.\n", carryPosted)
+						}
 					}
-					body += "\n\n" + prevThreadMarker
-					key := inlineKey(grp.FilePath, grp.NewLine, body)
-					sevKey := inlineSeverityKey(grp.FilePath, grp.NewLine, grp.Severity)
-					if _, ok := existingInline[key]; ok {
-						skippedExisting++
-						continue
+
+					existingInline := existingInlineKeys(discussions)
+					existingSeverity := existingInlineSeverityKeys(discussions)
+					existingFP := existingFingerprints(discussions)
+					reusableThreads := collectReusableThreads(discussions, validPositionsByFile, mentionHandle, pausedThreads, ignoredThreads)
+					reuseMatch := resolveReuseMatcher(conf)
+					postedInlineKeys := make(map[string]struct{})
+					postedFP := make(fingerprintLines)
+					reusedDiscussionIDs := make(map[string]struct{})
+					postedInline := 0
+					reusedInline := 0
+					updatedInline := 0
+					skippedExisting := 0
+					skippedRunDup := 0
+					var rateLimited []rateLimitedInline
+					// Providers that can batch (GitHub reviews) get every new
+					// comment in one request; the rest post one at a time.
+					batcher, _ := vcsProvider.(vcs.InlineCommentBatcher)
+					updater, _ := vcsProvider.(vcs.InlineCommentUpdater)
+					// New comments are queued while the groups are filtered, so
+					// the progress total counts only what is actually posted.
+					var pending []pendingInline
+					var progress *inlineProgress
+					postOne := func(i int, comment vcs.InlineComment) bool {
+						err := postInlineWithRetry(cmd.Context(), vcsProvider, projectID, mrIID, review.MR.DiffRefs, comment)
+						if wait, limited := vcs.RateLimited(err); limited {
+							// Requeued after this pass; its keys stay reserved so
+							// duplicates of it are still skipped.
+							rateLimited = append(rateLimited, rateLimitedInline{Index: i, Comment: comment, Wait: wait})
+							return true
+						}
+						if errors.As(err, new(*vcs.InlinePositionError)) {
+							unplaced = append(unplaced, rejectedInlineEntry(inlineGroups[i]))
+							inlineStatuses[i] = findingStatusUnplaced
+							return false
+						}
+						progress.record(comment, err)
+						if err != nil {
+							fmt.Fprintf(os.Stderr, "Warning: failed to post inline comment on %s:%d: %v\n",
+								comment.FilePath, inlineCommentLine(comment), err)
+							inlineStatuses[i] = findingStatusFailed
+							return false
+						}
+						postedInline++
+						inlineStatuses[i] = findingStatusPosted
+						return true
 					}
-					if _, ok := existingSeverity[sevKey]; ok {
-						skippedExisting++
-						continue
+					for i, grp := range inlineGroups {
+						anchorContent := validPositionsByFile[grp.FilePath].content[grp.NewLine]
+						alignedSuggestion, verbatimIndent := rebaseSuggestionForFile(grp.Suggestion, anchorContent, grp.FilePath)
+						body := buildInlineCommentBody(grp.FilePath, grp.anchorLine(), grp.Severity, grp.Message, alignedSuggestion, vcsProvider.FormatSuggestionBlock, inlineLimits, inlineTmpl)
+						if verbatimIndent {
+							body += "\n\n" + suggestionIndentNote
+						}
+						if fp := buildAgentFixPrompt(grp, fixPromptMode); fp != "" {
+							body += "\n\n" + buildCollapsibleFixPrompt(fp)
+						}
+						fp := findingFingerprint(grp.FilePath, grp.Message)
+						body += "\n\n" + prevThreadMarker + "\n" + fingerprintMarker(fp)
+						key := inlineKey(grp.FilePath, grp.anchorLine(), grp.OnDeleted, body)
+						sevKey := inlineSeverityKey(grp.FilePath, grp.anchorLine(), grp.OnDeleted, grp.Severity)
+						if existing, ok := existingInline[key]; ok {
+							if updater != nil && inlineNoteNeedsUpdate(existing, grp.Severity, grp.Message, body) {
+								err := updater.UpdateInlineComment(cmd.Context(), projectID, mrIID, existing.ID, body)
+								if err == nil {
+									updatedInline++
+									inlineStatuses[i] = findingStatusUpdated
+									postedInlineKeys[key] = struct{}{}
+									postedFP.add(fp, grp.anchorLine())
+									continue
+								}
+								fmt.Fprintf(os.Stderr, "Warning: failed to update inline comment on %s:%d: %v\n", grp.FilePath, grp.anchorLine(), err)
+							}
+							skippedExisting++
+							inlineStatuses[i] = findingStatusSkippedExisting
+							continue
+						}
+						if existingFP.near(fp, grp.anchorLine()) {
+							skippedExisting++
+							inlineStatuses[i] = findingStatusSkippedExisting
+							continue
+						}
+						if _, ok := existingSeverity[sevKey]; ok {
+							skippedExisting++
+							inlineStatuses[i] = findingStatusSkippedExisting
+							continue
+						}
+						if _, ok := postedInlineKeys[key]; ok {
+							skippedRunDup++
+							inlineStatuses[i] = findingStatusSkippedDuplicate
+							continue
+						}
+						if postedFP.near(fp, grp.anchorLine()) {
+							skippedRunDup++
+							inlineStatuses[i] = findingStatusSkippedDuplicate
+							continue
+						}
+						if r, ok := reuseMatch.match(reusableThreads, grp); ok {
+							if _, used := reusedDiscussionIDs[r.DiscussionID]; !used {
+								reply := fmt.Sprintf(
+									"%s\nRevalidated on current diff near `%s:%d`.\n\n%s",
+									prevReuseMarker, grp.FilePath, grp.anchorLine(), body,
+								)
+								if err := vcsProvider.ReplyToMRDiscussion(cmd.Context(), projectID, mrIID, r.DiscussionID, reply); err == nil {
+									postedInline++
+									reusedInline++
+									inlineStatuses[i] = findingStatusReused
+									reusedDiscussionIDs[r.DiscussionID] = struct{}{}
+									postedInlineKeys[key] = struct{}{}
+									postedFP.add(fp, grp.anchorLine())
+									existingSeverity[sevKey] = struct{}{}
+									continue
+								}
+							}
+						}
+						comment := vcs.InlineComment{
+							FilePath:  grp.FilePath,
+							OldPath:   validPositionsByFile[grp.FilePath].oldPath,
+							NewLine:   int64(grp.NewLine),
+							OldLine:   int64(grp.OldLine),
+							Body:      body,
+							OnDeleted: grp.OnDeleted,
+						}
+						pending = append(pending, pendingInline{Index: i, Comment: comment})
+						postedInlineKeys[key] = struct{}{}
+						postedFP.add(fp, grp.anchorLine())
+						existingSeverity[sevKey] = struct{}{}
 					}
-					if _, ok := postedInlineKeys[key]; ok {
-						skippedRunDup++
-						continue
+					progress = newInlineProgress(os.Stdout, len(pending), verbose)
+					if batcher == nil {
+						for _, item := range pending {
+							postOne(item.Index, item.Comment)
+						}
+					} else if batched := flushInlineBatch(cmd.Context(), batcher, projectID, mrIID, review.MR.DiffRefs, pending, postOne); batched > 0 {
+						for _, item := range pending {
+							inlineStatuses[item.Index] = findingStatusPosted
+						}
+						postedInline += batched
+						fmt.Printf("Submitted %d inline comments as one review.\n", batched)
 					}
-					if r, ok := matchReusableThread(reusableThreads, grp); ok {
-						if _, used := reusedDiscussionIDs[r.DiscussionID]; !used {
-							reply := fmt.Sprintf(
-								"%s\nRevalidated on current diff near `%s:%d`.\n\n%s",
-								prevReuseMarker, grp.FilePath, grp.NewLine, body,
-							)
-							if err := vcsProvider.ReplyToMRDiscussion(cmd.Context(), projectID, mrIID, r.DiscussionID, reply); err == nil {
-								postedInline++
-								reusedInline++
-								reusedDiscussionIDs[r.DiscussionID] = struct{}{}
-								postedInlineKeys[key] = struct{}{}
-								existingSeverity[sevKey] = struct{}{}
+					if len(rateLimited) > 0 {
+						results := requeueRateLimitedInline(cmd.Context(), rateLimited, func(c vcs.InlineComment) error {
+							return vcsProvider.PostInlineComment(cmd.Context(), projectID, mrIID, review.MR.DiffRefs, c)
+						})
+						for _, item := range rateLimited {
+							err := results[item.Index]
+							if errors.As(err, new(*vcs.InlinePositionError)) {
+								unplaced = append(unplaced, rejectedInlineEntry(inlineGroups[item.Index]))
+								inlineStatuses[item.Index] = findingStatusUnplaced
+								continue
+							}
+							progress.record(item.Comment, err)
+							if err != nil {
+								fmt.Fprintf(os.Stderr, "Warning: failed to post inline comment on %s:%d: %v\n",
+									item.Comment.FilePath, inlineCommentLine(item.Comment), err)
+								inlineStatuses[item.Index] = findingStatusFailed
 								continue
 							}
+							postedInline++
+							inlineStatuses[item.Index] = findingStatusPosted
 						}
 					}
-					err := vcsProvider.PostInlineComment(
-						cmd.Context(), projectID, mrIID,
-						review.MR.DiffRefs,
-						vcs.InlineComment{
-							FilePath: grp.FilePath,
-							OldPath:  validPositionsByFile[grp.FilePath].oldPath,
-							NewLine:  int64(grp.NewLine),
-							OldLine:  int64(grp.OldLine),
-							Body:     body,
-						},
-					)
-					if err != nil {
-						fmt.Fprintf(os.Stderr, "Warning: failed to post inline comment on %s:%d: %v\n",
-							grp.FilePath, grp.NewLine, err)
-						continue
+					progress.finish()
+					if updatedInline > 0 {
+						fmt.Printf("Updated %d existing inline comments with revised wording.\n", updatedInline)
 					}
-					postedInline++
-					postedInlineKeys[key] = struct{}{}
-					existingSeverity[sevKey] = struct{}{}
-				}
-				if postedInline > 0 {
-					fmt.Printf("Posted %d inline comments.\n", postedInline)
-					if reusedInline > 0 {
-						fmt.Printf("Reused %d existing discussions for continuity.\n", reusedInline)
+					if postedInline > 0 {
+						fmt.Printf("Posted %d inline comments.\n", postedInline)
+						if reusedInline > 0 {
+							fmt.Printf("Reused %d existing discussions for continuity.\n", reusedInline)
+						}
+					} else if skippedExisting > 0 || skippedRunDup > 0 {
+						fmt.Printf("No new inline comments to post (existing threads already cover %d findings).\n", skippedExisting)
+					} else if len(inlineGroups) == 0 {
+						fmt.Println("No inline findings generated by AI output.")
+					} else if len(unplaced) >= selection.Findings {
+						fmt.Println("No inline comments posted (all findings were unplaced for current MR diff).")
+					} else {
+						fmt.Println("No inline comments were posted.")
+					}
+					if len(unplaced) > 0 {
+						if err := postUnplacedFindings(cmd.Context(), vcsProvider, projectID, mrIID, notes, unplaced, inlineOnly, inlineOnlySummaryFallback); err != nil {
+							fmt.Fprintf(os.Stderr, "Warning: failed to post unplaced findings: %v\n", err)
+						}
+					}
+					if consolidateRecurring > 0 {
+						n, err := postRecurringFindingNotes(cmd.Context(), vcsProvider, projectID, mrIID, notes,
+							commentedInlineGroups(inlineGroups, inlineStatuses), consolidateRecurring)
+						if err != nil {
+							fmt.Fprintf(os.Stderr, "Warning: failed to post recurring finding note: %v\n", err)
+						}
+						if n > 0 {
+							fmt.Printf("Posted or updated %d grouped notes for recurring findings.\n", n)
+						}
 					}
-				} else if skippedExisting > 0 || skippedRunDup > 0 {
-					fmt.Printf("No new inline comments to post (existing threads already cover %d findings).\n", skippedExisting)
-				} else if len(inlineGroups) == 0 {
-					fmt.Println("No inline findings generated by AI output.")
-				} else if len(unplaced) >= len(fileComments) {
-					fmt.Println("No inline comments posted (all findings were unplaced for current MR diff).")
+				}
+			}
+
+			stopPost := timings.start("post")
+			if dryRun {
+				fmt.Println("\nDry run: nothing posted to the MR.")
+			} else if reportAsStatus {
+				summary := strings.TrimSpace(parsed.Summary)
+				if summary == "" {
+					summary = strings.TrimSpace(reviewContent)
+				}
+				state, err := reportReviewStatus(cmd.Context(), vcsProvider, projectID, review.MR.DiffRefs.HeadSHA, parsed.FileComments, summary)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to set review commit status (%v); posting comments instead.\n", err)
+					runPostingSteps(postOrder, postSummary, postInline)
 				} else {
-					fmt.Println("No inline comments were posted.")
+					fmt.Printf("\nSet commit status %s=%s on %s; no review comments posted.\n", reviewStatusName(), state, shortSHA(review.MR.DiffRefs.HeadSHA))
 				}
-				if len(unplaced) > 0 && !inlineOnly {
-					sort.Strings(unplaced)
-					note := "## Unplaced Inline Findings\n\nGitLab rejected precise inline placement for these findings. They are kept here for visibility:\n\n" + strings.Join(unplaced, "\n")
-					if err := vcsProvider.PostSummaryNote(cmd.Context(), projectID, mrIID, note); err != nil {
-						fmt.Fprintf(os.Stderr, "Warning: failed to post unplaced findings note: %v\n", err)
-					}
+			} else {
+				runPostingSteps(postOrder, postSummary, postInline)
+			}
+			stopPost()
+
+			if autoResolve && !dryRun {
+				if n := resolveStaleThreads(cmd.Context(), vcsProvider, projectID, mrIID, discussions, allPositionsByFile, carryOver, mentionHandle, pausedThreads, ignoredThreads); n > 0 {
+					fmt.Printf("Resolved %d threads whose findings are no longer in the diff.\n", n)
+				}
+			}
+
+			if reconsiderApproval && !dryRun {
+				posted, err := postApprovalReconsideration(cmd.Context(), vcsProvider, projectID, mrIID, notes, review.MR.DiffRefs.HeadSHA, parsed.FileComments)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to post approval reconsideration note: %v\n", err)
+				} else if posted {
+					fmt.Println("Posted approval reconsideration note (new CRITICAL findings since the last clean review).")
 				}
 			}
 
-			if incremental {
+			if (incremental || reconsiderApproval || onlyNewCommits) && !dryRun {
+				previous, _ := latestReviewBaseline(notes)
 				baseline := reviewBaseline{
-					HeadSHA:  review.MR.DiffRefs.HeadSHA,
-					FileSigs: currentSignatures,
+					HeadSHA:      review.MR.DiffRefs.HeadSHA,
+					FileSigs:     currentSignatures,
+					Verdict:      reviewVerdict(parsed.FileComments),
+					ReviewedSHAs: mergeReviewedSHAs(previous.ReviewedSHAs, mrCommitSHAs(repoPath, review.MR.DiffRefs)),
 				}
 				if err := postReviewBaseline(cmd.Context(), vcsProvider, projectID, mrIID, baseline); err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: failed to post incremental baseline marker: %v\n", err)
+					fmt.Fprintf(os.Stderr, "Warning: failed to post review baseline marker: %v\n", err)
+				}
+			}
+
+			if documentOutput {
+				summary := strings.TrimSpace(parsed.Summary)
+				if summary == "" {
+					summary = strings.TrimSpace(reviewContent)
+				}
+				doc := buildMRReviewJSON(review.MR, projectID, info.Name, model, dryRun, summary, inlineGroups, inlineStatuses, unplaced, review.Enrichment)
+				if err := writeMRReviewDocument(outputFormat, outputFile, jsonStdout, doc); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: failed to write %s output: %v\n", outputFormat, err)
+					os.Exit(1)
+				}
+				if outputFile != "" {
+					fmt.Printf("Report written to %s\n", outputFile)
+				}
+			}
+
+			timings.print(os.Stdout)
+
+			// The gate runs regardless of --dry-run so it can be previewed.
+			if failOn != "" {
+				gateGroups := inlineGroups
+				if !selected {
+					gateGroups = selectInlineGroups(parsed.FileComments, strictness, nitpick, conventions, validPositionsByFile, filterMode, maxComments).Groups
+					gateGroups, _ = applyPostMode(gateGroups, postMode)
+				}
+				if code := severityGateExitCode(os.Stdout, gateGroups, failOn); code != 0 {
+					os.Exit(code)
 				}
 			}
 		},
 	}
 
 	cmd.Flags().Bool("dry-run", false, "Print review without posting to VCS")
+	cmd.Flags().String("dry-run-save", "", "With --dry-run, write the assembled prompt and each pass's raw output to this file (or directory, if it exists or ends in /)")
+	cmd.Flags().Bool("print-settings-json", false, "Print the fully-resolved review settings as one JSON object on stdout and exit without reviewing")
+	cmd.Flags().String("output", mrOutputText, "Output format: text, json, html (json and html print a findings document to stdout; progress goes to stderr)")
+	cmd.Flags().String("output-file", "", "With --output json or html, write the document to this file instead of stdout")
 	cmd.Flags().Bool("summary-only", false, "Post only a summary comment, no inline comments")
+	cmd.Flags().Bool("verbose", false, "Log each inline comment (file:line) as it is posted")
+	cmd.Flags().Bool("profile", false, "Print how long the extract, enrich, passes and post stages took")
+	cmd.Flags().Bool("commits", false, "Add the MR's commit messages to the prompt so the diff is checked against their stated intent")
+	cmd.Flags().Bool("force-full", false, "Review inline even when the diff exceeds review.max_diff_bytes")
 	cmd.Flags().String("gitlab-token", "", "GitLab personal access token (or use GITLAB_TOKEN env)")
+	cmd.Flags().String("gitlab-token-file", "", "Read the VCS token from this file (or use GITLAB_TOKEN_FILE/GITHUB_TOKEN_FILE env)")
+	cmd.Flags().String("token-command", "", "Shell command printing the VCS token, like a git credential helper (e.g. \"pass show gitlab\")")
 	cmd.Flags().String("gitlab-url", "", "GitLab instance URL (or use GITLAB_URL env, default: https://gitlab.com)")
-	cmd.Flags().String("vcs", "", "VCS provider (gitlab, github; auto-detected from env)")
+	cmd.Flags().String("vcs", "", "VCS provider (gitlab, github, azuredevops; auto-detected from env)")
 	cmd.Flags().String("strictness", "", "Review strictness: strict, normal, lenient (default: normal)")
+	cmd.Flags().String("persona", "", "Reviewer persona: "+strings.Join(core.ReviewPersonaNames(), ", ")+", or custom text")
+	cmd.Flags().String("language", "", "Write summaries and finding messages in this language (e.g. French); KIND/SEVERITY tokens stay English")
+	cmd.Flags().String("repo-context-file", "", "Repository doc (e.g. ARCHITECTURE.md) whose excerpt is added to the prompt as background")
+	cmd.Flags().Int("repo-context-max-chars", defaultRepoContextMaxChars, "Maximum characters of --repo-context-file included in the prompt")
 	cmd.Flags().Int("max-comments", 0, "Maximum number of inline comments to post (0 = unlimited)")
 	cmd.Flags().Int("review-passes", 0, "Number of AI review passes to run (0 = config/default 1)")
+	cmd.Flags().Bool("cache", false, "Reuse cached AI review output for an unchanged prompt and diff (stored under .prev/cache/completion)")
+	cmd.Flags().String("cache-ttl", reviewcache.DefaultTTL.String(), "Maximum age of a cached review before it is refreshed (0 disables expiry)")
+	cmd.Flags().String("pass-mode", passModeRefine, "Multi-pass strategy: refine (sequential re-review), ensemble (concurrent independent passes merged)")
+	cmd.Flags().Bool("early-stop-on-clean", false, "In refine mode, stop once two consecutive passes find no issues")
+	cmd.Flags().Int("samples", 0, "Completions to sample per pass; findings from all samples are unioned (0 = config/default 1, max 5)")
+	cmd.Flags().String("severity-consensus", severityConsensusMax, "Severity selection across review passes: max, majority")
 	cmd.Flags().Bool("inline-only", false, "Post inline comments only (disable summary notes, thread replies, and unplaced summary notes)")
+	cmd.Flags().Bool("inline-only-summary-fallback", false, "In inline-only mode, post unplaced findings as one non-positional resolvable discussion")
 	cmd.Flags().Bool("incremental", false, "Review only file-level deltas since the last baseline marker")
+	cmd.Flags().Bool("only-new-commits", false, "Review only the commits pushed since the last recorded review head (uses the baseline marker)")
+	cmd.Flags().String("since", "", "Review only MR files changed between this commit SHA and the MR head (bypasses the incremental baseline)")
+	cmd.Flags().StringArray("files", nil, "Review only changed files matching this glob (repeatable, e.g. --files 'backend/**')")
+	cmd.Flags().Bool("report-as-status", false, "Report the review as a pass/fail commit status instead of posting comments")
+	cmd.Flags().Bool("auto-resolve", false, "Reply to and resolve prev threads whose finding is no longer present in the current diff")
+	cmd.Flags().Bool("stream", false, "In dry-run mode, print review output as it arrives instead of after each pass completes")
+	cmd.Flags().Bool("suppress-stale", false, "Drop findings whose quoted code no longer matches the added lines at their anchor")
+	cmd.Flags().Bool("include-diff-stats", false, "Prepend a change-stats table (files, additions/deletions, languages) to the review prompt")
+	cmd.Flags().Bool("reconsider-approval", false, "Post an \"approval should be reconsidered\" note when new commits add CRITICAL findings after a clean review")
 	cmd.Flags().String("filter-mode", "diff_context", "Inline filtering mode: added, diff_context, file, nofilter")
 	cmd.Flags().Bool("memory", true, "Enable persistent cross-MR reviewer memory")
 	cmd.Flags().String("memory-file", defaultReviewMemoryFile, "Path to persistent review memory markdown file")
 	cmd.Flags().Int("memory-max", 12, "Maximum historical memory items injected into the review prompt")
 	cmd.Flags().Bool("native-impact", true, "Enable native deterministic impact/risk precheck before AI review")
 	cmd.Flags().Int("native-impact-max-symbols", 12, "Maximum changed symbols used for native impact mapping")
+	cmd.Flags().Bool("dependency-review", true, "Inject a supply-chain focused dependency summary when manifests or lockfiles change")
 	cmd.Flags().String("fix-prompt", "off", "Include AI fix prompt block in inline comments: off, auto, always")
 	cmd.Flags().Bool("structured-output", false, "Request and parse structured JSON findings with markdown fallback")
-	cmd.Flags().String("mr-diff-source", "auto", "MR diff source strategy: auto, git, raw, api")
+	cmd.Flags().Int("max-title-chars", 0, "Maximum MR title characters included in the review prompt (0 = unlimited)")
+	cmd.Flags().Int("max-description-chars", 0, "Maximum MR description characters included in the review prompt (0 = unlimited)")
+	cmd.Flags().String("on-content-filter", contentFilterSkip, "Provider content-filter handling: fail, skip, retry_sanitized")
+	cmd.Flags().Bool("collapsible-summary", false, "Wrap the posted summary note in a collapsible details block")
+	cmd.Flags().Bool("use-merge-base", false, "Replace the reported MR base with the computed merge-base for diff positions")
+	cmd.Flags().String("post-order", postOrderInlineFirst, "Order of posting: inline_first, summary_first")
+	cmd.Flags().String("summary-target", summaryTargetNote, "Where the summary goes: note, label (verdict label), description (appended)")
+	cmd.Flags().String("post-mode", postModeDefault, "Inline fix surfacing: default, comment-only (no suggestion blocks), suggestion-first (only findings with a suggestion)")
+	cmd.Flags().String("fail-on", "", "Exit nonzero when any final finding is at or above this severity: critical, high, medium, low")
+	cmd.Flags().String("mr-diff-source", "auto", "MR diff source strategy: auto, git, compare, raw, api")
 	cmd.Flags().String("serena", "auto", "Serena mode: auto, on, off")
 	cmd.Flags().Int("context", 10, "Number of surrounding context lines for MR review context enrichment")
 	cmd.Flags().Int("max-tokens", 80000, "Maximum token budget for MR context enrichment")
+	cmd.Flags().Bool("no-skip-generated", false, "Review generated and vendored files instead of leaving them out of the prompt")
+	cmd.Flags().String("budget-strategy", diffparse.BudgetReduceContext, "Over-budget strategy when Serena is unavailable: reduce_context, drop_lowest_priority_files, summarize_large_files")
 	return cmd
 }
 
+// truncatePromptText caps text at maxChars runes, appending an ellipsis and a
+// short note so the model knows content was dropped. maxChars <= 0 disables it.
+func truncatePromptText(text string, maxChars int, label string) string {
+	if maxChars <= 0 {
+		return text
+	}
+	runes := []rune(text)
+	if len(runes) <= maxChars {
+		return text
+	}
+	truncated := strings.TrimRightFunc(string(runes[:maxChars]), unicode.IsSpace)
+	return fmt.Sprintf("%s…\n[%s truncated from %d to %d characters]", truncated, label, len(runes), maxChars)
+}
+
 func normalizeNitpickFromStrictness(nitpick int, strictness string) int {
 	if nitpick > 10 {
 		nitpick = 10
@@ -678,6 +1184,20 @@ func resolveMRStringSetting(
 	return strings.TrimSpace(fallback)
 }
 
+// resolveMRStringSliceSetting returns the first non-empty list among
+// configKeys, or fallback when none is set, conf has no config store, or the
+// value is not a list.
+func resolveMRStringSliceSetting(conf config.Config, configKeys []string, fallback []string) []string {
+	if conf.Viper != nil {
+		for _, k := range configKeys {
+			if v := conf.Viper.GetStringSlice(k); len(v) > 0 {
+				return v
+			}
+		}
+	}
+	return fallback
+}
+
 func resolveMRIntSetting(
 	cmd *cobra.Command,
 	flagName string,
@@ -731,6 +1251,7 @@ type inlinePositions struct {
 	oldPath  string
 	oldByNew map[int]int
 	added    map[int]struct{}
+	deleted  map[int]struct{} // old line numbers of removed lines
 	content  map[int]string
 	hunks    []hunkRange
 }
@@ -747,6 +1268,7 @@ func collectValidPositions(changes []diffparse.FileChange) map[string]inlinePosi
 			fp = inlinePositions{
 				oldByNew: make(map[int]int),
 				added:    make(map[int]struct{}),
+				deleted:  make(map[int]struct{}),
 				content:  make(map[int]string),
 			}
 		}
@@ -767,6 +1289,8 @@ func collectValidPositions(changes []diffparse.FileChange) map[string]inlinePosi
 					if l.Type == diffparse.LineAdded {
 						fp.added[l.NewLineNo] = struct{}{}
 					}
+				} else if l.Type == diffparse.LineDeleted && l.OldLineNo > 0 {
+					fp.deleted[l.OldLineNo] = struct{}{}
 				}
 			}
 		}
@@ -775,6 +1299,25 @@ func collectValidPositions(changes []diffparse.FileChange) map[string]inlinePosi
 	return out
 }
 
+// currentDiffPath maps path, taken from an earlier review's anchor, to the
+// file's name in the current diff. A file renamed since then is found through
+// its inlinePositions.oldPath; any other path is returned unchanged.
+func currentDiffPath(valid map[string]inlinePositions, path string) string {
+	if _, ok := valid[path]; ok || path == "" {
+		return path
+	}
+	renamed := ""
+	for name, fp := range valid {
+		if fp.oldPath == path && (renamed == "" || name < renamed) {
+			renamed = name
+		}
+	}
+	if renamed == "" {
+		return path
+	}
+	return renamed
+}
+
 func resolveInlinePosition(valid map[string]inlinePositions, filePath string, requestedLine int) (newLine, oldLine int, ok bool) {
 	fp, ok := valid[filePath]
 	if !ok {
@@ -789,6 +1332,11 @@ func resolveInlinePosition(valid map[string]inlinePositions, filePath string, re
 		}
 		return requestedLine, old, true
 	}
+	// The prompt numbers removed lines by their old line, so a line that is
+	// not on the new side but was removed anchors to the deletion.
+	if _, removed := fp.deleted[requestedLine]; removed {
+		return 0, requestedLine, true
+	}
 
 	if snapped, ok := nearestAddedInRelevantHunk(fp, requestedLine); ok {
 		return snapped, fp.oldByNew[snapped], true
@@ -953,6 +1501,7 @@ type carryOverFinding struct {
 	Line         int
 	Severity     string
 	Message      string
+	Fingerprint  string
 }
 
 type reusableThread struct {
@@ -1032,24 +1581,33 @@ func collectCarryOverFindings(
 			if !n.Resolvable || n.Resolved || n.FilePath == "" || n.Line <= 0 {
 				continue
 			}
-			if _, _, ok := resolveInlinePosition(valid, n.FilePath, n.Line); !ok {
+			path := currentDiffPath(valid, n.FilePath)
+			if _, _, ok := resolveInlinePosition(valid, path, n.Line); !ok {
 				continue
 			}
 			sev, msg, ok := severityAndMessage(n.Body)
 			if !ok {
 				continue
 			}
-			key := inlineKey(n.FilePath, n.Line, "["+sev+"] "+msg)
+			key := inlineKey(path, n.Line, n.OnDeleted, "["+sev+"] "+msg)
 			if _, ok := seen[key]; ok {
 				continue
 			}
+			fp, hasFP := extractFingerprint(n.Body)
+			if hasFP {
+				if _, ok := seen["fp|"+fp]; ok {
+					continue
+				}
+				seen["fp|"+fp] = struct{}{}
+			}
 			seen[key] = struct{}{}
 			out = append(out, carryOverFinding{
 				DiscussionID: d.ID,
-				FilePath:     n.FilePath,
+				FilePath:     path,
 				Line:         n.Line,
 				Severity:     sev,
 				Message:      msg,
+				Fingerprint:  fp,
 			})
 		}
 	}
@@ -1088,6 +1646,45 @@ func appendCarryOverGuidelines(guidelines string, carry []carryOverFinding) stri
 	return guidelines + "\n" + block
 }
 
+// postUnplacedFindings keeps findings that could not be anchored inline
+// visible. Outside inline-only mode they go into a summary note; in
+// inline-only mode they are only posted, as a single non-positional resolvable
+// discussion, when review.inline_only_summary_fallback is enabled.
+func postUnplacedFindings(
+	ctx context.Context,
+	vcsProvider vcs.VCSProvider,
+	projectID string,
+	mrIID int64,
+	notes []vcs.MRNote,
+	unplaced []string,
+	inlineOnly bool,
+	inlineOnlyFallback bool,
+) error {
+	if len(unplaced) == 0 || (inlineOnly && !inlineOnlyFallback) {
+		return nil
+	}
+	sort.Strings(unplaced)
+	if !inlineOnly {
+		note := "## Unplaced Inline Findings\n\nGitLab rejected precise inline placement for these findings. They are kept here for visibility:\n\n" + strings.Join(unplaced, "\n")
+		return vcsProvider.PostSummaryNote(ctx, projectID, mrIID, note)
+	}
+	if hasTopLevelMarker(notes, prevUnplacedMarker) {
+		fmt.Println("Unplaced findings discussion already posted; skipping duplicate.")
+		return nil
+	}
+	starter, ok := vcsProvider.(vcs.DiscussionStarter)
+	if !ok {
+		return fmt.Errorf("%s does not support non-positional discussions; %d unplaced findings dropped", vcsProvider.Info().Name, len(unplaced))
+	}
+	body := "## Unplaced Inline Findings\n\nThese findings could not be anchored to a changed line. Resolve this thread once they are addressed:\n\n" +
+		strings.Join(unplaced, "\n") + "\n\n" + prevThreadMarker + "\n" + prevUnplacedMarker
+	if err := starter.StartMRDiscussion(ctx, projectID, mrIID, body); err != nil {
+		return err
+	}
+	fmt.Printf("Posted %d unplaced findings as a discussion (inline-only mode).\n", len(unplaced))
+	return nil
+}
+
 func postCarryOverReminders(
 	ctx context.Context,
 	vcsProvider vcs.VCSProvider,
@@ -1113,26 +1710,142 @@ func postCarryOverReminders(
 		}
 	}
 
-	posted := 0
+	// One thread can carry several findings (different lines of the same
+	// discussion); they share a single reminder.
+	var order []string
+	byDiscussion := map[string][]carryOverFinding{}
 	for _, c := range carry {
-		if pausedThreads[c.DiscussionID] {
+		if _, ok := byDiscussion[c.DiscussionID]; !ok {
+			order = append(order, c.DiscussionID)
+		}
+		byDiscussion[c.DiscussionID] = append(byDiscussion[c.DiscussionID], c)
+	}
+
+	posted := 0
+	for _, id := range order {
+		if pausedThreads[id] {
+			continue
+		}
+		if _, ok := hasReminder[id]; ok {
 			continue
 		}
-		if _, ok := hasReminder[c.DiscussionID]; ok {
+		body := carryOverReminderBody(byDiscussion[id])
+		if err := vcsProvider.ReplyToMRDiscussion(ctx, projectID, mrIID, id, body); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to post carry-over reminder in discussion %s: %v\n", id, err)
 			continue
 		}
-		body := fmt.Sprintf(
+		hasReminder[id] = struct{}{}
+		posted++
+	}
+	return posted
+}
+
+// carryOverReminderBody renders the reminder for one discussion's carried
+// findings, most severe first.
+func carryOverReminderBody(findings []carryOverFinding) string {
+	if len(findings) == 1 {
+		c := findings[0]
+		return fmt.Sprintf(
 			"%s\nUnresolved prior finding is still present in this revision at `%s:%d` [%s]. Please address this before lower-priority items.",
 			prevCarryOverMarker, c.FilePath, c.Line, c.Severity,
 		)
-		if err := vcsProvider.ReplyToMRDiscussion(ctx, projectID, mrIID, c.DiscussionID, body); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to post carry-over reminder in discussion %s: %v\n", c.DiscussionID, err)
+	}
+	sorted := append([]carryOverFinding(nil), findings...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return severityRank(sorted[i].Severity) > severityRank(sorted[j].Severity)
+	})
+	lines := make([]string, 0, len(sorted)+3)
+	lines = append(lines, prevCarryOverMarker, "Unresolved prior findings are still present in this revision:", "")
+	for _, c := range sorted {
+		lines = append(lines, fmt.Sprintf("- `%s:%d` [%s] %s", c.FilePath, c.Line, c.Severity, c.Message))
+	}
+	lines = append(lines, "", "Please address these before lower-priority items.")
+	return strings.Join(lines, "\n")
+}
+
+// staleThreadIDs returns prev-authored, unresolved discussions whose anchored
+// findings no longer sit on any line of the current diff. Unlike carry-over,
+// anchors are not snapped to nearby changed lines. Threads that are
+// carried over, paused, ignored or already auto-resolved are left alone.
+func staleThreadIDs(
+	discussions []vcs.MRDiscussion,
+	valid map[string]inlinePositions,
+	carry []carryOverFinding,
+	mentionHandle string,
+	pausedThreads map[string]bool,
+	ignoredThreads map[string]bool,
+) []string {
+	carried := make(map[string]struct{}, len(carry))
+	for _, c := range carry {
+		carried[c.DiscussionID] = struct{}{}
+	}
+
+	var out []string
+	for _, d := range discussions {
+		if discussionResolved(d) || pausedThreads[d.ID] || ignoredThreads[d.ID] {
 			continue
 		}
-		hasReminder[c.DiscussionID] = struct{}{}
-		posted++
+		if _, ok := carried[d.ID]; ok {
+			continue
+		}
+		if !isPrevThread(d, mentionHandle) {
+			continue
+		}
+		anchored := false
+		present := false
+		autoResolved := false
+		for _, n := range d.Notes {
+			if strings.Contains(strings.ToLower(n.Body), strings.ToLower(prevResolvedMarker)) {
+				autoResolved = true
+				break
+			}
+			if !n.Resolvable || n.FilePath == "" || n.Line <= 0 {
+				continue
+			}
+			if _, _, ok := severityAndMessage(n.Body); !ok {
+				continue
+			}
+			anchored = true
+			if _, ok := valid[n.FilePath].oldByNew[n.Line]; ok {
+				present = true
+				break
+			}
+		}
+		if anchored && !present && !autoResolved {
+			out = append(out, d.ID)
+		}
 	}
-	return posted
+	return out
+}
+
+// resolveStaleThreads replies to and resolves prev threads whose finding has
+// disappeared from the current diff. It returns the number of threads resolved.
+func resolveStaleThreads(
+	ctx context.Context,
+	vcsProvider vcs.VCSProvider,
+	projectID string,
+	mrIID int64,
+	discussions []vcs.MRDiscussion,
+	valid map[string]inlinePositions,
+	carry []carryOverFinding,
+	mentionHandle string,
+	pausedThreads map[string]bool,
+	ignoredThreads map[string]bool,
+) int {
+	resolved := 0
+	for _, id := range staleThreadIDs(discussions, valid, carry, mentionHandle, pausedThreads, ignoredThreads) {
+		body := "Resolved: no longer present in current diff.\n\n" + prevResolvedMarker
+		if err := vcsProvider.ReplyToMRDiscussion(ctx, projectID, mrIID, id, body); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to reply to stale discussion %s: %v\n", id, err)
+			continue
+		}
+		if err := vcsProvider.ResolveMRDiscussion(ctx, projectID, mrIID, id); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to resolve stale discussion %s: %v\n", id, err)
+			continue
+		}
+		resolved++
+	}
+	return resolved
 }
 
 func processIgnoreCommands(
@@ -1175,6 +1888,7 @@ func processReplyCommands(
 	changes []diffparse.FileChange,
 	mentionHandle string,
 	pausedThreads map[string]bool,
+	systemPrompt string,
 ) int {
 	posted := 0
 	for _, d := range discussions {
@@ -1199,7 +1913,7 @@ func processReplyCommands(
 		hunk := extractHunkContext(changes, path, line)
 		prompt := buildThreadReplyPrompt(hunk, detailed)
 		conv := provider.NewConversation(ai, provider.ConversationOptions{
-			SystemPrompt: "You are an expert code reviewer replying in a merge request discussion. Be accurate, sharp, and direct. Keep the default reply concise, with no fluff and no emojis. Expand only when the latest request explicitly asks for more detail. Preserve thread continuity and tie your reply to the available hunk context.",
+			SystemPrompt: systemPromptOr(systemPrompt, defaultThreadReplySystemPrompt),
 			Messages:     buildDiscussionConversationMessages(d, mentionHandle),
 		})
 		content, err := completeConversationPrompt(ctx, conv, prompt)
@@ -1245,7 +1959,7 @@ func collectIgnoredFindings(
 			continue
 		}
 		item := ignoredFinding{
-			FilePath:      strings.TrimSpace(path),
+			FilePath:      core.NormalizePath(path),
 			Line:          line,
 			Message:       strings.TrimSpace(msg),
 			RuleID:        memoryRuleID(msg),
@@ -1351,6 +2065,7 @@ func processNoteReplyCommands(
 	mr *vcs.MergeRequest,
 	validPositionsByFile map[string]inlinePositions,
 	mentionHandle string,
+	systemPrompt string,
 ) int {
 	if strings.TrimSpace(mentionHandle) == "" {
 		return 0
@@ -1375,7 +2090,7 @@ func processNoteReplyCommands(
 		}
 		prompt := buildNoteReplyPrompt(note, mr, detailed)
 		conv := provider.NewConversation(ai, provider.ConversationOptions{
-			SystemPrompt: "You are an expert code reviewer replying to a merge request comment. Be accurate, sharp, and direct. Keep the default reply concise, with no fluff and no emojis. Expand only when the latest request explicitly asks for more detail. Stay scoped to the MR context and avoid boilerplate.",
+			SystemPrompt: systemPromptOr(systemPrompt, defaultNoteReplySystemPrompt),
 		})
 		content, err := completeConversationPrompt(ctx, conv, prompt)
 		if err != nil || strings.TrimSpace(content) == "" {
@@ -1507,6 +2222,9 @@ func isPrevThread(d vcs.MRDiscussion, mentionHandle string) bool {
 	if len(d.Notes) == 0 {
 		return false
 	}
+	if isForeignPrevDiscussion(d) {
+		return false
+	}
 	first := d.Notes[0]
 	if strings.Contains(strings.ToLower(first.Body), strings.ToLower(prevThreadMarker)) {
 		return true
@@ -1697,6 +2415,9 @@ func ignoredDiscussions(discussions []vcs.MRDiscussion, mentionHandle string) ma
 }
 
 func severityAndMessage(body string) (string, string, bool) {
+	if line, ok := inlineFindingMarkerLine(body); ok {
+		return severityAndMessage(line)
+	}
 	lines := strings.Split(strings.TrimSpace(body), "\n")
 	for i, raw := range lines {
 		line := strings.TrimSpace(raw)
@@ -1756,26 +2477,72 @@ func severityRank(sev string) int {
 	}
 }
 
-func existingInlineKeys(discussions []vcs.MRDiscussion) map[string]struct{} {
-	out := make(map[string]struct{})
+// existingInlineNote is an open inline note together with whether anyone
+// other than prev has replied in its thread.
+type existingInlineNote struct {
+	vcs.MRDiscussionNote
+	HasHumanReply bool
+}
+
+// sameInlineFindingSimilarity is the minimum trigram similarity between an
+// existing note's message and a new finding for the two to count as the same
+// finding when their fingerprints differ.
+const sameInlineFindingSimilarity = 0.5
+
+// existingInlineKeys maps the inline key of each open inline note to the
+// first note found at that position, so its ID is at hand for in-place
+// updates.
+func existingInlineKeys(discussions []vcs.MRDiscussion) map[string]existingInlineNote {
+	out := make(map[string]existingInlineNote)
 	for _, d := range discussions {
-		if discussionResolved(d) {
+		if discussionResolved(d) || isForeignPrevDiscussion(d) {
 			continue
 		}
+		humanReply := false
+		for _, n := range d.Notes[min(1, len(d.Notes)):] {
+			if _, ok := markerNamespaceOf(n.Body); !ok {
+				humanReply = true
+				break
+			}
+		}
 		for _, n := range d.Notes {
 			if n.FilePath == "" || n.Line <= 0 {
 				continue
 			}
-			out[inlineKey(n.FilePath, n.Line, n.Body)] = struct{}{}
+			key := inlineKey(n.FilePath, n.Line, n.OnDeleted, n.Body)
+			if _, ok := out[key]; !ok {
+				out[key] = existingInlineNote{MRDiscussionNote: n, HasHumanReply: humanReply}
+			}
 		}
 	}
 	return out
 }
 
+// inlineNoteNeedsUpdate reports whether existing is a prev-authored note for
+// the same finding, with the given severity, whose body differs from body,
+// i.e. a rerun has reworded it. The finding must match by fingerprint or by
+// message similarity, and threads someone has replied to are left alone.
+func inlineNoteNeedsUpdate(existing existingInlineNote, severity, message, body string) bool {
+	if existing.ID <= 0 || existing.HasHumanReply || !strings.Contains(strings.ToLower(existing.Body), strings.ToLower(prevThreadMarker)) {
+		return false
+	}
+	sev, oldMessage, ok := severityAndMessage(existing.Body)
+	if !ok || !strings.EqualFold(sev, strings.TrimSpace(severity)) {
+		return false
+	}
+	oldFP, hasOld := extractFingerprint(existing.Body)
+	newFP, hasNew := extractFingerprint(body)
+	sameFinding := hasOld && hasNew && oldFP == newFP
+	if !sameFinding && trigramSimilarity(oldMessage, message) < sameInlineFindingSimilarity {
+		return false
+	}
+	return strings.TrimSpace(existing.Body) != strings.TrimSpace(body)
+}
+
 func existingInlineSeverityKeys(discussions []vcs.MRDiscussion) map[string]struct{} {
 	out := make(map[string]struct{})
 	for _, d := range discussions {
-		if discussionResolved(d) {
+		if discussionResolved(d) || isForeignPrevDiscussion(d) {
 			continue
 		}
 		for _, n := range d.Notes {
@@ -1783,7 +2550,7 @@ func existingInlineSeverityKeys(discussions []vcs.MRDiscussion) map[string]struc
 				continue
 			}
 			if sev, _, ok := severityAndMessage(n.Body); ok {
-				out[inlineSeverityKey(n.FilePath, n.Line, sev)] = struct{}{}
+				out[inlineSeverityKey(n.FilePath, n.Line, n.OnDeleted, sev)] = struct{}{}
 			}
 		}
 	}
@@ -1792,6 +2559,7 @@ func existingInlineSeverityKeys(discussions []vcs.MRDiscussion) map[string]struc
 
 func collectReusableThreads(
 	discussions []vcs.MRDiscussion,
+	valid map[string]inlinePositions,
 	mentionHandle string,
 	pausedThreads map[string]bool,
 	ignoredThreads map[string]bool,
@@ -1814,6 +2582,7 @@ func collectReusableThreads(
 		if strings.TrimSpace(anchorPath) == "" || anchorLine <= 0 {
 			continue
 		}
+		anchorPath = currentDiffPath(valid, anchorPath)
 		for i := len(d.Notes) - 1; i >= 0; i-- {
 			n := d.Notes[i]
 			if n.Resolved || !n.Resolvable {
@@ -1849,30 +2618,7 @@ func discussionResolved(d vcs.MRDiscussion) bool {
 }
 
 func matchReusableThread(candidates []reusableThread, grp inlineGroup) (reusableThread, bool) {
-	bestIdx := -1
-	bestScore := -1
-	for i, c := range candidates {
-		if !strings.EqualFold(strings.TrimSpace(c.FilePath), strings.TrimSpace(grp.FilePath)) {
-			continue
-		}
-		if !strings.EqualFold(strings.TrimSpace(c.Severity), strings.TrimSpace(grp.Severity)) {
-			continue
-		}
-		msgScore := semanticMessageScore(c.Message, c.PrimarySymbol, grp.Message, semanticPrimarySymbol(grp.Message, grp.FilePath))
-		distPenalty := absInt(c.Line - grp.NewLine)
-		score := msgScore - minInt(distPenalty, 50)
-		if score > bestScore {
-			bestScore = score
-			bestIdx = i
-		}
-	}
-	if bestIdx < 0 {
-		return reusableThread{}, false
-	}
-	if bestScore < 25 {
-		return reusableThread{}, false
-	}
-	return candidates[bestIdx], true
+	return defaultReuseMatcher().match(candidates, grp)
 }
 
 func tokenOverlapScore(a, b string) int {
@@ -1918,29 +2664,88 @@ func minInt(a, b int) int {
 	return b
 }
 
-func inlineKey(filePath string, line int, body string) string {
+// inlineKey identifies an inline position: file, diff side and line. The side
+// matters on replaced lines, where removed old line N and added new line N
+// share a number but are different anchors.
+func inlineKey(filePath string, line int, onDeleted bool, body string) string {
 	_ = body
-	return strings.ToLower(strings.TrimSpace(filePath) + "|" + strconv.Itoa(line))
+	return strings.ToLower(strings.TrimSpace(filePath) + "|" + inlineSide(onDeleted) + "|" + strconv.Itoa(line))
 }
 
-func inlineSeverityKey(filePath string, line int, severity string) string {
-	return strings.ToLower(strings.TrimSpace(filePath) + "|" + strconv.Itoa(line) + "|" + strings.ToUpper(strings.TrimSpace(severity)))
+func inlineSeverityKey(filePath string, line int, onDeleted bool, severity string) string {
+	return strings.ToLower(strings.TrimSpace(filePath) + "|" + inlineSide(onDeleted) + "|" + strconv.Itoa(line) + "|" + strings.ToUpper(strings.TrimSpace(severity)))
+}
+
+func inlineSide(onDeleted bool) string {
+	if onDeleted {
+		return "old"
+	}
+	return "new"
 }
 
 func hasTopLevelMarker(notes []vcs.MRNote, marker string) bool {
+	_, ok := findTopLevelMarker(notes, marker)
+	return ok
+}
+
+// findTopLevelMarker returns the first note whose body contains marker,
+// ignoring case.
+func findTopLevelMarker(notes []vcs.MRNote, marker string) (vcs.MRNote, bool) {
 	marker = strings.ToLower(strings.TrimSpace(marker))
 	if marker == "" {
-		return false
+		return vcs.MRNote{}, false
 	}
 	for _, n := range notes {
 		if strings.Contains(strings.ToLower(n.Body), marker) {
-			return true
+			return n, true
 		}
 	}
-	return false
+	return vcs.MRNote{}, false
+}
+
+// Inline comment verbosity used when review.max_inline_key_points,
+// review.max_inline_key_point_chars and review.max_inline_chars are unset.
+const (
+	defaultInlineMaxKeyPoints  = 4
+	defaultInlineMaxPointChars = 220
+	defaultInlineMaxBodyChars  = 158
+)
+
+// inlineBodyLimits caps inline comment verbosity. MaxKeyPoints
+// (review.max_inline_key_points) and MaxPointChars
+// (review.max_inline_key_point_chars) bound the key points extracted from a
+// finding; MaxBodyChars (review.max_inline_chars) bounds the posted comment
+// line built from the first of them. Zero fields use the defaults.
+type inlineBodyLimits struct {
+	MaxKeyPoints  int
+	MaxPointChars int
+	MaxBodyChars  int
+}
+
+func (l inlineBodyLimits) keyPoints() int {
+	if l.MaxKeyPoints <= 0 {
+		return defaultInlineMaxKeyPoints
+	}
+	return l.MaxKeyPoints
+}
+
+func (l inlineBodyLimits) pointChars() int {
+	if l.MaxPointChars <= 0 {
+		return defaultInlineMaxPointChars
+	}
+	return l.MaxPointChars
+}
+
+func (l inlineBodyLimits) bodyChars() int {
+	if l.MaxBodyChars <= 0 {
+		return defaultInlineMaxBodyChars
+	}
+	return l.MaxBodyChars
 }
 
-func conciseInlineBody(body string) string {
+// conciseInlineBody reduces body to its first sentence (or first
+// paragraph, for lists), capped at maxLen bytes.
+func conciseInlineBody(body string, maxLen int) string {
 	trimmed := strings.TrimSpace(body)
 	if trimmed == "" {
 		return trimmed
@@ -1957,48 +2762,77 @@ func conciseInlineBody(body string) string {
 	}
 	candidate = strings.TrimSpace(candidate)
 	candidate = strings.Join(strings.Fields(stripEmojiRunes(candidate)), " ")
-	const maxLen = 158
-	if len(candidate) > maxLen {
-		candidate = strings.TrimSpace(candidate[:maxLen-1]) + "…"
+	return limitLen(candidate, maxLen)
+}
+
+// inlinePrimaryPoint returns the first actionable key point of message, the
+// line an inline comment leads with.
+func inlinePrimaryPoint(message string, limits inlineBodyLimits) string {
+	points := extractKeyPoints(message, limits.keyPoints(), limits.pointChars())
+	for _, p := range points {
+		if !isNonActionableInlinePoint(p) {
+			return p
+		}
 	}
-	return candidate
+	if len(points) > 0 {
+		return points[0]
+	}
+	return "Review this change for correctness and side effects."
 }
 
 func buildInlineCommentBody(
+	file string,
+	line int,
 	severity string,
 	message string,
 	suggestion string,
 	formatSuggestion func(string) string,
+	limits inlineBodyLimits,
+	tmpl *template.Template,
 ) string {
 	sev := strings.ToUpper(strings.TrimSpace(severity))
 	if sev == "" {
 		sev = "MEDIUM"
 	}
 
-	points := extractKeyPoints(message)
-	primary := ""
-	for _, p := range points {
-		if isNonActionableInlinePoint(p) {
-			continue
+	primary := inlinePrimaryPoint(message, limits)
+	suggestion = normalizeSuggestion(suggestion)
+	data := inlineTemplateData{
+		Severity:   sev,
+		Message:    conciseInlineBody(primary, limits.bodyChars()),
+		Suggestion: suggestion,
+		File:       file,
+		Line:       line,
+	}
+	defaultLine, _ := renderInlineTemplate(defaultInlineTmpl, data)
+	body := conciseInlineBody(defaultLine, limits.bodyChars())
+	if tmpl != nil {
+		rendered, err := renderInlineTemplate(tmpl, data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v; using the default inline format.\n", err)
+		} else if rendered != "" {
+			// Thread reuse, dedupe and memory read the [SEV] line back, so
+			// it stays in the body as a hidden marker.
+			body = rendered + "\n\n" + inlineFindingMarker(body)
 		}
-		primary = p
-		break
-	}
-	if primary == "" && len(points) > 0 {
-		primary = points[0]
 	}
-	if primary == "" {
-		primary = "Review this change for correctness and side effects."
-	}
-	body := conciseInlineBody(fmt.Sprintf("[%s] %s", sev, primary))
 
-	suggestion = normalizeSuggestion(suggestion)
 	if suggestion != "" && formatSuggestion != nil {
 		body += "\n\nSuggested patch:\n" + formatSuggestion(suggestion)
 	}
 	return body
 }
 
+// buildSummaryNoteBody renders the top-level summary note. The summary marker
+// always stays outside the collapsed region so duplicate detection keeps working.
+func buildSummaryNoteBody(reviewContent string, collapsible bool) string {
+	if !collapsible {
+		return fmt.Sprintf("%s\n## AI Code Review\n\n%s", prevSummaryMarker, reviewContent)
+	}
+	return fmt.Sprintf("%s\n<details>\n<summary>AI Review</summary>\n\n%s\n\n</details>",
+		prevSummaryMarker, strings.TrimSpace(reviewContent))
+}
+
 func buildCollapsibleFixPrompt(prompt string) string {
 	prompt = strings.TrimSpace(prompt)
 	if prompt == "" {
@@ -2106,22 +2940,10 @@ func rebaseSuggestionIndentation(suggestion string, anchorLine string) string {
 		return suggestion
 	}
 	lines := strings.Split(strings.ReplaceAll(suggestion, "\r\n", "\n"), "\n")
-	nonEmpty := make([]string, 0, len(lines))
-	for _, line := range lines {
-		if strings.TrimSpace(line) != "" {
-			nonEmpty = append(nonEmpty, line)
-		}
-	}
-	if len(nonEmpty) == 0 {
+	commonIndent, ok := commonLeadingIndent(lines)
+	if !ok {
 		return suggestion
 	}
-	commonIndent := leadingIndent(nonEmpty[0])
-	for i := 1; i < len(nonEmpty); i++ {
-		commonIndent = commonPrefix(commonIndent, leadingIndent(nonEmpty[i]))
-		if commonIndent == "" {
-			break
-		}
-	}
 	for i, line := range lines {
 		if strings.TrimSpace(line) == "" {
 			continue
@@ -2131,6 +2953,57 @@ func rebaseSuggestionIndentation(suggestion string, anchorLine string) string {
 	return strings.Join(lines, "\n")
 }
 
+// commonLeadingIndent returns the indent shared by all non-blank lines; ok
+// is false when every line is blank.
+func commonLeadingIndent(lines []string) (indent string, ok bool) {
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if !ok {
+			indent, ok = leadingIndent(line), true
+			continue
+		}
+		indent = commonPrefix(indent, leadingIndent(line))
+		if indent == "" {
+			break
+		}
+	}
+	return indent, ok
+}
+
+// indentSensitiveLanguages are diffparse.DetectLanguage names where
+// indentation is syntax, so a suggestion shifted by a level changes meaning.
+var indentSensitiveLanguages = map[string]bool{
+	"python":   true,
+	"yaml":     true,
+	"haskell":  true,
+	"makefile": true,
+}
+
+// suggestionIndentNote is appended to inline comments whose suggestion was
+// left verbatim by rebaseSuggestionForFile.
+const suggestionIndentNote = "_Indentation note: this suggestion keeps the indentation it was written with because it does not line up cleanly with the commented line; check the block level before applying._"
+
+// rebaseSuggestionForFile is rebaseSuggestionIndentation made aware of the
+// file's language. In indentation-sensitive languages the suggestion is only
+// re-indented when its common indent and the anchor's indent are one a
+// prefix of the other (same whitespace, different depth); otherwise it is
+// returned as written and verbatim is true.
+func rebaseSuggestionForFile(suggestion, anchorLine, filePath string) (rebased string, verbatim bool) {
+	if !indentSensitiveLanguages[diffparse.DetectLanguage(filePath)] {
+		return rebaseSuggestionIndentation(suggestion, anchorLine), false
+	}
+	normalized := normalizeSuggestion(suggestion)
+	anchorIndent := leadingIndent(anchorLine)
+	common, ok := commonLeadingIndent(strings.Split(strings.ReplaceAll(normalized, "\r\n", "\n"), "\n"))
+	if !ok || anchorIndent == "" || common == anchorIndent ||
+		strings.HasPrefix(anchorIndent, common) || strings.HasPrefix(common, anchorIndent) {
+		return rebaseSuggestionIndentation(suggestion, anchorLine), false
+	}
+	return normalized, true
+}
+
 func leadingIndent(s string) string {
 	i := 0
 	for i < len(s) {
@@ -2203,7 +3076,7 @@ func buildAgentFixPrompt(grp inlineGroup, mode string) string {
 		strings.TrimSpace(grp.FilePath),
 		grp.NewLine,
 		strings.ToUpper(strings.TrimSpace(grp.Severity)),
-		strings.TrimSpace(conciseInlineBody(grp.Message)),
+		strings.TrimSpace(conciseInlineBody(grp.Message, defaultInlineMaxBodyChars)),
 	)
 }
 
@@ -2223,7 +3096,9 @@ func isNonActionableInlinePoint(point string) bool {
 	}
 }
 
-func extractKeyPoints(message string) []string {
+// extractKeyPoints splits message into at most maxPoints bullet-free lines
+// of at most maxChars bytes each.
+func extractKeyPoints(message string, maxPoints, maxChars int) []string {
 	clean := sanitizeInlineMessage(message)
 	if clean == "" {
 		return nil
@@ -2246,15 +3121,15 @@ func extractKeyPoints(message string) []string {
 		}
 		t = squeezeSpaces(t)
 		if t != "" {
-			out = append(out, limitLen(t, 220))
+			out = append(out, limitLen(t, maxChars))
 		}
 	}
 
 	if len(out) == 0 {
 		return nil
 	}
-	if len(out) > 4 {
-		out = out[:4]
+	if maxPoints > 0 && len(out) > maxPoints {
+		out = out[:maxPoints]
 	}
 	return dedupeStrings(out)
 }
@@ -2412,6 +3287,121 @@ type inlineGroup struct {
 	Severity   string
 	Message    string
 	Suggestion string
+	// OnDeleted anchors the finding to removed line OldLine (NewLine is 0).
+	OnDeleted bool
+}
+
+// anchorLine is the line the finding is shown on: the old line for removed
+// lines, the new line otherwise.
+func (g inlineGroup) anchorLine() int {
+	if g.OnDeleted {
+		return g.OldLine
+	}
+	return g.NewLine
+}
+
+// rejectedInlineEntry formats a finding whose inline position the provider
+// rejected as an unplaced-findings list item.
+func rejectedInlineEntry(g inlineGroup) string {
+	return fmt.Sprintf("- %s:%d [%s] %s", g.FilePath, g.anchorLine(), strings.ToUpper(g.Severity), strings.Join(strings.Fields(g.Message), " "))
+}
+
+// inlineCommentLine is anchorLine for a built comment, used in log lines.
+func inlineCommentLine(c vcs.InlineComment) int64 {
+	if c.OnDeleted {
+		return c.OldLine
+	}
+	return c.NewLine
+}
+
+const (
+	postOrderInlineFirst  = "inline_first"
+	postOrderSummaryFirst = "summary_first"
+)
+
+func normalizePostOrder(order string) string {
+	switch strings.ToLower(strings.TrimSpace(order)) {
+	case postOrderSummaryFirst:
+		return postOrderSummaryFirst
+	default:
+		return postOrderInlineFirst
+	}
+}
+
+// runPostingSteps posts the summary note and inline comments in the configured
+// order. Platforms that notify per comment deliver them in this sequence.
+func runPostingSteps(order string, postSummary, postInline func()) {
+	if normalizePostOrder(order) == postOrderSummaryFirst {
+		postSummary()
+		postInline()
+		return
+	}
+	postInline()
+	postSummary()
+}
+
+const (
+	severityConsensusMax      = "max"
+	severityConsensusMajority = "majority"
+)
+
+func normalizeSeverityConsensus(mode string) string {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case severityConsensusMajority, "vote":
+		return severityConsensusMajority
+	default:
+		return severityConsensusMax
+	}
+}
+
+// applySeverityConsensus re-scores final findings by a per-pass vote. Each
+// pass contributes one vote per file:line (its highest severity there); the
+// most common severity wins and ties resolve to the less severe option so a
+// single over-escalating pass cannot dominate.
+func applySeverityConsensus(final []core.FileComment, passFindings [][]core.FileComment, mode string) []core.FileComment {
+	if normalizeSeverityConsensus(mode) != severityConsensusMajority || len(passFindings) < 2 {
+		return final
+	}
+	votes := make(map[string]map[string]int)
+	for _, findings := range passFindings {
+		perPass := make(map[string]string)
+		for _, f := range findings {
+			key := strings.ToLower(strings.TrimSpace(f.FilePath)) + "|" + strconv.Itoa(f.Line)
+			sev := strings.ToUpper(strings.TrimSpace(f.Severity))
+			if severityRank(sev) == 0 {
+				continue
+			}
+			if cur, ok := perPass[key]; !ok || severityRank(sev) > severityRank(cur) {
+				perPass[key] = sev
+			}
+		}
+		for key, sev := range perPass {
+			if votes[key] == nil {
+				votes[key] = make(map[string]int)
+			}
+			votes[key][sev]++
+		}
+	}
+
+	out := make([]core.FileComment, len(final))
+	copy(out, final)
+	for i, c := range out {
+		key := strings.ToLower(strings.TrimSpace(c.FilePath)) + "|" + strconv.Itoa(c.Line)
+		tally, ok := votes[key]
+		if !ok {
+			continue
+		}
+		best, bestVotes := "", 0
+		for sev, n := range tally {
+			if n > bestVotes || (n == bestVotes && severityRank(sev) < severityRank(best)) {
+				best, bestVotes = sev, n
+			}
+		}
+		if best != "" {
+			out[i].Severity = best
+		}
+	}
+	return out
 }
 
 func aggregateCommentsByHunk(
@@ -2548,6 +3538,18 @@ func aggregateCommentsByLine(
 				fc.FilePath, requestedLine, strings.ToUpper(fc.Kind), strings.ToUpper(fc.Severity), fc.Message))
 			continue
 		}
+		if newLine == 0 && oldLine > 0 {
+			// Removed line: there is no new-side code to refine against or to
+			// apply a suggestion to.
+			out = append(out, inlineGroup{
+				FilePath:  fc.FilePath,
+				OldLine:   oldLine,
+				Severity:  strings.ToUpper(strings.TrimSpace(fc.Severity)),
+				Message:   fc.Message,
+				OnDeleted: true,
+			})
+			continue
+		}
 		if fp, ok := validPositionsByFile[fc.FilePath]; ok {
 			newLine, oldLine = refineInlinePositionByMessage(fp, requestedLine, newLine, fc.Message)
 		}
@@ -2648,6 +3650,63 @@ func filterCommentsByFileFocus(comments []core.FileComment) []core.FileComment {
 	return out
 }
 
+// inlineSelection is the outcome of the inline findings pipeline: grouped,
+// placeable findings plus the findings that could not be anchored.
+type inlineSelection struct {
+	Groups   []inlineGroup
+	Unplaced []string
+	Findings int // focused findings before grouping
+}
+
+// selectInlineGroups filters, focuses, groups and limits parsed findings into
+// the inline comments that would be posted.
+func selectInlineGroups(
+	parsed []core.FileComment,
+	strictness string,
+	nitpick int,
+	conventions []string,
+	validPositionsByFile map[string]inlinePositions,
+	filterMode string,
+	maxComments int,
+) inlineSelection {
+	rawComments, usedFilterFallback := filterInlineCandidates(
+		parsed,
+		strictness,
+		nitpick,
+		conventions,
+		validPositionsByFile,
+		filterMode,
+	)
+	if usedFilterFallback {
+		fmt.Println("Inline filter fallback: severity/kind filtering removed all findings; using parsed findings scoped to changed files.")
+	}
+	fileComments := filterCommentsByFileFocus(rawComments)
+	if len(fileComments) == 0 && len(rawComments) > 0 {
+		fmt.Println("Inline filter fallback: typo-only doc filter removed all findings; using broader findings.")
+		fileComments = rawComments
+	}
+	fileComments = aggregateCommentsByChange(fileComments)
+	inlineGroups, unplaced := aggregateCommentsByLine(fileComments, validPositionsByFile)
+	if len(inlineGroups) == 0 && len(fileComments) > 0 {
+		fallbackGroups, fallbackUnplaced := aggregateCommentsByHunk(fileComments, validPositionsByFile)
+		if len(fallbackGroups) > 0 {
+			fmt.Println("Inline placement fallback: line-level grouping produced no placeable comments; using hunk-level grouping.")
+			inlineGroups = fallbackGroups
+		}
+		if len(fallbackUnplaced) > 0 {
+			unplaced = append(unplaced, fallbackUnplaced...)
+		}
+	}
+	fmt.Printf("Inline findings pipeline: parsed=%d filtered=%d focused=%d grouped=%d\n",
+		len(parsed), len(rawComments), len(fileComments), len(inlineGroups))
+	originalCount := len(inlineGroups)
+	inlineGroups = prioritizeAndLimitInlineGroups(inlineGroups, maxComments)
+	if maxComments > 0 && originalCount > len(inlineGroups) {
+		fmt.Printf("Limiting inline comments to top %d by severity (from %d findings).\n", len(inlineGroups), originalCount)
+	}
+	return inlineSelection{Groups: inlineGroups, Unplaced: unplaced, Findings: len(fileComments)}
+}
+
 func filterInlineCandidates(
 	parsed []core.FileComment,
 	strictness string,
@@ -2688,11 +3747,12 @@ func limitToChangedFiles(comments []core.FileComment, validPositionsByFile map[s
 
 	out := make([]core.FileComment, 0, len(comments))
 	for _, c := range comments {
-		path := strings.TrimSpace(strings.TrimPrefix(c.FilePath, "./"))
+		path := core.NormalizePath(c.FilePath)
 		if path == "" {
 			continue
 		}
 		if _, ok := validPositionsByFile[path]; ok {
+			c.FilePath = path
 			out = append(out, c)
 		}
 	}
@@ -2739,7 +3799,7 @@ func applyInlineFilterMode(
 }
 
 func isInDiffContext(c core.FileComment, valid map[string]inlinePositions) bool {
-	path := strings.TrimSpace(strings.TrimPrefix(c.FilePath, "./"))
+	path := core.NormalizePath(c.FilePath)
 	fp, ok := valid[path]
 	if !ok {
 		return false
@@ -2761,7 +3821,7 @@ func isInDiffContext(c core.FileComment, valid map[string]inlinePositions) bool
 }
 
 func isOnAddedLine(c core.FileComment, valid map[string]inlinePositions) bool {
-	path := strings.TrimSpace(strings.TrimPrefix(c.FilePath, "./"))
+	path := core.NormalizePath(c.FilePath)
 	fp, ok := valid[path]
 	if !ok {
 		return false
@@ -2780,8 +3840,11 @@ func isOnAddedLine(c core.FileComment, valid map[string]inlinePositions) bool {
 	return false
 }
 
+// parseReviewContent parses a review reply. JSON is tried first when
+// structured output is on, or when the reply is a bare JSON object (tool-call
+// arguments surfaced by BestText).
 func parseReviewContent(content string, structuredOutput bool) core.ReviewResult {
-	if structuredOutput {
+	if structuredOutput || strings.HasPrefix(strings.TrimSpace(content), "{") {
 		if parsed, ok := core.ParseReviewResponseJSON(content); ok {
 			return parsed
 		}
@@ -2799,6 +3862,16 @@ func appendLineAnchorInstructions(prompt string) string {
 	return prompt + block
 }
 
+// structuredOutputPrefill is the start of the structured review object.
+// Providers with assistant prefill (Anthropic) continue from it, which keeps
+// the reply in the JSON schema instead of drifting into markdown.
+func structuredOutputPrefill(jsonMode bool) string {
+	if !jsonMode {
+		return ""
+	}
+	return `{"summary":`
+}
+
 func appendStructuredOutputInstructions(prompt string) string {
 	const block = `
 ## Output Format (STRICT JSON)
@@ -2824,6 +3897,12 @@ If no findings, return {"summary":"...","findings":[]}.
 type reviewBaseline struct {
 	HeadSHA  string            `json:"head_sha"`
 	FileSigs map[string]string `json:"file_sigs"`
+	// Verdict is the outcome of the review at HeadSHA (see reviewVerdict);
+	// empty for baselines written before verdicts were recorded.
+	Verdict string `json:"verdict,omitempty"`
+	// ReviewedSHAs lists every commit reviewed so far on the MR, used by
+	// --only-new-commits; empty for older baselines.
+	ReviewedSHAs []string `json:"reviewed_shas,omitempty"`
 }
 
 func buildFileSignatures(changes []diffparse.FileChange) map[string]string {
@@ -2956,9 +4035,15 @@ func isLikelyTypoComment(message string) bool {
 	return false
 }
 
+// detectDeterministicFindings runs the built-in deterministic checks.
 func detectDeterministicFindings(changes []diffparse.FileChange) []core.FileComment {
+	return detectDeterministicFindingsWithRules(changes, builtinDeterministicRules)
+}
+
+// detectDeterministicFindingsWithRules runs rules (see loadDeterministicRules)
+// plus the conflict-marker and signature checks on every changed file.
+func detectDeterministicFindingsWithRules(changes []diffparse.FileChange, rules []deterministicRule) []core.FileComment {
 	var out []core.FileComment
-	seen := map[string]struct{}{}
 	for _, c := range changes {
 		filePath := strings.TrimSpace(c.NewName)
 		if filePath == "" {
@@ -2967,36 +4052,79 @@ func detectDeterministicFindings(changes []diffparse.FileChange) []core.FileComm
 		if filePath == "" {
 			continue
 		}
-		for _, h := range c.Hunks {
-			for _, l := range h.Lines {
-				if l.Type != diffparse.LineAdded {
-					continue
+		out = append(out, detectRuleFindings(c, filePath, rules)...)
+		out = append(out, detectConflictMarkers(c, filePath)...)
+		out = append(out, detectSignatureChanges(c, filePath)...)
+	}
+	return out
+}
+
+// detectConflictMarkers reports merge-conflict markers on added lines. A
+// conflict is reported once, on its "<<<<<<<" line, and only when a later added
+// ">>>>>>>" closes it: a lone "=======" is a Markdown setext heading or reST
+// underline far more often than a leftover marker.
+func detectConflictMarkers(c diffparse.FileChange, filePath string) []core.FileComment {
+	var out []core.FileComment
+	openLine := 0
+	for _, h := range c.Hunks {
+		for _, l := range h.Lines {
+			if l.Type != diffparse.LineAdded {
+				continue
+			}
+			marker := conflictMarker(l.Content)
+			if marker == "" {
+				continue
+			}
+			line := l.NewLineNo
+			if line <= 0 {
+				line = h.NewStart
+			}
+			switch marker {
+			case "<<<<<<<":
+				if openLine == 0 {
+					openLine = line
 				}
-				lower := strings.ToLower(l.Content)
-				if strings.Contains(lower, "json_dencode") {
-					line := l.NewLineNo
-					if line <= 0 {
-						line = h.NewStart
-					}
-					key := strings.ToLower(filePath) + "|" + strconv.Itoa(line) + "|json_dencode"
-					if _, ok := seen[key]; ok {
-						continue
-					}
-					seen[key] = struct{}{}
-					out = append(out, core.FileComment{
-						FilePath: filePath,
-						Line:     line,
-						Kind:     "ISSUE",
-						Severity: "HIGH",
-						Message:  "Typo `json_dencode` likely intended as `json_encode`; this will trigger undefined function errors at runtime.",
-					})
+			case ">>>>>>>":
+				if openLine == 0 {
+					continue
 				}
+				out = append(out, core.FileComment{
+					FilePath: filePath,
+					Line:     openLine,
+					Kind:     "ISSUE",
+					Severity: "CRITICAL",
+					Message:  "Unresolved merge-conflict marker `<<<<<<<` committed; resolve the conflict and remove the `<<<<<<<`/`=======`/`>>>>>>>` markers.",
+				})
+				openLine = 0
 			}
 		}
 	}
 	return out
 }
 
+// conflictMarker returns the git conflict marker that content starts with, or
+// "" when it has none. A marker is exactly seven '<', '=' or '>' at the start
+// of the line; '<' and '>' may be followed by a space and a label, '=' must
+// stand alone.
+func conflictMarker(content string) string {
+	content = strings.TrimRight(content, " \t\r")
+	if len(content) < 7 {
+		return ""
+	}
+	marker := content[:7]
+	if marker != "<<<<<<<" && marker != "=======" && marker != ">>>>>>>" {
+		return ""
+	}
+	rest := content[7:]
+	if rest == "" {
+		return marker
+	}
+	if marker != "=======" && (rest[0] == ' ' || rest[0] == '\t') {
+		return marker
+	}
+	return ""
+}
+
 func hasAnyModifiedLines(changes []diffparse.FileChange) bool {
 	for _, c := range changes {
 		if c.IsBinary {
@@ -3056,7 +4184,7 @@ func isLowSignalInlineFinding(c core.FileComment, valid map[string]inlinePositio
 	if strings.Contains(msg, "`") {
 		return false
 	}
-	path := strings.TrimSpace(strings.TrimPrefix(c.FilePath, "./"))
+	path := core.NormalizePath(c.FilePath)
 	fp, ok := valid[path]
 	if !ok || len(fp.content) == 0 {
 		return false
@@ -3115,11 +4243,187 @@ func isMetaContextFinding(message string) bool {
 	return false
 }
 
-func buildMRFormattedDiffs(review *handlers.MRReview, serenaMode string, contextLines, maxTokens int) (string, error) {
-	repoPath := resolveMRRepoPath()
-	if repoPath == "" {
+// resolveEnrichOptions builds the over-budget strategy for context enrichment
+// from review.budget_strategy and the review.budget_*_globs path lists.
+func resolveEnrichOptions(conf config.Config, strategy string) diffparse.EnrichOptions {
+	opts := diffparse.EnrichOptions{BudgetStrategy: diffparse.NormalizeBudgetStrategy(strategy)}
+	if conf.Viper != nil {
+		opts.PriorityGlobs = conf.Viper.GetStringSlice("review.budget_priority_globs")
+		opts.LowPriorityGlobs = conf.Viper.GetStringSlice("review.budget_low_priority_globs")
+	}
+	return opts
+}
+
+// buildMRFormattedDiffs builds the prompt diff section for an MR. maxTokens is
+// first clamped to what fits in model's context window. Files matching
+// ignoreGlobs or carrying a generated-code header are left out of the
+// prompt; a nil ignoreGlobs disables skipping. With includeStats, a compact
+// change-stats table for the reviewed files is prepended; its tokens come
+// out of maxTokens before the diff is fitted, and it is left out when it
+// alone would use up the budget.
+func buildMRFormattedDiffs(review *handlers.MRReview, model, serenaMode string, contextLines, maxTokens int, enrichOpts diffparse.EnrichOptions, ignoreGlobs []string, includeStats bool) (string, error) {
+	maxTokens = clampDiffBudgetToModel(model, maxTokens)
+	view := *review
+	if ignoreGlobs != nil {
+		kept, skipped := filterGeneratedChanges(review.Changes, ignoreGlobs)
+		if len(skipped) > 0 {
+			fmt.Printf("Skipped %d generated/vendored files: %s\n", len(skipped), strings.Join(skipped, ", "))
+		}
+		view.Changes = kept
+	}
+	var header string
+	if includeStats {
+		header = diffStatsHeader(view.Changes) + "\n\n"
+		if maxTokens > 0 {
+			// Same 4-chars-per-token estimate as fitDiffSectionsToBudget.
+			if reserve := (len(header) + 3) / 4; reserve < maxTokens {
+				maxTokens -= reserve
+			} else {
+				header = ""
+			}
+		}
+	}
+	formatted, err := buildFormattedDiffsForRepo(&view, resolveMRRepoPath(), serenaMode, contextLines, maxTokens, enrichOpts)
+	review.Enrichment = view.Enrichment
+	if err != nil {
+		return formatted, err
+	}
+	return header + formatted, nil
+}
+
+// diffStatsHeader renders a compact per-file additions/deletions table with
+// detected languages, plus a totals line.
+func diffStatsHeader(changes []diffparse.FileChange) string {
+	var sb strings.Builder
+	sb.WriteString("## Change Stats\n\n| File | Language | + | - |\n|---|---|---|---|\n")
+	additions, deletions := 0, 0
+	langFiles := map[string]int{}
+	for _, fc := range changes {
+		name := changeFileName(fc)
+		lang := diffparse.DetectLanguage(name)
+		if lang != "" {
+			langFiles[lang]++
+		} else {
+			lang = "-"
+		}
+		additions += fc.Stats.Additions
+		deletions += fc.Stats.Deletions
+		fmt.Fprintf(&sb, "| %s | %s | %d | %d |\n", name, lang, fc.Stats.Additions, fc.Stats.Deletions)
+	}
+	langs := make([]string, 0, len(langFiles))
+	for lang := range langFiles {
+		langs = append(langs, lang)
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		if langFiles[langs[i]] != langFiles[langs[j]] {
+			return langFiles[langs[i]] > langFiles[langs[j]]
+		}
+		return langs[i] < langs[j]
+	})
+	for i, lang := range langs {
+		langs[i] = fmt.Sprintf("%s (%d)", lang, langFiles[lang])
+	}
+	fmt.Fprintf(&sb, "\nTotal: %d files changed, +%d/-%d", len(changes), additions, deletions)
+	if len(langs) > 0 {
+		fmt.Fprintf(&sb, "; languages: %s", strings.Join(langs, ", "))
+	}
+	return sb.String()
+}
+
+// resolveIgnoreGlobs returns review.ignore_globs (core.DefaultGeneratedGlobs
+// when unset or not a list), or nil when --no-skip-generated is given. An
+// explicit empty list skips nothing.
+func resolveIgnoreGlobs(cmd *cobra.Command, conf config.Config) []string {
+	if noSkip, _ := cmd.Flags().GetBool("no-skip-generated"); noSkip {
+		return nil
+	}
+	if conf.Viper != nil && conf.Viper.IsSet("review.ignore_globs") {
+		if globs := conf.Viper.GetStringSlice("review.ignore_globs"); globs != nil {
+			return globs
+		}
+	}
+	return core.DefaultGeneratedGlobs
+}
+
+// applyPrevIgnore drops changes matched by root/.prevignore. The loaded
+// rules are returned so findings on ignored files can be dropped as well.
+func applyPrevIgnore(root string, changes []diffparse.FileChange) ([]diffparse.FileChange, *core.IgnoreRules) {
+	rules, err := core.LoadIgnoreRules(root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to read %s: %v\n", core.PrevIgnoreFile, err)
+		return changes, nil
+	}
+	if rules.Empty() {
+		return changes, rules
+	}
+	kept := make([]diffparse.FileChange, 0, len(changes))
+	for _, fc := range changes {
+		if rules.Match(changeFileName(fc)) {
+			continue
+		}
+		kept = append(kept, fc)
+	}
+	if ignored := len(changes) - len(kept); ignored > 0 {
+		fmt.Printf("Ignored %d files via %s\n", ignored, core.PrevIgnoreFile)
+	}
+	return kept, rules
+}
+
+// dropPrevIgnoredFindings removes findings on files matched by rules.
+func dropPrevIgnoredFindings(comments []core.FileComment, rules *core.IgnoreRules) []core.FileComment {
+	if rules.Empty() {
+		return comments
+	}
+	out := comments[:0]
+	for _, c := range comments {
+		if !rules.Match(c.FilePath) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// filterGeneratedChanges splits changes into reviewable files and the names of
+// generated or vendored ones.
+func filterGeneratedChanges(changes []diffparse.FileChange, globs []string) ([]diffparse.FileChange, []string) {
+	kept := make([]diffparse.FileChange, 0, len(changes))
+	var skipped []string
+	for _, fc := range changes {
+		name := fc.NewName
+		if name == "" {
+			name = fc.OldName
+		}
+		if core.IsGeneratedFileWithGlobs(name, leadingFileLines(fc, 10), globs) {
+			skipped = append(skipped, name)
+			continue
+		}
+		kept = append(kept, fc)
+	}
+	return kept, skipped
+}
+
+// leadingFileLines returns the first n lines of the new file as far as the
+// diff shows them, i.e. only when a hunk covers the top of the file.
+func leadingFileLines(fc diffparse.FileChange, n int) []string {
+	var out []string
+	for _, h := range fc.Hunks {
+		for _, l := range h.Lines {
+			if l.Type == diffparse.LineDeleted || l.NewLineNo <= 0 || l.NewLineNo > n {
+				continue
+			}
+			out = append(out, l.Content)
+		}
+	}
+	return out
+}
+
+// buildFormattedDiffsForRepo enriches review.Changes from the checkout at
+// repoPath, diffing review.MR.TargetBranch against review.MR.SourceBranch.
+func buildFormattedDiffsForRepo(review *handlers.MRReview, repoPath, serenaMode string, contextLines, maxTokens int, enrichOpts diffparse.EnrichOptions) (string, error) {
+	if repoPath == "" {
 		fmt.Println("Serena: skipped (repository path unavailable); using line-based diff context.")
-		return diffparse.FormatForReview(review.Changes), nil
+		review.Enrichment = rawEnrichmentOutcomes(review.Changes, "repository path unavailable")
+		return rawDiffWithinBudget(review.Changes, maxTokens, enrichOpts), nil
 	}
 
 	var serenaClient *serena.Client
@@ -3141,7 +4445,10 @@ func buildMRFormattedDiffs(review *handlers.MRReview, serenaMode string, context
 		}
 	}
 
-	enriched, err := diffparse.EnrichFileChanges(
+	if enrichOpts.HeadRef == "" {
+		enrichOpts.HeadRef = review.MR.DiffRefs.HeadSHA
+	}
+	enriched, err := diffparse.EnrichFileChangesWithOptions(
 		review.Changes,
 		repoPath,
 		review.MR.TargetBranch,
@@ -3149,67 +4456,466 @@ func buildMRFormattedDiffs(review *handlers.MRReview, serenaMode string, context
 		contextLines,
 		maxTokens,
 		serenaClient,
+		enrichOpts,
 	)
 	if err != nil {
 		if serenaMode == "on" {
 			return "", fmt.Errorf("failed to enrich MR changes with Serena context: %w", err)
 		}
 		fmt.Printf("Serena/context enrichment failed (%v); falling back to line-based diff context.\n", err)
-		return diffparse.FormatForReview(review.Changes), nil
+		review.Enrichment = rawEnrichmentOutcomes(review.Changes, err.Error())
+		return rawDiffWithinBudget(review.Changes, maxTokens, enrichOpts), nil
 	}
+	review.Enrichment = diffparse.EnrichmentOutcomes(enriched)
+	fmt.Println(diffparse.FormatEnrichmentSummary(review.Enrichment))
 
-	var sb strings.Builder
-	for i, efc := range enriched {
-		if i > 0 {
-			sb.WriteString("\n")
+	sections := make([]diffSection, 0, len(enriched))
+	for _, efc := range enriched {
+		name := efc.NewName
+		if name == "" {
+			name = efc.OldName
 		}
-		sb.WriteString(diffparse.FormatEnrichedForReview(efc))
+		sections = append(sections, diffSection{
+			Path:     name,
+			Text:     diffparse.FormatEnrichedForReview(efc),
+			Priority: budgetFilePriority(name, enrichOpts),
+		})
 	}
-	out := strings.TrimSpace(sb.String())
+	out := formatDiffSectionsWithinBudget(sections, maxTokens)
 	if out == "" {
-		return diffparse.FormatForReview(review.Changes), nil
+		return rawDiffWithinBudget(review.Changes, maxTokens, enrichOpts), nil
 	}
 	return out, nil
 }
 
+// rawDiffWithinBudget formats changes without enrichment, omitting files
+// that do not fit maxTokens in the order opts configures.
+func rawDiffWithinBudget(changes []diffparse.FileChange, maxTokens int, opts diffparse.EnrichOptions) string {
+	sections := make([]diffSection, 0, len(changes))
+	for _, fc := range changes {
+		sections = append(sections, diffSection{
+			Path:     changeFileName(fc),
+			Text:     diffparse.FormatForReview([]diffparse.FileChange{fc}),
+			Priority: budgetFilePriority(changeFileName(fc), opts),
+		})
+	}
+	return formatDiffSectionsWithinBudget(sections, maxTokens)
+}
+
+// formatDiffSectionsWithinBudget joins sections via fitDiffSectionsToBudget
+// and reports omitted files.
+func formatDiffSectionsWithinBudget(sections []diffSection, maxTokens int) string {
+	out, omitted := fitDiffSectionsToBudget(sections, maxTokens)
+	if len(omitted) > 0 {
+		fmt.Printf("Token budget: omitted %d files to fit max_tokens=%d: %s\n", len(omitted), maxTokens, strings.Join(omitted, ", "))
+	}
+	return out
+}
+
+func rawEnrichmentOutcomes(changes []diffparse.FileChange, reason string) []diffparse.EnrichmentOutcome {
+	out := make([]diffparse.EnrichmentOutcome, 0, len(changes))
+	for _, c := range changes {
+		out = append(out, diffparse.EnrichmentOutcome{
+			File:    changeFileName(c),
+			Outcome: diffparse.EnrichmentRawFallback,
+			Reason:  reason,
+		})
+	}
+	return out
+}
+
 func runReviewPasses(ctx context.Context, p provider.AIProvider, basePrompt string, passes int) (string, error) {
+	return runReviewPassesWithOptions(ctx, p, basePrompt, passes, reviewPassOptions{})
+}
+
+type reviewPassOptions struct {
+	OnContentFilter string // fail|skip|retry_sanitized
+	Mode            string // refine|ensemble
+	JSONMode        bool   // request native JSON output (structured_output)
+
+	// SystemPrompt, when set (review.system_prompt), replaces the built-in
+	// system prompt of every review pass.
+	SystemPrompt string
+
+	// EarlyStopOnClean ends refine passes once two consecutive passes yield
+	// no parseable findings.
+	EarlyStopOnClean bool
+
+	// Stream, when set, receives refine-pass output as it arrives. Ensemble
+	// passes and cache hits are never streamed.
+	Stream io.Writer
+
+	// Usage, when set, accumulates the token usage of every provider call.
+	Usage *tokenUsage
+
+	// Samples asks for that many choices per pass (review.samples); the
+	// alternates land in Choices. Streamed passes yield a single choice.
+	Samples int
+	Choices *sampledChoices
+
+	// Cache, when set, short-circuits the provider call for a prompt that was
+	// already reviewed against the same diff signature.
+	Cache          *reviewcache.Cache
+	CacheKey       string
+	CacheSignature string
+}
+
+// Built-in system prompts, replaced by review.system_prompt and
+// review.reply_system_prompt when those are set.
+const (
+	defaultRefineSystemPrompt      = "You are a helpful assistant and source code reviewer. Keep continuity across review passes, preserve valid findings, and improve precision on each pass."
+	defaultEnsembleSystemPrompt    = "You are a helpful assistant and source code reviewer. Review the merge request independently and report every valid finding."
+	defaultThreadReplySystemPrompt = "You are an expert code reviewer replying in a merge request discussion. Be accurate, sharp, and direct. Keep the default reply concise, with no fluff and no emojis. Expand only when the latest request explicitly asks for more detail. Preserve thread continuity and tie your reply to the available hunk context."
+	defaultNoteReplySystemPrompt   = "You are an expert code reviewer replying to a merge request comment. Be accurate, sharp, and direct. Keep the default reply concise, with no fluff and no emojis. Expand only when the latest request explicitly asks for more detail. Stay scoped to the MR context and avoid boilerplate."
+)
+
+// systemPromptOr returns override when it is non-blank, else def.
+func systemPromptOr(override, def string) string {
+	if strings.TrimSpace(override) != "" {
+		return strings.TrimSpace(override)
+	}
+	return def
+}
+
+const (
+	passModeRefine   = "refine"
+	passModeEnsemble = "ensemble"
+
+	// ensembleMaxWorkers bounds concurrent provider calls in ensemble mode.
+	ensembleMaxWorkers = 3
+)
+
+func normalizePassMode(mode string) string {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case passModeEnsemble:
+		return passModeEnsemble
+	default:
+		return passModeRefine
+	}
+}
+
+func runReviewPassesWithOptions(ctx context.Context, p provider.AIProvider, basePrompt string, passes int, opts reviewPassOptions) (string, error) {
+	outputs, err := collectReviewPassOutputs(ctx, p, basePrompt, passes, opts)
+	if err != nil || len(outputs) == 0 {
+		return "", err
+	}
+	return outputs[len(outputs)-1], nil
+}
+
+// collectReviewPassOutputs runs the review passes and returns the content of
+// every successful pass in order. Refine mode runs passes sequentially in one
+// conversation; ensemble mode runs independent passes concurrently.
+func collectReviewPassOutputs(ctx context.Context, p provider.AIProvider, basePrompt string, passes int, opts reviewPassOptions) ([]string, error) {
+	if opts.Cache == nil {
+		return collectProviderPassOutputs(ctx, p, basePrompt, passes, opts)
+	}
+	if outputs, samples, ok := opts.Cache.GetWithSamples(opts.CacheKey, opts.CacheSignature); ok {
+		fmt.Printf("Review cache: hit (key=%s)\n", opts.CacheKey)
+		opts.Choices.restore(samples)
+		return outputs, nil
+	}
+	fmt.Printf("Review cache: miss (key=%s)\n", opts.CacheKey)
+	outputs, err := collectProviderPassOutputs(ctx, p, basePrompt, passes, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := opts.Cache.PutWithSamples(opts.CacheKey, opts.CacheSignature, outputs, opts.Choices.outputs()); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write review cache: %v\n", err)
+	}
+	return outputs, nil
+}
+
+func collectProviderPassOutputs(ctx context.Context, p provider.AIProvider, basePrompt string, passes int, opts reviewPassOptions) ([]string, error) {
 	if passes <= 0 {
 		passes = 1
 	}
-	conv := provider.NewConversation(p, provider.ConversationOptions{
-		SystemPrompt: "You are a helpful assistant and source code reviewer. Keep continuity across review passes, preserve valid findings, and improve precision on each pass.",
-	})
+	if passes > 1 && normalizePassMode(opts.Mode) == passModeEnsemble {
+		return collectEnsemblePassOutputs(ctx, p, basePrompt, passes, opts)
+	}
+	onContentFilter := normalizeContentFilterMode(opts.OnContentFilter)
+	var outputs []string
+	// Only the alternates of the pass that ends the run are kept, so other
+	// passes do not pay for samples. That is the last pass, or with
+	// EarlyStopOnClean any pass following a clean one, since it may complete
+	// the clean streak; a later pass's set() drops them if the run goes on.
+	passSamples := func(pass int) int {
+		if pass == passes {
+			return opts.Samples
+		}
+		if opts.EarlyStopOnClean && pass > 1 && len(outputs) == pass-1 &&
+			len(parseReviewContent(outputs[pass-2], opts.JSONMode).FileComments) == 0 {
+			return opts.Samples
+		}
+		return 0
+	}
+	newConv := func(pass int) *provider.Conversation {
+		return provider.NewConversation(p, provider.ConversationOptions{
+			SystemPrompt:     systemPromptOr(opts.SystemPrompt, defaultRefineSystemPrompt),
+			JSONMode:         opts.JSONMode,
+			Samples:          passSamples(pass),
+			AssistantPrefill: structuredOutputPrefill(opts.JSONMode),
+		})
+	}
+	conv := newConv(1)
 	currentPrompt := basePrompt
-	latest := ""
 	for pass := 1; pass <= passes; pass++ {
 		fmt.Printf("Review pass %d/%d...\n", pass, passes)
-		content, err := completeConversationPrompt(ctx, conv, currentPrompt)
+		conv.SetSamples(passSamples(pass))
+		resp, err := completeReviewPass(ctx, conv, currentPrompt, opts.Stream)
+		opts.Usage.add(resp)
+		opts.Choices.set(resp)
+		if isContentFilterResult(resp, err) {
+			trigger := "MR review prompt (diff content)"
+			if pass > 1 {
+				trigger = "re-review prompt (conversation history)"
+			}
+			fmt.Fprintf(os.Stderr, "Warning: provider content filter triggered on pass %d/%d by %s.\n", pass, passes, trigger)
+			if onContentFilter == contentFilterFail {
+				if err == nil {
+					err = fmt.Errorf("provider content filter blocked review pass %d", pass)
+				}
+				return nil, err
+			}
+			if pass == 1 && onContentFilter == contentFilterRetrySanitized {
+				sanitized, redacted := sanitizePromptForContentFilter(basePrompt)
+				fmt.Printf("Retrying pass 1 with sanitized prompt (%d diff spans redacted).\n", redacted)
+				conv = newConv(pass)
+				resp, err = completeConversationResponse(ctx, conv, sanitized)
+				opts.Usage.add(resp)
+				opts.Choices.set(resp)
+				if err == nil && !isContentFilterResult(resp, nil) && strings.TrimSpace(resp.BestText()) != "" {
+					outputs = append(outputs, resp.BestText())
+					printPassProvider(p, resp, pass, passes)
+					if pass < passes {
+						currentPrompt = buildReReviewPrompt(pass+1, passes)
+					}
+					continue
+				}
+				fmt.Fprintln(os.Stderr, "Warning: sanitized retry did not produce a review; continuing without it.")
+			}
+			if len(outputs) == 0 {
+				fmt.Fprintln(os.Stderr, "Warning: no review content available after content filter; continuing with empty review.")
+			} else {
+				fmt.Printf("Continuing with review output from pass %d.\n", pass-1)
+			}
+			return outputs, nil
+		}
 		if err != nil {
-			return "", err
+			return nil, err
 		}
+		content := resp.BestText()
 		if strings.TrimSpace(content) == "" {
-			return "", fmt.Errorf("no response from AI provider on pass %d", pass)
+			return nil, fmt.Errorf("no response from AI provider on pass %d", pass)
+		}
+		outputs = append(outputs, content)
+		printPassProvider(p, resp, pass, passes)
+		if pass < passes && opts.EarlyStopOnClean && cleanPassStreak(outputs, opts.JSONMode) {
+			fmt.Printf("Early stop: passes %d and %d found no issues; skipping the remaining %d passes.\n", pass-1, pass, passes-pass)
+			break
 		}
-		latest = content
 		if pass < passes {
 			currentPrompt = buildReReviewPrompt(pass+1, passes)
 		}
 	}
-	return latest, nil
+	return outputs, nil
+}
+
+// printPassProvider names the provider that answered a pass when a fallback
+// chain is configured, since it can change mid-run. The name comes from the
+// response itself, so concurrent passes report their own provider.
+func printPassProvider(p provider.AIProvider, resp *provider.CompletionResponse, pass, passes int) {
+	if _, ok := p.(*provider.FallbackProvider); !ok || resp == nil || resp.Provider == "" {
+		return
+	}
+	fmt.Printf("Pass %d/%d served by %s\n", pass, passes, resp.Provider)
+}
+
+// cleanPassStreak reports whether the last two pass outputs both parse to
+// zero findings.
+func cleanPassStreak(outputs []string, structured bool) bool {
+	if len(outputs) < 2 {
+		return false
+	}
+	for _, out := range outputs[len(outputs)-2:] {
+		if len(parseReviewContent(out, structured).FileComments) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// collectEnsemblePassOutputs runs passes independent reviews of the same base
+// prompt on a bounded worker pool. Outputs keep pass order; passes blocked by
+// the content filter are dropped unless the mode is fail.
+func collectEnsemblePassOutputs(ctx context.Context, p provider.AIProvider, basePrompt string, passes int, opts reviewPassOptions) ([]string, error) {
+	onContentFilter := normalizeContentFilterMode(opts.OnContentFilter)
+	workers := minInt(passes, ensembleMaxWorkers)
+	fmt.Printf("Running %d independent review passes (ensemble, %d concurrent)...\n", passes, workers)
+
+	type passResult struct {
+		content string
+		err     error
+	}
+	results := make([]passResult, passes)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i].content, results[i].err = runEnsemblePass(ctx, p, basePrompt, i+1, passes, onContentFilter, opts)
+			}
+		}()
+	}
+	for i := 0; i < passes; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var outputs []string
+	for _, r := range results {
+		if r.err != nil {
+			return nil, r.err
+		}
+		if strings.TrimSpace(r.content) != "" {
+			outputs = append(outputs, r.content)
+		}
+	}
+	if len(outputs) == 0 {
+		return nil, fmt.Errorf("no response from AI provider in %d ensemble passes", passes)
+	}
+	return outputs, nil
+}
+
+func runEnsemblePass(ctx context.Context, p provider.AIProvider, basePrompt string, pass, passes int, onContentFilter string, opts reviewPassOptions) (string, error) {
+	newConv := func() *provider.Conversation {
+		return provider.NewConversation(p, provider.ConversationOptions{
+			SystemPrompt:     systemPromptOr(opts.SystemPrompt, defaultEnsembleSystemPrompt),
+			JSONMode:         opts.JSONMode,
+			Samples:          opts.Samples,
+			AssistantPrefill: structuredOutputPrefill(opts.JSONMode),
+		})
+	}
+	conv := newConv()
+	resp, err := completeConversationResponse(ctx, conv, basePrompt)
+	opts.Usage.add(resp)
+	if !isContentFilterResult(resp, err) {
+		opts.Choices.add(resp)
+	}
+	if isContentFilterResult(resp, err) {
+		fmt.Fprintf(os.Stderr, "Warning: provider content filter triggered on ensemble pass %d/%d.\n", pass, passes)
+		switch onContentFilter {
+		case contentFilterFail:
+			if err == nil {
+				err = fmt.Errorf("provider content filter blocked review pass %d", pass)
+			}
+			return "", err
+		case contentFilterRetrySanitized:
+			sanitized, _ := sanitizePromptForContentFilter(basePrompt)
+			conv = newConv()
+			resp, err = completeConversationResponse(ctx, conv, sanitized)
+			opts.Usage.add(resp)
+			if err != nil || isContentFilterResult(resp, nil) {
+				return "", nil
+			}
+			opts.Choices.add(resp)
+			printPassProvider(p, resp, pass, passes)
+			return resp.BestText(), nil
+		default:
+			return "", nil
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	printPassProvider(p, resp, pass, passes)
+	return resp.BestText(), nil
+}
+
+// mergeEnsembleFindings unions per-pass findings, de-duplicated by inlineKey.
+// The first finding for a location wins, upgraded to the highest severity any
+// pass reported there.
+func mergeEnsembleFindings(passFindings [][]core.FileComment) []core.FileComment {
+	var out []core.FileComment
+	index := make(map[string]int)
+	for _, findings := range passFindings {
+		for _, fc := range findings {
+			key := inlineKey(fc.FilePath, fc.Line, false, fc.Message)
+			if i, ok := index[key]; ok {
+				if severityRank(fc.Severity) > severityRank(out[i].Severity) {
+					out[i].Severity = fc.Severity
+				}
+				continue
+			}
+			index[key] = len(out)
+			out = append(out, fc)
+		}
+	}
+	return out
 }
 
 func completeConversationPrompt(parent context.Context, conv *provider.Conversation, prompt string) (string, error) {
+	resp, err := completeConversationResponse(parent, conv, prompt)
+	if err != nil {
+		return "", err
+	}
+	return resp.BestText(), nil
+}
+
+func completeConversationResponse(parent context.Context, conv *provider.Conversation, prompt string) (*provider.CompletionResponse, error) {
 	ctx, cancel := context.WithTimeout(parent, 120*time.Second)
 	defer cancel()
 
 	resp, err := conv.Complete(ctx, prompt)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if resp == nil {
+		return &provider.CompletionResponse{}, nil
+	}
+	return resp, nil
+}
+
+// completeReviewPass runs one review pass, echoing the reply to stream as it
+// arrives when stream is set.
+func completeReviewPass(parent context.Context, conv *provider.Conversation, prompt string, stream io.Writer) (*provider.CompletionResponse, error) {
+	if stream == nil {
+		return completeConversationResponse(parent, conv, prompt)
+	}
+	ctx, cancel := context.WithTimeout(parent, 120*time.Second)
+	defer cancel()
+
+	resp, err := conv.CompleteStream(ctx, prompt, func(chunk string) {
+		fmt.Fprint(stream, chunk)
+	})
+	fmt.Fprintln(stream)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return &provider.CompletionResponse{}, nil
 	}
-	return resp.Content, nil
+	return resp, nil
 }
 
-func runReviewPassesDryRun(conf config.Config, basePrompt string, passes int) {
+// countingWriter counts bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += n
+	return n, err
+}
+
+// runReviewPassesDryRun runs the review passes and prints the result. When
+// savePath is set, the prompt and every pass's raw output are also written
+// there with saveDryRunArtifacts. It returns the final pass's output.
+func runReviewPassesDryRun(conf config.Config, basePrompt string, passes int, opts reviewPassOptions, savePath string) string {
 	p, err := resolveProvider(conf)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error resolving provider: %v\n", err)
@@ -3221,12 +4927,90 @@ func runReviewPassesDryRun(conf config.Config, basePrompt string, passes int) {
 		model = info.DefaultModel
 	}
 	fmt.Printf("Model: provider=%s model=%s\n", info.Name, model)
-	content, err := runReviewPasses(context.Background(), p, basePrompt, passes)
+	p = withFallbackProviders(conf, p)
+	var streamed *countingWriter
+	if opts.Stream != nil {
+		streamed = &countingWriter{w: opts.Stream}
+		opts.Stream = streamed
+	}
+	if opts.Usage == nil {
+		opts.Usage = &tokenUsage{}
+	}
+	if savePath == "" {
+		// Alternates are only kept as saved artifacts in a dry run.
+		opts.Samples = 0
+		opts.Choices = nil
+	}
+	outputs, err := collectReviewPassOutputs(context.Background(), p, basePrompt, passes, opts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error from AI provider: %v\n", err)
 		os.Exit(1)
 	}
-	fmt.Print(renders.RenderMarkdown(content))
+	content := ""
+	if len(outputs) > 0 {
+		content = outputs[len(outputs)-1]
+	}
+	if streamed == nil || streamed.n == 0 {
+		fmt.Print(renders.RenderMarkdown(content))
+	}
+	printTokenUsage(os.Stdout, opts.Usage, resolveTokenPricing(conf))
+	if savePath != "" {
+		written, err := saveDryRunArtifacts(savePath, basePrompt, outputs, opts.Choices.outputs())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to save dry-run artifacts: %v\n", err)
+			return content
+		}
+		fmt.Printf("Saved prompt and %d raw pass outputs to %s\n", len(outputs), written)
+	}
+	return content
+}
+
+// saveDryRunArtifacts writes the assembled prompt and the raw output of each
+// pass (and any sampled alternates) to path. A path that is an existing
+// directory or ends in a separator gets one file per artifact (prompt.txt,
+// pass-01.txt, sample-01.txt, ...); any other path gets a single file with
+// delimited sections. It returns the path written.
+func saveDryRunArtifacts(path, prompt string, outputs, samples []string) (string, error) {
+	asDir := strings.HasSuffix(path, "/") || strings.HasSuffix(path, string(os.PathSeparator))
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		asDir = true
+	}
+	if asDir {
+		if err := os.MkdirAll(path, 0o755); err != nil {
+			return "", err
+		}
+		files := map[string]string{"prompt.txt": prompt}
+		for i, out := range outputs {
+			files[fmt.Sprintf("pass-%02d.txt", i+1)] = out
+		}
+		for i, out := range samples {
+			files[fmt.Sprintf("sample-%02d.txt", i+1)] = out
+		}
+		for name, body := range files {
+			if err := os.WriteFile(filepath.Join(path, name), []byte(body), 0o644); err != nil {
+				return "", err
+			}
+		}
+		return path, nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "===== PROMPT =====\n%s\n", prompt)
+	for i, out := range outputs {
+		fmt.Fprintf(&b, "\n===== PASS %d/%d RAW OUTPUT =====\n%s\n", i+1, len(outputs), out)
+	}
+	for i, out := range samples {
+		fmt.Fprintf(&b, "\n===== SAMPLE %d RAW OUTPUT =====\n%s\n", i+1, out)
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return "", err
+		}
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
 }
 
 func buildReReviewPrompt(pass, total int) string {
@@ -3268,20 +5052,44 @@ Requirements:
 	return content, nil
 }
 
+// resolveMRContextSource returns a GitLab MCP client when GITLAB_MCP_URL is
+// set for a GitLab review, or nil. GITLAB_MCP_TOKEN is sent as a bearer token.
+func resolveMRContextSource(vcsName string, getenv func(string) string) vcs.MRContextSource {
+	if !strings.EqualFold(strings.TrimSpace(vcsName), "gitlab") {
+		return nil
+	}
+	url := strings.TrimSpace(getenv("GITLAB_MCP_URL"))
+	if url == "" {
+		return nil
+	}
+	return gitlabmcp.New(url, getenv("GITLAB_MCP_TOKEN"))
+}
+
 func detectVCSContextStatus(
 	vcsName string,
+	usage handlers.MRContextUsage,
 	lookPath func(string) (string, error),
 	getenv func(string) string,
 ) string {
 	if strings.EqualFold(strings.TrimSpace(vcsName), "github") {
 		return "GitHub context: using standard GitHub API + Serena/local context enrichment."
 	}
-	if url := strings.TrimSpace(getenv("GITLAB_MCP_URL")); url != "" {
-		return fmt.Sprintf("GitLab MCP: configured via GITLAB_MCP_URL (%s).", url)
+	url := strings.TrimSpace(getenv("GITLAB_MCP_URL"))
+	if usage.Source != "" {
+		switch {
+		case usage.Metadata && usage.Diffs:
+			return fmt.Sprintf("GitLab MCP: MR metadata and diffs fetched via GITLAB_MCP_URL (%s).", url)
+		case usage.Fallback != "" && usage.Metadata:
+			return fmt.Sprintf("GitLab MCP: MR metadata fetched via GITLAB_MCP_URL (%s); diffs fell back to the standard GitLab API.", url)
+		case usage.Fallback != "":
+			return fmt.Sprintf("GitLab MCP: GITLAB_MCP_URL (%s) failed; using standard GitLab API + Serena/local context enrichment.", url)
+		case usage.Metadata:
+			return fmt.Sprintf("GitLab MCP: MR metadata fetched via GITLAB_MCP_URL (%s); diffs from the local git checkout.", url)
+		}
 	}
 	for _, bin := range []string{"gitlab-mcp", "glab-mcp"} {
 		if p, err := lookPath(bin); err == nil && strings.TrimSpace(p) != "" {
-			return fmt.Sprintf("GitLab MCP: detected local server binary (%s).", bin)
+			return fmt.Sprintf("GitLab MCP: local server binary (%s) found but unused; set GITLAB_MCP_URL to route MR context through it.", bin)
 		}
 	}
 	return "GitLab MCP: not detected/configured; using standard GitLab API + Serena/local context enrichment."
@@ -3298,6 +5106,205 @@ func resolveMRRepoPath() string {
 	return p
 }
 
+// newMRSummaryCmd posts a single high-level review summary note. It skips
+// the inline, carry-over, thread-command and memory machinery of mr review.
+func newMRSummaryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "summary <project_id> <mr_iid>",
+		Short:   "Post only an AI summary note on a Merge Request",
+		Example: "prev mr summary my-group/my-project 42\nprev mr summary my-group/my-project 42 --dry-run",
+		Args:    cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			conf := config.NewDefaultConfig()
+			applyFlags(cmd, &conf)
+			applyMarkerNamespace(resolveMarkerNamespace(conf))
+
+			projectID := args[0]
+			mrIID, err := strconv.ParseInt(args[1], 10, 64)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: invalid MR IID %q: %v\n", args[1], err)
+				os.Exit(1)
+			}
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			vcsProvider, err := resolveVCSProvider(cmd)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			var notes []vcs.MRNote
+			if !dryRun {
+				notes, err = vcsProvider.ListMRNotes(cmd.Context(), projectID, mrIID)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to fetch MR notes: %v\n", err)
+				}
+				if hasTopLevelMarker(notes, prevSummaryMarker) {
+					fmt.Println("Summary already posted; skipping duplicate summary note.")
+					return
+				}
+			}
+
+			review, err := handlers.ExtractMRHandlerWithOptions(
+				cmd.Context(), vcsProvider, projectID, mrIID, conf.Strictness,
+				handlers.MRExtractOptions{
+					DiffSource: resolveMRStringSetting(
+						cmd, "mr-diff-source", conf,
+						[]string{"review.mr_diff_source"},
+						"auto",
+					),
+					RepoPath: resolveMRRepoPath(),
+				},
+			)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			review.Changes, _ = applyPrevIgnore(guidelineRootForMR(), review.Changes)
+			if len(review.Changes) == 0 {
+				fmt.Printf("All changed files are excluded by %s; nothing to summarize.\n", core.PrevIgnoreFile)
+				return
+			}
+
+			strictness := resolveMRStringSetting(
+				cmd, "strictness", conf,
+				[]string{"review.strictness", "strictness"},
+				conf.Strictness,
+			)
+			conventions := resolveMRStringSliceSetting(conf, []string{"review.conventions.labels"}, defaultConventionLabels)
+			guidelines := mergeGuidelines(
+				resolveMRStringSetting(cmd, "", conf, []string{"review.guidelines"}, ""),
+				repoGuidelineSection(guidelineRootForMR()),
+				languageGuidelineSection(review.Changes, resolveLanguageGuidelines(conf)),
+			)
+			repoContext := repoContextSection(
+				guidelineRootForMR(),
+				resolveMRStringSetting(cmd, "repo-context-file", conf, []string{"review.repo_context_file"}, ""),
+				resolveMRIntSetting(cmd, "repo-context-max-chars", conf, []string{"review.repo_context_max_chars"}, defaultRepoContextMaxChars),
+			)
+			formattedDiffs, err := buildMRFormattedDiffs(
+				review,
+				reviewModelName(conf),
+				resolveMRStringSetting(cmd, "serena", conf, []string{"review.serena_mode", "serena_mode"}, "auto"),
+				resolveMRIntSetting(cmd, "context", conf, []string{"review.context_lines"}, 10),
+				diffTokenBudget(resolveMRIntSetting(cmd, "max-tokens", conf, []string{"review.max_tokens"}, 80000), repoContext),
+				resolveEnrichOptions(conf, resolveMRStringSetting(cmd, "", conf, []string{"review.budget_strategy"}, "")),
+				resolveIgnoreGlobs(cmd, conf),
+				resolveMRBoolSetting(cmd, "include-diff-stats", conf, []string{"review.include_diff_stats_in_prompt"}, false),
+			)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			prompt := core.BuildMRReviewPromptWithOptions(
+				review.MR.Title,
+				review.MR.Description,
+				review.MR.SourceBranch,
+				review.MR.TargetBranch,
+				formattedDiffs,
+				strictness,
+				normalizeNitpickFromStrictness(0, strictness),
+				conventions,
+				guidelines,
+			)
+			prompt = core.ApplyReviewPersona(prompt, resolveMRStringSetting(
+				cmd, "persona", conf,
+				[]string{"review.persona"},
+				"",
+			))
+			prompt = applyRepoContext(prompt, repoContext)
+			prompt = core.ApplyOutputLanguage(prompt, resolveMRStringSetting(
+				cmd, "language", conf,
+				[]string{"review.output_language"},
+				"",
+			))
+
+			fmt.Printf("Summarizing MR !%d: %s (%s -> %s)\n",
+				review.MR.IID, review.MR.Title,
+				review.MR.SourceBranch, review.MR.TargetBranch)
+			fmt.Printf("Files changed: %d\n\n", len(review.Changes))
+
+			p, err := resolveProvider(conf)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error resolving provider: %v\n", err)
+				os.Exit(1)
+			}
+			info := p.Info()
+			fmt.Printf("Model: provider=%s model=%s\n", info.Name, resolvedModelForLog(conf, info.DefaultModel))
+
+			content, err := runReviewPassesWithOptions(cmd.Context(), p, prompt, 1, reviewPassOptions{
+				OnContentFilter: resolveMRStringSetting(cmd, "on-content-filter", conf, []string{"review.on_content_filter"}, contentFilterSkip),
+				SystemPrompt:    resolveMRStringSetting(cmd, "", conf, []string{"review.system_prompt"}, ""),
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error from AI provider: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Print(renders.RenderMarkdown(content))
+
+			if dryRun {
+				fmt.Println("\nDry run: nothing posted to the MR.")
+				return
+			}
+			collapsible := resolveMRBoolSetting(
+				cmd, "collapsible-summary", conf,
+				[]string{"review.collapsible_summary"},
+				false,
+			)
+			posted, err := postMRSummaryNote(cmd.Context(), vcsProvider, projectID, mrIID, notes, content, collapsible)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to post summary note: %v\n", err)
+				os.Exit(1)
+			}
+			if posted {
+				fmt.Println("\nPosted summary comment to MR.")
+			} else {
+				fmt.Println("\nSummary skipped (no review content).")
+			}
+		},
+	}
+
+	cmd.Flags().Bool("dry-run", false, "Print the summary without posting to VCS")
+	cmd.Flags().String("gitlab-token", "", "GitLab personal access token (or use GITLAB_TOKEN env)")
+	cmd.Flags().String("gitlab-token-file", "", "Read the VCS token from this file (or use GITLAB_TOKEN_FILE/GITHUB_TOKEN_FILE env)")
+	cmd.Flags().String("token-command", "", "Shell command printing the VCS token, like a git credential helper (e.g. \"pass show gitlab\")")
+	cmd.Flags().String("gitlab-url", "", "GitLab instance URL (or use GITLAB_URL env, default: https://gitlab.com)")
+	cmd.Flags().String("vcs", "", "VCS provider (gitlab, github, azuredevops; auto-detected from env)")
+	cmd.Flags().String("strictness", "", "Review strictness: strict, normal, lenient (default: normal)")
+	cmd.Flags().String("persona", "", "Reviewer persona: "+strings.Join(core.ReviewPersonaNames(), ", ")+", or custom text")
+	cmd.Flags().String("language", "", "Write summaries and finding messages in this language (e.g. French); KIND/SEVERITY tokens stay English")
+	cmd.Flags().String("repo-context-file", "", "Repository doc (e.g. ARCHITECTURE.md) whose excerpt is added to the prompt as background")
+	cmd.Flags().Int("repo-context-max-chars", defaultRepoContextMaxChars, "Maximum characters of --repo-context-file included in the prompt")
+	cmd.Flags().Bool("collapsible-summary", false, "Wrap the posted summary note in a collapsible details block")
+	cmd.Flags().String("mr-diff-source", "auto", "MR diff source strategy: auto, git, compare, raw, api")
+	cmd.Flags().String("serena", "auto", "Serena mode: auto, on, off")
+	cmd.Flags().Int("context", 10, "Number of surrounding context lines for MR review context enrichment")
+	cmd.Flags().Int("max-tokens", 80000, "Maximum token budget for MR context enrichment")
+	cmd.Flags().Bool("no-skip-generated", false, "Review generated and vendored files instead of leaving them out of the prompt")
+	cmd.Flags().Bool("include-diff-stats", false, "Prepend a change-stats table (files, additions/deletions, languages) to the review prompt")
+	return cmd
+}
+
+// postMRSummaryNote posts content as the prev summary note unless it is empty
+// or a summary note already exists in notes. It reports whether it posted.
+func postMRSummaryNote(
+	ctx context.Context,
+	vcsProvider vcs.VCSProvider,
+	projectID string,
+	mrIID int64,
+	notes []vcs.MRNote,
+	content string,
+	collapsible bool,
+) (bool, error) {
+	if strings.TrimSpace(content) == "" || hasTopLevelMarker(notes, prevSummaryMarker) {
+		return false, nil
+	}
+	if err := vcsProvider.PostSummaryNote(ctx, projectID, mrIID, buildSummaryNoteBody(content, collapsible)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func newMRDiffCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:     "diff <project_id> <mr_iid>",
@@ -3379,3 +5386,174 @@ func newMRListCmd() *cobra.Command {
 		},
 	}
 }
+
+const (
+	mrOutputText = "text"
+	mrOutputJSON = "json"
+	mrOutputHTML = "html"
+)
+
+// Posting status of each grouped finding, reported in JSON output.
+const (
+	findingStatusPosted           = "posted"
+	findingStatusReused           = "reused"
+	findingStatusUpdated          = "updated"
+	findingStatusSkippedExisting  = "skipped_existing"
+	findingStatusSkippedDuplicate = "skipped_duplicate"
+	findingStatusFailed           = "failed"
+	findingStatusUnplaced         = "unplaced"
+	findingStatusNotPosted        = "not_posted"
+)
+
+func normalizeMROutputFormat(raw string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", mrOutputText:
+		return mrOutputText, true
+	case mrOutputJSON:
+		return mrOutputJSON, true
+	case mrOutputHTML:
+		return mrOutputHTML, true
+	default:
+		return "", false
+	}
+}
+
+// mrReviewJSON is the stable document printed by `prev mr review --output json`.
+type mrReviewJSON struct {
+	ProjectID    string                        `json:"project_id"`
+	MRIID        int64                         `json:"mr_iid"`
+	Title        string                        `json:"title"`
+	SourceBranch string                        `json:"source_branch"`
+	TargetBranch string                        `json:"target_branch"`
+	HeadSHA      string                        `json:"head_sha"`
+	Provider     string                        `json:"provider"`
+	Model        string                        `json:"model"`
+	DryRun       bool                          `json:"dry_run"`
+	Summary      string                        `json:"summary"`
+	Findings     []mrReviewJSONEntry           `json:"findings"`
+	Unplaced     []string                      `json:"unplaced"`
+	Enrichment   []diffparse.EnrichmentOutcome `json:"enrichment"`
+}
+
+type mrReviewJSONEntry struct {
+	File        string `json:"file"`
+	NewLine     int    `json:"new_line"`
+	OldLine     int    `json:"old_line"`
+	Severity    string `json:"severity"`
+	Message     string `json:"message"`
+	Suggestion  string `json:"suggestion"`
+	Fingerprint string `json:"fingerprint"`
+	Status      string `json:"status"`
+	Posted      bool   `json:"posted"`
+}
+
+func buildMRReviewJSON(
+	mr *vcs.MergeRequest,
+	projectID string,
+	providerName string,
+	model string,
+	dryRun bool,
+	summary string,
+	groups []inlineGroup,
+	statuses []string,
+	unplaced []string,
+	enrichment []diffparse.EnrichmentOutcome,
+) mrReviewJSON {
+	doc := mrReviewJSON{
+		ProjectID:  projectID,
+		Provider:   providerName,
+		Model:      model,
+		DryRun:     dryRun,
+		Summary:    summary,
+		Findings:   make([]mrReviewJSONEntry, 0, len(groups)),
+		Unplaced:   append([]string{}, unplaced...),
+		Enrichment: append([]diffparse.EnrichmentOutcome{}, enrichment...),
+	}
+	if mr != nil {
+		doc.MRIID = mr.IID
+		doc.Title = mr.Title
+		doc.SourceBranch = mr.SourceBranch
+		doc.TargetBranch = mr.TargetBranch
+		doc.HeadSHA = mr.DiffRefs.HeadSHA
+	}
+	sort.Strings(doc.Unplaced)
+	for i, grp := range groups {
+		status := findingStatusNotPosted
+		if i < len(statuses) && statuses[i] != "" {
+			status = statuses[i]
+		}
+		doc.Findings = append(doc.Findings, mrReviewJSONEntry{
+			File:        grp.FilePath,
+			NewLine:     grp.NewLine,
+			OldLine:     grp.OldLine,
+			Severity:    strings.ToUpper(strings.TrimSpace(grp.Severity)),
+			Message:     grp.Message,
+			Suggestion:  grp.Suggestion,
+			Fingerprint: findingFingerprint(grp.FilePath, grp.Message),
+			Status:      status,
+			Posted:      status == findingStatusPosted || status == findingStatusReused || status == findingStatusUpdated,
+		})
+	}
+	return doc
+}
+
+func writeMRReviewJSON(w io.Writer, doc mrReviewJSON) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// mrReviewHTMLReport converts the JSON document into the HTML renderer's
+// input so both formats report the same findings and statuses.
+func mrReviewHTMLReport(doc mrReviewJSON) renders.HTMLReport {
+	report := renders.HTMLReport{
+		Title:        doc.Title,
+		ProjectID:    doc.ProjectID,
+		MRIID:        doc.MRIID,
+		SourceBranch: doc.SourceBranch,
+		TargetBranch: doc.TargetBranch,
+		HeadSHA:      doc.HeadSHA,
+		Provider:     doc.Provider,
+		Model:        doc.Model,
+		DryRun:       doc.DryRun,
+		Summary:      doc.Summary,
+		Unplaced:     doc.Unplaced,
+	}
+	for _, f := range doc.Findings {
+		line := f.NewLine
+		if line == 0 {
+			line = f.OldLine
+		}
+		report.Findings = append(report.Findings, renders.HTMLFinding{
+			File:       f.File,
+			Line:       line,
+			Severity:   f.Severity,
+			Message:    f.Message,
+			Suggestion: f.Suggestion,
+			Status:     f.Status,
+		})
+	}
+	return report
+}
+
+// writeMRReviewDocument writes doc in the given format to path, or to
+// stdout when path is empty.
+func writeMRReviewDocument(format, path string, stdout io.Writer, doc mrReviewJSON) (err error) {
+	w := stdout
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		w = f
+	}
+	if format == mrOutputHTML {
+		return renders.RenderHTMLReport(w, mrReviewHTMLReport(doc))
+	}
+	return writeMRReviewJSON(w, doc)
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sanix-darker/prev/internal/core"
+	"gopkg.in/yaml.v3"
+)
+
+// acceptedFindingsFile is the committed list of findings a team has accepted
+// as known debt. Unlike the memory "ignored" status it is reviewed like code.
+const acceptedFindingsFile = ".prev/accepted.yml"
+
+// acceptedFinding is one .prev/accepted.yml entry. Every field that is set
+// must match; an entry with no matcher is skipped.
+type acceptedFinding struct {
+	// Rule is a finding fingerprint ("rule:filehash", as in --output json)
+	// or its rule part alone to accept the finding in any file.
+	Rule string `yaml:"rule"`
+	// Location is "path", "path:line" or a path glob.
+	Location string `yaml:"location"`
+	// MessageHash is a prefix of the SHA-1 of the normalized message.
+	MessageHash string `yaml:"message_hash"`
+	Reason      string `yaml:"reason"`
+}
+
+type acceptedFindings struct {
+	Accepted []acceptedFinding `yaml:"accepted"`
+}
+
+// loadAcceptedFindings reads root/.prev/accepted.yml. A missing file yields no
+// entries and no error.
+func loadAcceptedFindings(root string) ([]acceptedFinding, error) {
+	data, err := os.ReadFile(filepath.Join(root, acceptedFindingsFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var doc acceptedFindings
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", acceptedFindingsFile, err)
+	}
+	out := make([]acceptedFinding, 0, len(doc.Accepted))
+	for _, a := range doc.Accepted {
+		a.Rule = strings.ToLower(strings.TrimSpace(a.Rule))
+		a.Location = filepath.ToSlash(strings.TrimSpace(a.Location))
+		a.MessageHash = strings.ToLower(strings.TrimSpace(a.MessageHash))
+		if a.Rule == "" && a.Location == "" && a.MessageHash == "" {
+			continue
+		}
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+// acceptedMessageHash returns the hex SHA-1 of the normalized message, the
+// value .prev/accepted.yml message_hash entries are prefixes of.
+func acceptedMessageHash(message string) string {
+	sum := sha1.Sum([]byte(normalizeMemoryMessage(message)))
+	return fmt.Sprintf("%x", sum[:])
+}
+
+func (a acceptedFinding) matches(c core.FileComment) bool {
+	if a.Rule != "" {
+		fp := findingFingerprint(c.FilePath, c.Message)
+		rule, _, _ := strings.Cut(fp, ":")
+		if a.Rule != fp && a.Rule != rule {
+			return false
+		}
+	}
+	if a.MessageHash != "" && !strings.HasPrefix(acceptedMessageHash(c.Message), a.MessageHash) {
+		return false
+	}
+	if a.Location != "" && !acceptedLocationMatches(a.Location, c.FilePath, c.Line) {
+		return false
+	}
+	return true
+}
+
+// acceptedLocationMatches matches "path" or "path:line"; path may be a glob.
+func acceptedLocationMatches(location, filePath string, line int) bool {
+	pattern := location
+	if i := strings.LastIndex(location, ":"); i > 0 {
+		if n, err := strconv.Atoi(location[i+1:]); err == nil {
+			if n != line {
+				return false
+			}
+			pattern = location[:i]
+		}
+	}
+	filePath = core.NormalizePath(filePath)
+	pattern = strings.TrimPrefix(pattern, "/")
+	if pattern == filePath {
+		return true
+	}
+	ok, _ := path.Match(pattern, filePath)
+	return ok
+}
+
+// dropAcceptedFindings removes findings matching any accepted entry and
+// returns the kept findings and the number dropped.
+func dropAcceptedFindings(comments []core.FileComment, accepted []acceptedFinding) ([]core.FileComment, int) {
+	if len(accepted) == 0 {
+		return comments, 0
+	}
+	out := make([]core.FileComment, 0, len(comments))
+	for _, c := range comments {
+		if !findingAccepted(c, accepted) {
+			out = append(out, c)
+		}
+	}
+	return out, len(comments) - len(out)
+}
+
+func findingAccepted(c core.FileComment, accepted []acceptedFinding) bool {
+	for _, a := range accepted {
+		if a.matches(c) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/core"
+	"github.com/sanix-darker/prev/internal/vcs"
+)
+
+func TestDropAcceptedFindings_SuppressesOnlyMatchingEntries(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".prev"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	legacy := core.FileComment{FilePath: "legacy/db.go", Line: 120, Severity: "HIGH", Message: "SQL built with string concatenation."}
+	anywhere := core.FileComment{FilePath: "pkg/a.go", Line: 3, Severity: "MEDIUM", Message: "Magic number 42."}
+	hashed := core.FileComment{FilePath: "pkg/b.go", Line: 9, Severity: "LOW", Message: "Consider   renaming x."}
+	kept := core.FileComment{FilePath: "pkg/c.go", Line: 7, Severity: "HIGH", Message: "Nil dereference on error path."}
+	elsewhere := core.FileComment{FilePath: "legacy/db.go", Line: 200, Severity: "HIGH", Message: "Unchecked error."}
+
+	rule, _, _ := strings.Cut(findingFingerprint(anywhere.FilePath, anywhere.Message), ":")
+	content := "accepted:\n" +
+		"  - location: legacy/db.go:120\n    reason: tracked in DEBT-12\n" +
+		"  - rule: " + rule + "\n" +
+		"  - message_hash: " + acceptedMessageHash("consider renaming x.")[:10] + "\n" +
+		"  - reason: entry without matcher is ignored\n"
+	if err := os.WriteFile(filepath.Join(root, acceptedFindingsFile), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	accepted, err := loadAcceptedFindings(root)
+	if err != nil {
+		t.Fatalf("loadAcceptedFindings: %v", err)
+	}
+	if len(accepted) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(accepted))
+	}
+	out, dropped := dropAcceptedFindings([]core.FileComment{legacy, anywhere, hashed, kept, elsewhere}, accepted)
+	if dropped != 3 {
+		t.Fatalf("expected 3 suppressed, got %d", dropped)
+	}
+	if len(out) != 2 || out[0] != kept || out[1] != elsewhere {
+		t.Fatalf("unexpected kept findings: %+v", out)
+	}
+}
+
+func TestLoadAcceptedFindings_MissingFile(t *testing.T) {
+	accepted, err := loadAcceptedFindings(t.TempDir())
+	if err != nil || accepted != nil {
+		t.Fatalf("expected nil, nil; got %v, %v", accepted, err)
+	}
+}
+
+func TestAcceptedLocationMatches_Glob(t *testing.T) {
+	if !acceptedLocationMatches("legacy/*.go", "legacy/db.go", 5) {
+		t.Fatal("expected glob to match")
+	}
+	if acceptedLocationMatches("legacy/*.go:4", "legacy/db.go", 5) {
+		t.Fatal("expected line mismatch")
+	}
+}
+
+func TestAcceptedRule_MatchesFingerprintPastedFromJSON(t *testing.T) {
+	mr := &vcs.MergeRequest{IID: 7, Title: "Add cache"}
+	groups := []inlineGroup{{FilePath: "legacy/db.go", NewLine: 120, Severity: "HIGH", Message: "SQL built with string concatenation."}}
+	doc := buildMRReviewJSON(mr, "grp/proj", "openai", "gpt-4o", true, "", groups, nil, nil, nil)
+	var buf strings.Builder
+	if err := writeMRReviewJSON(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	var raw struct {
+		Findings []struct {
+			Fingerprint string `json:"fingerprint"`
+		} `json:"findings"`
+	}
+	if err := json.Unmarshal([]byte(buf.String()), &raw); err != nil || len(raw.Findings) != 1 {
+		t.Fatalf("unexpected JSON document: %v %s", err, buf.String())
+	}
+
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".prev"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := "accepted:\n  - rule: " + raw.Findings[0].Fingerprint + "\n"
+	if err := os.WriteFile(filepath.Join(root, acceptedFindingsFile), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	accepted, err := loadAcceptedFindings(root)
+	if err != nil || len(accepted) != 1 {
+		t.Fatalf("loadAcceptedFindings = %v, %v", accepted, err)
+	}
+
+	// The fingerprint has no line part, so the finding stays accepted when
+	// the code around it moves; the same message in another file does not.
+	moved := core.FileComment{FilePath: "legacy/db.go", Line: 180, Severity: "HIGH", Message: "SQL built with string concatenation."}
+	other := core.FileComment{FilePath: "pkg/db.go", Line: 120, Severity: "HIGH", Message: "SQL built with string concatenation."}
+	out, dropped := dropAcceptedFindings([]core.FileComment{moved, other}, accepted)
+	if dropped != 1 || len(out) != 1 || out[0] != other {
+		t.Fatalf("expected only the moved finding to be accepted, got %d dropped, kept %+v", dropped, out)
+	}
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sanix-darker/prev/internal/core"
+	"github.com/sanix-darker/prev/internal/vcs"
+)
+
+// Review verdicts recorded in the baseline marker.
+const (
+	reviewVerdictApproved         = "approved"
+	reviewVerdictChangesRequested = "changes_requested"
+)
+
+// reviewVerdict reports whether a review run found anything that should
+// block approval. Only CRITICAL findings block.
+func reviewVerdict(findings []core.FileComment) string {
+	if len(criticalFindings(findings)) > 0 {
+		return reviewVerdictChangesRequested
+	}
+	return reviewVerdictApproved
+}
+
+func criticalFindings(findings []core.FileComment) []core.FileComment {
+	var out []core.FileComment
+	for _, f := range findings {
+		if strings.EqualFold(strings.TrimSpace(f.Severity), "CRITICAL") {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// needsApprovalReconsideration returns the CRITICAL findings that warrant an
+// "approval should be reconsidered" note: the previous baseline recorded a
+// clean review at a different head, and the current head now has CRITICAL
+// findings. GitLab resets approvals on push, so the earlier clean verdict no
+// longer holds.
+func needsApprovalReconsideration(baseline reviewBaseline, hasBaseline bool, headSHA string, findings []core.FileComment) []core.FileComment {
+	if !hasBaseline || baseline.Verdict != reviewVerdictApproved {
+		return nil
+	}
+	headSHA = strings.TrimSpace(headSHA)
+	if headSHA == "" || baseline.HeadSHA == headSHA {
+		return nil
+	}
+	return criticalFindings(findings)
+}
+
+func reconsiderApprovalMarker(headSHA string) string {
+	return prevReconsiderPrefix + strings.TrimSpace(headSHA) + " -->"
+}
+
+func buildReconsiderApprovalNote(previousHead, headSHA string, criticals []core.FileComment) string {
+	var sb strings.Builder
+	sb.WriteString(reconsiderApprovalMarker(headSHA))
+	sb.WriteString("\n### Approval should be reconsidered\n\n")
+	sb.WriteString(fmt.Sprintf(
+		"The previous review at `%s` found no blocking issues, but the new commits up to `%s` introduce %d CRITICAL finding(s):\n\n",
+		shortSHA(previousHead), shortSHA(headSHA), len(criticals),
+	))
+	for _, f := range criticals {
+		sb.WriteString(fmt.Sprintf("- `%s:%d` %s\n", f.FilePath, f.Line, strings.TrimSpace(f.Message)))
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// postApprovalReconsideration posts the reconsideration note once per head
+// when needsApprovalReconsideration finds CRITICAL regressions. It reports
+// whether a note was posted.
+func postApprovalReconsideration(
+	ctx context.Context,
+	vcsProvider vcs.VCSProvider,
+	projectID string,
+	mrIID int64,
+	notes []vcs.MRNote,
+	headSHA string,
+	findings []core.FileComment,
+) (bool, error) {
+	baseline, ok := latestReviewBaseline(notes)
+	criticals := needsApprovalReconsideration(baseline, ok, headSHA, findings)
+	if len(criticals) == 0 {
+		return false, nil
+	}
+	if hasTopLevelMarker(notes, reconsiderApprovalMarker(headSHA)) {
+		return false, nil
+	}
+	body := buildReconsiderApprovalNote(baseline.HeadSHA, headSHA, criticals)
+	if err := vcsProvider.PostSummaryNote(ctx, projectID, mrIID, body); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func shortSHA(sha string) string {
+	sha = strings.TrimSpace(sha)
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+	return sha
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/core"
+	"github.com/sanix-darker/prev/internal/vcs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func baselineNote(t *testing.T, b reviewBaseline) vcs.MRNote {
+	t.Helper()
+	raw, err := json.Marshal(b)
+	require.NoError(t, err)
+	return vcs.MRNote{Body: prevBaselinePrefix + base64.StdEncoding.EncodeToString(raw) + " -->"}
+}
+
+func TestPostApprovalReconsideration_NewPushIntroducesCritical(t *testing.T) {
+	notes := []vcs.MRNote{baselineNote(t, reviewBaseline{
+		HeadSHA:  "aaaaaaaa1111",
+		FileSigs: map[string]string{"a.go": "sig"},
+		Verdict:  reviewVerdict([]core.FileComment{{FilePath: "a.go", Line: 3, Severity: "LOW", Message: "Nit"}}),
+	})}
+	findings := []core.FileComment{
+		{FilePath: "a.go", Line: 12, Severity: "CRITICAL", Message: "SQL built from user input"},
+		{FilePath: "b.go", Line: 4, Severity: "MEDIUM", Message: "Missing error wrap"},
+	}
+
+	rec := &recordingVCSProvider{}
+	posted, err := postApprovalReconsideration(context.Background(), rec, "grp/proj", 7, notes, "bbbbbbbb2222", findings)
+	require.NoError(t, err)
+	assert.True(t, posted)
+	require.Len(t, rec.summaries, 1)
+	assert.Contains(t, rec.summaries[0], "Approval should be reconsidered")
+	assert.Contains(t, rec.summaries[0], "`aaaaaaaa`")
+	assert.Contains(t, rec.summaries[0], "`a.go:12` SQL built from user input")
+	assert.NotContains(t, rec.summaries[0], "Missing error wrap")
+	assert.Contains(t, rec.summaries[0], reconsiderApprovalMarker("bbbbbbbb2222"))
+
+	// A rerun on the same head does not repost.
+	notes = append(notes, vcs.MRNote{Body: rec.summaries[0]})
+	posted, err = postApprovalReconsideration(context.Background(), rec, "grp/proj", 7, notes, "bbbbbbbb2222", findings)
+	require.NoError(t, err)
+	assert.False(t, posted)
+	assert.Len(t, rec.summaries, 1)
+}
+
+func TestNeedsApprovalReconsideration_RequiresPriorCleanVerdictAndNewHead(t *testing.T) {
+	critical := []core.FileComment{{FilePath: "a.go", Line: 1, Severity: "critical", Message: "Panic on nil"}}
+	clean := reviewBaseline{HeadSHA: "old", Verdict: reviewVerdictApproved}
+
+	assert.Len(t, needsApprovalReconsideration(clean, true, "new", critical), 1)
+	assert.Empty(t, needsApprovalReconsideration(clean, false, "new", critical))
+	assert.Empty(t, needsApprovalReconsideration(clean, true, "old", critical))
+	assert.Empty(t, needsApprovalReconsideration(reviewBaseline{HeadSHA: "old", Verdict: reviewVerdictChangesRequested}, true, "new", critical))
+	assert.Empty(t, needsApprovalReconsideration(reviewBaseline{HeadSHA: "old"}, true, "new", critical))
+	assert.Empty(t, needsApprovalReconsideration(clean, true, "new", []core.FileComment{{Severity: "HIGH"}}))
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/sanix-darker/prev/internal/vcs"
+)
+
+// pendingInline is an inline comment held back so it can be submitted
+// together with the others through a vcs.InlineCommentBatcher.
+type pendingInline struct {
+	Index   int
+	Comment vcs.InlineComment
+}
+
+// flushInlineBatch submits pending in one request. When the batch is
+// rejected (one bad anchor fails the whole GitHub review), each comment is
+// handed to postOne instead so the valid ones still land. It returns the
+// number of comments posted by the batch itself.
+func flushInlineBatch(
+	ctx context.Context,
+	batcher vcs.InlineCommentBatcher,
+	projectID string,
+	mrIID int64,
+	refs vcs.DiffRefs,
+	pending []pendingInline,
+	postOne func(index int, comment vcs.InlineComment) bool,
+) int {
+	if len(pending) == 0 {
+		return 0
+	}
+	comments := make([]vcs.InlineComment, len(pending))
+	for i, item := range pending {
+		comments[i] = item.Comment
+	}
+	err := batcher.PostInlineComments(ctx, projectID, mrIID, refs, comments)
+	if err == nil {
+		return len(pending)
+	}
+	fmt.Fprintf(os.Stderr, "Warning: failed to submit %d inline comments as one review: %v; posting them individually.\n", len(pending), err)
+	for _, item := range pending {
+		postOne(item.Index, item.Comment)
+	}
+	return 0
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/vcs"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeInlineBatcher struct {
+	err     error
+	batches [][]vcs.InlineComment
+}
+
+func (f *fakeInlineBatcher) PostInlineComments(_ context.Context, _ string, _ int64, _ vcs.DiffRefs, comments []vcs.InlineComment) error {
+	f.batches = append(f.batches, comments)
+	return f.err
+}
+
+func TestFlushInlineBatch_PostsOnce(t *testing.T) {
+	batcher := &fakeInlineBatcher{}
+	pending := []pendingInline{
+		{Index: 0, Comment: vcs.InlineComment{FilePath: "a.go", NewLine: 1}},
+		{Index: 2, Comment: vcs.InlineComment{FilePath: "b.go", NewLine: 5}},
+	}
+	var individual []int
+
+	n := flushInlineBatch(context.Background(), batcher, "acme/blog", 42, vcs.DiffRefs{HeadSHA: "h"}, pending,
+		func(i int, _ vcs.InlineComment) bool { individual = append(individual, i); return true })
+
+	assert.Equal(t, 2, n)
+	assert.Len(t, batcher.batches, 1)
+	assert.Len(t, batcher.batches[0], 2)
+	assert.Empty(t, individual)
+}
+
+func TestFlushInlineBatch_FallsBackToIndividualPosts(t *testing.T) {
+	batcher := &fakeInlineBatcher{err: errors.New("HTTP 422: pull_request_review_thread.line must be part of the diff")}
+	pending := []pendingInline{
+		{Index: 1, Comment: vcs.InlineComment{FilePath: "a.go", NewLine: 1}},
+		{Index: 3, Comment: vcs.InlineComment{FilePath: "b.go", NewLine: 5}},
+	}
+	var individual []int
+
+	n := flushInlineBatch(context.Background(), batcher, "acme/blog", 42, vcs.DiffRefs{HeadSHA: "h"}, pending,
+		func(i int, _ vcs.InlineComment) bool { individual = append(individual, i); return true })
+
+	assert.Equal(t, 0, n)
+	assert.Equal(t, []int{1, 3}, individual)
+}
+
+func TestFlushInlineBatch_EmptyIsNoop(t *testing.T) {
+	batcher := &fakeInlineBatcher{}
+	assert.Equal(t, 0, flushInlineBatch(context.Background(), batcher, "p", 1, vcs.DiffRefs{}, nil, nil))
+	assert.Empty(t, batcher.batches)
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sanix-darker/prev/internal/core"
+	"github.com/sanix-darker/prev/internal/diffparse"
+)
+
+// Review priority tiers used when whole files must be omitted to fit the
+// token budget. Higher tiers are kept longer. The low and pinned tiers hold
+// files matched by review.budget_low_priority_globs and
+// review.budget_priority_globs.
+const (
+	filePriorityLow = iota
+	filePriorityDocs
+	filePriorityTests
+	filePriorityConfig
+	filePrioritySource
+	filePriorityPinned
+)
+
+// diffSection is one file's formatted diff in the review prompt. Priority is
+// its budgetFilePriority tier.
+type diffSection struct {
+	Path     string
+	Text     string
+	Priority int
+}
+
+// reviewFilePriority ranks a changed file for budget truncation: source code
+// first, then config/build files, then tests, then docs.
+func reviewFilePriority(path string) int {
+	lower := strings.ToLower(filepath.ToSlash(path))
+	base := filepath.Base(lower)
+	ext := filepath.Ext(base)
+
+	switch ext {
+	case ".md", ".markdown", ".txt", ".rst", ".adoc":
+		return filePriorityDocs
+	}
+	if strings.HasPrefix(lower, "docs/") || strings.Contains(lower, "/docs/") {
+		return filePriorityDocs
+	}
+
+	stem := strings.TrimSuffix(base, ext)
+	if strings.HasSuffix(stem, "_test") || strings.HasSuffix(stem, ".test") || strings.HasSuffix(stem, ".spec") ||
+		strings.HasPrefix(stem, "test_") || strings.HasPrefix(lower, "test/") || strings.HasPrefix(lower, "tests/") ||
+		strings.Contains(lower, "/test/") || strings.Contains(lower, "/tests/") || strings.Contains(lower, "__tests__/") ||
+		strings.Contains(lower, "/testdata/") || strings.HasPrefix(lower, "testdata/") {
+		return filePriorityTests
+	}
+
+	switch ext {
+	case ".yml", ".yaml", ".json", ".toml", ".ini", ".cfg", ".conf", ".xml", ".lock", ".mod", ".sum":
+		return filePriorityConfig
+	}
+	switch base {
+	case "dockerfile", "makefile", "cmakelists.txt":
+		return filePriorityConfig
+	}
+	return filePrioritySource
+}
+
+// budgetFilePriority is reviewFilePriority with the review.budget_*_globs
+// applied on top: a priority glob match outranks every tier and a
+// low-priority glob match goes below docs.
+func budgetFilePriority(path string, opts diffparse.EnrichOptions) int {
+	switch {
+	case core.MatchesAnyPathGlob(path, opts.PriorityGlobs):
+		return filePriorityPinned
+	case core.MatchesAnyPathGlob(path, opts.LowPriorityGlobs):
+		return filePriorityLow
+	}
+	return reviewFilePriority(path)
+}
+
+// fitDiffSectionsToBudget joins sections, omitting whole files until the
+// estimate (4 chars per token) fits maxTokens. It is the final hard cap
+// after the configured budget strategy: files are dropped lowest Priority
+// tier first and, within a tier, largest first. When the last remaining
+// file alone is still over budget it is cut at a line boundary. The returned
+// list names every omitted file; a note listing them is appended to the text.
+// maxTokens <= 0 disables the check.
+func fitDiffSectionsToBudget(sections []diffSection, maxTokens int) (string, []string) {
+	join := func(keep []bool) string {
+		var parts []string
+		for i, s := range sections {
+			if keep[i] && strings.TrimSpace(s.Text) != "" {
+				parts = append(parts, strings.TrimSpace(s.Text))
+			}
+		}
+		return strings.Join(parts, "\n\n")
+	}
+	keep := make([]bool, len(sections))
+	for i := range keep {
+		keep[i] = true
+	}
+	full := join(keep)
+	if maxTokens <= 0 || len(full)/4 <= maxTokens {
+		return full, nil
+	}
+
+	order := make([]int, len(sections))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		sa, sb := sections[order[a]], sections[order[b]]
+		if sa.Priority != sb.Priority {
+			return sa.Priority < sb.Priority
+		}
+		return len(sa.Text) > len(sb.Text)
+	})
+
+	// Reserve room for the omission note and truncation marker up front.
+	const truncatedMarker = "\n[... diff truncated to fit the token budget ...]"
+	budgetChars := maxTokens * 4
+	reserved := 160 + len(truncatedMarker)
+	var omitted []string
+	total := len(full)
+	for _, idx := range order[:len(order)-1] {
+		if total+reserved <= budgetChars {
+			break
+		}
+		keep[idx] = false
+		total -= len(strings.TrimSpace(sections[idx].Text)) + 2
+		omitted = append(omitted, sections[idx].Path)
+		reserved += len(sections[idx].Path) + 2
+	}
+
+	out := join(keep)
+	if limit := max(budgetChars-reserved, 0); len(out) > limit {
+		cut := strings.LastIndex(out[:limit], "\n")
+		if cut < 0 {
+			cut = limit
+		}
+		out = out[:cut] + truncatedMarker
+	}
+	if len(omitted) > 0 {
+		sort.Strings(omitted)
+		out += fmt.Sprintf("\n\n## Omitted Files\nThese files changed but were omitted to fit the review token budget (max_tokens=%d): %s",
+			maxTokens, strings.Join(omitted, ", "))
+	}
+	return out, omitted
+}
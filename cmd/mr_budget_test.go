@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/diffparse"
+)
+
+func TestReviewFilePriority_Ordering(t *testing.T) {
+	cases := map[string]int{
+		"README.md":             filePriorityDocs,
+		"docs/guide.html":       filePriorityDocs,
+		"cmd/mr_test.go":        filePriorityTests,
+		"web/__tests__/app.js":  filePriorityTests,
+		"tests/test_parser.py":  filePriorityTests,
+		"config/app.yaml":       filePriorityConfig,
+		"Dockerfile":            filePriorityConfig,
+		"go.mod":                filePriorityConfig,
+		"cmd/mr.go":             filePrioritySource,
+		"internal/core/core.ts": filePrioritySource,
+	}
+	for path, want := range cases {
+		if got := reviewFilePriority(path); got != want {
+			t.Errorf("reviewFilePriority(%q) = %d, want %d", path, got, want)
+		}
+	}
+}
+
+func TestFitDiffSectionsToBudget_SourceOutlivesLargeDocsByDefault(t *testing.T) {
+	big := strings.Repeat(strings.Repeat("d", 99)+"\n", 300) // ~30KB
+	small := strings.Repeat(strings.Repeat("s", 99)+"\n", 20)
+	var sections []diffSection
+	for _, f := range []struct{ path, text string }{
+		{"README.md", big}, {"cmd/app_test.go", big}, {"cmd/app.go", small},
+	} {
+		sections = append(sections, diffSection{Path: f.path, Text: f.text, Priority: budgetFilePriority(f.path, diffparse.EnrichOptions{})})
+	}
+
+	out, omitted := fitDiffSectionsToBudget(sections, 9000)
+	if len(omitted) != 1 || omitted[0] != "README.md" {
+		t.Fatalf("expected only the docs to be omitted, got %v", omitted)
+	}
+	if !strings.Contains(out, small[:50]) {
+		t.Fatal("the small source change should be kept")
+	}
+}
+
+func TestFitDiffSectionsToBudget_GlobsAdjustTheTiers(t *testing.T) {
+	body := strings.Repeat(strings.Repeat("z", 99)+"\n", 100) // ~10KB per file
+	opts := diffparse.EnrichOptions{PriorityGlobs: []string{"docs/**"}, LowPriorityGlobs: []string{"pkg/**"}}
+	var sections []diffSection
+	for _, path := range []string{"docs/guide.md", "pkg/core.go", "app/main.go"} {
+		sections = append(sections, diffSection{Path: path, Text: body, Priority: budgetFilePriority(path, opts)})
+	}
+
+	_, omitted := fitDiffSectionsToBudget(sections, 6000)
+	if len(omitted) != 1 || omitted[0] != "pkg/core.go" {
+		t.Fatalf("expected the low-priority glob match to go first, got %v", omitted)
+	}
+	_, omitted = fitDiffSectionsToBudget(sections, 3500)
+	if len(omitted) != 2 || strings.Contains(strings.Join(omitted, ","), "docs/guide.md") {
+		t.Fatalf("expected the priority glob match to be kept, got %v", omitted)
+	}
+}
+
+func TestFitDiffSectionsToBudget_UnderBudgetUnchanged(t *testing.T) {
+	sections := []diffSection{
+		{Path: "a.go", Text: "diff a"},
+		{Path: "README.md", Text: "diff readme"},
+	}
+	out, omitted := fitDiffSectionsToBudget(sections, 1000)
+	if len(omitted) != 0 {
+		t.Fatalf("expected nothing omitted, got %v", omitted)
+	}
+	if out != "diff a\n\ndiff readme" {
+		t.Fatalf("unexpected output: %q", out)
+	}
+}
+
+func TestFitDiffSectionsToBudget_LargeDiffStaysUnderBudget(t *testing.T) {
+	line := strings.Repeat("x", 99) + "\n"
+	body := strings.Repeat(line, 500) // ~50KB per file
+	var sections []diffSection
+	for i := 0; i < 4; i++ {
+		sections = append(sections,
+			diffSection{Path: fmt.Sprintf("docs/page%d.md", i), Text: body, Priority: filePriorityDocs},
+			diffSection{Path: fmt.Sprintf("pkg/file%d_test.go", i), Text: body, Priority: filePriorityTests},
+		)
+	}
+	sections = append(sections,
+		diffSection{Path: "pkg/core.go", Text: body[:20000], Priority: filePrioritySource},
+		diffSection{Path: "pkg/util.go", Text: body[:20000], Priority: filePrioritySource},
+	)
+
+	const maxTokens = 20000
+	out, omitted := fitDiffSectionsToBudget(sections, maxTokens)
+	if len(out)/4 > maxTokens {
+		t.Fatalf("output estimate %d tokens exceeds budget %d", len(out)/4, maxTokens)
+	}
+	if len(omitted) != 8 {
+		t.Fatalf("expected 8 docs/tests omitted, got %d: %v", len(omitted), omitted)
+	}
+	for _, p := range omitted {
+		if reviewFilePriority(p) == filePrioritySource {
+			t.Fatalf("source file %s should have been kept", p)
+		}
+	}
+	if !strings.Contains(out, "## Omitted Files") || !strings.Contains(out, "docs/page0.md") {
+		t.Fatalf("expected omission note, got tail %q", out[len(out)-200:])
+	}
+}
+
+func TestFitDiffSectionsToBudget_TruncatesSingleHugeFile(t *testing.T) {
+	body := strings.Repeat(strings.Repeat("y", 79)+"\n", 10000)
+	out, omitted := fitDiffSectionsToBudget([]diffSection{{Path: "main.go", Text: body}}, 1000)
+	if len(omitted) != 0 {
+		t.Fatalf("the only file must never be omitted, got %v", omitted)
+	}
+	if len(out)/4 > 1000 {
+		t.Fatalf("output estimate %d tokens exceeds budget", len(out)/4)
+	}
+	if !strings.HasSuffix(out, "[... diff truncated to fit the token budget ...]") {
+		t.Fatalf("expected truncation marker, got tail %q", out[len(out)-80:])
+	}
+}
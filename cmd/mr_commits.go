@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sanix-darker/prev/internal/vcs"
+)
+
+const (
+	// maxPromptCommits caps the commit messages injected with --commits;
+	// the newest commits are kept since they describe the final state.
+	maxPromptCommits = 30
+	// maxPromptCommitChars caps each condensed commit line.
+	maxPromptCommitChars = 160
+)
+
+// fetchMRCommitsForPrompt returns the MR's commits for --commits, or nil
+// with a warning when the provider cannot list them.
+func fetchMRCommitsForPrompt(ctx context.Context, vcsProvider vcs.VCSProvider, projectID string, mrIID int64) []vcs.Commit {
+	lister, ok := vcsProvider.(vcs.MRCommitLister)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Warning: %s cannot list MR commits; --commits ignored.\n", vcsProvider.Info().Name)
+		return nil
+	}
+	commits, err := lister.FetchMRCommits(ctx, projectID, mrIID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to fetch MR commits: %v\n", err)
+		return nil
+	}
+	return commits
+}
+
+// appendCommitGuidelines adds the condensed commit messages so the model can
+// check the diff against the intent they state. Merge commits are skipped.
+func appendCommitGuidelines(guidelines string, commits []vcs.Commit) string {
+	var kept []vcs.Commit
+	for _, c := range commits {
+		if commitTitle(c) == "" || strings.HasPrefix(commitTitle(c), "Merge ") {
+			continue
+		}
+		kept = append(kept, c)
+	}
+	if len(kept) == 0 {
+		return guidelines
+	}
+	omitted := 0
+	if len(kept) > maxPromptCommits {
+		omitted = len(kept) - maxPromptCommits
+		kept = kept[omitted:]
+	}
+
+	lines := []string{"MR commit messages (oldest first). Check that the diff does what they state and flag changes they do not account for:"}
+	if omitted > 0 {
+		lines = append(lines, fmt.Sprintf("- (%d older commits omitted)", omitted))
+	}
+	for _, c := range kept {
+		line := commitTitle(c)
+		if body := commitBodySummary(c); body != "" {
+			line += " — " + body
+		}
+		sha := c.SHA
+		if len(sha) > 8 {
+			sha = sha[:8]
+		}
+		lines = append(lines, "- "+strings.TrimSpace(sha+" "+truncateRunes(line, maxPromptCommitChars)))
+	}
+	block := strings.Join(lines, "\n")
+	if strings.TrimSpace(guidelines) == "" {
+		return block
+	}
+	return guidelines + "\n" + block
+}
+
+func commitTitle(c vcs.Commit) string {
+	if t := strings.TrimSpace(c.Title); t != "" {
+		return t
+	}
+	title, _, _ := strings.Cut(strings.TrimSpace(c.Message), "\n")
+	return strings.TrimSpace(title)
+}
+
+// commitBodySummary returns the first non-empty body line, skipping
+// trailers such as Signed-off-by.
+func commitBodySummary(c vcs.Commit) string {
+	_, body, _ := strings.Cut(strings.TrimSpace(c.Message), "\n")
+	for _, l := range strings.Split(body, "\n") {
+		l = strings.TrimSpace(l)
+		if l == "" || strings.HasSuffix(strings.SplitN(l, " ", 2)[0], "-by:") {
+			continue
+		}
+		return l
+	}
+	return ""
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
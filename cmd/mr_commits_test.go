@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/vcs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendCommitGuidelines_CondensesMessages(t *testing.T) {
+	commits := []vcs.Commit{
+		{SHA: "0123456789abcdef", Title: "Add response cache", Message: "Add response cache\n\nSigned-off-by: Bob <b@x>\nCaches GET /users for 60s.\n"},
+		{SHA: "fedcba9876543210", Title: "Merge branch 'main' into feature"},
+		{SHA: "abc", Message: "Fix nil check"},
+	}
+
+	got := appendCommitGuidelines("Existing guideline.", commits)
+	assert.True(t, strings.HasPrefix(got, "Existing guideline.\nMR commit messages (oldest first)."))
+	assert.Contains(t, got, "- 01234567 Add response cache — Caches GET /users for 60s.")
+	assert.Contains(t, got, "- abc Fix nil check")
+	assert.NotContains(t, got, "Merge branch")
+	assert.NotContains(t, got, "Signed-off-by")
+}
+
+func TestAppendCommitGuidelines_CapsCommitCount(t *testing.T) {
+	var commits []vcs.Commit
+	for i := 1; i <= maxPromptCommits+5; i++ {
+		commits = append(commits, vcs.Commit{SHA: fmt.Sprintf("c%d", i), Title: fmt.Sprintf("Change %d", i)})
+	}
+
+	got := appendCommitGuidelines("", commits)
+	assert.Contains(t, got, "- (5 older commits omitted)")
+	assert.NotContains(t, got, "Change 5\n")
+	assert.Contains(t, got, "- c6 Change 6")
+	assert.Contains(t, got, fmt.Sprintf("- c%d Change %d", maxPromptCommits+5, maxPromptCommits+5))
+}
+
+func TestAppendCommitGuidelines_NoCommits(t *testing.T) {
+	assert.Equal(t, "keep", appendCommitGuidelines("keep", nil))
+	assert.Equal(t, "keep", appendCommitGuidelines("keep", []vcs.Commit{{SHA: "a", Title: "Merge pull request #1"}}))
+}
+
+func TestFetchMRCommitsForPrompt_UnsupportedProvider(t *testing.T) {
+	assert.Nil(t, fetchMRCommitsForPrompt(t.Context(), &recordingVCSProvider{}, "g/p", 1))
+}
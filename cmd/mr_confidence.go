@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/sanix-darker/prev/internal/core"
+)
+
+// speculativePhrases mark a finding as a guess or a generic nudge rather
+// than an observation about the changed code.
+var speculativePhrases = []string{
+	"consider ",
+	"you may want",
+	"might want to",
+	"might ",
+	"it may ",
+	"this may ",
+	"could potentially",
+	"potentially",
+	"possibly",
+	"perhaps",
+	"it would be good",
+	"it would be better",
+	"it's worth",
+	"it is worth",
+	"make sure",
+	"double-check",
+	"if this is intended",
+	"not sure",
+}
+
+// speculativePhrasesLongestFirst orders speculativePhrases so that a longer
+// phrase claims its span before a phrase it contains ("might want to" before
+// "might ").
+var speculativePhrasesLongestFirst = func() []string {
+	out := append([]string(nil), speculativePhrases...)
+	sort.SliceStable(out, func(i, j int) bool { return len(out[i]) > len(out[j]) })
+	return out
+}()
+
+// Score deductions applied by findingConfidence.
+const (
+	confidenceHedgePenalty    = 0.25
+	confidenceGenericPenalty  = 0.25
+	confidenceAbstractPenalty = 0.25
+)
+
+// findingConfidence scores how concrete a finding is, from 0 (pure
+// speculation) to 1. Every hedging phrase costs a step (see countHedges), as does matching a
+// known generic pattern and naming no identifier: a finding that neither
+// quotes code in backticks nor carries a suggestion points at nothing the
+// author can check.
+func findingConfidence(c core.FileComment) float64 {
+	msg := strings.TrimSpace(c.Message)
+	if msg == "" {
+		return 0
+	}
+	lower := strings.ToLower(msg) + " "
+	score := 1.0
+	score -= float64(countHedges(lower)) * confidenceHedgePenalty
+	if looksGenericInlineFinding(lower) {
+		score -= confidenceGenericPenalty
+	}
+	if !strings.Contains(msg, "`") && strings.TrimSpace(c.Suggestion) == "" {
+		score -= confidenceAbstractPenalty
+	}
+	return max(score, 0)
+}
+
+// countHedges counts the speculative phrases in lower, each at most once.
+// Phrases overlap ("might want to" contains "might "), so a match only counts
+// when it does not fall inside the span of a longer phrase already counted.
+func countHedges(lower string) int {
+	covered := make([]bool, len(lower))
+	count := 0
+	for _, p := range speculativePhrasesLongestFirst {
+		counted := false
+		for from := 0; from < len(lower); {
+			i := strings.Index(lower[from:], p)
+			if i < 0 {
+				break
+			}
+			start, end := from+i, from+i+len(p)
+			from = start + 1
+			if hasCovered(covered[start:end]) {
+				continue
+			}
+			for k := start; k < end; k++ {
+				covered[k] = true
+			}
+			if !counted {
+				count++
+				counted = true
+			}
+		}
+	}
+	return count
+}
+
+func hasCovered(span []bool) bool {
+	for _, c := range span {
+		if c {
+			return true
+		}
+	}
+	return false
+}
+
+// filterSpeculativeFindings drops findings scoring below minConfidence
+// (review.min_confidence). HIGH and CRITICAL findings are always kept, since
+// a hedged warning about a serious problem is still worth a look. A
+// minConfidence of 0 disables the filter.
+func filterSpeculativeFindings(comments []core.FileComment, minConfidence float64) ([]core.FileComment, int) {
+	if minConfidence <= 0 || len(comments) == 0 {
+		return comments, 0
+	}
+	out := make([]core.FileComment, 0, len(comments))
+	for _, c := range comments {
+		if severityRank(c.Severity) < severityRank("HIGH") && findingConfidence(c) < minConfidence {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out, len(comments) - len(out)
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindingConfidence_HedgedVsConcrete(t *testing.T) {
+	concrete := []core.FileComment{
+		{Severity: "MEDIUM", Message: "`parseID` ignores the error from `strconv.Atoi`, so a bad ID becomes 0."},
+		{Severity: "LOW", Message: "Off-by-one in the loop bound.", Suggestion: "for i := 0; i < len(xs); i++ {"},
+	}
+	hedged := []core.FileComment{
+		{Severity: "MEDIUM", Message: "Consider adding more error handling here."},
+		{Severity: "LOW", Message: "This might possibly cause issues; you may want to double-check it."},
+		{Severity: "MEDIUM", Message: "Ensure backward compatibility with existing clients."},
+	}
+	for _, c := range concrete {
+		assert.Equal(t, 1.0, findingConfidence(c), c.Message)
+	}
+	for _, c := range hedged {
+		assert.LessOrEqual(t, findingConfidence(c), 0.5, c.Message)
+	}
+}
+
+func TestFindingConfidence_HedgeWithIdentifierScoresBetween(t *testing.T) {
+	c := core.FileComment{Message: "Consider closing `resp.Body` after the read."}
+	assert.Equal(t, 0.75, findingConfidence(c))
+}
+
+func TestFindingConfidence_OverlappingPhrasesCountOnce(t *testing.T) {
+	assert.Equal(t, 1, countHedges("you might want to close `f` here. "))
+	assert.Equal(t, 1, countHedges("this could potentially leak `conn`. "))
+	assert.Equal(t, 2, countHedges("you might want to close `f`; it might leak. "))
+
+	c := core.FileComment{Message: "You might want to close `resp.Body` after the read."}
+	assert.Equal(t, 0.75, findingConfidence(c))
+}
+
+func TestFindingConfidence_DirectivesAreNotHedges(t *testing.T) {
+	for _, msg := range []string{
+		"Ensure that `rows.Close()` is deferred before the scan loop.",
+		"Verify that `token` is non-empty before calling `Login`.",
+	} {
+		assert.Equal(t, 1.0, findingConfidence(core.FileComment{Message: msg}), msg)
+	}
+}
+
+func TestFilterSpeculativeFindings(t *testing.T) {
+	in := []core.FileComment{
+		{FilePath: "a.go", Line: 1, Severity: "MEDIUM", Message: "`db.Close` is deferred before the error check."},
+		{FilePath: "a.go", Line: 2, Severity: "MEDIUM", Message: "Consider possibly refactoring this."},
+		{FilePath: "a.go", Line: 3, Severity: "HIGH", Message: "This might potentially leak connections."},
+		{FilePath: "a.go", Line: 4, Severity: "LOW", Message: "It would be good to add tests."},
+	}
+
+	got, dropped := filterSpeculativeFindings(in, 0.6)
+	assert.Equal(t, 2, dropped)
+	assert.Equal(t, []core.FileComment{in[0], in[2]}, got)
+}
+
+func TestFilterSpeculativeFindings_DisabledAtZero(t *testing.T) {
+	in := []core.FileComment{{Severity: "LOW", Message: "Consider possibly refactoring this."}}
+	got, dropped := filterSpeculativeFindings(in, 0)
+	assert.Zero(t, dropped)
+	assert.Equal(t, in, got)
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/sanix-darker/prev/internal/provider"
+)
+
+const (
+	contentFilterFail           = "fail"
+	contentFilterSkip           = "skip"
+	contentFilterRetrySanitized = "retry_sanitized"
+)
+
+var (
+	contentFilterStringLitRe = regexp.MustCompile("\"(?:[^\"\\\\]|\\\\.){3,}\"|'(?:[^'\\\\]|\\\\.){3,}'|`[^`]{3,}`")
+	contentFilterOpaqueRe    = regexp.MustCompile(`[A-Za-z0-9+/=_\-]{40,}`)
+)
+
+func normalizeContentFilterMode(mode string) string {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case contentFilterFail:
+		return contentFilterFail
+	case contentFilterRetrySanitized, "retry":
+		return contentFilterRetrySanitized
+	default:
+		return contentFilterSkip
+	}
+}
+
+// isContentFilterResult reports whether a provider call was blocked by the
+// provider's safety system, either as a classified error or a finish reason.
+func isContentFilterResult(resp *provider.CompletionResponse, err error) bool {
+	if err != nil {
+		return errors.Is(err, provider.ErrContentFilter)
+	}
+	if resp == nil {
+		return false
+	}
+	if strings.EqualFold(strings.TrimSpace(resp.FinishReason), string(provider.ErrCodeContentFilter)) {
+		return true
+	}
+	for _, c := range resp.Choices {
+		if strings.EqualFold(strings.TrimSpace(c.FinishReason), string(provider.ErrCodeContentFilter)) {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizePromptForContentFilter redacts string literals and long opaque
+// tokens from changed diff lines, which are the most likely triggers for
+// provider content filters. It returns the sanitized prompt and the number of
+// redacted spans.
+func sanitizePromptForContentFilter(prompt string) (string, int) {
+	lines := strings.Split(prompt, "\n")
+	redacted := 0
+	for i, line := range lines {
+		if !isChangedDiffLine(line) {
+			continue
+		}
+		line = contentFilterStringLitRe.ReplaceAllStringFunc(line, func(string) string {
+			redacted++
+			return `"<redacted>"`
+		})
+		line = contentFilterOpaqueRe.ReplaceAllStringFunc(line, func(string) string {
+			redacted++
+			return "<redacted>"
+		})
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n"), redacted
+}
+
+func isChangedDiffLine(line string) bool {
+	if strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---") {
+		return false
+	}
+	return strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-")
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunReviewPasses_ContentFilterRetrySanitized(t *testing.T) {
+	ai := &scriptedAIProvider{responses: []provider.CompletionResponse{
+		{FinishReason: "content_filter"},
+		{Content: "sanitized review"},
+	}}
+	prompt := "Review this diff:\n+ 12 | token := \"some-very-offensive-literal\"\n  13 | return token"
+
+	out, err := runReviewPassesWithOptions(context.Background(), ai, prompt, 1, reviewPassOptions{OnContentFilter: "retry_sanitized"})
+	require.NoError(t, err)
+	assert.Equal(t, "sanitized review", out)
+	require.Len(t, ai.requests, 2)
+	retried := ai.requests[1].Messages[len(ai.requests[1].Messages)-1].Content
+	assert.NotContains(t, retried, "some-very-offensive-literal")
+	assert.Contains(t, retried, `"<redacted>"`)
+	assert.Contains(t, retried, "13 | return token")
+}
+
+func TestRunReviewPasses_ContentFilterKeepsPartialResults(t *testing.T) {
+	ai := &scriptedAIProvider{responses: []provider.CompletionResponse{
+		{Content: "first review"},
+		{Choices: []provider.Choice{{FinishReason: "content_filter"}}},
+	}}
+
+	out, err := runReviewPassesWithOptions(context.Background(), ai, "BASE_PROMPT", 3, reviewPassOptions{OnContentFilter: "skip"})
+	require.NoError(t, err)
+	assert.Equal(t, "first review", out)
+	assert.Len(t, ai.requests, 2)
+}
+
+func TestRunReviewPasses_ContentFilterFailMode(t *testing.T) {
+	ai := &scriptedAIProvider{responses: []provider.CompletionResponse{
+		{FinishReason: "content_filter"},
+	}}
+
+	_, err := runReviewPassesWithOptions(context.Background(), ai, "BASE_PROMPT", 1, reviewPassOptions{OnContentFilter: "fail"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "content filter")
+}
+
+func TestIsContentFilterResult_ProviderError(t *testing.T) {
+	err := &provider.ProviderError{Code: provider.ErrCodeContentFilter, Provider: "openai"}
+	assert.True(t, isContentFilterResult(nil, err))
+	assert.False(t, isContentFilterResult(nil, provider.ErrRateLimit))
+	assert.False(t, isContentFilterResult(&provider.CompletionResponse{FinishReason: "stop"}, nil))
+}
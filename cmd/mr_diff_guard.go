@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/sanix-darker/prev/internal/diffparse"
+	"github.com/sanix-darker/prev/internal/vcs"
+)
+
+// degradedContextLines caps the context around hunks when an oversized
+// diff falls back to a summary-only review.
+const degradedContextLines = 3
+
+// mrDiffBytes returns the size of the diff lines that would reach the
+// prompt. Files matched by ignoreGlobs are left out since generated and
+// vendored files are never sent.
+func mrDiffBytes(changes []diffparse.FileChange, ignoreGlobs []string) int {
+	if ignoreGlobs != nil {
+		changes, _ = filterGeneratedChanges(changes, ignoreGlobs)
+	}
+	total := 0
+	for _, fc := range changes {
+		for _, h := range fc.Hunks {
+			for _, l := range h.Lines {
+				total += len(l.Content) + 2 // line prefix and newline
+			}
+		}
+	}
+	return total
+}
+
+// applyDiffSizeGuard checks the diff against review.max_diff_bytes. When it
+// is exceeded (and --force-full is not set) s is switched to the degraded
+// settings: little context, no Serena, large files summarized. The caller
+// is expected to skip inline comments. It returns the measured size and
+// whether the review was degraded.
+func applyDiffSizeGuard(s *mrReviewSettings, changes []diffparse.FileChange) (int, bool) {
+	if s.MaxDiffBytes <= 0 || s.ForceFull {
+		return 0, false
+	}
+	size := mrDiffBytes(changes, s.IgnoreGlobs)
+	if size <= s.MaxDiffBytes {
+		return size, false
+	}
+	s.ContextLines = min(s.ContextLines, degradedContextLines)
+	s.Serena = "off"
+	s.BudgetStrategy = diffparse.BudgetSummarizeLarge
+	return size, true
+}
+
+// diffSizeGuardWarning explains a degraded review. It only announces a
+// summary-only review when a summary will be posted; inline-only mode posts
+// neither.
+func diffSizeGuardWarning(size int, s mrReviewSettings) string {
+	if s.InlineOnly {
+		return fmt.Sprintf("Warning: diff is %d bytes, over review.max_diff_bytes (%d); inline comments are skipped and inline-only mode posts no summary, so nothing will be posted. Use --force-full to review it in full.",
+			size, s.MaxDiffBytes)
+	}
+	return fmt.Sprintf("Warning: diff is %d bytes, over review.max_diff_bytes (%d); running a summary-only review with %d context lines and no inline comments. Use --force-full to review it in full.",
+		size, s.MaxDiffBytes, s.ContextLines)
+}
+
+// summaryNoteRequested reports whether the summary note is posted. Inline-only
+// mode never posts one. Otherwise it normally waits for an explicit
+// `@handle summary` command, but a degraded review has no inline comments, so
+// its summary is always posted; otherwise the AI call would produce nothing on
+// the MR.
+func summaryNoteRequested(degraded, inlineOnly bool, discussions []vcs.MRDiscussion, mentionHandle string) bool {
+	if inlineOnly {
+		return false
+	}
+	return degraded || threadHasAnyCommand(discussions, mentionHandle, "summary")
+}
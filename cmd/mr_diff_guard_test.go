@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/core"
+	"github.com/sanix-darker/prev/internal/diffparse"
+	"github.com/sanix-darker/prev/internal/vcs"
+	"github.com/stretchr/testify/assert"
+)
+
+// syntheticChange returns a new file of n added lines of width bytes.
+func syntheticChange(name string, n, width int) diffparse.FileChange {
+	lines := make([]diffparse.DiffLine, n)
+	for i := range lines {
+		lines[i] = diffparse.DiffLine{Type: diffparse.LineAdded, NewLineNo: i + 1, Content: strings.Repeat("x", width)}
+	}
+	return diffparse.FileChange{NewName: name, IsNew: true, Hunks: []diffparse.Hunk{{NewStart: 1, NewLines: n, Lines: lines}}}
+}
+
+func TestApplyDiffSizeGuard_OversizedDiffDegrades(t *testing.T) {
+	changes := []diffparse.FileChange{syntheticChange("data/fixtures.json", 20000, 98)}
+	s := mrReviewSettings{MaxDiffBytes: 1 << 20, ContextLines: 10, Serena: "auto", BudgetStrategy: diffparse.BudgetReduceContext}
+
+	size, degraded := applyDiffSizeGuard(&s, changes)
+	assert.True(t, degraded)
+	assert.Equal(t, 20000*100, size)
+	assert.Equal(t, degradedContextLines, s.ContextLines)
+	assert.Equal(t, "off", s.Serena)
+	assert.Equal(t, diffparse.BudgetSummarizeLarge, s.BudgetStrategy)
+}
+
+func TestApplyDiffSizeGuard_KeepsFullReview(t *testing.T) {
+	big := []diffparse.FileChange{syntheticChange("data/fixtures.json", 20000, 98)}
+
+	forced := mrReviewSettings{MaxDiffBytes: 1 << 20, ForceFull: true, ContextLines: 10}
+	_, degraded := applyDiffSizeGuard(&forced, big)
+	assert.False(t, degraded, "--force-full overrides the guard")
+	assert.Equal(t, 10, forced.ContextLines)
+
+	disabled := mrReviewSettings{ContextLines: 10}
+	_, degraded = applyDiffSizeGuard(&disabled, big)
+	assert.False(t, degraded, "0 disables the guard")
+
+	small := mrReviewSettings{MaxDiffBytes: 1 << 20, ContextLines: 10}
+	_, degraded = applyDiffSizeGuard(&small, []diffparse.FileChange{syntheticChange("main.go", 10, 40)})
+	assert.False(t, degraded)
+}
+
+func TestApplyDiffSizeGuard_IgnoresGeneratedFiles(t *testing.T) {
+	changes := []diffparse.FileChange{
+		syntheticChange("package-lock.json", 20000, 98),
+		syntheticChange("src/app.ts", 10, 40),
+	}
+	s := mrReviewSettings{MaxDiffBytes: 1 << 20, ContextLines: 10, IgnoreGlobs: core.DefaultGeneratedGlobs}
+
+	size, degraded := applyDiffSizeGuard(&s, changes)
+	assert.False(t, degraded, "a regenerated lockfile is never sent, so it does not count")
+	assert.Equal(t, 10*42, size)
+}
+
+func TestSummaryNoteRequested_DegradedReviewAlwaysPostsSummary(t *testing.T) {
+	assert.True(t, summaryNoteRequested(true, false, nil, "prev"), "no summary command, but the review was degraded")
+	assert.False(t, summaryNoteRequested(true, true, nil, "prev"), "inline-only mode never posts a summary, even when degraded")
+	assert.False(t, summaryNoteRequested(false, false, nil, "prev"))
+
+	asked := []vcs.MRDiscussion{{ID: "d1", Notes: []vcs.MRDiscussionNote{{Body: "@prev summary"}}}}
+	assert.True(t, summaryNoteRequested(false, false, asked, "prev"))
+	assert.False(t, summaryNoteRequested(false, true, asked, "prev"))
+}
+
+func TestDiffSizeGuardWarning_OnlyPromisesASummaryThatWillBePosted(t *testing.T) {
+	s := mrReviewSettings{MaxDiffBytes: 1000, ContextLines: 3}
+	assert.Contains(t, diffSizeGuardWarning(2048, s), "running a summary-only review with 3 context lines")
+
+	s.InlineOnly = true
+	warning := diffSizeGuardWarning(2048, s)
+	assert.NotContains(t, warning, "summary-only review")
+	assert.Contains(t, warning, "nothing will be posted")
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sanix-darker/prev/internal/config"
+	"github.com/sanix-darker/prev/internal/core"
+	"github.com/sanix-darker/prev/internal/diffparse"
+	"github.com/sanix-darker/prev/internal/handlers"
+	"github.com/sanix-darker/prev/internal/provider"
+	"github.com/sanix-darker/prev/internal/renders"
+	"github.com/sanix-darker/prev/internal/vcs"
+	"github.com/spf13/cobra"
+)
+
+const explainSystemPrompt = "You are an expert code reviewer explaining a single change in a merge request. Be accurate and plain-spoken; avoid boilerplate and emojis."
+
+func newMRExplainCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "explain <project_id> <mr_iid> <file> <line>",
+		Short:   "Explain what a changed line of an MR does and what could go wrong",
+		Example: "prev mr explain my-group/my-project 42 internal/api/server.go 118\nprev mr explain my-group/my-project 42 internal/api/server.go 118 --dry-run",
+		Args:    cobra.ExactArgs(4),
+		Run: func(cmd *cobra.Command, args []string) {
+			conf := config.NewDefaultConfig()
+			applyFlags(cmd, &conf)
+
+			projectID, filePath := args[0], core.NormalizePath(args[2])
+			mrIID, err := strconv.ParseInt(args[1], 10, 64)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: invalid MR IID %q: %v\n", args[1], err)
+				os.Exit(1)
+			}
+			line, err := strconv.Atoi(args[3])
+			if err != nil || line <= 0 {
+				fmt.Fprintf(os.Stderr, "Error: invalid line %q: must be a positive line number\n", args[3])
+				os.Exit(1)
+			}
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			vcsProvider, err := resolveVCSProvider(cmd)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			review, err := handlers.ExtractMRHandlerWithOptions(
+				cmd.Context(), vcsProvider, projectID, mrIID, conf.Strictness,
+				handlers.MRExtractOptions{
+					DiffSource: resolveMRStringSetting(
+						cmd, "mr-diff-source", conf,
+						[]string{"review.mr_diff_source"},
+						"auto",
+					),
+					RepoPath: resolveMRRepoPath(),
+				},
+			)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			prompt, err := buildExplainPrompt(review.MR, review.Changes, filePath, line)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Explaining %s:%d in MR !%d: %s\n\n", filePath, line, review.MR.IID, review.MR.Title)
+			if dryRun {
+				fmt.Println(prompt)
+				fmt.Println("\nDry run: prompt not sent to the AI provider.")
+				return
+			}
+
+			p, err := resolveProvider(conf)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error resolving provider: %v\n", err)
+				os.Exit(1)
+			}
+			_, choices, err := provider.SimpleComplete(p, explainSystemPrompt, "", prompt)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error from AI provider: %v\n", err)
+				os.Exit(1)
+			}
+			if len(choices) == 0 || strings.TrimSpace(choices[0]) == "" {
+				fmt.Fprintln(os.Stderr, "Error: no response from AI provider")
+				os.Exit(1)
+			}
+			fmt.Print(renders.RenderMarkdown(choices[0]))
+		},
+	}
+
+	cmd.Flags().Bool("dry-run", false, "Print the explain prompt without calling the AI provider")
+	cmd.Flags().String("gitlab-token", "", "GitLab personal access token (or use GITLAB_TOKEN env)")
+	cmd.Flags().String("gitlab-token-file", "", "Read the VCS token from this file (or use GITLAB_TOKEN_FILE/GITHUB_TOKEN_FILE env)")
+	cmd.Flags().String("token-command", "", "Shell command printing the VCS token, like a git credential helper (e.g. \"pass show gitlab\")")
+	cmd.Flags().String("gitlab-url", "", "GitLab instance URL (or use GITLAB_URL env, default: https://gitlab.com)")
+	cmd.Flags().String("vcs", "", "VCS provider (gitlab, github, azuredevops; auto-detected from env)")
+	cmd.Flags().String("mr-diff-source", "auto", "MR diff source strategy: auto, git, compare, raw, api")
+	return cmd
+}
+
+// lineInChangedHunk reports whether line (new-side numbering) of filePath
+// falls inside one of the MR's hunks.
+func lineInChangedHunk(changes []diffparse.FileChange, filePath string, line int) bool {
+	for _, c := range changes {
+		if c.NewName != filePath {
+			continue
+		}
+		for _, h := range c.Hunks {
+			if line >= h.NewStart && line < h.NewStart+max(h.NewLines, 1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// buildExplainPrompt asks for a plain-English explanation of filePath:line,
+// using the surrounding hunk slice as context. It fails when the line is not
+// part of the MR's diff.
+func buildExplainPrompt(mr *vcs.MergeRequest, changes []diffparse.FileChange, filePath string, line int) (string, error) {
+	if !lineInChangedHunk(changes, filePath, line) {
+		return "", fmt.Errorf("%s:%d is not within a changed hunk of this MR; pick a line from `prev mr diff`", filePath, line)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Merge request: %s (%s -> %s)\n", mr.Title, mr.SourceBranch, mr.TargetBranch)
+	fmt.Fprintf(&b, "File: %s\nLine: %d\n\n", filePath, line)
+	b.WriteString("Hunk context (prefix, new line number, content):\n```\n")
+	b.WriteString(extractHunkContext(changes, filePath, line))
+	b.WriteString("\n```\n\n")
+	fmt.Fprintf(&b, `Explain line %d in plain English:
+- What the code does and why it likely changed in this MR.
+- Risks it introduces (bugs, edge cases, security, performance), or say none stand out.
+Keep it short and specific to this code; do not review the rest of the MR.`, line)
+	return b.String(), nil
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/diffparse"
+	"github.com/sanix-darker/prev/internal/vcs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func explainTestChanges() []diffparse.FileChange {
+	return []diffparse.FileChange{{
+		NewName: "main.go",
+		Hunks: []diffparse.Hunk{{
+			NewStart: 10,
+			NewLines: 3,
+			Lines: []diffparse.DiffLine{
+				{Type: diffparse.LineContext, Content: "func main() {", NewLineNo: 10},
+				{Type: diffparse.LineAdded, Content: "\tos.Exit(run())", NewLineNo: 11},
+				{Type: diffparse.LineContext, Content: "}", NewLineNo: 12},
+			},
+		}},
+	}}
+}
+
+func TestLineInChangedHunk(t *testing.T) {
+	changes := explainTestChanges()
+	assert.True(t, lineInChangedHunk(changes, "main.go", 10))
+	assert.True(t, lineInChangedHunk(changes, "main.go", 12))
+	assert.False(t, lineInChangedHunk(changes, "main.go", 13))
+	assert.False(t, lineInChangedHunk(changes, "main.go", 9))
+	assert.False(t, lineInChangedHunk(changes, "other.go", 11))
+}
+
+func TestBuildExplainPrompt(t *testing.T) {
+	mr := &vcs.MergeRequest{Title: "Exit with run status", SourceBranch: "feat", TargetBranch: "main"}
+	prompt, err := buildExplainPrompt(mr, explainTestChanges(), "main.go", 11)
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "Exit with run status (feat -> main)")
+	assert.Contains(t, prompt, "+ 11 \tos.Exit(run())")
+	assert.Contains(t, prompt, "Explain line 11 in plain English")
+
+	_, err = buildExplainPrompt(mr, explainTestChanges(), "main.go", 40)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "main.go:40 is not within a changed hunk")
+}
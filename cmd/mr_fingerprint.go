@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"strings"
+
+	"github.com/sanix-darker/prev/internal/vcs"
+)
+
+// findingFingerprint returns a stable "{ruleID}:{fileHash}" identifier for a
+// finding. It ignores the line number so the same finding keeps its ID when
+// surrounding code shifts it up or down.
+func findingFingerprint(filePath, message string) string {
+	path := strings.ToLower(strings.TrimSpace(filePath))
+	sum := sha1.Sum([]byte(path))
+	return memoryRuleID(message) + ":" + fmt.Sprintf("%x", sum[:4])
+}
+
+// fingerprintMarker renders the hidden marker embedded in inline comments so
+// external tools can track findings across reruns.
+func fingerprintMarker(fp string) string {
+	return prevFPPrefix + fp + " -->"
+}
+
+// extractFingerprint returns the fingerprint embedded in body, if any.
+func extractFingerprint(body string) (string, bool) {
+	idx := strings.Index(strings.ToLower(body), prevFPPrefix)
+	if idx < 0 {
+		return "", false
+	}
+	start := idx + len(prevFPPrefix)
+	end := strings.Index(body[start:], "-->")
+	if end < 0 {
+		return "", false
+	}
+	fp := strings.ToLower(strings.TrimSpace(body[start : start+end]))
+	if fp == "" {
+		return "", false
+	}
+	return fp, true
+}
+
+// fingerprintMatchDistance is how many lines apart two findings with the
+// same fingerprint may be and still count as one finding. It tolerates code
+// shifting a finding up or down, while the same message far apart in one
+// file stays two findings.
+const fingerprintMatchDistance = 50
+
+// fingerprintLines maps a fingerprint to the lines its findings sit on.
+// A line <= 0 (a thread with no current anchor) matches any line.
+type fingerprintLines map[string][]int
+
+func (f fingerprintLines) add(fp string, line int) {
+	f[fp] = append(f[fp], line)
+}
+
+// near reports whether fp was recorded within fingerprintMatchDistance of
+// line.
+func (f fingerprintLines) near(fp string, line int) bool {
+	for _, l := range f[fp] {
+		if l <= 0 || line <= 0 || absInt(l-line) <= fingerprintMatchDistance {
+			return true
+		}
+	}
+	return false
+}
+
+// existingFingerprints collects fingerprints, with the lines they are
+// anchored on, from unresolved threads owned by this prev instance.
+func existingFingerprints(discussions []vcs.MRDiscussion) fingerprintLines {
+	out := make(fingerprintLines)
+	for _, d := range discussions {
+		if discussionResolved(d) || isForeignPrevDiscussion(d) {
+			continue
+		}
+		for _, n := range d.Notes {
+			if fp, ok := extractFingerprint(n.Body); ok {
+				out.add(fp, n.Line)
+			}
+		}
+	}
+	return out
+}
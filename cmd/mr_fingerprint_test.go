@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/vcs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindingFingerprint_IgnoresLineAndFormatting(t *testing.T) {
+	a := findingFingerprint("pkg/a.go", "Nil guard missing before dereference.")
+	b := findingFingerprint(" PKG/a.go ", "  nil guard   missing before dereference.")
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, findingFingerprint("pkg/b.go", "Nil guard missing before dereference."))
+	assert.NotEqual(t, a, findingFingerprint("pkg/a.go", "Unchecked error from Close."))
+}
+
+func TestExtractFingerprint_RoundTrip(t *testing.T) {
+	fp := findingFingerprint("a.go", "Nil guard missing.")
+	body := "[HIGH] Nil guard missing.\n\n" + prevThreadMarker + "\n" + fingerprintMarker(fp)
+
+	got, ok := extractFingerprint(body)
+	require.True(t, ok)
+	assert.Equal(t, fp, got)
+
+	sev, msg, ok := severityAndMessage(body)
+	require.True(t, ok)
+	assert.Equal(t, "HIGH", sev)
+	assert.Equal(t, "Nil guard missing.", msg)
+
+	_, ok = extractFingerprint("[HIGH] Nil guard missing.\n\n" + prevThreadMarker)
+	assert.False(t, ok)
+}
+
+func TestExistingFingerprints_MatchesFindingOnShiftedLine(t *testing.T) {
+	msg := "Nil guard missing before dereference."
+	posted := "[HIGH] " + msg + "\n\n" + prevThreadMarker + "\n" + fingerprintMarker(findingFingerprint("a.go", msg))
+	discussions := []vcs.MRDiscussion{{
+		ID: "d1",
+		Notes: []vcs.MRDiscussionNote{
+			{Author: "prev", Body: posted, FilePath: "a.go", Line: 10, Resolvable: true},
+		},
+	}}
+
+	// The same finding now lands 12 lines lower after an unrelated insertion.
+	shiftedKey := inlineKey("a.go", 22, false, msg)
+	_, sameLine := existingInlineKeys(discussions)[shiftedKey]
+	assert.False(t, sameLine)
+
+	assert.True(t, existingFingerprints(discussions).near(findingFingerprint("a.go", msg), 22))
+}
+
+func TestExistingFingerprints_SeparatesDistantSameMessageFindings(t *testing.T) {
+	msg := "Unchecked error from Close."
+	posted := "[LOW] " + msg + "\n\n" + prevThreadMarker + "\n" + fingerprintMarker(findingFingerprint("a.go", msg))
+	discussions := []vcs.MRDiscussion{{
+		ID:    "d1",
+		Notes: []vcs.MRDiscussionNote{{Author: "prev", Body: posted, FilePath: "a.go", Line: 12, Resolvable: true}},
+	}}
+
+	existing := existingFingerprints(discussions)
+	fp := findingFingerprint("a.go", msg)
+	assert.True(t, existing.near(fp, 40), "a shifted copy of the same finding")
+	assert.False(t, existing.near(fp, 240), "the same message far down the file is another finding")
+
+	unanchored := fingerprintLines{}
+	unanchored.add(fp, 0)
+	assert.True(t, unanchored.near(fp, 240), "a thread with no current line matches anywhere")
+}
+
+func TestExistingFingerprints_SkipsResolvedAndForeignThreads(t *testing.T) {
+	t.Cleanup(func() { applyMarkerNamespace("") })
+	msg := "Nil guard missing before dereference."
+	fp := findingFingerprint("a.go", msg)
+
+	applyMarkerNamespace("security")
+	foreign := vcs.MRDiscussion{ID: "sec", Notes: []vcs.MRDiscussionNote{
+		{Author: "prev", Body: "[HIGH] " + msg + "\n\n" + prevThreadMarker + "\n" + fingerprintMarker(fp), FilePath: "a.go", Line: 10, Resolvable: true},
+	}}
+	applyMarkerNamespace("")
+	resolved := vcs.MRDiscussion{ID: "res", Notes: []vcs.MRDiscussionNote{
+		{Author: "prev", Body: "[HIGH] " + msg + "\n\n" + prevThreadMarker + "\n" + fingerprintMarker(fp), FilePath: "a.go", Line: 10, Resolvable: true, Resolved: true},
+	}}
+
+	assert.Empty(t, existingFingerprints([]vcs.MRDiscussion{foreign, resolved}))
+}
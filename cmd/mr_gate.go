@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// normalizeFailOnSeverity validates a --fail-on / review.fail_on value. An
+// empty value disables the gate.
+func normalizeFailOnSeverity(raw string) (string, error) {
+	sev := strings.ToUpper(strings.TrimSpace(raw))
+	if sev == "" || sev == "OFF" || sev == "NONE" {
+		return "", nil
+	}
+	if severityRank(sev) == 0 {
+		return "", fmt.Errorf("invalid fail-on severity %q (use critical, high, medium or low)", raw)
+	}
+	return sev, nil
+}
+
+// severityGate counts the final inline groups at or above threshold. Only
+// findings from this run count: carry-over reminders are never inline groups.
+func severityGate(groups []inlineGroup, threshold string) (count int, failed bool) {
+	if threshold == "" {
+		return 0, false
+	}
+	min := severityRank(threshold)
+	for _, g := range groups {
+		if severityRank(g.Severity) >= min {
+			count++
+		}
+	}
+	return count, count > 0
+}
+
+// severityGateExitCode prints the gate line to w and returns the process exit
+// code: 1 when the gate fails, 0 otherwise.
+func severityGateExitCode(w io.Writer, groups []inlineGroup, threshold string) int {
+	count, failed := severityGate(groups, threshold)
+	fmt.Fprintln(w, severityGateLine(count, threshold, failed))
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// severityGateLine is the one-line gate summary printed after the review.
+func severityGateLine(count int, threshold string, failed bool) string {
+	noun := "findings"
+	if count == 1 {
+		noun = "finding"
+	}
+	verdict := "passing"
+	if failed {
+		verdict = "failing"
+	}
+	return fmt.Sprintf("Gate: %d %s at or above %s — %s", count, noun, threshold, verdict)
+}
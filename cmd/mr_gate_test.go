@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/diffparse"
+	"github.com/sanix-darker/prev/internal/provider"
+)
+
+func TestNormalizeFailOnSeverity(t *testing.T) {
+	cases := map[string]string{"": "", "off": "", " high ": "HIGH", "Critical": "CRITICAL", "low": "LOW"}
+	for in, want := range cases {
+		got, err := normalizeFailOnSeverity(in)
+		if err != nil || got != want {
+			t.Errorf("normalizeFailOnSeverity(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := normalizeFailOnSeverity("blocker"); err == nil {
+		t.Fatal("expected error for unknown severity")
+	}
+}
+
+func TestSeverityGate(t *testing.T) {
+	groups := []inlineGroup{
+		{FilePath: "a.go", NewLine: 1, Severity: "CRITICAL"},
+		{FilePath: "a.go", NewLine: 5, Severity: "HIGH"},
+		{FilePath: "b.go", NewLine: 2, Severity: "MEDIUM"},
+	}
+	if n, failed := severityGate(groups, "HIGH"); n != 2 || !failed {
+		t.Fatalf("HIGH gate = %d, %v; want 2, true", n, failed)
+	}
+	if got := severityGateLine(2, "HIGH", true); got != "Gate: 2 findings at or above HIGH — failing" {
+		t.Fatalf("unexpected gate line %q", got)
+	}
+	if n, failed := severityGate(groups[2:], "HIGH"); n != 0 || failed {
+		t.Fatalf("MEDIUM-only gate = %d, %v; want 0, false", n, failed)
+	}
+	if _, failed := severityGate(groups, ""); failed {
+		t.Fatal("disabled gate must not fail")
+	}
+	// Carry-over reminders alone leave no inline groups.
+	if _, failed := severityGate(nil, "LOW"); failed {
+		t.Fatal("gate must not fire without findings from this run")
+	}
+}
+
+func TestSeverityGateExitCode_DryRunOutput(t *testing.T) {
+	review := `{"summary":"One bug.","findings":[` +
+		`{"file_path":"api/handler.go","line":12,"kind":"ISSUE","severity":"CRITICAL","message":"SQL built from user input."},` +
+		`{"file_path":"api/router.go","line":30,"kind":"ISSUE","severity":"HIGH","message":"Route on an unchanged file."}]}`
+	ai := &scriptedAIProvider{responses: []provider.CompletionResponse{{Content: review}}}
+	outs, err := collectReviewPassOutputs(context.Background(), ai, "BASE_PROMPT", 1, reviewPassOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings := parseReviewContent(outs[len(outs)-1], true).FileComments
+	positions := collectValidPositions([]diffparse.FileChange{{
+		NewName: "api/handler.go",
+		Hunks: []diffparse.Hunk{{
+			NewStart: 11,
+			NewLines: 2,
+			Lines: []diffparse.DiffLine{
+				{Type: diffparse.LineContext, OldLineNo: 11, NewLineNo: 11},
+				{Type: diffparse.LineAdded, NewLineNo: 12},
+			},
+		}},
+	}})
+	// Dry runs recompute the groups that would have been posted.
+	groups := selectInlineGroups(findings, "normal", 3, defaultConventionLabels, positions, "diff_context", 0).Groups
+
+	var out bytes.Buffer
+	if code := severityGateExitCode(&out, groups, "HIGH"); code != 1 {
+		t.Fatalf("exit code = %d; want 1", code)
+	}
+	if got := out.String(); got != "Gate: 1 finding at or above HIGH — failing\n" {
+		t.Fatalf("unexpected gate output %q", got)
+	}
+	out.Reset()
+	if code := severityGateExitCode(&out, nil, "HIGH"); code != 0 {
+		t.Fatalf("empty exit code = %d; want 0", code)
+	}
+}
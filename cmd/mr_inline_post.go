@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sanix-darker/prev/internal/vcs"
+	"golang.org/x/term"
+)
+
+const (
+	// inlinePostRetries is how often an inline comment is re-posted after a
+	// transient failure before it is counted as failed.
+	inlinePostRetries    = 2
+	inlinePostRetryDelay = time.Second
+)
+
+// isTransientPostError reports whether a failed inline post is worth
+// retrying: a dropped connection or a gateway/server error. The VCS client
+// never replays POSTs itself, since the server may have processed them.
+func isTransientPostError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var he *vcs.HTTPError
+	if errors.As(err, &he) {
+		switch he.StatusCode {
+		case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	var ne net.Error
+	return errors.As(err, &ne) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// postInlineWithRetry posts comment and retries transient failures. Before
+// each retry it checks whether the failed attempt landed after all, so a
+// comment the server did accept is not posted twice.
+func postInlineWithRetry(ctx context.Context, vcsProvider vcs.VCSProvider, projectID string, mrIID int64, refs vcs.DiffRefs, comment vcs.InlineComment) error {
+	err := vcsProvider.PostInlineComment(ctx, projectID, mrIID, refs, comment)
+	for attempt := 1; attempt <= inlinePostRetries && isTransientPostError(err); attempt++ {
+		fmt.Fprintf(os.Stderr, "Warning: posting inline comment on %s:%d failed (%v); retrying (%d/%d).\n",
+			comment.FilePath, inlineCommentLine(comment), err, attempt, inlinePostRetries)
+		if serr := inlineRequeueSleep(ctx, time.Duration(attempt)*inlinePostRetryDelay); serr != nil {
+			return err
+		}
+		if inlineCommentLanded(ctx, vcsProvider, projectID, mrIID, comment.Body) {
+			return nil
+		}
+		err = vcsProvider.PostInlineComment(ctx, projectID, mrIID, refs, comment)
+	}
+	return err
+}
+
+// inlineCommentLanded reports whether a note with body already exists on
+// the MR. A failed lookup counts as not landed.
+func inlineCommentLanded(ctx context.Context, vcsProvider vcs.VCSProvider, projectID string, mrIID int64, body string) bool {
+	discussions, err := vcsProvider.ListMRDiscussions(ctx, projectID, mrIID)
+	if err != nil {
+		return false
+	}
+	body = strings.TrimSpace(body)
+	for _, d := range discussions {
+		for _, n := range d.Notes {
+			if strings.TrimSpace(n.Body) == body {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inlineProgress reports inline posting as it happens. With verbose every
+// comment gets its own line; otherwise a terminal gets one counter line
+// rewritten in place and other outputs get nothing until the final count.
+type inlineProgress struct {
+	w       io.Writer
+	total   int
+	posted  int
+	failed  int
+	verbose bool
+	live    bool
+}
+
+func newInlineProgress(w *os.File, total int, verbose bool) *inlineProgress {
+	return &inlineProgress{
+		w:       w,
+		total:   total,
+		verbose: verbose,
+		live:    !verbose && term.IsTerminal(int(w.Fd())),
+	}
+}
+
+// record counts one post attempt; err is nil when the comment was posted.
+func (p *inlineProgress) record(comment vcs.InlineComment, err error) {
+	if err != nil {
+		p.failed++
+	} else {
+		p.posted++
+	}
+	switch {
+	case p.verbose && err != nil:
+		fmt.Fprintf(p.w, "  [%d/%d] failed %s:%d\n", p.posted+p.failed, p.total, comment.FilePath, inlineCommentLine(comment))
+	case p.verbose:
+		fmt.Fprintf(p.w, "  [%d/%d] posted %s:%d\n", p.posted+p.failed, p.total, comment.FilePath, inlineCommentLine(comment))
+	case p.live:
+		fmt.Fprintf(p.w, "\rPosting inline comments: %d/%d", p.posted+p.failed, p.total)
+		if p.failed > 0 {
+			fmt.Fprintf(p.w, " (%d failed)", p.failed)
+		}
+	}
+}
+
+// finish ends the live counter line.
+func (p *inlineProgress) finish() {
+	if p.live && p.posted+p.failed > 0 {
+		fmt.Fprintln(p.w)
+	}
+}
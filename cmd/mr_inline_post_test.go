@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/vcs"
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyInlineVCSProvider fails the first len(errs) inline posts with errs
+// in order, optionally recording a failed post as landed anyway.
+type flakyInlineVCSProvider struct {
+	vcs.VCSProvider
+	errs       []error
+	landOnFail bool
+	calls      int
+	posted     []string
+}
+
+func (f *flakyInlineVCSProvider) PostInlineComment(_ context.Context, _ string, _ int64, _ vcs.DiffRefs, c vcs.InlineComment) error {
+	f.calls++
+	if f.calls <= len(f.errs) {
+		if f.landOnFail {
+			f.posted = append(f.posted, c.Body)
+		}
+		return f.errs[f.calls-1]
+	}
+	f.posted = append(f.posted, c.Body)
+	return nil
+}
+
+func (f *flakyInlineVCSProvider) ListMRDiscussions(context.Context, string, int64) ([]vcs.MRDiscussion, error) {
+	var notes []vcs.MRDiscussionNote
+	for _, b := range f.posted {
+		notes = append(notes, vcs.MRDiscussionNote{Body: b})
+	}
+	return []vcs.MRDiscussion{{ID: "d1", Notes: notes}}, nil
+}
+
+func TestPostInlineWithRetry_RetriesTransientErrors(t *testing.T) {
+	waits := stubInlineRequeueSleep(t)
+	f := &flakyInlineVCSProvider{errs: []error{
+		&vcs.HTTPError{Provider: "gitlab", StatusCode: http.StatusBadGateway},
+		io.ErrUnexpectedEOF,
+	}}
+
+	err := postInlineWithRetry(context.Background(), f, "g/p", 1, vcs.DiffRefs{}, vcs.InlineComment{FilePath: "a.go", NewLine: 3, Body: "finding"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, f.calls)
+	assert.Equal(t, []string{"finding"}, f.posted)
+	assert.Len(t, *waits, 2)
+}
+
+func TestPostInlineWithRetry_GivesUpAfterRetries(t *testing.T) {
+	stubInlineRequeueSleep(t)
+	gateway := &vcs.HTTPError{Provider: "gitlab", StatusCode: http.StatusGatewayTimeout}
+	f := &flakyInlineVCSProvider{errs: []error{gateway, gateway, gateway}}
+
+	err := postInlineWithRetry(context.Background(), f, "g/p", 1, vcs.DiffRefs{}, vcs.InlineComment{FilePath: "a.go", NewLine: 3, Body: "finding"})
+	assert.ErrorIs(t, err, gateway)
+	assert.Equal(t, 1+inlinePostRetries, f.calls)
+}
+
+func TestPostInlineWithRetry_DoesNotRetryPermanentErrors(t *testing.T) {
+	stubInlineRequeueSleep(t)
+	forbidden := &vcs.HTTPError{Provider: "gitlab", StatusCode: http.StatusForbidden}
+	f := &flakyInlineVCSProvider{errs: []error{forbidden}}
+
+	err := postInlineWithRetry(context.Background(), f, "g/p", 1, vcs.DiffRefs{}, vcs.InlineComment{FilePath: "a.go", NewLine: 3, Body: "finding"})
+	assert.ErrorIs(t, err, forbidden)
+	assert.Equal(t, 1, f.calls)
+}
+
+func TestPostInlineWithRetry_SkipsRetryWhenFailedPostLanded(t *testing.T) {
+	stubInlineRequeueSleep(t)
+	f := &flakyInlineVCSProvider{
+		errs:       []error{&vcs.HTTPError{Provider: "gitlab", StatusCode: http.StatusBadGateway}},
+		landOnFail: true,
+	}
+
+	err := postInlineWithRetry(context.Background(), f, "g/p", 1, vcs.DiffRefs{}, vcs.InlineComment{FilePath: "a.go", NewLine: 3, Body: "finding"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, f.calls, "the comment is not posted twice")
+}
+
+func TestIsTransientPostError(t *testing.T) {
+	assert.False(t, isTransientPostError(nil))
+	assert.False(t, isTransientPostError(context.Canceled))
+	assert.False(t, isTransientPostError(errors.New("boom")))
+	assert.False(t, isTransientPostError(&vcs.HTTPError{StatusCode: http.StatusUnprocessableEntity}))
+	assert.True(t, isTransientPostError(&vcs.HTTPError{StatusCode: http.StatusBadGateway}))
+}
+
+func TestInlineProgress_VerboseLogsEachComment(t *testing.T) {
+	var buf bytes.Buffer
+	p := &inlineProgress{w: &buf, total: 3, verbose: true}
+	p.record(vcs.InlineComment{FilePath: "a.go", NewLine: 4}, nil)
+	p.record(vcs.InlineComment{FilePath: "b.go", OldLine: 9, OnDeleted: true}, errors.New("boom"))
+	p.finish()
+
+	assert.Equal(t, "  [1/3] posted a.go:4\n  [2/3] failed b.go:9\n", buf.String())
+}
+
+func TestInlineProgress_LiveCounterRewritesLine(t *testing.T) {
+	var buf bytes.Buffer
+	p := &inlineProgress{w: &buf, total: 2, live: true}
+	p.record(vcs.InlineComment{FilePath: "a.go", NewLine: 4}, nil)
+	p.record(vcs.InlineComment{FilePath: "b.go", NewLine: 5}, errors.New("boom"))
+	p.finish()
+
+	assert.Equal(t, "\rPosting inline comments: 1/2\rPosting inline comments: 2/2 (1 failed)\n", buf.String())
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// defaultInlineTemplate is the inline comment line prev posts when
+// review.inline_template is unset.
+const defaultInlineTemplate = "[{{.Severity}}] {{.Message}}"
+
+// inlineTemplateData is what review.inline_template is executed against:
+//
+//	**{{.Severity}}** {{.Message}}
+//	<sub>{{.File}}:{{.Line}} · [severity guide](https://wiki.example.com/review/{{lower .Severity}})</sub>
+//
+// The suggestion block is still appended after the rendered text, so a
+// template only needs .Suggestion to mention that one exists.
+type inlineTemplateData struct {
+	Severity   string // upper-cased, MEDIUM when the finding has none
+	Message    string // the finding's first actionable key point
+	Suggestion string // normalized suggested replacement, empty when none
+	File       string
+	Line       int
+}
+
+var inlineTemplateFuncs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// parseInlineTemplate parses a review.inline_template value and executes it
+// once against sample data, so unknown fields fail at startup rather than on
+// the first posted comment.
+func parseInlineTemplate(text string) (*template.Template, error) {
+	t, err := template.New("inline").Funcs(inlineTemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid review.inline_template: %w", err)
+	}
+	sample := inlineTemplateData{Severity: "HIGH", Message: "Check the error.", Suggestion: "return err", File: "main.go", Line: 1}
+	if _, err := renderInlineTemplate(t, sample); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// renderInlineTemplate executes t against data.
+func renderInlineTemplate(t *template.Template, data inlineTemplateData) (string, error) {
+	var sb strings.Builder
+	if err := t.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("invalid review.inline_template: %w", err)
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+var defaultInlineTmpl = template.Must(template.New("inline").Funcs(inlineTemplateFuncs).Parse(defaultInlineTemplate))
+
+// inlineFindingMarker hides line, the default "[SEV] message" line, in a
+// templated inline body so severityAndMessage can still read it back.
+func inlineFindingMarker(line string) string {
+	return prevFindingPrefix + strings.ReplaceAll(line, "-->", "-- >") + " -->"
+}
+
+var inlineFindingMarkerRe = regexp.MustCompile(`<!-- prev:(?:[a-z0-9][a-z0-9_-]*:)?finding (.+?) -->`)
+
+// inlineFindingMarkerLine returns the "[SEV] message" line hidden in body by
+// inlineFindingMarker.
+func inlineFindingMarkerLine(body string) (string, bool) {
+	m := inlineFindingMarkerRe.FindStringSubmatch(body)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
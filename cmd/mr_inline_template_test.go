@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildInlineCommentBody_CustomTemplate(t *testing.T) {
+	tmpl, err := parseInlineTemplate("**{{.Severity}}** {{.Message}}\n<sub>{{.File}}:{{.Line}} · [guide](https://docs.example.com/{{lower .Severity}})</sub>")
+	require.NoError(t, err)
+
+	body := buildInlineCommentBody(
+		"api/handler.go",
+		42,
+		"high",
+		"Key points:\n- Missing nil check in handler.",
+		"if h == nil {\n\treturn err\n}",
+		func(s string) string { return "```suggestion\n" + s + "\n```" },
+		inlineBodyLimits{},
+		tmpl,
+	)
+	assert.Equal(t, "**HIGH** Missing nil check in handler.\n"+
+		"<sub>api/handler.go:42 · [guide](https://docs.example.com/high)</sub>\n\n"+
+		"<!-- prev:finding [HIGH] Missing nil check in handler. -->\n\n"+
+		"Suggested patch:\n```suggestion\nif h == nil {\n\treturn err\n}\n```", body)
+}
+
+func TestBuildInlineCommentBody_TemplateRoundTripsSeverityAndMessage(t *testing.T) {
+	msg := "Key points:\n- Missing nil check in handler. The caller panics."
+	plain := buildInlineCommentBody("a.go", 1, "HIGH", msg, "", nil, inlineBodyLimits{}, nil)
+	assert.Equal(t, "[HIGH] Missing nil check in handler.", plain)
+	wantSev, wantMsg, ok := severityAndMessage(plain)
+	require.True(t, ok)
+
+	for _, text := range []string{
+		defaultInlineTemplate,
+		"**{{.Severity}}** {{.Message}}",
+		"{{.Message}}\n\n_{{lower .Severity}} · {{.File}}:{{.Line}}_",
+		"Looks off --> {{.Message}}",
+	} {
+		tmpl, err := parseInlineTemplate(text)
+		require.NoError(t, err, text)
+		body := buildInlineCommentBody("a.go", 1, "HIGH", msg, "", nil, inlineBodyLimits{}, tmpl)
+		body += "\n\n" + prevThreadMarker + "\n" + fingerprintMarker(findingFingerprint("a.go", msg))
+
+		sev, got, ok := severityAndMessage(body)
+		require.True(t, ok, text)
+		assert.Equal(t, wantSev, sev, text)
+		assert.Equal(t, wantMsg, got, text)
+	}
+}
+
+func TestInlineFindingMarker_NamespacedAndEscaped(t *testing.T) {
+	t.Cleanup(func() { applyMarkerNamespace("") })
+	applyMarkerNamespace("team-a")
+
+	marker := inlineFindingMarker("[LOW] Arrow --> in message")
+	assert.Equal(t, "<!-- prev:team-a:finding [LOW] Arrow -- > in message -->", marker)
+	ns, ok := markerNamespaceOf("**LOW** x\n\n" + marker)
+	require.True(t, ok)
+	assert.Equal(t, "team-a", ns)
+
+	sev, msg, ok := severityAndMessage("**LOW** x\n\n" + marker)
+	require.True(t, ok)
+	assert.Equal(t, "LOW", sev)
+	assert.Equal(t, "Arrow -- > in message", msg)
+}
+
+func TestParseInlineTemplate_Invalid(t *testing.T) {
+	_, err := parseInlineTemplate("{{.Severity")
+	assert.ErrorContains(t, err, "review.inline_template")
+
+	_, err = parseInlineTemplate("{{.Author}} {{.Message}}")
+	assert.ErrorContains(t, err, "review.inline_template", "unknown fields fail at parse time")
+}
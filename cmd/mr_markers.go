@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sanix-darker/prev/internal/config"
+	"github.com/sanix-darker/prev/internal/vcs"
+)
+
+var prevMarkerRe = regexp.MustCompile(`<!-- prev:(?:([a-z0-9][a-z0-9_-]*):)?(thread|carry-over|reply|summary|ignore|reuse|resolved|unplaced|baseline|reconsider|recurring|finding)[ -]`)
+
+// resolveMarkerNamespace returns the normalized review.marker_namespace, or
+// an empty string when unset or invalid.
+func resolveMarkerNamespace(conf config.Config) string {
+	if conf.Viper == nil {
+		return ""
+	}
+	return normalizeMarkerNamespace(conf.Viper.GetString("review.marker_namespace"))
+}
+
+func normalizeMarkerNamespace(raw string) string {
+	ns := strings.ToLower(strings.TrimSpace(raw))
+	if ns == "" {
+		return ""
+	}
+	if ok, _ := regexp.MatchString(`^[a-z0-9][a-z0-9_-]{0,31}$`, ns); !ok {
+		return ""
+	}
+	return ns
+}
+
+// applyMarkerNamespace rewrites the bot markers so that several prev
+// instances reviewing the same MR only recognize their own notes.
+func applyMarkerNamespace(ns string) {
+	ns = normalizeMarkerNamespace(ns)
+	prefix := "<!-- prev:"
+	if ns != "" {
+		prefix += ns + ":"
+	}
+	prevMarkerNamespace = ns
+	prevThreadMarker = prefix + "thread -->"
+	prevCarryOverMarker = prefix + "carry-over -->"
+	prevReplyMarker = prefix + "reply -->"
+	prevSummaryMarker = prefix + "summary -->"
+	prevSummaryEndMarker = prefix + "summary-end -->"
+	prevIgnoreMarker = prefix + "ignore -->"
+	prevReuseMarker = prefix + "reuse -->"
+	prevResolvedMarker = prefix + "resolved -->"
+	prevUnplacedMarker = prefix + "unplaced -->"
+	prevBaselinePrefix = prefix + "baseline "
+	prevFPPrefix = prefix + "fp:"
+	prevReconsiderPrefix = prefix + "reconsider "
+	prevRecurringPrefix = prefix + "recurring "
+	prevFindingPrefix = prefix + "finding "
+}
+
+// markerNamespaceOf reports the namespace of the first prev marker in body.
+func markerNamespaceOf(body string) (string, bool) {
+	m := prevMarkerRe.FindStringSubmatch(strings.ToLower(body))
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// isForeignPrevDiscussion reports whether a discussion was started by a prev
+// instance running under a different marker namespace.
+func isForeignPrevDiscussion(d vcs.MRDiscussion) bool {
+	if len(d.Notes) == 0 {
+		return false
+	}
+	ns, ok := markerNamespaceOf(d.Notes[0].Body)
+	return ok && ns != prevMarkerNamespace
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/config"
+	"github.com/sanix-darker/prev/internal/vcs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func useMarkerNamespace(t *testing.T, ns string) {
+	t.Helper()
+	applyMarkerNamespace(ns)
+	t.Cleanup(func() { applyMarkerNamespace("") })
+}
+
+func namespacedThread(id, ns string) vcs.MRDiscussion {
+	applyMarkerNamespace(ns)
+	body := "[HIGH] Nil guard missing before dereference.\n\n" + prevThreadMarker
+	return vcs.MRDiscussion{ID: id, Notes: []vcs.MRDiscussionNote{
+		{Author: "bot", Body: body, FilePath: "a.go", Line: 10, Resolvable: true},
+	}}
+}
+
+func TestApplyMarkerNamespace_RewritesMarkers(t *testing.T) {
+	useMarkerNamespace(t, "Security")
+	assert.Equal(t, "<!-- prev:security:thread -->", prevThreadMarker)
+	assert.Equal(t, "<!-- prev:security:summary -->", prevSummaryMarker)
+	assert.Equal(t, "<!-- prev:security:baseline ", prevBaselinePrefix)
+
+	applyMarkerNamespace("")
+	assert.Equal(t, "<!-- prev:thread -->", prevThreadMarker)
+}
+
+func TestMarkerNamespaces_DoNotCrossDetectThreads(t *testing.T) {
+	t.Cleanup(func() { applyMarkerNamespace("") })
+	security := namespacedThread("sec-1", "security")
+	style := namespacedThread("style-1", "style")
+	legacy := namespacedThread("legacy-1", "")
+	discussions := []vcs.MRDiscussion{security, style, legacy}
+
+	applyMarkerNamespace("security")
+	assert.True(t, isPrevThread(security, "prev"))
+	assert.False(t, isPrevThread(style, "prev"))
+	assert.False(t, isPrevThread(legacy, "prev"))
+	assert.Len(t, existingInlineKeys(discussions), 1)
+	reusable := collectReusableThreads(discussions, nil, "prev", nil, nil)
+	require.Len(t, reusable, 1)
+	assert.Equal(t, "sec-1", reusable[0].DiscussionID)
+
+	applyMarkerNamespace("style")
+	assert.False(t, isPrevThread(security, "prev"))
+	assert.True(t, isPrevThread(style, "prev"))
+
+	applyMarkerNamespace("")
+	assert.True(t, isPrevThread(legacy, "prev"))
+	assert.False(t, isPrevThread(security, "prev"))
+}
+
+func TestMarkerNamespaces_SummaryNotesAreScoped(t *testing.T) {
+	useMarkerNamespace(t, "style")
+	notes := []vcs.MRNote{{Body: buildSummaryNoteBody("style review", false)}}
+
+	assert.True(t, hasTopLevelMarker(notes, prevSummaryMarker))
+	applyMarkerNamespace("security")
+	assert.False(t, hasTopLevelMarker(notes, prevSummaryMarker))
+}
+
+func TestResolveMarkerNamespace(t *testing.T) {
+	v := config.NewStore()
+	v.Set("review.marker_namespace", " Security ")
+	assert.Equal(t, "security", resolveMarkerNamespace(config.Config{Viper: v}))
+
+	v.Set("review.marker_namespace", "bad namespace!")
+	assert.Equal(t, "", resolveMarkerNamespace(config.Config{Viper: v}))
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/sha1"
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -20,6 +21,13 @@ import (
 const (
 	defaultReviewMemoryFile = ".prev/review-memory.md"
 	reviewMemoryVersion     = 1
+
+	// defaultReviewMemoryDecayDays is the default review.memory_decay_days
+	// half-life.
+	defaultReviewMemoryDecayDays = 90
+	// reviewMemoryExpiryHalfLives is how many half-lives an entry may go
+	// unseen before it is pruned regardless of the entry cap.
+	reviewMemoryExpiryHalfLives = 4
 )
 
 var reviewMemoryJSONFence = regexp.MustCompile("(?s)```prev-memory-json\\s*(\\{.*?\\})\\s*```")
@@ -109,7 +117,75 @@ func parseReviewMemoryMarkdown(raw []byte) (reviewMemory, error) {
 	return mem, nil
 }
 
+// saveReviewMemory replaces the memory at path with mem, under the memory
+// lock. Use it when entries are meant to disappear (prune, reset); review
+// runs use mergeReviewMemoryFile so concurrent jobs do not drop each other's
+// entries.
 func saveReviewMemory(path string, mem reviewMemory) error {
+	unlock, err := lockReviewMemory(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return writeReviewMemory(path, mem)
+}
+
+// mergeReviewMemoryFile re-reads the memory at path under the memory lock,
+// unions it with mem by entry ID (the copy seen most recently wins, mem on a
+// tie), trims the result to maxEntries using decay and writes it back. It
+// returns the memory as written.
+func mergeReviewMemoryFile(path string, mem reviewMemory, maxEntries int, decay reviewMemoryDecay) (reviewMemory, error) {
+	unlock, err := lockReviewMemory(path)
+	if err != nil {
+		return mem, err
+	}
+	defer unlock()
+
+	onDisk := reviewMemory{Version: reviewMemoryVersion}
+	if raw, rerr := os.ReadFile(path); rerr == nil {
+		if onDisk, err = parseReviewMemoryMarkdown(raw); err != nil {
+			return mem, err
+		}
+	} else if !os.IsNotExist(rerr) {
+		return mem, rerr
+	}
+	merged := mergeReviewMemories(onDisk, mem)
+	trimReviewMemory(&merged, maxEntries, decay)
+	if err := writeReviewMemory(path, merged); err != nil {
+		return mem, err
+	}
+	return merged, nil
+}
+
+// mergeReviewMemories unions base and ours by entry ID. For an ID in both,
+// the entry with the later LastSeen wins, ours on a tie.
+func mergeReviewMemories(base, ours reviewMemory) reviewMemory {
+	normalizeReviewMemory(&base)
+	normalizeReviewMemory(&ours)
+	out := reviewMemory{Version: reviewMemoryVersion, Entries: make([]reviewMemoryEntry, 0, len(base.Entries)+len(ours.Entries))}
+	index := make(map[string]int, len(base.Entries)+len(ours.Entries))
+	for _, e := range base.Entries {
+		index[e.ID] = len(out.Entries)
+		out.Entries = append(out.Entries, e)
+	}
+	for _, e := range ours.Entries {
+		i, ok := index[e.ID]
+		if !ok {
+			index[e.ID] = len(out.Entries)
+			out.Entries = append(out.Entries, e)
+			continue
+		}
+		if e.LastSeen >= out.Entries[i].LastSeen {
+			out.Entries[i] = e
+		}
+	}
+	normalizeReviewMemory(&out)
+	return out
+}
+
+// writeReviewMemory renders mem and atomically replaces path with it. The
+// caller holds the memory lock.
+func writeReviewMemory(path string, mem reviewMemory) error {
 	normalizeReviewMemory(&mem)
 	mem.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
 	raw, err := json.MarshalIndent(mem, "", "  ")
@@ -120,7 +196,23 @@ func saveReviewMemory(path string, mem reviewMemory) error {
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return err
 	}
-	return os.WriteFile(path, []byte(content), 0o644)
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
 }
 
 func renderReviewMemoryMarkdown(mem reviewMemory, payload string) string {
@@ -349,7 +441,7 @@ func updateReviewMemoryFromDiscussions(mem *reviewMemory, discussions []vcs.MRDi
 func updateReviewMemoryFromFindings(mem *reviewMemory, findings []core.FileComment, mrRef string, now time.Time) bool {
 	changed := false
 	for _, f := range findings {
-		filePath := strings.TrimSpace(strings.TrimPrefix(f.FilePath, "./"))
+		filePath := core.NormalizePath(f.FilePath)
 		if filePath == "" || f.Line <= 0 || strings.TrimSpace(f.Message) == "" {
 			continue
 		}
@@ -451,7 +543,7 @@ func upsertReviewMemory(
 		PrimarySymbol: semanticPrimarySymbol(message, filePath),
 		Status:        status,
 		Severity:      strings.ToUpper(strings.TrimSpace(severity)),
-		FilePath:      strings.TrimSpace(filePath),
+		FilePath:      core.NormalizePath(filePath),
 		Line:          line,
 		Message:       strings.TrimSpace(message),
 		FirstSeen:     when,
@@ -473,11 +565,15 @@ func upsertReviewMemory(
 	return true
 }
 
-func appendReviewMemoryGuidelines(guidelines string, mem reviewMemory, changes []diffparse.FileChange, maxItems int) string {
+func appendReviewMemoryGuidelines(guidelines string, mem reviewMemory, changes []diffparse.FileChange, maxItems int, decay reviewMemoryDecay) string {
 	if maxItems <= 0 {
 		maxItems = 10
 	}
+	// mem is a copy, but its Entries share the caller's backing array;
+	// normalizing sorts and pruning filters in place, so work on our own.
+	mem.Entries = append([]reviewMemoryEntry(nil), mem.Entries...)
 	normalizeReviewMemory(&mem)
+	decay.dropExpired(&mem)
 	if len(mem.Entries) == 0 {
 		return guidelines
 	}
@@ -496,8 +592,17 @@ func appendReviewMemoryGuidelines(guidelines string, mem reviewMemory, changes [
 	changedKeywords := changedTextKeywords(changes)
 
 	type scoredEntry struct {
-		entry reviewMemoryEntry
-		score int
+		entry  reviewMemoryEntry
+		score  int
+		weight float64
+	}
+	byRelevance := func(items []scoredEntry) {
+		sort.SliceStable(items, func(i, j int) bool {
+			if items[i].score != items[j].score {
+				return items[i].score > items[j].score
+			}
+			return items[i].weight > items[j].weight
+		})
 	}
 	relevant := make([]scoredEntry, 0, maxItems)
 	for _, e := range mem.Entries {
@@ -505,7 +610,7 @@ func appendReviewMemoryGuidelines(guidelines string, mem reviewMemory, changes [
 		if score <= 0 {
 			continue
 		}
-		relevant = append(relevant, scoredEntry{entry: e, score: score})
+		relevant = append(relevant, scoredEntry{entry: e, score: score, weight: decay.score(e)})
 	}
 	if len(relevant) == 0 {
 		for _, e := range mem.Entries {
@@ -513,28 +618,18 @@ func appendReviewMemoryGuidelines(guidelines string, mem reviewMemory, changes [
 				continue
 			}
 			relevant = append(relevant, scoredEntry{
-				entry: e,
-				score: semanticEvidenceScore(e, changedPaths, changedSymbols, changedKeywords),
+				entry:  e,
+				score:  semanticEvidenceScore(e, changedPaths, changedSymbols, changedKeywords),
+				weight: decay.score(e),
 			})
-			if len(relevant) >= minInt(3, maxItems) {
-				break
-			}
 		}
+		byRelevance(relevant)
+		relevant = relevant[:minInt(len(relevant), minInt(3, maxItems))]
 	}
 	if len(relevant) == 0 {
 		return guidelines
 	}
-	sort.Slice(relevant, func(i, j int) bool {
-		if relevant[i].score != relevant[j].score {
-			return relevant[i].score > relevant[j].score
-		}
-		ri := severityRank(relevant[i].entry.Severity)
-		rj := severityRank(relevant[j].entry.Severity)
-		if ri != rj {
-			return ri > rj
-		}
-		return relevant[i].entry.LastSeen > relevant[j].entry.LastSeen
-	})
+	byRelevance(relevant)
 
 	lines := []string{
 		"Historical reviewer memory from prior MRs (revalidated against the current diff before injection):",
@@ -561,18 +656,17 @@ func appendReviewMemoryGuidelines(guidelines string, mem reviewMemory, changes [
 	return guidelines + "\n" + block
 }
 
-func trimReviewMemory(mem *reviewMemory, maxEntries int) {
+// trimReviewMemory drops entries expired under decay, then keeps the
+// maxEntries entries with the highest decayed score.
+func trimReviewMemory(mem *reviewMemory, maxEntries int, decay reviewMemoryDecay) {
+	decay.dropExpired(mem)
 	if maxEntries <= 0 || len(mem.Entries) <= maxEntries {
 		return
 	}
 	sort.SliceStable(mem.Entries, func(i, j int) bool {
-		if mem.Entries[i].Status != mem.Entries[j].Status {
-			return mem.Entries[i].Status == "open"
-		}
-		ri := severityRank(mem.Entries[i].Severity)
-		rj := severityRank(mem.Entries[j].Severity)
-		if ri != rj {
-			return ri > rj
+		si, sj := decay.score(mem.Entries[i]), decay.score(mem.Entries[j])
+		if si != sj {
+			return si > sj
 		}
 		if mem.Entries[i].LastSeen != mem.Entries[j].LastSeen {
 			return mem.Entries[i].LastSeen > mem.Entries[j].LastSeen
@@ -582,6 +676,66 @@ func trimReviewMemory(mem *reviewMemory, maxEntries int) {
 	mem.Entries = mem.Entries[:maxEntries]
 }
 
+// reviewMemoryDecay ages memory entries by how long ago they were last seen.
+// An entry's weight halves every HalfLifeDays, and entries unseen for
+// reviewMemoryExpiryHalfLives half-lives are pruned. Ignored entries never
+// decay. HalfLifeDays <= 0 disables decay.
+type reviewMemoryDecay struct {
+	HalfLifeDays int
+	Now          time.Time
+}
+
+// score ranks e by severity, recurrence and recency. Fixed findings weigh
+// half as much as open or ignored ones, which still steer future reviews.
+func (d reviewMemoryDecay) score(e reviewMemoryEntry) float64 {
+	weight := float64(severityRank(e.Severity)+1) * (1 + math.Log2(1+float64(max(e.Hits, 0))))
+	if e.Status == "fixed" {
+		weight /= 2
+	}
+	if age, ok := d.age(e); ok {
+		weight *= math.Exp2(-age.Hours() / 24 / float64(d.HalfLifeDays))
+	}
+	return weight
+}
+
+// expired reports whether e has gone unseen long enough to be pruned.
+func (d reviewMemoryDecay) expired(e reviewMemoryEntry) bool {
+	age, ok := d.age(e)
+	return ok && age > time.Duration(d.HalfLifeDays*reviewMemoryExpiryHalfLives)*24*time.Hour
+}
+
+// age returns how long ago e was last seen. ok is false when decay is
+// disabled, LastSeen cannot be parsed, or e is ignored: a suppressed finding
+// stays suppressed however long it goes unseen.
+func (d reviewMemoryDecay) age(e reviewMemoryEntry) (time.Duration, bool) {
+	if d.HalfLifeDays <= 0 || normalizeMemoryStatus(e.Status) == "ignored" {
+		return 0, false
+	}
+	last := parseMemoryTime(e.LastSeen)
+	if last.IsZero() {
+		return 0, false
+	}
+	now := d.Now
+	if now.IsZero() {
+		now = time.Now().UTC()
+	}
+	return max(now.Sub(last), 0), true
+}
+
+// dropExpired removes expired entries from mem.
+func (d reviewMemoryDecay) dropExpired(mem *reviewMemory) {
+	if d.HalfLifeDays <= 0 {
+		return
+	}
+	kept := mem.Entries[:0]
+	for _, e := range mem.Entries {
+		if !d.expired(e) {
+			kept = append(kept, e)
+		}
+	}
+	mem.Entries = kept
+}
+
 func filterIgnoredFindings(findings []core.FileComment, mem reviewMemory, ignored []ignoredFinding) []core.FileComment {
 	if len(findings) == 0 {
 		return findings
@@ -615,7 +769,7 @@ func filterIgnoredFindings(findings []core.FileComment, mem reviewMemory, ignore
 }
 
 func ignoredMatchesFinding(finding core.FileComment, ignored []ignoredFinding) bool {
-	filePath := strings.TrimSpace(strings.TrimPrefix(finding.FilePath, "./"))
+	filePath := core.NormalizePath(finding.FilePath)
 	if filePath == "" {
 		return false
 	}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package cmd
+
+// lockReviewMemory is a no-op where flock is unavailable; saves still merge
+// with the file on disk and replace it atomically.
+func lockReviewMemory(string) (func(), error) {
+	return func() {}, nil
+}
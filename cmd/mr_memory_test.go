@@ -1,9 +1,11 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -50,6 +52,58 @@ func TestReviewMemory_SaveAndLoadMarkdown(t *testing.T) {
 	assert.Equal(t, "open", loaded.Entries[0].Status)
 }
 
+func TestMergeReviewMemoryFile_ConcurrentDisjointUpsertsSurvive(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".prev", "review-memory.md")
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	const jobs = 8
+	var wg sync.WaitGroup
+	errs := make([]error, jobs)
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Each job loads before any other saves, like parallel CI reviews.
+			mem, _, err := loadReviewMemory(dir, "")
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			upsertReviewMemory(&mem, "", fmt.Sprintf("file%d.go", i), 10, "HIGH", fmt.Sprintf("Finding number %d", i), "open", fmt.Sprintf("grp/proj!%d", i), now)
+			_, errs[i] = mergeReviewMemoryFile(path, mem, 500, reviewMemoryDecay{HalfLifeDays: defaultReviewMemoryDecayDays, Now: now})
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	mem, _, err := loadReviewMemory(dir, "")
+	require.NoError(t, err)
+	require.Len(t, mem.Entries, jobs)
+}
+
+func TestMergeReviewMemories_LatestLastSeenWins(t *testing.T) {
+	base := reviewMemory{Entries: []reviewMemoryEntry{
+		{ID: "a", Status: "open", Message: "disk newer", LastSeen: "2026-01-02T00:00:00Z"},
+		{ID: "b", Status: "open", Message: "disk only", LastSeen: "2026-01-01T00:00:00Z"},
+	}}
+	ours := reviewMemory{Entries: []reviewMemoryEntry{
+		{ID: "a", Status: "fixed", Message: "ours stale", LastSeen: "2026-01-01T00:00:00Z"},
+		{ID: "c", Status: "open", Message: "ours only", LastSeen: "2026-01-03T00:00:00Z"},
+	}}
+	merged := mergeReviewMemories(base, ours)
+	byID := map[string]reviewMemoryEntry{}
+	for _, e := range merged.Entries {
+		byID[e.ID] = e
+	}
+	require.Len(t, byID, 3)
+	assert.Equal(t, "disk newer", byID["a"].Message)
+	assert.Equal(t, "disk only", byID["b"].Message)
+	assert.Equal(t, "ours only", byID["c"].Message)
+}
+
 func TestUpdateReviewMemoryFromDiscussions_OpenBeatsFixed(t *testing.T) {
 	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
 	mem := reviewMemory{Version: reviewMemoryVersion}
@@ -206,7 +260,7 @@ func TestAppendReviewMemoryGuidelines_UsesRelevantChangedFiles(t *testing.T) {
 		{NewName: "public/index.php"},
 	}
 
-	out := appendReviewMemoryGuidelines("Base", mem, changes, 10)
+	out := appendReviewMemoryGuidelines("Base", mem, changes, 10, reviewMemoryDecay{})
 	assert.Contains(t, out, "Historical reviewer memory")
 	assert.Contains(t, out, "OPEN `public/index.php:31` [HIGH]")
 	assert.NotContains(t, out, "other/file.go")
@@ -299,7 +353,74 @@ func TestAppendReviewMemoryGuidelines_RevalidatesByChangedSymbol(t *testing.T) {
 		},
 	}
 
-	out := appendReviewMemoryGuidelines("Base", mem, changes, 10)
+	out := appendReviewMemoryGuidelines("Base", mem, changes, 10, reviewMemoryDecay{})
 	assert.Contains(t, out, "ProcessOrder should reject nil payload")
 	assert.NotContains(t, out, "Unrelated issue")
 }
+
+func TestTrimReviewMemory_RecentHighBeatsAncientLow(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	mem := reviewMemory{Entries: []reviewMemoryEntry{
+		{ID: "ancient-low", Status: "open", Severity: "LOW", Hits: 3, LastSeen: now.AddDate(0, 0, -300).Format(time.RFC3339)},
+		{ID: "recent-high", Status: "fixed", Severity: "HIGH", Hits: 1, LastSeen: now.AddDate(0, 0, -2).Format(time.RFC3339)},
+	}}
+	trimReviewMemory(&mem, 1, reviewMemoryDecay{HalfLifeDays: 90, Now: now})
+	require.Len(t, mem.Entries, 1)
+	assert.Equal(t, "recent-high", mem.Entries[0].ID)
+}
+
+func TestTrimReviewMemory_PrunesExpiredRegardlessOfCount(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	mem := reviewMemory{Entries: []reviewMemoryEntry{
+		{ID: "expired", Status: "open", Severity: "CRITICAL", LastSeen: now.AddDate(0, 0, -121).Format(time.RFC3339)},
+		{ID: "fresh", Status: "open", Severity: "LOW", LastSeen: now.AddDate(0, 0, -10).Format(time.RFC3339)},
+		{ID: "undated", Status: "open", Severity: "LOW"},
+	}}
+	trimReviewMemory(&mem, 500, reviewMemoryDecay{HalfLifeDays: 30, Now: now})
+	ids := []string{}
+	for _, e := range mem.Entries {
+		ids = append(ids, e.ID)
+	}
+	assert.ElementsMatch(t, []string{"fresh", "undated"}, ids)
+
+	// Decay disabled keeps everything.
+	mem.Entries = append(mem.Entries, reviewMemoryEntry{ID: "expired", Status: "open", LastSeen: now.AddDate(-2, 0, 0).Format(time.RFC3339)})
+	trimReviewMemory(&mem, 500, reviewMemoryDecay{Now: now})
+	assert.Len(t, mem.Entries, 3)
+}
+
+func TestTrimReviewMemory_KeepsIgnoredEntries(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	mem := reviewMemory{Entries: []reviewMemoryEntry{
+		{ID: "suppressed", Status: "ignored", Severity: "LOW", LastSeen: now.AddDate(-2, 0, 0).Format(time.RFC3339)},
+		{ID: "stale", Status: "open", Severity: "LOW", LastSeen: now.AddDate(-2, 0, 0).Format(time.RFC3339)},
+	}}
+	trimReviewMemory(&mem, 500, reviewMemoryDecay{HalfLifeDays: 30, Now: now})
+	require.Len(t, mem.Entries, 1)
+	assert.Equal(t, "suppressed", mem.Entries[0].ID, "ignored findings do not expire")
+}
+
+func TestAppendReviewMemoryGuidelines_LeavesCallerEntriesUnchanged(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	entries := []reviewMemoryEntry{
+		{ID: "expired", Status: "open", Severity: "LOW", FilePath: "svc/a.go", Line: 1, Message: "stale finding", LastSeen: now.AddDate(0, 0, -400).Format(time.RFC3339)},
+		{ID: "fresh", Status: "open", Severity: "HIGH", FilePath: "svc/a.go", Line: 2, Message: "fresh finding", LastSeen: now.AddDate(0, 0, -1).Format(time.RFC3339)},
+	}
+	mem := reviewMemory{Entries: entries}
+	want := append([]reviewMemoryEntry(nil), entries...)
+
+	appendReviewMemoryGuidelines("", mem, []diffparse.FileChange{{NewName: "svc/a.go"}}, 5, reviewMemoryDecay{HalfLifeDays: 30, Now: now})
+	assert.Equal(t, want, mem.Entries, "the caller later merges and saves this memory")
+}
+
+func TestAppendReviewMemoryGuidelines_DecayOrdersEqualRelevance(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	mem := reviewMemory{Entries: []reviewMemoryEntry{
+		{ID: "old", Status: "open", Severity: "LOW", FilePath: "svc/a.go", Line: 1, Message: "ancient low finding", LastSeen: now.AddDate(0, 0, -300).Format(time.RFC3339)},
+		{ID: "new", Status: "open", Severity: "HIGH", FilePath: "svc/a.go", Line: 2, Message: "recent high finding", LastSeen: now.AddDate(0, 0, -1).Format(time.RFC3339)},
+	}}
+	changes := []diffparse.FileChange{{NewName: "svc/a.go"}}
+	out := appendReviewMemoryGuidelines("", mem, changes, 1, reviewMemoryDecay{HalfLifeDays: 90, Now: now})
+	assert.Contains(t, out, "recent high finding")
+	assert.NotContains(t, out, "ancient low finding")
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// How inline findings surface fixes (--post-mode / review.post_mode).
+const (
+	postModeDefault         = "default"
+	postModeCommentOnly     = "comment-only"
+	postModeSuggestionFirst = "suggestion-first"
+)
+
+func normalizePostMode(raw string) (string, error) {
+	switch m := strings.ToLower(strings.TrimSpace(raw)); m {
+	case "", postModeDefault:
+		return postModeDefault, nil
+	case postModeCommentOnly, postModeSuggestionFirst:
+		return m, nil
+	default:
+		return "", fmt.Errorf("invalid post mode %q (want default, comment-only or suggestion-first)", raw)
+	}
+}
+
+// applyPostMode adapts the selected inline groups to mode: suggestion-first
+// keeps only groups carrying a concrete suggestion, comment-only strips the
+// suggestion from every group. It returns the groups to post and how many
+// prose-only groups were dropped.
+func applyPostMode(groups []inlineGroup, mode string) ([]inlineGroup, int) {
+	switch mode {
+	case postModeSuggestionFirst:
+		out := make([]inlineGroup, 0, len(groups))
+		for _, g := range groups {
+			if normalizeSuggestion(g.Suggestion) != "" {
+				out = append(out, g)
+			}
+		}
+		return out, len(groups) - len(out)
+	case postModeCommentOnly:
+		out := make([]inlineGroup, len(groups))
+		for i, g := range groups {
+			g.Suggestion = ""
+			out[i] = g
+		}
+		return out, 0
+	default:
+		return groups, 0
+	}
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func postModeGroups() []inlineGroup {
+	return []inlineGroup{
+		{FilePath: "a.go", NewLine: 3, Severity: "HIGH", Message: "`err` is dropped.", Suggestion: "if err != nil {\n\treturn err\n}"},
+		{FilePath: "a.go", NewLine: 9, Severity: "MEDIUM", Message: "This loop is quadratic in the number of users."},
+		{FilePath: "b.go", NewLine: 1, Severity: "LOW", Message: "Blank suggestion.", Suggestion: "\n  \n"},
+	}
+}
+
+func TestNormalizePostMode(t *testing.T) {
+	for raw, want := range map[string]string{"": "default", "Default": "default", " comment-only ": "comment-only", "SUGGESTION-FIRST": "suggestion-first"} {
+		got, err := normalizePostMode(raw)
+		require.NoError(t, err, raw)
+		assert.Equal(t, want, got, raw)
+	}
+	_, err := normalizePostMode("suggestions")
+	assert.Error(t, err)
+}
+
+func TestApplyPostMode_SuggestionFirstSkipsProseOnlyFindings(t *testing.T) {
+	groups := postModeGroups()
+
+	got, skipped := applyPostMode(groups, postModeSuggestionFirst)
+	assert.Equal(t, 2, skipped)
+	require.Len(t, got, 1)
+	assert.Equal(t, groups[0], got[0])
+}
+
+func TestApplyPostMode_CommentOnlyStripsSuggestions(t *testing.T) {
+	groups := postModeGroups()
+
+	got, skipped := applyPostMode(groups, postModeCommentOnly)
+	assert.Zero(t, skipped)
+	require.Len(t, got, len(groups))
+	for _, g := range got {
+		assert.Empty(t, g.Suggestion)
+	}
+	body := buildInlineCommentBody("", 0, got[0].Severity, got[0].Message, got[0].Suggestion, func(s string) string { return "```suggestion\n" + s + "\n```" }, inlineBodyLimits{}, nil)
+	assert.NotContains(t, body, "suggestion")
+	assert.NotEmpty(t, groups[0].Suggestion, "input groups are not modified")
+}
+
+func TestApplyPostMode_DefaultKeepsGroups(t *testing.T) {
+	groups := postModeGroups()
+	got, skipped := applyPostMode(groups, postModeDefault)
+	assert.Zero(t, skipped)
+	assert.Equal(t, groups, got)
+}
+
+func TestResolveMRReviewSettings_PostMode(t *testing.T) {
+	v := config.NewStore()
+	v.Set("review.post_mode", "comment-only")
+
+	s, _, err := resolveMRReviewSettings(newMRReviewCmd(), config.Config{Viper: v})
+	require.NoError(t, err)
+	assert.Equal(t, postModeCommentOnly, s.PostMode)
+
+	cmd := newMRReviewCmd()
+	require.NoError(t, cmd.Flags().Set("post-mode", "suggestion-first"))
+	s, _, err = resolveMRReviewSettings(cmd, config.Config{Viper: v})
+	require.NoError(t, err)
+	assert.Equal(t, postModeSuggestionFirst, s.PostMode, "flag beats config")
+
+	v.Set("review.post_mode", "bogus")
+	_, _, err = resolveMRReviewSettings(newMRReviewCmd(), config.Config{Viper: v})
+	assert.Error(t, err)
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/sanix-darker/prev/internal/config"
+)
+
+// targetBranchProfile overrides review settings for MRs whose target branch
+// matches Match (a path.Match glob such as "main" or "release/*").
+type targetBranchProfile struct {
+	Match     string
+	Overrides map[string]interface{}
+}
+
+// loadTargetBranchProfiles reads review.target_branch_profiles, a list of
+// entries with a "match" glob and any review.* keys to override:
+//
+//	target_branch_profiles:
+//	  - match: "release/*"
+//	    strictness: strict
+//	    max_comments: 0
+func loadTargetBranchProfiles(conf config.Config) ([]targetBranchProfile, error) {
+	if conf.Viper == nil {
+		return nil, nil
+	}
+	raw, ok := conf.Viper.Get("review.target_branch_profiles")
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	entries, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("review.target_branch_profiles must be a list of {match, ...overrides}")
+	}
+
+	var out []targetBranchProfile
+	for i, e := range entries {
+		fields := stringKeyedMap(e)
+		if fields == nil {
+			return nil, fmt.Errorf("review.target_branch_profiles[%d] must be a mapping", i)
+		}
+		match, _ := fields["match"].(string)
+		match = strings.TrimSpace(match)
+		if match == "" {
+			return nil, fmt.Errorf("review.target_branch_profiles[%d].match is required", i)
+		}
+		if _, err := path.Match(match, ""); err != nil {
+			return nil, fmt.Errorf("review.target_branch_profiles[%d].match %q is not a valid glob: %v", i, match, err)
+		}
+		p := targetBranchProfile{Match: match, Overrides: map[string]interface{}{}}
+		for k, v := range fields {
+			k = strings.ToLower(strings.TrimSpace(k))
+			if k == "match" || k == "" || k == "target_branch_profiles" {
+				continue
+			}
+			p.Overrides[k] = v
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func stringKeyedMap(v interface{}) map[string]interface{} {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(m))
+		for k, val := range m {
+			out[fmt.Sprint(k)] = val
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// matchTargetBranchProfile returns the first profile whose glob matches the
+// target branch. Profiles are evaluated in configuration order.
+func matchTargetBranchProfile(profiles []targetBranchProfile, targetBranch string) (targetBranchProfile, bool) {
+	branch := strings.TrimPrefix(strings.TrimSpace(targetBranch), "refs/heads/")
+	if branch == "" {
+		return targetBranchProfile{}, false
+	}
+	for _, p := range profiles {
+		if ok, _ := path.Match(p.Match, branch); ok {
+			return p, true
+		}
+	}
+	return targetBranchProfile{}, false
+}
+
+// applyTargetBranchProfile writes the matching profile's overrides onto
+// review.* config keys so that the normal flag > config resolution picks them
+// up. Explicit CLI flags still win. It returns the matched profile.
+func applyTargetBranchProfile(conf config.Config, targetBranch string) (targetBranchProfile, bool, error) {
+	profiles, err := loadTargetBranchProfiles(conf)
+	if err != nil || len(profiles) == 0 {
+		return targetBranchProfile{}, false, err
+	}
+	p, ok := matchTargetBranchProfile(profiles, targetBranch)
+	if !ok {
+		return targetBranchProfile{}, false, nil
+	}
+	for k, v := range p.Overrides {
+		conf.Viper.Set("review."+k, v)
+	}
+	return p, true, nil
+}
+
+func describeProfileOverrides(overrides map[string]interface{}) string {
+	keys := make([]string, 0, len(overrides))
+	for k := range overrides {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%v", k, overrides[k]))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTargetBranchProfileConf() config.Config {
+	v := config.NewStore()
+	v.Set("review.strictness", "normal")
+	v.Set("review.target_branch_profiles", []interface{}{
+		map[string]interface{}{"match": "main", "strictness": "strict", "max_comments": 3},
+		map[string]interface{}{"match": "release/*", "strictness": "strict"},
+	})
+	return config.Config{Viper: v}
+}
+
+func TestApplyTargetBranchProfile_MainPicksStricterProfile(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.Flags().String("strictness", "", "")
+	cmd.Flags().Int("max-comments", 0, "")
+
+	conf := newTargetBranchProfileConf()
+	profile, ok, err := applyTargetBranchProfile(conf, "main")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "main", profile.Match)
+	assert.Equal(t, "strict", resolveMRStringSetting(cmd, "strictness", conf, []string{"review.strictness"}, "normal"))
+	assert.Equal(t, 3, resolveMRIntSetting(cmd, "max-comments", conf, []string{"review.max_comments"}, 0))
+
+	conf = newTargetBranchProfileConf()
+	_, ok, err = applyTargetBranchProfile(conf, "feature/login")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, "normal", resolveMRStringSetting(cmd, "strictness", conf, []string{"review.strictness"}, "normal"))
+	assert.Equal(t, 0, resolveMRIntSetting(cmd, "max-comments", conf, []string{"review.max_comments"}, 0))
+}
+
+func TestApplyTargetBranchProfile_GlobAndFlagPrecedence(t *testing.T) {
+	conf := newTargetBranchProfileConf()
+	_, ok, err := applyTargetBranchProfile(conf, "refs/heads/release/1.4")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	cmd := &cobra.Command{}
+	cmd.Flags().String("strictness", "", "")
+	require.NoError(t, cmd.Flags().Set("strictness", "lenient"))
+	assert.Equal(t, "lenient", resolveMRStringSetting(cmd, "strictness", conf, []string{"review.strictness"}, "normal"))
+}
+
+func TestLoadTargetBranchProfiles_RejectsMissingMatch(t *testing.T) {
+	v := config.NewStore()
+	v.Set("review.target_branch_profiles", []interface{}{
+		map[string]interface{}{"strictness": "strict"},
+	})
+	_, err := loadTargetBranchProfiles(config.Config{Viper: v})
+	assert.ErrorContains(t, err, "match is required")
+	assert.Contains(t, validateEffectiveConfig(config.Config{Viper: v}), "review.target_branch_profiles[0].match is required")
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sanix-darker/prev/internal/vcs"
+)
+
+// recurringFinding is one finding repeated across several locations, keyed
+// by memoryRuleID of its message.
+type recurringFinding struct {
+	RuleID    string
+	Severity  string
+	Message   string
+	Locations []inlineGroup
+}
+
+// findRecurringFindings returns the findings whose normalized message occurs
+// at more than threshold locations, most frequent first. Each location still
+// gets its own inline comment; these only feed the grouped note.
+func findRecurringFindings(groups []inlineGroup, threshold int) []recurringFinding {
+	if threshold <= 0 {
+		return nil
+	}
+	byRule := map[string]*recurringFinding{}
+	var order []string
+	for _, g := range groups {
+		if normalizeMemoryMessage(g.Message) == "" {
+			continue
+		}
+		id := memoryRuleID(g.Message)
+		rf, ok := byRule[id]
+		if !ok {
+			rf = &recurringFinding{RuleID: id, Severity: strings.ToUpper(strings.TrimSpace(g.Severity)), Message: strings.TrimSpace(g.Message)}
+			byRule[id] = rf
+			order = append(order, id)
+		}
+		if severityRank(g.Severity) > severityRank(rf.Severity) {
+			rf.Severity = strings.ToUpper(strings.TrimSpace(g.Severity))
+		}
+		rf.Locations = append(rf.Locations, g)
+	}
+
+	var out []recurringFinding
+	for _, id := range order {
+		if rf := byRule[id]; len(rf.Locations) > threshold {
+			out = append(out, *rf)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return len(out[i].Locations) > len(out[j].Locations) })
+	return out
+}
+
+func recurringFindingMarker(ruleID string) string {
+	return prevRecurringPrefix + ruleID + " -->"
+}
+
+// commentedInlineGroups returns the groups that have an inline comment on the
+// MR after posting: posted, reused, updated, or already covered by an
+// existing thread. statuses is indexed like groups.
+func commentedInlineGroups(groups []inlineGroup, statuses []string) []inlineGroup {
+	var out []inlineGroup
+	for i, g := range groups {
+		if i >= len(statuses) {
+			break
+		}
+		switch statuses[i] {
+		case findingStatusPosted, findingStatusReused, findingStatusUpdated, findingStatusSkippedExisting:
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+// buildRecurringFindingNote summarizes one recurring finding and lists every
+// location it was reported at.
+func buildRecurringFindingNote(rf recurringFinding) string {
+	files := map[string]struct{}{}
+	for _, loc := range rf.Locations {
+		files[loc.FilePath] = struct{}{}
+	}
+	var sb strings.Builder
+	sb.WriteString(recurringFindingMarker(rf.RuleID))
+	sb.WriteString("\n### Recurring finding\n\n")
+	sb.WriteString(fmt.Sprintf("**[%s]** %s\n\n", rf.Severity, inlinePrimaryPoint(rf.Message, inlineBodyLimits{})))
+	sb.WriteString(fmt.Sprintf("Reported at %d locations in %d files; each has its own inline comment. Consider fixing the pattern once:\n\n",
+		len(rf.Locations), len(files)))
+	for _, loc := range rf.Locations {
+		sb.WriteString(fmt.Sprintf("- `%s:%d`\n", loc.FilePath, loc.anchorLine()))
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// postRecurringFindingNotes posts one grouped note per recurring finding
+// among groups, which should only hold findings that have an inline comment
+// (see commentedInlineGroups). A finding already summarized on the MR has its
+// note updated in place when the provider supports it and the body changed.
+// It reports how many notes were posted or updated.
+func postRecurringFindingNotes(
+	ctx context.Context,
+	vcsProvider vcs.VCSProvider,
+	projectID string,
+	mrIID int64,
+	notes []vcs.MRNote,
+	groups []inlineGroup,
+	threshold int,
+) (int, error) {
+	updater, _ := vcsProvider.(vcs.SummaryNoteUpdater)
+	posted := 0
+	for _, rf := range findRecurringFindings(groups, threshold) {
+		body := buildRecurringFindingNote(rf)
+		if existing, ok := findTopLevelMarker(notes, recurringFindingMarker(rf.RuleID)); ok {
+			if updater == nil || strings.TrimSpace(existing.Body) == body {
+				continue
+			}
+			if err := updater.UpdateSummaryNote(ctx, projectID, mrIID, existing.ID, body); err != nil {
+				return posted, err
+			}
+			posted++
+			continue
+		}
+		if err := vcsProvider.PostSummaryNote(ctx, projectID, mrIID, body); err != nil {
+			return posted, err
+		}
+		posted++
+	}
+	return posted, nil
+}
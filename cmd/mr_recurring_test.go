@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/vcs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func recurringTestGroups() []inlineGroup {
+	var groups []inlineGroup
+	for i := 1; i <= 5; i++ {
+		// Case and spacing differ; normalizeMemoryMessage folds them.
+		msg := "missing error check on `rows.Close()`."
+		if i%2 == 0 {
+			msg = "Missing error check on  `rows.Close()`."
+		}
+		groups = append(groups, inlineGroup{
+			FilePath: fmt.Sprintf("pkg/store%d.go", i),
+			NewLine:  10 * i,
+			Severity: "MEDIUM",
+			Message:  msg,
+		})
+	}
+	groups[2].Severity = "HIGH"
+	return append(groups, inlineGroup{FilePath: "main.go", NewLine: 3, Severity: "LOW", Message: "Unused import."})
+}
+
+func TestFindRecurringFindings_GroupsByNormalizedMessage(t *testing.T) {
+	got := findRecurringFindings(recurringTestGroups(), 3)
+	require.Len(t, got, 1)
+	assert.Equal(t, memoryRuleID("missing error check on `rows.Close()`."), got[0].RuleID)
+	assert.Equal(t, "HIGH", got[0].Severity, "the group takes the highest severity")
+	assert.Len(t, got[0].Locations, 5)
+
+	assert.Empty(t, findRecurringFindings(recurringTestGroups(), 5), "exactly N findings do not recur")
+	assert.Empty(t, findRecurringFindings(recurringTestGroups(), 0), "0 disables consolidation")
+}
+
+func TestBuildRecurringFindingNote_ListsAllLocations(t *testing.T) {
+	rf := findRecurringFindings(recurringTestGroups(), 3)[0]
+	note := buildRecurringFindingNote(rf)
+
+	assert.Contains(t, note, recurringFindingMarker(rf.RuleID))
+	assert.Contains(t, note, "Reported at 5 locations in 5 files")
+	for i := 1; i <= 5; i++ {
+		assert.Contains(t, note, fmt.Sprintf("- `pkg/store%d.go:%d`", i, 10*i))
+	}
+	assert.NotContains(t, note, "main.go")
+}
+
+func TestPostRecurringFindingNotes_SkipsAlreadyPosted(t *testing.T) {
+	rec := &recordingVCSProvider{}
+	n, err := postRecurringFindingNotes(context.Background(), rec, "g/p", 1, nil, recurringTestGroups(), 3)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	require.Len(t, rec.summaries, 1)
+
+	notes := []vcs.MRNote{{ID: 9, Body: rec.summaries[0]}}
+	n, err = postRecurringFindingNotes(context.Background(), rec, "g/p", 1, notes, recurringTestGroups(), 3)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Len(t, rec.summaries, 1)
+	assert.Empty(t, rec.updated, "an unchanged note is left alone")
+}
+
+func TestPostRecurringFindingNotes_UpdatesStaleNoteInPlace(t *testing.T) {
+	rec := &recordingVCSProvider{}
+	_, err := postRecurringFindingNotes(context.Background(), rec, "g/p", 1, nil, recurringTestGroups(), 3)
+	require.NoError(t, err)
+	require.Len(t, rec.summaries, 1)
+
+	groups := append(recurringTestGroups(), inlineGroup{FilePath: "pkg/store9.go", NewLine: 90, Severity: "MEDIUM", Message: "Missing error check on `rows.Close()`."})
+	notes := []vcs.MRNote{{ID: 9, Body: rec.summaries[0]}}
+	n, err := postRecurringFindingNotes(context.Background(), rec, "g/p", 1, notes, groups, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Len(t, rec.summaries, 1, "no second note is posted")
+	assert.Contains(t, rec.updated[9], "- `pkg/store9.go:90`")
+	assert.Contains(t, rec.updated[9], "Reported at 6 locations")
+}
+
+func TestCommentedInlineGroups_DropsFailedAndSkipped(t *testing.T) {
+	groups := recurringTestGroups()
+	statuses := []string{
+		findingStatusPosted, findingStatusFailed, findingStatusSkippedExisting,
+		findingStatusSkippedDuplicate, findingStatusUnplaced, findingStatusReused,
+	}
+	got := commentedInlineGroups(groups, statuses)
+	require.Len(t, got, 3)
+	assert.Equal(t, "pkg/store1.go", got[0].FilePath)
+	assert.Equal(t, "pkg/store3.go", got[1].FilePath)
+	assert.Equal(t, "main.go", got[2].FilePath)
+	assert.Empty(t, findRecurringFindings(got, 2), "only two locations of the pattern were commented")
+}
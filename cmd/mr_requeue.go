@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sanix-darker/prev/internal/vcs"
+)
+
+const (
+	// inlineRequeueMaxAttempts bounds how often one rate-limited inline
+	// comment is re-posted after the main posting pass.
+	inlineRequeueMaxAttempts = 3
+	// inlineRequeueBreakerLimit opens the breaker after this many consecutive
+	// rate-limited attempts; everything still queued then fails fast.
+	inlineRequeueBreakerLimit = 5
+	inlineRequeueBaseDelay    = 2 * time.Second
+	inlineRequeueMaxDelay     = 2 * time.Minute
+)
+
+// errInlineRequeueBreakerOpen is reported for comments dropped after the
+// rate-limit breaker opened.
+var errInlineRequeueBreakerOpen = errors.New("rate limit persisted; giving up on remaining inline comments")
+
+// inlineRequeueSleep waits for d or until ctx is done; replaced in tests.
+var inlineRequeueSleep = func(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// rateLimitedInline is an inline comment whose post hit HTTP 429 after the
+// VCS client's own retries were exhausted.
+type rateLimitedInline struct {
+	Index   int
+	Comment vcs.InlineComment
+	Wait    time.Duration // server Retry-After, 0 when absent
+}
+
+// requeueRateLimitedInline re-posts rate-limited inline comments in order,
+// waiting the server's Retry-After (or an exponential backoff) before each
+// attempt. A comment that is still throttled goes back to the end of the queue
+// until it has used inlineRequeueMaxAttempts. It returns the outcome per
+// Index: nil when posted.
+func requeueRateLimitedInline(ctx context.Context, queue []rateLimitedInline, post func(vcs.InlineComment) error) map[int]error {
+	results := make(map[int]error, len(queue))
+	attempts := make(map[int]int, len(queue))
+	delay := inlineRequeueBaseDelay
+	consecutive := 0
+	for len(queue) > 0 {
+		item := queue[0]
+		queue = queue[1:]
+		if consecutive >= inlineRequeueBreakerLimit {
+			results[item.Index] = errInlineRequeueBreakerOpen
+			continue
+		}
+
+		wait := item.Wait
+		if wait <= 0 {
+			wait = delay
+		}
+		fmt.Fprintf(os.Stderr, "Warning: rate limited posting inline comment on %s:%d; retrying in %s.\n",
+			item.Comment.FilePath, inlineCommentLine(item.Comment), wait.Round(time.Millisecond))
+		if err := inlineRequeueSleep(ctx, wait); err != nil {
+			results[item.Index] = err
+			continue
+		}
+
+		attempts[item.Index]++
+		err := post(item.Comment)
+		retryAfter, limited := vcs.RateLimited(err)
+		switch {
+		case err == nil:
+			results[item.Index] = nil
+			consecutive = 0
+			delay = inlineRequeueBaseDelay
+		case limited && attempts[item.Index] < inlineRequeueMaxAttempts:
+			consecutive++
+			delay = min(delay*2, inlineRequeueMaxDelay)
+			item.Wait = retryAfter
+			queue = append(queue, item)
+		default:
+			if limited {
+				consecutive++
+			}
+			results[item.Index] = err
+		}
+	}
+	return results
+}
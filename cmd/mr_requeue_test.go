@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sanix-darker/prev/internal/vcs"
+	"github.com/stretchr/testify/assert"
+)
+
+func stubInlineRequeueSleep(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var waits []time.Duration
+	orig := inlineRequeueSleep
+	inlineRequeueSleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	t.Cleanup(func() { inlineRequeueSleep = orig })
+	return &waits
+}
+
+func TestRequeueRateLimitedInline_PostsAfterBackoff(t *testing.T) {
+	waits := stubInlineRequeueSleep(t)
+	calls := map[string]int{}
+	post := func(c vcs.InlineComment) error {
+		calls[c.FilePath]++
+		if c.FilePath == "b.go" && calls[c.FilePath] == 1 {
+			return &vcs.HTTPError{Provider: "gitlab", StatusCode: http.StatusTooManyRequests, RetryAfter: 7 * time.Second}
+		}
+		return nil
+	}
+	queue := []rateLimitedInline{
+		{Index: 1, Comment: vcs.InlineComment{FilePath: "b.go", NewLine: 4}, Wait: 3 * time.Second},
+		{Index: 4, Comment: vcs.InlineComment{FilePath: "c.go", NewLine: 9}},
+	}
+
+	results := requeueRateLimitedInline(context.Background(), queue, post)
+
+	assert.Equal(t, map[int]error{1: nil, 4: nil}, results)
+	assert.Equal(t, 2, calls["b.go"], "429 then 200 posts the comment on the second try")
+	assert.Equal(t, 1, calls["c.go"])
+	assert.Equal(t, []time.Duration{3 * time.Second, 2 * inlineRequeueBaseDelay, 7 * time.Second}, *waits,
+		"Retry-After is honored, backoff grows while throttled, and the comment is requeued behind the others")
+}
+
+func TestRequeueRateLimitedInline_BreakerStopsPersistentThrottling(t *testing.T) {
+	stubInlineRequeueSleep(t)
+	calls := 0
+	post := func(vcs.InlineComment) error {
+		calls++
+		return &vcs.HTTPError{Provider: "github", StatusCode: http.StatusTooManyRequests}
+	}
+	var queue []rateLimitedInline
+	for i := 0; i < 10; i++ {
+		queue = append(queue, rateLimitedInline{Index: i, Comment: vcs.InlineComment{FilePath: "a.go", NewLine: int64(i + 1)}})
+	}
+
+	results := requeueRateLimitedInline(context.Background(), queue, post)
+
+	assert.Equal(t, inlineRequeueBreakerLimit, calls)
+	assert.Len(t, results, 10)
+	for i := 0; i < 10; i++ {
+		assert.Error(t, results[i])
+	}
+	assert.True(t, errors.Is(results[9], errInlineRequeueBreakerOpen))
+}
+
+func TestRequeueRateLimitedInline_NonRateLimitErrorIsFinal(t *testing.T) {
+	stubInlineRequeueSleep(t)
+	calls := 0
+	boom := &vcs.HTTPError{Provider: "gitlab", StatusCode: http.StatusBadRequest, Body: "line_code invalid"}
+	results := requeueRateLimitedInline(context.Background(),
+		[]rateLimitedInline{{Index: 0, Comment: vcs.InlineComment{FilePath: "a.go", NewLine: 1}}},
+		func(vcs.InlineComment) error { calls++; return boom })
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, boom, results[0])
+}
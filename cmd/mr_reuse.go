@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/sanix-darker/prev/internal/config"
+)
+
+// Similarity functions available for review.reuse.similarity.
+const (
+	reuseSimilaritySemantic = "semantic"
+	reuseSimilarityTrigram  = "trigram"
+)
+
+// reuseSimilarityFunc scores how similar an existing thread's finding is to
+// a new one. Scores are roughly on a 0-100 scale.
+type reuseSimilarityFunc func(c reusableThread, grp inlineGroup) int
+
+var reuseSimilarityFuncs = map[string]reuseSimilarityFunc{
+	reuseSimilaritySemantic: semanticReuseSimilarity,
+	reuseSimilarityTrigram:  trigramReuseSimilarity,
+}
+
+// reuseMatcher decides whether a new finding continues an existing prev
+// thread. A candidate's score is MessageWeight*similarity minus
+// DistancePenalty per line of distance (capped at reuseMaxDistance lines);
+// the best candidate is reused when its score reaches MinScore.
+type reuseMatcher struct {
+	Similarity      string
+	MessageWeight   float64
+	DistancePenalty float64
+	MinScore        int
+}
+
+const reuseMaxDistance = 50
+
+func defaultReuseMatcher() reuseMatcher {
+	return reuseMatcher{
+		Similarity:      reuseSimilaritySemantic,
+		MessageWeight:   1,
+		DistancePenalty: 1,
+		MinScore:        25,
+	}
+}
+
+// resolveReuseMatcher reads the review.reuse.* settings over the defaults.
+func resolveReuseMatcher(conf config.Config) reuseMatcher {
+	m := defaultReuseMatcher()
+	if conf.Viper == nil {
+		return m
+	}
+	if conf.Viper.IsSet("review.reuse.similarity") {
+		m.Similarity = normalizeReuseSimilarity(conf.Viper.GetString("review.reuse.similarity"))
+	}
+	if conf.Viper.IsSet("review.reuse.message_weight") {
+		if w := conf.Viper.GetFloat64("review.reuse.message_weight"); w > 0 {
+			m.MessageWeight = w
+		}
+	}
+	if conf.Viper.IsSet("review.reuse.distance_penalty") {
+		if p := conf.Viper.GetFloat64("review.reuse.distance_penalty"); p >= 0 {
+			m.DistancePenalty = p
+		}
+	}
+	if conf.Viper.IsSet("review.reuse.min_score") {
+		m.MinScore = conf.Viper.GetInt("review.reuse.min_score")
+	}
+	return m
+}
+
+func normalizeReuseSimilarity(raw string) string {
+	s := strings.ToLower(strings.TrimSpace(raw))
+	if _, ok := reuseSimilarityFuncs[s]; ok {
+		return s
+	}
+	return reuseSimilaritySemantic
+}
+
+func (m reuseMatcher) score(c reusableThread, grp inlineGroup) float64 {
+	similarity, ok := reuseSimilarityFuncs[m.Similarity]
+	if !ok {
+		similarity = semanticReuseSimilarity
+	}
+	dist := minInt(absInt(c.Line-grp.anchorLine()), reuseMaxDistance)
+	return m.MessageWeight*float64(similarity(c, grp)) - m.DistancePenalty*float64(dist)
+}
+
+// match returns the best-scoring candidate on the same file and severity.
+func (m reuseMatcher) match(candidates []reusableThread, grp inlineGroup) (reusableThread, bool) {
+	bestIdx := -1
+	var bestScore float64
+	for i, c := range candidates {
+		if !strings.EqualFold(strings.TrimSpace(c.FilePath), strings.TrimSpace(grp.FilePath)) {
+			continue
+		}
+		if !strings.EqualFold(strings.TrimSpace(c.Severity), strings.TrimSpace(grp.Severity)) {
+			continue
+		}
+		score := m.score(c, grp)
+		if bestIdx < 0 || score > bestScore {
+			bestScore = score
+			bestIdx = i
+		}
+	}
+	if bestIdx < 0 || bestScore < float64(m.MinScore) {
+		return reusableThread{}, false
+	}
+	return candidates[bestIdx], true
+}
+
+func semanticReuseSimilarity(c reusableThread, grp inlineGroup) int {
+	return semanticMessageScore(c.Message, c.PrimarySymbol, grp.Message, semanticPrimarySymbol(grp.Message, grp.FilePath))
+}
+
+// trigramReuseSimilarity scores character-trigram overlap, which tolerates
+// reworded and re-inflected findings better than whole-keyword overlap.
+func trigramReuseSimilarity(c reusableThread, grp inlineGroup) int {
+	score := int(trigramSimilarity(c.Message, grp.Message) * 100)
+	symbol := semanticPrimarySymbol(grp.Message, grp.FilePath)
+	if c.PrimarySymbol != "" && symbol != "" && strings.EqualFold(strings.TrimSpace(c.PrimarySymbol), strings.TrimSpace(symbol)) {
+		score += 20
+	}
+	return score
+}
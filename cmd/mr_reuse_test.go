@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func reuseCandidates() []reusableThread {
+	return []reusableThread{{
+		DiscussionID:  "d1",
+		FilePath:      "api/handler.go",
+		Line:          42,
+		Severity:      "HIGH",
+		Message:       "Missing nil check before request dereference",
+		PrimarySymbol: "request",
+	}}
+}
+
+func TestReuseMatcher_TrigramMatchesParaphrase(t *testing.T) {
+	paraphrase := inlineGroup{
+		FilePath: "api/handler.go",
+		NewLine:  45,
+		Severity: "HIGH",
+		Message:  "Dereferencing req without checking for nil may crash the handler",
+	}
+	unrelated := inlineGroup{
+		FilePath: "api/handler.go",
+		NewLine:  45,
+		Severity: "HIGH",
+		Message:  "Close the response body to avoid leaking connections",
+	}
+
+	// The default keyword matcher misses the reworded finding.
+	_, ok := defaultReuseMatcher().match(reuseCandidates(), paraphrase)
+	assert.False(t, ok)
+
+	tuned := reuseMatcher{Similarity: reuseSimilarityTrigram, MessageWeight: 1, DistancePenalty: 0.5, MinScore: 20}
+	got, ok := tuned.match(reuseCandidates(), paraphrase)
+	assert.True(t, ok)
+	assert.Equal(t, "d1", got.DiscussionID)
+
+	_, ok = tuned.match(reuseCandidates(), unrelated)
+	assert.False(t, ok)
+}
+
+func TestReuseMatcher_DeletedLineScoresByOldLine(t *testing.T) {
+	m := defaultReuseMatcher()
+	c := reuseCandidates()[0]
+	onDeleted := inlineGroup{FilePath: c.FilePath, OldLine: 42, OnDeleted: true, Severity: "HIGH", Message: c.Message}
+	onSameLine := inlineGroup{FilePath: c.FilePath, NewLine: 42, Severity: "HIGH", Message: c.Message}
+	assert.Equal(t, m.score(c, onSameLine), m.score(c, onDeleted))
+}
+
+func TestReuseMatcher_DistancePenaltyAndMinScore(t *testing.T) {
+	grp := inlineGroup{
+		FilePath: "api/handler.go",
+		NewLine:  90,
+		Severity: "HIGH",
+		Message:  "request dereference can panic when nil check is missing",
+	}
+	m := defaultReuseMatcher()
+	_, ok := m.match(reuseCandidates(), grp)
+	assert.True(t, ok)
+
+	m.DistancePenalty = 2
+	_, ok = m.match(reuseCandidates(), grp)
+	assert.False(t, ok, "48 lines away at 2 points per line should drop below min_score")
+
+	m.MinScore = -100
+	grp.Severity = "LOW"
+	_, ok = m.match(reuseCandidates(), grp)
+	assert.False(t, ok, "severity must still match")
+}
+
+func TestResolveReuseMatcher_FromConfig(t *testing.T) {
+	assert.Equal(t, defaultReuseMatcher(), resolveReuseMatcher(config.Config{}))
+
+	v := config.NewStore()
+	v.Set("review.reuse.similarity", "Trigram")
+	v.Set("review.reuse.message_weight", 1.5)
+	v.Set("review.reuse.distance_penalty", "0.25")
+	v.Set("review.reuse.min_score", 15)
+	got := resolveReuseMatcher(config.Config{Viper: v})
+	assert.Equal(t, reuseMatcher{Similarity: reuseSimilarityTrigram, MessageWeight: 1.5, DistancePenalty: 0.25, MinScore: 15}, got)
+
+	v.Set("review.reuse.similarity", "bogus")
+	v.Set("review.reuse.message_weight", 0)
+	got = resolveReuseMatcher(config.Config{Viper: v})
+	assert.Equal(t, reuseSimilaritySemantic, got.Similarity)
+	assert.Equal(t, 1.0, got.MessageWeight)
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/sanix-darker/prev/internal/core"
+	"github.com/sanix-darker/prev/internal/provider"
+)
+
+// maxReviewSamples caps review.samples; every extra sample is a full review
+// completion.
+const maxReviewSamples = 5
+
+// sampledChoices collects the alternate choices returned when review.samples
+// asks for more than one completion per call. In refine mode only the last
+// pass's alternates are kept, since earlier passes are drafts; ensemble
+// passes all contribute. A nil *sampledChoices ignores every call.
+type sampledChoices struct {
+	mu    sync.Mutex
+	texts []string
+}
+
+// set replaces the collected alternates with those on resp.
+func (s *sampledChoices) set(resp *provider.CompletionResponse) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.texts = alternateChoices(resp)
+}
+
+// add appends the alternates on resp.
+func (s *sampledChoices) add(resp *provider.CompletionResponse) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.texts = append(s.texts, alternateChoices(resp)...)
+}
+
+// restore replaces the collected alternates with texts, e.g. from a cache
+// entry.
+func (s *sampledChoices) restore(texts []string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.texts = append([]string(nil), texts...)
+}
+
+// outputs returns the collected alternate review texts.
+func (s *sampledChoices) outputs() []string {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.texts...)
+}
+
+// alternateChoices returns the non-empty choices after the first, which is
+// already the pass output.
+func alternateChoices(resp *provider.CompletionResponse) []string {
+	if resp == nil || len(resp.Choices) < 2 {
+		return nil
+	}
+	var out []string
+	for _, c := range resp.Choices[1:] {
+		if strings.TrimSpace(c.Content) != "" {
+			out = append(out, c.Content)
+		}
+	}
+	return out
+}
+
+// unionSampledFindings adds the findings of every alternate sample to
+// findings, de-duplicated by inlineKey like ensemble passes.
+func unionSampledFindings(findings []core.FileComment, samples []string, structured bool) []core.FileComment {
+	if len(samples) == 0 {
+		return findings
+	}
+	passFindings := make([][]core.FileComment, 0, len(samples)+1)
+	passFindings = append(passFindings, findings)
+	for _, out := range samples {
+		passFindings = append(passFindings, parseReviewContent(out, structured).FileComments)
+	}
+	return mergeEnsembleFindings(passFindings)
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sanix-darker/prev/internal/core"
+	"github.com/sanix-darker/prev/internal/provider"
+	"github.com/sanix-darker/prev/internal/reviewcache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectReviewPassOutputs_KeepsLastPassSamples(t *testing.T) {
+	ai := &scriptedAIProvider{responses: []provider.CompletionResponse{
+		{Content: "draft", Choices: []provider.Choice{{Content: "draft"}, {Content: "draft alt"}}},
+		{Content: "final", Choices: []provider.Choice{{Content: "final"}, {Content: "final alt"}}},
+	}}
+	choices := &sampledChoices{}
+	outs, err := collectReviewPassOutputs(context.Background(), ai, "BASE_PROMPT", 2, reviewPassOptions{Samples: 2, Choices: choices})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"draft", "final"}, outs)
+	assert.Equal(t, []string{"final alt"}, choices.outputs())
+	for _, req := range ai.requests {
+		assert.Zero(t, req.N, "scripted provider has no native n")
+	}
+}
+
+func TestCollectReviewPassOutputs_SamplesOnlyTheFinalPass(t *testing.T) {
+	ai := &scriptedAIProvider{responses: []provider.CompletionResponse{
+		{Content: "draft"}, {Content: "final"}, {Content: "final alt"},
+	}}
+	choices := &sampledChoices{}
+	outs, err := collectReviewPassOutputs(context.Background(), ai, "BASE_PROMPT", 2, reviewPassOptions{Samples: 2, Choices: choices})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"draft", "final"}, outs)
+	assert.Equal(t, []string{"final alt"}, choices.outputs())
+	assert.Len(t, ai.requests, 3, "one call for the draft pass, two for the sampled final pass")
+}
+
+func TestCollectReviewPassOutputs_SamplesThePassThatStopsEarly(t *testing.T) {
+	finding := `{"summary":"One bug.","findings":[{"file_path":"a.go","line":3,"kind":"ISSUE","severity":"HIGH","message":"Unchecked error."}]}`
+	clean := `{"summary":"Looks good.","findings":[]}`
+	alt := `{"summary":"One nit.","findings":[{"file_path":"b.go","line":7,"kind":"ISSUE","severity":"LOW","message":"Unused variable."}]}`
+	ai := &scriptedAIProvider{responses: []provider.CompletionResponse{
+		{Content: finding}, {Content: clean}, {Content: clean}, {Content: alt},
+	}}
+	choices := &sampledChoices{}
+	outs, err := collectReviewPassOutputs(context.Background(), ai, "BASE_PROMPT", 5,
+		reviewPassOptions{JSONMode: true, EarlyStopOnClean: true, Samples: 2, Choices: choices})
+	require.NoError(t, err)
+	assert.Len(t, outs, 3, "passes 2 and 3 are clean, so the run stops after pass 3")
+	assert.Equal(t, []string{alt}, choices.outputs())
+	assert.Len(t, ai.requests, 4, "only pass 3, which follows a clean pass, is sampled")
+}
+
+func TestCollectReviewPassOutputs_CacheHitRestoresSamples(t *testing.T) {
+	cache := reviewcache.New(t.TempDir(), time.Hour)
+	opts := reviewPassOptions{Samples: 2, Cache: cache, CacheKey: reviewcache.Key("p", "m", "BASE_PROMPT", "samples=2"), CacheSignature: "sig"}
+
+	opts.Choices = &sampledChoices{}
+	ai := &scriptedAIProvider{responses: []provider.CompletionResponse{
+		{Content: "final", Choices: []provider.Choice{{Content: "final"}, {Content: "final alt"}}},
+	}}
+	_, err := collectReviewPassOutputs(context.Background(), ai, "BASE_PROMPT", 1, opts)
+	require.NoError(t, err)
+
+	opts.Choices = &sampledChoices{}
+	outs, err := collectReviewPassOutputs(context.Background(), &scriptedAIProvider{}, "BASE_PROMPT", 1, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"final"}, outs)
+	assert.Equal(t, []string{"final alt"}, opts.Choices.outputs())
+}
+
+func TestUnionSampledFindings_DedupesByInlineKey(t *testing.T) {
+	base := []core.FileComment{{FilePath: "a.go", Line: 3, Severity: "MEDIUM", Message: "Nil map write"}}
+	samples := []string{
+		"- a.go:3 [HIGH] Nil map write\n- b.go:7 [LOW] Unused variable",
+	}
+	got := unionSampledFindings(base, samples, false)
+	require.Len(t, got, 2)
+	assert.Equal(t, "HIGH", got[0].Severity)
+	assert.Equal(t, "b.go", got[1].FilePath)
+
+	assert.Equal(t, base, unionSampledFindings(base, nil, false))
+}
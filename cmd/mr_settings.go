@@ -0,0 +1,435 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/sanix-darker/prev/internal/config"
+	"github.com/sanix-darker/prev/internal/diffparse"
+	"github.com/sanix-darker/prev/internal/provider"
+	"github.com/sanix-darker/prev/internal/reviewcache"
+	"github.com/spf13/cobra"
+)
+
+// defaultConventionLabels are the finding kinds used when
+// review.conventions.labels is unset.
+var defaultConventionLabels = []string{"issue", "suggestion", "remark"}
+
+// mrReviewSettings holds the fully-resolved mr review settings (flag > config
+// > default). --print-settings-json emits it as a single JSON object.
+type mrReviewSettings struct {
+	Provider                  string   `json:"provider"`
+	Model                     string   `json:"model"`
+	Strictness                string   `json:"strictness"`
+	Persona                   string   `json:"persona"`
+	OutputLanguage            string   `json:"output_language"`
+	RepoContextFile           string   `json:"repo_context_file"`
+	RepoContextMaxChars       int      `json:"repo_context_max_chars"`
+	Nitpick                   int      `json:"nitpick"`
+	MaxComments               int      `json:"max_comments"`
+	Passes                    int      `json:"passes"`
+	PassMode                  string   `json:"pass_mode"`
+	EarlyStopOnClean          bool     `json:"early_stop_on_clean"`
+	Samples                   int      `json:"samples"`
+	SeverityConsensus         string   `json:"severity_consensus"`
+	Incremental               bool     `json:"incremental"`
+	FilterMode                string   `json:"filter_mode"`
+	Memory                    bool     `json:"memory"`
+	MemoryFile                string   `json:"memory_file"`
+	MemoryMax                 int      `json:"memory_max"`
+	MemoryDecayDays           int      `json:"memory_decay_days"`
+	NativeImpact              bool     `json:"native_impact"`
+	NativeImpactMaxSymbols    int      `json:"native_impact_max_symbols"`
+	DependencyReview          bool     `json:"dependency_review"`
+	FixPrompt                 string   `json:"fix_prompt"`
+	MaxInlineKeyPoints        int      `json:"max_inline_key_points"`
+	MaxInlineKeyPointChars    int      `json:"max_inline_key_point_chars"`
+	MaxInlineChars            int      `json:"max_inline_chars"`
+	StructuredOutput          bool     `json:"structured_output"`
+	OnContentFilter           string   `json:"on_content_filter"`
+	Cache                     bool     `json:"cache"`
+	CacheTTL                  string   `json:"cache_ttl"`
+	CollapsibleSummary        bool     `json:"collapsible_summary"`
+	PostOrder                 string   `json:"post_order"`
+	SummaryTemplate           string   `json:"summary_template"`
+	InlineTemplate            string   `json:"inline_template"`
+	SummaryTarget             string   `json:"summary_target"`
+	PostMode                  string   `json:"post_mode"`
+	SystemPrompt              string   `json:"system_prompt"`
+	ReplySystemPrompt         string   `json:"reply_system_prompt"`
+	CostPer1KInput            float64  `json:"cost_per_1k_input"`
+	CostPer1KOutput           float64  `json:"cost_per_1k_output"`
+	FailOn                    string   `json:"fail_on"`
+	InlineOnly                bool     `json:"inline_only"`
+	InlineOnlySummaryFallback bool     `json:"inline_only_summary_fallback"`
+	ReportAsStatus            bool     `json:"report_as_status"`
+	ReconsiderApproval        bool     `json:"reconsider_approval"`
+	AutoResolve               bool     `json:"auto_resolve"`
+	SuppressStale             bool     `json:"suppress_stale"`
+	IncludeDiffStats          bool     `json:"include_diff_stats_in_prompt"`
+	MaxTitleChars             int      `json:"max_title_chars"`
+	MaxDescriptionChars       int      `json:"max_description_chars"`
+	Conventions               []string `json:"conventions"`
+	MRDiffSource              string   `json:"mr_diff_source"`
+	UseMergeBase              bool     `json:"use_merge_base"`
+	Serena                    string   `json:"serena"`
+	ContextLines              int      `json:"context_lines"`
+	MaxTokens                 int      `json:"max_tokens"`
+	BudgetStrategy            string   `json:"budget_strategy"`
+	IgnoreGlobs               []string `json:"ignore_globs"`
+	SkipGenerated             bool     `json:"skip_generated"`
+	MaxDiffBytes              int      `json:"max_diff_bytes"`
+	ForceFull                 bool     `json:"force_full"`
+	Commits                   bool     `json:"commits"`
+	MinConfidence             float64  `json:"min_confidence"`
+	ConsolidateRecurring      int      `json:"consolidate_recurring"`
+
+	cacheTTL time.Duration
+}
+
+// resolveMRReviewSettings resolves every mr review setting from flags and
+// config. notes describe settings that were turned off because they conflict
+// with another one; the caller decides whether to print them.
+func resolveMRReviewSettings(cmd *cobra.Command, conf config.Config) (s mrReviewSettings, notes []string, err error) {
+	s.Provider, s.Model = resolvedProviderAndModel(conf)
+
+	s.Strictness = resolveMRStringSetting(
+		cmd, "strictness", conf,
+		[]string{"review.strictness", "strictness"},
+		conf.Strictness,
+	)
+	s.Persona = resolveMRStringSetting(
+		cmd, "persona", conf,
+		[]string{"review.persona"},
+		"",
+	)
+	s.OutputLanguage = resolveMRStringSetting(
+		cmd, "language", conf,
+		[]string{"review.output_language"},
+		"",
+	)
+	s.RepoContextFile = resolveMRStringSetting(
+		cmd, "repo-context-file", conf,
+		[]string{"review.repo_context_file"},
+		"",
+	)
+	s.RepoContextMaxChars = resolveMRIntSetting(
+		cmd, "repo-context-max-chars", conf,
+		[]string{"review.repo_context_max_chars"},
+		defaultRepoContextMaxChars,
+	)
+	s.Nitpick = normalizeNitpickFromStrictness(resolveMRIntSetting(
+		cmd, "nitpick", conf,
+		[]string{"review.nitpick"},
+		0,
+	), s.Strictness)
+	s.MaxComments = resolveMRIntSetting(
+		cmd, "max-comments", conf,
+		[]string{"review.max_comments"},
+		0,
+	)
+	if s.MaxComments < 0 {
+		s.MaxComments = 0
+	}
+	s.Passes = resolveMRIntSetting(
+		cmd, "review-passes", conf,
+		[]string{"review.passes"},
+		0,
+	)
+	if s.Passes <= 0 {
+		s.Passes = 1
+	}
+	if s.Passes > 6 {
+		s.Passes = 6
+	}
+	s.SeverityConsensus = normalizeSeverityConsensus(resolveMRStringSetting(
+		cmd, "severity-consensus", conf,
+		[]string{"review.severity_consensus"},
+		severityConsensusMax,
+	))
+	if conf.Viper != nil {
+		s.Incremental = conf.Viper.GetBool("review.incremental")
+	}
+	if f := cmd.Flags().Lookup("incremental"); f != nil && f.Changed {
+		s.Incremental, _ = cmd.Flags().GetBool("incremental")
+	}
+	s.FilterMode = normalizeInlineFilterMode(resolveMRStringSetting(
+		cmd, "filter-mode", conf,
+		[]string{"review.filter_mode"},
+		"diff_context",
+	))
+	s.Memory = resolveMRBoolSetting(
+		cmd, "memory", conf,
+		[]string{"review.memory"},
+		true,
+	)
+	s.MemoryFile = resolveMRStringSetting(
+		cmd, "memory-file", conf,
+		[]string{"review.memory_file"},
+		defaultReviewMemoryFile,
+	)
+	s.MemoryMax = resolveMRIntSetting(
+		cmd, "memory-max", conf,
+		[]string{"review.memory_max"},
+		12,
+	)
+	if s.MemoryMax <= 0 {
+		s.MemoryMax = 12
+	}
+	s.MemoryDecayDays = resolveMRIntSetting(
+		cmd, "", conf,
+		[]string{"review.memory_decay_days"},
+		defaultReviewMemoryDecayDays,
+	)
+	s.NativeImpact = resolveMRBoolSetting(
+		cmd, "native-impact", conf,
+		[]string{"review.native_impact"},
+		true,
+	)
+	s.NativeImpactMaxSymbols = resolveMRIntSetting(
+		cmd, "native-impact-max-symbols", conf,
+		[]string{"review.native_impact_max_symbols"},
+		12,
+	)
+	s.DependencyReview = resolveMRBoolSetting(
+		cmd, "dependency-review", conf,
+		[]string{"review.dependency_review"},
+		true,
+	)
+	s.FixPrompt = normalizeFixPromptMode(resolveMRStringSetting(
+		cmd, "fix-prompt", conf,
+		[]string{"review.fix_prompt"},
+		"off",
+	))
+	// Zero keeps the built-in inline verbosity (see inlineBodyLimits).
+	s.MaxInlineKeyPoints = max(resolveMRIntSetting(cmd, "", conf, []string{"review.max_inline_key_points"}, 0), 0)
+	s.MaxInlineKeyPointChars = max(resolveMRIntSetting(cmd, "", conf, []string{"review.max_inline_key_point_chars"}, 0), 0)
+	s.MaxInlineChars = max(resolveMRIntSetting(cmd, "", conf, []string{"review.max_inline_chars"}, 0), 0)
+	if conf.Viper != nil {
+		s.StructuredOutput = conf.Viper.GetBool("review.structured_output")
+	}
+	if f := cmd.Flags().Lookup("structured-output"); f != nil && f.Changed {
+		s.StructuredOutput, _ = cmd.Flags().GetBool("structured-output")
+	}
+	s.OnContentFilter = resolveMRStringSetting(
+		cmd, "on-content-filter", conf,
+		[]string{"review.on_content_filter"},
+		contentFilterSkip,
+	)
+	s.PassMode = normalizePassMode(resolveMRStringSetting(
+		cmd, "pass-mode", conf,
+		[]string{"review.pass_mode"},
+		passModeRefine,
+	))
+	s.EarlyStopOnClean = resolveMRBoolSetting(
+		cmd, "early-stop-on-clean", conf,
+		[]string{"review.early_stop_on_clean"},
+		false,
+	)
+	s.Samples = resolveMRIntSetting(
+		cmd, "samples", conf,
+		[]string{"review.samples"},
+		1,
+	)
+	s.Samples = max(1, min(s.Samples, maxReviewSamples))
+	s.Cache = resolveMRBoolSetting(
+		cmd, "cache", conf,
+		[]string{"review.cache"},
+		false,
+	)
+	if s.Samples > 1 && s.Cache {
+		notes = append(notes, "Review cache disabled with samples > 1 (alternate samples are not cached).")
+		s.Cache = false
+	}
+	s.cacheTTL = reviewcache.DefaultTTL
+	if raw := strings.TrimSpace(resolveMRStringSetting(
+		cmd, "cache-ttl", conf,
+		[]string{"review.cache_ttl"},
+		reviewcache.DefaultTTL.String(),
+	)); raw != "" {
+		d, perr := time.ParseDuration(raw)
+		if perr != nil {
+			return s, nil, fmt.Errorf("invalid cache TTL %q: %w", raw, perr)
+		}
+		s.cacheTTL = d
+	}
+	s.CacheTTL = s.cacheTTL.String()
+	s.CollapsibleSummary = resolveMRBoolSetting(
+		cmd, "collapsible-summary", conf,
+		[]string{"review.collapsible_summary"},
+		false,
+	)
+	s.PostOrder = normalizePostOrder(resolveMRStringSetting(
+		cmd, "post-order", conf,
+		[]string{"review.post_order"},
+		postOrderInlineFirst,
+	))
+	s.SummaryTemplate = resolveMRStringSetting(
+		cmd, "summary-template", conf,
+		[]string{"review.summary_template"},
+		"",
+	)
+	if strings.TrimSpace(s.SummaryTemplate) != "" {
+		if _, terr := parseSummaryTemplate(s.SummaryTemplate); terr != nil {
+			return s, nil, terr
+		}
+	}
+	s.InlineTemplate = resolveMRStringSetting(cmd, "", conf, []string{"review.inline_template"}, "")
+	if strings.TrimSpace(s.InlineTemplate) != "" {
+		if _, terr := parseInlineTemplate(s.InlineTemplate); terr != nil {
+			return s, nil, terr
+		}
+	}
+	summaryTarget, serr := normalizeSummaryTarget(resolveMRStringSetting(
+		cmd, "summary-target", conf,
+		[]string{"review.summary_target"},
+		summaryTargetNote,
+	))
+	if serr != nil {
+		return s, nil, serr
+	}
+	s.SummaryTarget = summaryTarget
+	postMode, perr := normalizePostMode(resolveMRStringSetting(
+		cmd, "post-mode", conf,
+		[]string{"review.post_mode"},
+		postModeDefault,
+	))
+	if perr != nil {
+		return s, nil, perr
+	}
+	s.PostMode = postMode
+	// System prompt overrides are config-only (no flags).
+	s.SystemPrompt = resolveMRStringSetting(cmd, "", conf, []string{"review.system_prompt"}, "")
+	s.ReplySystemPrompt = resolveMRStringSetting(cmd, "", conf, []string{"review.reply_system_prompt"}, "")
+	// Cost estimates are config-only too.
+	pricing := resolveTokenPricing(conf)
+	s.CostPer1KInput, s.CostPer1KOutput = pricing.InputPer1K, pricing.OutputPer1K
+	failOn, ferr := normalizeFailOnSeverity(resolveMRStringSetting(
+		cmd, "fail-on", conf,
+		[]string{"review.fail_on"},
+		"",
+	))
+	if ferr != nil {
+		return s, nil, ferr
+	}
+	s.FailOn = failOn
+	if conf.Viper != nil {
+		s.InlineOnly = conf.Viper.GetBool("review.inline_only")
+	}
+	if f := cmd.Flags().Lookup("inline-only"); f != nil && f.Changed {
+		s.InlineOnly, _ = cmd.Flags().GetBool("inline-only")
+	}
+	s.InlineOnlySummaryFallback = resolveMRBoolSetting(
+		cmd, "inline-only-summary-fallback", conf,
+		[]string{"review.inline_only_summary_fallback"},
+		false,
+	)
+	if s.InlineOnly && s.Incremental {
+		notes = append(notes, "Incremental mode disabled in inline-only mode (baseline markers require non-inline MR notes).")
+		s.Incremental = false
+	}
+	s.ReportAsStatus = resolveMRBoolSetting(
+		cmd, "report-as-status", conf,
+		[]string{"review.report_as_status"},
+		false,
+	)
+	s.ReconsiderApproval = resolveMRBoolSetting(
+		cmd, "reconsider-approval", conf,
+		[]string{"review.reconsider_approval"},
+		false,
+	)
+	if s.InlineOnly && s.ReconsiderApproval {
+		notes = append(notes, "Approval reconsideration disabled in inline-only mode (it posts a non-inline MR note).")
+		s.ReconsiderApproval = false
+	}
+	s.AutoResolve = resolveMRBoolSetting(
+		cmd, "auto-resolve", conf,
+		[]string{"review.auto_resolve"},
+		false,
+	)
+	if s.InlineOnly && s.AutoResolve {
+		notes = append(notes, "Auto-resolve disabled in inline-only mode (it posts thread replies).")
+		s.AutoResolve = false
+	}
+	s.SuppressStale = resolveMRBoolSetting(
+		cmd, "suppress-stale", conf,
+		[]string{"review.suppress_stale"},
+		false,
+	)
+	s.IncludeDiffStats = resolveMRBoolSetting(
+		cmd, "include-diff-stats", conf,
+		[]string{"review.include_diff_stats_in_prompt"},
+		false,
+	)
+	s.MaxTitleChars = resolveMRIntSetting(
+		cmd, "max-title-chars", conf,
+		[]string{"review.max_title_chars"},
+		0,
+	)
+	s.MaxDescriptionChars = resolveMRIntSetting(
+		cmd, "max-description-chars", conf,
+		[]string{"review.max_description_chars"},
+		0,
+	)
+	s.Conventions = resolveMRStringSliceSetting(conf, []string{"review.conventions.labels"}, defaultConventionLabels)
+	s.MRDiffSource = resolveMRStringSetting(
+		cmd, "mr-diff-source", conf,
+		[]string{"review.mr_diff_source"},
+		"auto",
+	)
+	s.UseMergeBase = resolveMRBoolSetting(
+		cmd, "use-merge-base", conf,
+		[]string{"review.use_merge_base"},
+		false,
+	)
+	s.Serena = resolveMRStringSetting(
+		cmd, "serena", conf,
+		[]string{"review.serena_mode", "serena_mode"},
+		"auto",
+	)
+	s.ContextLines = resolveMRIntSetting(
+		cmd, "context", conf,
+		[]string{"review.context_lines"},
+		10,
+	)
+	s.MaxTokens = resolveMRIntSetting(
+		cmd, "max-tokens", conf,
+		[]string{"review.max_tokens"},
+		80000,
+	)
+	s.BudgetStrategy = diffparse.NormalizeBudgetStrategy(resolveMRStringSetting(
+		cmd, "budget-strategy", conf,
+		[]string{"review.budget_strategy"},
+		diffparse.BudgetReduceContext,
+	))
+	s.IgnoreGlobs = resolveIgnoreGlobs(cmd, conf)
+	s.SkipGenerated = s.IgnoreGlobs != nil
+	s.MaxDiffBytes = max(resolveMRIntSetting(cmd, "", conf, []string{"review.max_diff_bytes"}, 0), 0)
+	s.ForceFull = resolveMRBoolSetting(cmd, "force-full", conf, nil, false)
+	s.Commits = resolveMRBoolSetting(cmd, "commits", conf, []string{"review.commits"}, false)
+	s.ConsolidateRecurring = max(resolveMRIntSetting(cmd, "", conf, []string{"review.consolidate_recurring"}, 0), 0)
+	if conf.Viper != nil {
+		s.MinConfidence = min(max(conf.Viper.GetFloat64("review.min_confidence"), 0), 1)
+	}
+	return s, notes, nil
+}
+
+// resolvedProviderAndModel returns the AI provider name and model that
+// resolveProvider would use, without constructing the provider. The model is
+// empty when the provider default applies.
+func resolvedProviderAndModel(conf config.Config) (string, string) {
+	name := strings.TrimSpace(conf.Provider)
+	if name == "" && conf.Viper != nil {
+		name = provider.ResolveProvider(conf.Viper).Name
+	}
+	return name, resolvedModelForLog(conf, "")
+}
+
+func writeMRReviewSettingsJSON(w io.Writer, s mrReviewSettings) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/config"
+	"github.com/sanix-darker/prev/internal/reviewcache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveMRReviewSettings_JSONPrecedence(t *testing.T) {
+	v := config.NewStore()
+	v.Set("review.strictness", "lenient")
+	v.Set("review.nitpick", 7)
+	v.Set("review.passes", 3)
+	v.Set("review.inline_only", true)
+	v.Set("review.incremental", true)
+	v.Set("review.ignore_globs", []string{"gen/**"})
+
+	cmd := newMRReviewCmd()
+	require.NoError(t, cmd.Flags().Set("strictness", "strict"))
+	require.NoError(t, cmd.Flags().Set("max-tokens", "1234"))
+
+	s, notes, err := resolveMRReviewSettings(cmd, config.Config{Viper: v, Provider: "anthropic", Model: "claude-x"})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, writeMRReviewSettingsJSON(&buf, s))
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+
+	assert.Equal(t, "anthropic", got["provider"])
+	assert.Equal(t, "claude-x", got["model"])
+	assert.Equal(t, "strict", got["strictness"], "flag beats config")
+	assert.Equal(t, float64(7), got["nitpick"], "config beats default")
+	assert.Equal(t, float64(3), got["passes"])
+	assert.Equal(t, float64(1234), got["max_tokens"])
+	assert.Equal(t, float64(10), got["context_lines"], "default when neither is set")
+	assert.Equal(t, "reduce_context", got["budget_strategy"])
+	assert.Equal(t, "diff_context", got["filter_mode"])
+	assert.Equal(t, reviewcache.DefaultTTL.String(), got["cache_ttl"])
+	assert.Equal(t, []interface{}{"gen/**"}, got["ignore_globs"])
+	assert.Equal(t, true, got["inline_only"])
+	assert.Equal(t, false, got["incremental"], "inline-only turns incremental off")
+	assert.Len(t, notes, 1)
+}
+
+func TestResolveMRReviewSettings_InvalidCacheTTL(t *testing.T) {
+	cmd := newMRReviewCmd()
+	require.NoError(t, cmd.Flags().Set("cache-ttl", "soon"))
+
+	_, _, err := resolveMRReviewSettings(cmd, config.Config{Viper: config.NewStore()})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid cache TTL")
+}
+
+func TestResolveMRReviewSettings_InlineVerbosityFromConfig(t *testing.T) {
+	cmd := newMRReviewCmd()
+	v := config.NewStore()
+
+	s, _, err := resolveMRReviewSettings(cmd, config.Config{Viper: v})
+	require.NoError(t, err)
+	assert.Zero(t, s.MaxInlineKeyPoints, "unset keeps the built-in inline limits")
+	assert.Zero(t, s.MaxInlineKeyPointChars)
+	assert.Zero(t, s.MaxInlineChars)
+
+	v.Set("max_key_points", 3)
+	v.Set("max_characters_per_key_point", 100)
+	s, _, err = resolveMRReviewSettings(cmd, config.Config{Viper: v})
+	require.NoError(t, err)
+	assert.Zero(t, s.MaxInlineKeyPoints, "the prompt key-point settings do not shape inline comments")
+	assert.Zero(t, s.MaxInlineKeyPointChars)
+
+	v.Set("review.max_inline_key_points", 2)
+	v.Set("review.max_inline_key_point_chars", 40)
+	s, _, err = resolveMRReviewSettings(cmd, config.Config{Viper: v})
+	require.NoError(t, err)
+	limits := inlineBodyLimits{MaxKeyPoints: s.MaxInlineKeyPoints, MaxPointChars: s.MaxInlineKeyPointChars, MaxBodyChars: s.MaxInlineChars}
+	assert.Equal(t, 2, limits.keyPoints())
+	assert.Equal(t, 40, limits.pointChars())
+	assert.Equal(t, defaultInlineMaxBodyChars, limits.bodyChars(), "the posted line has its own limit")
+
+	msg := "- This finding message is long enough to be cut at forty chars."
+	body := buildInlineCommentBody("", 0, "HIGH", msg, "", nil, limits, nil)
+	assert.Equal(t, "[HIGH] This finding message is long enough to…", body, "the key point is cut at 40")
+
+	v.Set("review.max_inline_key_point_chars", 0)
+	v.Set("review.max_inline_chars", 30)
+	s, _, err = resolveMRReviewSettings(cmd, config.Config{Viper: v})
+	require.NoError(t, err)
+	limits = inlineBodyLimits{MaxKeyPoints: s.MaxInlineKeyPoints, MaxPointChars: s.MaxInlineKeyPointChars, MaxBodyChars: s.MaxInlineChars}
+	body = buildInlineCommentBody("", 0, "HIGH", msg, "", nil, limits, nil)
+	assert.Equal(t, "[HIGH] This finding message i…", body, "the posted line is cut at 30")
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sanix-darker/prev/internal/core"
+	"github.com/sanix-darker/prev/internal/diffparse"
+	"github.com/sanix-darker/prev/internal/vcs"
+)
+
+// scopeChangesSince narrows changes to the hunks that overlap the diff
+// between since and head, read from the local repository first and the
+// provider compare API second. Files the delta does not touch are dropped.
+// The returned string names the source used.
+func scopeChangesSince(ctx context.Context, vcsProvider vcs.VCSProvider, repoPath, projectID, since, head string, changes []diffparse.FileChange) ([]diffparse.FileChange, string, error) {
+	delta, source, err := diffSince(ctx, vcsProvider, repoPath, projectID, since, head)
+	if err != nil {
+		return nil, "", err
+	}
+	return restrictChangesToDelta(changes, delta), source, nil
+}
+
+// diffSince returns the straight (two-dot) diff between since and head.
+func diffSince(ctx context.Context, vcsProvider vcs.VCSProvider, repoPath, projectID, since, head string) ([]diffparse.FileChange, string, error) {
+	if strings.TrimSpace(head) == "" {
+		head = "HEAD"
+	}
+	raw, gitErr := core.GetGitDiffBetween(repoPath, since, head)
+	if gitErr == nil {
+		delta, err := diffparse.ParseGitDiff(raw)
+		if err == nil {
+			return delta, "git", nil
+		}
+		gitErr = err
+	}
+	differ, ok := vcsProvider.(vcs.CompareDiffer)
+	if !ok {
+		return nil, "", fmt.Errorf("commit %s is not reachable: %v", since, gitErr)
+	}
+	diffs, err := differ.CompareDiffs(ctx, projectID, since, head)
+	if err != nil {
+		return nil, "", fmt.Errorf("commit %s is not reachable locally (%v) or through the %s compare API: %w",
+			since, gitErr, vcsProvider.Info().Name, err)
+	}
+	glDiffs := make([]diffparse.GitLabDiff, 0, len(diffs))
+	for _, d := range diffs {
+		glDiffs = append(glDiffs, diffparse.GitLabDiff{
+			OldPath:     d.OldPath,
+			NewPath:     d.NewPath,
+			Diff:        d.Diff,
+			NewFile:     d.NewFile,
+			RenamedFile: d.RenamedFile,
+			DeletedFile: d.DeletedFile,
+		})
+	}
+	delta, err := diffparse.ParseGitLabDiffs(glDiffs)
+	if err != nil {
+		return nil, "", err
+	}
+	return delta, vcsProvider.Info().Name + " compare API", nil
+}
+
+// restrictChangesToDelta keeps, for each MR change touched by delta, the
+// hunks whose new-side range overlaps a delta hunk. Both diffs end at the
+// same head, so their new-side line numbers agree. A delta file without
+// hunks (binary, rename only, patch omitted) keeps the whole MR change.
+func restrictChangesToDelta(changes, delta []diffparse.FileChange) []diffparse.FileChange {
+	touched := make(map[string][]diffparse.Hunk, len(delta))
+	for _, d := range delta {
+		for _, name := range []string{d.NewName, d.OldName} {
+			if name = strings.TrimSpace(name); name != "" {
+				touched[name] = append(touched[name], d.Hunks...)
+			}
+		}
+	}
+	out := make([]diffparse.FileChange, 0, len(changes))
+	for _, c := range changes {
+		hunks, ok := touched[strings.TrimSpace(changeFileName(c))]
+		if !ok {
+			continue
+		}
+		if len(hunks) == 0 || len(c.Hunks) == 0 {
+			out = append(out, c)
+			continue
+		}
+		var kept []diffparse.Hunk
+		for _, h := range c.Hunks {
+			for _, d := range hunks {
+				if hunksOverlap(h, d) {
+					kept = append(kept, h)
+					break
+				}
+			}
+		}
+		if len(kept) == 0 {
+			continue
+		}
+		c.Hunks = kept
+		out = append(out, c)
+	}
+	return out
+}
+
+// hunksOverlap reports whether the new-side ranges of a and b intersect. A
+// pure deletion has no new lines and covers the line it sits before.
+func hunksOverlap(a, b diffparse.Hunk) bool {
+	aEnd := a.NewStart + max(a.NewLines, 1) - 1
+	bEnd := b.NewStart + max(b.NewLines, 1) - 1
+	return a.NewStart <= bEnd && b.NewStart <= aEnd
+}
+
+// filterChangesByGlobs keeps the changes whose old or new path matches one
+// of globs (core.MatchesAnyPathGlob syntax).
+func filterChangesByGlobs(changes []diffparse.FileChange, globs []string) []diffparse.FileChange {
+	out := make([]diffparse.FileChange, 0, len(changes))
+	for _, c := range changes {
+		if core.MatchesAnyPathGlob(c.NewName, globs) || core.MatchesAnyPathGlob(c.OldName, globs) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// newCommitsSinceBaseline lists, newest first, the commits between the
+// baseline head and head that the baseline has not recorded as reviewed.
+func newCommitsSinceBaseline(repoPath string, baseline reviewBaseline, head string) ([]core.CommitInfo, error) {
+	commits, err := core.GetCommitList(repoPath, baseline.HeadSHA, head)
+	if err != nil {
+		return nil, err
+	}
+	reviewed := make(map[string]struct{}, len(baseline.ReviewedSHAs)+1)
+	reviewed[baseline.HeadSHA] = struct{}{}
+	for _, sha := range baseline.ReviewedSHAs {
+		reviewed[sha] = struct{}{}
+	}
+	out := make([]core.CommitInfo, 0, len(commits))
+	for _, c := range commits {
+		if _, ok := reviewed[c.Hash]; !ok {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+// mrCommitSHAs returns the SHAs of the MR's commits from the local
+// repository, or just head when they cannot be listed.
+func mrCommitSHAs(repoPath string, refs vcs.DiffRefs) []string {
+	if refs.BaseSHA != "" && refs.HeadSHA != "" {
+		if commits, err := core.GetCommitList(repoPath, refs.BaseSHA, refs.HeadSHA); err == nil && len(commits) > 0 {
+			out := make([]string, 0, len(commits))
+			for _, c := range commits {
+				out = append(out, c.Hash)
+			}
+			return out
+		}
+	}
+	if refs.HeadSHA == "" {
+		return nil
+	}
+	return []string{refs.HeadSHA}
+}
+
+// mergeReviewedSHAs appends the SHAs in current that previous lacks,
+// keeping the order of first appearance.
+func mergeReviewedSHAs(previous, current []string) []string {
+	seen := make(map[string]struct{}, len(previous)+len(current))
+	out := make([]string, 0, len(previous)+len(current))
+	for _, sha := range append(append([]string{}, previous...), current...) {
+		sha = strings.TrimSpace(sha)
+		if sha == "" {
+			continue
+		}
+		if _, ok := seen[sha]; ok {
+			continue
+		}
+		seen[sha] = struct{}{}
+		out = append(out, sha)
+	}
+	return out
+}
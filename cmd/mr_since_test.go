@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/diffparse"
+	"github.com/sanix-darker/prev/internal/vcs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type comparingVCSProvider struct {
+	recordingVCSProvider
+	diffs []vcs.FileDiff
+	err   error
+	calls []string
+}
+
+func (c *comparingVCSProvider) CompareDiffs(_ context.Context, projectID, from, to string) ([]vcs.FileDiff, error) {
+	c.calls = append(c.calls, projectID+" "+from+".."+to)
+	return c.diffs, c.err
+}
+
+func TestScopeChangesSince_KeepsOnlyHunksTouchedSince(t *testing.T) {
+	p := &comparingVCSProvider{diffs: []vcs.FileDiff{
+		{OldPath: "b.go", NewPath: "b.go", Diff: "@@ -40,2 +40,3 @@\n ctx := r.Context()\n+log(ctx)\n return nil\n"},
+		{OldPath: "docs/old.md", NewPath: "docs/new.md", RenamedFile: true},
+	}}
+	changes := []diffparse.FileChange{
+		{NewName: "a.go", OldName: "a.go", Hunks: []diffparse.Hunk{{NewStart: 1, NewLines: 3}}},
+		{NewName: "b.go", OldName: "b.go", Hunks: []diffparse.Hunk{
+			{NewStart: 3, NewLines: 4},
+			{NewStart: 38, NewLines: 6},
+		}},
+		{NewName: "docs/new.md", OldName: "docs/old.md", Hunks: []diffparse.Hunk{{NewStart: 1, NewLines: 2}}},
+	}
+
+	scoped, source, err := scopeChangesSince(context.Background(), p, t.TempDir(), "acme/blog", "abc1234", "def5678", changes)
+	require.NoError(t, err)
+	assert.Equal(t, "recording compare API", source)
+	assert.Equal(t, []string{"acme/blog abc1234..def5678"}, p.calls)
+	require.Len(t, scoped, 2)
+	assert.Equal(t, "b.go", scoped[0].NewName)
+	assert.Equal(t, []diffparse.Hunk{{NewStart: 38, NewLines: 6}}, scoped[0].Hunks, "hunk before the new commits is dropped")
+	assert.Equal(t, "docs/new.md", scoped[1].NewName)
+}
+
+func TestScopeChangesSince_UnreachableSHA(t *testing.T) {
+	p := &comparingVCSProvider{err: errors.New("HTTP 404: No common ancestor")}
+	_, _, err := scopeChangesSince(context.Background(), p, t.TempDir(), "acme/blog", "deadbeef", "def5678", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "commit deadbeef is not reachable")
+	assert.Contains(t, err.Error(), "No common ancestor")
+
+	_, _, err = scopeChangesSince(context.Background(), &recordingVCSProvider{}, t.TempDir(), "acme/blog", "deadbeef", "def5678", nil)
+	assert.ErrorContains(t, err, "commit deadbeef is not reachable")
+}
+
+func TestHunksOverlap(t *testing.T) {
+	assert.True(t, hunksOverlap(diffparse.Hunk{NewStart: 10, NewLines: 5}, diffparse.Hunk{NewStart: 14, NewLines: 2}))
+	assert.False(t, hunksOverlap(diffparse.Hunk{NewStart: 10, NewLines: 5}, diffparse.Hunk{NewStart: 15, NewLines: 2}))
+	assert.True(t, hunksOverlap(diffparse.Hunk{NewStart: 10, NewLines: 5}, diffparse.Hunk{NewStart: 12, NewLines: 0}), "pure deletion inside the hunk")
+}
+
+func TestFilterChangesByGlobs(t *testing.T) {
+	changes := []diffparse.FileChange{
+		{NewName: "backend/api/server.go"},
+		{NewName: "backend/db/query.go"},
+		{NewName: "frontend/src/app.tsx"},
+		{OldName: "backend/legacy.go", NewName: "web/legacy.go", IsRenamed: true},
+		{NewName: "README.md"},
+	}
+
+	filtered := filterChangesByGlobs(changes, []string{"backend/**"})
+	names := make([]string, 0, len(filtered))
+	for _, c := range filtered {
+		names = append(names, changeFileName(c))
+	}
+	assert.Equal(t, []string{"backend/api/server.go", "backend/db/query.go", "web/legacy.go"}, names)
+
+	assert.Len(t, filterChangesByGlobs(changes, []string{"*.tsx", "README.md"}), 2)
+	assert.Empty(t, filterChangesByGlobs(changes, []string{"docs/**"}))
+}
+
+func TestNewCommitsSinceBaseline_SkipsReviewedCommits(t *testing.T) {
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@test.com",
+			"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@test.com",
+		)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v failed: %s", args, strings.TrimSpace(string(out)))
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(file, msg string) string {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(msg+"\n"), 0644))
+		git("add", ".")
+		git("commit", "-m", msg)
+		return git("rev-parse", "HEAD")
+	}
+
+	git("init", "-b", "main")
+	base := commit("a.go", "base")
+	reviewedHead := commit("a.go", "first push")
+	cherry := commit("b.go", "already reviewed elsewhere")
+	latest := commit("c.go", "second push")
+
+	baseline := reviewBaseline{HeadSHA: reviewedHead, ReviewedSHAs: []string{base, reviewedHead, cherry}}
+	commits, err := newCommitsSinceBaseline(dir, baseline, latest)
+	require.NoError(t, err)
+	require.Len(t, commits, 1)
+	assert.Equal(t, latest, commits[0].Hash)
+	assert.Equal(t, "second push", commits[0].Subject)
+
+	assert.Equal(t, []string{latest, cherry, reviewedHead}, mrCommitSHAs(dir, vcs.DiffRefs{BaseSHA: base, HeadSHA: latest}))
+	assert.Equal(t, []string{"head"}, mrCommitSHAs(t.TempDir(), vcs.DiffRefs{BaseSHA: "base", HeadSHA: "head"}))
+}
+
+func TestMergeReviewedSHAs(t *testing.T) {
+	assert.Equal(t, []string{"a", "b", "c"}, mergeReviewedSHAs([]string{"a", "b"}, []string{"c", "a", " "}))
+	assert.Empty(t, mergeReviewedSHAs(nil, nil))
+}
+
+func TestReviewBaseline_ReviewedSHAsOmittedWhenEmpty(t *testing.T) {
+	raw, err := json.Marshal(reviewBaseline{HeadSHA: "abc"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "reviewed_shas")
+
+	notes := []vcs.MRNote{{Body: prevBaselinePrefix + "eyJoZWFkX3NoYSI6ImFiYyJ9 -->"}}
+	baseline, ok := latestReviewBaseline(notes)
+	require.True(t, ok)
+	assert.Equal(t, "abc", baseline.HeadSHA)
+	assert.Empty(t, baseline.ReviewedSHAs)
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sanix-darker/prev/internal/core"
+)
+
+const (
+	// staleAnchorWindow is how many lines around the anchor are compared, to
+	// tolerate findings anchored a line or two off.
+	staleAnchorWindow = 2
+	// staleMinOverlap is the share of quoted-code tokens that must still
+	// appear near the anchor for a finding to be considered current.
+	staleMinOverlap = 0.34
+)
+
+var staleQuotedCodeRe = regexp.MustCompile("`([^`\n]{3,})`")
+
+// suppressStaleFindings drops findings whose quoted code no longer matches
+// the added lines at their anchor, which happens when a later commit in the
+// MR rewrote the line the finding was written against.
+func suppressStaleFindings(comments []core.FileComment, valid map[string]inlinePositions) ([]core.FileComment, int) {
+	if len(comments) == 0 {
+		return comments, 0
+	}
+	out := make([]core.FileComment, 0, len(comments))
+	suppressed := 0
+	for _, c := range comments {
+		if isPossiblyStaleFinding(c, valid) {
+			suppressed++
+			continue
+		}
+		out = append(out, c)
+	}
+	return out, suppressed
+}
+
+// isPossiblyStaleFinding reports whether a finding anchored on an added line
+// quotes code that shares little with the current content around that line.
+// Findings without quoted code, or not anchored on an added line, are never
+// considered stale.
+func isPossiblyStaleFinding(c core.FileComment, valid map[string]inlinePositions) bool {
+	fp, ok := valid[core.NormalizePath(c.FilePath)]
+	if !ok {
+		return false
+	}
+	if _, added := fp.added[c.Line]; !added {
+		return false
+	}
+	var quoted []string
+	for _, m := range staleQuotedCodeRe.FindAllStringSubmatch(c.Message, -1) {
+		quoted = append(quoted, m[1])
+	}
+	anchor := toKeywordSet(strings.Join(quoted, " "))
+	if len(anchor) < 2 {
+		return false
+	}
+
+	var current []string
+	for line := c.Line - staleAnchorWindow; line <= c.Line+staleAnchorWindow; line++ {
+		if content, ok := fp.content[line]; ok {
+			current = append(current, content)
+		}
+	}
+	present := toKeywordSet(strings.Join(current, "\n"))
+	matched := 0
+	for tok := range anchor {
+		if _, ok := present[tok]; ok {
+			matched++
+		}
+	}
+	return float64(matched)/float64(len(anchor)) < staleMinOverlap
+}
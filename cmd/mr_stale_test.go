@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/core"
+	"github.com/sanix-darker/prev/internal/diffparse"
+	"github.com/stretchr/testify/assert"
+)
+
+func staleTestPositions() map[string]inlinePositions {
+	return collectValidPositions([]diffparse.FileChange{
+		{
+			NewName: "client/retry.go",
+			Hunks: []diffparse.Hunk{
+				{
+					NewStart: 10,
+					NewLines: 3,
+					Lines: []diffparse.DiffLine{
+						{Type: diffparse.LineContext, OldLineNo: 10, NewLineNo: 10, Content: "func (c *Client) send(req *Request) error {"},
+						{Type: diffparse.LineAdded, NewLineNo: 11, Content: "\tbackoff := c.policy.Backoff()"},
+						{Type: diffparse.LineAdded, NewLineNo: 12, Content: "\treturn c.transport.Do(req, backoff)"},
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestSuppressStaleFindings_DropsFindingWhoseAnchorChanged(t *testing.T) {
+	comments := []core.FileComment{
+		{FilePath: "client/retry.go", Line: 11, Severity: "HIGH", Message: "`retries := maxRetries * 2` overflows when `maxRetries` is large."},
+		{FilePath: "client/retry.go", Line: 12, Severity: "MEDIUM", Message: "`c.transport.Do(req, backoff)` ignores context cancellation."},
+		{FilePath: "client/retry.go", Line: 11, Severity: "LOW", Message: "Consider documenting the backoff policy."},
+	}
+
+	kept, suppressed := suppressStaleFindings(comments, staleTestPositions())
+	assert.Equal(t, 1, suppressed)
+	assert.Equal(t, []core.FileComment{comments[1], comments[2]}, kept)
+}
+
+func TestIsPossiblyStaleFinding_IgnoresContextLines(t *testing.T) {
+	c := core.FileComment{FilePath: "client/retry.go", Line: 10, Message: "`retries := maxRetries * 2` overflows."}
+	assert.False(t, isPossiblyStaleFinding(c, staleTestPositions()))
+}
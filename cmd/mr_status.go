@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sanix-darker/prev/internal/core"
+	"github.com/sanix-darker/prev/internal/vcs"
+)
+
+// reviewStatusName is the commit status name set by review.report_as_status,
+// scoped by review.marker_namespace so parallel instances keep separate checks.
+func reviewStatusName() string {
+	if prevMarkerNamespace != "" {
+		return "prev/" + prevMarkerNamespace
+	}
+	return "prev/review"
+}
+
+// buildReviewStatus maps findings to a commit status: failure when the review
+// verdict requests changes (any CRITICAL finding), success otherwise. The
+// description carries severity counts followed by the review summary.
+func buildReviewStatus(findings []core.FileComment, summary string) (state, description string) {
+	state = vcs.StatusSuccess
+	if reviewVerdict(findings) == reviewVerdictChangesRequested {
+		state = vcs.StatusFailure
+	}
+
+	counts := map[string]int{}
+	for _, f := range findings {
+		counts[strings.ToUpper(strings.TrimSpace(f.Severity))]++
+	}
+	var parts []string
+	for _, sev := range []string{"CRITICAL", "HIGH", "MEDIUM", "LOW"} {
+		if n := counts[sev]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, sev))
+		}
+	}
+	head := "No findings"
+	if len(parts) > 0 {
+		head = strings.Join(parts, ", ")
+	}
+	summary = strings.Join(strings.Fields(summary), " ")
+	if summary == "" {
+		return state, head
+	}
+	return state, head + ". " + summary
+}
+
+// reportReviewStatus sets the review commit status on headSHA. It returns the
+// state that was set.
+func reportReviewStatus(
+	ctx context.Context,
+	vcsProvider vcs.VCSProvider,
+	projectID string,
+	headSHA string,
+	findings []core.FileComment,
+	summary string,
+) (string, error) {
+	reporter, ok := vcsProvider.(vcs.StatusReporter)
+	if !ok {
+		return "", fmt.Errorf("%s does not support commit statuses", vcsProvider.Info().Name)
+	}
+	if strings.TrimSpace(headSHA) == "" {
+		return "", fmt.Errorf("missing head SHA for commit status")
+	}
+	state, description := buildReviewStatus(findings, summary)
+	if err := reporter.CreateStatus(ctx, projectID, headSHA, state, description, reviewStatusName()); err != nil {
+		return "", err
+	}
+	return state, nil
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/core"
+	"github.com/sanix-darker/prev/internal/vcs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type statusCall struct {
+	sha, state, description, name string
+}
+
+type statusRecordingVCSProvider struct {
+	recordingVCSProvider
+	statuses []statusCall
+}
+
+func (s *statusRecordingVCSProvider) CreateStatus(_ context.Context, _ string, sha, state, description, name string) error {
+	s.statuses = append(s.statuses, statusCall{sha: sha, state: state, description: description, name: name})
+	return nil
+}
+
+func TestReportReviewStatus_CleanReviewSetsSuccess(t *testing.T) {
+	rec := &statusRecordingVCSProvider{}
+	findings := []core.FileComment{{FilePath: "a.go", Line: 3, Severity: "LOW", Message: "Nit"}}
+
+	state, err := reportReviewStatus(context.Background(), rec, "grp/proj", "abc123", findings, "Looks good overall.")
+	require.NoError(t, err)
+	assert.Equal(t, vcs.StatusSuccess, state)
+	require.Len(t, rec.statuses, 1)
+	assert.Equal(t, statusCall{sha: "abc123", state: vcs.StatusSuccess, description: "1 LOW. Looks good overall.", name: "prev/review"}, rec.statuses[0])
+	assert.Empty(t, rec.summaries)
+}
+
+func TestReportReviewStatus_CriticalSetsFailure(t *testing.T) {
+	rec := &statusRecordingVCSProvider{}
+	findings := []core.FileComment{
+		{FilePath: "a.go", Line: 3, Severity: "CRITICAL", Message: "SQL injection"},
+		{FilePath: "b.go", Line: 9, Severity: "HIGH", Message: "Unchecked error"},
+	}
+
+	state, err := reportReviewStatus(context.Background(), rec, "grp/proj", "abc123", findings, "")
+	require.NoError(t, err)
+	assert.Equal(t, vcs.StatusFailure, state)
+	require.Len(t, rec.statuses, 1)
+	assert.Equal(t, "1 CRITICAL, 1 HIGH", rec.statuses[0].description)
+}
+
+func TestReportReviewStatus_UnsupportedProvider(t *testing.T) {
+	_, err := reportReviewStatus(context.Background(), &recordingVCSProvider{}, "grp/proj", "abc123", nil, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not support commit statuses")
+}
+
+func TestReviewStatusName_FollowsMarkerNamespace(t *testing.T) {
+	t.Cleanup(func() { applyMarkerNamespace("") })
+	assert.Equal(t, "prev/review", reviewStatusName())
+	applyMarkerNamespace("security")
+	assert.Equal(t, "prev/security", reviewStatusName())
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sanix-darker/prev/internal/core"
+	"github.com/sanix-darker/prev/internal/vcs"
+)
+
+// Where mr review writes its summary (review.summary_target).
+const (
+	summaryTargetNote        = "note"
+	summaryTargetLabel       = "label"
+	summaryTargetDescription = "description"
+)
+
+// Verdict labels set with review.summary_target=label.
+const (
+	prevLabelNeedsWork = "prev:needs-work"
+	prevLabelLooksGood = "prev:looks-good"
+)
+
+func normalizeSummaryTarget(raw string) (string, error) {
+	switch t := strings.ToLower(strings.TrimSpace(raw)); t {
+	case "", summaryTargetNote:
+		return summaryTargetNote, nil
+	case summaryTargetLabel, summaryTargetDescription:
+		return t, nil
+	default:
+		return "", fmt.Errorf("invalid summary target %q (want note, label or description)", raw)
+	}
+}
+
+// summaryVerdictLabel derives the verdict label from the most severe
+// finding: any HIGH or CRITICAL finding means the MR needs work.
+func summaryVerdictLabel(findings []core.FileComment) string {
+	for _, f := range findings {
+		if severityRank(f.Severity) >= severityRank("HIGH") {
+			return prevLabelNeedsWork
+		}
+	}
+	return prevLabelLooksGood
+}
+
+// mergeDescriptionSummary returns description with section (which starts
+// with prevSummaryMarker) appended, replacing the section a previous run
+// appended so reruns do not stack summaries.
+func mergeDescriptionSummary(description, section string) string {
+	section = strings.TrimSpace(section) + "\n" + prevSummaryEndMarker
+	if start := strings.Index(description, prevSummaryMarker); start >= 0 {
+		rest := description[start:]
+		if end := strings.Index(rest, prevSummaryEndMarker); end >= 0 {
+			return description[:start] + section + rest[end+len(prevSummaryEndMarker):]
+		}
+		return description[:start] + section
+	}
+	description = strings.TrimRight(description, " \t\n")
+	if description == "" {
+		return section
+	}
+	return description + "\n\n" + section
+}
+
+// applySummaryTarget records the review on the MR itself for the label and
+// description targets. It returns the progress line to print.
+func applySummaryTarget(
+	ctx context.Context,
+	vcsProvider vcs.VCSProvider,
+	projectID string,
+	mrIID int64,
+	target string,
+	mr *vcs.MergeRequest,
+	summaryBody string,
+	findings []core.FileComment,
+) (string, error) {
+	editor, ok := vcsProvider.(vcs.MRMetadataEditor)
+	if !ok {
+		return "", fmt.Errorf("%s cannot update MR labels or descriptions", vcsProvider.Info().Name)
+	}
+	switch target {
+	case summaryTargetLabel:
+		label := summaryVerdictLabel(findings)
+		other := prevLabelLooksGood
+		if label == prevLabelLooksGood {
+			other = prevLabelNeedsWork
+		}
+		if err := editor.SetMRLabels(ctx, projectID, mrIID, []string{label}, []string{other}); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Labeled MR %s.", label), nil
+	case summaryTargetDescription:
+		description := ""
+		if mr != nil {
+			description = mr.Description
+		}
+		if err := editor.UpdateMRDescription(ctx, projectID, mrIID, mergeDescriptionSummary(description, summaryBody)); err != nil {
+			return "", err
+		}
+		return "Appended summary to the MR description.", nil
+	default:
+		return "", fmt.Errorf("unsupported summary target %q", target)
+	}
+}
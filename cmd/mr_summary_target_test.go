@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/core"
+	"github.com/sanix-darker/prev/internal/vcs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type metadataRecordingVCSProvider struct {
+	recordingVCSProvider
+	added, removed []string
+	description    string
+}
+
+func (m *metadataRecordingVCSProvider) SetMRLabels(_ context.Context, _ string, _ int64, add, remove []string) error {
+	m.added, m.removed = add, remove
+	return nil
+}
+
+func (m *metadataRecordingVCSProvider) UpdateMRDescription(_ context.Context, _ string, _ int64, description string) error {
+	m.description = description
+	return nil
+}
+
+func TestNormalizeSummaryTarget(t *testing.T) {
+	for raw, want := range map[string]string{"": "note", "Note": "note", " label ": "label", "DESCRIPTION": "description"} {
+		got, err := normalizeSummaryTarget(raw)
+		require.NoError(t, err, raw)
+		assert.Equal(t, want, got, raw)
+	}
+	_, err := normalizeSummaryTarget("status")
+	assert.Error(t, err)
+}
+
+func TestSummaryVerdictLabel_UsesHighestSeverity(t *testing.T) {
+	assert.Equal(t, prevLabelLooksGood, summaryVerdictLabel(nil))
+	assert.Equal(t, prevLabelLooksGood, summaryVerdictLabel([]core.FileComment{{Severity: "LOW"}, {Severity: "MEDIUM"}}))
+	assert.Equal(t, prevLabelNeedsWork, summaryVerdictLabel([]core.FileComment{{Severity: "LOW"}, {Severity: "high"}}))
+	assert.Equal(t, prevLabelNeedsWork, summaryVerdictLabel([]core.FileComment{{Severity: "CRITICAL"}}))
+}
+
+func TestApplySummaryTarget_LabelSwapsVerdict(t *testing.T) {
+	fake := &metadataRecordingVCSProvider{}
+	msg, err := applySummaryTarget(context.Background(), fake, "g/p", 7, summaryTargetLabel, nil, "", []core.FileComment{{Severity: "CRITICAL"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{prevLabelNeedsWork}, fake.added)
+	assert.Equal(t, []string{prevLabelLooksGood}, fake.removed)
+	assert.Contains(t, msg, prevLabelNeedsWork)
+	assert.Empty(t, fake.summaries, "no summary note is posted")
+}
+
+func TestApplySummaryTarget_DescriptionReplacesPreviousSection(t *testing.T) {
+	fake := &metadataRecordingVCSProvider{}
+	mr := &vcs.MergeRequest{Description: "Adds caching."}
+
+	_, err := applySummaryTarget(context.Background(), fake, "g/p", 7, summaryTargetDescription, mr, buildSummaryNoteBody("first run", false), nil)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(fake.description, "Adds caching.\n\n"+prevSummaryMarker))
+	assert.Contains(t, fake.description, "first run")
+
+	mr.Description = fake.description + "\n\nEdited by the author afterwards."
+	_, err = applySummaryTarget(context.Background(), fake, "g/p", 7, summaryTargetDescription, mr, buildSummaryNoteBody("second run", false), nil)
+	require.NoError(t, err)
+	assert.NotContains(t, fake.description, "first run")
+	assert.Contains(t, fake.description, "second run")
+	assert.Equal(t, 1, strings.Count(fake.description, prevSummaryMarker))
+	assert.True(t, strings.HasSuffix(fake.description, "Edited by the author afterwards."))
+}
+
+func TestApplySummaryTarget_UnsupportedProvider(t *testing.T) {
+	_, err := applySummaryTarget(context.Background(), &recordingVCSProvider{}, "g/p", 7, summaryTargetLabel, nil, "", nil)
+	assert.ErrorContains(t, err, "recording")
+}
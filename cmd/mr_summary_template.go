@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/sanix-darker/prev/internal/core"
+	"github.com/sanix-darker/prev/internal/diffparse"
+)
+
+// summaryTemplateData is what review.summary_template is executed against:
+//
+//	## Overview
+//	{{.Summary}}
+//
+//	## Key Findings
+//	{{.Total}} findings ({{.Critical}} critical, {{.High}} high) in {{.FilesChanged}} files.
+//
+//	<details><summary>Full review ({{.Provider}}/{{.Model}})</summary>
+//
+//	{{.Review}}
+//	</details>
+type summaryTemplateData struct {
+	Review         string // raw review content
+	Summary        string // parsed summary section, or the raw review when none
+	Provider       string
+	Model          string
+	FilesChanged   int
+	Additions      int
+	Deletions      int
+	Counts         map[string]int // findings per severity: CRITICAL, HIGH, MEDIUM, LOW
+	Critical       int
+	High           int
+	Medium         int
+	Low            int
+	Total          int
+	Recommendation string // "approve" or "request changes", as reviewVerdict decides
+}
+
+// newSummaryTemplateData collects counts for the summary template.
+func newSummaryTemplateData(reviewContent, summary, providerName, model string, changes []diffparse.FileChange, findings []core.FileComment) summaryTemplateData {
+	d := summaryTemplateData{
+		Review:       strings.TrimSpace(reviewContent),
+		Summary:      strings.TrimSpace(summary),
+		Provider:     providerName,
+		Model:        model,
+		FilesChanged: len(changes),
+		Counts:       map[string]int{"CRITICAL": 0, "HIGH": 0, "MEDIUM": 0, "LOW": 0},
+	}
+	if d.Summary == "" {
+		d.Summary = d.Review
+	}
+	for _, c := range changes {
+		d.Additions += c.Stats.Additions
+		d.Deletions += c.Stats.Deletions
+	}
+	for _, f := range findings {
+		sev := strings.ToUpper(strings.TrimSpace(f.Severity))
+		if _, ok := d.Counts[sev]; !ok {
+			continue
+		}
+		d.Counts[sev]++
+		d.Total++
+	}
+	d.Critical, d.High, d.Medium, d.Low = d.Counts["CRITICAL"], d.Counts["HIGH"], d.Counts["MEDIUM"], d.Counts["LOW"]
+	d.Recommendation = "approve"
+	if reviewVerdict(findings) == reviewVerdictChangesRequested {
+		d.Recommendation = "request changes"
+	}
+	return d
+}
+
+// parseSummaryTemplate parses a review.summary_template value.
+func parseSummaryTemplate(text string) (*template.Template, error) {
+	t, err := template.New("summary").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid review.summary_template: %w", err)
+	}
+	return t, nil
+}
+
+// renderSummaryTemplate executes text against data.
+func renderSummaryTemplate(text string, data summaryTemplateData) (string, error) {
+	t, err := parseSummaryTemplate(text)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := t.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("render review.summary_template: %w", err)
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// buildTemplatedSummaryNoteBody is buildSummaryNoteBody for a rendered
+// template: the template supplies its own headings.
+func buildTemplatedSummaryNoteBody(rendered string, collapsible bool) string {
+	if collapsible {
+		return buildSummaryNoteBody(rendered, true)
+	}
+	return prevSummaryMarker + "\n" + rendered
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/core"
+	"github.com/sanix-darker/prev/internal/diffparse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderSummaryTemplate_SeverityCounts(t *testing.T) {
+	changes := []diffparse.FileChange{
+		{NewName: "a.go", Stats: diffparse.DiffStats{Additions: 10, Deletions: 2}},
+		{NewName: "b.go", Stats: diffparse.DiffStats{Additions: 5}},
+	}
+	findings := []core.FileComment{
+		{FilePath: "a.go", Line: 1, Severity: "CRITICAL", Message: "SQL injection."},
+		{FilePath: "a.go", Line: 4, Severity: "high", Message: "Unchecked error."},
+		{FilePath: "b.go", Line: 2, Severity: "HIGH", Message: "Race on map."},
+		{FilePath: "b.go", Line: 3, Severity: "LOW", Message: "Naming."},
+	}
+	data := newSummaryTemplateData("raw review text", "Adds a DB layer.", "openai", "gpt-4o", changes, findings)
+
+	tmpl := `## Overview
+{{.Summary}}
+
+## Risk
+{{if .Critical}}High{{else}}Low{{end}} ({{.FilesChanged}} files, +{{.Additions}}/-{{.Deletions}})
+
+## Key Findings
+critical={{.Critical}} high={{.Counts.HIGH}} medium={{.Medium}} low={{.Low}} total={{.Total}}
+
+## Recommendation
+{{.Recommendation}} ({{.Provider}}/{{.Model}})
+{{.Review}}`
+	got, err := renderSummaryTemplate(tmpl, data)
+	require.NoError(t, err)
+	assert.Contains(t, got, "## Overview\nAdds a DB layer.")
+	assert.Contains(t, got, "High (2 files, +15/-2)")
+	assert.Contains(t, got, "critical=1 high=2 medium=0 low=1 total=4")
+	assert.Contains(t, got, "request changes (openai/gpt-4o)")
+	assert.True(t, strings.HasSuffix(got, "raw review text"))
+
+	body := buildTemplatedSummaryNoteBody(got, false)
+	assert.True(t, strings.HasPrefix(body, prevSummaryMarker+"\n## Overview"))
+	assert.NotContains(t, body, "## AI Code Review")
+}
+
+func TestParseSummaryTemplate_Invalid(t *testing.T) {
+	_, err := parseSummaryTemplate("{{.Total")
+	assert.ErrorContains(t, err, "review.summary_template")
+}
@@ -1,16 +1,24 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/sanix-darker/prev/internal/config"
 	"github.com/sanix-darker/prev/internal/core"
 	"github.com/sanix-darker/prev/internal/diffparse"
+	"github.com/sanix-darker/prev/internal/handlers"
 	"github.com/sanix-darker/prev/internal/provider"
+	"github.com/sanix-darker/prev/internal/reviewcache"
 	"github.com/sanix-darker/prev/internal/vcs"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
@@ -41,17 +49,44 @@ func TestHasMentionCommand(t *testing.T) {
 }
 
 func TestInlineKey_IgnoresBody(t *testing.T) {
-	k1 := inlineKey("a/b.go", 42, "[HIGH] first")
-	k2 := inlineKey("a/b.go", 42, "[HIGH] second different message")
+	k1 := inlineKey("a/b.go", 42, false, "[HIGH] first")
+	k2 := inlineKey("a/b.go", 42, false, "[HIGH] second different message")
 	assert.Equal(t, k1, k2)
 }
 
 func TestInlineSeverityKey_IncludesSeverity(t *testing.T) {
-	k1 := inlineSeverityKey("a/b.go", 42, "HIGH")
-	k2 := inlineSeverityKey("a/b.go", 42, "MEDIUM")
+	k1 := inlineSeverityKey("a/b.go", 42, false, "HIGH")
+	k2 := inlineSeverityKey("a/b.go", 42, false, "MEDIUM")
 	assert.NotEqual(t, k1, k2)
 }
 
+func TestInlineKeys_SeparateDiffSides(t *testing.T) {
+	// A replaced line: the removed old line 12 already has a finding; the
+	// added new line 12 gets a different one.
+	discussions := []vcs.MRDiscussion{
+		{
+			ID: "d1",
+			Notes: []vcs.MRDiscussionNote{
+				{ID: 3, FilePath: "a/b.go", Line: 12, OnDeleted: true, Body: "[HIGH] Removed guard\n\n" + prevThreadMarker, Resolvable: true},
+			},
+		},
+	}
+	removed := inlineGroup{FilePath: "a/b.go", OldLine: 12, OnDeleted: true, Severity: "HIGH"}
+	added := inlineGroup{FilePath: "a/b.go", NewLine: 12, Severity: "HIGH"}
+
+	keys := existingInlineKeys(discussions)
+	_, removedPosted := keys[inlineKey(removed.FilePath, removed.anchorLine(), removed.OnDeleted, "")]
+	_, addedPosted := keys[inlineKey(added.FilePath, added.anchorLine(), added.OnDeleted, "")]
+	assert.True(t, removedPosted)
+	assert.False(t, addedPosted, "the added line at the same number is a different anchor")
+
+	sevKeys := existingInlineSeverityKeys(discussions)
+	_, removedSev := sevKeys[inlineSeverityKey(removed.FilePath, removed.anchorLine(), removed.OnDeleted, "HIGH")]
+	_, addedSev := sevKeys[inlineSeverityKey(added.FilePath, added.anchorLine(), added.OnDeleted, "HIGH")]
+	assert.True(t, removedSev)
+	assert.False(t, addedSev)
+}
+
 func TestIsReplyRequest_OnlyExplicitReplyCommand(t *testing.T) {
 	assert.True(t, isReplyRequest("prev reply", "prev"))
 	assert.False(t, isReplyRequest("prev can you check this?", "prev"))
@@ -66,19 +101,19 @@ func TestIsIgnoreRequest_OnlyExplicitIgnoreCommand(t *testing.T) {
 
 func TestConciseInlineBody(t *testing.T) {
 	body := "[HIGH] This is a long first sentence. Additional details should be trimmed.\n\nSecond paragraph."
-	assert.Equal(t, "[HIGH] This is a long first sentence.", conciseInlineBody(body))
+	assert.Equal(t, "[HIGH] This is a long first sentence.", conciseInlineBody(body, defaultInlineMaxBodyChars))
 }
 
 func TestConciseInlineBody_StripsEmojiAndCapsLength(t *testing.T) {
 	body := "[HIGH] This is a very long finding with noise 🙂 that should stay short, precise, and free of emoji while keeping the main point visible for reviewers in the thread."
-	got := conciseInlineBody(body)
+	got := conciseInlineBody(body, defaultInlineMaxBodyChars)
 	assert.NotContains(t, got, "🙂")
 	assert.LessOrEqual(t, len(got), 160)
 }
 
 func TestConciseInlineBody_PreservesKeyPointsList(t *testing.T) {
 	body := "[HIGH] Key points:\n- First issue with details.\n- Second issue with details."
-	got := conciseInlineBody(body)
+	got := conciseInlineBody(body, defaultInlineMaxBodyChars)
 	assert.Contains(t, got, "Key points:")
 	assert.Contains(t, got, "- First issue with details.")
 	assert.Contains(t, got, "- Second issue with details.")
@@ -452,6 +487,43 @@ func TestAggregateCommentsByLine_Fallback(t *testing.T) {
 	assert.Equal(t, 10, got[0].NewLine)
 }
 
+func TestAggregateCommentsByLine_AnchorsToDeletedLine(t *testing.T) {
+	changes := []diffparse.FileChange{
+		{
+			NewName: "auth/session.go",
+			OldName: "auth/session.go",
+			Hunks: []diffparse.Hunk{
+				{
+					OldStart: 40, OldLines: 3, NewStart: 10, NewLines: 1,
+					Lines: []diffparse.DiffLine{
+						{Type: diffparse.LineContext, OldLineNo: 40, NewLineNo: 10, Content: "func load(s *Session) {"},
+						{Type: diffparse.LineDeleted, OldLineNo: 41, Content: "if s == nil { return }"},
+						{Type: diffparse.LineDeleted, OldLineNo: 42, Content: "s.mu.Lock()"},
+					},
+				},
+			},
+		},
+	}
+	pos := collectValidPositions(changes)
+	comments := []core.FileComment{
+		{FilePath: "auth/session.go", Line: 41, Severity: "HIGH", Message: "Removing the nil guard lets load panic.", Suggestion: "if s == nil { return }"},
+		{FilePath: "auth/session.go", Line: 10, Severity: "LOW", Message: "Context line finding."},
+	}
+
+	got, unplaced := aggregateCommentsByLine(comments, pos)
+	assert.Empty(t, unplaced)
+	require.Len(t, got, 2)
+
+	assert.True(t, got[0].OnDeleted)
+	assert.Equal(t, 0, got[0].NewLine)
+	assert.Equal(t, 41, got[0].OldLine)
+	assert.Equal(t, 41, got[0].anchorLine())
+	assert.Empty(t, got[0].Suggestion)
+
+	assert.False(t, got[1].OnDeleted)
+	assert.Equal(t, 10, got[1].NewLine)
+}
+
 func TestAggregateCommentsByHunk_FallbackWhenLineMissing(t *testing.T) {
 	changes := []diffparse.FileChange{
 		{
@@ -480,24 +552,96 @@ func TestAggregateCommentsByHunk_FallbackWhenLineMissing(t *testing.T) {
 	assert.Contains(t, got[0].Message, "General file-level risk")
 }
 
+func TestApplySeverityConsensus_MajorityBeatsSingleEscalation(t *testing.T) {
+	passes := [][]core.FileComment{
+		{{FilePath: "a.go", Line: 10, Severity: "MEDIUM", Message: "missing nil check"}},
+		{{FilePath: "a.go", Line: 10, Severity: "CRITICAL", Message: "nil deref crashes handler"}},
+		{{FilePath: "a.go", Line: 10, Severity: "MEDIUM", Message: "missing nil check"}, {FilePath: "b.go", Line: 3, Severity: "LOW", Message: "naming"}},
+	}
+	final := []core.FileComment{
+		{FilePath: "a.go", Line: 10, Severity: "CRITICAL", Message: "nil deref crashes handler"},
+		{FilePath: "c.go", Line: 1, Severity: "HIGH", Message: "only in final"},
+	}
+
+	got := applySeverityConsensus(final, passes, "majority")
+	require.Len(t, got, 2)
+	assert.Equal(t, "MEDIUM", got[0].Severity)
+	assert.Equal(t, "HIGH", got[1].Severity)
+	assert.Equal(t, "CRITICAL", final[0].Severity, "input must not be mutated")
+
+	assert.Equal(t, "CRITICAL", applySeverityConsensus(final, passes, "max")[0].Severity)
+}
+
 func TestBuildInlineCommentBody_SeparatesSuggestionBlock(t *testing.T) {
 	body := buildInlineCommentBody(
+		"",
+		0,
 		"HIGH",
 		"Key points:\n- Missing nil check in handler.\n- Error context is weak.",
 		"if h == nil {\n\treturn err\n}",
 		func(s string) string { return "```suggestion\n" + s + "\n```" },
+		inlineBodyLimits{},
+		nil,
 	)
 	assert.Contains(t, body, "[HIGH] Missing nil check in handler.")
 	assert.Contains(t, body, "Suggested patch:")
 	assert.Contains(t, body, "```suggestion")
 }
 
+func TestTruncatePromptText_CapsDescriptionInPrompt(t *testing.T) {
+	desc := strings.Repeat("checklist item ", 50)
+	got := truncatePromptText(desc, 40, "description")
+	assert.True(t, strings.HasPrefix(got, strings.TrimSpace(desc[:40])+"…"))
+	assert.Contains(t, got, "[description truncated from 750 to 40 characters]")
+
+	prompt := core.BuildMRReviewPromptWithOptions("title", got, "feature", "main", "diff", "normal", 5, nil, "")
+	assert.Contains(t, prompt, got)
+	assert.NotContains(t, prompt, desc)
+
+	assert.Equal(t, desc, truncatePromptText(desc, 0, "description"))
+	assert.Equal(t, "short", truncatePromptText("short", 40, "title"))
+}
+
+func TestBuildSummaryNoteBody_CollapsibleKeepsMarkerDetectable(t *testing.T) {
+	body := buildSummaryNoteBody("Looks good overall.\n\n- minor nit", true)
+	assert.Contains(t, body, "<details>\n<summary>AI Review</summary>")
+	assert.Contains(t, body, "Looks good overall.")
+	assert.True(t, strings.HasSuffix(body, "</details>"))
+	assert.Less(t, strings.Index(body, prevSummaryMarker), strings.Index(body, "<details>"))
+	assert.True(t, hasTopLevelMarker([]vcs.MRNote{{Body: body}}, prevSummaryMarker))
+
+	plain := buildSummaryNoteBody("Looks good overall.", false)
+	assert.NotContains(t, plain, "<details>")
+	assert.Contains(t, plain, "## AI Code Review")
+}
+
+func TestRunPostingSteps_FollowsConfiguredOrder(t *testing.T) {
+	var seq []string
+	postSummary := func() { seq = append(seq, "summary") }
+	postInline := func() { seq = append(seq, "inline") }
+
+	runPostingSteps(postOrderInlineFirst, postSummary, postInline)
+	assert.Equal(t, []string{"inline", "summary"}, seq)
+
+	seq = nil
+	runPostingSteps(postOrderSummaryFirst, postSummary, postInline)
+	assert.Equal(t, []string{"summary", "inline"}, seq)
+
+	seq = nil
+	runPostingSteps("", postSummary, postInline)
+	assert.Equal(t, []string{"inline", "summary"}, seq)
+}
+
 func TestBuildInlineCommentBody_StripsCodeFenceFromMessage(t *testing.T) {
 	body := buildInlineCommentBody(
+		"",
+		0,
 		"MEDIUM",
 		"Key points:\n- First issue.\n```go\nfmt.Println(\"noise\")\n```\n- Second issue.",
 		"",
 		nil,
+		inlineBodyLimits{},
+		nil,
 	)
 	assert.Contains(t, body, "[MEDIUM] First issue.")
 	assert.NotContains(t, body, "fmt.Println")
@@ -506,10 +650,14 @@ func TestBuildInlineCommentBody_StripsCodeFenceFromMessage(t *testing.T) {
 
 func TestBuildInlineCommentBody_SkipsNonActionableLeadPoints(t *testing.T) {
 	body := buildInlineCommentBody(
+		"",
+		0,
 		"HIGH",
 		"Hunk new lines 60-66\nKey points:\n- Remediation Plan\n- Missing null-check before json_encode.",
 		"",
 		nil,
+		inlineBodyLimits{},
+		nil,
 	)
 	assert.Contains(t, body, "[HIGH] Missing null-check before json_encode.")
 	assert.NotContains(t, body, "Hunk new lines")
@@ -518,10 +666,14 @@ func TestBuildInlineCommentBody_SkipsNonActionableLeadPoints(t *testing.T) {
 
 func TestBuildInlineCommentBody_PreservesSuggestionPadding(t *testing.T) {
 	body := buildInlineCommentBody(
+		"",
+		0,
 		"HIGH",
 		"Key points:\n- Keep original indentation.",
 		"\n\n    $value = trim($value);\n\treturn $value;\n",
 		func(s string) string { return "```suggestion\n" + s + "\n```" },
+		inlineBodyLimits{},
+		nil,
 	)
 	assert.Contains(t, body, "```suggestion\n    $value = trim($value);\n\treturn $value;\n```")
 }
@@ -580,6 +732,29 @@ func TestRebaseSuggestionIndentation_RebasesToAnchor(t *testing.T) {
 	)
 }
 
+func TestRebaseSuggestionForFile_PythonRebasesCleanIndent(t *testing.T) {
+	anchor := "        return total"
+	suggestion := "    if total is None:\n        return 0\n    return total"
+
+	got, verbatim := rebaseSuggestionForFile(suggestion, anchor, "pkg/stats.py")
+	assert.False(t, verbatim)
+	assert.Equal(t, "        if total is None:\n            return 0\n        return total", got)
+}
+
+func TestRebaseSuggestionForFile_PythonKeepsMismatchedIndentVerbatim(t *testing.T) {
+	anchor := "\treturn total"
+	suggestion := "    if total is None:\n        return 0\n    return total"
+
+	got, verbatim := rebaseSuggestionForFile(suggestion, anchor, "pkg/stats.py")
+	assert.True(t, verbatim)
+	assert.Equal(t, suggestion, got)
+
+	// Languages where indentation is cosmetic are still rebased.
+	got, verbatim = rebaseSuggestionForFile(suggestion, anchor, "pkg/stats.go")
+	assert.False(t, verbatim)
+	assert.Equal(t, "\tif total is None:\n\t    return 0\n\treturn total", got)
+}
+
 func TestRebaseSuggestionIndentation_PreservesRelativePadding(t *testing.T) {
 	anchor := "\t\tvalue := data[idx]"
 	suggestion := "    if value == nil {\n        return errors.New(\"bad\")\n    }"
@@ -629,22 +804,49 @@ func TestBuildAgentFixPrompt_AutoModeSkipsWhenSuggestionExists(t *testing.T) {
 }
 
 func TestDetectVCSContextStatus_FromEnv(t *testing.T) {
+	getenv := func(k string) string {
+		if k == "GITLAB_MCP_URL" {
+			return "http://mcp.local"
+		}
+		return ""
+	}
+	notFound := func(string) (string, error) { return "", fmt.Errorf("not found") }
+
 	got := detectVCSContextStatus(
 		"gitlab",
-		func(string) (string, error) { return "", fmt.Errorf("not found") },
-		func(k string) string {
-			if k == "GITLAB_MCP_URL" {
-				return "http://mcp.local"
-			}
-			return ""
-		},
+		handlers.MRContextUsage{Source: "gitlab-mcp", Metadata: true, Diffs: true},
+		notFound,
+		getenv,
+	)
+	assert.Contains(t, got, "MR metadata and diffs fetched via GITLAB_MCP_URL (http://mcp.local)")
+
+	got = detectVCSContextStatus(
+		"gitlab",
+		handlers.MRContextUsage{Source: "gitlab-mcp", Fallback: "connection refused"},
+		notFound,
+		getenv,
 	)
-	assert.Contains(t, got, "configured via GITLAB_MCP_URL")
+	assert.Contains(t, got, "failed; using standard GitLab API")
+}
+
+func TestResolveMRContextSource(t *testing.T) {
+	getenv := func(k string) string {
+		if k == "GITLAB_MCP_URL" {
+			return "http://mcp.local"
+		}
+		return ""
+	}
+	src := resolveMRContextSource("gitlab", getenv)
+	require.NotNil(t, src)
+	assert.Equal(t, "gitlab-mcp", src.Name())
+	assert.Nil(t, resolveMRContextSource("github", getenv))
+	assert.Nil(t, resolveMRContextSource("gitlab", func(string) string { return "" }))
 }
 
 func TestDetectVCSContextStatus_FromBinary(t *testing.T) {
 	got := detectVCSContextStatus(
 		"gitlab",
+		handlers.MRContextUsage{},
 		func(bin string) (string, error) {
 			if bin == "gitlab-mcp" {
 				return "/usr/local/bin/gitlab-mcp", nil
@@ -653,12 +855,13 @@ func TestDetectVCSContextStatus_FromBinary(t *testing.T) {
 		},
 		func(string) string { return "" },
 	)
-	assert.Contains(t, got, "detected local server binary")
+	assert.Contains(t, got, "found but unused")
 }
 
 func TestDetectVCSContextStatus_Fallback(t *testing.T) {
 	got := detectVCSContextStatus(
 		"gitlab",
+		handlers.MRContextUsage{},
 		func(string) (string, error) { return "", fmt.Errorf("not found") },
 		func(string) string { return "" },
 	)
@@ -668,6 +871,7 @@ func TestDetectVCSContextStatus_Fallback(t *testing.T) {
 func TestDetectVCSContextStatus_GitHub(t *testing.T) {
 	got := detectVCSContextStatus(
 		"github",
+		handlers.MRContextUsage{},
 		func(string) (string, error) { return "", fmt.Errorf("not found") },
 		func(string) string { return "" },
 	)
@@ -693,8 +897,8 @@ func TestExistingInlineSeverityKeys(t *testing.T) {
 		},
 	}
 	keys := existingInlineSeverityKeys(discussions)
-	_, okHigh := keys[inlineSeverityKey("a/b.go", 10, "HIGH")]
-	_, okNoSev := keys[inlineSeverityKey("a/b.go", 11, "MEDIUM")]
+	_, okHigh := keys[inlineSeverityKey("a/b.go", 10, false, "HIGH")]
+	_, okNoSev := keys[inlineSeverityKey("a/b.go", 11, false, "MEDIUM")]
 	assert.True(t, okHigh)
 	assert.False(t, okNoSev)
 }
@@ -716,12 +920,67 @@ func TestExistingInlineKeys_IgnoresResolvedDiscussions(t *testing.T) {
 	}
 
 	keys := existingInlineKeys(discussions)
-	_, hasActive := keys[inlineKey("a/b.go", 10, "")]
-	_, hasResolved := keys[inlineKey("a/b.go", 20, "")]
+	_, hasActive := keys[inlineKey("a/b.go", 10, false, "")]
+	_, hasResolved := keys[inlineKey("a/b.go", 20, false, "")]
 	assert.True(t, hasActive)
 	assert.False(t, hasResolved)
 }
 
+func TestExistingInlineKeys_TracksNoteIDs(t *testing.T) {
+	discussions := []vcs.MRDiscussion{
+		{
+			ID: "d1",
+			Notes: []vcs.MRDiscussionNote{
+				{ID: 7, FilePath: "a/b.go", Line: 10, Body: "[HIGH] Old wording\n\n" + prevThreadMarker},
+				{ID: 8, FilePath: "a/b.go", Line: 10, Body: "Thanks, will fix."},
+			},
+		},
+		{
+			ID: "d2",
+			Notes: []vcs.MRDiscussionNote{
+				{ID: 9, FilePath: "a/b.go", Line: 20, Body: "[HIGH] Other finding\n\n" + prevThreadMarker},
+				{ID: 10, FilePath: "a/b.go", Line: 20, Body: "Kept as is.\n\n" + prevReplyMarker},
+			},
+		},
+	}
+
+	keys := existingInlineKeys(discussions)
+	assert.Equal(t, int64(7), keys[inlineKey("a/b.go", 10, false, "")].ID)
+	assert.True(t, keys[inlineKey("a/b.go", 10, false, "")].HasHumanReply)
+	assert.False(t, keys[inlineKey("a/b.go", 20, false, "")].HasHumanReply, "prev's own reply")
+}
+
+func TestInlineNoteNeedsUpdate(t *testing.T) {
+	oldMsg := "Missing nil guard before dereferencing the config pointer."
+	newMsg := "Nil guard missing before the config pointer is dereferenced."
+	existing := existingInlineNote{MRDiscussionNote: vcs.MRDiscussionNote{
+		ID: 7, FilePath: "a/b.go", Line: 10,
+		Body: "[HIGH] " + oldMsg + "\n\n" + prevThreadMarker + "\n" + fingerprintMarker(findingFingerprint("a/b.go", oldMsg)),
+	}}
+	reworded := "[HIGH] " + newMsg + "\n\n" + prevThreadMarker + "\n" + fingerprintMarker(findingFingerprint("a/b.go", newMsg))
+
+	assert.True(t, inlineNoteNeedsUpdate(existing, "high", newMsg, reworded))
+	assert.False(t, inlineNoteNeedsUpdate(existing, "HIGH", oldMsg, existing.Body), "unchanged body")
+	assert.False(t, inlineNoteNeedsUpdate(existing, "LOW", newMsg, reworded), "severity changed")
+
+	unrelatedMsg := "SQL query is built by string concatenation."
+	unrelated := "[HIGH] " + unrelatedMsg + "\n\n" + prevThreadMarker + "\n" + fingerprintMarker(findingFingerprint("a/b.go", unrelatedMsg))
+	assert.False(t, inlineNoteNeedsUpdate(existing, "HIGH", unrelatedMsg, unrelated), "different finding on the same line")
+
+	sameFP := existing
+	sameFP.Body = "[HIGH] Terse\n\n" + prevThreadMarker + "\n" + fingerprintMarker(findingFingerprint("a/b.go", unrelatedMsg))
+	assert.True(t, inlineNoteNeedsUpdate(sameFP, "HIGH", unrelatedMsg, unrelated), "same fingerprint")
+
+	replied := existing
+	replied.HasHumanReply = true
+	assert.False(t, inlineNoteNeedsUpdate(replied, "HIGH", newMsg, reworded), "thread has a human reply")
+
+	human := existingInlineNote{MRDiscussionNote: vcs.MRDiscussionNote{ID: 9, Body: "[HIGH] Human note"}}
+	assert.False(t, inlineNoteNeedsUpdate(human, "HIGH", newMsg, reworded), "not prev-authored")
+	existing.ID = 0
+	assert.False(t, inlineNoteNeedsUpdate(existing, "HIGH", newMsg, reworded), "no note ID")
+}
+
 func TestDiscussionResolved_UsesLatestResolvableState(t *testing.T) {
 	d1 := vcs.MRDiscussion{
 		Notes: []vcs.MRDiscussionNote{
@@ -764,7 +1023,7 @@ func TestCollectReusableThreads_FiltersPausedAndResolved(t *testing.T) {
 	}
 	paused := map[string]bool{"d2": true}
 	ignored := map[string]bool{}
-	got := collectReusableThreads(discussions, "bot", paused, ignored)
+	got := collectReusableThreads(discussions, nil, "bot", paused, ignored)
 	assert.Len(t, got, 1)
 	assert.Equal(t, "d1", got[0].DiscussionID)
 	assert.Equal(t, "HIGH", got[0].Severity)
@@ -779,10 +1038,65 @@ func TestCollectReusableThreads_FiltersIgnored(t *testing.T) {
 			},
 		},
 	}
-	got := collectReusableThreads(discussions, "bot", map[string]bool{}, map[string]bool{"d1": true})
+	got := collectReusableThreads(discussions, nil, "bot", map[string]bool{}, map[string]bool{"d1": true})
 	assert.Empty(t, got)
 }
 
+func renamedFileChanges() []diffparse.FileChange {
+	return []diffparse.FileChange{{
+		OldName:   "pkg/old.go",
+		NewName:   "pkg/new.go",
+		IsRenamed: true,
+		Hunks: []diffparse.Hunk{{
+			OldStart: 8, OldLines: 3, NewStart: 8, NewLines: 3,
+			Lines: []diffparse.DiffLine{
+				{Type: diffparse.LineContext, Content: "a", OldLineNo: 8, NewLineNo: 8},
+				{Type: diffparse.LineAdded, Content: "b", NewLineNo: 9},
+				{Type: diffparse.LineContext, Content: "c", OldLineNo: 9, NewLineNo: 10},
+			},
+		}},
+	}}
+}
+
+func TestCurrentDiffPath_FollowsRename(t *testing.T) {
+	valid := collectValidPositions(renamedFileChanges())
+	assert.Equal(t, "pkg/new.go", currentDiffPath(valid, "pkg/old.go"))
+	assert.Equal(t, "pkg/new.go", currentDiffPath(valid, "pkg/new.go"))
+	assert.Equal(t, "other.go", currentDiffPath(valid, "other.go"))
+}
+
+func TestCollectCarryOverFindings_MatchesRenamedFile(t *testing.T) {
+	valid := collectValidPositions(renamedFileChanges())
+	discussions := []vcs.MRDiscussion{{
+		ID: "d1",
+		Notes: []vcs.MRDiscussionNote{
+			{Author: "bot", Body: "<!-- prev:thread -->\n[HIGH] Nil guard missing", FilePath: "pkg/old.go", Line: 9, Resolvable: true},
+		},
+	}}
+	got := collectCarryOverFindings(discussions, valid, "bot", nil, nil)
+	require.Len(t, got, 1)
+	assert.Equal(t, "pkg/new.go", got[0].FilePath)
+	assert.Equal(t, 9, got[0].Line)
+	assert.Equal(t, "HIGH", got[0].Severity)
+}
+
+func TestCollectReusableThreads_MatchesRenamedFile(t *testing.T) {
+	valid := collectValidPositions(renamedFileChanges())
+	discussions := []vcs.MRDiscussion{{
+		ID: "d1",
+		Notes: []vcs.MRDiscussionNote{
+			{Author: "bot", Body: "<!-- prev:thread -->\n[HIGH] Nil guard missing in handler", FilePath: "pkg/old.go", Line: 9, Resolvable: true},
+		},
+	}}
+	got := collectReusableThreads(discussions, valid, "bot", nil, nil)
+	require.Len(t, got, 1)
+	assert.Equal(t, "pkg/new.go", got[0].FilePath)
+
+	match, ok := matchReusableThread(got, inlineGroup{FilePath: "pkg/new.go", NewLine: 9, Severity: "HIGH", Message: "Nil guard missing in handler"})
+	require.True(t, ok)
+	assert.Equal(t, "d1", match.DiscussionID)
+}
+
 func TestMatchReusableThread_SameFileSeverityAndSimilarMessage(t *testing.T) {
 	candidates := []reusableThread{
 		{
@@ -857,6 +1171,34 @@ func TestResolveMRStringSetting_FromConfig(t *testing.T) {
 	assert.Equal(t, "lenient", got)
 }
 
+func TestResolveMRStringSliceSetting_FallsBack(t *testing.T) {
+	def := []string{"issue"}
+	assert.Equal(t, def, resolveMRStringSliceSetting(config.Config{}, []string{"review.conventions.labels"}, def), "no config store")
+
+	v := config.NewStore()
+	conf := config.Config{Viper: v}
+	assert.Equal(t, def, resolveMRStringSliceSetting(conf, []string{"review.conventions.labels"}, def), "key missing")
+
+	v.Set("review.conventions.labels", "issue,remark")
+	assert.Equal(t, def, resolveMRStringSliceSetting(conf, []string{"review.conventions.labels"}, def), "not a list")
+
+	v.Set("review.conventions.labels", []interface{}{"remark"})
+	assert.Equal(t, []string{"remark"}, resolveMRStringSliceSetting(conf, []string{"review.conventions.labels"}, def))
+}
+
+func TestResolveIgnoreGlobs_FallsBackWhenNotAList(t *testing.T) {
+	cmd := &cobra.Command{Use: "x"}
+	cmd.Flags().Bool("no-skip-generated", false, "")
+	v := config.NewStore()
+	conf := config.Config{Viper: v}
+
+	assert.Equal(t, core.DefaultGeneratedGlobs, resolveIgnoreGlobs(cmd, config.Config{}))
+	v.Set("review.ignore_globs", "vendor/**")
+	assert.Equal(t, core.DefaultGeneratedGlobs, resolveIgnoreGlobs(cmd, conf))
+	v.Set("review.ignore_globs", []interface{}{})
+	assert.Equal(t, []string{}, resolveIgnoreGlobs(cmd, conf), "an explicit empty list skips nothing")
+}
+
 func TestFilterInlineCandidates_FallsBackToChangedFiles(t *testing.T) {
 	parsed := []core.FileComment{
 		{FilePath: "./public/index.php", Line: 10, Kind: "ISSUE", Severity: "MEDIUM", Message: "Changed-file finding"},
@@ -873,7 +1215,24 @@ func TestFilterInlineCandidates_FallsBackToChangedFiles(t *testing.T) {
 	got, fallback := filterInlineCandidates(parsed, "strict", 3, []string{"issue", "suggestion", "remark"}, valid, "diff_context")
 	assert.True(t, fallback)
 	if assert.Len(t, got, 1) {
-		assert.Equal(t, "./public/index.php", got[0].FilePath)
+		assert.Equal(t, "public/index.php", got[0].FilePath, "path is canonicalized for placement")
+	}
+}
+
+func TestLimitToChangedFiles_CanonicalizesPathVariants(t *testing.T) {
+	valid := map[string]inlinePositions{"public/index.php": {}}
+	parsed := []core.FileComment{
+		{FilePath: "/public/index.php", Line: 1},
+		{FilePath: `public\index.php`, Line: 2},
+		{FilePath: "public//index.php", Line: 3},
+		{FilePath: "other.php", Line: 4},
+	}
+
+	got := limitToChangedFiles(parsed, valid)
+	if assert.Len(t, got, 3) {
+		for _, c := range got {
+			assert.Equal(t, "public/index.php", c.FilePath)
+		}
 	}
 }
 
@@ -988,11 +1347,22 @@ func TestParseReviewContent_StructuredFallbackToMarkdown(t *testing.T) {
 
 func TestExtractKeyPoints_SkipsBulletKeyPointsHeading(t *testing.T) {
 	msg := "Hunk new lines 1-10\nKey points:\n- Key points:\n- Real actionable issue."
-	points := extractKeyPoints(msg)
+	points := extractKeyPoints(msg, defaultInlineMaxKeyPoints, defaultInlineMaxPointChars)
 	assert.NotContains(t, points, "Key points:")
 	assert.Contains(t, points, "Real actionable issue.")
 }
 
+func TestExtractKeyPoints_RespectsConfiguredLimits(t *testing.T) {
+	msg := "- First point.\n- Second point.\n- Third point.\n- Fourth point.\n- Fifth point is rather long."
+	assert.Len(t, extractKeyPoints(msg, defaultInlineMaxKeyPoints, defaultInlineMaxPointChars), 4)
+
+	points := extractKeyPoints(msg, 2, defaultInlineMaxPointChars)
+	assert.Equal(t, []string{"First point.", "Second point."}, points)
+
+	short := extractKeyPoints("- Fifth point is rather long.", 2, 10)
+	assert.Equal(t, []string{"Fifth poi…"}, short)
+}
+
 func TestDetectDeterministicFindings_JsonDencode(t *testing.T) {
 	changes := []diffparse.FileChange{
 		{
@@ -1016,6 +1386,112 @@ func TestDetectDeterministicFindings_JsonDencode(t *testing.T) {
 	}
 }
 
+func TestDetectDeterministicFindings_ConflictMarkers(t *testing.T) {
+	changes := []diffparse.FileChange{
+		{
+			NewName: "service.go",
+			Hunks: []diffparse.Hunk{
+				{
+					NewStart: 20,
+					Lines: []diffparse.DiffLine{
+						{Type: diffparse.LineAdded, NewLineNo: 20, Content: "<<<<<<< HEAD"},
+						{Type: diffparse.LineAdded, NewLineNo: 21, Content: "\treturn a"},
+						{Type: diffparse.LineAdded, NewLineNo: 22, Content: "======="},
+						{Type: diffparse.LineAdded, NewLineNo: 23, Content: "\treturn b"},
+						{Type: diffparse.LineAdded, NewLineNo: 24, Content: ">>>>>>> feature"},
+					},
+				},
+			},
+		},
+	}
+	got := detectDeterministicFindings(changes)
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, "service.go", got[0].FilePath)
+		assert.Equal(t, 20, got[0].Line)
+		assert.Equal(t, "CRITICAL", got[0].Severity)
+		assert.Contains(t, got[0].Message, "merge-conflict marker")
+	}
+}
+
+func TestDetectDeterministicFindings_ConflictMarkerLookalikes(t *testing.T) {
+	changes := []diffparse.FileChange{
+		{
+			NewName: "banner.go",
+			Hunks: []diffparse.Hunk{
+				{
+					NewStart: 1,
+					Lines: []diffparse.DiffLine{
+						{Type: diffparse.LineAdded, NewLineNo: 1, Content: `sep := "======="`},
+						{Type: diffparse.LineAdded, NewLineNo: 2, Content: "// ======= section ======="},
+						{Type: diffparse.LineAdded, NewLineNo: 3, Content: "========"},
+						{Type: diffparse.LineAdded, NewLineNo: 4, Content: "<<<<<<<<"},
+						{Type: diffparse.LineContext, NewLineNo: 5, Content: "<<<<<<< HEAD"},
+					},
+				},
+			},
+		},
+	}
+	assert.Empty(t, detectDeterministicFindings(changes))
+
+	stray := []diffparse.FileChange{
+		{
+			NewName: "left.go",
+			Hunks: []diffparse.Hunk{
+				{
+					NewStart: 7,
+					Lines: []diffparse.DiffLine{
+						{Type: diffparse.LineAdded, NewLineNo: 7, Content: "======="},
+						{Type: diffparse.LineAdded, NewLineNo: 9, Content: ">>>>>>> main"},
+					},
+				},
+			},
+		},
+	}
+	assert.Empty(t, detectDeterministicFindings(stray), "markers without an opening <<<<<<< are not a conflict")
+}
+
+func TestDetectDeterministicFindings_SetextHeadingIsNotConflict(t *testing.T) {
+	changes := []diffparse.FileChange{
+		{
+			NewName: "README.md",
+			Hunks: []diffparse.Hunk{
+				{
+					NewStart: 1,
+					Lines: []diffparse.DiffLine{
+						{Type: diffparse.LineAdded, NewLineNo: 1, Content: "Install"},
+						{Type: diffparse.LineAdded, NewLineNo: 2, Content: "======="},
+						{Type: diffparse.LineAdded, NewLineNo: 3, Content: ""},
+						{Type: diffparse.LineAdded, NewLineNo: 4, Content: "Run `make`."},
+					},
+				},
+			},
+		},
+	}
+	assert.Empty(t, detectDeterministicFindings(changes))
+}
+
+func TestFilterGeneratedChanges(t *testing.T) {
+	changes := []diffparse.FileChange{
+		{NewName: "main.go", Hunks: []diffparse.Hunk{{NewStart: 1, Lines: []diffparse.DiffLine{
+			{Type: diffparse.LineAdded, NewLineNo: 1, Content: "package main"},
+		}}}},
+		{NewName: "go.sum"},
+		{NewName: "mocks/store.go", IsNew: true, Hunks: []diffparse.Hunk{{NewStart: 1, Lines: []diffparse.DiffLine{
+			{Type: diffparse.LineAdded, NewLineNo: 1, Content: "// Code generated by MockGen. DO NOT EDIT."},
+			{Type: diffparse.LineAdded, NewLineNo: 2, Content: "package mocks"},
+		}}}},
+	}
+
+	kept, skipped := filterGeneratedChanges(changes, core.DefaultGeneratedGlobs)
+	require.Len(t, kept, 1)
+	assert.Equal(t, "main.go", kept[0].NewName)
+	assert.Equal(t, []string{"go.sum", "mocks/store.go"}, skipped)
+
+	kept, skipped = filterGeneratedChanges(changes, []string{})
+	assert.Len(t, kept, 2, "an empty glob list still honors generated headers")
+	assert.Equal(t, []string{"mocks/store.go"}, skipped)
+}
+
 func TestFilterOutMetaContextFindings(t *testing.T) {
 	in := []core.FileComment{
 		{
@@ -1169,6 +1645,7 @@ func TestResolveMRBoolSetting_PrefersFlagThenConfig(t *testing.T) {
 }
 
 type scriptedAIProvider struct {
+	mu        sync.Mutex
 	requests  []provider.CompletionRequest
 	responses []provider.CompletionResponse
 }
@@ -1178,6 +1655,8 @@ func (s *scriptedAIProvider) Info() provider.ProviderInfo {
 }
 
 func (s *scriptedAIProvider) Complete(_ context.Context, req provider.CompletionRequest) (*provider.CompletionResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.requests = append(s.requests, req)
 	idx := len(s.requests) - 1
 	resp := provider.CompletionResponse{Content: "ok", Choices: []provider.Choice{{Content: "ok"}}}
@@ -1223,6 +1702,120 @@ func TestRunReviewPasses_PreservesConversationHistory(t *testing.T) {
 	assert.Contains(t, ai.requests[1].Messages[3].Content, "review pass 2/2")
 }
 
+func TestCollectReviewPassOutputs_EnsembleRunsIndependentPasses(t *testing.T) {
+	ai := &scriptedAIProvider{}
+
+	outs, err := collectReviewPassOutputs(context.Background(), ai, "BASE_PROMPT", 3, reviewPassOptions{Mode: passModeEnsemble})
+	require.NoError(t, err)
+	assert.Len(t, outs, 3)
+	require.Len(t, ai.requests, 3)
+	for _, req := range ai.requests {
+		require.Len(t, req.Messages, 2)
+		assert.Equal(t, "BASE_PROMPT", req.Messages[1].Content)
+	}
+}
+
+func TestCollectReviewPassOutputs_StreamEchoesEachPass(t *testing.T) {
+	ai := &scriptedAIProvider{responses: []provider.CompletionResponse{
+		{Content: "first review"},
+		{Content: "second review"},
+	}}
+	var buf bytes.Buffer
+
+	outs, err := collectReviewPassOutputs(context.Background(), ai, "BASE_PROMPT", 2, reviewPassOptions{Stream: &buf})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first review", "second review"}, outs)
+	assert.Equal(t, "first review\nsecond review\n", buf.String())
+	require.Len(t, ai.requests, 2)
+	assert.Equal(t, "first review", ai.requests[1].Messages[2].Content)
+}
+
+func TestCollectReviewPassOutputs_EarlyStopOnClean(t *testing.T) {
+	finding := `{"summary":"One bug.","findings":[{"file_path":"api/handler.go","line":12,"kind":"ISSUE","severity":"HIGH","message":"Unchecked error."}]}`
+	clean := `{"summary":"Looks good.","findings":[]}`
+	ai := &scriptedAIProvider{responses: []provider.CompletionResponse{
+		{Content: finding},
+		{Content: clean},
+		{Content: clean},
+		{Content: clean},
+		{Content: clean},
+	}}
+
+	outs, err := collectReviewPassOutputs(context.Background(), ai, "BASE_PROMPT", 5, reviewPassOptions{EarlyStopOnClean: true})
+	require.NoError(t, err)
+	assert.Len(t, outs, 3)
+	assert.Len(t, ai.requests, 3)
+
+	ai = &scriptedAIProvider{responses: []provider.CompletionResponse{{Content: clean}, {Content: clean}, {Content: clean}}}
+	outs, err = collectReviewPassOutputs(context.Background(), ai, "BASE_PROMPT", 3, reviewPassOptions{})
+	require.NoError(t, err)
+	assert.Len(t, outs, 3, "without early stop every pass runs")
+}
+
+func TestCollectReviewPassOutputs_ToolCallOnlyResponseYieldsFindings(t *testing.T) {
+	args := `{"summary":"One bug.","findings":[{"file_path":"api/handler.go","line":12,"kind":"ISSUE","severity":"HIGH","message":"Unchecked error from Decode."}]}`
+	ai := &scriptedAIProvider{responses: []provider.CompletionResponse{{
+		FinishReason: "tool_calls",
+		ToolCalls:    []provider.ToolCall{{ID: "call_1", Name: "report_review", Arguments: args}},
+	}}}
+
+	outs, err := collectReviewPassOutputs(context.Background(), ai, "BASE_PROMPT", 1, reviewPassOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []string{args}, outs)
+
+	parsed := parseReviewContent(outs[0], false)
+	require.Len(t, parsed.FileComments, 1)
+	assert.Equal(t, "api/handler.go", parsed.FileComments[0].FilePath)
+	assert.Equal(t, 12, parsed.FileComments[0].Line)
+	assert.Equal(t, "HIGH", parsed.FileComments[0].Severity)
+}
+
+func TestCollectReviewPassOutputs_CacheHitSkipsProvider(t *testing.T) {
+	cache := reviewcache.New(t.TempDir(), time.Hour)
+	opts := reviewPassOptions{
+		Cache:          cache,
+		CacheKey:       reviewcache.Key("scripted", "m", "BASE_PROMPT"),
+		CacheSignature: reviewcache.Signature(map[string]string{"a.go": "v1"}),
+	}
+
+	first := &scriptedAIProvider{responses: []provider.CompletionResponse{{Content: "fresh review"}}}
+	outs, err := collectReviewPassOutputs(context.Background(), first, "BASE_PROMPT", 1, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fresh review"}, outs)
+	assert.Len(t, first.requests, 1)
+
+	second := &scriptedAIProvider{}
+	outs, err = collectReviewPassOutputs(context.Background(), second, "BASE_PROMPT", 1, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fresh review"}, outs)
+	assert.Empty(t, second.requests)
+
+	opts.CacheSignature = reviewcache.Signature(map[string]string{"a.go": "v2"})
+	third := &scriptedAIProvider{}
+	_, err = collectReviewPassOutputs(context.Background(), third, "BASE_PROMPT", 1, opts)
+	require.NoError(t, err)
+	assert.Len(t, third.requests, 1)
+}
+
+func TestMergeEnsembleFindings_DedupesAndKeepsHighestSeverity(t *testing.T) {
+	merged := mergeEnsembleFindings([][]core.FileComment{
+		{
+			{FilePath: "a.go", Line: 3, Kind: "ISSUE", Severity: "LOW", Message: "nil deref"},
+			{FilePath: "b.go", Line: 9, Kind: "SUGGESTION", Severity: "MEDIUM", Message: "rename"},
+		},
+		{
+			{FilePath: "a.go", Line: 3, Kind: "ISSUE", Severity: "HIGH", Message: "nil deref"},
+			{FilePath: "c.go", Line: 1, Kind: "ISSUE", Severity: "CRITICAL", Message: "sql injection"},
+		},
+	})
+
+	require.Len(t, merged, 3)
+	assert.Equal(t, "a.go", merged[0].FilePath)
+	assert.Equal(t, "HIGH", merged[0].Severity)
+	assert.Equal(t, "b.go", merged[1].FilePath)
+	assert.Equal(t, "c.go", merged[2].FilePath)
+}
+
 func TestBuildDiscussionConversationMessages_StripsMarkersAndMergesRoles(t *testing.T) {
 	discussion := vcs.MRDiscussion{Notes: []vcs.MRDiscussionNote{
 		{Author: "prev", Body: "<!-- prev:thread -->\nFirst bot note"},
@@ -1238,3 +1831,447 @@ func TestBuildDiscussionConversationMessages_StripsMarkersAndMergesRoles(t *test
 	assert.Contains(t, msgs[0].Content, "Second bot note")
 	assert.Equal(t, provider.RoleUser, msgs[1].Role)
 }
+
+type recordingVCSProvider struct {
+	vcs.VCSProvider
+	summaries   []string
+	discussions []string
+	replies     map[string][]string
+	resolved    []string
+	updated     map[int64]string
+}
+
+func (r *recordingVCSProvider) Info() vcs.ProviderInfo { return vcs.ProviderInfo{Name: "recording"} }
+
+func (r *recordingVCSProvider) PostSummaryNote(_ context.Context, _ string, _ int64, body string) error {
+	r.summaries = append(r.summaries, body)
+	return nil
+}
+
+func (r *recordingVCSProvider) UpdateSummaryNote(_ context.Context, _ string, _ int64, noteID int64, body string) error {
+	if r.updated == nil {
+		r.updated = map[int64]string{}
+	}
+	r.updated[noteID] = body
+	return nil
+}
+
+func (r *recordingVCSProvider) StartMRDiscussion(_ context.Context, _ string, _ int64, body string) error {
+	r.discussions = append(r.discussions, body)
+	return nil
+}
+
+func (r *recordingVCSProvider) ReplyToMRDiscussion(_ context.Context, _ string, _ int64, discussionID, body string) error {
+	if r.replies == nil {
+		r.replies = map[string][]string{}
+	}
+	r.replies[discussionID] = append(r.replies[discussionID], body)
+	return nil
+}
+
+func (r *recordingVCSProvider) ResolveMRDiscussion(_ context.Context, _ string, _ int64, discussionID string) error {
+	r.resolved = append(r.resolved, discussionID)
+	return nil
+}
+
+func TestResolveStaleThreads_ResolvesOnlyVanishedPrevFindings(t *testing.T) {
+	changes := []diffparse.FileChange{
+		{
+			NewName: "a.go",
+			Hunks: []diffparse.Hunk{
+				{
+					NewStart: 10,
+					NewLines: 2,
+					Lines: []diffparse.DiffLine{
+						{Type: diffparse.LineContext, OldLineNo: 10, NewLineNo: 10},
+						{Type: diffparse.LineAdded, NewLineNo: 11},
+					},
+				},
+			},
+		},
+	}
+	valid := collectValidPositions(changes)
+	prevNote := func(path string, line int) vcs.MRDiscussionNote {
+		return vcs.MRDiscussionNote{
+			Body:       "[HIGH] Nil guard missing\n\n" + prevThreadMarker,
+			FilePath:   path,
+			Line:       line,
+			Resolvable: true,
+		}
+	}
+	discussions := []vcs.MRDiscussion{
+		{ID: "present", Notes: []vcs.MRDiscussionNote{prevNote("a.go", 11)}},
+		{ID: "gone-file", Notes: []vcs.MRDiscussionNote{prevNote("b.go", 4)}},
+		{ID: "gone-line", Notes: []vcs.MRDiscussionNote{prevNote("a.go", 200)}},
+		{ID: "carried", Notes: []vcs.MRDiscussionNote{prevNote("c.go", 1)}},
+		{ID: "paused", Notes: []vcs.MRDiscussionNote{prevNote("d.go", 1)}},
+		{ID: "human", Notes: []vcs.MRDiscussionNote{{Body: "[HIGH] looks off", FilePath: "b.go", Line: 4, Resolvable: true}}},
+		{ID: "already", Notes: []vcs.MRDiscussionNote{
+			prevNote("b.go", 9),
+			{Body: "Resolved: no longer present in current diff.\n\n" + prevResolvedMarker},
+		}},
+	}
+	carry := []carryOverFinding{{DiscussionID: "carried", FilePath: "c.go", Line: 1}}
+	paused := map[string]bool{"paused": true}
+
+	rec := &recordingVCSProvider{}
+	n := resolveStaleThreads(context.Background(), rec, "grp/proj", 1, discussions, valid, carry, "prev", paused, nil)
+
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"gone-file", "gone-line"}, rec.resolved)
+	require.Len(t, rec.replies["gone-file"], 1)
+	assert.Contains(t, rec.replies["gone-file"][0], "Resolved: no longer present in current diff.")
+	assert.Contains(t, rec.replies["gone-file"][0], prevResolvedMarker)
+	assert.NotContains(t, rec.replies, "present")
+}
+
+func TestPostCarryOverReminders_CombinesFindingsPerDiscussion(t *testing.T) {
+	carry := []carryOverFinding{
+		{DiscussionID: "thread", FilePath: "a.go", Line: 12, Severity: "MEDIUM", Message: "Error ignored"},
+		{DiscussionID: "other", FilePath: "b.go", Line: 3, Severity: "LOW", Message: "Rename helper"},
+		{DiscussionID: "thread", FilePath: "a.go", Line: 20, Severity: "HIGH", Message: "Nil guard missing"},
+	}
+
+	rec := &recordingVCSProvider{}
+	n := postCarryOverReminders(context.Background(), rec, "grp/proj", 1, nil, carry, nil)
+
+	assert.Equal(t, 2, n)
+	require.Len(t, rec.replies["thread"], 1)
+	body := rec.replies["thread"][0]
+	assert.Contains(t, body, prevCarryOverMarker)
+	assert.Contains(t, body, "- `a.go:20` [HIGH] Nil guard missing")
+	assert.Contains(t, body, "- `a.go:12` [MEDIUM] Error ignored")
+	assert.Less(t, strings.Index(body, "a.go:20"), strings.Index(body, "a.go:12"))
+	require.Len(t, rec.replies["other"], 1)
+	assert.Contains(t, rec.replies["other"][0], "at `b.go:3` [LOW]")
+}
+
+func TestPostMRSummaryNote_GuardsDuplicatesAndEmptyContent(t *testing.T) {
+	rec := &recordingVCSProvider{}
+	posted, err := postMRSummaryNote(context.Background(), rec, "grp/proj", 1, nil, "Looks good overall.", false)
+	require.NoError(t, err)
+	assert.True(t, posted)
+	require.Len(t, rec.summaries, 1)
+	assert.Contains(t, rec.summaries[0], prevSummaryMarker)
+	assert.Contains(t, rec.summaries[0], "Looks good overall.")
+
+	existing := []vcs.MRNote{{Body: rec.summaries[0]}}
+	posted, err = postMRSummaryNote(context.Background(), rec, "grp/proj", 1, existing, "Another summary", false)
+	require.NoError(t, err)
+	assert.False(t, posted)
+
+	posted, err = postMRSummaryNote(context.Background(), rec, "grp/proj", 1, nil, "  ", false)
+	require.NoError(t, err)
+	assert.False(t, posted)
+	assert.Len(t, rec.summaries, 1)
+}
+
+func TestPostUnplacedFindings_InlineOnlyFallbackStartsDiscussion(t *testing.T) {
+	unplaced := []string{"- b.go:40 [LOW] Rename helper", "- a.go:12 [HIGH] Nil guard missing"}
+
+	dropped := &recordingVCSProvider{}
+	require.NoError(t, postUnplacedFindings(context.Background(), dropped, "grp/proj", 1, nil, unplaced, true, false))
+	assert.Empty(t, dropped.summaries)
+	assert.Empty(t, dropped.discussions)
+
+	fallback := &recordingVCSProvider{}
+	require.NoError(t, postUnplacedFindings(context.Background(), fallback, "grp/proj", 1, nil, unplaced, true, true))
+	assert.Empty(t, fallback.summaries)
+	require.Len(t, fallback.discussions, 1)
+	assert.Contains(t, fallback.discussions[0], "a.go:12 [HIGH] Nil guard missing")
+	assert.Contains(t, fallback.discussions[0], "b.go:40 [LOW] Rename helper")
+	assert.Contains(t, fallback.discussions[0], prevThreadMarker)
+
+	// A rerun finds the marked discussion among the notes and posts no duplicate.
+	rerun := &recordingVCSProvider{}
+	notes := []vcs.MRNote{{ID: 5, Body: fallback.discussions[0]}}
+	require.NoError(t, postUnplacedFindings(context.Background(), rerun, "grp/proj", 1, notes, unplaced, true, true))
+	assert.Empty(t, rerun.discussions)
+
+	summary := &recordingVCSProvider{}
+	require.NoError(t, postUnplacedFindings(context.Background(), summary, "grp/proj", 1, nil, unplaced, false, true))
+	require.Len(t, summary.summaries, 1)
+	assert.Empty(t, summary.discussions)
+}
+
+func TestPostUnplacedFindings_InlineOnlyFallbackUnsupportedProvider(t *testing.T) {
+	p := struct{ vcs.VCSProvider }{&recordingVCSProvider{}}
+	err := postUnplacedFindings(context.Background(), p, "grp/proj", 1, nil, []string{"- a.go:1 [LOW] x"}, true, true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unplaced findings dropped")
+}
+
+func TestBuildMRReviewJSON_StableSnakeCaseDocument(t *testing.T) {
+	mr := &vcs.MergeRequest{IID: 7, Title: "Add cache", SourceBranch: "feat", TargetBranch: "main", DiffRefs: vcs.DiffRefs{HeadSHA: "abc"}}
+	groups := []inlineGroup{
+		{FilePath: "a.go", NewLine: 12, OldLine: 10, Severity: "high", Message: "Nil guard missing", Suggestion: "if x == nil {"},
+		{FilePath: "b.go", NewLine: 3, Severity: "LOW", Message: "Rename helper"},
+	}
+	doc := buildMRReviewJSON(mr, "grp/proj", "openai", "gpt-4o", true, "Looks mostly fine.", groups,
+		[]string{findingStatusPosted}, []string{"- c.go:1 [LOW] z", "- b.go:9 [LOW] y"},
+		[]diffparse.EnrichmentOutcome{{File: "a.go", Outcome: diffparse.EnrichmentSerena}, {File: "b.go", Outcome: diffparse.EnrichmentRawFallback, Reason: "file too large"}})
+
+	var buf strings.Builder
+	require.NoError(t, writeMRReviewJSON(&buf, doc))
+
+	var raw map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(buf.String()), &raw))
+	assert.Equal(t, "grp/proj", raw["project_id"])
+	assert.Equal(t, float64(7), raw["mr_iid"])
+	assert.Equal(t, true, raw["dry_run"])
+	assert.Equal(t, "Looks mostly fine.", raw["summary"])
+	assert.Equal(t, []interface{}{"- b.go:9 [LOW] y", "- c.go:1 [LOW] z"}, raw["unplaced"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"file": "a.go", "outcome": "serena"},
+		map[string]interface{}{"file": "b.go", "outcome": "raw-fallback", "reason": "file too large"},
+	}, raw["enrichment"])
+
+	findings, ok := raw["findings"].([]interface{})
+	require.True(t, ok)
+	require.Len(t, findings, 2)
+	first := findings[0].(map[string]interface{})
+	assert.Equal(t, "a.go", first["file"])
+	assert.Equal(t, float64(12), first["new_line"])
+	assert.Equal(t, float64(10), first["old_line"])
+	assert.Equal(t, "HIGH", first["severity"])
+	assert.Equal(t, "if x == nil {", first["suggestion"])
+	assert.Equal(t, findingStatusPosted, first["status"])
+	assert.Equal(t, true, first["posted"])
+	second := findings[1].(map[string]interface{})
+	assert.Equal(t, findingStatusNotPosted, second["status"])
+	assert.Equal(t, false, second["posted"])
+}
+
+func TestNormalizeMROutputFormat(t *testing.T) {
+	got, ok := normalizeMROutputFormat("")
+	assert.True(t, ok)
+	assert.Equal(t, mrOutputText, got)
+	got, ok = normalizeMROutputFormat(" JSON ")
+	assert.True(t, ok)
+	assert.Equal(t, mrOutputJSON, got)
+	got, ok = normalizeMROutputFormat("html")
+	assert.True(t, ok)
+	assert.Equal(t, mrOutputHTML, got)
+	_, ok = normalizeMROutputFormat("yaml")
+	assert.False(t, ok)
+}
+
+func TestWriteMRReviewDocument_HTMLToFile(t *testing.T) {
+	mr := &vcs.MergeRequest{IID: 7, Title: "Add cache", SourceBranch: "feat", TargetBranch: "main"}
+	groups := []inlineGroup{
+		{FilePath: "a.go", OldLine: 10, Severity: "high", Message: "Removed guard", OnDeleted: true},
+	}
+	doc := buildMRReviewJSON(mr, "grp/proj", "openai", "gpt-4o", false, "Summary text", groups,
+		[]string{findingStatusPosted}, nil, nil)
+
+	report := mrReviewHTMLReport(doc)
+	require.Len(t, report.Findings, 1)
+	assert.Equal(t, 10, report.Findings[0].Line)
+	assert.Equal(t, findingStatusPosted, report.Findings[0].Status)
+
+	path := filepath.Join(t.TempDir(), "report.html")
+	var stdout strings.Builder
+	require.NoError(t, writeMRReviewDocument(mrOutputHTML, path, &stdout, doc))
+	assert.Empty(t, stdout.String())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "<title>Review: Add cache</title>")
+	assert.Contains(t, string(data), "Removed guard")
+}
+
+func TestCollectReviewPassOutputs_JSONModeFollowsStructuredOutput(t *testing.T) {
+	structured := &scriptedAIProvider{}
+	_, err := collectReviewPassOutputs(context.Background(), structured, "BASE_PROMPT", 2, reviewPassOptions{JSONMode: true})
+	require.NoError(t, err)
+	require.Len(t, structured.requests, 2)
+	assert.True(t, structured.requests[0].JSONMode)
+	assert.True(t, structured.requests[1].JSONMode)
+
+	markdown := &scriptedAIProvider{}
+	_, err = collectReviewPassOutputs(context.Background(), markdown, "BASE_PROMPT", 1, reviewPassOptions{})
+	require.NoError(t, err)
+	require.Len(t, markdown.requests, 1)
+	assert.False(t, markdown.requests[0].JSONMode)
+}
+
+func TestCollectReviewPassOutputs_SystemPromptOverride(t *testing.T) {
+	const custom = "You review an embedded C codebase that follows MISRA C:2012."
+
+	refine := &scriptedAIProvider{}
+	_, err := collectReviewPassOutputs(context.Background(), refine, "BASE_PROMPT", 1, reviewPassOptions{SystemPrompt: custom})
+	require.NoError(t, err)
+	require.Len(t, refine.requests, 1)
+	assert.Equal(t, provider.RoleSystem, refine.requests[0].Messages[0].Role)
+	assert.Equal(t, custom, refine.requests[0].Messages[0].Content)
+
+	ensemble := &scriptedAIProvider{}
+	_, err = collectReviewPassOutputs(context.Background(), ensemble, "BASE_PROMPT", 2, reviewPassOptions{Mode: passModeEnsemble, SystemPrompt: custom})
+	require.NoError(t, err)
+	for _, req := range ensemble.requests {
+		assert.Equal(t, custom, req.Messages[0].Content)
+	}
+
+	defaults := &scriptedAIProvider{}
+	_, err = collectReviewPassOutputs(context.Background(), defaults, "BASE_PROMPT", 1, reviewPassOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, defaultRefineSystemPrompt, defaults.requests[0].Messages[0].Content)
+}
+
+func TestRunEnsemblePass_SanitizedRetryKeepsSystemPrompt(t *testing.T) {
+	const custom = "You review an embedded C codebase that follows MISRA C:2012."
+	ai := &scriptedAIProvider{responses: []provider.CompletionResponse{
+		{FinishReason: string(provider.ErrCodeContentFilter)},
+		{Content: "retried review"},
+	}}
+	out, err := runEnsemblePass(context.Background(), ai, "BASE_PROMPT", 1, 2, contentFilterRetrySanitized, reviewPassOptions{Mode: passModeEnsemble, SystemPrompt: custom, JSONMode: true})
+	require.NoError(t, err)
+	assert.Equal(t, "retried review", out)
+	require.Len(t, ai.requests, 2)
+	assert.Equal(t, ai.requests[0].Messages[0], ai.requests[1].Messages[0])
+	assert.Equal(t, custom, ai.requests[1].Messages[0].Content)
+	assert.True(t, ai.requests[1].JSONMode)
+}
+
+func TestProcessNoteReplyCommands_UsesReplySystemPrompt(t *testing.T) {
+	ai := &scriptedAIProvider{}
+	vcsProvider := &recordingVCSProvider{}
+	notes := []vcs.MRNote{{ID: 7, Author: "alice", Body: "@prev reply why is this needed?"}}
+	positions := map[string]inlinePositions{
+		"main.go": {oldByNew: map[int]int{3: 3}, added: map[int]struct{}{3: {}}, content: map[int]string{3: "x := 1"}},
+	}
+
+	processNoteReplyCommands(context.Background(), vcsProvider, ai, "grp/proj", 1, notes, &vcs.MergeRequest{}, positions, "prev", "Answer as a MISRA auditor.")
+
+	require.Len(t, ai.requests, 1)
+	assert.Equal(t, "Answer as a MISRA auditor.", ai.requests[0].Messages[0].Content)
+}
+
+func TestBuildMRFormattedDiffs_IncludesStatsHeaderWhenEnabled(t *testing.T) {
+	t.Setenv("CI_PROJECT_DIR", t.TempDir())
+	review := &handlers.MRReview{
+		MR: &vcs.MergeRequest{TargetBranch: "main", SourceBranch: "feature"},
+		Changes: []diffparse.FileChange{
+			{
+				NewName: "cmd/app.go",
+				Stats:   diffparse.DiffStats{Additions: 1, Deletions: 0},
+				Hunks: []diffparse.Hunk{{
+					NewStart: 1,
+					NewLines: 1,
+					Lines:    []diffparse.DiffLine{{Type: diffparse.LineAdded, Content: "package main", NewLineNo: 1}},
+				}},
+			},
+			{NewName: "README.md", Stats: diffparse.DiffStats{Additions: 2, Deletions: 3}},
+		},
+	}
+
+	plain, err := buildMRFormattedDiffs(review, "gpt-4o", "off", 0, 80000, diffparse.EnrichOptions{}, nil, false)
+	require.NoError(t, err)
+	assert.NotContains(t, plain, "## Change Stats")
+
+	withStats, err := buildMRFormattedDiffs(review, "gpt-4o", "off", 0, 80000, diffparse.EnrichOptions{}, nil, true)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(withStats, "## Change Stats"))
+	assert.Contains(t, withStats, "| cmd/app.go | go | 1 | 0 |")
+	assert.Contains(t, withStats, "| README.md | markdown | 2 | 3 |")
+	assert.Contains(t, withStats, "Total: 2 files changed, +3/-3")
+}
+
+func TestBuildMRFormattedDiffs_StatsHeaderFitsTheBudget(t *testing.T) {
+	t.Setenv("CI_PROJECT_DIR", t.TempDir())
+	var changes []diffparse.FileChange
+	for i := 0; i < 6; i++ {
+		var lines []diffparse.DiffLine
+		for n := 1; n <= 20; n++ {
+			lines = append(lines, diffparse.DiffLine{Type: diffparse.LineAdded, Content: strings.Repeat("x", 60), NewLineNo: n})
+		}
+		changes = append(changes, diffparse.FileChange{
+			NewName: fmt.Sprintf("pkg/file%d.go", i),
+			Stats:   diffparse.DiffStats{Additions: 20},
+			Hunks:   []diffparse.Hunk{{NewStart: 1, NewLines: 20, Lines: lines}},
+		})
+	}
+	review := &handlers.MRReview{MR: &vcs.MergeRequest{TargetBranch: "main", SourceBranch: "feature"}, Changes: changes}
+
+	const budget = 800
+	out, err := buildMRFormattedDiffs(review, "gpt-4o", "off", 0, budget, diffparse.EnrichOptions{}, nil, true)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(out, "## Change Stats"))
+	assert.LessOrEqual(t, len(out)/4, budget, "the stats header counts against the budget")
+
+	out, err = buildMRFormattedDiffs(review, "gpt-4o", "off", 0, 50, diffparse.EnrichOptions{}, nil, true)
+	require.NoError(t, err)
+	assert.NotContains(t, out, "## Change Stats", "a header that alone exceeds the budget is left out")
+}
+
+func TestApplyPrevIgnore_FiltersChangesAndFindings(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, core.PrevIgnoreFile), []byte("legacy/\n*.snap\n!legacy/keep.go\n"), 0o644))
+	changes := []diffparse.FileChange{
+		{NewName: "legacy/old.go"},
+		{NewName: "legacy/keep.go"},
+		{NewName: "ui/app.snap"},
+		{NewName: "cmd/main.go"},
+	}
+
+	kept, rules := applyPrevIgnore(root, changes)
+	require.Len(t, kept, 2)
+	assert.Equal(t, "legacy/keep.go", kept[0].NewName)
+	assert.Equal(t, "cmd/main.go", kept[1].NewName)
+
+	findings := dropPrevIgnoredFindings([]core.FileComment{
+		{FilePath: "legacy/old.go", Line: 3, Message: "x"},
+		{FilePath: "cmd/main.go", Line: 5, Message: "y"},
+	}, rules)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "cmd/main.go", findings[0].FilePath)
+
+	kept, rules = applyPrevIgnore(t.TempDir(), changes)
+	assert.Len(t, kept, 4)
+	assert.True(t, rules.Empty())
+}
+
+func TestSaveDryRunArtifacts_SingleFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out", "run.txt")
+	written, err := saveDryRunArtifacts(path, "PROMPT BODY", []string{"first pass", "second pass"}, []string{"alt"})
+	require.NoError(t, err)
+	assert.Equal(t, path, written)
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	got := string(raw)
+	assert.Contains(t, got, "===== PROMPT =====\nPROMPT BODY\n")
+	assert.Contains(t, got, "===== PASS 1/2 RAW OUTPUT =====\nfirst pass\n")
+	assert.Contains(t, got, "===== PASS 2/2 RAW OUTPUT =====\nsecond pass\n")
+	assert.Contains(t, got, "===== SAMPLE 1 RAW OUTPUT =====\nalt\n")
+}
+
+func TestSaveDryRunArtifacts_Directory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "fixtures") + "/"
+	_, err := saveDryRunArtifacts(dir, "PROMPT BODY", []string{"first pass", "second pass"}, nil)
+	require.NoError(t, err)
+
+	for name, want := range map[string]string{
+		"prompt.txt":  "PROMPT BODY",
+		"pass-01.txt": "first pass",
+		"pass-02.txt": "second pass",
+	} {
+		raw, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err, name)
+		assert.Equal(t, want, string(raw))
+	}
+	_, err = os.Stat(filepath.Join(dir, "sample-01.txt"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRunReviewPasses_StructuredOutputSendsPrefill(t *testing.T) {
+	p := &scriptedAIProvider{responses: []provider.CompletionResponse{{Content: `{"summary":"ok","findings":[]}`}}}
+	_, err := runReviewPassesWithOptions(context.Background(), p, "prompt", 1, reviewPassOptions{JSONMode: true})
+	require.NoError(t, err)
+	require.Len(t, p.requests, 1)
+	assert.Equal(t, `{"summary":`, p.requests[0].AssistantPrefill)
+
+	p = &scriptedAIProvider{responses: []provider.CompletionResponse{{Content: "plain"}}}
+	_, err = runReviewPassesWithOptions(context.Background(), p, "prompt", 1, reviewPassOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, p.requests[0].AssistantPrefill)
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/sanix-darker/prev/internal/config"
+	"github.com/sanix-darker/prev/internal/provider"
+)
+
+// tokenUsage accumulates provider token counts across review passes. It is
+// safe for concurrent use by ensemble workers; a nil *tokenUsage ignores
+// every call.
+type tokenUsage struct {
+	mu         sync.Mutex
+	prompt     int
+	completion int
+	reasoning  int
+	total      int
+}
+
+// add records the usage reported on resp.
+func (u *tokenUsage) add(resp *provider.CompletionResponse) {
+	if u == nil || resp == nil {
+		return
+	}
+	total := resp.Usage.TotalTokens
+	if total == 0 {
+		total = resp.Usage.PromptTokens + resp.Usage.CompletionTokens
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.prompt += resp.Usage.PromptTokens
+	u.completion += resp.Usage.CompletionTokens
+	u.reasoning += resp.Usage.ReasoningTokens
+	u.total += total
+}
+
+// snapshot returns the accumulated prompt, completion and total tokens.
+func (u *tokenUsage) snapshot() (prompt, completion, total int) {
+	if u == nil {
+		return 0, 0, 0
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.prompt, u.completion, u.total
+}
+
+// reasoningTokens returns the accumulated reasoning tokens, which are
+// already counted in the completion total.
+func (u *tokenUsage) reasoningTokens() int {
+	if u == nil {
+		return 0
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.reasoning
+}
+
+// tokenPricing is the per-1K-token price set by review.cost_per_1k_input and
+// review.cost_per_1k_output. Zero prices disable the cost estimate.
+type tokenPricing struct {
+	InputPer1K  float64
+	OutputPer1K float64
+}
+
+func (p tokenPricing) enabled() bool {
+	return p.InputPer1K > 0 || p.OutputPer1K > 0
+}
+
+// resolveTokenPricing reads the config-only cost settings. Negative values
+// are rejected by validateEffectiveConfig and treated as zero here.
+func resolveTokenPricing(conf config.Config) tokenPricing {
+	if conf.Viper == nil {
+		return tokenPricing{}
+	}
+	return tokenPricing{
+		InputPer1K:  max(conf.Viper.GetFloat64("review.cost_per_1k_input"), 0),
+		OutputPer1K: max(conf.Viper.GetFloat64("review.cost_per_1k_output"), 0),
+	}
+}
+
+// printTokenUsage writes the token totals and, when pricing is set, the
+// estimated cost. Nothing is printed when the provider reported no usage
+// (e.g. a review cache hit).
+func printTokenUsage(w io.Writer, u *tokenUsage, pricing tokenPricing) {
+	prompt, completion, total := u.snapshot()
+	if total == 0 {
+		return
+	}
+	if reasoning := u.reasoningTokens(); reasoning > 0 {
+		fmt.Fprintf(w, "Tokens: prompt=%d completion=%d (reasoning=%d) total=%d\n", prompt, completion, reasoning, total)
+	} else {
+		fmt.Fprintf(w, "Tokens: prompt=%d completion=%d total=%d\n", prompt, completion, total)
+	}
+	if pricing.enabled() {
+		cost := float64(prompt)/1000*pricing.InputPer1K + float64(completion)/1000*pricing.OutputPer1K
+		fmt.Fprintf(w, "Estimated cost: $%.4f\n", cost)
+	}
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/config"
+	"github.com/sanix-darker/prev/internal/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectReviewPassOutputs_AccumulatesUsage(t *testing.T) {
+	ai := &scriptedAIProvider{responses: []provider.CompletionResponse{
+		{Content: "pass one", Usage: provider.Usage{PromptTokens: 1000, CompletionTokens: 200, TotalTokens: 1200}},
+		{Content: "pass two", Usage: provider.Usage{PromptTokens: 1500, CompletionTokens: 300}},
+	}}
+	usage := &tokenUsage{}
+	_, err := collectReviewPassOutputs(context.Background(), ai, "BASE_PROMPT", 2, reviewPassOptions{Usage: usage})
+	require.NoError(t, err)
+
+	prompt, completion, total := usage.snapshot()
+	assert.Equal(t, 2500, prompt)
+	assert.Equal(t, 500, completion)
+	assert.Equal(t, 3000, total)
+}
+
+func TestCollectReviewPassOutputs_AccumulatesEnsembleUsage(t *testing.T) {
+	ai := &scriptedAIProvider{responses: []provider.CompletionResponse{
+		{Content: "a", Usage: provider.Usage{PromptTokens: 10, CompletionTokens: 1, TotalTokens: 11}},
+		{Content: "b", Usage: provider.Usage{PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12}},
+		{Content: "c", Usage: provider.Usage{PromptTokens: 10, CompletionTokens: 3, TotalTokens: 13}},
+	}}
+	usage := &tokenUsage{}
+	_, err := collectReviewPassOutputs(context.Background(), ai, "BASE_PROMPT", 3, reviewPassOptions{Mode: passModeEnsemble, Usage: usage})
+	require.NoError(t, err)
+
+	_, _, total := usage.snapshot()
+	assert.Equal(t, 36, total)
+}
+
+func TestPrintTokenUsage(t *testing.T) {
+	usage := &tokenUsage{}
+	usage.add(&provider.CompletionResponse{Usage: provider.Usage{PromptTokens: 12034, CompletionTokens: 1890, TotalTokens: 13924}})
+
+	var buf bytes.Buffer
+	printTokenUsage(&buf, usage, tokenPricing{})
+	assert.Equal(t, "Tokens: prompt=12034 completion=1890 total=13924\n", buf.String())
+
+	buf.Reset()
+	printTokenUsage(&buf, usage, tokenPricing{InputPer1K: 0.0025, OutputPer1K: 0.01})
+	assert.Contains(t, buf.String(), "Estimated cost: $0.0490\n")
+
+	buf.Reset()
+	printTokenUsage(&buf, &tokenUsage{}, tokenPricing{InputPer1K: 1})
+	assert.Empty(t, buf.String())
+	printTokenUsage(&buf, nil, tokenPricing{})
+	assert.Empty(t, buf.String())
+}
+
+func TestResolveTokenPricing(t *testing.T) {
+	conf := config.NewDefaultConfig()
+	assert.False(t, resolveTokenPricing(conf).enabled())
+
+	conf.Viper.Set("review.cost_per_1k_input", 0.003)
+	conf.Viper.Set("review.cost_per_1k_output", -1.0)
+	pricing := resolveTokenPricing(conf)
+	assert.InDelta(t, 0.003, pricing.InputPer1K, 1e-9)
+	assert.Zero(t, pricing.OutputPer1K)
+	assert.Contains(t, validateEffectiveConfig(conf), "review.cost_per_1k_output must be >= 0")
+}
+
+func TestPrintTokenUsage_ShowsReasoningTokens(t *testing.T) {
+	usage := &tokenUsage{}
+	usage.add(&provider.CompletionResponse{Usage: provider.Usage{PromptTokens: 100, CompletionTokens: 900, TotalTokens: 1000, ReasoningTokens: 640}})
+
+	var buf bytes.Buffer
+	printTokenUsage(&buf, usage, tokenPricing{})
+	assert.Equal(t, "Tokens: prompt=100 completion=900 (reasoning=640) total=1000\n", buf.String())
+}
@@ -25,7 +25,75 @@ func resolveProvider(conf config.Config) (provider.AIProvider, error) {
 		pcfg.Viper.Set("model", conf.Model)
 	}
 
-	return provider.Get(pcfg.Name, pcfg.Viper)
+	p, err := provider.Get(pcfg.Name, pcfg.Viper)
+	if err != nil {
+		return nil, err
+	}
+	if conf.Debug {
+		requested := pcfg.Viper.GetString("model")
+		resolved := provider.ResolveModelAlias(provider.ModelAliases(pcfg.Name, pcfg.Viper), requested)
+		if requested != "" && resolved != requested {
+			fmt.Fprintf(os.Stderr, "[debug] model alias %q -> %q\n", requested, resolved)
+		}
+	}
+	return p, nil
+}
+
+// withFallbackProviders wraps p so that rate-limit and availability errors
+// fail over, for the rest of the run, to the providers listed in
+// review.fallback_providers. Each fallback is built from its own
+// providers.<name> block; --model only applies to the primary provider.
+func withFallbackProviders(conf config.Config, p provider.AIProvider) provider.AIProvider {
+	if conf.Viper == nil {
+		return p
+	}
+	chain := []provider.AIProvider{p}
+	names := []string{p.Info().Name}
+	seen := map[string]bool{p.Info().Name: true}
+	for _, raw := range conf.Viper.GetStringSlice("review.fallback_providers") {
+		name := strings.ToLower(strings.TrimSpace(raw))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		pcfg := provider.ResolveNamedProvider(conf.Viper, name)
+		fallback, err := provider.Get(pcfg.Name, pcfg.Viper)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping fallback provider %q: %v\n", name, err)
+			continue
+		}
+		chain = append(chain, fallback)
+		names = append(names, name)
+	}
+	if len(chain) == 1 {
+		return p
+	}
+	fmt.Printf("Provider fallback chain: %s\n", strings.Join(names, " -> "))
+	fp := provider.NewFallbackProvider(chain...)
+	fp.OnSwitch = func(from, to provider.ProviderInfo, err error) {
+		fmt.Fprintf(os.Stderr, "Warning: provider %s failed (%v); falling back to %s for the rest of the run.\n", from.Name, err, to.Name)
+	}
+	return fp
+}
+
+// reviewModelName returns the model a review will be sent to, resolved from
+// --model and the config file without instantiating the provider.
+func reviewModelName(conf config.Config) string {
+	if conf.Viper == nil {
+		return conf.Model
+	}
+	return provider.ConfiguredModel(conf.Viper, conf.Model)
+}
+
+// clampDiffBudgetToModel lowers the diff token budget so the prompt fits in
+// model's context window, printing a notice when it does.
+func clampDiffBudgetToModel(model string, maxTokens int) int {
+	budget, clamped := provider.PromptTokenBudget(model, maxTokens)
+	if clamped {
+		fmt.Printf("Notice: max_tokens %d exceeds the %s context window (%d); diff budget clamped to %d\n",
+			maxTokens, model, provider.ModelContextWindow(model), budget)
+	}
+	return budget
 }
 
 // callProvider sends a prompt to the configured AI provider and prints the result.
@@ -54,7 +122,7 @@ func blockingCallProvider(ctx context.Context, conf config.Config, p provider.AI
 	id, choices, err := provider.SimpleCompleteWithContext(
 		ctx,
 		p,
-		"You are a helpful assistant and source code reviewer.",
+		reviewSystemPrompt(conf),
 		"You are code reviewer for a project",
 		prompt,
 	)
@@ -73,7 +141,16 @@ func blockingCallProvider(ctx context.Context, conf config.Config, p provider.AI
 }
 
 func streamCallProvider(ctx context.Context, conf config.Config, p provider.AIProvider, prompt string) {
-	provider.ApiCallWithProviderContext(ctx, conf.Debug, p, prompt)
+	provider.ApiCallWithSystemPrompt(ctx, conf.Debug, p, reviewSystemPrompt(conf), prompt)
+}
+
+// reviewSystemPrompt returns review.system_prompt, or the bridge default when
+// it is unset.
+func reviewSystemPrompt(conf config.Config) string {
+	if conf.Viper == nil {
+		return provider.DefaultSystemPrompt
+	}
+	return systemPromptOr(conf.Viper.GetString("review.system_prompt"), provider.DefaultSystemPrompt)
 }
 
 func resolvedModelForLog(conf config.Config, fallback string) string {
@@ -32,3 +32,18 @@ func TestResolvedModelForLog_EnvOverridesConfig(t *testing.T) {
 	conf := config.Config{Viper: v, Provider: "openai"}
 	assert.Equal(t, "gpt-5.3-codex", resolvedModelForLog(conf, "fallback"))
 }
+
+func TestReviewModelName_ExpandsAlias(t *testing.T) {
+	t.Setenv("ANTHROPIC_MODEL", "")
+	v := config.NewStore()
+	v.Set("provider", "anthropic")
+	v.Set("providers.anthropic.model", "sonnet")
+	assert.Equal(t, "claude-sonnet-4-20250514", reviewModelName(config.Config{Viper: v}))
+	assert.Equal(t, "claude-3-5-haiku-20241022", reviewModelName(config.Config{Viper: v, Model: "haiku"}))
+}
+
+func TestClampDiffBudgetToModel(t *testing.T) {
+	assert.Equal(t, 8192-2048, clampDiffBudgetToModel("gpt-4", 80000))
+	assert.Equal(t, 80000, clampDiffBudgetToModel("gpt-4o", 80000))
+	assert.Equal(t, 80000, clampDiffBudgetToModel("unknown-model", 80000))
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// defaultRepoContextMaxChars caps the review.repo_context_file excerpt
+// (about 1k tokens).
+const defaultRepoContextMaxChars = 4000
+
+type repoContextExcerpt struct {
+	text      string
+	truncated bool
+}
+
+// repoContextCache memoizes loaded excerpts by path and cap so a run that
+// builds several prompts reads the file once.
+var repoContextCache sync.Map
+
+// loadRepoContextExcerpt reads file (relative to root unless absolute) and
+// returns at most maxChars of it, cut at a line boundary. The bool reports
+// whether the text was truncated.
+func loadRepoContextExcerpt(root, file string, maxChars int) (string, bool, error) {
+	if maxChars <= 0 {
+		maxChars = defaultRepoContextMaxChars
+	}
+	p := file
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(root, p)
+	}
+	key := fmt.Sprintf("%s|%d", p, maxChars)
+	if cached, ok := repoContextCache.Load(key); ok {
+		c := cached.(repoContextExcerpt)
+		return c.text, c.truncated, nil
+	}
+
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return "", false, err
+	}
+	text := strings.TrimSpace(string(data))
+	truncated := false
+	if len(text) > maxChars {
+		cut := strings.LastIndex(text[:maxChars], "\n")
+		if cut <= 0 {
+			cut = maxChars
+		}
+		text = strings.TrimSpace(text[:cut])
+		truncated = true
+	}
+	repoContextCache.Store(key, repoContextExcerpt{text: text, truncated: truncated})
+	return text, truncated, nil
+}
+
+// repoContextSection builds the "Repository Context" prompt section from
+// review.repo_context_file. It returns "" when file is empty; a missing or
+// unreadable file is reported as a warning.
+func repoContextSection(root, file string, maxChars int) string {
+	file = strings.TrimSpace(file)
+	if file == "" {
+		return ""
+	}
+	text, truncated, err := loadRepoContextExcerpt(root, file, maxChars)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to read repo context file %s: %v\n", file, err)
+		return ""
+	}
+	if text == "" {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("## Repository Context\n")
+	fmt.Fprintf(&sb, "Background on the repository as a whole, from %s. It is not part of this change: use it to understand intent and architecture, and do not report findings against it.\n\n", filepath.ToSlash(file))
+	sb.WriteString(text)
+	if truncated {
+		sb.WriteString("\n[... repository context truncated ...]")
+	}
+	sb.WriteString("\n\n---")
+	return sb.String()
+}
+
+// applyRepoContext prepends section to prompt.
+func applyRepoContext(prompt, section string) string {
+	if section == "" {
+		return prompt
+	}
+	return section + "\n\n" + prompt
+}
+
+// diffTokenBudget returns the diff budget left after the repository context
+// takes its share of maxTokens. maxTokens <= 0 (unlimited) is kept as is.
+func diffTokenBudget(maxTokens int, section string) int {
+	if maxTokens <= 0 || section == "" {
+		return maxTokens
+	}
+	return max(maxTokens-len(section)/4, 1)
+}
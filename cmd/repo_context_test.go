@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepoContextSection_IncludedAndTruncatedAtCap(t *testing.T) {
+	root := t.TempDir()
+	doc := "# Architecture\n" + strings.Repeat("The API layer talks to the store through interfaces.\n", 100)
+	require.NoError(t, os.WriteFile(filepath.Join(root, "ARCHITECTURE.md"), []byte(doc), 0o644))
+
+	section := repoContextSection(root, "ARCHITECTURE.md", 500)
+	require.NotEmpty(t, section)
+	assert.True(t, strings.HasPrefix(section, "## Repository Context\n"))
+	assert.Contains(t, section, "from ARCHITECTURE.md. It is not part of this change")
+	assert.Contains(t, section, "# Architecture")
+	assert.Contains(t, section, "[... repository context truncated ...]")
+	assert.Less(t, len(section), 500+400, "excerpt is capped")
+
+	prompt := applyRepoContext("REVIEW PROMPT", section)
+	assert.True(t, strings.HasPrefix(prompt, "## Repository Context"))
+	assert.True(t, strings.HasSuffix(prompt, "---\n\nREVIEW PROMPT"))
+}
+
+func TestRepoContextSection_SmallDocAndBudget(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "README.md"), []byte("Short primer.\n"), 0o644))
+
+	section := repoContextSection(root, "README.md", 0)
+	assert.Contains(t, section, "Short primer.")
+	assert.NotContains(t, section, "truncated")
+
+	assert.Equal(t, "", repoContextSection(root, "", 100))
+	assert.Equal(t, "", repoContextSection(root, "missing.md", 100))
+	assert.Equal(t, "PROMPT", applyRepoContext("PROMPT", ""))
+
+	assert.Equal(t, 80000-len(section)/4, diffTokenBudget(80000, section))
+	assert.Equal(t, 0, diffTokenBudget(0, section))
+	assert.Equal(t, 1000, diffTokenBudget(1000, ""))
+}
@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sanix-darker/prev/internal/diffparse"
+)
+
+type dependencyChange struct {
+	Manifest   string
+	Name       string
+	OldVersion string
+	NewVersion string
+}
+
+var (
+	dependencyManifests = map[string]struct{}{
+		"package.json": {}, "composer.json": {}, "go.mod": {}, "requirements.txt": {},
+		"requirements-dev.txt": {}, "pyproject.toml": {}, "pipfile": {}, "cargo.toml": {},
+		"gemfile": {}, "pom.xml": {}, "build.gradle": {}, "build.gradle.kts": {},
+	}
+	dependencyLockfiles = map[string]struct{}{
+		"package-lock.json": {}, "npm-shrinkwrap.json": {}, "yarn.lock": {}, "pnpm-lock.yaml": {},
+		"composer.lock": {}, "go.sum": {}, "pipfile.lock": {}, "poetry.lock": {}, "uv.lock": {},
+		"cargo.lock": {}, "gemfile.lock": {}, "gradle.lockfile": {},
+	}
+
+	depJSONEntryRe    = regexp.MustCompile(`^\s*"([^"]+)"\s*:\s*"([^"]*)"\s*,?\s*$`)
+	depJSONSectionRe  = regexp.MustCompile(`^\s*"([A-Za-z-]+)"\s*:\s*\{`)
+	depGoModRe        = regexp.MustCompile(`^\s*(?:require\s+)?([A-Za-z0-9][A-Za-z0-9._~-]*\.[A-Za-z0-9._~/-]+)\s+(v[0-9][^\s]*)`)
+	depRequirementsRe = regexp.MustCompile(`^\s*([A-Za-z0-9][A-Za-z0-9._-]*(?:\[[^\]]*\])?)\s*(?:(==|>=|<=|~=|!=|>|<)\s*([^\s;#,]+))?`)
+	depTOMLEntryRe    = regexp.MustCompile(`^\s*([A-Za-z0-9][A-Za-z0-9._-]*)\s*=\s*(?:"([^"]*)"|\{[^}]*version\s*=\s*"([^"]*)")`)
+	depTOMLSectionRe  = regexp.MustCompile(`^\s*\[([^\]]+)\]`)
+	depGemRe          = regexp.MustCompile(`^\s*gem\s+['"]([^'"]+)['"](?:\s*,\s*['"]([^'"]+)['"])?`)
+	depVersionLikeRe  = regexp.MustCompile(`^(?:[\^~><=v* ]*[0-9]|workspace:|npm:|git\+|github:|file:|link:|latest$|\*$)`)
+)
+
+func isDependencyManifest(p string) bool {
+	_, ok := dependencyManifests[strings.ToLower(path.Base(p))]
+	return ok
+}
+
+func isDependencyLockfile(p string) bool {
+	_, ok := dependencyLockfiles[strings.ToLower(path.Base(p))]
+	return ok
+}
+
+func appendDependencyReviewGuidelines(guidelines string, changes []diffparse.FileChange, enabled bool) string {
+	if !enabled {
+		return guidelines
+	}
+	report := buildDependencyReviewReport(changes)
+	if strings.TrimSpace(report) == "" {
+		return guidelines
+	}
+	if strings.TrimSpace(guidelines) == "" {
+		return report
+	}
+	return guidelines + "\n" + report
+}
+
+func buildDependencyReviewReport(changes []diffparse.FileChange) string {
+	deps := extractDependencyChanges(changes)
+	var lockfiles []string
+	for _, c := range changes {
+		name := changeFileName(c)
+		if isDependencyLockfile(name) {
+			lockfiles = append(lockfiles, fmt.Sprintf("%s (+%d/-%d)", name, c.Stats.Additions, c.Stats.Deletions))
+		}
+	}
+	if len(deps) == 0 && len(lockfiles) == 0 {
+		return ""
+	}
+
+	lines := []string{"Dependency change summary (deterministic):"}
+	for _, d := range deps {
+		switch {
+		case d.OldVersion == "":
+			lines = append(lines, fmt.Sprintf("- %s: added `%s` %s", d.Manifest, d.Name, d.NewVersion))
+		case d.NewVersion == "":
+			lines = append(lines, fmt.Sprintf("- %s: removed `%s` %s", d.Manifest, d.Name, d.OldVersion))
+		default:
+			lines = append(lines, fmt.Sprintf("- %s: %s `%s` %s -> %s", d.Manifest, dependencyChangeVerb(d.OldVersion, d.NewVersion), d.Name, d.OldVersion, d.NewVersion))
+		}
+	}
+	if len(lockfiles) > 0 {
+		lines = append(lines, "- lockfiles changed: "+strings.Join(lockfiles, ", "))
+	}
+	lines = append(lines,
+		"Supply-chain review guidelines:",
+		"- Verify each newly added package is the intended, well-known project; flag names that look like typosquats of popular packages.",
+		"- For version bumps, check for major-version jumps, known advisories, and breaking changes in the upstream changelog.",
+		"- Flag unpinned or wildcard ranges, git/URL sources, and packages that run install-time scripts.",
+		"- Confirm lockfile updates match manifest changes; unexplained lockfile-only churn deserves a comment.",
+	)
+	return strings.Join(lines, "\n")
+}
+
+// extractDependencyChanges parses added/removed package entries from manifest
+// hunks and pairs them by name to classify additions, removals and bumps.
+func extractDependencyChanges(changes []diffparse.FileChange) []dependencyChange {
+	var out []dependencyChange
+	for _, c := range changes {
+		name := changeFileName(c)
+		if !isDependencyManifest(name) {
+			continue
+		}
+		removed := map[string]string{}
+		added := map[string]string{}
+		var order []string
+		seen := map[string]struct{}{}
+		for _, h := range c.Hunks {
+			section := ""
+			for _, l := range h.Lines {
+				section = dependencySection(name, l.Content, section)
+				if l.Type == diffparse.LineContext {
+					continue
+				}
+				pkg, version, ok := parseDependencyLine(name, l.Content, section)
+				if !ok {
+					continue
+				}
+				if _, dup := seen[pkg]; !dup {
+					seen[pkg] = struct{}{}
+					order = append(order, pkg)
+				}
+				if l.Type == diffparse.LineAdded {
+					added[pkg] = version
+				} else {
+					removed[pkg] = version
+				}
+			}
+		}
+		for _, pkg := range order {
+			oldV, hadOld := removed[pkg]
+			newV, hasNew := added[pkg]
+			if hadOld && hasNew && oldV == newV {
+				continue
+			}
+			if hadOld && oldV == "" {
+				oldV = "(unversioned)"
+			}
+			if hasNew && newV == "" {
+				newV = "(unversioned)"
+			}
+			out = append(out, dependencyChange{Manifest: name, Name: pkg, OldVersion: oldV, NewVersion: newV})
+		}
+	}
+	return out
+}
+
+func dependencySection(manifest, line, current string) string {
+	switch strings.ToLower(path.Base(manifest)) {
+	case "package.json", "composer.json":
+		if m := depJSONSectionRe.FindStringSubmatch(line); m != nil {
+			return m[1]
+		}
+		if strings.TrimSpace(line) == "}" || strings.TrimSpace(line) == "}," {
+			return ""
+		}
+	case "cargo.toml", "pyproject.toml", "pipfile":
+		if m := depTOMLSectionRe.FindStringSubmatch(line); m != nil {
+			return m[1]
+		}
+	}
+	return current
+}
+
+func parseDependencyLine(manifest, line, section string) (string, string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//") {
+		return "", "", false
+	}
+	switch strings.ToLower(path.Base(manifest)) {
+	case "package.json", "composer.json":
+		m := depJSONEntryRe.FindStringSubmatch(line)
+		if m == nil {
+			return "", "", false
+		}
+		lowerSection := strings.ToLower(section)
+		if section != "" && !strings.Contains(lowerSection, "dependencies") && lowerSection != "require" && lowerSection != "require-dev" {
+			return "", "", false
+		}
+		if section == "" && (m[1] == "version" || !depVersionLikeRe.MatchString(m[2])) {
+			return "", "", false
+		}
+		return m[1], m[2], true
+	case "go.mod":
+		if strings.HasPrefix(trimmed, "module ") || strings.HasPrefix(trimmed, "replace ") || strings.Contains(trimmed, "=>") {
+			return "", "", false
+		}
+		m := depGoModRe.FindStringSubmatch(line)
+		if m == nil {
+			return "", "", false
+		}
+		return m[1], m[2], true
+	case "requirements.txt", "requirements-dev.txt":
+		if strings.HasPrefix(trimmed, "-") {
+			return "", "", false
+		}
+		m := depRequirementsRe.FindStringSubmatch(line)
+		if m == nil {
+			return "", "", false
+		}
+		version := ""
+		if m[3] != "" {
+			version = m[2] + m[3]
+		}
+		return m[1], version, true
+	case "cargo.toml", "pyproject.toml", "pipfile":
+		if !strings.Contains(strings.ToLower(section), "dependencies") && !strings.Contains(strings.ToLower(section), "packages") {
+			return "", "", false
+		}
+		m := depTOMLEntryRe.FindStringSubmatch(line)
+		if m == nil {
+			return "", "", false
+		}
+		version := m[2]
+		if version == "" {
+			version = m[3]
+		}
+		return m[1], version, true
+	case "gemfile":
+		m := depGemRe.FindStringSubmatch(line)
+		if m == nil {
+			return "", "", false
+		}
+		return m[1], m[2], true
+	}
+	return "", "", false
+}
+
+func dependencyChangeVerb(oldV, newV string) string {
+	a := versionNumbers(oldV)
+	b := versionNumbers(newV)
+	if len(a) == 0 || len(b) == 0 {
+		return "changed"
+	}
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if b[i] > a[i] {
+			return "upgraded"
+		}
+		if b[i] < a[i] {
+			return "downgraded"
+		}
+	}
+	if len(b) > len(a) {
+		return "upgraded"
+	}
+	if len(b) < len(a) {
+		return "downgraded"
+	}
+	return "changed"
+}
+
+func versionNumbers(v string) []int {
+	v = strings.TrimLeft(strings.TrimSpace(v), "^~<>=!v ")
+	if i := strings.IndexAny(v, "-+ "); i >= 0 {
+		v = v[:i]
+	}
+	var out []int
+	for _, part := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			break
+		}
+		out = append(out, n)
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/diffparse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractDependencyChanges_PackageJSON(t *testing.T) {
+	raw := `diff --git a/package.json b/package.json
+--- a/package.json
++++ b/package.json
+@@ -1,9 +1,10 @@
+ {
+   "name": "web",
+-  "version": "1.0.0",
++  "version": "1.1.0",
+   "dependencies": {
+-    "express": "^4.18.2",
++    "express": "^5.0.1",
++    "lodahs": "^4.17.21",
+-    "request": "^2.88.2"
++    "axios": "^1.7.2"
+   }
+ }
+`
+	changes, err := diffparse.ParseGitDiff(raw)
+	require.NoError(t, err)
+
+	got := extractDependencyChanges(changes)
+	require.Len(t, got, 4)
+	assert.Equal(t, dependencyChange{Manifest: "package.json", Name: "express", OldVersion: "^4.18.2", NewVersion: "^5.0.1"}, got[0])
+	assert.Equal(t, dependencyChange{Manifest: "package.json", Name: "lodahs", NewVersion: "^4.17.21"}, got[1])
+	assert.Equal(t, dependencyChange{Manifest: "package.json", Name: "request", OldVersion: "^2.88.2"}, got[2])
+	assert.Equal(t, dependencyChange{Manifest: "package.json", Name: "axios", NewVersion: "^1.7.2"}, got[3])
+}
+
+func TestExtractDependencyChanges_GoMod(t *testing.T) {
+	raw := `diff --git a/go.mod b/go.mod
+--- a/go.mod
++++ b/go.mod
+@@ -3,7 +3,8 @@ module example.com/app
+ go 1.22
+ 
+ require (
+-	github.com/spf13/cobra v1.7.0
++	github.com/spf13/cobra v1.8.1
++	github.com/google/uuid v1.6.0
+ 	github.com/stretchr/testify v1.9.0
+ )
+`
+	changes, err := diffparse.ParseGitDiff(raw)
+	require.NoError(t, err)
+
+	got := extractDependencyChanges(changes)
+	require.Len(t, got, 2)
+	assert.Equal(t, "github.com/spf13/cobra", got[0].Name)
+	assert.Equal(t, "upgraded", dependencyChangeVerb(got[0].OldVersion, got[0].NewVersion))
+	assert.Equal(t, dependencyChange{Manifest: "go.mod", Name: "github.com/google/uuid", NewVersion: "v1.6.0"}, got[1])
+
+	report := buildDependencyReviewReport(changes)
+	assert.Contains(t, report, "go.mod: added `github.com/google/uuid` v1.6.0")
+	assert.Contains(t, report, "go.mod: upgraded `github.com/spf13/cobra` v1.7.0 -> v1.8.1")
+	assert.Contains(t, report, "typosquats")
+}
+
+func TestAppendDependencyReviewGuidelines_SkipsNonManifestChanges(t *testing.T) {
+	changes := []diffparse.FileChange{{NewName: "main.go"}}
+	assert.Equal(t, "base", appendDependencyReviewGuidelines("base", changes, true))
+
+	lock := []diffparse.FileChange{{NewName: "yarn.lock", Stats: diffparse.DiffStats{Additions: 4, Deletions: 2}}}
+	assert.Equal(t, "base", appendDependencyReviewGuidelines("base", lock, false))
+	assert.Contains(t, appendDependencyReviewGuidelines("base", lock, true), "lockfiles changed: yarn.lock (+4/-2)")
+}
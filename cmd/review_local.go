@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	config "github.com/sanix-darker/prev/internal/config"
+	core "github.com/sanix-darker/prev/internal/core"
+	"github.com/sanix-darker/prev/internal/diffparse"
+	handlers "github.com/sanix-darker/prev/internal/handlers"
+	"github.com/sanix-darker/prev/internal/vcs"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(newReviewLocalCmd())
+}
+
+// localReviewSettings holds the resolved prompt settings for review-local.
+type localReviewSettings struct {
+	Strictness   string
+	Persona      string
+	Language     string
+	RepoContext  string
+	Nitpick      int
+	SerenaMode   string
+	ContextLines int
+	MaxTokens    int
+	Model        string
+	EnrichOpts   diffparse.EnrichOptions
+	Guidelines   string
+	Conventions  []string
+}
+
+func newReviewLocalCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "review-local <base> <head>",
+		Short:   "Review a local branch range (base...head) without a VCS provider",
+		Example: "prev review-local main feature/login\nprev review-local origin/main HEAD --serena off --review-passes 2",
+		Args:    cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			conf := config.NewDefaultConfig()
+			applyFlags(cmd, &conf)
+
+			base, head := args[0], args[1]
+			repoPath, _ := cmd.Flags().GetString("repo")
+			if strings.TrimSpace(repoPath) == "" {
+				repoPath = resolveMRRepoPath()
+			}
+
+			strictness := resolveMRStringSetting(
+				cmd, "strictness", conf,
+				[]string{"review.strictness", "strictness"},
+				conf.Strictness,
+			)
+			nitpick := resolveMRIntSetting(
+				cmd, "nitpick", conf,
+				[]string{"review.nitpick"},
+				0,
+			)
+			reviewPasses := resolveMRIntSetting(
+				cmd, "review-passes", conf,
+				[]string{"review.passes"},
+				0,
+			)
+			if reviewPasses <= 0 {
+				reviewPasses = 1
+			}
+			if reviewPasses > 6 {
+				reviewPasses = 6
+			}
+			reviewGuidelines := ""
+			var conventions []string
+			if conf.Viper != nil {
+				reviewGuidelines = strings.TrimSpace(conf.Viper.GetString("review.guidelines"))
+				conventions = resolveMRStringSliceSetting(conf, []string{"review.conventions.labels"}, defaultConventionLabels)
+			}
+			settings := localReviewSettings{
+				Strictness: strictness,
+				Persona: resolveMRStringSetting(
+					cmd, "persona", conf,
+					[]string{"review.persona"},
+					"",
+				),
+				Language: resolveMRStringSetting(
+					cmd, "language", conf,
+					[]string{"review.output_language"},
+					"",
+				),
+				RepoContext: repoContextSection(
+					repoPath,
+					resolveMRStringSetting(cmd, "repo-context-file", conf, []string{"review.repo_context_file"}, ""),
+					resolveMRIntSetting(cmd, "repo-context-max-chars", conf, []string{"review.repo_context_max_chars"}, defaultRepoContextMaxChars),
+				),
+				Nitpick: normalizeNitpickFromStrictness(nitpick, strictness),
+				SerenaMode: resolveMRStringSetting(
+					cmd, "serena", conf,
+					[]string{"review.serena_mode", "serena_mode"},
+					"auto",
+				),
+				ContextLines: resolveMRIntSetting(
+					cmd, "context", conf,
+					[]string{"review.context_lines"},
+					10,
+				),
+				MaxTokens: resolveMRIntSetting(
+					cmd, "max-tokens", conf,
+					[]string{"review.max_tokens"},
+					80000,
+				),
+				Model: reviewModelName(conf),
+				EnrichOpts: resolveEnrichOptions(conf, resolveMRStringSetting(
+					cmd, "budget-strategy", conf,
+					[]string{"review.budget_strategy"},
+					diffparse.BudgetReduceContext,
+				)),
+				Guidelines: mergeGuidelines(
+					reviewGuidelines,
+					repoGuidelineSection(guidelineRootFromRepoPath(repoPath)),
+				),
+				Conventions: conventions,
+			}
+			fmt.Printf("Review settings: strictness=%s nitpick=%d passes=%d serena=%s context=%d max_tokens=%d budget_strategy=%s\n",
+				settings.Strictness, settings.Nitpick, reviewPasses, settings.SerenaMode,
+				settings.ContextLines, settings.MaxTokens, settings.EnrichOpts.BudgetStrategy)
+
+			review, err := buildLocalReview(repoPath, base, head, settings)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("Reviewing %s...%s\n", base, head)
+			fmt.Printf("Files changed: %d\n\n", len(review.Changes))
+
+			passMode := normalizePassMode(resolveMRStringSetting(
+				cmd, "pass-mode", conf,
+				[]string{"review.pass_mode"},
+				passModeRefine,
+			))
+			runReviewPassesDryRun(conf, review.Prompt, reviewPasses, reviewPassOptions{
+				Mode:         passMode,
+				SystemPrompt: resolveMRStringSetting(cmd, "", conf, []string{"review.system_prompt"}, ""),
+				EarlyStopOnClean: resolveMRBoolSetting(
+					cmd, "early-stop-on-clean", conf,
+					[]string{"review.early_stop_on_clean"},
+					false,
+				),
+			}, "")
+		},
+	}
+
+	cmd.Flags().String("repo", "", "Path to the local git repository (default: CI_PROJECT_DIR or current directory)")
+	cmd.Flags().Int("nitpick", 0, "Nitpick level 1-10 (overrides strictness preset)")
+	cmd.Flags().String("persona", "", "Reviewer persona: "+strings.Join(core.ReviewPersonaNames(), ", ")+", or custom text")
+	cmd.Flags().String("language", "", "Write summaries and finding messages in this language (e.g. French); KIND/SEVERITY tokens stay English")
+	cmd.Flags().String("repo-context-file", "", "Repository doc (e.g. ARCHITECTURE.md) whose excerpt is added to the prompt as background")
+	cmd.Flags().Int("repo-context-max-chars", defaultRepoContextMaxChars, "Maximum characters of --repo-context-file included in the prompt")
+	cmd.Flags().Int("review-passes", 1, "Number of review passes (1-6)")
+	cmd.Flags().String("pass-mode", passModeRefine, "Multi-pass mode: refine or ensemble")
+	cmd.Flags().Bool("early-stop-on-clean", false, "In refine mode, stop once two consecutive passes find no issues")
+	cmd.Flags().String("serena", "auto", "Serena mode: auto, on, off")
+	cmd.Flags().Int("context", 10, "Number of surrounding context lines for review")
+	cmd.Flags().Int("max-tokens", 80000, "Maximum tokens for the enriched diff context")
+	cmd.Flags().String("budget-strategy", diffparse.BudgetReduceContext, "Over-budget strategy: reduce_context, drop_lowest_priority_files, summarize_large_files")
+
+	return cmd
+}
+
+// buildLocalReview diffs base...head in repoPath and builds the same review
+// prompt an MR review would, using the ref names in place of MR metadata.
+func buildLocalReview(repoPath, base, head string, settings localReviewSettings) (*handlers.MRReview, error) {
+	raw, err := core.GetGitDiffForRefs(repoPath, base, head)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff %s...%s: %w", base, head, err)
+	}
+	changes, err := diffparse.ParseGitDiff(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse diff: %w", err)
+	}
+	changes = diffparse.FilterTextChanges(changes)
+	changes, _ = applyPrevIgnore(repoPath, changes)
+	if len(changes) == 0 {
+		return nil, fmt.Errorf("no reviewable changes between %s and %s", base, head)
+	}
+
+	review := &handlers.MRReview{
+		MR: &vcs.MergeRequest{
+			Title:        fmt.Sprintf("Local review %s...%s", base, head),
+			SourceBranch: head,
+			TargetBranch: base,
+		},
+		Changes: changes,
+	}
+	formattedDiffs, err := buildFormattedDiffsForRepo(
+		review, repoPath,
+		settings.SerenaMode, settings.ContextLines, clampDiffBudgetToModel(settings.Model, diffTokenBudget(settings.MaxTokens, settings.RepoContext)), settings.EnrichOpts,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	conventions := settings.Conventions
+	if len(conventions) == 0 {
+		conventions = defaultConventionLabels
+	}
+	review.Prompt = core.BuildMRReviewPromptWithOptions(
+		review.MR.Title,
+		"",
+		head,
+		base,
+		formattedDiffs,
+		settings.Strictness,
+		settings.Nitpick,
+		conventions,
+		settings.Guidelines,
+	)
+	review.Prompt = core.ApplyReviewPersona(review.Prompt, settings.Persona)
+	review.Prompt = applyRepoContext(review.Prompt, settings.RepoContext)
+	review.Prompt = appendLineAnchorInstructions(review.Prompt)
+	review.Prompt = core.ApplyOutputLanguage(review.Prompt, settings.Language)
+	return review, nil
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/diffparse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupLocalReviewRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@test.com",
+			"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@test.com",
+		)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v failed: %s", args, strings.TrimSpace(string(out)))
+	}
+
+	git("init", "-b", "main")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.go"), []byte("package app\n\nfunc a() {}\n"), 0644))
+	git("add", ".")
+	git("commit", "-m", "base")
+	git("checkout", "-b", "feature")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.go"), []byte("package app\n\nfunc a() { panic(\"todo\") }\n"), 0644))
+	git("commit", "-am", "feature change")
+	return dir
+}
+
+func TestBuildLocalReview_BuildsPromptFromRefRange(t *testing.T) {
+	dir := setupLocalReviewRepo(t)
+
+	review, err := buildLocalReview(dir, "main", "feature", localReviewSettings{
+		Strictness:   "normal",
+		SerenaMode:   "off",
+		ContextLines: 3,
+		MaxTokens:    80000,
+		EnrichOpts:   diffparse.EnrichOptions{BudgetStrategy: diffparse.BudgetReduceContext},
+	})
+	require.NoError(t, err)
+	require.Len(t, review.Changes, 1)
+	assert.Equal(t, "app.go", review.Changes[0].NewName)
+	assert.Equal(t, "main", review.MR.TargetBranch)
+	assert.Equal(t, "feature", review.MR.SourceBranch)
+	assert.Contains(t, review.Prompt, "app.go")
+	assert.Contains(t, review.Prompt, `panic("todo")`)
+}
+
+func TestBuildLocalReview_NoChangesIsError(t *testing.T) {
+	dir := setupLocalReviewRepo(t)
+
+	_, err := buildLocalReview(dir, "feature", "feature", localReviewSettings{SerenaMode: "off"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no reviewable changes")
+}
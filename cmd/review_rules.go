@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sanix-darker/prev/internal/config"
+	"github.com/sanix-darker/prev/internal/core"
+	"github.com/sanix-darker/prev/internal/diffparse"
+)
+
+// deterministicRule is a regex checked against every added diff line.
+// Languages restricts it to files whose diffparse.DetectLanguage name or
+// extension (without the dot) is listed; empty means every file.
+type deterministicRule struct {
+	Pattern   string
+	Severity  string
+	Message   string
+	Languages []string
+	re        *regexp.Regexp
+}
+
+// builtinDeterministicRules are always active; review.deterministic_rules
+// entries are appended to them.
+var builtinDeterministicRules = mustCompileDeterministicRules([]deterministicRule{
+	{
+		Pattern:  `(?i)json_dencode`,
+		Severity: "HIGH",
+		Message:  "Typo `json_dencode` likely intended as `json_encode`; this will trigger undefined function errors at runtime.",
+	},
+})
+
+func mustCompileDeterministicRules(rules []deterministicRule) []deterministicRule {
+	for i := range rules {
+		rules[i].re = regexp.MustCompile(rules[i].Pattern)
+	}
+	return rules
+}
+
+// loadDeterministicRules returns the built-in rules plus the compiled
+// review.deterministic_rules list:
+//
+//	deterministic_rules:
+//	  - pattern: '\bvar_dump\('
+//	    severity: medium
+//	    message: "Debug output left in code."
+//	    languages: [php]
+func loadDeterministicRules(conf config.Config) ([]deterministicRule, error) {
+	rules := append([]deterministicRule(nil), builtinDeterministicRules...)
+	if conf.Viper == nil {
+		return rules, nil
+	}
+	raw, ok := conf.Viper.Get("review.deterministic_rules")
+	if !ok || raw == nil {
+		return rules, nil
+	}
+	entries, ok := raw.([]interface{})
+	if !ok {
+		return rules, fmt.Errorf("review.deterministic_rules must be a list of {pattern, severity, message, languages}")
+	}
+	for i, e := range entries {
+		fields := stringKeyedMap(e)
+		if fields == nil {
+			return rules, fmt.Errorf("review.deterministic_rules[%d] must be a mapping", i)
+		}
+		r := deterministicRule{
+			Pattern:  strings.TrimSpace(fmt.Sprint(valueOrEmpty(fields["pattern"]))),
+			Severity: strings.ToUpper(strings.TrimSpace(fmt.Sprint(valueOrEmpty(fields["severity"])))),
+			Message:  strings.TrimSpace(fmt.Sprint(valueOrEmpty(fields["message"]))),
+		}
+		if r.Pattern == "" {
+			return rules, fmt.Errorf("review.deterministic_rules[%d].pattern is required", i)
+		}
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return rules, fmt.Errorf("review.deterministic_rules[%d].pattern %q is not a valid regexp: %v", i, r.Pattern, err)
+		}
+		r.re = re
+		if r.Severity == "" {
+			r.Severity = "MEDIUM"
+		} else if severityRank(r.Severity) == 0 {
+			return rules, fmt.Errorf("review.deterministic_rules[%d].severity must be one of: critical, high, medium, low", i)
+		}
+		if r.Message == "" {
+			r.Message = fmt.Sprintf("Line matches deterministic rule `%s`.", r.Pattern)
+		}
+		switch langs := fields["languages"].(type) {
+		case []interface{}:
+			for _, l := range langs {
+				if s := normalizeRuleLanguage(fmt.Sprint(l)); s != "" {
+					r.Languages = append(r.Languages, s)
+				}
+			}
+		case string:
+			for _, l := range strings.Split(langs, ",") {
+				if s := normalizeRuleLanguage(l); s != "" {
+					r.Languages = append(r.Languages, s)
+				}
+			}
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func valueOrEmpty(v interface{}) interface{} {
+	if v == nil {
+		return ""
+	}
+	return v
+}
+
+func normalizeRuleLanguage(l string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(l)), ".")
+}
+
+// appliesTo reports whether the rule checks filePath.
+func (r deterministicRule) appliesTo(filePath string) bool {
+	if len(r.Languages) == 0 {
+		return true
+	}
+	lang := diffparse.DetectLanguage(filePath)
+	ext := strings.TrimPrefix(strings.ToLower(path.Ext(filePath)), ".")
+	for _, l := range r.Languages {
+		if l == lang || l == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// detectRuleFindings runs rules against the added lines of c, reporting each
+// rule at most once per line.
+func detectRuleFindings(c diffparse.FileChange, filePath string, rules []deterministicRule) []core.FileComment {
+	var active []int
+	for i, r := range rules {
+		if r.re != nil && r.appliesTo(filePath) {
+			active = append(active, i)
+		}
+	}
+	if len(active) == 0 {
+		return nil
+	}
+	var out []core.FileComment
+	seen := map[string]struct{}{}
+	for _, h := range c.Hunks {
+		for _, l := range h.Lines {
+			if l.Type != diffparse.LineAdded {
+				continue
+			}
+			for _, i := range active {
+				r := rules[i]
+				if !r.re.MatchString(l.Content) {
+					continue
+				}
+				line := l.NewLineNo
+				if line <= 0 {
+					line = h.NewStart
+				}
+				key := strconv.Itoa(line) + "|" + r.Pattern
+				if _, ok := seen[key]; ok {
+					continue
+				}
+				seen[key] = struct{}{}
+				out = append(out, core.FileComment{
+					FilePath: filePath,
+					Line:     line,
+					Kind:     "ISSUE",
+					Severity: r.Severity,
+					Message:  r.Message,
+				})
+			}
+		}
+	}
+	return out
+}
+
+// loadSecretScanner builds the secret detector from
+// review.secret_entropy_threshold and review.secret_allowlist. An invalid
+// allowlist is reported and ignored, so secrets are still scanned.
+func loadSecretScanner(conf config.Config) (*core.SecretScanner, error) {
+	if conf.Viper == nil {
+		return core.NewSecretScanner(0, "")
+	}
+	return core.NewSecretScanner(
+		conf.Viper.GetFloat64("review.secret_entropy_threshold"),
+		conf.Viper.GetString("review.secret_allowlist"),
+	)
+}
+
+// detectSecretLeaks runs scanner on every added line of changes.
+func detectSecretLeaks(changes []diffparse.FileChange, scanner *core.SecretScanner) []core.FileComment {
+	if scanner == nil {
+		return nil
+	}
+	var out []core.FileComment
+	for _, c := range changes {
+		filePath := strings.TrimSpace(c.NewName)
+		if filePath == "" || c.IsDeleted {
+			continue
+		}
+		for _, h := range c.Hunks {
+			for _, l := range h.Lines {
+				if l.Type != diffparse.LineAdded {
+					continue
+				}
+				line := l.NewLineNo
+				if line <= 0 {
+					line = h.NewStart
+				}
+				if fc, ok := scanner.ScanLine(filePath, line, l.Content); ok {
+					out = append(out, fc)
+				}
+			}
+		}
+	}
+	return out
+}
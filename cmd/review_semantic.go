@@ -79,6 +79,35 @@ func semanticJaccardScore(a, b string) float64 {
 	return float64(intersection) / float64(union)
 }
 
+// trigramSimilarity is the Jaccard index of the padded character trigrams of
+// the keywords in a and b.
+func trigramSimilarity(a, b string) float64 {
+	ta := keywordTrigrams(a)
+	tb := keywordTrigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	intersection := 0
+	for g := range ta {
+		if _, ok := tb[g]; ok {
+			intersection++
+		}
+	}
+	union := len(ta) + len(tb) - intersection
+	return float64(intersection) / float64(union)
+}
+
+func keywordTrigrams(s string) map[string]struct{} {
+	out := map[string]struct{}{}
+	for tok := range toKeywordSet(s) {
+		padded := " " + tok + " "
+		for i := 0; i+3 <= len(padded); i++ {
+			out[padded[i:i+3]] = struct{}{}
+		}
+	}
+	return out
+}
+
 func semanticMessageScore(aMessage, aSymbol, bMessage, bSymbol string) int {
 	score := semanticKeywordOverlap(aMessage, bMessage) * 10
 	if semanticBehaviorID(aMessage) == semanticBehaviorID(bMessage) {
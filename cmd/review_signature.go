@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/sanix-darker/prev/internal/core"
+	"github.com/sanix-darker/prev/internal/diffparse"
+)
+
+// exportedDecl is one exported declaration found on a diff line.
+type exportedDecl struct {
+	Key       string // receiver-qualified name, e.g. "(*Server).Start"
+	Kind      string // "function", "method", "type", ...
+	Signature string // whitespace-normalized text after the name
+	Line      int
+}
+
+var (
+	goFuncDeclRe = regexp.MustCompile(`^func\s*(?:\(\s*(?:\w+\s+)?(\*?\s*\w+)(?:\[[^\]]*\])?\s*\))?\s*(\w+)(.*)$`)
+	goTypeDeclRe = regexp.MustCompile(`^type\s+(\w+)(.*)$`)
+	tsExportRe   = regexp.MustCompile(`^export\s+(?:default\s+)?(?:declare\s+)?(?:abstract\s+)?(?:async\s+)?(function\*?|interface|type|class|const|let|enum)\s+(\w+)(.*)$`)
+)
+
+// detectSignatureChanges flags exported Go functions/methods/types and
+// exported TypeScript declarations whose declaration line was rewritten in
+// the diff: the same symbol appears on a removed and an added line with a
+// different signature. Unexported symbols and pure moves are ignored.
+func detectSignatureChanges(c diffparse.FileChange, filePath string) []core.FileComment {
+	parse := signatureParser(filePath)
+	if parse == nil {
+		return nil
+	}
+	removed := map[string][]string{}
+	var added []exportedDecl
+	for _, h := range c.Hunks {
+		for _, l := range h.Lines {
+			switch l.Type {
+			case diffparse.LineDeleted:
+				if d, ok := parse(l.Content); ok {
+					removed[d.Key] = append(removed[d.Key], d.Signature)
+				}
+			case diffparse.LineAdded:
+				if d, ok := parse(l.Content); ok {
+					d.Line = l.NewLineNo
+					if d.Line <= 0 {
+						d.Line = h.NewStart
+					}
+					added = append(added, d)
+				}
+			}
+		}
+	}
+
+	var out []core.FileComment
+	for _, d := range added {
+		prev, ok := removed[d.Key]
+		if !ok || slices.Contains(prev, d.Signature) {
+			continue
+		}
+		severity := "HIGH"
+		if d.Kind != "function" && d.Kind != "method" {
+			severity = "MEDIUM"
+		}
+		out = append(out, core.FileComment{
+			FilePath: filePath,
+			Line:     d.Line,
+			Kind:     "ISSUE",
+			Severity: severity,
+			Message: fmt.Sprintf("Potential breaking change: signature of exported %s `%s` changed from `%s` to `%s`; callers outside this diff may no longer compile.",
+				d.Kind, d.Key, prev[0], d.Signature),
+		})
+	}
+	return out
+}
+
+// signatureParser returns the declaration parser for filePath's language, or
+// nil when the file is not checked.
+func signatureParser(filePath string) func(string) (exportedDecl, bool) {
+	base := strings.ToLower(path.Base(filePath))
+	switch {
+	case strings.HasSuffix(base, ".go") && !strings.HasSuffix(base, "_test.go"):
+		return parseGoExportedDecl
+	case strings.HasSuffix(base, ".d.ts"), strings.HasSuffix(base, ".ts"), strings.HasSuffix(base, ".tsx"):
+		if strings.Contains(base, ".test.") || strings.Contains(base, ".spec.") {
+			return nil
+		}
+		return parseTSExportedDecl
+	}
+	return nil
+}
+
+func parseGoExportedDecl(line string) (exportedDecl, bool) {
+	text := strings.TrimSpace(line)
+	if m := goFuncDeclRe.FindStringSubmatch(text); m != nil {
+		name := m[2]
+		if !isGoExported(name) {
+			return exportedDecl{}, false
+		}
+		if recv := strings.ReplaceAll(m[1], " ", ""); recv != "" {
+			if !isGoExported(strings.TrimPrefix(recv, "*")) {
+				return exportedDecl{}, false
+			}
+			return exportedDecl{Key: "(" + recv + ")." + name, Kind: "method", Signature: normalizeSignature(m[3])}, true
+		}
+		return exportedDecl{Key: name, Kind: "function", Signature: normalizeSignature(m[3])}, true
+	}
+	if m := goTypeDeclRe.FindStringSubmatch(text); m != nil && isGoExported(m[1]) {
+		return exportedDecl{Key: m[1], Kind: "type", Signature: normalizeSignature(m[2])}, true
+	}
+	return exportedDecl{}, false
+}
+
+func parseTSExportedDecl(line string) (exportedDecl, bool) {
+	m := tsExportRe.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return exportedDecl{}, false
+	}
+	kind := strings.TrimSuffix(m[1], "*")
+	sig := m[3]
+	if kind == "let" || kind == "const" {
+		kind = "binding"
+		sig = bindingSignature(sig)
+	}
+	return exportedDecl{Key: m[2], Kind: kind, Signature: normalizeSignature(sig)}, true
+}
+
+// bindingSignature reduces the text after an exported const/let name to its
+// API surface: the type annotation, plus the parameter list when the value
+// is an arrow function. A changed value such as `TIMEOUT = 30` -> `60` is
+// not an API change.
+func bindingSignature(rest string) string {
+	eq := -1
+	for i := 0; i < len(rest); i++ {
+		if rest[i] == '=' && (i+1 == len(rest) || (rest[i+1] != '>' && rest[i+1] != '=')) && (i == 0 || !strings.ContainsRune("=!<>", rune(rest[i-1]))) {
+			eq = i
+			break
+		}
+	}
+	if eq < 0 {
+		return rest
+	}
+	annotation := rest[:eq]
+	value := strings.TrimSpace(rest[eq+1:])
+	value = strings.TrimSpace(strings.TrimPrefix(value, "async "))
+	if arrow := strings.Index(value, "=>"); arrow > 0 && strings.HasPrefix(value, "(") {
+		return annotation + " = " + value[:arrow] + "=>"
+	}
+	return annotation
+}
+
+func isGoExported(name string) bool {
+	return name != "" && name[0] >= 'A' && name[0] <= 'Z'
+}
+
+// normalizeSignature collapses whitespace and drops a trailing body opener
+// and line comment so formatting-only edits do not count as changes.
+func normalizeSignature(s string) string {
+	if i := strings.Index(s, "//"); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.TrimSpace(s)
+	s = strings.TrimSpace(strings.TrimSuffix(s, "{"))
+	s = strings.TrimSpace(strings.TrimSuffix(s, ";"))
+	return strings.Join(strings.Fields(s), " ")
+}
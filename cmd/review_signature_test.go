@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/diffparse"
+	"github.com/stretchr/testify/assert"
+)
+
+func signatureChange(name string, lines ...diffparse.DiffLine) diffparse.FileChange {
+	return diffparse.FileChange{
+		NewName: name,
+		Hunks:   []diffparse.Hunk{{NewStart: 10, Lines: lines}},
+	}
+}
+
+func TestDetectSignatureChanges_GoExportedOnly(t *testing.T) {
+	changes := []diffparse.FileChange{signatureChange("pkg/client.go",
+		diffparse.DiffLine{Type: diffparse.LineDeleted, OldLineNo: 10, Content: "func Fetch(ctx context.Context, id string) error {"},
+		diffparse.DiffLine{Type: diffparse.LineAdded, NewLineNo: 10, Content: "func Fetch(ctx context.Context, id int64) error {"},
+		diffparse.DiffLine{Type: diffparse.LineDeleted, OldLineNo: 20, Content: "func fetchRaw(id string) error {"},
+		diffparse.DiffLine{Type: diffparse.LineAdded, NewLineNo: 20, Content: "func fetchRaw(id int64) error {"},
+	)}
+
+	got := detectDeterministicFindings(changes)
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, "pkg/client.go", got[0].FilePath)
+		assert.Equal(t, 10, got[0].Line)
+		assert.Equal(t, "HIGH", got[0].Severity)
+		assert.Contains(t, got[0].Message, "Potential breaking change")
+		assert.Contains(t, got[0].Message, "`Fetch`")
+	}
+}
+
+func TestDetectSignatureChanges_IgnoresFormattingAndUnexportedReceivers(t *testing.T) {
+	changes := []diffparse.FileChange{signatureChange("pkg/server.go",
+		diffparse.DiffLine{Type: diffparse.LineDeleted, Content: "func (s *Server) Start(addr string) error {"},
+		diffparse.DiffLine{Type: diffparse.LineAdded, NewLineNo: 10, Content: "func (s  *Server) Start(addr  string) error { // listen"},
+		diffparse.DiffLine{Type: diffparse.LineDeleted, Content: "func (h *handler) Serve(w io.Writer) {"},
+		diffparse.DiffLine{Type: diffparse.LineAdded, NewLineNo: 11, Content: "func (h *handler) Serve(w io.Writer, r *Request) {"},
+	)}
+	assert.Empty(t, detectSignatureChanges(changes[0], "pkg/server.go"))
+}
+
+func TestDetectSignatureChanges_TSExportedType(t *testing.T) {
+	c := signatureChange("src/api.ts",
+		diffparse.DiffLine{Type: diffparse.LineDeleted, Content: "export type Id = string;"},
+		diffparse.DiffLine{Type: diffparse.LineAdded, NewLineNo: 3, Content: "export type Id = string | number;"},
+		diffparse.DiffLine{Type: diffparse.LineDeleted, Content: "type Local = string;"},
+		diffparse.DiffLine{Type: diffparse.LineAdded, NewLineNo: 4, Content: "type Local = number;"},
+	)
+	got := detectSignatureChanges(c, "src/api.ts")
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, 3, got[0].Line)
+		assert.Equal(t, "MEDIUM", got[0].Severity)
+		assert.Contains(t, got[0].Message, "exported type `Id`")
+	}
+}
+
+func TestDetectSignatureChanges_TSBindingValueIsNotAPIChange(t *testing.T) {
+	c := signatureChange("src/config.ts",
+		diffparse.DiffLine{Type: diffparse.LineDeleted, Content: "export const TIMEOUT = 30;"},
+		diffparse.DiffLine{Type: diffparse.LineAdded, NewLineNo: 1, Content: "export const TIMEOUT = 60;"},
+		diffparse.DiffLine{Type: diffparse.LineDeleted, Content: "export let retries: number = 3;"},
+		diffparse.DiffLine{Type: diffparse.LineAdded, NewLineNo: 2, Content: "export let retries: number = 5;"},
+		diffparse.DiffLine{Type: diffparse.LineDeleted, Content: "export const limit: number = 10;"},
+		diffparse.DiffLine{Type: diffparse.LineAdded, NewLineNo: 3, Content: "export const limit: string = \"10\";"},
+		diffparse.DiffLine{Type: diffparse.LineDeleted, Content: "export const fetchUser = (id: string) => api.get(id);"},
+		diffparse.DiffLine{Type: diffparse.LineAdded, NewLineNo: 4, Content: "export const fetchUser = (id: number) => api.get(id);"},
+	)
+	got := detectSignatureChanges(c, "src/config.ts")
+	if assert.Len(t, got, 2, "only the annotation and arrow parameters are API") {
+		assert.Equal(t, 3, got[0].Line)
+		assert.Contains(t, got[0].Message, "`limit`")
+		assert.Equal(t, 4, got[1].Line)
+		assert.Contains(t, got[1].Message, "`fetchUser`")
+	}
+}
@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sanix-darker/prev/internal/config"
+	"github.com/sanix-darker/prev/internal/webhook"
+	"github.com/spf13/cobra"
+)
+
+const defaultWebhookReviewTimeout = 20 * time.Minute
+
+func init() {
+	rootCmd.AddCommand(newServeCmd())
+}
+
+func newServeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Listen for GitLab/GitHub webhooks and review MRs on push or comment",
+		Example: `PREV_WEBHOOK_GITLAB_SECRET=s3cret prev serve --addr :8080
+prev serve --addr :8080 --github-secret "$WEBHOOK_SECRET"`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			conf := config.NewDefaultConfig()
+			applyFlags(cmd, &conf)
+
+			addr, _ := cmd.Flags().GetString("addr")
+			gitlabSecret := flagOrEnv(cmd, "gitlab-secret", "PREV_WEBHOOK_GITLAB_SECRET")
+			githubSecret := flagOrEnv(cmd, "github-secret", "PREV_WEBHOOK_GITHUB_SECRET")
+			if gitlabSecret == "" && githubSecret == "" {
+				fmt.Fprintln(os.Stderr, "Error: set --gitlab-secret and/or --github-secret (or PREV_WEBHOOK_GITLAB_SECRET / PREV_WEBHOOK_GITHUB_SECRET); unsigned webhooks are rejected")
+				os.Exit(1)
+			}
+			exe, err := os.Executable()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: cannot locate the prev binary: %v\n", err)
+				os.Exit(1)
+			}
+			timeout, _ := cmd.Flags().GetDuration("review-timeout")
+			reposDir, _ := cmd.Flags().GetString("repos-dir")
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			dispatcher := newWebhookDispatcher(ctx, func(ctx context.Context, ev webhook.Event) error {
+				dir, cleanup, err := webhookReviewDir(reposDir, ev)
+				if err != nil {
+					return err
+				}
+				defer cleanup()
+				ctx, cancel := context.WithTimeout(ctx, timeout)
+				defer cancel()
+				c := exec.CommandContext(ctx, exe, webhookReviewArgs(ev)...)
+				c.Dir = dir
+				c.Env = append(os.Environ(), "CI_PROJECT_DIR="+dir)
+				c.Stdout = os.Stdout
+				c.Stderr = os.Stderr
+				return c.Run()
+			})
+
+			mux := http.NewServeMux()
+			mux.Handle("/webhook", &webhook.Handler{
+				GitLabSecret:  gitlabSecret,
+				GitHubSecret:  githubSecret,
+				MentionHandle: resolveMentionHandle(conf),
+				Dispatch:      dispatcher.dispatch,
+			})
+			mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				_ = server.Shutdown(shutdownCtx)
+			}()
+
+			fmt.Printf("Listening for webhooks on %s/webhook (gitlab=%t github=%t)\n", addr, gitlabSecret != "", githubSecret != "")
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			dispatcher.wait()
+		},
+	}
+
+	cmd.Flags().String("addr", ":8080", "Address to listen on")
+	cmd.Flags().String("gitlab-secret", "", "GitLab webhook secret token (or use PREV_WEBHOOK_GITLAB_SECRET env)")
+	cmd.Flags().String("github-secret", "", "GitHub webhook secret (or use PREV_WEBHOOK_GITHUB_SECRET env)")
+	cmd.Flags().Duration("review-timeout", defaultWebhookReviewTimeout, "Maximum duration of one triggered review")
+	cmd.Flags().String("repos-dir", "", "Directory holding a checkout of each project at <repos-dir>/<project path>; reviews of other projects run without a local checkout")
+	return cmd
+}
+
+// flagOrEnv returns the flag value, falling back to the environment variable.
+func flagOrEnv(cmd *cobra.Command, flag, env string) string {
+	if v, _ := cmd.Flags().GetString(flag); strings.TrimSpace(v) != "" {
+		return strings.TrimSpace(v)
+	}
+	return strings.TrimSpace(os.Getenv(env))
+}
+
+// webhookReviewArgs is the prev command line a review event runs.
+func webhookReviewArgs(ev webhook.Event) []string {
+	return []string{"mr", "review", ev.ProjectID, strconv.FormatInt(ev.MRIID, 10), "--vcs", ev.Provider}
+}
+
+// webhookReviewDir returns the directory a review of ev runs in. With
+// reposDir set and a checkout at <reposDir>/<project path>, that checkout is
+// used, so git diffs and context come from the MR's own repository. Otherwise
+// the review runs in an empty temporary directory, never in serve's working
+// directory, whose checkout belongs to some other project; the diff then
+// comes from the provider API. cleanup removes any temporary directory.
+func webhookReviewDir(reposDir string, ev webhook.Event) (dir string, cleanup func(), err error) {
+	if reposDir = strings.TrimSpace(reposDir); reposDir != "" {
+		rel := filepath.FromSlash(strings.Trim(ev.ProjectID, "/"))
+		if rel != "" && filepath.IsLocal(rel) {
+			candidate := filepath.Join(reposDir, rel)
+			if info, serr := os.Stat(candidate); serr == nil && info.IsDir() {
+				return candidate, func() {}, nil
+			}
+		}
+	}
+	tmp, err := os.MkdirTemp("", "prev-webhook-")
+	if err != nil {
+		return "", nil, fmt.Errorf("create review directory: %w", err)
+	}
+	return tmp, func() { _ = os.RemoveAll(tmp) }, nil
+}
+
+// webhookDispatcher runs one review per MR at a time. An event for an MR
+// whose review is still running queues exactly one follow-up run, so a burst
+// of pushes collapses into a review of the latest head.
+type webhookDispatcher struct {
+	ctx     context.Context
+	run     func(ctx context.Context, ev webhook.Event) error
+	mu      sync.Mutex
+	running map[string]bool
+	pending map[string]*webhook.Event
+	cancel  map[string]context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func newWebhookDispatcher(ctx context.Context, run func(ctx context.Context, ev webhook.Event) error) *webhookDispatcher {
+	return &webhookDispatcher{
+		ctx:     ctx,
+		run:     run,
+		running: make(map[string]bool),
+		pending: make(map[string]*webhook.Event),
+		cancel:  make(map[string]context.CancelFunc),
+	}
+}
+
+// dispatch acts on ev without blocking. Pause starts no run, since later
+// reviews read the comment to stay paused, but it cancels a review of that
+// MR that is already running and drops its queued rerun.
+func (d *webhookDispatcher) dispatch(ev webhook.Event) {
+	target := fmt.Sprintf("%s %s!%d", ev.Provider, ev.ProjectID, ev.MRIID)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if ev.Command == webhook.CommandPause {
+		delete(d.pending, target)
+		if cancel, ok := d.cancel[target]; ok {
+			cancel()
+			fmt.Printf("Webhook: reviews paused on %s by %s; cancelled the running review\n", target, ev.Author)
+			return
+		}
+		fmt.Printf("Webhook: reviews paused on %s by %s\n", target, ev.Author)
+		return
+	}
+	if d.running[target] {
+		d.pending[target] = &ev
+		fmt.Printf("Webhook: review of %s already running; queued one more\n", target)
+		return
+	}
+	d.running[target] = true
+	d.wg.Add(1)
+	go d.loop(target, ev)
+}
+
+func (d *webhookDispatcher) loop(target string, ev webhook.Event) {
+	defer d.wg.Done()
+	for {
+		fmt.Printf("Webhook: reviewing %s\n", target)
+		ctx, cancel := context.WithCancel(d.ctx)
+		d.mu.Lock()
+		d.cancel[target] = cancel
+		d.mu.Unlock()
+		if err := d.run(ctx, ev); err != nil {
+			if ctx.Err() != nil && d.ctx.Err() == nil {
+				fmt.Printf("Webhook: review of %s cancelled\n", target)
+			} else {
+				fmt.Fprintf(os.Stderr, "Warning: webhook review of %s failed: %v\n", target, err)
+			}
+		}
+		cancel()
+
+		d.mu.Lock()
+		delete(d.cancel, target)
+		next := d.pending[target]
+		if next == nil || d.ctx.Err() != nil {
+			delete(d.running, target)
+			delete(d.pending, target)
+			d.mu.Unlock()
+			return
+		}
+		delete(d.pending, target)
+		d.mu.Unlock()
+		ev = *next
+	}
+}
+
+// wait blocks until every running review has finished.
+func (d *webhookDispatcher) wait() {
+	d.wg.Wait()
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookReviewArgs(t *testing.T) {
+	ev := webhook.Event{Provider: "github", ProjectID: "org/repo", MRIID: 12, Command: webhook.CommandReview}
+	assert.Equal(t, []string{"mr", "review", "org/repo", "12", "--vcs", "github"}, webhookReviewArgs(ev))
+}
+
+func TestWebhookDispatcher_CoalescesRunsPerMR(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 10)
+	var mu sync.Mutex
+	var runs [][]string
+	d := newWebhookDispatcher(context.Background(), func(_ context.Context, ev webhook.Event) error {
+		mu.Lock()
+		runs = append(runs, webhookReviewArgs(ev))
+		mu.Unlock()
+		started <- struct{}{}
+		<-release
+		return nil
+	})
+
+	ev := webhook.Event{Provider: "gitlab", ProjectID: "grp/proj", MRIID: 3, Command: webhook.CommandReview}
+	d.dispatch(ev)
+	<-started
+	// Three more events while the first review runs collapse into one rerun.
+	d.dispatch(ev)
+	d.dispatch(webhook.Event{Provider: "gitlab", ProjectID: "grp/proj", MRIID: 3, Command: webhook.CommandResume})
+	d.dispatch(ev)
+	// Pause never starts a review.
+	d.dispatch(webhook.Event{Provider: "gitlab", ProjectID: "grp/proj", MRIID: 4, Command: webhook.CommandPause})
+
+	release <- struct{}{}
+	<-started
+	release <- struct{}{}
+	d.wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, runs, 2)
+}
+
+func TestWebhookDispatcher_PauseCancelsRunningReview(t *testing.T) {
+	started := make(chan struct{}, 10)
+	var mu sync.Mutex
+	var errs []error
+	d := newWebhookDispatcher(context.Background(), func(ctx context.Context, _ webhook.Event) error {
+		started <- struct{}{}
+		<-ctx.Done()
+		mu.Lock()
+		errs = append(errs, ctx.Err())
+		mu.Unlock()
+		return ctx.Err()
+	})
+
+	ev := webhook.Event{Provider: "gitlab", ProjectID: "grp/proj", MRIID: 3, Command: webhook.CommandReview}
+	d.dispatch(ev)
+	<-started
+	d.dispatch(ev) // queued rerun, dropped by the pause
+	d.dispatch(webhook.Event{Provider: "gitlab", ProjectID: "grp/proj", MRIID: 3, Command: webhook.CommandPause, Author: "alice"})
+	d.wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []error{context.Canceled}, errs)
+}
+
+func TestWebhookReviewDir(t *testing.T) {
+	repos := t.TempDir()
+	checkout := filepath.Join(repos, "grp", "proj")
+	require.NoError(t, os.MkdirAll(checkout, 0o755))
+
+	dir, cleanup, err := webhookReviewDir(repos, webhook.Event{ProjectID: "grp/proj"})
+	require.NoError(t, err)
+	cleanup()
+	assert.Equal(t, checkout, dir)
+	assert.DirExists(t, checkout, "a project checkout is never removed")
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	for _, project := range []string{"grp/other", "../escape"} {
+		dir, cleanup, err = webhookReviewDir(repos, webhook.Event{ProjectID: project})
+		require.NoError(t, err, project)
+		assert.NotEqual(t, cwd, dir, project)
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err, project)
+		assert.Empty(t, entries, "%s runs in an empty directory", project)
+		cleanup()
+		assert.NoDirExists(t, dir, project)
+	}
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// stopwatch accumulates wall time per named stage for --profile. A disabled
+// stopwatch records nothing, so call sites need no guards.
+type stopwatch struct {
+	enabled bool
+	order   []string
+	elapsed map[string]time.Duration
+	now     func() time.Time
+}
+
+func newStopwatch(enabled bool) *stopwatch {
+	return &stopwatch{enabled: enabled, elapsed: map[string]time.Duration{}, now: time.Now}
+}
+
+// start begins timing stage and returns the func that stops it. Timing the
+// same stage twice adds up, so retried or split stages report one total.
+func (s *stopwatch) start(stage string) func() {
+	if !s.enabled {
+		return func() {}
+	}
+	began := s.now()
+	return func() {
+		if _, seen := s.elapsed[stage]; !seen {
+			s.order = append(s.order, stage)
+		}
+		s.elapsed[stage] += s.now().Sub(began)
+	}
+}
+
+// summary renders the stages in the order they first finished, e.g.
+// "Timing: extract=1.2s enrich=3.4s passes=22.1s post=4.5s".
+func (s *stopwatch) summary() string {
+	parts := make([]string, 0, len(s.order))
+	for _, stage := range s.order {
+		parts = append(parts, fmt.Sprintf("%s=%.1fs", stage, s.elapsed[stage].Seconds()))
+	}
+	return "Timing: " + strings.Join(parts, " ")
+}
+
+// print writes the summary to w when profiling is on and any stage ran.
+func (s *stopwatch) print(w io.Writer) {
+	if !s.enabled || len(s.order) == 0 {
+		return
+	}
+	fmt.Fprintln(w, s.summary())
+}
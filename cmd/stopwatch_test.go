@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStopwatch_SummaryAccumulatesStages(t *testing.T) {
+	clock := time.Unix(0, 0)
+	sw := newStopwatch(true)
+	sw.now = func() time.Time { return clock }
+
+	for _, step := range []struct {
+		stage string
+		took  time.Duration
+	}{
+		{"extract", 1200 * time.Millisecond},
+		{"enrich", 3400 * time.Millisecond},
+		{"passes", 22 * time.Second},
+		{"post", 4500 * time.Millisecond},
+		{"passes", 100 * time.Millisecond},
+	} {
+		stop := sw.start(step.stage)
+		clock = clock.Add(step.took)
+		stop()
+	}
+
+	assert.Equal(t, "Timing: extract=1.2s enrich=3.4s passes=22.1s post=4.5s", sw.summary())
+}
+
+func TestStopwatch_DisabledPrintsNothing(t *testing.T) {
+	sw := newStopwatch(false)
+	sw.start("extract")()
+
+	var out bytes.Buffer
+	sw.print(&out)
+	assert.Empty(t, out.String())
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sanix-darker/prev/internal/common"
+	"github.com/spf13/cobra"
+)
+
+// vcsTokenEnv names the token environment variable of each VCS provider.
+// The same name with a _FILE suffix points at a file holding the token.
+var vcsTokenEnv = map[string]string{
+	"gitlab":      "GITLAB_TOKEN",
+	"github":      "GITHUB_TOKEN",
+	"azuredevops": "AZURE_DEVOPS_TOKEN",
+}
+
+// vcsTokenEnvSet reports whether a token for vcsName is supplied through
+// the environment, directly or as a file.
+func vcsTokenEnvSet(vcsName string) bool {
+	env := vcsTokenEnv[vcsName]
+	return env != "" && (os.Getenv(env) != "" || os.Getenv(env+"_FILE") != "")
+}
+
+// resolveVCSToken returns the token for vcsName from, in order: --gitlab-token,
+// --gitlab-token-file, --token-command, the provider's token variable and its
+// _FILE variant. Files and helper commands keep the secret out of the process
+// environment. An empty token means none was given this way.
+func resolveVCSToken(cmd *cobra.Command, vcsName string) (string, error) {
+	if token, _ := cmd.Flags().GetString("gitlab-token"); token != "" {
+		return token, nil
+	}
+	if path, _ := cmd.Flags().GetString("gitlab-token-file"); path != "" {
+		return readTokenFile(path)
+	}
+	if command, _ := cmd.Flags().GetString("token-command"); strings.TrimSpace(command) != "" {
+		return runTokenCommand(command)
+	}
+	env := vcsTokenEnv[vcsName]
+	if env == "" {
+		return "", nil
+	}
+	if token := os.Getenv(env); token != "" {
+		return token, nil
+	}
+	if path := os.Getenv(env + "_FILE"); path != "" {
+		return readTokenFile(path)
+	}
+	return "", nil
+}
+
+// readTokenFile reads a token from path, dropping surrounding whitespace
+// such as the trailing newline most editors and secret mounts add.
+func readTokenFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", path)
+	}
+	return token, nil
+}
+
+// runTokenCommand runs a --token-command credential helper.
+func runTokenCommand(command string) (string, error) {
+	token, err := common.RunSecretCommand(command)
+	if err != nil {
+		return "", fmt.Errorf("token command %w", err)
+	}
+	return token, nil
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTokenTestCmd() *cobra.Command {
+	cmd := &cobra.Command{Use: "x"}
+	cmd.Flags().String("gitlab-token", "", "")
+	cmd.Flags().String("gitlab-token-file", "", "")
+	cmd.Flags().String("token-command", "", "")
+	return cmd
+}
+
+func writeTokenFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func clearVCSTokenEnv(t *testing.T) {
+	t.Helper()
+	for _, env := range vcsTokenEnv {
+		t.Setenv(env, "")
+		t.Setenv(env+"_FILE", "")
+	}
+}
+
+func TestReadTokenFile_TrimsTrailingNewlines(t *testing.T) {
+	for _, content := range []string{"glpat-abc\n", "glpat-abc\r\n", "  glpat-abc\n\n"} {
+		token, err := readTokenFile(writeTokenFile(t, content))
+		require.NoError(t, err)
+		assert.Equal(t, "glpat-abc", token, "%q", content)
+	}
+}
+
+func TestReadTokenFile_Errors(t *testing.T) {
+	_, err := readTokenFile(writeTokenFile(t, "\n"))
+	assert.ErrorContains(t, err, "is empty")
+
+	_, err = readTokenFile(filepath.Join(t.TempDir(), "missing"))
+	assert.ErrorContains(t, err, "failed to read token file")
+}
+
+func TestResolveVCSToken_FileFlag(t *testing.T) {
+	clearVCSTokenEnv(t)
+	t.Setenv("GITLAB_TOKEN", "from-env")
+	cmd := newTokenTestCmd()
+	require.NoError(t, cmd.Flags().Set("gitlab-token-file", writeTokenFile(t, "from-file\n")))
+
+	token, err := resolveVCSToken(cmd, "gitlab")
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", token, "an explicit token file beats the env token")
+}
+
+func TestResolveVCSToken_EnvFilePerProvider(t *testing.T) {
+	clearVCSTokenEnv(t)
+	t.Setenv("GITHUB_TOKEN_FILE", writeTokenFile(t, "ghp-file\n"))
+
+	token, err := resolveVCSToken(newTokenTestCmd(), "github")
+	require.NoError(t, err)
+	assert.Equal(t, "ghp-file", token)
+	assert.True(t, vcsTokenEnvSet("github"))
+	assert.False(t, vcsTokenEnvSet("gitlab"))
+
+	token, err = resolveVCSToken(newTokenTestCmd(), "gitlab")
+	require.NoError(t, err)
+	assert.Empty(t, token)
+}
+
+func TestResolveVCSToken_TokenCommand(t *testing.T) {
+	clearVCSTokenEnv(t)
+	cmd := newTokenTestCmd()
+	require.NoError(t, cmd.Flags().Set("token-command", "printf 'helper-token\\n'"))
+
+	token, err := resolveVCSToken(cmd, "gitlab")
+	require.NoError(t, err)
+	assert.Equal(t, "helper-token", token)
+
+	require.NoError(t, cmd.Flags().Set("token-command", "exit 3"))
+	_, err = resolveVCSToken(cmd, "gitlab")
+	assert.ErrorContains(t, err, "token command failed")
+}
+
+func TestResolveVCSToken_FlagTokenWins(t *testing.T) {
+	clearVCSTokenEnv(t)
+	cmd := newTokenTestCmd()
+	require.NoError(t, cmd.Flags().Set("gitlab-token", "flag-token"))
+	require.NoError(t, cmd.Flags().Set("gitlab-token-file", filepath.Join(t.TempDir(), "missing")))
+
+	token, err := resolveVCSToken(cmd, "gitlab")
+	require.NoError(t, err)
+	assert.Equal(t, "flag-token", token)
+}
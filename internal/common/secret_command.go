@@ -0,0 +1,38 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// SecretCommandTimeout bounds a credential helper run, so a helper waiting on
+// a prompt nobody answers cannot hang prev.
+const SecretCommandTimeout = 30 * time.Second
+
+// RunSecretCommand runs a credential helper (api_key_command, --token-command)
+// through the shell and returns its trimmed stdout. The helper's stderr is
+// passed through so prompts and errors stay visible. Errors read as a
+// predicate for the caller to prefix, e.g. "token command failed: ...".
+func RunSecretCommand(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), SecretCommandTimeout)
+	defer cancel()
+	c := exec.CommandContext(ctx, "sh", "-c", command)
+	c.Stderr = os.Stderr
+	out, err := c.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("timed out after %s", SecretCommandTimeout)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed: %w", err)
+	}
+	secret := strings.TrimSpace(string(out))
+	if secret == "" {
+		return "", errors.New("printed nothing")
+	}
+	return secret, nil
+}
@@ -0,0 +1,20 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunSecretCommand(t *testing.T) {
+	secret, err := RunSecretCommand("echo ' s3cret '")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", secret)
+
+	_, err = RunSecretCommand("true")
+	assert.ErrorContains(t, err, "printed nothing")
+
+	_, err = RunSecretCommand("exit 3")
+	assert.ErrorContains(t, err, "failed: exit status 3")
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -46,3 +48,25 @@ func TestSetupStore_NoConfigFile(t *testing.T) {
 	v := setupStore(conf)
 	assert.NotNil(t, v)
 }
+
+func TestStore_GetStringMapString(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`review:
+  language_guidelines:
+    go: "Wrap errors with %w."
+    python: "Type-hint public functions."
+    nested:
+      deep: "skipped"
+`), 0o644))
+	s := NewStore()
+	require.NoError(t, s.LoadYAMLFile(path))
+
+	assert.Equal(t, map[string]string{
+		"go":     "Wrap errors with %w.",
+		"python": "Type-hint public functions.",
+	}, s.GetStringMapString("review.language_guidelines"))
+	assert.Empty(t, s.GetStringMapString("review.missing"))
+
+	s.Set("review.language_guidelines.rust", "Avoid unwrap.")
+	assert.Equal(t, "Avoid unwrap.", s.GetStringMapString("review.language_guidelines")["rust"])
+}
@@ -84,6 +84,15 @@ func (s *Store) GetInt(key string) int {
 	return toInt(v)
 }
 
+// GetFloat64 returns the float value for a key.
+func (s *Store) GetFloat64(key string) float64 {
+	v, ok := s.Get(key)
+	if !ok {
+		return 0
+	}
+	return toFloat64(v)
+}
+
 // GetBool returns the boolean value for a key.
 func (s *Store) GetBool(key string) bool {
 	v, ok := s.Get(key)
@@ -122,6 +131,28 @@ func (s *Store) GetStringSlice(key string) []string {
 	}
 }
 
+// GetStringMapString returns the direct children of key as strings, e.g.
+// "review.language_guidelines.go" as "go". Nested children are skipped.
+func (s *Store) GetStringMapString(key string) map[string]string {
+	out := map[string]string{}
+	if v, ok := s.Get(key); ok {
+		if m, ok := v.(map[string]interface{}); ok {
+			for k, mv := range m {
+				out[k] = toString(mv)
+			}
+		}
+	}
+	dot := key + "."
+	for _, src := range []map[string]interface{}{s.defaults, s.data} {
+		for k, v := range src {
+			if rest, ok := strings.CutPrefix(k, dot); ok && !strings.Contains(rest, ".") {
+				out[rest] = toString(v)
+			}
+		}
+	}
+	return out
+}
+
 // Sub returns a new Store scoped to the given prefix.
 // For example, Sub("providers.openai") returns a store where
 // "api_key" maps to the original "providers.openai.api_key".
@@ -210,6 +241,22 @@ func toInt(v interface{}) int {
 	}
 }
 
+func toFloat64(v interface{}) float64 {
+	switch val := v.(type) {
+	case float64:
+		return val
+	case int:
+		return float64(val)
+	case int64:
+		return float64(val)
+	case string:
+		f, _ := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		return f
+	default:
+		return 0
+	}
+}
+
 func toBool(v interface{}) bool {
 	switch val := v.(type) {
 	case bool:
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"path"
+	"strings"
+)
+
+// DefaultGeneratedGlobs are the path globs treated as generated or vendored
+// when review.ignore_globs is unset.
+var DefaultGeneratedGlobs = []string{
+	"package-lock.json",
+	"yarn.lock",
+	"pnpm-lock.yaml",
+	"go.sum",
+	"Cargo.lock",
+	"poetry.lock",
+	"composer.lock",
+	"Gemfile.lock",
+	"*.pb.go",
+	"*_pb2.py",
+	"*.min.js",
+	"*.min.css",
+	"**/vendor/**",
+	"**/node_modules/**",
+}
+
+// generatedHeaderScanLines bounds how many leading lines are checked for a
+// generated-code header.
+const generatedHeaderScanLines = 10
+
+// IsGeneratedFile reports whether filePath matches DefaultGeneratedGlobs or
+// firstLines carry a generated-code header.
+func IsGeneratedFile(filePath string, firstLines []string) bool {
+	return IsGeneratedFileWithGlobs(filePath, firstLines, DefaultGeneratedGlobs)
+}
+
+// IsGeneratedFileWithGlobs is IsGeneratedFile with a custom glob list.
+func IsGeneratedFileWithGlobs(filePath string, firstLines []string, globs []string) bool {
+	return MatchesAnyPathGlob(filePath, globs) || hasGeneratedHeader(firstLines)
+}
+
+// hasGeneratedHeader detects the usual generator banners: Go's
+// "Code generated ... DO NOT EDIT.", "@generated", and "<auto-generated>".
+func hasGeneratedHeader(lines []string) bool {
+	for i, line := range lines {
+		if i >= generatedHeaderScanLines {
+			break
+		}
+		lower := strings.ToLower(line)
+		if strings.Contains(lower, "do not edit") &&
+			(strings.Contains(lower, "generated") || strings.Contains(lower, "autogenerated")) {
+			return true
+		}
+		if strings.Contains(lower, "@generated") || strings.Contains(lower, "<auto-generated") {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesAnyPathGlob matches name against path.Match globs, both on the full
+// path and on the base name. A trailing "/**" matches everything below a
+// directory and a leading "**/" lets the rest match at any depth.
+func MatchesAnyPathGlob(name string, globs []string) bool {
+	name = NormalizePath(name)
+	if name == "" {
+		return false
+	}
+	for _, g := range globs {
+		g = strings.TrimPrefix(strings.TrimSpace(g), "/")
+		if g == "" {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(g, "**/"); ok {
+			for sub := name; sub != ""; {
+				if MatchesAnyPathGlob(sub, []string{rest}) {
+					return true
+				}
+				_, next, found := strings.Cut(sub, "/")
+				if !found {
+					break
+				}
+				sub = next
+			}
+			continue
+		}
+		if dir, ok := strings.CutSuffix(g, "/**"); ok {
+			if name == dir || strings.HasPrefix(name, dir+"/") {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(g, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(g, path.Base(name)); ok {
+			return true
+		}
+	}
+	return false
+}
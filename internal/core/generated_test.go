@@ -0,0 +1,49 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsGeneratedFile_DefaultGlobs(t *testing.T) {
+	for _, p := range []string{
+		"package-lock.json",
+		"web/package-lock.json",
+		"go.sum",
+		"api/v1/user.pb.go",
+		"vendor/github.com/x/y.go",
+		"services/api/vendor/lib.go",
+		"frontend/node_modules/react/index.js",
+		"static/app.min.js",
+	} {
+		assert.True(t, IsGeneratedFile(p, nil), p)
+	}
+	for _, p := range []string{"main.go", "go.mod", "vendored.go", "docs/vendor.md", "package.json"} {
+		assert.False(t, IsGeneratedFile(p, nil), p)
+	}
+}
+
+func TestIsGeneratedFile_HeaderScan(t *testing.T) {
+	assert.True(t, IsGeneratedFile("internal/mocks/store.go", []string{
+		"// Code generated by MockGen. DO NOT EDIT.",
+		"package mocks",
+	}))
+	assert.True(t, IsGeneratedFile("schema.ts", []string{"/* @generated */"}))
+	assert.True(t, IsGeneratedFile("Form.Designer.cs", []string{"// <auto-generated>"}))
+	assert.False(t, IsGeneratedFile("handler.go", []string{"package handler", "// DO NOT EDIT the retry constants without load tests."}))
+
+	late := make([]string, 0, 12)
+	for i := 0; i < 11; i++ {
+		late = append(late, "// filler")
+	}
+	late = append(late, "// Code generated by x. DO NOT EDIT.")
+	assert.False(t, IsGeneratedFile("late.go", late), "only the leading lines are scanned")
+}
+
+func TestIsGeneratedFileWithGlobs_CustomList(t *testing.T) {
+	globs := []string{"gen/**", "*.snap"}
+	assert.True(t, IsGeneratedFileWithGlobs("gen/client.go", nil, globs))
+	assert.True(t, IsGeneratedFileWithGlobs("ui/__snapshots__/a.snap", nil, globs))
+	assert.False(t, IsGeneratedFileWithGlobs("go.sum", nil, globs), "custom list replaces the defaults")
+}
@@ -20,6 +20,28 @@ func GetGitDiffForRefs(repoPath, baseRef, headRef string) (string, error) {
 	return runGitDiff(repoPath, args)
 }
 
+// GetGitMergeBase returns the merge-base commit of two refs.
+func GetGitMergeBase(repoPath, a, b string) (string, error) {
+	out, err := runGitDiff(repoPath, []string{"merge-base", a, b})
+	if err != nil {
+		return "", err
+	}
+	if out == "" {
+		return "", fmt.Errorf("git merge-base returned no commit for %s and %s", a, b)
+	}
+	return out, nil
+}
+
+// GetGitDiffBetween returns the straight diff between two commits
+// (two-dot: from..to). It fails when fromRef is not a commit in the local
+// repository.
+func GetGitDiffBetween(repoPath, fromRef, toRef string) (string, error) {
+	if _, err := runGitDiff(repoPath, []string{"rev-parse", "--verify", "--quiet", fromRef + "^{commit}"}); err != nil {
+		return "", fmt.Errorf("commit %s not found in %s", fromRef, repoPath)
+	}
+	return runGitDiff(repoPath, []string{"diff", fromRef, toRef})
+}
+
 // GetGitDiffForCommit returns the diff for a single commit.
 func GetGitDiffForCommit(repoPath, commitHash string) (string, error) {
 	args := []string{"show", "--format=", commitHash}
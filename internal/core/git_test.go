@@ -154,3 +154,19 @@ func TestGetCommitMessage(t *testing.T) {
 	require.NoError(t, err)
 	assert.Contains(t, msg, "second feature commit")
 }
+
+func TestGetGitDiffBetween(t *testing.T) {
+	repoPath := setupGitRepo(t)
+
+	diff, err := GetGitDiffBetween(repoPath, "main", "feature")
+	require.NoError(t, err)
+	assert.Contains(t, diff, "hello.go")
+	assert.Contains(t, diff, "new_file.go")
+
+	diff, err = GetGitDiffBetween(repoPath, "feature~1", "feature")
+	require.NoError(t, err)
+	assert.Empty(t, diff, "empty commit changes no files")
+
+	_, err = GetGitDiffBetween(repoPath, "0123456789abcdef0123456789abcdef01234567", "feature")
+	assert.ErrorContains(t, err, "not found")
+}
@@ -0,0 +1,135 @@
+package core
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// PrevIgnoreFile is the repository-root file listing paths excluded from
+// review, in gitignore syntax.
+const PrevIgnoreFile = ".prevignore"
+
+type ignorePattern struct {
+	segments []string
+	negate   bool
+	dirOnly  bool
+}
+
+// IgnoreRules is a parsed .prevignore file. The zero value and nil ignore
+// nothing.
+type IgnoreRules struct {
+	patterns []ignorePattern
+}
+
+// ParseIgnoreRules parses gitignore-style content: one glob per line, "#"
+// comments, "!" to re-include, a trailing "/" to match directories only, and
+// "**" for any number of directories. A pattern without an inner "/" matches
+// at any depth; one with a "/" is relative to the repository root.
+func ParseIgnoreRules(content string) *IgnoreRules {
+	rules := &IgnoreRules{}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var p ignorePattern
+		if rest, ok := strings.CutPrefix(line, "!"); ok {
+			p.negate = true
+			line = rest
+		} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+			line = line[1:]
+		}
+		if rest, ok := strings.CutSuffix(line, "/"); ok {
+			p.dirOnly = true
+			line = rest
+		}
+		if !strings.Contains(line, "/") {
+			line = "**/" + line
+		}
+		line = strings.TrimPrefix(line, "/")
+		if line == "" {
+			continue
+		}
+		p.segments = strings.Split(line, "/")
+		rules.patterns = append(rules.patterns, p)
+	}
+	return rules
+}
+
+// LoadIgnoreRules reads root/.prevignore. A missing file yields nil rules and
+// no error.
+func LoadIgnoreRules(root string) (*IgnoreRules, error) {
+	data, err := os.ReadFile(filepath.Join(root, PrevIgnoreFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseIgnoreRules(string(data)), nil
+}
+
+// Empty reports whether the rules ignore nothing.
+func (r *IgnoreRules) Empty() bool {
+	return r == nil || len(r.patterns) == 0
+}
+
+// Match reports whether the repository-relative file path is ignored. A
+// pattern matching one of the file's parent directories ignores the file too;
+// the last matching pattern wins, so "!" can re-include a file.
+func (r *IgnoreRules) Match(filePath string) bool {
+	if r.Empty() {
+		return false
+	}
+	filePath = NormalizePath(filePath)
+	if filePath == "" {
+		return false
+	}
+	parts := strings.Split(filePath, "/")
+	ignored := false
+	for _, p := range r.patterns {
+		if p.matches(parts) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+func (p ignorePattern) matches(parts []string) bool {
+	for n := 1; n <= len(parts); n++ {
+		if p.dirOnly && n == len(parts) {
+			break
+		}
+		if matchPathSegments(p.segments, parts[:n]) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPathSegments matches path segments against glob segments, where a
+// "**" segment matches zero or more path segments.
+func matchPathSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchPathSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+		return false
+	}
+	return matchPathSegments(pattern[1:], parts[1:])
+}
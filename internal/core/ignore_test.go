@@ -0,0 +1,49 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIgnoreRules_GitignoreSyntax(t *testing.T) {
+	rules := ParseIgnoreRules(`
+# generated fixtures
+testdata/
+*.snap
+/docs/*.md
+!docs/CHANGELOG.md
+web/**/dist
+`)
+	cases := map[string]bool{
+		"testdata/a.json":          true,
+		"pkg/testdata/deep/b.json": true,
+		"testdata":                 false, // directory-only pattern
+		"ui/__snapshots__/x.snap":  true,
+		"docs/intro.md":            true,
+		"docs/CHANGELOG.md":        false,
+		"pkg/docs/intro.md":        false, // anchored to the root
+		"web/app/dist/main.js":     true,
+		"web/dist/main.js":         true,
+		"cmd/main.go":              false,
+	}
+	for p, want := range cases {
+		assert.Equal(t, want, rules.Match(p), p)
+	}
+}
+
+func TestLoadIgnoreRules_MissingFileIgnoresNothing(t *testing.T) {
+	root := t.TempDir()
+	rules, err := LoadIgnoreRules(root)
+	require.NoError(t, err)
+	assert.True(t, rules.Empty())
+	assert.False(t, rules.Match("anything.go"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, PrevIgnoreFile), []byte("legacy/\n"), 0o644))
+	rules, err = LoadIgnoreRules(root)
+	require.NoError(t, err)
+	assert.True(t, rules.Match("legacy/old.go"))
+}
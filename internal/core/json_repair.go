@@ -0,0 +1,144 @@
+package core
+
+import (
+	"strings"
+)
+
+// repairJSONCandidates returns lenient rewrites of a malformed structured
+// review, most specific first: the extracted payload and the largest
+// balanced {...} object in content, each with single-quoted strings turned
+// into JSON strings and trailing commas removed.
+func repairJSONCandidates(content, payload string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, raw := range []string{payload, largestBalancedObject(content)} {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		fixed := removeTrailingCommas(normalizeSingleQuotes(raw))
+		if !seen[fixed] {
+			seen[fixed] = true
+			out = append(out, fixed)
+		}
+	}
+	return out
+}
+
+// largestBalancedObject returns the longest brace-balanced {...} span of s,
+// ignoring braces inside quoted strings, or "" when there is none.
+func largestBalancedObject(s string) string {
+	best := ""
+	for i := 0; i < len(s); i++ {
+		if s[i] != '{' {
+			continue
+		}
+		end := matchingBrace(s, i)
+		if end < 0 {
+			continue
+		}
+		if end-i+1 > len(best) {
+			best = s[i : end+1]
+		}
+		i = end
+	}
+	return best
+}
+
+// matchingBrace returns the index of the '}' closing the '{' at start, or -1.
+func matchingBrace(s string, start int) int {
+	depth := 0
+	var quote byte
+	for i := start; i < len(s); i++ {
+		c := s[i]
+		if quote != 0 {
+			switch c {
+			case '\\':
+				i++
+			case quote:
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '"', '\'':
+			quote = c
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// normalizeSingleQuotes rewrites 'single-quoted' strings outside of
+// double-quoted ones as JSON strings.
+func normalizeSingleQuotes(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == 0 && c == '"':
+			quote = '"'
+			b.WriteByte(c)
+		case quote == 0 && c == '\'':
+			quote = '\''
+			b.WriteByte('"')
+		case quote == 0:
+			b.WriteByte(c)
+		case c == '\\' && i+1 < len(s):
+			i++
+			if quote == '\'' && s[i] == '\'' {
+				b.WriteByte('\'')
+			} else {
+				b.WriteByte(c)
+				b.WriteByte(s[i])
+			}
+		case c == quote:
+			quote = 0
+			b.WriteByte('"')
+		case quote == '\'' && c == '"':
+			b.WriteString(`\"`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// removeTrailingCommas drops commas that directly precede a closing '}' or
+// ']', outside of strings.
+func removeTrailingCommas(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	inString := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			b.WriteByte(c)
+			if c == '\\' && i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		if c == '"' {
+			inString = true
+		}
+		if c == ',' {
+			next := strings.TrimLeft(s[i+1:], " \t\r\n")
+			if next != "" && (next[0] == '}' || next[0] == ']') {
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,60 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReviewResponseJSON_RepairsCommonMalformations(t *testing.T) {
+	fixtures := map[string]string{
+		"trailing commas": `{
+  "summary": "One issue.",
+  "findings": [
+    {"file": "a.go", "line": 12, "severity": "HIGH", "message": "Nil guard missing",},
+  ],
+}`,
+		"single quotes": `{'summary': 'One issue.', 'findings': [{'file': 'a.go', 'line': 12, 'severity': 'HIGH', 'message': 'Nil guard missing'}]}`,
+		"surrounding prose": `Sure! Here's the review {as requested}:
+
+{"summary": "One issue.", "findings": [{"file": "a.go", "line": 12, "severity": "HIGH", "message": "Nil guard missing"}]}
+
+Let me know if you need anything else.`,
+		"string line":                `{"summary": "One issue.", "findings": [{"file": "a.go", "line": "L12", "severity": "HIGH", "message": "Nil guard missing"}]}`,
+		"fenced with trailing comma": "```json\n{\"summary\": \"One issue.\", \"findings\": [{\"file\": \"a.go\", \"line\": \"12-14\", \"severity\": \"HIGH\", \"message\": \"Nil guard missing\"},]}\n```",
+	}
+	for name, content := range fixtures {
+		t.Run(name, func(t *testing.T) {
+			result, ok := ParseReviewResponseJSON(content)
+			require.True(t, ok)
+			assert.Equal(t, "One issue.", result.Summary)
+			require.Len(t, result.FileComments, 1)
+			assert.Equal(t, "a.go", result.FileComments[0].FilePath)
+			assert.Equal(t, 12, result.FileComments[0].Line)
+			assert.Equal(t, "HIGH", result.FileComments[0].Severity)
+			assert.Equal(t, "Nil guard missing", result.FileComments[0].Message)
+		})
+	}
+}
+
+func TestParseReviewResponseJSON_DropsFindingsMissingRequiredFields(t *testing.T) {
+	content := `{"findings": [
+  {"file": "a.go", "line": 3, "message": "Kept"},
+  {"file": "b.go", "line": 4},
+  {"line": 5, "message": "No file"}
+]}`
+	result, ok := ParseReviewResponseJSON(content)
+	require.True(t, ok)
+	require.Len(t, result.FileComments, 1)
+	assert.Equal(t, "Kept", result.FileComments[0].Message)
+}
+
+func TestParseReviewResponseJSON_UnrepairableFails(t *testing.T) {
+	_, ok := ParseReviewResponseJSON(`{"summary": "cut off", "findings": [{"file": "a.go", "line": 1, "message": "trunc`)
+	assert.False(t, ok)
+}
+
+func TestNormalizeSingleQuotes_KeepsApostrophesInDoubleQuotedStrings(t *testing.T) {
+	assert.Equal(t, `{"a": "it's", "b": "say \"hi\""}`, normalizeSingleQuotes(`{"a": "it's", 'b': 'say "hi"'}`))
+}
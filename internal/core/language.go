@@ -0,0 +1,28 @@
+package core
+
+import "strings"
+
+// outputLanguageBlock returns the instruction asking the model to write prose
+// in language, or "" when language is empty or English.
+func outputLanguageBlock(language string) string {
+	language = strings.TrimSpace(language)
+	switch strings.ToLower(language) {
+	case "", "en", "english":
+		return ""
+	}
+	return "## Output Language\n" +
+		"Write the summary, scope map, finding descriptions, remediation plan and reply text in " + language + ".\n" +
+		"Keep these in English exactly as specified so the output stays parseable: the `**File: path**` prefix, " +
+		"`(line N)`, the KIND tokens (ISSUE, SUGGESTION, REMARK), the SEVERITY tokens (CRITICAL, HIGH, MEDIUM, LOW), " +
+		"JSON keys, file paths, and code identifiers, snippets and suggestion blocks."
+}
+
+// ApplyOutputLanguage appends the output-language instruction to a review
+// prompt. The prompt is returned unchanged when language is empty or English.
+func ApplyOutputLanguage(prompt, language string) string {
+	block := outputLanguageBlock(language)
+	if block == "" {
+		return prompt
+	}
+	return prompt + "\n\n" + block
+}
@@ -0,0 +1,31 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyOutputLanguage_OnlyWhenSet(t *testing.T) {
+	base := BuildMRReviewPromptWithOptions("T", "D", "feat", "main", "diff", "normal", 0, nil, "")
+
+	assert.Equal(t, base, ApplyOutputLanguage(base, ""))
+	assert.Equal(t, base, ApplyOutputLanguage(base, " English "))
+
+	prompt := ApplyOutputLanguage(base, "French")
+	assert.True(t, len(prompt) > len(base))
+	assert.Contains(t, prompt, "## Output Language\nWrite the summary")
+	assert.Contains(t, prompt, "in French.")
+	assert.Contains(t, prompt, "ISSUE, SUGGESTION, REMARK")
+}
+
+func TestParseCommentHeader_LocalizedMessage(t *testing.T) {
+	h, ok := parseCommentHeader("**File: api/handler.go** (line 12) [ISSUE] [HIGH]: L'erreur retournée par `Decode` est ignorée.")
+	require.True(t, ok)
+	assert.Equal(t, "api/handler.go", h.filePath)
+	assert.Equal(t, 12, h.line)
+	assert.Equal(t, "ISSUE", h.kind)
+	assert.Equal(t, "HIGH", h.severity)
+	assert.Equal(t, "L'erreur retournée par `Decode` est ignorée.", h.message)
+}
@@ -0,0 +1,24 @@
+package core
+
+import (
+	"path"
+	"strings"
+)
+
+// NormalizePath returns the canonical repository-relative form of a file
+// path as a model or user may write it: surrounding whitespace (including a
+// trailing CR) is dropped, backslashes become slashes, redundant separators
+// and "./" segments are cleaned, and a leading "/" is removed, so
+// "./public/index.php", "/public/index.php" and "public\index.php" all map
+// to "public/index.php". Empty input stays empty.
+func NormalizePath(p string) string {
+	p = strings.TrimSpace(p)
+	if p == "" {
+		return ""
+	}
+	p = strings.TrimLeft(path.Clean(strings.ReplaceAll(p, `\`, "/")), "/")
+	if p == "." {
+		return ""
+	}
+	return p
+}
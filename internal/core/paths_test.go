@@ -0,0 +1,40 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizePath_VariantsShareCanonicalKey(t *testing.T) {
+	for _, raw := range []string{
+		"public/index.php",
+		"./public/index.php",
+		"/public/index.php",
+		`public\index.php`,
+		`.\public\index.php`,
+		"public//index.php",
+		"public/./index.php",
+		"  public/index.php\r",
+		"//public/index.php",
+	} {
+		assert.Equal(t, "public/index.php", NormalizePath(raw), "%q", raw)
+	}
+}
+
+func TestNormalizePath_Empty(t *testing.T) {
+	for _, raw := range []string{"", "  ", ".", "./", "/"} {
+		assert.Empty(t, NormalizePath(raw), "%q", raw)
+	}
+}
+
+func TestParseReviewResponse_NormalizesFilePaths(t *testing.T) {
+	content := "**File: /public/index.php** (line 4) [HIGH]: Unescaped output\r\n" +
+		"**File: public\\lib\\db.php** (line 9) [LOW]: Unused variable\r\n"
+
+	result := ParseReviewResponse(content)
+	if assert.Len(t, result.FileComments, 2) {
+		assert.Equal(t, "public/index.php", result.FileComments[0].FilePath)
+		assert.Equal(t, "public/lib/db.php", result.FileComments[1].FilePath)
+	}
+}
@@ -0,0 +1,60 @@
+package core
+
+import (
+	"sort"
+	"strings"
+)
+
+// reviewPersonas are the built-in reviewer personas selectable through
+// review.persona. They change tone and focus only; severity thresholds stay
+// governed by strictness and nitpick.
+var reviewPersonas = map[string]string{
+	"staff-engineer": `Review as a staff engineer. Weigh design, API boundaries, coupling and
+long-term maintainability alongside correctness. Call out changes that will
+be hard to evolve or operate, and be direct and brief.`,
+	"security-auditor": `Review as a security auditor. Trace untrusted input through the change and
+look first for injection, authn/authz gaps, secrets handling, unsafe
+deserialization and missing validation. State the attack path for each finding.`,
+	"mentor": `Review as a friendly mentor. Explain the reasoning behind each finding so the
+author learns from it, keep the tone encouraging, and acknowledge what the
+change does well in the summary.`,
+	"performance": `Review as a performance engineer. Focus on hot paths, allocations, N+1 queries,
+blocking I/O, lock contention and algorithmic complexity. Quantify the impact
+when you can.`,
+}
+
+// ReviewPersonaNames returns the built-in persona names, sorted.
+func ReviewPersonaNames() []string {
+	names := make([]string, 0, len(reviewPersonas))
+	for name := range reviewPersonas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// personaBlock resolves persona to its prompt fragment. Built-in names are
+// matched case-insensitively (spaces and underscores read as dashes); any
+// other non-empty text is used verbatim as a custom persona.
+func personaBlock(persona string) string {
+	persona = strings.TrimSpace(persona)
+	if persona == "" {
+		return ""
+	}
+	key := strings.NewReplacer(" ", "-", "_", "-").Replace(strings.ToLower(persona))
+	text, ok := reviewPersonas[key]
+	if !ok {
+		text = persona
+	}
+	return "## Reviewer Persona\n" + text + "\nThe persona sets tone and focus only; follow the severity rules in the review instructions unchanged."
+}
+
+// ApplyReviewPersona prepends the persona fragment to a review prompt. The
+// prompt is returned unchanged when persona is empty.
+func ApplyReviewPersona(prompt, persona string) string {
+	block := personaBlock(persona)
+	if block == "" {
+		return prompt
+	}
+	return block + "\n\n" + prompt
+}
@@ -0,0 +1,23 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyReviewPersona_BuiltinFragmentIsIncluded(t *testing.T) {
+	base := BuildMRReviewPromptWithOptions("T", "D", "feat", "main", "diff", "normal", 0, nil, "")
+
+	prompt := ApplyReviewPersona(base, "Security Auditor")
+	assert.Contains(t, prompt, "## Reviewer Persona\nReview as a security auditor.")
+	assert.Contains(t, prompt, "follow the severity rules")
+	assert.Contains(t, prompt, "Report MEDIUM severity and above.", "strictness block is untouched")
+	assert.True(t, len(prompt) > len(base))
+}
+
+func TestApplyReviewPersona_CustomTextAndEmpty(t *testing.T) {
+	assert.Equal(t, "BASE", ApplyReviewPersona("BASE", "  "))
+	assert.Contains(t, ApplyReviewPersona("BASE", "a pragmatic SRE"), "## Reviewer Persona\na pragmatic SRE\n")
+	assert.Equal(t, []string{"mentor", "performance", "security-auditor", "staff-engineer"}, ReviewPersonaNames())
+}
@@ -16,8 +16,17 @@ var relaxedCommentHeaderPattern = regexp.MustCompile(
 	`(?i)^\s*(?:[-*]\s*)?(?:File:\s*)?([^\s]+?\.\w+)\s*(?:\(([^)]*)\))?\s*(?:\[(\w+)\])?\s*(?:\[(\w+)\])?\s*:?\s*(.*)\s*$`,
 )
 
+var firstIntPattern = regexp.MustCompile(`\d+`)
+
 var lineInParensPattern = regexp.MustCompile(`(?i)\bline\s*(\d+)\b`)
 
+// headingCommentHeaderPattern matches markdown headings such as
+// "### src/app.go:42 [ISSUE] [HIGH] Missing check", where the path:line
+// token may follow a short label ("### 1. src/app.go:42").
+var headingCommentHeaderPattern = regexp.MustCompile(
+	`(?i)^\s*#{1,6}\s+(?:.*?\s)?([^\s]+?\.\w+):(\d+)\b\s*(?:\[(\w+)\])?\s*(?:\[(\w+)\])?\s*(.*)$`,
+)
+
 // ReviewResult holds the parsed AI review output.
 type ReviewResult struct {
 	Summary      string
@@ -72,34 +81,50 @@ func ParseReviewResponse(content string) ReviewResult {
 // 1) {"summary":"...","findings":[...]}
 // 2) {"file_comments":[...]}
 // 3) [{"file":"...","line":1,...}]
+// Malformed JSON is repaired leniently (trailing commas, single quotes,
+// surrounding prose) before giving up. Findings without a file or message
+// are dropped.
 func ParseReviewResponseJSON(content string) (ReviewResult, bool) {
 	payload := extractJSONPayload(content)
 	if payload == "" {
 		return ReviewResult{}, false
 	}
+	if result, ok, decoded := parseJSONReviewPayload(payload); decoded {
+		return result, ok
+	}
+	for _, repaired := range repairJSONCandidates(content, payload) {
+		if result, ok, decoded := parseJSONReviewPayload(repaired); decoded {
+			return result, ok
+		}
+	}
+	return ReviewResult{}, false
+}
 
+// parseJSONReviewPayload decodes payload; decoded is false when it is not
+// valid JSON of a supported shape.
+func parseJSONReviewPayload(payload string) (result ReviewResult, ok bool, decoded bool) {
 	// Object root
 	var obj map[string]any
 	if err := json.Unmarshal([]byte(payload), &obj); err == nil && len(obj) > 0 {
-		result := ReviewResult{}
 		if s, ok := obj["summary"].(string); ok {
 			result.Summary = strings.TrimSpace(s)
 		}
 		items := pickJSONFindings(obj)
 		if len(items) == 0 {
-			return result, false
+			return result, false, true
 		}
 		result.FileComments = toFileComments(items)
-		return result, len(result.FileComments) > 0
+		return result, len(result.FileComments) > 0, true
 	}
 
 	// Array root
 	var arr []map[string]any
 	if err := json.Unmarshal([]byte(payload), &arr); err == nil && len(arr) > 0 {
-		return ReviewResult{FileComments: toFileComments(arr)}, true
+		result.FileComments = toFileComments(arr)
+		return result, len(result.FileComments) > 0, true
 	}
 
-	return ReviewResult{}, false
+	return ReviewResult{}, false, false
 }
 
 func parseFileComments(lines []string) []FileComment {
@@ -139,7 +164,7 @@ func parseFileComments(lines []string) []FileComment {
 			}
 
 			current = &FileComment{
-				FilePath: header.filePath,
+				FilePath: NormalizePath(header.filePath),
 				Line:     header.line,
 				Kind:     header.kind,
 				Severity: header.severity,
@@ -189,13 +214,19 @@ func extractJSONPayload(content string) string {
 			return strings.TrimSpace(trimmed[:endObj+1])
 		}
 	default:
+		// Prose around the payload: take whichever root opens first, so an
+		// object's findings array is not mistaken for an array root.
 		startArr := strings.Index(trimmed, "[")
 		endArr := strings.LastIndex(trimmed, "]")
+		startObj := strings.Index(trimmed, "{")
+		endObj := strings.LastIndex(trimmed, "}")
+		objFirst := startObj >= 0 && (startArr < 0 || startObj < startArr)
+		if objFirst && endObj > startObj {
+			return strings.TrimSpace(trimmed[startObj : endObj+1])
+		}
 		if startArr >= 0 && endArr > startArr {
 			return strings.TrimSpace(trimmed[startArr : endArr+1])
 		}
-		startObj := strings.Index(trimmed, "{")
-		endObj := strings.LastIndex(trimmed, "}")
 		if startObj >= 0 && endObj > startObj {
 			return strings.TrimSpace(trimmed[startObj : endObj+1])
 		}
@@ -238,9 +269,12 @@ func toFileComments(items []map[string]any) []FileComment {
 			firstString(m, "severity", "level", "priority"),
 		)
 		msg := firstString(m, "message", "title", "description")
+		if strings.TrimSpace(msg) == "" {
+			continue
+		}
 		sug := firstString(m, "suggestion", "patch", "fix")
 		out = append(out, FileComment{
-			FilePath:   strings.TrimSpace(path),
+			FilePath:   NormalizePath(path),
 			Line:       line,
 			Kind:       kind,
 			Severity:   sev,
@@ -282,9 +316,12 @@ func firstInt(m map[string]any, keys ...string) int {
 		case int:
 			return t
 		case string:
-			n, err := strconv.Atoi(strings.TrimSpace(t))
-			if err == nil {
-				return n
+			// Accept "42", "L42" and ranges like "42-45" (start line).
+			if m := firstIntPattern.FindString(t); m != "" {
+				n, err := strconv.Atoi(m)
+				if err == nil {
+					return n
+				}
 			}
 		}
 	}
@@ -305,6 +342,10 @@ func parseCommentHeader(line string) (commentHeader, bool) {
 		return commentHeader{}, false
 	}
 
+	if strings.HasPrefix(normalized, "#") {
+		return parseHeadingCommentHeader(normalized)
+	}
+
 	match := commentHeaderPattern.FindStringSubmatch(normalized)
 	if match != nil && strings.TrimSpace(match[1]) != "" {
 		hasStructuredMeta := strings.TrimSpace(match[2]) != "" ||
@@ -354,6 +395,27 @@ func parseCommentHeader(line string) (commentHeader, bool) {
 	}, true
 }
 
+// parseHeadingCommentHeader reads a markdown heading that names a finding's
+// path:line. Headings without a line number are section titles, not findings.
+func parseHeadingCommentHeader(heading string) (commentHeader, bool) {
+	match := headingCommentHeaderPattern.FindStringSubmatch(strings.ReplaceAll(heading, "`", ""))
+	if match == nil {
+		return commentHeader{}, false
+	}
+	lineNo, err := strconv.Atoi(match[2])
+	if err != nil || lineNo <= 0 {
+		return commentHeader{}, false
+	}
+	kind, severity := parseKindAndSeverity(match[3], match[4])
+	return commentHeader{
+		filePath: match[1],
+		line:     lineNo,
+		kind:     kind,
+		severity: severity,
+		message:  strings.TrimSpace(strings.TrimLeft(match[5], " :-–—")),
+	}, true
+}
+
 func parseKindAndSeverity(first, second string) (string, string) {
 	kind := "ISSUE"
 	severity := "MEDIUM"
@@ -372,35 +434,133 @@ func parseKindAndSeverity(first, second string) (string, string) {
 	return kind, severity
 }
 
-// extractSuggestion scans message lines for a ```suggestion fenced block.
+// extractSuggestion scans message lines for a ```suggestion fenced block,
+// or a ```diff block that is a clean single-hunk replacement. Only the first
+// such block is used; later ones stay in the message.
 // Returns the message text (without the suggestion block) and the suggestion content.
+// A diff block that cannot be read as a replacement stays in the message.
 func extractSuggestion(msgLines []string) (message, suggestion string) {
 	var msgParts []string
 	var sugParts []string
+	var diffParts []string
 	inSuggestion := false
+	inDiff := false
+	found := false
 
 	for _, line := range msgLines {
 		trimmed := strings.TrimSpace(line)
-		if !inSuggestion && strings.HasPrefix(trimmed, "```suggestion") {
+		if !inSuggestion && !inDiff && !found && strings.HasPrefix(trimmed, "```suggestion") {
 			inSuggestion = true
 			continue
 		}
+		if !inSuggestion && !inDiff && trimmed == "```diff" {
+			inDiff = true
+			diffParts = []string{line}
+			continue
+		}
 		if inSuggestion {
 			if trimmed == "```" {
 				inSuggestion = false
+				found = true
 				continue
 			}
 			sugParts = append(sugParts, line)
+		} else if inDiff {
+			diffParts = append(diffParts, line)
+			if trimmed != "```" {
+				continue
+			}
+			inDiff = false
+			if replacement, ok := diffReplacement(diffParts[1 : len(diffParts)-1]); ok && !found {
+				sugParts = replacement
+				found = true
+				continue
+			}
+			msgParts = append(msgParts, diffParts...)
 		} else {
 			msgParts = append(msgParts, line)
 		}
 	}
+	if inDiff {
+		msgParts = append(msgParts, diffParts...)
+	}
 
 	message = strings.TrimSpace(strings.Join(msgParts, "\n"))
 	suggestion = strings.Join(trimBlankEdges(sugParts), "\n")
 	return
 }
 
+// diffReplacement reads a unified diff as a suggestion: it must be one hunk
+// whose single removed line is directly followed by the added lines that
+// replace it, optionally followed by context. The added lines, without
+// their "+" prefix, are the replacement. Anything else (several hunks, more
+// than one removed line, context before the removed line, additions
+// interleaved with removals or context) is ambiguous, since the suggestion
+// only replaces the line it is anchored on.
+func diffReplacement(lines []string) ([]string, bool) {
+	const (
+		leading = iota
+		removing
+		adding
+		trailing
+	)
+	state := leading
+	hunks := 0
+	removed := 0
+	var added []string
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case isDiffFileHeader(lines, i, state == leading && hunks == 0):
+			// Skip the "+++ " half of the header as well.
+			i++
+		case strings.HasPrefix(line, "@@"):
+			hunks++
+			if hunks > 1 {
+				return nil, false
+			}
+		case strings.HasPrefix(line, "-"):
+			if state != leading && state != removing {
+				return nil, false
+			}
+			state = removing
+			removed++
+			if removed > 1 {
+				return nil, false
+			}
+		case strings.HasPrefix(line, "+"):
+			if state == trailing {
+				return nil, false
+			}
+			state = adding
+			added = append(added, line[1:])
+		default:
+			// Context lines (" " prefix or blank). Leading context would be
+			// kept above a replacement that already sits on the removed line.
+			if state == removing || (state == leading && strings.TrimSpace(line) != "") {
+				return nil, false
+			}
+			if state == adding {
+				state = trailing
+			}
+		}
+	}
+	if removed == 0 || len(added) == 0 {
+		return nil, false
+	}
+	return added, true
+}
+
+// isDiffFileHeader reports whether lines[i] starts a "--- "/"+++ " file
+// header pair. Headers only appear before the first hunk, and a lone
+// "--- x" is a removed line such as a SQL or Lua comment.
+func isDiffFileHeader(lines []string, i int, beforeHunk bool) bool {
+	return beforeHunk &&
+		strings.HasPrefix(lines[i], "--- ") &&
+		i+1 < len(lines) &&
+		strings.HasPrefix(lines[i+1], "+++ ")
+}
+
 func trimBlankEdges(lines []string) []string {
 	start := 0
 	for start < len(lines) && strings.TrimSpace(lines[start]) == "" {
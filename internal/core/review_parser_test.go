@@ -110,6 +110,46 @@ func TestParseReviewResponse_RelaxedFileHeaderWithoutLine(t *testing.T) {
 	assert.Equal(t, "Verify json_encode error handling.", result.FileComments[0].Message)
 }
 
+func TestParseReviewResponse_MarkdownHeadingHeaders(t *testing.T) {
+	content := `## Summary
+The change adds request caching. Two problems stand out.
+
+## Findings
+
+### ` + "`src/app.go:42`" + ` [ISSUE] [HIGH]
+The cache key ignores the tenant ID, so tenants can read each other's entries.
+
+` + "```suggestion" + `
+key := tenantID + ":" + path
+` + "```" + `
+
+### 2. internal/cache/store.go:117 [SUGGESTION] [LOW] - Consider a bounded LRU.
+The map grows without limit.
+
+### Closing notes
+Nothing else.
+`
+
+	result := ParseReviewResponse(content)
+	assert.Contains(t, result.Summary, "Two problems stand out.")
+	assert.Len(t, result.FileComments, 2)
+
+	first := result.FileComments[0]
+	assert.Equal(t, "src/app.go", first.FilePath)
+	assert.Equal(t, 42, first.Line)
+	assert.Equal(t, "ISSUE", first.Kind)
+	assert.Equal(t, "HIGH", first.Severity)
+	assert.Equal(t, "The cache key ignores the tenant ID, so tenants can read each other's entries.", first.Message)
+	assert.Equal(t, `key := tenantID + ":" + path`, first.Suggestion)
+
+	second := result.FileComments[1]
+	assert.Equal(t, "internal/cache/store.go", second.FilePath)
+	assert.Equal(t, 117, second.Line)
+	assert.Equal(t, "SUGGESTION", second.Kind)
+	assert.Equal(t, "LOW", second.Severity)
+	assert.Contains(t, second.Message, "Consider a bounded LRU.\nThe map grows without limit.")
+}
+
 func TestParseReviewResponseJSON_ObjectRoot(t *testing.T) {
 	content := `{
   "summary": "One high issue found.",
@@ -216,6 +256,124 @@ func TestExtractSuggestion_PreservesPadding(t *testing.T) {
 	assert.Equal(t, "    $value = trim($value);\n\treturn $value;", sug)
 }
 
+func TestExtractSuggestion_DiffReplacement(t *testing.T) {
+	lines := []string{
+		"Guard against nil.",
+		"```diff",
+		"--- a/main.go",
+		"+++ b/main.go",
+		"@@ -11,2 +11,4 @@",
+		"-\treturn c.Do()",
+		"+\tif c == nil {",
+		"+\t\treturn nil",
+		"+\t}",
+		" }",
+		"```",
+	}
+
+	msg, sug := extractSuggestion(lines)
+	assert.Equal(t, "Guard against nil.", msg)
+	assert.Equal(t, "\tif c == nil {\n\t\treturn nil\n\t}", sug)
+}
+
+func TestExtractSuggestion_DiffWithLeadingContextStaysInMessage(t *testing.T) {
+	lines := []string{
+		"Guard against nil.",
+		"```diff",
+		"@@ -10,2 +10,4 @@",
+		" func run(c *Client) {",
+		"-\treturn c.Do()",
+		"+\tif c == nil {",
+		"+\t\treturn nil",
+		"+\t}",
+		"```",
+	}
+
+	msg, sug := extractSuggestion(lines)
+	assert.Empty(t, sug)
+	assert.Contains(t, msg, " func run(c *Client) {")
+}
+
+func TestExtractSuggestion_OnlyFirstBlockIsUsed(t *testing.T) {
+	lines := []string{
+		"Guard against nil.",
+		"```diff",
+		"-\treturn c.Do()",
+		"+\treturn c.DoSafe()",
+		"```",
+		"Or alternatively:",
+		"```suggestion",
+		"\treturn c.Must().Do()",
+		"```",
+	}
+
+	msg, sug := extractSuggestion(lines)
+	assert.Equal(t, "\treturn c.DoSafe()", sug)
+	assert.Contains(t, msg, "```suggestion\n\treturn c.Must().Do()\n```")
+}
+
+func TestExtractSuggestion_MixedDiffStaysInMessage(t *testing.T) {
+	lines := []string{
+		"Rework both calls.",
+		"```diff",
+		"-a := load()",
+		"+a := mustLoad()",
+		" b := 1",
+		"-c := save(a)",
+		"+c := mustSave(a)",
+		"```",
+	}
+
+	msg, sug := extractSuggestion(lines)
+	assert.Empty(t, sug)
+	assert.Contains(t, msg, "Rework both calls.")
+	assert.Contains(t, msg, "```diff\n-a := load()")
+	assert.Contains(t, msg, "+c := mustSave(a)\n```")
+}
+
+func TestExtractSuggestion_DiffWithSeveralRemovedLinesStaysInMessage(t *testing.T) {
+	lines := []string{
+		"Collapse the two calls.",
+		"```diff",
+		"-a := load()",
+		"-b := parse(a)",
+		"+b := loadAndParse()",
+		"```",
+	}
+
+	msg, sug := extractSuggestion(lines)
+	assert.Empty(t, sug)
+	assert.Contains(t, msg, "-b := parse(a)")
+}
+
+func TestExtractSuggestion_DiffRemovingSQLComment(t *testing.T) {
+	lines := []string{
+		"Drop the stale note.",
+		"```diff",
+		"@@ -3,1 +3,1 @@",
+		"--- TODO: index this",
+		"+-- indexed in 0042_users.sql",
+		"```",
+	}
+
+	msg, sug := extractSuggestion(lines)
+	assert.Equal(t, "Drop the stale note.", msg)
+	assert.Equal(t, "-- indexed in 0042_users.sql", sug)
+}
+
+func TestExtractSuggestion_DiffRemovingSQLCommentWithoutHunkHeader(t *testing.T) {
+	lines := []string{
+		"Drop the stale note.",
+		"```diff",
+		"--- TODO: index this",
+		"+SELECT 1;",
+		"```",
+	}
+
+	_, sug := extractSuggestion(lines)
+	assert.Equal(t, "SELECT 1;", sug)
+}
+
 // --- Severity filtering tests ---
 
 func TestFilterBySeverity_Strict(t *testing.T) {
@@ -0,0 +1,200 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// DefaultSecretEntropyThreshold is the Shannon entropy, in bits per
+// character, above which a value assigned to a key/secret/token identifier
+// is treated as a credential rather than a placeholder.
+const DefaultSecretEntropyThreshold = 3.5
+
+// secretMinValueLen is the shortest assigned value checked for entropy.
+// Shorter random strings rarely clear the threshold and mostly match slugs.
+const secretMinValueLen = 20
+
+// secretPattern is a fixed-format credential recognised by its shape alone.
+type secretPattern struct {
+	name string
+	re   *regexp.Regexp
+}
+
+var secretPatterns = []secretPattern{
+	{"AWS access key ID", regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA)[0-9A-Z]{16}\b`)},
+	{"private key", regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`)},
+	{"GitHub token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)},
+}
+
+// secretAssignmentPattern matches `<name> = "<value>"` and `<name>: '<value>'`
+// with a quoted string-literal value. The name is group 1, the value group 2.
+// Unquoted values are identifiers, expressions or calls, not credentials.
+var secretAssignmentPattern = regexp.MustCompile(
+	`([A-Za-z0-9_.\-]+)["']?\s*(?::=|=>|=|:)\s*["'\x60]([^"'\x60\s]+)["'\x60]`)
+
+// secretEnvPattern matches dotenv-style `NAME=value` lines, the one place an
+// unquoted value is a literal.
+var secretEnvPattern = regexp.MustCompile(
+	`^\s*(?:export\s+)?([A-Z][A-Z0-9_]*)=([^"'\x60\s#()]+)\s*(?:#.*)?$`)
+
+// secretNameParts are identifier components that mark a secret-like name.
+// They match whole components only, so tokenizer or keyboard do not count.
+var secretNameParts = map[string]bool{
+	"key": true, "apikey": true, "secret": true, "secrets": true,
+	"token": true, "password": true, "passwd": true, "pwd": true,
+	"credential": true, "credentials": true,
+}
+
+// hexDigestPattern matches checksums and commit hashes, which are high-entropy
+// but not secrets.
+var hexDigestPattern = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+
+// SecretScanner flags credentials on added lines. Its checks are
+// deterministic so committed secrets are reported regardless of what the
+// model says.
+type SecretScanner struct {
+	// EntropyThreshold applies to values assigned to secret-like names.
+	EntropyThreshold float64
+	// Allowlist, when set, exempts every line it matches.
+	Allowlist *regexp.Regexp
+}
+
+// NewSecretScanner returns a scanner with the given entropy threshold
+// (DefaultSecretEntropyThreshold when <= 0) and optional allowlist regexp.
+func NewSecretScanner(entropyThreshold float64, allowlist string) (*SecretScanner, error) {
+	s := &SecretScanner{EntropyThreshold: entropyThreshold}
+	if s.EntropyThreshold <= 0 {
+		s.EntropyThreshold = DefaultSecretEntropyThreshold
+	}
+	if allowlist = strings.TrimSpace(allowlist); allowlist != "" {
+		re, err := regexp.Compile(allowlist)
+		if err != nil {
+			return s, fmt.Errorf("invalid secret allowlist %q: %w", allowlist, err)
+		}
+		s.Allowlist = re
+	}
+	return s, nil
+}
+
+// ScanLine reports the first secret found on an added line. The message
+// names the kind of secret but never repeats its value.
+func (s *SecretScanner) ScanLine(filePath string, line int, content string) (FileComment, bool) {
+	if s == nil || strings.TrimSpace(content) == "" {
+		return FileComment{}, false
+	}
+	if s.Allowlist != nil && s.Allowlist.MatchString(content) {
+		return FileComment{}, false
+	}
+	kind := ""
+	for _, p := range secretPatterns {
+		if p.re.MatchString(content) {
+			kind = p.name
+			break
+		}
+	}
+	if kind == "" {
+		matches := secretAssignmentPattern.FindAllStringSubmatch(content, -1)
+		if m := secretEnvPattern.FindStringSubmatch(content); m != nil {
+			matches = append(matches, m)
+		}
+		for _, m := range matches {
+			if isSecretName(m[1]) && looksLikeSecretValue(m[2], s.EntropyThreshold) {
+				kind = fmt.Sprintf("high-entropy value assigned to `%s`", m[1])
+				break
+			}
+		}
+	}
+	if kind == "" {
+		return FileComment{}, false
+	}
+	return FileComment{
+		FilePath: filePath,
+		Line:     line,
+		Kind:     "ISSUE",
+		Severity: "CRITICAL",
+		Message: fmt.Sprintf("Possible secret committed (%s). Revoke and rotate it, then load it from the environment or a secret manager instead of the source tree.",
+			kind),
+	}, true
+}
+
+// isSecretName reports whether one of name's components, split on
+// punctuation and camelCase boundaries, is a secret-like word.
+func isSecretName(name string) bool {
+	for _, part := range identifierParts(name) {
+		if secretNameParts[strings.ToLower(part)] {
+			return true
+		}
+	}
+	return false
+}
+
+// identifierParts splits snake_case, kebab-case, dotted and camelCase names
+// into their components: "awsSecretAccessKey" -> aws, Secret, Access, Key and
+// "APIKey" -> API, Key. Digits stay with the component they follow.
+func identifierParts(name string) []string {
+	var parts []string
+	for _, field := range strings.FieldsFunc(name, func(r rune) bool {
+		return !isASCIILetter(r) && (r < '0' || r > '9')
+	}) {
+		start := 0
+		for i := 1; i < len(field); i++ {
+			prev, cur := field[i-1], field[i]
+			lowerToUpper := isUpper(cur) && !isUpper(prev)
+			acronymEnd := isUpper(prev) && isUpper(cur) && i+1 < len(field) && isLower(field[i+1])
+			if lowerToUpper || acronymEnd {
+				parts = append(parts, field[start:i])
+				start = i
+			}
+		}
+		parts = append(parts, field[start:])
+	}
+	return parts
+}
+
+func isUpper(b byte) bool { return b >= 'A' && b <= 'Z' }
+func isLower(b byte) bool { return b >= 'a' && b <= 'z' }
+
+// looksLikeSecretValue rejects values that are too short, look like dotted
+// references or hex digests, lack either letters or digits (words, version
+// strings), or have low entropy (placeholders such as "changeme-changeme").
+func looksLikeSecretValue(v string, threshold float64) bool {
+	if len(v) < secretMinValueLen {
+		return false
+	}
+	if strings.Contains(v, ".") || hexDigestPattern.MatchString(v) {
+		return false
+	}
+	if !strings.ContainsAny(v, "0123456789") || strings.IndexFunc(v, isASCIILetter) < 0 {
+		return false
+	}
+	return ShannonEntropy(v) >= threshold
+}
+
+func isASCIILetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// ShannonEntropy returns the entropy of s in bits per byte.
+func ShannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+	n := float64(len(s))
+	var h float64
+	for _, c := range counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
@@ -0,0 +1,168 @@
+package diffparse
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sanix-darker/prev/internal/core"
+)
+
+// Budget strategies applied when enriched changes exceed the token budget
+// and Serena is unavailable.
+const (
+	// BudgetReduceContext re-enriches every file with 3 context lines.
+	BudgetReduceContext = "reduce_context"
+	// BudgetDropLowestPriority omits whole files, least important first.
+	BudgetDropLowestPriority = "drop_lowest_priority_files"
+	// BudgetSummarizeLarge replaces the largest files with a line-range summary.
+	BudgetSummarizeLarge = "summarize_large_files"
+)
+
+// priorityBoost outweighs any realistic change size so glob matches always
+// decide the order before line counts do.
+const priorityBoost = 1 << 20
+
+// EnrichOptions tunes EnrichFileChangesWithOptions.
+type EnrichOptions struct {
+	// BudgetStrategy is one of the Budget* constants (default reduce_context).
+	BudgetStrategy string
+	// PriorityGlobs marks paths that are kept in full as long as possible.
+	PriorityGlobs []string
+	// LowPriorityGlobs marks paths that are dropped or summarized first.
+	LowPriorityGlobs []string
+	// HeadRef is the source-side commit (usually the MR head SHA) that new
+	// files are read from. The head branch name may not resolve in CI
+	// checkouts, and a new file has no other side to read from.
+	HeadRef string
+}
+
+// NormalizeBudgetStrategy maps unknown or empty values to BudgetReduceContext.
+func NormalizeBudgetStrategy(strategy string) string {
+	switch strings.ToLower(strings.TrimSpace(strategy)) {
+	case BudgetDropLowestPriority:
+		return BudgetDropLowestPriority
+	case BudgetSummarizeLarge:
+		return BudgetSummarizeLarge
+	default:
+		return BudgetReduceContext
+	}
+}
+
+// filePriority scores how important a file is to keep in the review context.
+// Larger changes score higher; priority and low-priority globs shift the score
+// by priorityBoost, and deleted or binary files rank below everything else.
+func filePriority(efc EnrichedFileChange, opts EnrichOptions) int {
+	name := efc.NewName
+	if name == "" {
+		name = efc.OldName
+	}
+	score := efc.Stats.Additions + efc.Stats.Deletions
+	if core.MatchesAnyPathGlob(name, opts.PriorityGlobs) {
+		score += priorityBoost
+	}
+	if core.MatchesAnyPathGlob(name, opts.LowPriorityGlobs) {
+		score -= priorityBoost
+	}
+	if efc.IsBinary || efc.IsDeleted {
+		score -= 2 * priorityBoost
+	}
+	return score
+}
+
+// dropLowestPriorityFiles omits files in ascending priority order until the
+// total estimate fits maxTokens. The most important file is always kept.
+func dropLowestPriorityFiles(enriched []EnrichedFileChange, maxTokens int, opts EnrichOptions) []EnrichedFileChange {
+	order := make([]int, len(enriched))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return filePriority(enriched[order[a]], opts) < filePriority(enriched[order[b]], opts)
+	})
+
+	total := totalTokenEstimate(enriched)
+	for _, idx := range order[:max(len(order)-1, 0)] {
+		if total <= maxTokens {
+			break
+		}
+		efc := &enriched[idx]
+		before := efc.TokenEstimate
+		efc.Enrichment = EnrichmentDropped
+		efc.EnrichmentError = "token budget exceeded"
+		efc.EnrichedHunks = nil
+		efc.TokenEstimate = len(FormatEnrichedForReview(*efc)) / 4
+		total -= before - efc.TokenEstimate
+	}
+	return enriched
+}
+
+// summarizeLargeFiles replaces file content with a changed-line summary,
+// starting with low-priority files and, within a tier, the largest estimate.
+func summarizeLargeFiles(enriched []EnrichedFileChange, maxTokens int, opts EnrichOptions) []EnrichedFileChange {
+	order := make([]int, len(enriched))
+	for i := range order {
+		order[i] = i
+	}
+	tier := func(efc EnrichedFileChange) int {
+		p := filePriority(efc, opts)
+		switch {
+		case p >= priorityBoost:
+			return 2
+		case p < 0:
+			return 0
+		default:
+			return 1
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ea, eb := enriched[order[a]], enriched[order[b]]
+		if ta, tb := tier(ea), tier(eb); ta != tb {
+			return ta < tb
+		}
+		return ea.TokenEstimate > eb.TokenEstimate
+	})
+
+	total := totalTokenEstimate(enriched)
+	for _, idx := range order {
+		if total <= maxTokens {
+			break
+		}
+		efc := &enriched[idx]
+		if efc.IsBinary || efc.Enrichment == EnrichmentSkipped {
+			continue
+		}
+		before := efc.TokenEstimate
+		efc.Enrichment = EnrichmentSummarized
+		efc.EnrichmentError = "token budget exceeded"
+		efc.EnrichedHunks = nil
+		efc.TokenEstimate = len(FormatEnrichedForReview(*efc)) / 4
+		total -= before - efc.TokenEstimate
+	}
+	return enriched
+}
+
+func totalTokenEstimate(enriched []EnrichedFileChange) int {
+	total := 0
+	for _, efc := range enriched {
+		total += efc.TokenEstimate
+	}
+	return total
+}
+
+// changedLineRanges lists the new-side line range of every hunk.
+func changedLineRanges(hunks []Hunk) []string {
+	out := make([]string, 0, len(hunks))
+	for _, h := range hunks {
+		end := h.NewStart + h.NewLines - 1
+		if end <= h.NewStart {
+			out = append(out, fmt.Sprintf("%d", h.NewStart))
+			continue
+		}
+		out = append(out, fmt.Sprintf("%d-%d", h.NewStart, end))
+	}
+	if len(out) == 0 {
+		out = append(out, "none")
+	}
+	return out
+}
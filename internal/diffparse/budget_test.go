@@ -0,0 +1,119 @@
+package diffparse
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addedFile builds a new-file change with n added lines.
+func addedFile(name string, n int) FileChange {
+	lines := make([]DiffLine, 0, n)
+	for i := 1; i <= n; i++ {
+		lines = append(lines, DiffLine{Type: LineAdded, Content: fmt.Sprintf("line %d of %s with some padding text", i, name), NewLineNo: i})
+	}
+	return FileChange{
+		NewName: name,
+		IsNew:   true,
+		Hunks:   []Hunk{{NewStart: 1, NewLines: n, Lines: lines}},
+		Stats:   DiffStats{Additions: n},
+	}
+}
+
+func findEnriched(t *testing.T, enriched []EnrichedFileChange, name string) EnrichedFileChange {
+	t.Helper()
+	for _, efc := range enriched {
+		if efc.NewName == name {
+			return efc
+		}
+	}
+	t.Fatalf("file %s not found", name)
+	return EnrichedFileChange{}
+}
+
+func TestEnrichFileChanges_DropLowestPriorityFiles(t *testing.T) {
+	changes := []FileChange{
+		addedFile("service/handler.go", 40),
+		addedFile("package-lock.json", 60),
+		addedFile("docs/notes.md", 5),
+	}
+	full, err := EnrichFileChanges(changes, "/nonexistent", "main", "feature", 3, 1<<30, nil)
+	require.NoError(t, err)
+	handler := findEnriched(t, full, "service/handler.go")
+	lock := findEnriched(t, full, "package-lock.json")
+	notes := findEnriched(t, full, "docs/notes.md")
+
+	// Budget fits the handler and the notes but not the lockfile.
+	budget := handler.TokenEstimate + notes.TokenEstimate + 50
+	require.Greater(t, handler.TokenEstimate+lock.TokenEstimate+notes.TokenEstimate, budget)
+
+	enriched, err := EnrichFileChangesWithOptions(changes, "/nonexistent", "main", "feature", 3, budget, nil, EnrichOptions{
+		BudgetStrategy:   BudgetDropLowestPriority,
+		LowPriorityGlobs: []string{"*-lock.json"},
+	})
+	require.NoError(t, err)
+	require.Len(t, enriched, 3)
+
+	droppedLock := findEnriched(t, enriched, "package-lock.json")
+	assert.Equal(t, EnrichmentDropped, droppedLock.Enrichment)
+	assert.Empty(t, droppedLock.EnrichedHunks)
+	assert.Contains(t, FormatEnrichedForReview(droppedLock), "Omitted from review context")
+
+	// Remaining files keep their full hunks instead of losing context.
+	keptHandler := findEnriched(t, enriched, "service/handler.go")
+	assert.NotEqual(t, EnrichmentDropped, keptHandler.Enrichment)
+	assert.Equal(t, handler.EnrichedHunks, keptHandler.EnrichedHunks)
+	keptNotes := findEnriched(t, enriched, "docs/notes.md")
+	assert.NotEqual(t, EnrichmentDropped, keptNotes.Enrichment)
+
+	assert.LessOrEqual(t, totalTokenEstimate(enriched), budget)
+}
+
+func TestDropLowestPriorityFiles_KeepsMostImportantFile(t *testing.T) {
+	enriched := []EnrichedFileChange{
+		{FileChange: FileChange{NewName: "a.go", Stats: DiffStats{Additions: 10}}, TokenEstimate: 500},
+		{FileChange: FileChange{NewName: "b.go", Stats: DiffStats{Additions: 90}}, TokenEstimate: 900},
+	}
+	out := dropLowestPriorityFiles(enriched, 10, EnrichOptions{})
+	assert.Equal(t, EnrichmentDropped, out[0].Enrichment)
+	assert.NotEqual(t, EnrichmentDropped, out[1].Enrichment)
+}
+
+func TestSummarizeLargeFiles_SummarizesLargestFirst(t *testing.T) {
+	changes := []FileChange{
+		addedFile("small.go", 3),
+		addedFile("large.go", 80),
+	}
+	full, err := EnrichFileChanges(changes, "/nonexistent", "main", "feature", 3, 1<<30, nil)
+	require.NoError(t, err)
+	small := findEnriched(t, full, "small.go")
+
+	enriched, err := EnrichFileChangesWithOptions(changes, "/nonexistent", "main", "feature", 3, small.TokenEstimate+60, nil, EnrichOptions{
+		BudgetStrategy: BudgetSummarizeLarge,
+	})
+	require.NoError(t, err)
+
+	large := findEnriched(t, enriched, "large.go")
+	assert.Equal(t, EnrichmentSummarized, large.Enrichment)
+	assert.Contains(t, FormatEnrichedForReview(large), "changed lines: 1-80")
+	assert.Equal(t, small.EnrichedHunks, findEnriched(t, enriched, "small.go").EnrichedHunks)
+}
+
+func TestFilePriority_GlobsOverrideChangeSize(t *testing.T) {
+	opts := EnrichOptions{PriorityGlobs: []string{"internal/auth/**"}, LowPriorityGlobs: []string{"vendor/**"}}
+	auth := EnrichedFileChange{FileChange: FileChange{NewName: "internal/auth/token.go", Stats: DiffStats{Additions: 1}}}
+	big := EnrichedFileChange{FileChange: FileChange{NewName: "cmd/main.go", Stats: DiffStats{Additions: 500}}}
+	vendored := EnrichedFileChange{FileChange: FileChange{NewName: "vendor/x/y.go", Stats: DiffStats{Additions: 900}}}
+
+	assert.Greater(t, filePriority(auth, opts), filePriority(big, opts))
+	assert.Greater(t, filePriority(big, opts), filePriority(vendored, opts))
+}
+
+func TestNormalizeBudgetStrategy(t *testing.T) {
+	assert.Equal(t, BudgetReduceContext, NormalizeBudgetStrategy(""))
+	assert.Equal(t, BudgetReduceContext, NormalizeBudgetStrategy("bogus"))
+	assert.Equal(t, BudgetDropLowestPriority, NormalizeBudgetStrategy(" DROP_LOWEST_PRIORITY_FILES "))
+	assert.Equal(t, BudgetSummarizeLarge, NormalizeBudgetStrategy("summarize_large_files"))
+}
@@ -11,6 +11,16 @@ import (
 	"github.com/sanix-darker/prev/internal/serena"
 )
 
+// Enrichment outcomes recorded per file by EnrichFileChanges.
+const (
+	EnrichmentContext     = "enriched"
+	EnrichmentSerena      = "serena"
+	EnrichmentRawFallback = "raw-fallback"
+	EnrichmentSkipped     = "skipped"
+	EnrichmentDropped     = "dropped"
+	EnrichmentSummarized  = "summarized"
+)
+
 // EnrichedFileChange is a FileChange augmented with surrounding code context.
 type EnrichedFileChange struct {
 	FileChange
@@ -18,6 +28,17 @@ type EnrichedFileChange struct {
 	FullNewContent string
 	EnrichedHunks  []EnrichedHunk
 	TokenEstimate  int
+	// Enrichment is one of the Enrichment* outcomes; EnrichmentError holds
+	// the reason when the file fell back to raw hunks.
+	Enrichment      string
+	EnrichmentError string
+}
+
+// EnrichmentOutcome reports how a single file was prepared for review.
+type EnrichmentOutcome struct {
+	File    string `json:"file"`
+	Outcome string `json:"outcome"`
+	Reason  string `json:"reason,omitempty"`
 }
 
 // EnrichedHunk is a Hunk with surrounding context lines.
@@ -95,13 +116,37 @@ func DetectLanguage(filePath string) string {
 
 // EnrichFileChanges takes parsed file changes and adds surrounding code context.
 // serenaClient can be nil (disabled). contextLines defaults to 10 if <= 0.
-// maxBatchTokens is the budget; if exceeded with Serena unavailable, contextLines is reduced.
+// maxBatchTokens is the budget; if exceeded with Serena unavailable, hunks in
+// supported languages (Go, Python) get their enclosing symbol as context
+// when that fits, and contextLines is reduced otherwise.
 func EnrichFileChanges(
 	changes []FileChange,
 	repoPath, baseBranch, targetBranch string,
 	contextLines int,
 	maxBatchTokens int,
 	serenaClient *serena.Client,
+) ([]EnrichedFileChange, error) {
+	return EnrichFileChangesWithOptions(changes, repoPath, baseBranch, targetBranch, contextLines, maxBatchTokens, serenaClient, EnrichOptions{})
+}
+
+// contentRef picks the ref a file's new content is read from: opts.HeadRef
+// for new files when set, the head branch otherwise.
+func contentRef(fc FileChange, headBranch string, opts EnrichOptions) string {
+	if fc.IsNew && strings.TrimSpace(opts.HeadRef) != "" {
+		return strings.TrimSpace(opts.HeadRef)
+	}
+	return headBranch
+}
+
+// EnrichFileChangesWithOptions is EnrichFileChanges with a configurable
+// strategy for diffs that exceed maxBatchTokens while Serena is unavailable.
+func EnrichFileChangesWithOptions(
+	changes []FileChange,
+	repoPath, baseBranch, targetBranch string,
+	contextLines int,
+	maxBatchTokens int,
+	serenaClient *serena.Client,
+	opts EnrichOptions,
 ) ([]EnrichedFileChange, error) {
 	if contextLines <= 0 {
 		contextLines = 10
@@ -125,14 +170,20 @@ func EnrichFileChanges(
 
 		if fc.IsBinary || fc.IsDeleted {
 			efc.TokenEstimate = 100
+			efc.Enrichment = EnrichmentSkipped
 			enriched = append(enriched, efc)
 			continue
 		}
 
-		// Get full file content from target branch
-		content, err := core.GetFileContent(repoPath, targetBranch, name)
+		// Get full file content from the source side of the diff
+		content, err := core.GetFileContent(repoPath, contentRef(fc, targetBranch, opts), name)
+		if err == nil && content == "" && fc.IsNew && opts.HeadRef != "" {
+			content, err = core.GetFileContent(repoPath, targetBranch, name)
+		}
 		if err != nil {
 			// Non-fatal: keep raw hunks so review context remains actionable.
+			efc.Enrichment = EnrichmentRawFallback
+			efc.EnrichmentError = strings.TrimSpace(err.Error())
 			efc.EnrichedHunks = fallbackEnrichedHunks(fc.Hunks)
 			formatted := FormatEnrichedForReview(efc)
 			efc.TokenEstimate = len(formatted) / 4
@@ -147,8 +198,11 @@ func EnrichFileChanges(
 		}
 
 		// Enrich hunks with context
+		efc.Enrichment = EnrichmentContext
 		efc.EnrichedHunks = enrichHunks(fc.Hunks, newLines, contextLines)
 		if len(efc.EnrichedHunks) == 0 && len(fc.Hunks) > 0 {
+			efc.Enrichment = EnrichmentRawFallback
+			efc.EnrichmentError = "no hunk context could be mapped onto file content"
 			efc.EnrichedHunks = fallbackEnrichedHunks(fc.Hunks)
 		}
 
@@ -165,13 +219,25 @@ func EnrichFileChanges(
 		totalTokens += efc.TokenEstimate
 	}
 
-	// If over budget, try Serena or reduce context
+	// If over budget, try Serena, then the built-in symbol finder, then the
+	// configured budget strategy
 	if totalTokens > maxBatchTokens {
 		if serenaClient != nil {
 			enriched = enrichWithSerena(enriched, serenaClient, repoPath)
-		} else if contextLines > 3 {
-			// Reduce context and re-enrich
-			return EnrichFileChanges(changes, repoPath, baseBranch, targetBranch, 3, maxBatchTokens, nil)
+		} else if symbolic, ok := enrichWithSymbols(enriched); ok && totalTokenEstimate(symbolic) <= maxBatchTokens {
+			enriched = symbolic
+		} else {
+			switch NormalizeBudgetStrategy(opts.BudgetStrategy) {
+			case BudgetDropLowestPriority:
+				enriched = dropLowestPriorityFiles(enriched, maxBatchTokens, opts)
+			case BudgetSummarizeLarge:
+				enriched = summarizeLargeFiles(enriched, maxBatchTokens, opts)
+			default:
+				if contextLines > 3 {
+					// Reduce context and re-enrich
+					return EnrichFileChangesWithOptions(changes, repoPath, baseBranch, targetBranch, 3, maxBatchTokens, nil, opts)
+				}
+			}
 		}
 	}
 
@@ -321,6 +387,7 @@ func enrichWithSerena(enriched []EnrichedFileChange, client *serena.Client, repo
 			eh.ContextAfter = nil
 			eh.StartLine = symbol.StartLine
 			eh.EndLine = symbol.EndLine
+			efc.Enrichment = EnrichmentSerena
 		}
 
 		// Recalculate token estimate
@@ -331,6 +398,50 @@ func enrichWithSerena(enriched []EnrichedFileChange, client *serena.Client, repo
 	return enriched
 }
 
+// EnrichmentOutcomes lists the per-file enrichment outcome in input order.
+func EnrichmentOutcomes(enriched []EnrichedFileChange) []EnrichmentOutcome {
+	out := make([]EnrichmentOutcome, 0, len(enriched))
+	for _, efc := range enriched {
+		name := efc.NewName
+		if name == "" {
+			name = efc.OldName
+		}
+		outcome := efc.Enrichment
+		if outcome == "" {
+			outcome = EnrichmentRawFallback
+		}
+		out = append(out, EnrichmentOutcome{File: name, Outcome: outcome, Reason: efc.EnrichmentError})
+	}
+	return out
+}
+
+// FormatEnrichmentSummary renders a one-line count of enrichment outcomes,
+// naming the files that fell back to raw hunks.
+func FormatEnrichmentSummary(outcomes []EnrichmentOutcome) string {
+	counts := map[string]int{}
+	var fallback []string
+	for _, o := range outcomes {
+		counts[o.Outcome]++
+		if o.Outcome == EnrichmentRawFallback {
+			fallback = append(fallback, o.File)
+		}
+	}
+	line := fmt.Sprintf("Context enrichment: %s=%d %s=%d %s=%d %s=%d",
+		EnrichmentContext, counts[EnrichmentContext],
+		EnrichmentSerena, counts[EnrichmentSerena],
+		EnrichmentRawFallback, counts[EnrichmentRawFallback],
+		EnrichmentSkipped, counts[EnrichmentSkipped])
+	for _, extra := range []string{EnrichmentSymbol, EnrichmentDropped, EnrichmentSummarized} {
+		if counts[extra] > 0 {
+			line += fmt.Sprintf(" %s=%d", extra, counts[extra])
+		}
+	}
+	if len(fallback) > 0 {
+		line += " (raw: " + strings.Join(fallback, ", ") + ")"
+	}
+	return line
+}
+
 // FormatEnrichedForReview formats an enriched file change for AI review.
 func FormatEnrichedForReview(efc EnrichedFileChange) string {
 	var sb strings.Builder
@@ -362,6 +473,16 @@ func FormatEnrichedForReview(efc EnrichedFileChange) string {
 		return sb.String()
 	}
 
+	switch efc.Enrichment {
+	case EnrichmentDropped:
+		sb.WriteString("Omitted from review context to fit the token budget.\n\n")
+		return sb.String()
+	case EnrichmentSummarized:
+		sb.WriteString(fmt.Sprintf("Summarized to fit the token budget; changed lines: %s.\n\n",
+			strings.Join(changedLineRanges(efc.Hunks), ", ")))
+		return sb.String()
+	}
+
 	for _, eh := range efc.EnrichedHunks {
 		sb.WriteString(fmt.Sprintf("### Lines %d-%d:\n", eh.StartLine, eh.EndLine))
 		sb.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n",
@@ -4,6 +4,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -138,6 +139,47 @@ func TestEnrichFileChanges(t *testing.T) {
 	assert.GreaterOrEqual(t, eh.StartLine, 1)
 }
 
+func TestEnrichFileChanges_ReportsRawFallbackOutcome(t *testing.T) {
+	repoPath, baseBranch, targetBranch := setupTestRepo(t)
+
+	changes := []FileChange{
+		{
+			OldName: "main.go",
+			NewName: "main.go",
+			Hunks: []Hunk{
+				{NewStart: 6, NewLines: 1, OldStart: 6, OldLines: 1, Lines: []DiffLine{
+					{Type: LineAdded, Content: `	fmt.Println("hello world!")`, NewLineNo: 6},
+				}},
+			},
+		},
+		{
+			NewName: "missing.go",
+			IsNew:   true,
+			Hunks: []Hunk{
+				{NewStart: 1, NewLines: 1, Lines: []DiffLine{
+					{Type: LineAdded, Content: "package main", NewLineNo: 1},
+				}},
+			},
+		},
+		{OldName: "gone.go", IsDeleted: true},
+	}
+
+	enriched, err := EnrichFileChanges(changes, repoPath, baseBranch, targetBranch, 3, 80000, nil)
+	require.NoError(t, err)
+
+	outcomes := EnrichmentOutcomes(enriched)
+	require.Len(t, outcomes, 3)
+	assert.Equal(t, EnrichmentOutcome{File: "main.go", Outcome: EnrichmentContext}, outcomes[0])
+	assert.Equal(t, "missing.go", outcomes[1].File)
+	assert.Equal(t, EnrichmentRawFallback, outcomes[1].Outcome)
+	assert.NotEmpty(t, outcomes[1].Reason)
+	assert.Equal(t, EnrichmentSkipped, outcomes[2].Outcome)
+
+	assert.Equal(t,
+		"Context enrichment: enriched=1 serena=0 raw-fallback=1 skipped=1 (raw: missing.go)",
+		FormatEnrichmentSummary(outcomes))
+}
+
 func TestHunkMerging(t *testing.T) {
 	// Two hunks close together should merge
 	lines := make([]string, 30)
@@ -183,6 +225,62 @@ func TestEnrich_NewFile(t *testing.T) {
 	assert.Contains(t, FormatEnrichedForReview(enriched[0]), "```go")
 }
 
+func TestEnrichFileChanges_NewFileReadFromHeadRef(t *testing.T) {
+	repoPath, baseBranch, targetBranch := setupTestRepo(t)
+
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repoPath}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Test",
+			"GIT_AUTHOR_EMAIL=test@test.com",
+			"GIT_COMMITTER_NAME=Test",
+			"GIT_COMMITTER_EMAIL=test@test.com",
+		)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v failed: %s", args, string(out))
+		return strings.TrimSpace(string(out))
+	}
+
+	// The MR head commit adds a file but the local feature branch lags behind,
+	// as in CI checkouts where only the head SHA is fetched.
+	var body strings.Builder
+	body.WriteString("package main\n\n")
+	for i := 0; i < 8; i++ {
+		body.WriteString("// filler\n")
+	}
+	body.WriteString("func added() {}\n")
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "added.go"), []byte(body.String()), 0644))
+	git("add", "added.go")
+	git("commit", "-m", "add file")
+	headSHA := git("rev-parse", "HEAD")
+	git("reset", "--hard", "HEAD~1")
+
+	changes := []FileChange{
+		{
+			NewName: "added.go",
+			IsNew:   true,
+			Hunks: []Hunk{
+				{NewStart: 11, NewLines: 1, Lines: []DiffLine{
+					{Type: LineAdded, Content: "func added() {}", NewLineNo: 11},
+				}},
+			},
+		},
+	}
+
+	fromBranch, err := EnrichFileChangesWithOptions(changes, repoPath, baseBranch, targetBranch, 3, 80000, nil, EnrichOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, EnrichmentRawFallback, fromBranch[0].Enrichment)
+
+	fromHead, err := EnrichFileChangesWithOptions(changes, repoPath, baseBranch, targetBranch, 3, 80000, nil, EnrichOptions{HeadRef: headSHA})
+	require.NoError(t, err)
+	require.Len(t, fromHead, 1)
+	assert.Equal(t, EnrichmentContext, fromHead[0].Enrichment)
+	assert.Contains(t, fromHead[0].FullNewContent, "func added() {}")
+	require.NotEmpty(t, fromHead[0].EnrichedHunks)
+	assert.NotEmpty(t, fromHead[0].EnrichedHunks[0].ContextBefore)
+}
+
 func TestEnrich_DeletedFile(t *testing.T) {
 	changes := []FileChange{
 		{
@@ -0,0 +1,179 @@
+package diffparse
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strings"
+)
+
+// EnrichmentSymbol marks a file whose hunks were widened to their enclosing
+// function or class by the built-in symbol finder (used without Serena).
+const EnrichmentSymbol = "symbol"
+
+// maxSymbolLines caps the symbols used as context; a hunk inside a larger
+// function keeps its line context.
+const maxSymbolLines = 300
+
+// enclosingSymbol is the line span of a function, method or class.
+type enclosingSymbol struct {
+	Name      string
+	StartLine int // 1-based, inclusive
+	EndLine   int // 1-based, inclusive
+}
+
+// symbolFinders are the languages the built-in finder understands. Go is
+// parsed with go/parser; Python blocks are delimited by indentation.
+var symbolFinders = map[string]func(content string, line int) (enclosingSymbol, bool){
+	"go":     findGoEnclosingSymbol,
+	"python": findPythonEnclosingSymbol,
+}
+
+// findEnclosingSymbol returns the innermost symbol of the given language
+// spanning line in content.
+func findEnclosingSymbol(language, content string, line int) (enclosingSymbol, bool) {
+	find, ok := symbolFinders[language]
+	if !ok || content == "" || line <= 0 {
+		return enclosingSymbol{}, false
+	}
+	return find(content, line)
+}
+
+// findGoEnclosingSymbol returns the innermost function or type declaration
+// spanning line. A file with syntax errors is still searched as far as the
+// parser got.
+func findGoEnclosingSymbol(content string, line int) (enclosingSymbol, bool) {
+	fset := token.NewFileSet()
+	f, _ := parser.ParseFile(fset, "", content, parser.ParseComments|parser.SkipObjectResolution)
+	if f == nil {
+		return enclosingSymbol{}, false
+	}
+	var best enclosingSymbol
+	found := false
+	consider := func(name string, from, to token.Pos) {
+		start, end := fset.Position(from).Line, fset.Position(to).Line
+		if start > line || end < line {
+			return
+		}
+		if !found || end-start < best.EndLine-best.StartLine {
+			best = enclosingSymbol{Name: name, StartLine: start, EndLine: end}
+			found = true
+		}
+	}
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			from := d.Pos()
+			if d.Doc != nil {
+				from = d.Doc.Pos()
+			}
+			consider(d.Name.Name, from, d.End())
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if len(d.Specs) == 1 {
+					consider(ts.Name.Name, d.Pos(), d.End())
+				} else {
+					consider(ts.Name.Name, ts.Pos(), ts.End())
+				}
+			}
+		}
+	}
+	return best, found
+}
+
+var pythonBlockHeader = regexp.MustCompile(`^(\s*)(?:async\s+def|def|class)\s+([A-Za-z_][A-Za-z0-9_]*)`)
+
+// findPythonEnclosingSymbol returns the innermost def or class spanning
+// line. A block ends before the next code line indented no deeper than its
+// header; decorators directly above the header belong to it.
+func findPythonEnclosingSymbol(content string, line int) (enclosingSymbol, bool) {
+	lines := strings.Split(content, "\n")
+	if line > len(lines) {
+		return enclosingSymbol{}, false
+	}
+	var best enclosingSymbol
+	found := false
+	for i, l := range lines {
+		if i+1 > line {
+			break
+		}
+		m := pythonBlockHeader.FindStringSubmatch(l)
+		if m == nil {
+			continue
+		}
+		indent := len(m[1])
+		end := i + 1
+		for j := i + 1; j < len(lines); j++ {
+			trimmed := strings.TrimSpace(lines[j])
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+			if pythonIndent(lines[j]) <= indent && !strings.ContainsAny(trimmed[:1], ")]}") {
+				break
+			}
+			end = j + 1
+		}
+		if end < line {
+			continue
+		}
+		start := i + 1
+		for start > 1 && strings.HasPrefix(strings.TrimSpace(lines[start-2]), "@") &&
+			pythonIndent(lines[start-2]) == indent {
+			start--
+		}
+		if !found || end-start < best.EndLine-best.StartLine {
+			best = enclosingSymbol{Name: m[2], StartLine: start, EndLine: end}
+			found = true
+		}
+	}
+	return best, found
+}
+
+func pythonIndent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// enrichWithSymbols is the Serena-less counterpart of enrichWithSerena: it
+// replaces each hunk's line context with its enclosing function or class,
+// found in FullNewContent by the built-in finder. Hunks in unsupported
+// languages, outside any symbol or inside an oversized one are kept as they
+// are. The input is not modified.
+func enrichWithSymbols(enriched []EnrichedFileChange) ([]EnrichedFileChange, bool) {
+	out := make([]EnrichedFileChange, len(enriched))
+	changed := false
+	for i, efc := range enriched {
+		out[i] = efc
+		if efc.IsBinary || efc.IsDeleted || efc.FullNewContent == "" || symbolFinders[efc.Language] == nil {
+			continue
+		}
+		lines := strings.Split(efc.FullNewContent, "\n")
+		hunks := make([]EnrichedHunk, len(efc.EnrichedHunks))
+		copy(hunks, efc.EnrichedHunks)
+		fileChanged := false
+		for j := range hunks {
+			eh := &hunks[j]
+			symbol, ok := findEnclosingSymbol(efc.Language, efc.FullNewContent, eh.Hunk.NewStart)
+			if !ok || symbol.EndLine > len(lines) || symbol.EndLine-symbol.StartLine+1 > maxSymbolLines {
+				continue
+			}
+			eh.ContextBefore = lines[symbol.StartLine-1 : symbol.EndLine]
+			eh.ContextAfter = nil
+			eh.StartLine = symbol.StartLine
+			eh.EndLine = symbol.EndLine
+			fileChanged = true
+		}
+		if !fileChanged {
+			continue
+		}
+		out[i].EnrichedHunks = hunks
+		out[i].Enrichment = EnrichmentSymbol
+		out[i].TokenEstimate = len(FormatEnrichedForReview(out[i])) / 4
+		changed = true
+	}
+	return out, changed
+}
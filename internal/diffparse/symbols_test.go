@@ -0,0 +1,130 @@
+package diffparse
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const goSymbolSource = `package demo
+
+import "fmt"
+
+// Greeter says hello.
+type Greeter struct {
+	Name string
+}
+
+// Greet prints a greeting.
+func (g Greeter) Greet() {
+	msg := "hello " + g.Name
+	fmt.Println(msg)
+}
+
+func helper() int {
+	return 42
+}
+`
+
+const pySymbolSource = `import os
+
+
+class Store:
+    def __init__(self, path):
+        self.path = path
+
+    @property
+    def exists(self):
+        return os.path.exists(
+            self.path,
+        )
+
+
+def top_level():
+    return 1
+`
+
+func TestFindEnclosingSymbol_Go(t *testing.T) {
+	tests := []struct {
+		line      int
+		name      string
+		start     int
+		end       int
+		wantFound bool
+	}{
+		{line: 12, name: "Greet", start: 10, end: 14, wantFound: true},
+		{line: 17, name: "helper", start: 16, end: 18, wantFound: true},
+		{line: 7, name: "Greeter", start: 6, end: 8, wantFound: true},
+		{line: 3, wantFound: false},
+	}
+	for _, tt := range tests {
+		got, ok := findEnclosingSymbol("go", goSymbolSource, tt.line)
+		require.Equal(t, tt.wantFound, ok, "line %d", tt.line)
+		if tt.wantFound {
+			assert.Equal(t, enclosingSymbol{Name: tt.name, StartLine: tt.start, EndLine: tt.end}, got, "line %d", tt.line)
+		}
+	}
+}
+
+func TestFindEnclosingSymbol_Python(t *testing.T) {
+	tests := []struct {
+		line      int
+		name      string
+		start     int
+		end       int
+		wantFound bool
+	}{
+		{line: 6, name: "__init__", start: 5, end: 6, wantFound: true},
+		{line: 11, name: "exists", start: 8, end: 12, wantFound: true},
+		{line: 7, name: "Store", start: 4, end: 12, wantFound: true},
+		{line: 16, name: "top_level", start: 15, end: 16, wantFound: true},
+		{line: 1, wantFound: false},
+	}
+	for _, tt := range tests {
+		got, ok := findEnclosingSymbol("python", pySymbolSource, tt.line)
+		require.Equal(t, tt.wantFound, ok, "line %d", tt.line)
+		if tt.wantFound {
+			assert.Equal(t, enclosingSymbol{Name: tt.name, StartLine: tt.start, EndLine: tt.end}, got, "line %d", tt.line)
+		}
+	}
+}
+
+func TestFindEnclosingSymbol_UnsupportedLanguage(t *testing.T) {
+	_, ok := findEnclosingSymbol("ruby", "def x\nend\n", 1)
+	assert.False(t, ok)
+}
+
+func TestEnrichWithSymbols_ReplacesContext(t *testing.T) {
+	efc := EnrichedFileChange{
+		FileChange:     FileChange{NewName: "demo.go"},
+		Language:       "go",
+		FullNewContent: goSymbolSource,
+		Enrichment:     EnrichmentContext,
+		EnrichedHunks: []EnrichedHunk{{
+			Hunk:          Hunk{NewStart: 12, NewLines: 1},
+			ContextBefore: []string{"unrelated"},
+			ContextAfter:  []string{"unrelated"},
+			StartLine:     1,
+			EndLine:       18,
+		}},
+	}
+	in := []EnrichedFileChange{efc, {FileChange: FileChange{NewName: "a.rb"}, Language: "ruby", FullNewContent: "x"}}
+
+	out, changed := enrichWithSymbols(in)
+	require.True(t, changed)
+	got := out[0]
+	assert.Equal(t, EnrichmentSymbol, got.Enrichment)
+	require.Len(t, got.EnrichedHunks, 1)
+	eh := got.EnrichedHunks[0]
+	assert.Equal(t, 10, eh.StartLine)
+	assert.Equal(t, 14, eh.EndLine)
+	assert.Nil(t, eh.ContextAfter)
+	assert.Equal(t, "// Greet prints a greeting.", eh.ContextBefore[0])
+	assert.True(t, strings.HasPrefix(eh.ContextBefore[len(eh.ContextBefore)-1], "}"))
+	assert.Equal(t, in[1], out[1])
+	// The input is left untouched so the caller can fall back to it.
+	assert.Equal(t, []string{"unrelated"}, in[0].EnrichedHunks[0].ContextBefore)
+	assert.Equal(t, EnrichmentContext, in[0].Enrichment)
+}
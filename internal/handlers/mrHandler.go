@@ -15,11 +15,31 @@ type MRReview struct {
 	MR      *vcs.MergeRequest
 	Changes []diffparse.FileChange
 	Prompt  string
+	// Enrichment records how each changed file was prepared for the prompt.
+	Enrichment []diffparse.EnrichmentOutcome
+	// ReportedBaseSHA is the provider-reported base when it was replaced by
+	// the computed merge-base; empty when no correction was applied.
+	ReportedBaseSHA string
+	// Context reports whether an alternate context source was used.
+	Context MRContextUsage
+}
+
+// MRContextUsage records how a configured vcs.MRContextSource contributed to
+// the review.
+type MRContextUsage struct {
+	Source   string // source name; empty when none was configured
+	Metadata bool   // MR metadata came from Source
+	Diffs    bool   // MR diffs came from Source
+	Fallback string // why Source was bypassed for the provider API, if it failed
 }
 
 type MRExtractOptions struct {
-	DiffSource string // auto|git|raw|api
-	RepoPath   string
+	DiffSource   string // auto|git|compare|raw|api
+	RepoPath     string
+	UseMergeBase bool
+	// ContextSource, when set, is tried for MR metadata and diffs before
+	// the provider API; failures fall back to the provider.
+	ContextSource vcs.MRContextSource
 }
 
 // ExtractMRHandler fetches MR details and diffs, then builds a review prompt.
@@ -42,12 +62,31 @@ func ExtractMRHandlerWithOptions(
 	opts MRExtractOptions,
 ) (*MRReview, error) {
 	// Fetch MR details
-	mr, err := provider.FetchMR(ctx, projectID, mrIID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch MR: %w", err)
+	var usage MRContextUsage
+	var mr *vcs.MergeRequest
+	if opts.ContextSource != nil {
+		usage.Source = opts.ContextSource.Name()
+		m, err := opts.ContextSource.FetchMR(ctx, projectID, mrIID)
+		if err != nil {
+			usage.Fallback = err.Error()
+		} else {
+			mr = m
+			usage.Metadata = true
+		}
+	}
+	if mr == nil {
+		m, err := provider.FetchMR(ctx, projectID, mrIID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch MR: %w", err)
+		}
+		mr = m
+	}
+	reportedBase := ""
+	if opts.UseMergeBase {
+		reportedBase = correctMergeBase(ctx, provider, projectID, mr, opts.RepoPath)
 	}
 
-	changes, err := extractMRChanges(ctx, provider, projectID, mrIID, mr, opts)
+	changes, err := extractMRChanges(ctx, provider, projectID, mrIID, mr, opts, &usage)
 	if err != nil {
 		return nil, err
 	}
@@ -70,12 +109,46 @@ func ExtractMRHandlerWithOptions(
 	)
 
 	return &MRReview{
-		MR:      mr,
-		Changes: changes,
-		Prompt:  prompt,
+		MR:              mr,
+		Changes:         changes,
+		Prompt:          prompt,
+		ReportedBaseSHA: reportedBase,
+		Context:         usage,
 	}, nil
 }
 
+// correctMergeBase replaces DiffRefs.BaseSHA/StartSHA with the true merge-base
+// of base and head. Fork MRs can report a base that lags the target branch,
+// which shifts old-side line positions. The provider's resolver is tried
+// first, then the local repository. It returns the replaced base SHA, or ""
+// when refs were left untouched.
+func correctMergeBase(ctx context.Context, provider vcs.VCSProvider, projectID string, mr *vcs.MergeRequest, repoPath string) string {
+	base := strings.TrimSpace(mr.DiffRefs.BaseSHA)
+	head := strings.TrimSpace(mr.DiffRefs.HeadSHA)
+	if base == "" || head == "" {
+		return ""
+	}
+
+	mergeBase := ""
+	if r, ok := provider.(vcs.MergeBaseResolver); ok {
+		if sha, err := r.MergeBase(ctx, projectID, base, head); err == nil {
+			mergeBase = sha
+		}
+	}
+	if mergeBase == "" && strings.TrimSpace(repoPath) != "" {
+		if sha, err := core.GetGitMergeBase(repoPath, base, head); err == nil {
+			mergeBase = sha
+		}
+	}
+	if mergeBase == "" || mergeBase == base {
+		return ""
+	}
+
+	mr.DiffRefs.BaseSHA = mergeBase
+	mr.DiffRefs.StartSHA = mergeBase
+	return base
+}
+
 func extractMRChanges(
 	ctx context.Context,
 	provider vcs.VCSProvider,
@@ -83,6 +156,7 @@ func extractMRChanges(
 	mrIID int64,
 	mr *vcs.MergeRequest,
 	opts MRExtractOptions,
+	usage *MRContextUsage,
 ) ([]diffparse.FileChange, error) {
 	source := normalizeDiffSource(opts.DiffSource)
 
@@ -103,6 +177,25 @@ func extractMRChanges(
 		}
 	}
 
+	if source == "compare" {
+		return extractCompareChanges(ctx, provider, projectID, mr)
+	}
+
+	// The alternate context source replaces the provider's diff endpoints,
+	// unless its metadata call already failed.
+	if opts.ContextSource != nil && source != "git" && usage.Fallback == "" {
+		diffs, err := opts.ContextSource.FetchMRDiffs(ctx, projectID, mrIID)
+		if err == nil {
+			changes, perr := parseFileDiffs(diffs)
+			if perr == nil {
+				usage.Diffs = true
+				return changes, nil
+			}
+			err = perr
+		}
+		usage.Fallback = err.Error()
+	}
+
 	if source == "raw" || source == "auto" {
 		raw, err := provider.FetchMRRawDiff(ctx, projectID, mrIID)
 		if err == nil && strings.TrimSpace(raw) != "" {
@@ -121,6 +214,30 @@ func extractMRChanges(
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch MR diffs: %w", err)
 	}
+	return parseFileDiffs(mrDiffs)
+}
+
+// extractCompareChanges builds the MR changes from the provider's compare
+// API between the MR's base and head commits. Unlike the git source it needs
+// no local checkout, which shallow CI clones lack.
+func extractCompareChanges(ctx context.Context, provider vcs.VCSProvider, projectID string, mr *vcs.MergeRequest) ([]diffparse.FileChange, error) {
+	differ, ok := provider.(vcs.CompareDiffer)
+	if !ok {
+		return nil, fmt.Errorf("diff source compare is not supported by %s; use auto, git, raw or api", provider.Info().Name)
+	}
+	base := strings.TrimSpace(mr.DiffRefs.BaseSHA)
+	head := strings.TrimSpace(mr.DiffRefs.HeadSHA)
+	if base == "" || head == "" {
+		return nil, fmt.Errorf("diff source compare needs the MR base and head commits, which the provider did not report")
+	}
+	diffs, err := differ.CompareDiffs(ctx, projectID, base, head)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build MR changes from compare %s...%s: %w", base, head, err)
+	}
+	return parseFileDiffs(diffs)
+}
+
+func parseFileDiffs(mrDiffs []vcs.FileDiff) ([]diffparse.FileChange, error) {
 	var glDiffs []diffparse.GitLabDiff
 	for _, d := range mrDiffs {
 		glDiffs = append(glDiffs, diffparse.GitLabDiff{
@@ -141,7 +258,7 @@ func extractMRChanges(
 
 func normalizeDiffSource(source string) string {
 	switch strings.ToLower(strings.TrimSpace(source)) {
-	case "git", "raw", "api":
+	case "git", "compare", "raw", "api":
 		return strings.ToLower(strings.TrimSpace(source))
 	default:
 		return "auto"
@@ -2,8 +2,14 @@ package handlers
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/sanix-darker/prev/internal/diffparse"
 	"github.com/sanix-darker/prev/internal/vcs"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -41,6 +47,9 @@ func (m *mockMRVCSProvider) PostInlineComment(context.Context, string, int64, vc
 func (m *mockMRVCSProvider) ReplyToMRDiscussion(context.Context, string, int64, string, string) error {
 	return nil
 }
+func (m *mockMRVCSProvider) ResolveMRDiscussion(context.Context, string, int64, string) error {
+	return nil
+}
 func (m *mockMRVCSProvider) FormatSuggestionBlock(s string) string { return s }
 func (m *mockMRVCSProvider) Validate() error                       { return nil }
 
@@ -50,6 +59,62 @@ func TestNormalizeDiffSource(t *testing.T) {
 	assert.Equal(t, "git", normalizeDiffSource("git"))
 	assert.Equal(t, "raw", normalizeDiffSource("RAW"))
 	assert.Equal(t, "api", normalizeDiffSource("api"))
+	assert.Equal(t, "compare", normalizeDiffSource("Compare"))
+}
+
+// compareMRVCSProvider serves diffs through the compare API only.
+type compareMRVCSProvider struct {
+	mockMRVCSProvider
+	compared []string
+}
+
+func (c *compareMRVCSProvider) CompareDiffs(_ context.Context, _ string, fromSHA, toSHA string) ([]vcs.FileDiff, error) {
+	c.compared = append(c.compared, fromSHA+"..."+toSHA)
+	return c.diffs, nil
+}
+
+func TestExtractMRHandlerWithOptions_CompareSource(t *testing.T) {
+	provider := &compareMRVCSProvider{mockMRVCSProvider: mockMRVCSProvider{
+		mr: &vcs.MergeRequest{
+			IID:          42,
+			SourceBranch: "feature",
+			TargetBranch: "main",
+			DiffRefs:     vcs.DiffRefs{BaseSHA: "aaa", HeadSHA: "bbb", StartSHA: "aaa"},
+		},
+		diffs: []vcs.FileDiff{{
+			OldPath: "app.go",
+			NewPath: "app.go",
+			Diff:    "@@ -10,2 +10,3 @@ func main() {\n \tx := 1\n+\ty := 2\n \treturn\n",
+		}},
+		// A stale raw diff proves the compare API is what got used.
+		rawDiff: "diff --git a/other.go b/other.go\n--- a/other.go\n+++ b/other.go\n@@ -1,1 +1,2 @@\n a\n+b\n",
+	}}
+
+	got, err := ExtractMRHandlerWithOptions(context.Background(), provider, "grp/proj", 42, "normal", MRExtractOptions{
+		DiffSource: "compare",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"aaa...bbb"}, provider.compared)
+	require.Len(t, got.Changes, 1)
+	assert.Equal(t, "app.go", got.Changes[0].NewName)
+	require.NotEmpty(t, got.Changes[0].Hunks)
+	assert.Equal(t, 10, got.Changes[0].Hunks[0].NewStart)
+	var added []int
+	for _, l := range got.Changes[0].Hunks[0].Lines {
+		if l.Type == diffparse.LineAdded {
+			added = append(added, l.NewLineNo)
+		}
+	}
+	assert.Equal(t, []int{11}, added)
+}
+
+func TestExtractMRHandlerWithOptions_CompareSourceUnsupported(t *testing.T) {
+	provider := &mockMRVCSProvider{mr: &vcs.MergeRequest{DiffRefs: vcs.DiffRefs{BaseSHA: "aaa", HeadSHA: "bbb"}}}
+	_, err := ExtractMRHandlerWithOptions(context.Background(), provider, "grp/proj", 42, "normal", MRExtractOptions{
+		DiffSource: "compare",
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not supported by mock")
 }
 
 func TestExtractMRHandlerWithOptions_RawDiffPreferred(t *testing.T) {
@@ -95,3 +160,165 @@ func TestExtractMRHandlerWithOptions_FailsOnNoHunks(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "no reviewable modified hunks found")
 }
+
+// setupForkRepo builds a fork scenario: feature branches from A, then main
+// moves to B by inserting lines above the code the feature touches. The
+// provider reports B as the MR base although the merge-base is A.
+func setupForkRepo(t *testing.T) (dir, mergeBase, staleBase, head string) {
+	t.Helper()
+	dir = t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@test.com",
+			"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@test.com",
+		)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v failed: %s", args, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	write := func(content string) {
+		t.Helper()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "app.go"), []byte(content), 0644))
+	}
+
+	git("init", "-b", "main")
+	write("package app\n\nfunc a() {}\nfunc b() {}\nfunc c() {}\n")
+	git("add", ".")
+	git("commit", "-m", "A")
+	mergeBase = git("rev-parse", "HEAD")
+
+	git("checkout", "-b", "feature")
+	write("package app\n\nfunc a() {}\nfunc b() { return }\nfunc c() {}\n")
+	git("commit", "-am", "feature change")
+	head = git("rev-parse", "HEAD")
+
+	git("checkout", "main")
+	write("package app\n\n// one\n// two\n// three\nfunc a() {}\nfunc b() {}\nfunc c() {}\n")
+	git("commit", "-am", "B")
+	staleBase = git("rev-parse", "HEAD")
+	return dir, mergeBase, staleBase, head
+}
+
+// oldLineOfChange diffs base..head directly, the way base-anchored inline
+// positions are computed, and returns the old-side line of the first removal.
+func oldLineOfChange(t *testing.T, repo, base, head string) int {
+	t.Helper()
+	out, err := exec.Command("git", "-C", repo, "diff", base, head).Output()
+	require.NoError(t, err)
+	changes, err := diffparse.ParseGitDiff(string(out))
+	require.NoError(t, err)
+	for _, c := range changes {
+		for _, h := range c.Hunks {
+			for _, l := range h.Lines {
+				if l.Type == diffparse.LineDeleted && strings.Contains(l.Content, "func b()") {
+					return l.OldLineNo
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func TestExtractMRHandlerWithOptions_MergeBaseCorrectsForkPositions(t *testing.T) {
+	repo, mergeBase, staleBase, head := setupForkRepo(t)
+	newProvider := func() *mockMRVCSProvider {
+		return &mockMRVCSProvider{mr: &vcs.MergeRequest{
+			IID:          7,
+			SourceBranch: "feature",
+			TargetBranch: "main",
+			DiffRefs:     vcs.DiffRefs{BaseSHA: staleBase, HeadSHA: head, StartSHA: staleBase},
+		}}
+	}
+
+	plain, err := ExtractMRHandlerWithOptions(context.Background(), newProvider(), "grp/proj", 7, "normal", MRExtractOptions{
+		DiffSource: "git",
+		RepoPath:   repo,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, staleBase, plain.MR.DiffRefs.BaseSHA)
+	assert.Empty(t, plain.ReportedBaseSHA)
+
+	corrected, err := ExtractMRHandlerWithOptions(context.Background(), newProvider(), "grp/proj", 7, "normal", MRExtractOptions{
+		DiffSource:   "git",
+		RepoPath:     repo,
+		UseMergeBase: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, mergeBase, corrected.MR.DiffRefs.BaseSHA)
+	assert.Equal(t, mergeBase, corrected.MR.DiffRefs.StartSHA)
+	assert.Equal(t, staleBase, corrected.ReportedBaseSHA)
+
+	// The changed line is line 4 at the merge-base; anchoring against the
+	// stale base shifts it by the three lines main added meanwhile.
+	assert.Equal(t, 4, oldLineOfChange(t, repo, corrected.MR.DiffRefs.BaseSHA, head))
+	assert.Equal(t, 7, oldLineOfChange(t, repo, plain.MR.DiffRefs.BaseSHA, head))
+}
+
+type stubContextSource struct {
+	mr       *vcs.MergeRequest
+	diffs    []vcs.FileDiff
+	mrErr    error
+	diffsErr error
+}
+
+func (s *stubContextSource) Name() string { return "stub-mcp" }
+func (s *stubContextSource) FetchMR(context.Context, string, int64) (*vcs.MergeRequest, error) {
+	return s.mr, s.mrErr
+}
+func (s *stubContextSource) FetchMRDiffs(context.Context, string, int64) ([]vcs.FileDiff, error) {
+	return s.diffs, s.diffsErr
+}
+
+func TestExtractMRHandlerWithOptions_ContextSourceUsed(t *testing.T) {
+	provider := &mockMRVCSProvider{
+		mr:    &vcs.MergeRequest{IID: 42, Title: "from api"},
+		diffs: []vcs.FileDiff{{OldPath: "api.go", NewPath: "api.go", Diff: "@@ -1,1 +1,2 @@\n a\n+b\n"}},
+	}
+	src := &stubContextSource{
+		mr:    &vcs.MergeRequest{IID: 42, Title: "from mcp"},
+		diffs: []vcs.FileDiff{{OldPath: "mcp.go", NewPath: "mcp.go", Diff: "@@ -1,1 +1,2 @@\n a\n+b\n"}},
+	}
+	got, err := ExtractMRHandlerWithOptions(context.Background(), provider, "grp/proj", 42, "normal", MRExtractOptions{
+		DiffSource:    "api",
+		ContextSource: src,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "from mcp", got.MR.Title)
+	require.Len(t, got.Changes, 1)
+	assert.Equal(t, "mcp.go", got.Changes[0].NewName)
+	assert.Equal(t, MRContextUsage{Source: "stub-mcp", Metadata: true, Diffs: true}, got.Context)
+}
+
+func TestExtractMRHandlerWithOptions_ContextSourceFallsBack(t *testing.T) {
+	provider := &mockMRVCSProvider{
+		mr:    &vcs.MergeRequest{IID: 42, Title: "from api"},
+		diffs: []vcs.FileDiff{{OldPath: "api.go", NewPath: "api.go", Diff: "@@ -1,1 +1,2 @@\n a\n+b\n"}},
+	}
+
+	got, err := ExtractMRHandlerWithOptions(context.Background(), provider, "grp/proj", 42, "normal", MRExtractOptions{
+		DiffSource:    "api",
+		ContextSource: &stubContextSource{mrErr: fmt.Errorf("connection refused")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "from api", got.MR.Title)
+	assert.Equal(t, "api.go", got.Changes[0].NewName)
+	assert.False(t, got.Context.Metadata)
+	assert.False(t, got.Context.Diffs)
+	assert.Equal(t, "connection refused", got.Context.Fallback)
+
+	got, err = ExtractMRHandlerWithOptions(context.Background(), provider, "grp/proj", 42, "normal", MRExtractOptions{
+		DiffSource: "api",
+		ContextSource: &stubContextSource{
+			mr:       &vcs.MergeRequest{IID: 42, Title: "from mcp"},
+			diffsErr: fmt.Errorf("tool missing"),
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "from mcp", got.MR.Title)
+	assert.Equal(t, "api.go", got.Changes[0].NewName)
+	assert.True(t, got.Context.Metadata)
+	assert.False(t, got.Context.Diffs)
+	assert.Equal(t, "tool missing", got.Context.Fallback)
+}
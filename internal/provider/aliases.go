@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"strings"
+
+	"github.com/sanix-darker/prev/internal/config"
+)
+
+// builtinModelAliases maps friendly model names to full model IDs per
+// provider. providers.<name>.model_aliases entries override them.
+var builtinModelAliases = map[string]map[string]string{
+	"openai": {
+		"gpt4":      "gpt-4o",
+		"gpt4o":     "gpt-4o",
+		"gpt4-mini": "gpt-4o-mini",
+		"mini":      "gpt-4o-mini",
+		"gpt41":     "gpt-4.1",
+	},
+	"anthropic": {
+		"sonnet": "claude-sonnet-4-20250514",
+		"opus":   "claude-opus-4-20250514",
+		"haiku":  "claude-3-5-haiku-20241022",
+	},
+	"gemini": {
+		"flash": "gemini-2.0-flash",
+		"pro":   "gemini-2.5-pro",
+	},
+	"mistral": {
+		"large":     "mistral-large-latest",
+		"medium":    "mistral-medium-latest",
+		"small":     "mistral-small-latest",
+		"codestral": "codestral-latest",
+	},
+}
+
+func init() {
+	builtinModelAliases["claude"] = builtinModelAliases["anthropic"]
+}
+
+// ModelAliases returns the alias table for a provider: the built-in aliases
+// overlaid with providers.<name>.model_aliases from v. Keys are lowercased.
+func ModelAliases(providerName string, v *config.Store) map[string]string {
+	out := map[string]string{}
+	for k, id := range builtinModelAliases[strings.ToLower(providerName)] {
+		out[k] = id
+	}
+	if v != nil {
+		for k, id := range v.GetStringMapString("model_aliases") {
+			if k = strings.ToLower(strings.TrimSpace(k)); k != "" && strings.TrimSpace(id) != "" {
+				out[k] = strings.TrimSpace(id)
+			}
+		}
+	}
+	return out
+}
+
+// ResolveModelAlias expands model through aliases (case-insensitive) and
+// returns it unchanged when it is not an alias.
+func ResolveModelAlias(aliases map[string]string, model string) string {
+	if id, ok := aliases[strings.ToLower(strings.TrimSpace(model))]; ok {
+		return id
+	}
+	return model
+}
@@ -7,6 +7,7 @@
 //   - Streaming uses distinct event types (content_block_delta, etc.).
 //   - Authentication uses "x-api-key" header, not Bearer tokens.
 //   - max_tokens is required (not optional).
+//   - A trailing assistant message is a prefill the model continues.
 //
 // This implementation normalizes all of those differences behind the
 // provider.AIProvider interface.
@@ -21,6 +22,7 @@ import (
 	"net/http"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/sanix-darker/prev/internal/config"
 	"github.com/sanix-darker/prev/internal/provider"
@@ -112,12 +114,14 @@ const anthropicVersion = "2023-06-01"
 
 // Provider implements provider.AIProvider for the Anthropic Messages API.
 type Provider struct {
-	client   *http.Client
-	apiKey   string
-	baseURL  string
-	model    string
-	maxTok   int
-	retryCfg provider.RetryConfig
+	client      *http.Client
+	apiKey      string
+	baseURL     string
+	model       string
+	aliases     map[string]string
+	maxTok      int
+	retryCfg    provider.RetryConfig
+	clampNotice provider.ClampNotice
 }
 
 // NewProvider is the factory function registered with the provider registry.
@@ -131,6 +135,8 @@ func NewProvider(v *config.Store) (provider.AIProvider, error) {
 	if model == "" {
 		model = "claude-sonnet-4-20250514"
 	}
+	aliases := provider.ModelAliases("anthropic", v)
+	model = provider.ResolveModelAlias(aliases, model)
 	maxTok := v.GetInt("max_tokens")
 	if maxTok == 0 {
 		maxTok = 1024
@@ -145,6 +151,7 @@ func NewProvider(v *config.Store) (provider.AIProvider, error) {
 		apiKey:   apiKey,
 		baseURL:  strings.TrimRight(baseURL, "/"),
 		model:    model,
+		aliases:  aliases,
 		maxTok:   maxTok,
 		retryCfg: provider.DefaultRetryConfig(),
 	}, nil
@@ -161,7 +168,7 @@ func (p *Provider) Info() provider.ProviderInfo {
 	}
 }
 
-// Validate checks that the API key is present.
+// Validate checks that the API key is present and accepted by the API.
 func (p *Provider) Validate(ctx context.Context) error {
 	if p.apiKey == "" {
 		return &provider.ProviderError{
@@ -170,6 +177,41 @@ func (p *Provider) Validate(ctx context.Context) error {
 			Provider: "anthropic",
 		}
 	}
+	// Live check: a one-token completion exercises the key and the model.
+	ctx, cancel := context.WithTimeout(ctx, provider.ValidateTimeout)
+	defer cancel()
+	body := p.buildRequest(provider.CompletionRequest{
+		Messages:  []provider.Message{{Role: provider.RoleUser, Content: "ping"}},
+		MaxTokens: 1,
+	}, false)
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return &provider.ProviderError{
+			Code: provider.ErrCodeUnknown, Message: "failed to marshal validation request",
+			Provider: "anthropic", Cause: err,
+		}
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		p.baseURL+"/v1/messages", bytes.NewReader(bodyBytes))
+	if err != nil {
+		return &provider.ProviderError{
+			Code: provider.ErrCodeUnknown, Message: "failed to build validation request",
+			Provider: "anthropic", Cause: err,
+		}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", p.apiKey)
+	httpReq.Header.Set("anthropic-version", anthropicVersion)
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return provider.TransportError("anthropic", "failed to reach Anthropic API", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return classifyHTTPError(resp.StatusCode, resp.Header, respBody)
+	}
 	return nil
 }
 
@@ -221,7 +263,7 @@ func (p *Provider) doComplete(ctx context.Context, req provider.CompletionReques
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, classifyHTTPError(resp.StatusCode, respBody)
+		return nil, classifyHTTPError(resp.StatusCode, resp.Header, respBody)
 	}
 
 	var apiResp apiResponse
@@ -232,7 +274,7 @@ func (p *Provider) doComplete(ctx context.Context, req provider.CompletionReques
 		}
 	}
 
-	return toCompletionResponse(&apiResp), nil
+	return toCompletionResponse(&apiResp, assistantPrefill(req)), nil
 }
 
 // CompleteStream performs a streaming chat completion using Anthropic's SSE
@@ -285,13 +327,22 @@ func (p *Provider) CompleteStream(ctx context.Context, req provider.CompletionRe
 		if httpResp.StatusCode != http.StatusOK {
 			var buf [4096]byte
 			n, _ := httpResp.Body.Read(buf[:])
-			errCh <- classifyHTTPError(httpResp.StatusCode, buf[:n])
+			errCh <- classifyHTTPError(httpResp.StatusCode, httpResp.Header, buf[:n])
 			return
 		}
 
 		// Anthropic SSE format:
 		//   event: <event_type>
 		//   data: <json>
+		// The model continues the prefill; emit it first so the streamed
+		// text matches the blocking response.
+		if prefill := assistantPrefill(req); prefill != "" {
+			if !provider.SendStreamChunk(ctx, chunks, provider.StreamChunk{Content: prefill}) {
+				errCh <- ctx.Err()
+				return
+			}
+		}
+
 		scanner := provider.NewSSEScanner(httpResp.Body)
 		var currentEvent string
 
@@ -371,7 +422,7 @@ func (p *Provider) CompleteStream(ctx context.Context, req provider.CompletionRe
 // Anthropic's native format. The key difference is that the "system" message
 // is extracted from Messages and placed in the top-level System field.
 func (p *Provider) buildRequest(req provider.CompletionRequest, stream bool) apiRequest {
-	model := req.Model
+	model := provider.ResolveModelAlias(p.aliases, req.Model)
 	if model == "" {
 		model = p.model
 	}
@@ -379,6 +430,10 @@ func (p *Provider) buildRequest(req provider.CompletionRequest, stream bool) api
 	if maxTok == 0 {
 		maxTok = p.maxTok
 	}
+	if clamped, ok := provider.ClampResponseTokens(model, maxTok, req.Messages); ok {
+		p.clampNotice.Warn(model, maxTok, clamped)
+		maxTok = clamped
+	}
 
 	var systemPrompt string
 	var messages []apiMessage
@@ -409,6 +464,9 @@ func (p *Provider) buildRequest(req provider.CompletionRequest, stream bool) api
 		systemPrompt += messages[0].Content
 		messages = messages[1:]
 	}
+	if prefill := assistantPrefill(req); prefill != "" {
+		messages = append(messages, apiMessage{Role: "assistant", Content: prefill})
+	}
 
 	return apiRequest{
 		Model:         model,
@@ -422,8 +480,16 @@ func (p *Provider) buildRequest(req provider.CompletionRequest, stream bool) api
 	}
 }
 
-func toCompletionResponse(r *apiResponse) *provider.CompletionResponse {
-	var content string
+// assistantPrefill returns req's prefill without trailing whitespace, which
+// the Messages API rejects in a final assistant turn.
+func assistantPrefill(req provider.CompletionRequest) string {
+	return strings.TrimRightFunc(req.AssistantPrefill, unicode.IsSpace)
+}
+
+// toCompletionResponse converts r, putting prefill back in front of the
+// text the model continued from it.
+func toCompletionResponse(r *apiResponse, prefill string) *provider.CompletionResponse {
+	content := prefill
 	for _, block := range r.Content {
 		if block.Type == "text" {
 			content += block.Text
@@ -460,7 +526,7 @@ func toCompletionResponse(r *apiResponse) *provider.CompletionResponse {
 
 // classifyHTTPError maps HTTP status codes to normalized provider errors for
 // the Anthropic API.
-func classifyHTTPError(statusCode int, body []byte) *provider.ProviderError {
+func classifyHTTPError(statusCode int, header http.Header, body []byte) *provider.ProviderError {
 	var apiErr apiError
 	_ = json.Unmarshal(body, &apiErr)
 	msg := apiErr.Error.Message
@@ -472,6 +538,7 @@ func classifyHTTPError(statusCode int, body []byte) *provider.ProviderError {
 		Provider:   "anthropic",
 		Message:    msg,
 		StatusCode: statusCode,
+		RetryAfter: provider.ParseRetryAfter(header),
 	}
 
 	switch {
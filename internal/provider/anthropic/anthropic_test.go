@@ -156,3 +156,113 @@ func TestClaudeValidate_NoAPIKey(t *testing.T) {
 	assert.Error(t, err)
 	assert.ErrorIs(t, err, provider.ErrAuthentication)
 }
+
+func TestClaudeValidate_SendsOneTokenRequest(t *testing.T) {
+	var gotReq apiRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/messages", r.URL.Path)
+		_ = json.NewDecoder(r.Body).Decode(&gotReq)
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`))
+	}))
+	defer server.Close()
+
+	v := config.NewStore()
+	v.Set("api_key", "test-key")
+	v.Set("base_url", server.URL)
+	p, err := NewProvider(v)
+	require.NoError(t, err)
+
+	err = p.Validate(context.Background())
+	assert.ErrorIs(t, err, provider.ErrRateLimit)
+	assert.Equal(t, 1, gotReq.MaxTokens)
+}
+
+func TestClaudeComplete_ResolvesModelAlias(t *testing.T) {
+	var gotModel string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body apiRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		gotModel = body.Model
+		json.NewEncoder(w).Encode(apiResponse{
+			ID:      "msg-test",
+			Content: []apiContentBlock{{Type: "text", Text: "ok"}},
+		})
+	}))
+	defer server.Close()
+
+	v := config.NewStore()
+	v.Set("api_key", "test-key")
+	v.Set("base_url", server.URL)
+	v.Set("model", "sonnet")
+	p, err := NewProvider(v)
+	require.NoError(t, err)
+	assert.Equal(t, "claude-sonnet-4-20250514", p.Info().DefaultModel)
+
+	_, err = p.Complete(context.Background(), provider.CompletionRequest{
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hello"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "claude-sonnet-4-20250514", gotModel)
+}
+
+func TestClaudeComplete_AssistantPrefill(t *testing.T) {
+	var got apiRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		json.NewEncoder(w).Encode(apiResponse{
+			ID:         "msg-test",
+			StopReason: "end_turn",
+			Content:    []apiContentBlock{{Type: "text", Text: ` "ok", "findings": []}`}},
+		})
+	}))
+	defer server.Close()
+
+	v := config.NewStore()
+	v.Set("api_key", "test-key")
+	v.Set("base_url", server.URL)
+	p, err := NewProvider(v)
+	require.NoError(t, err)
+
+	resp, err := p.Complete(context.Background(), provider.CompletionRequest{
+		Messages: []provider.Message{
+			{Role: provider.RoleSystem, Content: "Review"},
+			{Role: provider.RoleUser, Content: "Diff here"},
+		},
+		AssistantPrefill: "{\"summary\":\n",
+	})
+	require.NoError(t, err)
+
+	require.Len(t, got.Messages, 2)
+	assert.Equal(t, "Review", got.System)
+	assert.Equal(t, apiMessage{Role: "assistant", Content: `{"summary":`}, got.Messages[1])
+	assert.Equal(t, `{"summary": "ok", "findings": []}`, resp.Content)
+	assert.Equal(t, resp.Content, resp.Choices[0].Content)
+}
+
+func TestClaudeCompleteStream_AssistantPrefillIsFirstChunk(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\" \\\"ok\\\"}\"}}\n\n"))
+		w.Write([]byte("event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"))
+	}))
+	defer server.Close()
+
+	v := config.NewStore()
+	v.Set("api_key", "test-key")
+	v.Set("base_url", server.URL)
+	p, err := NewProvider(v)
+	require.NoError(t, err)
+
+	result := p.CompleteStream(context.Background(), provider.CompletionRequest{
+		Messages:         []provider.Message{{Role: provider.RoleUser, Content: "Hello"}},
+		Stream:           true,
+		AssistantPrefill: `{"summary":`,
+	})
+	var content string
+	for chunk := range result.Chunks {
+		content += chunk.Content
+	}
+	require.NoError(t, <-result.Err)
+	assert.Equal(t, `{"summary": "ok"}`, content)
+}
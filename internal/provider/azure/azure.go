@@ -83,13 +83,14 @@ type apiError struct {
 
 // Provider implements provider.AIProvider for Azure OpenAI Service.
 type Provider struct {
-	client     *http.Client
-	apiKey     string
-	endpoint   string // e.g. https://<resource>.openai.azure.com
-	deployment string // Azure deployment name
-	apiVersion string
-	maxTok     int
-	retryCfg   provider.RetryConfig
+	client      *http.Client
+	apiKey      string
+	endpoint    string // e.g. https://<resource>.openai.azure.com
+	deployment  string // Azure deployment name
+	apiVersion  string
+	maxTok      int
+	retryCfg    provider.RetryConfig
+	clampNotice provider.ClampNotice
 }
 
 // NewProvider is the factory function registered with the provider registry.
@@ -152,7 +153,8 @@ func (p *Provider) Info() provider.ProviderInfo {
 	}
 }
 
-// Validate checks that required config fields are present.
+// Validate checks that required config fields are present and that the
+// deployment answers a one-token completion.
 func (p *Provider) Validate(ctx context.Context) error {
 	if p.apiKey == "" {
 		return &provider.ProviderError{
@@ -168,6 +170,48 @@ func (p *Provider) Validate(ctx context.Context) error {
 			Provider: "azure",
 		}
 	}
+
+	ctx, cancel := context.WithTimeout(ctx, provider.ValidateTimeout)
+	defer cancel()
+	body := p.buildRequest(provider.CompletionRequest{
+		Messages:  []provider.Message{{Role: provider.RoleUser, Content: "ping"}},
+		MaxTokens: 1,
+	}, false)
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return &provider.ProviderError{
+			Code: provider.ErrCodeUnknown, Message: "failed to marshal validation request",
+			Provider: "azure", Cause: err,
+		}
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		p.completionsURL(), bytes.NewReader(bodyBytes))
+	if err != nil {
+		return &provider.ProviderError{
+			Code: provider.ErrCodeUnknown, Message: "failed to build validation request",
+			Provider: "azure", Cause: err,
+		}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("api-key", p.apiKey)
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return provider.TransportError("azure", "failed to reach Azure endpoint", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return &provider.ProviderError{
+			Code:       provider.ErrCodeInvalidRequest,
+			Message:    fmt.Sprintf("deployment %q not found at %s (check model and api_version)", p.deployment, p.endpoint),
+			Provider:   "azure",
+			StatusCode: resp.StatusCode,
+		}
+	case resp.StatusCode != http.StatusOK:
+		return classifyHTTPError(resp.StatusCode, resp.Header, respBody)
+	}
 	return nil
 }
 
@@ -218,7 +262,7 @@ func (p *Provider) doComplete(ctx context.Context, req provider.CompletionReques
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, classifyHTTPError(resp.StatusCode, respBody)
+		return nil, classifyHTTPError(resp.StatusCode, resp.Header, respBody)
 	}
 
 	var apiResp apiResponse
@@ -280,7 +324,7 @@ func (p *Provider) CompleteStream(ctx context.Context, req provider.CompletionRe
 		if httpResp.StatusCode != http.StatusOK {
 			var buf [4096]byte
 			n, _ := httpResp.Body.Read(buf[:])
-			errCh <- classifyHTTPError(httpResp.StatusCode, buf[:n])
+			errCh <- classifyHTTPError(httpResp.StatusCode, httpResp.Header, buf[:n])
 			return
 		}
 
@@ -340,6 +384,10 @@ func (p *Provider) buildRequest(req provider.CompletionRequest, stream bool) api
 	if maxTok == 0 {
 		maxTok = p.maxTok
 	}
+	if clamped, ok := provider.ClampResponseTokens(p.deployment, maxTok, req.Messages); ok {
+		p.clampNotice.Warn(p.deployment, maxTok, clamped)
+		maxTok = clamped
+	}
 
 	msgs := make([]apiMessage, len(req.Messages))
 	for i, m := range req.Messages {
@@ -380,7 +428,7 @@ func toCompletionResponse(r *apiResponse) *provider.CompletionResponse {
 	return resp
 }
 
-func classifyHTTPError(statusCode int, body []byte) *provider.ProviderError {
+func classifyHTTPError(statusCode int, header http.Header, body []byte) *provider.ProviderError {
 	var apiErr apiError
 	_ = json.Unmarshal(body, &apiErr)
 	msg := apiErr.Error.Message
@@ -392,6 +440,7 @@ func classifyHTTPError(statusCode int, body []byte) *provider.ProviderError {
 		Provider:   "azure",
 		Message:    msg,
 		StatusCode: statusCode,
+		RetryAfter: provider.ParseRetryAfter(header),
 	}
 
 	switch {
@@ -110,10 +110,20 @@ func ApiCallWithProvider(debug bool, p AIProvider, prompt string) {
 	ApiCallWithProviderContext(ctx, debug, p, prompt)
 }
 
+// DefaultSystemPrompt is the system prompt used by the bridge helpers when
+// the caller does not supply one.
+const DefaultSystemPrompt = "You are a helpful assistant and source code reviewer."
+
 // ApiCallWithProviderContext runs a provider call with an explicit parent context.
 func ApiCallWithProviderContext(ctx context.Context, debug bool, p AIProvider, prompt string) {
+	ApiCallWithSystemPrompt(ctx, debug, p, DefaultSystemPrompt, prompt)
+}
+
+// ApiCallWithSystemPrompt is ApiCallWithProviderContext with a caller-chosen
+// system prompt.
+func ApiCallWithSystemPrompt(ctx context.Context, debug bool, p AIProvider, systemPrompt, prompt string) {
 	req := buildBridgeRequest(
-		"You are a helpful assistant and source code reviewer.",
+		systemPrompt,
 		"You are code reviewer for a project",
 		prompt,
 		false,
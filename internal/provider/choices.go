@@ -0,0 +1,43 @@
+package provider
+
+import "context"
+
+// CompleteChoices runs req and returns a response holding req.N choices
+// (at least one). Providers that support N natively answer in one call; any
+// choices still missing, for example because the endpoint ignored n, are
+// requested one at a time. Usage is summed across calls and Content stays
+// the first choice.
+func CompleteChoices(ctx context.Context, p AIProvider, req CompletionRequest) (*CompletionResponse, error) {
+	want := req.N
+	if want <= 1 {
+		req.N = 0
+		return p.Complete(ctx, req)
+	}
+	if !p.Info().SupportsMultipleChoices {
+		req.N = 0
+	}
+	resp, err := p.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		resp.Choices = []Choice{{Content: resp.BestText(), FinishReason: resp.FinishReason}}
+	}
+	req.N = 0
+	for len(resp.Choices) < want {
+		more, err := p.Complete(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		resp.Choices = append(resp.Choices, Choice{
+			Index:        len(resp.Choices),
+			Content:      more.BestText(),
+			FinishReason: more.FinishReason,
+		})
+		resp.Usage.PromptTokens += more.Usage.PromptTokens
+		resp.Usage.CompletionTokens += more.Usage.CompletionTokens
+		resp.Usage.TotalTokens += more.Usage.TotalTokens
+		resp.Usage.ReasoningTokens += more.Usage.ReasoningTokens
+	}
+	return resp, nil
+}
@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// multiChoiceProvider answers every request with req.N choices.
+type multiChoiceProvider struct {
+	scriptedProvider
+}
+
+func (m *multiChoiceProvider) Info() ProviderInfo {
+	return ProviderInfo{Name: "multi", SupportsMultipleChoices: true}
+}
+
+func (m *multiChoiceProvider) Complete(_ context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	m.requests = append(m.requests, req)
+	resp := &CompletionResponse{Content: "c0", Usage: Usage{PromptTokens: 10, CompletionTokens: 3 * max(req.N, 1)}}
+	for i := 0; i < max(req.N, 1); i++ {
+		resp.Choices = append(resp.Choices, Choice{Index: i, Content: "c" + string(rune('0'+i))})
+	}
+	return resp, nil
+}
+
+func TestCompleteChoices_NativeN(t *testing.T) {
+	p := &multiChoiceProvider{}
+	resp, err := CompleteChoices(context.Background(), p, CompletionRequest{N: 3})
+	require.NoError(t, err)
+	require.Len(t, p.requests, 1)
+	assert.Equal(t, 3, p.requests[0].N)
+	require.Len(t, resp.Choices, 3)
+	assert.Equal(t, "c0", resp.Content)
+	assert.Equal(t, "c2", resp.Choices[2].Content)
+}
+
+func TestCompleteChoices_LoopsWithoutNativeN(t *testing.T) {
+	p := &scriptedProvider{responses: []CompletionResponse{
+		{Content: "first", Usage: Usage{PromptTokens: 5, CompletionTokens: 1, TotalTokens: 6}},
+		{Content: "second", Usage: Usage{PromptTokens: 5, CompletionTokens: 2, TotalTokens: 7}},
+		{Content: "third", Usage: Usage{PromptTokens: 5, CompletionTokens: 3, TotalTokens: 8}},
+	}}
+	resp, err := CompleteChoices(context.Background(), p, CompletionRequest{N: 3})
+	require.NoError(t, err)
+	require.Len(t, p.requests, 3)
+	for _, req := range p.requests {
+		assert.Zero(t, req.N)
+	}
+	require.Len(t, resp.Choices, 3)
+	assert.Equal(t, "first", resp.Content)
+	assert.Equal(t, []string{"first", "second", "third"}, []string{resp.Choices[0].Content, resp.Choices[1].Content, resp.Choices[2].Content})
+	assert.Equal(t, 21, resp.Usage.TotalTokens)
+}
+
+func TestCompleteChoices_SingleChoiceIsPlainComplete(t *testing.T) {
+	p := &scriptedProvider{}
+	_, err := CompleteChoices(context.Background(), p, CompletionRequest{N: 1})
+	require.NoError(t, err)
+	require.Len(t, p.requests, 1)
+	assert.Zero(t, p.requests[0].N)
+}
+
+func TestConversation_SamplesRecordsFirstChoice(t *testing.T) {
+	p := &multiChoiceProvider{}
+	conv := NewConversation(p, ConversationOptions{Samples: 2})
+	resp, err := conv.Complete(context.Background(), "review")
+	require.NoError(t, err)
+	assert.Len(t, resp.Choices, 2)
+	assert.Equal(t, 2, p.requests[0].N)
+	msgs := conv.Messages()
+	require.Len(t, msgs, 2)
+	assert.Equal(t, "c0", msgs[1].Content)
+}
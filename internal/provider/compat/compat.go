@@ -21,6 +21,9 @@
 //	    base_url: http://localhost:11434/v1
 //	    model: llama3
 //	    # api_key is optional for local endpoints
+//
+// "gemini-openai" targets Google's OpenAI-compatible Gemini endpoint; the
+// native "gemini" provider lives in the gemini package.
 package compat
 
 import (
@@ -46,11 +49,21 @@ func init() {
 	// register arbitrary names through the config file; the Resolve logic in
 	// provider/config.go will fall back to this factory when the name does
 	// not match a specifically-registered provider.
-	for _, name := range []string{"ollama", "groq", "together", "lmstudio", "openai-compat", "gemini"} {
+	for _, name := range []string{"ollama", "groq", "together", "lmstudio", "openai-compat", "gemini-openai"} {
 		provider.Register(name, newFactory(name))
 	}
 }
 
+// jsonModeDefaults lists the built-in services known to accept
+// response_format {"type":"json_object"}. Other endpoints opt in with
+// providers.<name>.json_mode: true.
+var jsonModeDefaults = map[string]bool{
+	"ollama":        true,
+	"groq":          true,
+	"together":      true,
+	"gemini-openai": true,
+}
+
 // newFactory returns a Factory closure that captures the provider name.
 func newFactory(name string) provider.Factory {
 	return func(v *config.Store) (provider.AIProvider, error) {
@@ -63,8 +76,18 @@ func newFactory(name string) provider.Factory {
 // ---------------------------------------------------------------------------
 
 type apiMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role      string        `json:"role"`
+	Content   string        `json:"content"`
+	ToolCalls []apiToolCall `json:"tool_calls,omitempty"`
+}
+
+type apiToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
 }
 
 type apiRequest struct {
@@ -75,6 +98,13 @@ type apiRequest struct {
 	TopP        *float64     `json:"top_p,omitempty"`
 	Stream      bool         `json:"stream,omitempty"`
 	Stop        []string     `json:"stop,omitempty"`
+	N           int          `json:"n,omitempty"`
+
+	ResponseFormat *apiResponseFormat `json:"response_format,omitempty"`
+}
+
+type apiResponseFormat struct {
+	Type string `json:"type"`
 }
 
 type apiChoice struct {
@@ -111,13 +141,16 @@ type apiError struct {
 
 // Provider implements provider.AIProvider for OpenAI-compatible endpoints.
 type Provider struct {
-	name     string
-	client   *http.Client
-	apiKey   string
-	baseURL  string
-	model    string
-	maxTok   int
-	retryCfg provider.RetryConfig
+	name        string
+	client      *http.Client
+	apiKey      string
+	baseURL     string
+	model       string
+	aliases     map[string]string
+	maxTok      int
+	jsonMode    bool
+	retryCfg    provider.RetryConfig
+	clampNotice provider.ClampNotice
 }
 
 // NewProvider creates a new generic OpenAI-compatible provider.
@@ -135,6 +168,8 @@ func NewProvider(name string, v *config.Store) (provider.AIProvider, error) {
 	if model == "" {
 		model = "default"
 	}
+	aliases := provider.ModelAliases(name, v)
+	model = provider.ResolveModelAlias(aliases, model)
 	maxTok := v.GetInt("max_tokens")
 	if maxTok == 0 {
 		maxTok = 1024
@@ -143,6 +178,10 @@ func NewProvider(name string, v *config.Store) (provider.AIProvider, error) {
 	if timeout == 0 {
 		timeout = 60 * time.Second
 	}
+	jsonMode := jsonModeDefaults[name]
+	if v.IsSet("json_mode") {
+		jsonMode = v.GetBool("json_mode")
+	}
 
 	return &Provider{
 		name:     name,
@@ -150,7 +189,9 @@ func NewProvider(name string, v *config.Store) (provider.AIProvider, error) {
 		apiKey:   v.GetString("api_key"),
 		baseURL:  strings.TrimRight(baseURL, "/"),
 		model:    model,
+		aliases:  aliases,
 		maxTok:   maxTok,
+		jsonMode: jsonMode,
 		retryCfg: provider.DefaultRetryConfig(),
 	}, nil
 }
@@ -158,16 +199,19 @@ func NewProvider(name string, v *config.Store) (provider.AIProvider, error) {
 // Info returns provider metadata.
 func (p *Provider) Info() provider.ProviderInfo {
 	return provider.ProviderInfo{
-		Name:              p.name,
-		DisplayName:       strings.Title(p.name) + " (OpenAI-compatible)", //nolint:staticcheck
-		Description:       fmt.Sprintf("OpenAI-compatible endpoint (%s)", p.baseURL),
-		DefaultModel:      p.model,
-		SupportsStreaming: true,
+		Name:                    p.name,
+		DisplayName:             strings.Title(p.name) + " (OpenAI-compatible)", //nolint:staticcheck
+		Description:             fmt.Sprintf("OpenAI-compatible endpoint (%s)", p.baseURL),
+		DefaultModel:            p.model,
+		SupportsStreaming:       true,
+		SupportsJSONMode:        p.jsonMode,
+		SupportsMultipleChoices: true,
 	}
 }
 
-// Validate checks basic configuration. For local endpoints the API key may be
-// empty, so we only verify the base URL is set.
+// Validate checks basic configuration and lists models to confirm the
+// endpoint is reachable. For local endpoints the API key may be empty, so it
+// is only sent when set.
 func (p *Provider) Validate(ctx context.Context) error {
 	if p.baseURL == "" {
 		return &provider.ProviderError{
@@ -176,6 +220,29 @@ func (p *Provider) Validate(ctx context.Context) error {
 			Provider: p.name,
 		}
 	}
+
+	ctx, cancel := context.WithTimeout(ctx, provider.ValidateTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/models", nil)
+	if err != nil {
+		return &provider.ProviderError{
+			Code: provider.ErrCodeUnknown, Message: "failed to build validation request",
+			Provider: p.name, Cause: err,
+		}
+	}
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return provider.TransportError(p.name, "failed to reach "+p.baseURL, err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return classifyHTTPError(p.name, resp.StatusCode, resp.Header, respBody)
+	}
 	return nil
 }
 
@@ -228,7 +295,7 @@ func (p *Provider) doComplete(ctx context.Context, req provider.CompletionReques
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, classifyHTTPError(p.name, resp.StatusCode, respBody)
+		return nil, classifyHTTPError(p.name, resp.StatusCode, resp.Header, respBody)
 	}
 
 	var apiResp apiResponse
@@ -292,7 +359,7 @@ func (p *Provider) CompleteStream(ctx context.Context, req provider.CompletionRe
 		if httpResp.StatusCode != http.StatusOK {
 			var buf [4096]byte
 			n, _ := httpResp.Body.Read(buf[:])
-			errCh <- classifyHTTPError(p.name, httpResp.StatusCode, buf[:n])
+			errCh <- classifyHTTPError(p.name, httpResp.StatusCode, httpResp.Header, buf[:n])
 			return
 		}
 
@@ -348,7 +415,7 @@ func (p *Provider) CompleteStream(ctx context.Context, req provider.CompletionRe
 // ---------------------------------------------------------------------------
 
 func (p *Provider) buildRequest(req provider.CompletionRequest, stream bool) apiRequest {
-	model := req.Model
+	model := provider.ResolveModelAlias(p.aliases, req.Model)
 	if model == "" {
 		model = p.model
 	}
@@ -356,13 +423,17 @@ func (p *Provider) buildRequest(req provider.CompletionRequest, stream bool) api
 	if maxTok == 0 {
 		maxTok = p.maxTok
 	}
+	if clamped, ok := provider.ClampResponseTokens(model, maxTok, req.Messages); ok {
+		p.clampNotice.Warn(model, maxTok, clamped)
+		maxTok = clamped
+	}
 
 	msgs := make([]apiMessage, len(req.Messages))
 	for i, m := range req.Messages {
 		msgs[i] = apiMessage{Role: string(m.Role), Content: m.Content}
 	}
 
-	return apiRequest{
+	body := apiRequest{
 		Model:       model,
 		Messages:    msgs,
 		MaxTokens:   maxTok,
@@ -371,6 +442,13 @@ func (p *Provider) buildRequest(req provider.CompletionRequest, stream bool) api
 		Stream:      stream,
 		Stop:        req.StopSequences,
 	}
+	if req.JSONMode && p.jsonMode {
+		body.ResponseFormat = &apiResponseFormat{Type: "json_object"}
+	}
+	if req.N > 1 && !stream {
+		body.N = req.N
+	}
+	return body
 }
 
 func toCompletionResponse(r *apiResponse) *provider.CompletionResponse {
@@ -393,11 +471,18 @@ func toCompletionResponse(r *apiResponse) *provider.CompletionResponse {
 	if len(resp.Choices) > 0 {
 		resp.Content = resp.Choices[0].Content
 		resp.FinishReason = resp.Choices[0].FinishReason
+		for _, tc := range r.Choices[0].Message.ToolCalls {
+			resp.ToolCalls = append(resp.ToolCalls, provider.ToolCall{
+				ID:        tc.ID,
+				Name:      tc.Function.Name,
+				Arguments: tc.Function.Arguments,
+			})
+		}
 	}
 	return resp
 }
 
-func classifyHTTPError(providerName string, statusCode int, body []byte) *provider.ProviderError {
+func classifyHTTPError(providerName string, statusCode int, header http.Header, body []byte) *provider.ProviderError {
 	var apiErr apiError
 	_ = json.Unmarshal(body, &apiErr)
 	msg := apiErr.Error.Message
@@ -409,6 +494,7 @@ func classifyHTTPError(providerName string, statusCode int, body []byte) *provid
 		Provider:   providerName,
 		Message:    msg,
 		StatusCode: statusCode,
+		RetryAfter: provider.ParseRetryAfter(header),
 	}
 
 	switch {
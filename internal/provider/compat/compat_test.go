@@ -0,0 +1,107 @@
+package compat
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/config"
+	"github.com/sanix-darker/prev/internal/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestProvider(t *testing.T, name string, jsonMode *bool) *Provider {
+	t.Helper()
+	v := config.NewStore()
+	v.Set("base_url", "http://localhost:11434/v1")
+	if jsonMode != nil {
+		v.Set("json_mode", *jsonMode)
+	}
+	p, err := NewProvider(name, v)
+	require.NoError(t, err)
+	return p.(*Provider)
+}
+
+func TestBuildRequest_JSONModeOnlyForSupportingProviders(t *testing.T) {
+	req := provider.CompletionRequest{
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Return JSON"}},
+		JSONMode: true,
+	}
+
+	ollama := newTestProvider(t, "ollama", nil)
+	assert.True(t, ollama.Info().SupportsJSONMode)
+	body := ollama.buildRequest(req, false)
+	require.NotNil(t, body.ResponseFormat)
+	assert.Equal(t, "json_object", body.ResponseFormat.Type)
+
+	generic := newTestProvider(t, "openai-compat", nil)
+	assert.False(t, generic.Info().SupportsJSONMode)
+	assert.Nil(t, generic.buildRequest(req, false).ResponseFormat)
+
+	enabled := true
+	optIn := newTestProvider(t, "lmstudio", &enabled)
+	assert.NotNil(t, optIn.buildRequest(req, true).ResponseFormat)
+
+	disabled := false
+	optOut := newTestProvider(t, "groq", &disabled)
+	assert.Nil(t, optOut.buildRequest(req, false).ResponseFormat)
+}
+
+func TestBuildRequest_JSONModeOffLeavesResponseFormatUnset(t *testing.T) {
+	ollama := newTestProvider(t, "ollama", nil)
+	body := ollama.buildRequest(provider.CompletionRequest{
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Review"}},
+	}, false)
+	assert.Nil(t, body.ResponseFormat)
+}
+
+func TestToCompletionResponse_SurfacesToolCalls(t *testing.T) {
+	var r apiResponse
+	require.NoError(t, json.Unmarshal([]byte(`{"choices":[{"message":{"role":"assistant","content":null,
+		"tool_calls":[{"id":"c1","type":"function","function":{"name":"a","arguments":"{\"x\":1}"}},
+		{"id":"c2","type":"function","function":{"name":"b","arguments":"{\"y\":2}"}}]},
+		"finish_reason":"tool_calls"}]}`), &r))
+
+	resp := toCompletionResponse(&r)
+	require.Len(t, resp.ToolCalls, 2)
+	assert.Equal(t, "b", resp.ToolCalls[1].Name)
+	assert.Equal(t, "{\"x\":1}\n{\"y\":2}", resp.BestText())
+}
+
+func TestValidate_ListsModels(t *testing.T) {
+	var gotPath, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{"data":[{"id":"llama3"}]}`))
+	}))
+	defer server.Close()
+
+	v := config.NewStore()
+	v.Set("base_url", server.URL+"/v1")
+	v.Set("api_key", "k")
+	p, err := NewProvider("ollama", v)
+	require.NoError(t, err)
+
+	require.NoError(t, p.Validate(context.Background()))
+	assert.Equal(t, "/v1/models", gotPath)
+	assert.Equal(t, "Bearer k", gotAuth)
+}
+
+func TestValidate_ClassifiesFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":{"message":"invalid api key"}}`))
+	}))
+	v := config.NewStore()
+	v.Set("base_url", server.URL)
+	p, err := NewProvider("groq", v)
+	require.NoError(t, err)
+	assert.ErrorIs(t, p.Validate(context.Background()), provider.ErrAuthentication)
+
+	server.Close()
+	assert.ErrorIs(t, p.Validate(context.Background()), provider.ErrProviderUnavailable)
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/sanix-darker/prev/internal/common"
 	"github.com/sanix-darker/prev/internal/config"
 )
 
@@ -41,14 +42,13 @@ const ConfigKeyProvider = "provider"
 //	    api_key: ...
 //	    model: gpt-4o
 func ResolveProvider(v *config.Store) ProviderConfig {
-	// Determine the active provider name.
-	name := v.GetString(ConfigKeyProvider)
-	if name == "" {
-		name = os.Getenv("PREV_PROVIDER")
-	}
-	if name == "" {
-		name = "openai"
-	}
+	return ResolveNamedProvider(v, activeProviderName(v))
+}
+
+// ResolveNamedProvider returns the config block of the named provider with
+// the same env-var and api_key_command handling as ResolveProvider. It is
+// used for providers other than the active one, such as fallbacks.
+func ResolveNamedProvider(v *config.Store, name string) ProviderConfig {
 	name = strings.ToLower(strings.TrimSpace(name))
 
 	// Build a sub-store for the provider's config block.
@@ -62,10 +62,43 @@ func ResolveProvider(v *config.Store) ProviderConfig {
 	// Bind common env vars so they override file-based config. Providers
 	// that need additional bindings do so in their factory function.
 	bindProviderEnvVars(name, sub)
+	resolveAPIKeyCommand(name, sub)
 
 	return ProviderConfig{Name: name, Viper: sub}
 }
 
+// activeProviderName applies the provider-name lookup order documented on
+// ResolveProvider.
+func activeProviderName(v *config.Store) string {
+	name := v.GetString(ConfigKeyProvider)
+	if name == "" {
+		name = os.Getenv("PREV_PROVIDER")
+	}
+	if name == "" {
+		name = "openai"
+	}
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// resolveAPIKeyCommand fills api_key from the output of api_key_command when
+// no key was configured directly or through the environment. This lets users
+// keep secrets in a password manager (e.g. "pass show openai").
+func resolveAPIKeyCommand(name string, v *config.Store) {
+	if strings.TrimSpace(v.GetString("api_key")) != "" {
+		return
+	}
+	command := strings.TrimSpace(v.GetString("api_key_command"))
+	if command == "" {
+		return
+	}
+	key, err := common.RunSecretCommand(command)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: api_key_command for provider %q %v\n", name, err)
+		return
+	}
+	v.Set("api_key", key)
+}
+
 // bindProviderEnvVars sets up well-known environment variables for each
 // provider so that users can configure prev entirely through the shell.
 func bindProviderEnvVars(name string, v *config.Store) {
@@ -93,12 +126,25 @@ func bindProviderEnvVars(name string, v *config.Store) {
 		overrideFromEnv(v, "base_url", "AZURE_OPENAI_ENDPOINT")
 		overrideFromEnv(v, "api_version", "AZURE_OPENAI_API_VERSION")
 	case "gemini":
+		// Native Gemini API (generateContent).
+		v.SetDefault("model", "gemini-2.0-flash")
+		v.SetDefault("base_url", "https://generativelanguage.googleapis.com/v1beta")
+		overrideFromEnv(v, "api_key", "GEMINI_API_KEY")
+		overrideFromEnv(v, "model", "GEMINI_MODEL")
+		overrideFromEnv(v, "base_url", "GEMINI_BASE_URL")
+	case "gemini-openai":
 		// Gemini via Google's OpenAI-compatible endpoint.
 		v.SetDefault("model", "gemini-2.0-flash")
 		v.SetDefault("base_url", "https://generativelanguage.googleapis.com/v1beta/openai")
 		overrideFromEnv(v, "api_key", "GEMINI_API_KEY")
 		overrideFromEnv(v, "model", "GEMINI_MODEL")
 		overrideFromEnv(v, "base_url", "GEMINI_BASE_URL")
+	case "mistral":
+		v.SetDefault("model", "mistral-large-latest")
+		v.SetDefault("base_url", "https://api.mistral.ai/v1")
+		overrideFromEnv(v, "api_key", "MISTRAL_API_KEY")
+		overrideFromEnv(v, "model", "MISTRAL_MODEL")
+		overrideFromEnv(v, "base_url", "MISTRAL_BASE_URL")
 	default:
 		// Generic / OpenAI-compatible: try PREV_<PROVIDER>_* env vars.
 		prefix := strings.ToUpper(name)
@@ -118,7 +164,7 @@ func overrideFromEnv(v *config.Store, key, envName string) {
 // provider settings. It is used by the "prev init" or "prev config" command.
 func SampleConfigYAML() string {
 	return `# prev configuration
-# Active provider (openai | anthropic | azure | gemini | ollama | custom).
+# Active provider (openai | anthropic | azure | gemini | mistral | ollama | custom).
 provider: openai
 
 # Provider-specific settings. Each block corresponds to a registered provider.
@@ -126,8 +172,11 @@ providers:
   openai:
     # api_key can also be set via OPENAI_API_KEY env var.
     api_key: ""
+    # api_key_command: "pass show openai"  # used when api_key is empty
     model: "gpt-4o"
     # base_url: "https://api.openai.com/v1"  # override for proxies
+    # api: "responses"  # use /responses instead of /chat/completions (reasoning models)
+    # reasoning_effort: "medium"  # low | medium | high, sent with api: responses
     max_tokens: 1024
     timeout: 30s
 
@@ -137,6 +186,9 @@ providers:
     model: "claude-sonnet-4-20250514"
     max_tokens: 1024
     timeout: 30s
+    # model_aliases map short names to model IDs (built in: sonnet, opus, haiku).
+    # model_aliases:
+    #   fast: "claude-3-5-haiku-20241022"
 
   azure:
     # api_key can also be set via AZURE_OPENAI_API_KEY env var.
@@ -150,11 +202,22 @@ providers:
   gemini:
     # api_key can also be set via GEMINI_API_KEY env var.
     api_key: ""
-    base_url: "https://generativelanguage.googleapis.com/v1beta/openai"
+    base_url: "https://generativelanguage.googleapis.com/v1beta"
     model: "gemini-2.0-flash"
     max_tokens: 1024
     timeout: 30s
 
+  mistral:
+    # api_key can also be set via MISTRAL_API_KEY env var.
+    api_key: ""
+    model: "mistral-large-latest"
+    max_tokens: 1024
+    timeout: 60s
+    # Prepend Mistral's guardrail system prompt.
+    safe_prompt: false
+    # Assistant prefix the model must continue from (sent with prefix: true).
+    # prefix: "**File:"
+
   # Example: self-hosted Ollama or any OpenAI-compatible endpoint.
   ollama:
     base_url: "http://localhost:11434/v1"
@@ -169,6 +232,14 @@ retry:
   max_interval: 30s
   multiplier: 2.0
 
+# VCS API settings (GitLab/GitHub pagination safety cap; --debug logs each page).
+vcs:
+  max_pages: 200
+  # Fallbacks used when --vcs/--gitlab-token/--gitlab-url and env vars are unset.
+  # provider: gitlab
+  # token: ""
+  # url: "https://gitlab.example.com"
+
 # Review policy and conventions.
 review:
   # 1: critical only, 10: include nits and minor suggestions.
@@ -176,40 +247,204 @@ review:
   # Optional strictness default for MR review when CLI flag is not provided.
   # Allowed: strict | normal | lenient
   # strictness: "normal"
+  # Optional reviewer persona (tone and focus, not severity thresholds).
+  # Built-in: staff-engineer | security-auditor | mentor | performance;
+  # any other text is used as a custom persona.
+  # persona: "staff-engineer"
+  # Language for summaries and finding messages (e.g. "French"). KIND and
+  # SEVERITY tokens, file paths and code stay in English.
+  # output_language: ""
+  # Repository doc (e.g. ARCHITECTURE.md, relative to the repo root) whose
+  # excerpt is added to every review prompt as background. Its tokens are
+  # taken out of max_tokens before the diff is fitted.
+  # repo_context_file: ""
+  # repo_context_max_chars: 4000
   # Number of AI review passes (re-review loop) for MR review.
   passes: 1
+  # Multi-pass strategy: refine (sequential re-review) | ensemble (concurrent, merged)
+  pass_mode: "refine"
+  # In refine mode, stop once two consecutive passes find no issues.
+  early_stop_on_clean: false
+  # Completions sampled per pass; findings from every sample are unioned
+  # (more recall, more tokens). Native n on OpenAI/compatible endpoints.
+  samples: 1
+  # Severity selection when passes disagree: max | majority
+  severity_consensus: "max"
+  # Reuse cached AI output for an unchanged prompt and diff (.prev/cache/completion).
+  cache: false
+  # Maximum age of a cached review (0 disables expiry).
+  cache_ttl: "24h"
   # Maximum inline comments for MR review (0 = unlimited).
   max_comments: 0
+  # Cap MR title/description characters sent to the review prompt (0 = unlimited).
+  max_title_chars: 0
+  max_description_chars: 0
   # Inline filtering mode: added | diff_context | file | nofilter
   filter_mode: "diff_context"
-  # MR diff source strategy: auto | git | raw | api
+  # MR diff source strategy: auto | git | compare | raw | api
   mr_diff_source: "auto"
+  # Correct fork MRs whose reported base lags by using the computed merge-base.
+  use_merge_base: false
   # Enable structured JSON findings output parsing (with markdown fallback).
   structured_output: false
+  # Provider content-filter handling: fail | skip | retry_sanitized
+  on_content_filter: "skip"
+  # Replace the built-in system prompts for review passes and thread replies.
+  # system_prompt: "You review an embedded C codebase that follows MISRA C:2012."
+  # reply_system_prompt: "You answer review questions about an embedded C codebase; cite MISRA rules."
+  # cost_per_1k_input: 0.0025   # USD per 1K prompt tokens; prints an estimated cost after a review
+  # cost_per_1k_output: 0.01    # USD per 1K completion tokens
+  # Wrap the posted summary note in a collapsible <details> block.
+  collapsible_summary: false
+  # Go text/template for the posted summary note instead of the raw review.
+  # Fields: .Review .Summary .Provider .Model .FilesChanged .Additions
+  # .Deletions .Critical .High .Medium .Low .Total .Counts .Recommendation
+  # summary_template: |
+  #   ## Overview
+  #   {{.Summary}}
+  #   ## Key Findings
+  #   {{.Critical}} critical, {{.High}} high, {{.Medium}} medium, {{.Low}} low
+  #   ## Recommendation
+  #   {{.Recommendation}}
+  # Go text/template for each inline comment line; the suggestion block is
+  # still appended below it. Fields: .Severity .Message .Suggestion .File
+  # .Line; functions: lower, upper. Default: "[{{.Severity}}] {{.Message}}".
+  # The default line is kept as a hidden marker so reruns still match threads.
+  # inline_template: |
+  #   **{{.Severity}}** {{.Message}}
+  #   <sub>{{.File}}:{{.Line}} - reviewed by prev</sub>
+  # Posting order of summary note vs inline comments: inline_first | summary_first
+  post_order: "inline_first"
+  # note | label | description. label sets prev:needs-work (any HIGH or
+  # CRITICAL finding) or prev:looks-good; description appends the summary to
+  # the MR description, replacing the section of a previous run.
+  # summary_target: "note"
+  # default | comment-only | suggestion-first. suggestion-first posts only
+  # inline findings that carry a suggested patch; comment-only drops the
+  # suggestion blocks and posts prose only.
+  # post_mode: "default"
+  # Exit nonzero when a finding is at or above this severity (CI gate):
+  # critical | high | medium | low (empty disables). Also applies to dry runs.
+  # fail_on: ""
+  # Retries for VCS API calls on 429 (honoring Retry-After) and 5xx
+  # (0 disables). POSTs are only retried on 429/503.
+  vcs_max_retries: 3
   # Enable incremental review scope using baseline markers.
   incremental: false
+  # Report the review as one pass/fail commit status (failure on any CRITICAL
+  # finding, summary in the description) instead of posting comments.
+  report_as_status: false
+  # Post an "approval should be reconsidered" note when new commits introduce
+  # CRITICAL findings after a clean review (GitLab resets approvals on push).
+  reconsider_approval: false
+  # Reply to and resolve prev threads whose finding is no longer present in
+  # the current diff.
+  auto_resolve: false
+  # Drop findings whose quoted code no longer matches the added lines at
+  # their anchor (the line was rewritten by a later commit in the MR).
+  suppress_stale: false
+  # Prepend a change-stats table (files, additions/deletions, languages) to
+  # the diff in the review prompt. Costs a few tokens per changed file.
+  include_diff_stats_in_prompt: false
   # Post inline comments only (skip summary notes and thread replies).
   inline_only: false
+  # In inline-only mode, post unplaced findings as one resolvable discussion
+  # instead of dropping them (GitLab, Azure DevOps).
+  inline_only_summary_fallback: false
   # Persistent review memory and deterministic prechecks.
   memory: true
   memory_file: ".prev/review-memory.md"
   memory_max: 12
+  # Half-life in days for ranking memory entries; entries unseen for 4
+  # half-lives are pruned. 0 disables decay.
+  memory_decay_days: 90
   native_impact: true
   native_impact_max_symbols: 12
+  # Supply-chain focused summary when dependency manifests/lockfiles change.
+  dependency_review: true
   # Include AI fix prompt blocks in inline comments: off | auto | always.
   fix_prompt: "off"
   # Plain keyword used in MR thread commands. Config accepts an optional leading @, but comments should use the bare keyword.
   mention_handle: "prev"
+  # Optional marker namespace so several prev instances can review the same MR
+  # without claiming each other's threads (markers become <!-- prev:{ns}:thread -->).
+  # marker_namespace: "security"
+  # Optional per-target-branch overrides of review.* settings (first glob match wins).
+  # target_branch_profiles:
+  #   - match: "main"
+  #     strictness: strict
+  #   - match: "release/*"
+  #     strictness: strict
+  #     max_comments: 0
+  # Extra regex rules run on added lines by mr review (the built-in
+  # json_dencode check always applies). languages: DetectLanguage names or
+  # file extensions; omit for every file. severity defaults to medium.
+  # deterministic_rules:
+  #   - pattern: '\bvar_dump\('
+  #     severity: medium
+  #     message: "Debug output left in code."
+  #     languages: [php]
+  # Added lines are always scanned for secrets (AWS keys, private keys,
+  # high-entropy values assigned to *key*/*secret*/*token* names) and
+  # reported as critical. Raise the entropy threshold to be less strict and
+  # exempt known fixtures with an allowlist regexp matched per line.
+  # secret_entropy_threshold: 3.5
+  # secret_allowlist: 'EXAMPLE|dummy'
+  # Drop hedged findings ("consider", "might", "possibly", no quoted identifier)
+  # scoring below this confidence (0..1). HIGH and CRITICAL findings are
+  # always kept. 0 disables the filter.
+  # min_confidence: 0.6
+  # Characters of the posted inline comment line (default 158).
+  # max_inline_chars: 158
+  # Key points kept per inline finding and characters per key point
+  # (defaults 4 / 220).
+  # max_inline_key_points: 4
+  # max_inline_key_point_chars: 220
+  # When more than this many inline findings share the same message, also
+  # post one grouped note listing every location. 0 disables it.
+  # consolidate_recurring: 3
   # Optional Serena/context defaults for MR review.
   # serena_mode: "auto"
   # context_lines: 10
   # max_tokens: 80000
+  # Diffs larger than this (in bytes, generated files excluded) get a
+  # summary-only review with reduced context instead of inline comments.
+  # --force-full overrides it for one run. 0 disables the guard.
+  # max_diff_bytes: 500000
+  # Add the MR's commit messages (newest 30, merge commits skipped) to the
+  # prompt so the diff is checked against their stated intent.
+  # commits: false
+  # Over-budget strategy without Serena:
+  # reduce_context | drop_lowest_priority_files | summarize_large_files
+  # budget_strategy: "reduce_context"
+  # Paths kept in full as long as possible / dropped or summarized first.
+  # budget_priority_globs: ["internal/auth/**"]
+  # budget_low_priority_globs: ["*.lock", "*-lock.json", "vendor/**"]
+  # Providers to fail over to, in order, when the active one is rate-limited
+  # or unavailable mid-review. Each uses its own providers.<name> block.
+  # fallback_providers: ["anthropic", "ollama"]
+  # Generated/vendored files left out of the prompt (replaces the built-in
+  # list; files with a "Code generated ... DO NOT EDIT" header are always
+  # skipped). Disable per run with --no-skip-generated.
+  # ignore_globs: ["go.sum", "package-lock.json", "*.pb.go", "**/vendor/**"]
   conventions:
     labels: ["issue", "suggestion", "remark"]
+  # Matching of new findings to existing prev threads on reruns.
+  # score = message_weight * similarity - distance_penalty * line distance
+  # (capped at 50 lines); the best thread is reused when score >= min_score.
+  reuse:
+    similarity: semantic     # semantic | trigram
+    message_weight: 1.0
+    distance_penalty: 1.0
+    min_score: 25
   # Optional custom instructions injected into review prompts.
   guidelines: |
     Prioritize correctness, security, and maintainability.
     Keep findings concrete and actionable.
+  # Optional per-language instructions, injected for each language in the diff.
+  # language_guidelines:
+  #   go: Prefer returning wrapped errors over panics.
+  #   python: Require type hints on public functions.
 
 # Display options.
 debug: false
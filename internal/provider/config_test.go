@@ -1,10 +1,13 @@
 package provider
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/sanix-darker/prev/internal/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBindProviderEnvVars_OpenAIEnvOverridesConfig(t *testing.T) {
@@ -57,5 +60,39 @@ func TestBindProviderEnvVars_GeminiDefaultsAndEnv(t *testing.T) {
 
 	assert.Equal(t, "gem-key", v.GetString("api_key"))
 	assert.Equal(t, "gemini-2.5-pro", v.GetString("model"))
-	assert.Equal(t, "https://generativelanguage.googleapis.com/v1beta/openai", v.GetString("base_url"))
+	assert.Equal(t, "https://generativelanguage.googleapis.com/v1beta", v.GetString("base_url"))
+
+	compat := config.NewStore()
+	bindProviderEnvVars("gemini-openai", compat)
+	assert.Equal(t, "gem-key", compat.GetString("api_key"))
+	assert.Equal(t, "https://generativelanguage.googleapis.com/v1beta/openai", compat.GetString("base_url"))
+}
+
+func TestResolveAPIKeyCommand_FillsMissingKey(t *testing.T) {
+	v := config.NewStore()
+	v.Set("api_key_command", "echo ' cmd-key '")
+
+	resolveAPIKeyCommand("openai", v)
+
+	assert.Equal(t, "cmd-key", v.GetString("api_key"))
+}
+
+func TestResolveAPIKeyCommand_ExplicitKeyWins(t *testing.T) {
+	v := config.NewStore()
+	v.Set("api_key", "file-key")
+	v.Set("api_key_command", "echo cmd-key")
+
+	resolveAPIKeyCommand("openai", v)
+
+	assert.Equal(t, "file-key", v.GetString("api_key"))
+}
+
+func TestSampleConfigYAML_LeavesOptionalGuidelinesCommentedOut(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte(SampleConfigYAML()), 0o600))
+
+	v := config.NewStore()
+	require.NoError(t, v.LoadYAMLFile(path))
+	assert.False(t, v.IsSet("review.language_guidelines.go"))
+	assert.False(t, v.IsSet("review.language_guidelines.python"))
 }
@@ -0,0 +1,146 @@
+package provider
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/sanix-darker/prev/internal/config"
+)
+
+// DefaultContextWindow is the context window (in tokens) assumed for models
+// missing from modelContextWindows.
+const DefaultContextWindow = 128000
+
+// maxResponseReserve caps how much of a window PromptTokenBudget keeps free
+// for the model's answer.
+const maxResponseReserve = 8192
+
+// exactModelContextWindows holds models whose ID is a prefix of unrelated,
+// larger models (gpt-4 of gpt-4.5 and gpt-4-turbo), so they only match
+// exactly.
+var exactModelContextWindows = map[string]int{
+	"gpt-4":      8192,
+	"gpt-4-0314": 8192,
+	"gpt-4-0613": 8192,
+}
+
+// modelContextWindows maps model IDs (or ID prefixes) to their context window
+// in tokens. Lookups try an exact match first, then the longest prefix, so
+// dated snapshots like "gpt-4o-2024-08-06" resolve through "gpt-4o".
+var modelContextWindows = map[string]int{
+	// OpenAI
+	"gpt-4o":        128000,
+	"gpt-4o-mini":   128000,
+	"gpt-4-turbo":   128000,
+	"gpt-4-32k":     32768,
+	"gpt-4.1":       1047576,
+	"gpt-4.5":       128000,
+	"gpt-3.5-turbo": 16385,
+	"gpt-5":         400000,
+	"o1":            200000,
+	"o3":            200000,
+	"o4-mini":       200000,
+	// Anthropic
+	"claude-": 200000,
+	// Gemini
+	"gemini-1.5-pro":   2097152,
+	"gemini-1.5-flash": 1048576,
+	"gemini-2.0-flash": 1048576,
+	"gemini-2.5":       1048576,
+	// Mistral
+	"mistral-large":   131072,
+	"mistral-medium":  131072,
+	"mistral-small":   32768,
+	"codestral":       262144,
+	"open-mistral-7b": 32768,
+	// Common local models (Ollama tags)
+	"llama3":      8192,
+	"llama3.1":    131072,
+	"llama3.2":    131072,
+	"qwen2.5":     32768,
+	"deepseek-r1": 131072,
+	"mixtral":     32768,
+	"phi3":        4096,
+}
+
+// ModelContextWindow returns the context window in tokens for model, or
+// DefaultContextWindow when the model is unknown.
+func ModelContextWindow(model string) int {
+	model = strings.ToLower(strings.TrimSpace(model))
+	if n, ok := exactModelContextWindows[model]; ok {
+		return n
+	}
+	if n, ok := modelContextWindows[model]; ok {
+		return n
+	}
+	best, window := 0, DefaultContextWindow
+	for prefix, n := range modelContextWindows {
+		if len(prefix) > best && strings.HasPrefix(model, prefix) {
+			best, window = len(prefix), n
+		}
+	}
+	return window
+}
+
+// PromptTokenBudget returns tokens limited to what fits in model's window
+// after reserving room for the response, and whether it had to be lowered.
+func PromptTokenBudget(model string, tokens int) (int, bool) {
+	window := ModelContextWindow(model)
+	limit := window - min(window/4, maxResponseReserve)
+	if tokens <= 0 || tokens <= limit {
+		return tokens, false
+	}
+	return limit, true
+}
+
+// ClampResponseTokens lowers maxTokens so that the estimated prompt size
+// plus the response budget fits in model's window, and reports whether it
+// did. A prompt that alone exceeds the window is left to the API to reject.
+// It is called on every request, so callers warn through a ClampNotice.
+func ClampResponseTokens(model string, maxTokens int, messages []Message) (int, bool) {
+	if maxTokens <= 0 {
+		return maxTokens, false
+	}
+	window := ModelContextWindow(model)
+	promptTokens := 0
+	for _, m := range messages {
+		promptTokens += len(m.Content) / 4
+	}
+	available := window - promptTokens
+	if available <= 0 || maxTokens <= available {
+		return maxTokens, false
+	}
+	return available, true
+}
+
+// ClampNotice prints the max_tokens clamp notice once per provider instance,
+// so retries and repeated passes do not repeat it.
+type ClampNotice struct {
+	once sync.Once
+}
+
+// Warn reports that maxTokens was clamped to clamped for model.
+func (n *ClampNotice) Warn(model string, maxTokens, clamped int) {
+	n.once.Do(func() {
+		fmt.Fprintf(os.Stderr, "Notice: max_tokens %d exceeds the remaining %s context window; clamped to %d\n", maxTokens, model, clamped)
+	})
+}
+
+// ConfiguredModel returns the model the active provider will use, with
+// aliases expanded, without instantiating the provider. override (usually
+// the --model flag) wins over the config file.
+func ConfiguredModel(v *config.Store, override string) string {
+	name := activeProviderName(v)
+	sub := v.Sub(fmt.Sprintf("providers.%s", name))
+	if sub == nil {
+		sub = config.NewStore()
+	}
+	bindProviderEnvVars(name, sub)
+	model := strings.TrimSpace(override)
+	if model == "" {
+		model = sub.GetString("model")
+	}
+	return ResolveModelAlias(ModelAliases(name, sub), model)
+}
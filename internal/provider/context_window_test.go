@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModelContextWindow_KnownModels(t *testing.T) {
+	assert.Equal(t, 8192, ModelContextWindow("gpt-4"))
+	assert.Equal(t, 128000, ModelContextWindow("gpt-4o"))
+	assert.Equal(t, 128000, ModelContextWindow("gpt-4o-2024-08-06"))
+	assert.Equal(t, 1047576, ModelContextWindow("gpt-4.1-mini"))
+	assert.Equal(t, 200000, ModelContextWindow("claude-sonnet-4-20250514"))
+	assert.Equal(t, 32768, ModelContextWindow("Mistral-Small-Latest"))
+	assert.Equal(t, 131072, ModelContextWindow("llama3.1:8b"))
+	assert.Equal(t, 8192, ModelContextWindow("llama3:8b"))
+}
+
+func TestModelContextWindow_UnknownFallsBackToDefault(t *testing.T) {
+	assert.Equal(t, DefaultContextWindow, ModelContextWindow("my-finetune"))
+	assert.Equal(t, DefaultContextWindow, ModelContextWindow(""))
+}
+
+func TestPromptTokenBudget(t *testing.T) {
+	budget, clamped := PromptTokenBudget("gpt-4", 80000)
+	assert.True(t, clamped)
+	assert.Equal(t, 8192-2048, budget)
+
+	budget, clamped = PromptTokenBudget("claude-opus-4-20250514", 80000)
+	assert.False(t, clamped)
+	assert.Equal(t, 80000, budget)
+
+	budget, clamped = PromptTokenBudget("gpt-4", 0)
+	assert.False(t, clamped)
+	assert.Equal(t, 0, budget)
+}
+
+func TestClampResponseTokens(t *testing.T) {
+	prompt := []Message{{Role: RoleUser, Content: strings.Repeat("x", 4*6000)}}
+
+	got, clamped := ClampResponseTokens("gpt-4", 4096, prompt)
+	assert.True(t, clamped)
+	assert.Equal(t, 8192-6000, got)
+	got, clamped = ClampResponseTokens("gpt-4", 1024, prompt)
+	assert.False(t, clamped)
+	assert.Equal(t, 1024, got)
+	got, clamped = ClampResponseTokens("gpt-4o", 4096, prompt)
+	assert.False(t, clamped)
+	assert.Equal(t, 4096, got)
+}
+
+func TestModelContextWindow_GPT4IsExactOnly(t *testing.T) {
+	assert.Equal(t, 8192, ModelContextWindow("gpt-4"))
+	assert.Equal(t, 8192, ModelContextWindow("gpt-4-0613"))
+	assert.Equal(t, 128000, ModelContextWindow("gpt-4.5-preview"))
+	assert.Equal(t, 128000, ModelContextWindow("gpt-4-turbo-2024-04-09"))
+	assert.Equal(t, 1047576, ModelContextWindow("gpt-4.1-mini"))
+}
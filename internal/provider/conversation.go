@@ -15,6 +15,15 @@ type ConversationOptions struct {
 	Temperature   *float64
 	TopP          *float64
 	StopSequences []string
+	JSONMode      bool
+
+	// Samples, when above one, asks for that many choices per blocking
+	// call (see CompleteChoices). Only the first choice enters the history.
+	Samples int
+
+	// AssistantPrefill is sent as CompletionRequest.AssistantPrefill on
+	// every call.
+	AssistantPrefill string
 }
 
 // Conversation keeps provider-agnostic conversation state so related review
@@ -28,6 +37,9 @@ type Conversation struct {
 	temperature    *float64
 	topP           *float64
 	stopSequences  []string
+	jsonMode       bool
+	samples        int
+	prefill        string
 	lastResponseID string
 }
 
@@ -41,6 +53,9 @@ func NewConversation(p AIProvider, opts ConversationOptions) *Conversation {
 		temperature:   opts.Temperature,
 		topP:          opts.TopP,
 		stopSequences: append([]string(nil), opts.StopSequences...),
+		jsonMode:      opts.JSONMode,
+		samples:       opts.Samples,
+		prefill:       opts.AssistantPrefill,
 	}
 	c.messages = append(c.messages, normalizeMessages(opts.Messages)...)
 	return c
@@ -59,12 +74,23 @@ func (c *Conversation) Clone() *Conversation {
 		temperature:    c.temperature,
 		topP:           c.topP,
 		stopSequences:  append([]string(nil), c.stopSequences...),
+		jsonMode:       c.jsonMode,
+		samples:        c.samples,
+		prefill:        c.prefill,
 		lastResponseID: c.lastResponseID,
 	}
 	clone.messages = append(clone.messages, c.messages...)
 	return clone
 }
 
+// SetSamples changes how many choices later blocking calls ask for.
+func (c *Conversation) SetSamples(n int) {
+	if c == nil {
+		return
+	}
+	c.samples = n
+}
+
 // Append adds normalized messages to the conversation history.
 func (c *Conversation) Append(msgs ...Message) {
 	if c == nil {
@@ -103,6 +129,69 @@ func (c *Conversation) CompleteMessage(ctx context.Context, msg Message) (*Compl
 	}
 	msg.Content = strings.TrimSpace(msg.Content)
 
+	resp, err := CompleteChoices(ctx, c.provider, c.request(msg))
+	if err != nil {
+		return nil, err
+	}
+	c.record(msg, resp)
+	return resp, nil
+}
+
+// CompleteStream sends a new user message and calls onChunk with each content
+// delta as it arrives. The returned response carries the reassembled content
+// and is recorded in the conversation like Complete. Providers without
+// streaming support fall back to a blocking call delivered as one chunk.
+func (c *Conversation) CompleteStream(ctx context.Context, prompt string, onChunk func(string)) (*CompletionResponse, error) {
+	if c == nil {
+		return nil, nil
+	}
+	msg := Message{Role: RoleUser, Content: strings.TrimSpace(prompt)}
+	if onChunk == nil {
+		onChunk = func(string) {}
+	}
+
+	if !c.provider.Info().SupportsStreaming {
+		resp, err := c.provider.Complete(ctx, c.request(msg))
+		if err != nil {
+			return nil, err
+		}
+		onChunk(resp.BestText())
+		c.record(msg, resp)
+		return resp, nil
+	}
+
+	req := c.request(msg)
+	req.Stream = true
+	req.N = 0
+	result := c.provider.CompleteStream(ctx, req)
+	var b strings.Builder
+	resp := &CompletionResponse{}
+	for chunk := range result.Chunks {
+		if chunk.Content != "" {
+			b.WriteString(chunk.Content)
+			onChunk(chunk.Content)
+		}
+		if chunk.FinishReason != "" {
+			resp.FinishReason = chunk.FinishReason
+		}
+		if chunk.Usage != nil {
+			resp.Usage = *chunk.Usage
+		}
+		if chunk.Provider != "" {
+			resp.Provider = chunk.Provider
+		}
+	}
+	if err := <-result.Err; err != nil {
+		return nil, err
+	}
+	resp.Content = b.String()
+	resp.Choices = []Choice{{Content: resp.Content, FinishReason: resp.FinishReason}}
+	c.record(msg, resp)
+	return resp, nil
+}
+
+// request builds the provider request for msg on top of the current history.
+func (c *Conversation) request(msg Message) CompletionRequest {
 	messages := make([]Message, 0, len(c.messages)+2)
 	if c.systemPrompt != "" {
 		messages = append(messages, Message{Role: RoleSystem, Content: c.systemPrompt})
@@ -111,27 +200,28 @@ func (c *Conversation) CompleteMessage(ctx context.Context, msg Message) (*Compl
 	if msg.Content != "" {
 		messages = append(messages, msg)
 	}
-
-	resp, err := c.provider.Complete(ctx, CompletionRequest{
-		Model:         c.model,
-		Messages:      messages,
-		MaxTokens:     c.maxTokens,
-		Temperature:   c.temperature,
-		TopP:          c.topP,
-		StopSequences: append([]string(nil), c.stopSequences...),
-	})
-	if err != nil {
-		return nil, err
+	return CompletionRequest{
+		Model:            c.model,
+		Messages:         messages,
+		MaxTokens:        c.maxTokens,
+		Temperature:      c.temperature,
+		TopP:             c.topP,
+		StopSequences:    append([]string(nil), c.stopSequences...),
+		JSONMode:         c.jsonMode,
+		N:                c.samples,
+		AssistantPrefill: c.prefill,
 	}
+}
 
+// record appends msg and the assistant reply to the history.
+func (c *Conversation) record(msg Message, resp *CompletionResponse) {
 	if msg.Content != "" {
 		c.messages = append(c.messages, msg)
 	}
-	if strings.TrimSpace(resp.Content) != "" {
-		c.messages = append(c.messages, Message{Role: RoleAssistant, Content: strings.TrimSpace(resp.Content)})
+	if text := strings.TrimSpace(resp.BestText()); text != "" {
+		c.messages = append(c.messages, Message{Role: RoleAssistant, Content: text})
 	}
 	c.lastResponseID = strings.TrimSpace(resp.ID)
-	return resp, nil
 }
 
 func normalizeMessages(msgs []Message) []Message {
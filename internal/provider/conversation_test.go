@@ -87,6 +87,20 @@ func TestConversation_CloneForksHistoryWithoutMutatingParent(t *testing.T) {
 	assert.Equal(t, "fork prompt", fork.Messages()[3].Content)
 }
 
+func TestConversation_SendsAssistantPrefillOnEveryCall(t *testing.T) {
+	p := &scriptedProvider{}
+	conv := NewConversation(p, ConversationOptions{AssistantPrefill: `{"summary":`})
+	_, err := conv.Complete(context.Background(), "first")
+	require.NoError(t, err)
+	_, err = conv.Clone().Complete(context.Background(), "second")
+	require.NoError(t, err)
+
+	require.Len(t, p.requests, 2)
+	for _, req := range p.requests {
+		assert.Equal(t, `{"summary":`, req.AssistantPrefill)
+	}
+}
+
 func TestSimpleComplete_UsesConversationFriendlyMessageShape(t *testing.T) {
 	p := &scriptedProvider{responses: []CompletionResponse{{ID: "resp-1", Content: "done", Choices: []Choice{{Content: "done"}}}}}
 	id, choices, err := SimpleComplete(p, "system", "assistant", "question")
@@ -96,3 +110,56 @@ func TestSimpleComplete_UsesConversationFriendlyMessageShape(t *testing.T) {
 	require.Len(t, p.requests, 1)
 	assert.Len(t, p.requests[0].Messages, 3)
 }
+
+type streamingScriptedProvider struct {
+	scriptedProvider
+	chunks []string
+}
+
+func (s *streamingScriptedProvider) Info() ProviderInfo {
+	return ProviderInfo{Name: "streaming", SupportsStreaming: true}
+}
+
+func (s *streamingScriptedProvider) CompleteStream(_ context.Context, req CompletionRequest) StreamResult {
+	s.requests = append(s.requests, req)
+	chunks := make(chan StreamChunk, len(s.chunks)+1)
+	errs := make(chan error, 1)
+	for _, c := range s.chunks {
+		chunks <- StreamChunk{Content: c}
+	}
+	chunks <- StreamChunk{Done: true, FinishReason: "stop"}
+	close(chunks)
+	close(errs)
+	return StreamResult{Chunks: chunks, Err: errs}
+}
+
+func TestConversation_CompleteStreamReassemblesAndRecordsReply(t *testing.T) {
+	p := &streamingScriptedProvider{chunks: []string{"## Sum", "mary\n", "done"}}
+	conv := NewConversation(p, ConversationOptions{SystemPrompt: "review system"})
+
+	var seen []string
+	resp, err := conv.CompleteStream(context.Background(), "review this", func(s string) { seen = append(seen, s) })
+	require.NoError(t, err)
+	assert.Equal(t, []string{"## Sum", "mary\n", "done"}, seen)
+	assert.Equal(t, "## Summary\ndone", resp.Content)
+	assert.Equal(t, "stop", resp.FinishReason)
+
+	require.Len(t, p.requests, 1)
+	assert.True(t, p.requests[0].Stream)
+	history := conv.Messages()
+	require.Len(t, history, 2)
+	assert.Equal(t, "## Summary\ndone", history[1].Content)
+}
+
+func TestConversation_CompleteStreamFallsBackToBlocking(t *testing.T) {
+	p := &scriptedProvider{responses: []CompletionResponse{{Content: "whole reply"}}}
+	conv := NewConversation(p, ConversationOptions{})
+
+	var seen []string
+	resp, err := conv.CompleteStream(context.Background(), "review this", func(s string) { seen = append(seen, s) })
+	require.NoError(t, err)
+	assert.Equal(t, []string{"whole reply"}, seen)
+	assert.Equal(t, "whole reply", resp.Content)
+	require.Len(t, p.requests, 1)
+	assert.False(t, p.requests[0].Stream)
+}
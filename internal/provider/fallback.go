@@ -0,0 +1,132 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// FallbackProvider serves requests from the first provider of its chain and
+// switches to the next one when the current provider is rate-limited or
+// unavailable. The switch is sticky: once a provider fails over, the rest of
+// the run stays on its successor.
+type FallbackProvider struct {
+	mu      sync.Mutex
+	chain   []AIProvider
+	current int
+
+	// OnSwitch, when set, is called each time the chain moves on to the
+	// next provider.
+	OnSwitch func(from, to ProviderInfo, err error)
+}
+
+// NewFallbackProvider returns a provider that tries chain in order. It
+// panics if chain is empty.
+func NewFallbackProvider(chain ...AIProvider) *FallbackProvider {
+	if len(chain) == 0 {
+		panic("provider: NewFallbackProvider needs at least one provider")
+	}
+	return &FallbackProvider{chain: chain}
+}
+
+// shouldFailOver reports whether err means the provider cannot serve the
+// request right now, as opposed to the request itself being bad.
+func shouldFailOver(err error) bool {
+	return errors.Is(err, ErrRateLimit) || errors.Is(err, ErrProviderUnavailable)
+}
+
+// Current returns the provider requests are currently sent to.
+func (f *FallbackProvider) Current() AIProvider {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.chain[f.current]
+}
+
+// advance moves past failed, unless another caller already did. It
+// returns the provider to use next, or nil when the chain is exhausted.
+func (f *FallbackProvider) advance(failed AIProvider, err error) AIProvider {
+	f.mu.Lock()
+	if f.chain[f.current] != failed {
+		next := f.chain[f.current]
+		f.mu.Unlock()
+		return next
+	}
+	if f.current == len(f.chain)-1 {
+		f.mu.Unlock()
+		return nil
+	}
+	f.current++
+	next := f.chain[f.current]
+	onSwitch := f.OnSwitch
+	f.mu.Unlock()
+	if onSwitch != nil {
+		onSwitch(failed.Info(), next.Info(), err)
+	}
+	return next
+}
+
+// Info returns the metadata of the current provider.
+func (f *FallbackProvider) Info() ProviderInfo {
+	return f.Current().Info()
+}
+
+// Complete sends req to the current provider, failing over down the chain
+// on rate-limit and unavailability errors. The response names the provider
+// that served it, since concurrent callers may see the chain move.
+func (f *FallbackProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	p := f.Current()
+	for {
+		resp, err := p.Complete(ctx, req)
+		if err == nil || !shouldFailOver(err) || ctx.Err() != nil {
+			if resp != nil && resp.Provider == "" {
+				resp.Provider = p.Info().Name
+			}
+			return resp, err
+		}
+		if p = f.advance(p, err); p == nil {
+			return resp, err
+		}
+	}
+}
+
+// CompleteStream streams from the current provider. It fails over only when
+// the stream errors before yielding any content, so callers never see
+// output from two providers mixed together.
+func (f *FallbackProvider) CompleteStream(ctx context.Context, req CompletionRequest) StreamResult {
+	chunks := make(chan StreamChunk)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		defer close(chunks)
+		p := f.Current()
+		for {
+			res := p.CompleteStream(ctx, req)
+			emitted := false
+			name := p.Info().Name
+			for c := range res.Chunks {
+				emitted = true
+				if c.Provider == "" {
+					c.Provider = name
+				}
+				chunks <- c
+			}
+			err := <-res.Err
+			if err == nil || emitted || !shouldFailOver(err) || ctx.Err() != nil {
+				if err != nil {
+					errc <- err
+				}
+				return
+			}
+			if p = f.advance(p, err); p == nil {
+				errc <- err
+				return
+			}
+		}
+	}()
+	return StreamResult{Chunks: chunks, Err: errc}
+}
+
+// Validate checks the current provider.
+func (f *FallbackProvider) Validate(ctx context.Context) error {
+	return f.Current().Validate(ctx)
+}
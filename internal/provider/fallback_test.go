@@ -0,0 +1,89 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingProvider struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (f *failingProvider) Info() ProviderInfo { return ProviderInfo{Name: f.name} }
+
+func (f *failingProvider) Complete(_ context.Context, _ CompletionRequest) (*CompletionResponse, error) {
+	f.calls++
+	return nil, f.err
+}
+
+func (f *failingProvider) CompleteStream(_ context.Context, _ CompletionRequest) StreamResult {
+	f.calls++
+	chunks := make(chan StreamChunk)
+	errs := make(chan error, 1)
+	close(chunks)
+	errs <- f.err
+	close(errs)
+	return StreamResult{Chunks: chunks, Err: errs}
+}
+
+func (f *failingProvider) Validate(_ context.Context) error { return nil }
+
+func TestFallbackProvider_FailsOverOnRateLimitAndStays(t *testing.T) {
+	primary := &failingProvider{name: "openai", err: &ProviderError{Code: ErrCodeRateLimit, Provider: "openai"}}
+	backup := &scriptedProvider{}
+	fp := NewFallbackProvider(primary, backup)
+	var switches []string
+	fp.OnSwitch = func(from, to ProviderInfo, _ error) { switches = append(switches, from.Name+"->"+to.Name) }
+
+	resp, err := fp.Complete(context.Background(), CompletionRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp.Content)
+	assert.Equal(t, "scripted", resp.Provider)
+	assert.Equal(t, "scripted", fp.Info().Name)
+
+	_, err = fp.Complete(context.Background(), CompletionRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, primary.calls)
+	assert.Len(t, backup.requests, 2)
+	assert.Equal(t, []string{"openai->scripted"}, switches)
+}
+
+func TestFallbackProvider_DoesNotFailOverOnRequestErrors(t *testing.T) {
+	primary := &failingProvider{name: "openai", err: &ProviderError{Code: ErrCodeInvalidRequest}}
+	backup := &scriptedProvider{}
+	fp := NewFallbackProvider(primary, backup)
+
+	_, err := fp.Complete(context.Background(), CompletionRequest{})
+	assert.ErrorIs(t, err, ErrInvalidRequest)
+	assert.Empty(t, backup.requests)
+	assert.Equal(t, "openai", fp.Info().Name)
+}
+
+func TestFallbackProvider_ReturnsLastErrorWhenChainExhausted(t *testing.T) {
+	first := &failingProvider{name: "openai", err: &ProviderError{Code: ErrCodeRateLimit}}
+	second := &failingProvider{name: "anthropic", err: &ProviderError{Code: ErrCodeProviderUnavailable}}
+	fp := NewFallbackProvider(first, second)
+
+	_, err := fp.Complete(context.Background(), CompletionRequest{})
+	assert.ErrorIs(t, err, ErrProviderUnavailable)
+	assert.Equal(t, 1, first.calls)
+	assert.Equal(t, 1, second.calls)
+}
+
+func TestFallbackProvider_StreamFailsOverBeforeFirstChunk(t *testing.T) {
+	primary := &failingProvider{name: "openai", err: &ProviderError{Code: ErrCodeRateLimit}}
+	backup := &scriptedProvider{}
+	fp := NewFallbackProvider(primary, backup)
+
+	res := fp.CompleteStream(context.Background(), CompletionRequest{})
+	for c := range res.Chunks {
+		assert.Equal(t, "scripted", c.Provider)
+	}
+	assert.NoError(t, <-res.Err)
+	assert.Equal(t, "scripted", fp.Info().Name)
+}
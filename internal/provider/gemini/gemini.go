@@ -0,0 +1,530 @@
+// Package gemini implements the AIProvider interface for Google's native
+// Gemini API (generativelanguage.googleapis.com).
+//
+// Gemini's API differs from OpenAI's in several key ways:
+//   - Messages are "contents" with roles "user" and "model" only.
+//   - The system prompt is a top-level "systemInstruction" field.
+//   - Sampling options live under "generationConfig".
+//   - Streaming uses ":streamGenerateContent?alt=sse" where every event is
+//     a full GenerateContentResponse fragment.
+//   - Authentication uses the "x-goog-api-key" header.
+//
+// Configs that still point base_url at Google's OpenAI-compatible endpoint
+// (".../v1beta/openai") fall back to the compat provider.
+package gemini
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sanix-darker/prev/internal/config"
+	"github.com/sanix-darker/prev/internal/provider"
+	"github.com/sanix-darker/prev/internal/provider/compat"
+)
+
+// ---------------------------------------------------------------------------
+// Registration
+// ---------------------------------------------------------------------------
+
+func init() {
+	provider.Register("gemini", NewProvider)
+}
+
+// ---------------------------------------------------------------------------
+// Gemini-specific API types (request)
+// ---------------------------------------------------------------------------
+
+type apiPart struct {
+	Text string `json:"text"`
+}
+
+type apiContent struct {
+	Role  string    `json:"role,omitempty"`
+	Parts []apiPart `json:"parts"`
+}
+
+type apiGenerationConfig struct {
+	MaxOutputTokens int      `json:"maxOutputTokens,omitempty"`
+	Temperature     *float64 `json:"temperature,omitempty"`
+	TopP            *float64 `json:"topP,omitempty"`
+	StopSequences   []string `json:"stopSequences,omitempty"`
+}
+
+type apiRequest struct {
+	Contents          []apiContent         `json:"contents"`
+	SystemInstruction *apiContent          `json:"systemInstruction,omitempty"`
+	GenerationConfig  *apiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+// ---------------------------------------------------------------------------
+// Gemini-specific API types (response)
+// ---------------------------------------------------------------------------
+
+type apiCandidate struct {
+	Index        int        `json:"index"`
+	Content      apiContent `json:"content"`
+	FinishReason string     `json:"finishReason"`
+}
+
+type apiUsageMetadata struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+	TotalTokenCount      int `json:"totalTokenCount"`
+}
+
+type apiResponse struct {
+	Candidates     []apiCandidate    `json:"candidates"`
+	UsageMetadata  *apiUsageMetadata `json:"usageMetadata"`
+	ModelVersion   string            `json:"modelVersion"`
+	ResponseID     string            `json:"responseId"`
+	PromptFeedback *struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+}
+
+type apiError struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error"`
+}
+
+// ---------------------------------------------------------------------------
+// Provider implementation
+// ---------------------------------------------------------------------------
+
+const (
+	defaultBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+	defaultModel   = "gemini-2.0-flash"
+)
+
+// Provider implements provider.AIProvider for the native Gemini API.
+type Provider struct {
+	client      *http.Client
+	apiKey      string
+	baseURL     string
+	model       string
+	aliases     map[string]string
+	maxTok      int
+	retryCfg    provider.RetryConfig
+	clampNotice provider.ClampNotice
+}
+
+// NewProvider is the factory function registered with the provider registry.
+func NewProvider(v *config.Store) (provider.AIProvider, error) {
+	baseURL := strings.TrimRight(v.GetString("base_url"), "/")
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	if strings.HasSuffix(baseURL, "/openai") {
+		// Legacy configuration targeting the OpenAI-compatible surface.
+		return compat.NewProvider("gemini", v)
+	}
+	model := v.GetString("model")
+	if model == "" {
+		model = defaultModel
+	}
+	aliases := provider.ModelAliases("gemini", v)
+	model = provider.ResolveModelAlias(aliases, model)
+	maxTok := v.GetInt("max_tokens")
+	if maxTok == 0 {
+		maxTok = 1024
+	}
+	timeout := v.GetDuration("timeout")
+	if timeout == 0 {
+		timeout = 60 * time.Second
+	}
+
+	return &Provider{
+		client:   &http.Client{Timeout: timeout},
+		apiKey:   v.GetString("api_key"),
+		baseURL:  baseURL,
+		model:    model,
+		aliases:  aliases,
+		maxTok:   maxTok,
+		retryCfg: provider.DefaultRetryConfig(),
+	}, nil
+}
+
+// Info returns provider metadata.
+func (p *Provider) Info() provider.ProviderInfo {
+	return provider.ProviderInfo{
+		Name:              "gemini",
+		DisplayName:       "Google Gemini",
+		Description:       "Google Gemini API (generateContent)",
+		DefaultModel:      defaultModel,
+		SupportsStreaming: true,
+	}
+}
+
+// Validate checks that the API key is present.
+func (p *Provider) Validate(ctx context.Context) error {
+	if p.apiKey == "" {
+		return &provider.ProviderError{
+			Code:     provider.ErrCodeAuthentication,
+			Message:  "GEMINI_API_KEY is not set",
+			Provider: "gemini",
+		}
+	}
+	return nil
+}
+
+// Complete performs a synchronous generateContent call.
+func (p *Provider) Complete(ctx context.Context, req provider.CompletionRequest) (*provider.CompletionResponse, error) {
+	return provider.WithRetry(ctx, p.retryCfg, func() (*provider.CompletionResponse, error) {
+		return p.doComplete(ctx, req)
+	})
+}
+
+func (p *Provider) doComplete(ctx context.Context, req provider.CompletionRequest) (*provider.CompletionResponse, error) {
+	model := p.resolveModel(req)
+	bodyBytes, err := json.Marshal(p.buildRequest(req))
+	if err != nil {
+		return nil, &provider.ProviderError{
+			Code: provider.ErrCodeUnknown, Message: "failed to marshal request",
+			Provider: "gemini", Cause: err,
+		}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		p.endpoint(model, "generateContent"), bytes.NewReader(bodyBytes))
+	if err != nil {
+		return nil, &provider.ProviderError{
+			Code: provider.ErrCodeUnknown, Message: "failed to build request",
+			Provider: "gemini", Cause: err,
+		}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-goog-api-key", p.apiKey)
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, &provider.ProviderError{
+			Code: provider.ErrCodeProviderUnavailable, Message: "HTTP request failed",
+			Provider: "gemini", Cause: err,
+		}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &provider.ProviderError{
+			Code: provider.ErrCodeUnknown, Message: "failed to read response",
+			Provider: "gemini", Cause: err,
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, classifyHTTPError(resp.StatusCode, resp.Header, respBody)
+	}
+
+	var apiResp apiResponse
+	if err := json.Unmarshal(respBody, &apiResp); err != nil {
+		return nil, &provider.ProviderError{
+			Code: provider.ErrCodeUnknown, Message: "failed to decode response",
+			Provider: "gemini", Cause: err,
+		}
+	}
+
+	return toCompletionResponse(&apiResp, model), nil
+}
+
+// CompleteStream performs a streaming completion using Gemini's SSE mode.
+func (p *Provider) CompleteStream(ctx context.Context, req provider.CompletionRequest) provider.StreamResult {
+	chunks := make(chan provider.StreamChunk, 64)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(chunks)
+		defer close(errCh)
+
+		model := p.resolveModel(req)
+		bodyBytes, err := json.Marshal(p.buildRequest(req))
+		if err != nil {
+			errCh <- &provider.ProviderError{
+				Code: provider.ErrCodeUnknown, Message: "failed to marshal request",
+				Provider: "gemini", Cause: err,
+			}
+			return
+		}
+
+		httpReq, err := http.NewRequestWithContext(
+			ctx, http.MethodPost,
+			p.endpoint(model, "streamGenerateContent")+"?alt=sse",
+			bytes.NewReader(bodyBytes),
+		)
+		if err != nil {
+			errCh <- &provider.ProviderError{
+				Code: provider.ErrCodeUnknown, Message: "failed to build request",
+				Provider: "gemini", Cause: err,
+			}
+			return
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set("x-goog-api-key", p.apiKey)
+		httpReq.Header.Set("Accept", "text/event-stream")
+
+		httpResp, err := p.client.Do(httpReq)
+		if err != nil {
+			errCh <- &provider.ProviderError{
+				Code: provider.ErrCodeProviderUnavailable, Message: "stream request failed",
+				Provider: "gemini", Cause: err,
+			}
+			return
+		}
+		defer httpResp.Body.Close()
+
+		if httpResp.StatusCode != http.StatusOK {
+			var buf [4096]byte
+			n, _ := httpResp.Body.Read(buf[:])
+			errCh <- classifyHTTPError(httpResp.StatusCode, httpResp.Header, buf[:n])
+			return
+		}
+
+		// Each SSE event carries a partial GenerateContentResponse; the last
+		// one holds the finish reason and usageMetadata.
+		scanner := provider.NewSSEScanner(httpResp.Body)
+		var usage *provider.Usage
+		finishReason := ""
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+			var evt apiResponse
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &evt); err != nil {
+				continue
+			}
+			if evt.UsageMetadata != nil {
+				usage = toUsage(evt.UsageMetadata)
+			}
+			if evt.PromptFeedback != nil && evt.PromptFeedback.BlockReason != "" {
+				finishReason = string(provider.ErrCodeContentFilter)
+			}
+			if len(evt.Candidates) == 0 {
+				continue
+			}
+			c := evt.Candidates[0]
+			if c.FinishReason != "" {
+				finishReason = normalizeFinishReason(c.FinishReason)
+			}
+			if text := candidateText(c); text != "" {
+				if !provider.SendStreamChunk(ctx, chunks, provider.StreamChunk{Content: text}) {
+					errCh <- ctx.Err()
+					return
+				}
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			errCh <- &provider.ProviderError{
+				Code: provider.ErrCodeUnknown, Message: "stream read error",
+				Provider: "gemini", Cause: err,
+			}
+			return
+		}
+
+		if !provider.SendStreamChunk(ctx, chunks, provider.StreamChunk{
+			Done:         true,
+			FinishReason: finishReason,
+			Usage:        usage,
+		}) {
+			errCh <- ctx.Err()
+		}
+	}()
+
+	return provider.StreamResult{Chunks: chunks, Err: errCh}
+}
+
+// ---------------------------------------------------------------------------
+// Helpers
+// ---------------------------------------------------------------------------
+
+func (p *Provider) resolveModel(req provider.CompletionRequest) string {
+	model := provider.ResolveModelAlias(p.aliases, req.Model)
+	if model == "" {
+		model = p.model
+	}
+	return strings.TrimPrefix(model, "models/")
+}
+
+func (p *Provider) endpoint(model, method string) string {
+	return fmt.Sprintf("%s/models/%s:%s", p.baseURL, url.PathEscape(model), method)
+}
+
+// buildRequest converts the provider-agnostic CompletionRequest into Gemini's
+// native format. System messages become systemInstruction and assistant turns
+// use the "model" role. A leading model turn is folded into the system
+// instruction because Gemini expects conversations to start with the user.
+func (p *Provider) buildRequest(req provider.CompletionRequest) apiRequest {
+	maxTok := req.MaxTokens
+	if maxTok == 0 {
+		maxTok = p.maxTok
+	}
+	if clamped, ok := provider.ClampResponseTokens(p.resolveModel(req), maxTok, req.Messages); ok {
+		p.clampNotice.Warn(p.resolveModel(req), maxTok, clamped)
+		maxTok = clamped
+	}
+
+	var systemPrompt string
+	var contents []apiContent
+	for _, m := range req.Messages {
+		if m.Role == provider.RoleSystem {
+			if systemPrompt != "" {
+				systemPrompt += "\n\n"
+			}
+			systemPrompt += m.Content
+			continue
+		}
+		role := "user"
+		if m.Role == provider.RoleAssistant {
+			role = "model"
+		}
+		// Gemini rejects consecutive turns with the same role; merge them.
+		if n := len(contents); n > 0 && contents[n-1].Role == role {
+			contents[n-1].Parts = append(contents[n-1].Parts, apiPart{Text: m.Content})
+			continue
+		}
+		contents = append(contents, apiContent{Role: role, Parts: []apiPart{{Text: m.Content}}})
+	}
+
+	if len(contents) > 0 && contents[0].Role == "model" {
+		for _, part := range contents[0].Parts {
+			if systemPrompt != "" {
+				systemPrompt += "\n\n"
+			}
+			systemPrompt += part.Text
+		}
+		contents = contents[1:]
+	}
+
+	out := apiRequest{
+		Contents: contents,
+		GenerationConfig: &apiGenerationConfig{
+			MaxOutputTokens: maxTok,
+			Temperature:     req.Temperature,
+			TopP:            req.TopP,
+			StopSequences:   req.StopSequences,
+		},
+	}
+	if systemPrompt != "" {
+		out.SystemInstruction = &apiContent{Parts: []apiPart{{Text: systemPrompt}}}
+	}
+	return out
+}
+
+func candidateText(c apiCandidate) string {
+	var sb strings.Builder
+	for _, part := range c.Content.Parts {
+		sb.WriteString(part.Text)
+	}
+	return sb.String()
+}
+
+// normalizeFinishReason maps Gemini finish reasons onto the values used by the
+// other providers; safety blocks surface as "content_filter".
+func normalizeFinishReason(reason string) string {
+	switch strings.ToUpper(strings.TrimSpace(reason)) {
+	case "":
+		return ""
+	case "STOP":
+		return "stop"
+	case "MAX_TOKENS":
+		return "length"
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII":
+		return string(provider.ErrCodeContentFilter)
+	default:
+		return strings.ToLower(reason)
+	}
+}
+
+func toUsage(u *apiUsageMetadata) *provider.Usage {
+	total := u.TotalTokenCount
+	if total == 0 {
+		total = u.PromptTokenCount + u.CandidatesTokenCount
+	}
+	return &provider.Usage{
+		PromptTokens:     u.PromptTokenCount,
+		CompletionTokens: u.CandidatesTokenCount,
+		TotalTokens:      total,
+	}
+}
+
+func toCompletionResponse(r *apiResponse, model string) *provider.CompletionResponse {
+	resp := &provider.CompletionResponse{
+		ID:    r.ResponseID,
+		Model: model,
+	}
+	if r.ModelVersion != "" {
+		resp.Model = r.ModelVersion
+	}
+	if r.UsageMetadata != nil {
+		resp.Usage = *toUsage(r.UsageMetadata)
+	}
+	for i, c := range r.Candidates {
+		resp.Choices = append(resp.Choices, provider.Choice{
+			Index:        i,
+			Content:      candidateText(c),
+			FinishReason: normalizeFinishReason(c.FinishReason),
+		})
+	}
+	if len(resp.Choices) > 0 {
+		resp.Content = resp.Choices[0].Content
+		resp.FinishReason = resp.Choices[0].FinishReason
+	}
+	if r.PromptFeedback != nil && r.PromptFeedback.BlockReason != "" {
+		resp.FinishReason = string(provider.ErrCodeContentFilter)
+		resp.ProviderMeta = map[string]interface{}{
+			"block_reason": r.PromptFeedback.BlockReason,
+		}
+	}
+	return resp
+}
+
+// classifyHTTPError maps HTTP status codes to normalized provider errors for
+// the Gemini API.
+func classifyHTTPError(statusCode int, header http.Header, body []byte) *provider.ProviderError {
+	var apiErr apiError
+	_ = json.Unmarshal(body, &apiErr)
+	msg := apiErr.Error.Message
+	if msg == "" {
+		msg = fmt.Sprintf("HTTP %d", statusCode)
+	}
+
+	pe := &provider.ProviderError{
+		Provider:   "gemini",
+		Message:    msg,
+		StatusCode: statusCode,
+		RetryAfter: provider.ParseRetryAfter(header),
+	}
+
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		pe.Code = provider.ErrCodeAuthentication
+	case statusCode == http.StatusTooManyRequests:
+		pe.Code = provider.ErrCodeRateLimit
+	case statusCode == http.StatusBadRequest:
+		lower := strings.ToLower(msg)
+		if strings.Contains(lower, "api key") {
+			pe.Code = provider.ErrCodeAuthentication
+		} else if strings.Contains(lower, "token") && strings.Contains(lower, "exceed") {
+			pe.Code = provider.ErrCodeContextLength
+		} else {
+			pe.Code = provider.ErrCodeInvalidRequest
+		}
+	case statusCode >= 500:
+		pe.Code = provider.ErrCodeProviderUnavailable
+	default:
+		pe.Code = provider.ErrCodeUnknown
+	}
+
+	return pe
+}
@@ -0,0 +1,162 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/config"
+	"github.com/sanix-darker/prev/internal/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestProvider(t *testing.T, baseURL string) provider.AIProvider {
+	t.Helper()
+	v := config.NewStore()
+	v.Set("api_key", "test-key")
+	v.Set("base_url", baseURL)
+	v.Set("model", "gemini-2.0-flash")
+	v.Set("max_tokens", 100)
+	v.Set("timeout", "10s")
+	p, err := NewProvider(v)
+	require.NoError(t, err)
+	return p
+}
+
+func TestGeminiComplete(t *testing.T) {
+	var got apiRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/models/gemini-2.0-flash:generateContent", r.URL.Path)
+		assert.Equal(t, "test-key", r.Header.Get("x-goog-api-key"))
+		body, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(body, &got))
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"responseId":   "resp-1",
+			"modelVersion": "gemini-2.0-flash-001",
+			"candidates": []map[string]interface{}{{
+				"content":      map[string]interface{}{"role": "model", "parts": []map[string]string{{"text": "Looks "}, {"text": "good"}}},
+				"finishReason": "STOP",
+			}},
+			"usageMetadata": map[string]int{"promptTokenCount": 12, "candidatesTokenCount": 3, "totalTokenCount": 15},
+		})
+	}))
+	defer server.Close()
+
+	p := newTestProvider(t, server.URL)
+	resp, err := p.Complete(context.Background(), provider.CompletionRequest{
+		Messages: []provider.Message{
+			{Role: provider.RoleSystem, Content: "You review code."},
+			{Role: provider.RoleUser, Content: "Review this."},
+			{Role: provider.RoleAssistant, Content: "Sure."},
+			{Role: provider.RoleUser, Content: "Thanks."},
+		},
+	})
+	require.NoError(t, err)
+
+	require.NotNil(t, got.SystemInstruction)
+	assert.Equal(t, "You review code.", got.SystemInstruction.Parts[0].Text)
+	require.Len(t, got.Contents, 3)
+	assert.Equal(t, "user", got.Contents[0].Role)
+	assert.Equal(t, "model", got.Contents[1].Role)
+	assert.Equal(t, "user", got.Contents[2].Role)
+	assert.Equal(t, 100, got.GenerationConfig.MaxOutputTokens)
+
+	assert.Equal(t, "Looks good", resp.Content)
+	assert.Equal(t, "stop", resp.FinishReason)
+	assert.Equal(t, "resp-1", resp.ID)
+	assert.Equal(t, "gemini-2.0-flash-001", resp.Model)
+	assert.Equal(t, provider.Usage{PromptTokens: 12, CompletionTokens: 3, TotalTokens: 15}, resp.Usage)
+}
+
+func TestGeminiComplete_SafetyMapsToContentFilter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"candidates": []map[string]interface{}{{"finishReason": "SAFETY"}},
+		})
+	}))
+	defer server.Close()
+
+	p := newTestProvider(t, server.URL)
+	resp, err := p.Complete(context.Background(), provider.CompletionRequest{
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "x"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, string(provider.ErrCodeContentFilter), resp.FinishReason)
+}
+
+func TestGeminiComplete_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"error":{"code":403,"message":"API key not valid","status":"PERMISSION_DENIED"}}`))
+	}))
+	defer server.Close()
+
+	p := newTestProvider(t, server.URL)
+	_, err := p.Complete(context.Background(), provider.CompletionRequest{
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "x"}},
+	})
+	require.Error(t, err)
+	var pe *provider.ProviderError
+	require.ErrorAs(t, err, &pe)
+	assert.Equal(t, provider.ErrCodeAuthentication, pe.Code)
+	assert.Equal(t, "API key not valid", pe.Message)
+}
+
+func TestGeminiComplete_Streaming(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/models/gemini-2.0-flash:streamGenerateContent", r.URL.Path)
+		assert.Equal(t, "sse", r.URL.Query().Get("alt"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		events := []string{
+			`{"candidates":[{"content":{"role":"model","parts":[{"text":"Hel"}]}}]}`,
+			`{"candidates":[{"content":{"role":"model","parts":[{"text":"lo"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":4,"candidatesTokenCount":2,"totalTokenCount":6}}`,
+		}
+		for _, e := range events {
+			fmt.Fprintf(w, "data: %s\n\n", e)
+		}
+	}))
+	defer server.Close()
+
+	p := newTestProvider(t, server.URL)
+	result := p.CompleteStream(context.Background(), provider.CompletionRequest{
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	})
+
+	var content string
+	var last provider.StreamChunk
+	for chunk := range result.Chunks {
+		content += chunk.Content
+		last = chunk
+	}
+	require.NoError(t, <-result.Err)
+	assert.Equal(t, "Hello", content)
+	assert.True(t, last.Done)
+	assert.Equal(t, "stop", last.FinishReason)
+	require.NotNil(t, last.Usage)
+	assert.Equal(t, 6, last.Usage.TotalTokens)
+}
+
+func TestGeminiNewProvider_OpenAIBaseURLFallsBackToCompat(t *testing.T) {
+	v := config.NewStore()
+	v.Set("api_key", "test-key")
+	v.Set("base_url", "https://generativelanguage.googleapis.com/v1beta/openai")
+
+	p, err := NewProvider(v)
+	require.NoError(t, err)
+	_, native := p.(*Provider)
+	assert.False(t, native)
+	assert.Contains(t, p.Info().DisplayName, "OpenAI-compatible")
+}
+
+func TestGeminiValidate_NoAPIKey(t *testing.T) {
+	v := config.NewStore()
+	p, err := NewProvider(v)
+	require.NoError(t, err)
+	assert.Error(t, p.Validate(context.Background()))
+}
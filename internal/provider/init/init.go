@@ -3,7 +3,7 @@
 //
 //	import _ "github.com/sanix-darker/prev/internal/provider/init"
 //
-// This registers all built-in providers (openai, anthropic, azure, and the
+// This registers all built-in providers (openai, anthropic, azure, gemini, mistral, and the
 // OpenAI-compatible adapters) with the global provider.Registry.
 package init
 
@@ -11,5 +11,7 @@ import (
 	_ "github.com/sanix-darker/prev/internal/provider/anthropic"
 	_ "github.com/sanix-darker/prev/internal/provider/azure"
 	_ "github.com/sanix-darker/prev/internal/provider/compat"
+	_ "github.com/sanix-darker/prev/internal/provider/gemini"
+	_ "github.com/sanix-darker/prev/internal/provider/mistral"
 	_ "github.com/sanix-darker/prev/internal/provider/openai"
 )
@@ -0,0 +1,493 @@
+// Package mistral implements the AIProvider interface for Mistral's native
+// Chat Completions API (api.mistral.ai).
+//
+// The wire format is close to OpenAI's, with two Mistral-specific additions:
+//   - "safe_prompt" prepends Mistral's guardrail system prompt.
+//   - A trailing assistant message may carry "prefix": true, which makes the
+//     model continue from that text. prev uses it to force findings to start
+//     in an exact format; the configured "prefix" is appended as such a
+//     message when the conversation ends on a user turn.
+//
+// Rate-limit state is reported through "x-ratelimit-*" response headers; an
+// exhausted budget is classified as ErrCodeRateLimit so the shared retry
+// helper backs off.
+package mistral
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sanix-darker/prev/internal/config"
+	"github.com/sanix-darker/prev/internal/provider"
+)
+
+// ---------------------------------------------------------------------------
+// Registration
+// ---------------------------------------------------------------------------
+
+func init() {
+	provider.Register("mistral", NewProvider)
+}
+
+// ---------------------------------------------------------------------------
+// Mistral-specific API types (request)
+// ---------------------------------------------------------------------------
+
+type apiMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	Prefix  bool   `json:"prefix,omitempty"`
+}
+
+type apiResponseFormat struct {
+	Type string `json:"type"`
+}
+
+type apiRequest struct {
+	Model          string             `json:"model"`
+	Messages       []apiMessage       `json:"messages"`
+	MaxTokens      int                `json:"max_tokens,omitempty"`
+	Temperature    *float64           `json:"temperature,omitempty"`
+	TopP           *float64           `json:"top_p,omitempty"`
+	Stream         bool               `json:"stream,omitempty"`
+	Stop           []string           `json:"stop,omitempty"`
+	SafePrompt     bool               `json:"safe_prompt,omitempty"`
+	ResponseFormat *apiResponseFormat `json:"response_format,omitempty"`
+}
+
+// ---------------------------------------------------------------------------
+// Mistral-specific API types (response)
+// ---------------------------------------------------------------------------
+
+type apiChoice struct {
+	Index        int        `json:"index"`
+	Message      apiMessage `json:"message"`
+	Delta        apiMessage `json:"delta"` // used in streaming
+	FinishReason string     `json:"finish_reason"`
+}
+
+type apiUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+type apiResponse struct {
+	ID      string      `json:"id"`
+	Model   string      `json:"model"`
+	Choices []apiChoice `json:"choices"`
+	Usage   apiUsage    `json:"usage"`
+}
+
+// apiError covers both error shapes Mistral returns: a top-level "message"
+// and an OpenAI-style nested "error" object.
+type apiError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Error   struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error"`
+}
+
+// ---------------------------------------------------------------------------
+// Provider implementation
+// ---------------------------------------------------------------------------
+
+const (
+	defaultBaseURL = "https://api.mistral.ai/v1"
+	defaultModel   = "mistral-large-latest"
+)
+
+// Provider implements provider.AIProvider for the Mistral API.
+type Provider struct {
+	client      *http.Client
+	apiKey      string
+	baseURL     string
+	model       string
+	aliases     map[string]string
+	maxTok      int
+	safePrompt  bool
+	prefix      string
+	retryCfg    provider.RetryConfig
+	clampNotice provider.ClampNotice
+}
+
+// NewProvider is the factory function registered with the provider registry.
+func NewProvider(v *config.Store) (provider.AIProvider, error) {
+	baseURL := strings.TrimRight(v.GetString("base_url"), "/")
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	model := v.GetString("model")
+	if model == "" {
+		model = defaultModel
+	}
+	aliases := provider.ModelAliases("mistral", v)
+	model = provider.ResolveModelAlias(aliases, model)
+	maxTok := v.GetInt("max_tokens")
+	if maxTok == 0 {
+		maxTok = 1024
+	}
+	timeout := v.GetDuration("timeout")
+	if timeout == 0 {
+		timeout = 60 * time.Second
+	}
+
+	return &Provider{
+		client:     &http.Client{Timeout: timeout},
+		apiKey:     v.GetString("api_key"),
+		baseURL:    baseURL,
+		model:      model,
+		aliases:    aliases,
+		maxTok:     maxTok,
+		safePrompt: v.GetBool("safe_prompt"),
+		prefix:     v.GetString("prefix"),
+		retryCfg:   provider.DefaultRetryConfig(),
+	}, nil
+}
+
+// Info returns provider metadata.
+func (p *Provider) Info() provider.ProviderInfo {
+	return provider.ProviderInfo{
+		Name:              "mistral",
+		DisplayName:       "Mistral AI",
+		Description:       "Mistral Chat Completions API (with safe_prompt and prefix support)",
+		DefaultModel:      defaultModel,
+		SupportsStreaming: true,
+		SupportsJSONMode:  true,
+	}
+}
+
+// Validate checks that the API key is present.
+func (p *Provider) Validate(ctx context.Context) error {
+	if p.apiKey == "" {
+		return &provider.ProviderError{
+			Code:     provider.ErrCodeAuthentication,
+			Message:  "MISTRAL_API_KEY is not set",
+			Provider: "mistral",
+		}
+	}
+	return nil
+}
+
+// Complete performs a synchronous (non-streaming) chat completion.
+func (p *Provider) Complete(ctx context.Context, req provider.CompletionRequest) (*provider.CompletionResponse, error) {
+	return provider.WithRetry(ctx, p.retryCfg, func() (*provider.CompletionResponse, error) {
+		return p.doComplete(ctx, req)
+	})
+}
+
+func (p *Provider) doComplete(ctx context.Context, req provider.CompletionRequest) (*provider.CompletionResponse, error) {
+	bodyBytes, err := json.Marshal(p.buildRequest(req, false))
+	if err != nil {
+		return nil, &provider.ProviderError{
+			Code: provider.ErrCodeUnknown, Message: "failed to marshal request",
+			Provider: "mistral", Cause: err,
+		}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		p.baseURL+"/chat/completions", bytes.NewReader(bodyBytes))
+	if err != nil {
+		return nil, &provider.ProviderError{
+			Code: provider.ErrCodeUnknown, Message: "failed to build request",
+			Provider: "mistral", Cause: err,
+		}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, &provider.ProviderError{
+			Code: provider.ErrCodeProviderUnavailable, Message: "HTTP request failed",
+			Provider: "mistral", Cause: err,
+		}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &provider.ProviderError{
+			Code: provider.ErrCodeUnknown, Message: "failed to read response",
+			Provider: "mistral", Cause: err,
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, classifyHTTPError(resp.StatusCode, resp.Header, respBody)
+	}
+
+	var apiResp apiResponse
+	if err := json.Unmarshal(respBody, &apiResp); err != nil {
+		return nil, &provider.ProviderError{
+			Code: provider.ErrCodeUnknown, Message: "failed to decode response",
+			Provider: "mistral", Cause: err,
+		}
+	}
+
+	return toCompletionResponse(&apiResp), nil
+}
+
+// CompleteStream performs a streaming chat completion using server-sent events.
+func (p *Provider) CompleteStream(ctx context.Context, req provider.CompletionRequest) provider.StreamResult {
+	chunks := make(chan provider.StreamChunk, 64)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(chunks)
+		defer close(errCh)
+
+		bodyBytes, err := json.Marshal(p.buildRequest(req, true))
+		if err != nil {
+			errCh <- &provider.ProviderError{
+				Code: provider.ErrCodeUnknown, Message: "failed to marshal request",
+				Provider: "mistral", Cause: err,
+			}
+			return
+		}
+
+		httpReq, err := http.NewRequestWithContext(
+			ctx, http.MethodPost,
+			p.baseURL+"/chat/completions",
+			bytes.NewReader(bodyBytes),
+		)
+		if err != nil {
+			errCh <- &provider.ProviderError{
+				Code: provider.ErrCodeUnknown, Message: "failed to build request",
+				Provider: "mistral", Cause: err,
+			}
+			return
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+		httpReq.Header.Set("Accept", "text/event-stream")
+
+		httpResp, err := p.client.Do(httpReq)
+		if err != nil {
+			errCh <- &provider.ProviderError{
+				Code: provider.ErrCodeProviderUnavailable, Message: "stream request failed",
+				Provider: "mistral", Cause: err,
+			}
+			return
+		}
+		defer httpResp.Body.Close()
+
+		if httpResp.StatusCode != http.StatusOK {
+			var buf [4096]byte
+			n, _ := httpResp.Body.Read(buf[:])
+			errCh <- classifyHTTPError(httpResp.StatusCode, httpResp.Header, buf[:n])
+			return
+		}
+
+		scanner := provider.NewSSEScanner(httpResp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+			data := strings.TrimPrefix(line, "data: ")
+			if data == "[DONE]" {
+				if !provider.SendStreamChunk(ctx, chunks, provider.StreamChunk{Done: true}) {
+					errCh <- ctx.Err()
+				}
+				return
+			}
+
+			var chunk apiResponse
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				continue // skip malformed chunks
+			}
+			if len(chunk.Choices) == 0 {
+				continue
+			}
+
+			sc := provider.StreamChunk{
+				Content:      chunk.Choices[0].Delta.Content,
+				FinishReason: normalizeFinishReason(chunk.Choices[0].FinishReason),
+			}
+			if sc.FinishReason != "" && chunk.Usage.TotalTokens > 0 {
+				sc.Usage = &provider.Usage{
+					PromptTokens:     chunk.Usage.PromptTokens,
+					CompletionTokens: chunk.Usage.CompletionTokens,
+					TotalTokens:      chunk.Usage.TotalTokens,
+				}
+			}
+
+			if !provider.SendStreamChunk(ctx, chunks, sc) {
+				errCh <- ctx.Err()
+				return
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			errCh <- &provider.ProviderError{
+				Code: provider.ErrCodeUnknown, Message: "stream read error",
+				Provider: "mistral", Cause: err,
+			}
+		}
+	}()
+
+	return provider.StreamResult{Chunks: chunks, Err: errCh}
+}
+
+// ---------------------------------------------------------------------------
+// Helpers
+// ---------------------------------------------------------------------------
+
+// buildRequest converts the provider-agnostic CompletionRequest into
+// Mistral's format. A trailing assistant message is sent with prefix: true
+// (Mistral rejects a final assistant turn otherwise); when the conversation
+// ends on a user turn, the configured prefix is appended as one.
+func (p *Provider) buildRequest(req provider.CompletionRequest, stream bool) apiRequest {
+	model := provider.ResolveModelAlias(p.aliases, req.Model)
+	if model == "" {
+		model = p.model
+	}
+	maxTok := req.MaxTokens
+	if maxTok == 0 {
+		maxTok = p.maxTok
+	}
+	if clamped, ok := provider.ClampResponseTokens(model, maxTok, req.Messages); ok {
+		p.clampNotice.Warn(model, maxTok, clamped)
+		maxTok = clamped
+	}
+
+	msgs := make([]apiMessage, 0, len(req.Messages)+1)
+	for _, m := range req.Messages {
+		msgs = append(msgs, apiMessage{Role: string(m.Role), Content: m.Content})
+	}
+	if n := len(msgs); n > 0 && msgs[n-1].Role == string(provider.RoleAssistant) {
+		msgs[n-1].Prefix = true
+	} else if p.prefix != "" {
+		msgs = append(msgs, apiMessage{Role: string(provider.RoleAssistant), Content: p.prefix, Prefix: true})
+	}
+
+	out := apiRequest{
+		Model:       model,
+		Messages:    msgs,
+		MaxTokens:   maxTok,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		Stream:      stream,
+		Stop:        req.StopSequences,
+		SafePrompt:  p.safePrompt,
+	}
+	if req.JSONMode {
+		out.ResponseFormat = &apiResponseFormat{Type: "json_object"}
+	}
+	return out
+}
+
+// normalizeFinishReason maps Mistral finish reasons onto the values used by
+// the other providers; moderation stops surface as "content_filter".
+func normalizeFinishReason(reason string) string {
+	reason = strings.ToLower(strings.TrimSpace(reason))
+	switch reason {
+	case "model_length":
+		return "length"
+	case "content_filter", "moderation":
+		return string(provider.ErrCodeContentFilter)
+	default:
+		return reason
+	}
+}
+
+func toCompletionResponse(r *apiResponse) *provider.CompletionResponse {
+	resp := &provider.CompletionResponse{
+		ID:    r.ID,
+		Model: r.Model,
+		Usage: provider.Usage{
+			PromptTokens:     r.Usage.PromptTokens,
+			CompletionTokens: r.Usage.CompletionTokens,
+			TotalTokens:      r.Usage.TotalTokens,
+		},
+	}
+	for _, c := range r.Choices {
+		resp.Choices = append(resp.Choices, provider.Choice{
+			Index:        c.Index,
+			Content:      c.Message.Content,
+			FinishReason: normalizeFinishReason(c.FinishReason),
+		})
+	}
+	if len(resp.Choices) > 0 {
+		resp.Content = resp.Choices[0].Content
+		resp.FinishReason = resp.Choices[0].FinishReason
+	}
+	return resp
+}
+
+// exhaustedRateLimits returns the "x-ratelimit-*remaining*" headers whose
+// value is 0, formatted as "name=0" and sorted.
+func exhaustedRateLimits(h http.Header) []string {
+	var out []string
+	for name, values := range h {
+		lower := strings.ToLower(name)
+		if !strings.HasPrefix(lower, "x-ratelimit") || !strings.Contains(lower, "remaining") {
+			continue
+		}
+		for _, v := range values {
+			if strings.TrimSpace(v) == "0" {
+				out = append(out, lower+"=0")
+				break
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// classifyHTTPError maps HTTP status codes and rate-limit headers to
+// normalized provider errors for the Mistral API.
+func classifyHTTPError(statusCode int, header http.Header, body []byte) *provider.ProviderError {
+	var apiErr apiError
+	_ = json.Unmarshal(body, &apiErr)
+	msg := apiErr.Message
+	if msg == "" {
+		msg = apiErr.Error.Message
+	}
+	if msg == "" {
+		msg = fmt.Sprintf("HTTP %d", statusCode)
+	}
+
+	pe := &provider.ProviderError{
+		Provider:   "mistral",
+		Message:    msg,
+		StatusCode: statusCode,
+		RetryAfter: provider.ParseRetryAfter(header),
+	}
+
+	exhausted := exhaustedRateLimits(header)
+	switch {
+	case statusCode == http.StatusTooManyRequests || len(exhausted) > 0:
+		pe.Code = provider.ErrCodeRateLimit
+		if len(exhausted) > 0 {
+			pe.Message = fmt.Sprintf("%s (%s)", msg, strings.Join(exhausted, ", "))
+		}
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		pe.Code = provider.ErrCodeAuthentication
+	case statusCode == http.StatusBadRequest || statusCode == http.StatusUnprocessableEntity:
+		lower := strings.ToLower(msg)
+		if strings.Contains(lower, "too large") || (strings.Contains(lower, "context") && strings.Contains(lower, "length")) {
+			pe.Code = provider.ErrCodeContextLength
+		} else {
+			pe.Code = provider.ErrCodeInvalidRequest
+		}
+	case statusCode == http.StatusRequestTimeout || statusCode == http.StatusGatewayTimeout:
+		pe.Code = provider.ErrCodeTimeout
+	case statusCode >= 500:
+		pe.Code = provider.ErrCodeProviderUnavailable
+	default:
+		pe.Code = provider.ErrCodeUnknown
+	}
+
+	return pe
+}
@@ -0,0 +1,156 @@
+package mistral
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/config"
+	"github.com/sanix-darker/prev/internal/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestProvider(t *testing.T, baseURL string, extra map[string]interface{}) provider.AIProvider {
+	t.Helper()
+	v := config.NewStore()
+	v.Set("api_key", "test-key")
+	v.Set("base_url", baseURL)
+	v.Set("model", "mistral-small-latest")
+	v.Set("max_tokens", 100)
+	v.Set("timeout", "10s")
+	for k, val := range extra {
+		v.Set(k, val)
+	}
+	p, err := NewProvider(v)
+	require.NoError(t, err)
+	return p
+}
+
+func TestMistralComplete_SendsSafePromptAndConfiguredPrefix(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		body, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(body, &got))
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":    "cmpl-1",
+			"model": "mistral-small-2409",
+			"choices": []map[string]interface{}{{
+				"index":         0,
+				"message":       map[string]string{"role": "assistant", "content": "**File: a.go** (line 3) [ISSUE] [HIGH]: nil deref"},
+				"finish_reason": "stop",
+			}},
+			"usage": map[string]int{"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15},
+		})
+	}))
+	defer server.Close()
+
+	p := newTestProvider(t, server.URL, map[string]interface{}{"safe_prompt": true, "prefix": "**File:"})
+	resp, err := p.Complete(context.Background(), provider.CompletionRequest{
+		Messages: []provider.Message{
+			{Role: provider.RoleSystem, Content: "You review code."},
+			{Role: provider.RoleUser, Content: "Review this."},
+		},
+		JSONMode: true,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, true, got["safe_prompt"])
+	assert.Equal(t, "mistral-small-latest", got["model"])
+	assert.Equal(t, map[string]interface{}{"type": "json_object"}, got["response_format"])
+	msgs := got["messages"].([]interface{})
+	require.Len(t, msgs, 3)
+	assert.Equal(t, map[string]interface{}{"role": "assistant", "content": "**File:", "prefix": true}, msgs[2])
+	assert.NotContains(t, msgs[1], "prefix")
+
+	assert.Equal(t, "cmpl-1", resp.ID)
+	assert.Equal(t, "stop", resp.FinishReason)
+	assert.Contains(t, resp.Content, "nil deref")
+	assert.Equal(t, provider.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}, resp.Usage)
+}
+
+func TestMistralBuildRequest_TrailingAssistantBecomesPrefix(t *testing.T) {
+	p := newTestProvider(t, "http://unused", map[string]interface{}{"prefix": "ignored"}).(*Provider)
+	req := p.buildRequest(provider.CompletionRequest{Messages: []provider.Message{
+		{Role: provider.RoleUser, Content: "Review this."},
+		{Role: provider.RoleAssistant, Content: "## Summary"},
+	}}, false)
+
+	require.Len(t, req.Messages, 2)
+	assert.False(t, req.Messages[0].Prefix)
+	assert.True(t, req.Messages[1].Prefix)
+	assert.Equal(t, "## Summary", req.Messages[1].Content)
+	assert.Equal(t, 100, req.MaxTokens)
+}
+
+func TestMistralCompleteStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body apiRequest
+		raw, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(raw, &body))
+		assert.True(t, body.Stream)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Looks \"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"good\"},\"finish_reason\":\"model_length\"}],\"usage\":{\"prompt_tokens\":4,\"completion_tokens\":2,\"total_tokens\":6}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	p := newTestProvider(t, server.URL, nil)
+	result := p.CompleteStream(context.Background(), provider.CompletionRequest{
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+	})
+	var content, finish string
+	var usage *provider.Usage
+	for chunk := range result.Chunks {
+		content += chunk.Content
+		if chunk.FinishReason != "" {
+			finish = chunk.FinishReason
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+	}
+	require.NoError(t, <-result.Err)
+	assert.Equal(t, "Looks good", content)
+	assert.Equal(t, "length", finish)
+	require.NotNil(t, usage)
+	assert.Equal(t, 6, usage.TotalTokens)
+}
+
+func TestClassifyHTTPError_RateLimitHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("X-Ratelimit-Remaining-Tokens-Minute", "0")
+	h.Set("X-Ratelimit-Remaining-Req-Minute", "12")
+
+	pe := classifyHTTPError(http.StatusBadRequest, h, []byte(`{"message":"Requests rate limit exceeded"}`))
+	assert.Equal(t, provider.ErrCodeRateLimit, pe.Code)
+	assert.Equal(t, "Requests rate limit exceeded (x-ratelimit-remaining-tokens-minute=0)", pe.Message)
+
+	pe = classifyHTTPError(http.StatusTooManyRequests, http.Header{}, nil)
+	assert.Equal(t, provider.ErrCodeRateLimit, pe.Code)
+
+	pe = classifyHTTPError(http.StatusUnauthorized, http.Header{}, []byte(`{"error":{"message":"bad key"}}`))
+	assert.Equal(t, provider.ErrCodeAuthentication, pe.Code)
+	assert.Equal(t, "bad key", pe.Message)
+}
+
+func TestMistralValidate_MissingKey(t *testing.T) {
+	v := config.NewStore()
+	p, err := NewProvider(v)
+	require.NoError(t, err)
+	err = p.Validate(context.Background())
+	var pe *provider.ProviderError
+	require.True(t, errors.As(err, &pe))
+	assert.Equal(t, provider.ErrCodeAuthentication, pe.Code)
+	assert.Equal(t, defaultModel, p.Info().DefaultModel)
+}
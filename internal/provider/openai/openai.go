@@ -1,6 +1,7 @@
 // Package openai implements the AIProvider interface for the OpenAI Chat
 // Completions API (and any OpenAI-compatible endpoint such as Azure, Ollama,
-// LM Studio, etc.).
+// LM Studio, etc.). Setting "api: responses" switches to the Responses API,
+// which reasoning models need to report their reasoning tokens.
 //
 // It uses go-resty/v2 for HTTP transport and supports both blocking and
 // streaming completions with SSE (server-sent events).
@@ -33,8 +34,18 @@ func init() {
 // ---------------------------------------------------------------------------
 
 type apiMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role      string        `json:"role"`
+	Content   string        `json:"content"`
+	ToolCalls []apiToolCall `json:"tool_calls,omitempty"`
+}
+
+type apiToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
 }
 
 type apiRequest struct {
@@ -46,6 +57,13 @@ type apiRequest struct {
 	TopP                *float64     `json:"top_p,omitempty"`
 	Stream              bool         `json:"stream,omitempty"`
 	Stop                []string     `json:"stop,omitempty"`
+	N                   int          `json:"n,omitempty"`
+
+	ResponseFormat *apiResponseFormat `json:"response_format,omitempty"`
+}
+
+type apiResponseFormat struct {
+	Type string `json:"type"`
 }
 
 // ---------------------------------------------------------------------------
@@ -88,12 +106,18 @@ type apiError struct {
 
 // Provider implements provider.AIProvider for OpenAI's Chat Completions API.
 type Provider struct {
-	client   *http.Client
-	apiKey   string
-	baseURL  string
-	model    string
-	maxTok   int
-	retryCfg provider.RetryConfig
+	client      *http.Client
+	apiKey      string
+	baseURL     string
+	model       string
+	aliases     map[string]string
+	maxTok      int
+	retryCfg    provider.RetryConfig
+	clampNotice provider.ClampNotice
+
+	// api selects the endpoint: apiChat (default) or apiResponses.
+	api             string
+	reasoningEffort string
 }
 
 // NewProvider is the factory function registered with the provider registry.
@@ -108,6 +132,8 @@ func NewProvider(v *config.Store) (provider.AIProvider, error) {
 	if model == "" {
 		model = "gpt-4o"
 	}
+	aliases := provider.ModelAliases("openai", v)
+	model = provider.ResolveModelAlias(aliases, model)
 	maxTok := v.GetInt("max_tokens")
 	if maxTok == 0 {
 		maxTok = 1024
@@ -116,25 +142,43 @@ func NewProvider(v *config.Store) (provider.AIProvider, error) {
 	if timeout == 0 {
 		timeout = 30 * time.Second
 	}
+	api := strings.ToLower(strings.TrimSpace(v.GetString("api")))
+	switch api {
+	case "", apiChat:
+		api = apiChat
+	case apiResponses:
+	default:
+		return nil, &provider.ProviderError{
+			Code:     provider.ErrCodeInvalidRequest,
+			Message:  fmt.Sprintf("unknown api %q (want %q or %q)", api, apiChat, apiResponses),
+			Provider: "openai",
+		}
+	}
 
 	return &Provider{
 		client:   &http.Client{Timeout: timeout},
 		apiKey:   apiKey,
 		baseURL:  strings.TrimRight(baseURL, "/"),
 		model:    model,
+		aliases:  aliases,
 		maxTok:   maxTok,
 		retryCfg: provider.DefaultRetryConfig(),
+
+		api:             api,
+		reasoningEffort: strings.ToLower(strings.TrimSpace(v.GetString("reasoning_effort"))),
 	}, nil
 }
 
 // Info returns provider metadata.
 func (p *Provider) Info() provider.ProviderInfo {
 	return provider.ProviderInfo{
-		Name:              "openai",
-		DisplayName:       "OpenAI",
-		Description:       "OpenAI Chat Completions API (GPT-4o, GPT-4, GPT-3.5-turbo, etc.)",
-		DefaultModel:      "gpt-4o",
-		SupportsStreaming: true,
+		Name:                    "openai",
+		DisplayName:             "OpenAI",
+		Description:             "OpenAI Chat Completions API (GPT-4o, GPT-4, GPT-3.5-turbo, etc.)",
+		DefaultModel:            "gpt-4o",
+		SupportsStreaming:       true,
+		SupportsJSONMode:        true,
+		SupportsMultipleChoices: p.api != apiResponses,
 	}
 }
 
@@ -190,7 +234,10 @@ func (p *Provider) Complete(ctx context.Context, req provider.CompletionRequest)
 }
 
 func (p *Provider) doComplete(ctx context.Context, req provider.CompletionRequest) (*provider.CompletionResponse, error) {
-	model := req.Model
+	if p.api == apiResponses {
+		return p.doResponsesComplete(ctx, req)
+	}
+	model := provider.ResolveModelAlias(p.aliases, req.Model)
 	if model == "" {
 		model = p.model
 	}
@@ -198,6 +245,10 @@ func (p *Provider) doComplete(ctx context.Context, req provider.CompletionReques
 	if maxTok == 0 {
 		maxTok = p.maxTok
 	}
+	if clamped, ok := provider.ClampResponseTokens(model, maxTok, req.Messages); ok {
+		p.clampNotice.Warn(model, maxTok, clamped)
+		maxTok = clamped
+	}
 
 	body := apiRequest{
 		Model:       model,
@@ -207,7 +258,11 @@ func (p *Provider) doComplete(ctx context.Context, req provider.CompletionReques
 		Stream:      false,
 		Stop:        req.StopSequences,
 	}
+	if req.N > 1 {
+		body.N = req.N
+	}
 	applyTokenParam(&body, model, maxTok)
+	applyJSONMode(&body, req.JSONMode)
 
 	bodyBytes, err := json.Marshal(body)
 	if err != nil {
@@ -248,7 +303,7 @@ func (p *Provider) doComplete(ctx context.Context, req provider.CompletionReques
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, classifyHTTPError("openai", resp.StatusCode, respBody)
+		return nil, classifyHTTPError("openai", resp.StatusCode, resp.Header, respBody)
 	}
 
 	var apiResp apiResponse
@@ -273,7 +328,12 @@ func (p *Provider) CompleteStream(ctx context.Context, req provider.CompletionRe
 		defer close(chunks)
 		defer close(errCh)
 
-		model := req.Model
+		if p.api == apiResponses {
+			p.streamResponses(ctx, req, chunks, errCh)
+			return
+		}
+
+		model := provider.ResolveModelAlias(p.aliases, req.Model)
 		if model == "" {
 			model = p.model
 		}
@@ -281,6 +341,10 @@ func (p *Provider) CompleteStream(ctx context.Context, req provider.CompletionRe
 		if maxTok == 0 {
 			maxTok = p.maxTok
 		}
+		if clamped, ok := provider.ClampResponseTokens(model, maxTok, req.Messages); ok {
+			p.clampNotice.Warn(model, maxTok, clamped)
+			maxTok = clamped
+		}
 
 		body := apiRequest{
 			Model:       model,
@@ -291,6 +355,7 @@ func (p *Provider) CompleteStream(ctx context.Context, req provider.CompletionRe
 			Stop:        req.StopSequences,
 		}
 		applyTokenParam(&body, model, maxTok)
+		applyJSONMode(&body, req.JSONMode)
 
 		bodyBytes, err := json.Marshal(body)
 		if err != nil {
@@ -331,7 +396,7 @@ func (p *Provider) CompleteStream(ctx context.Context, req provider.CompletionRe
 		if httpResp.StatusCode != http.StatusOK {
 			var buf [4096]byte
 			n, _ := httpResp.Body.Read(buf[:])
-			errCh <- classifyHTTPError("openai", httpResp.StatusCode, buf[:n])
+			errCh <- classifyHTTPError("openai", httpResp.StatusCode, httpResp.Header, buf[:n])
 			return
 		}
 
@@ -426,12 +491,19 @@ func toCompletionResponse(r *apiResponse) *provider.CompletionResponse {
 	if len(resp.Choices) > 0 {
 		resp.Content = resp.Choices[0].Content
 		resp.FinishReason = resp.Choices[0].FinishReason
+		for _, tc := range r.Choices[0].Message.ToolCalls {
+			resp.ToolCalls = append(resp.ToolCalls, provider.ToolCall{
+				ID:        tc.ID,
+				Name:      tc.Function.Name,
+				Arguments: tc.Function.Arguments,
+			})
+		}
 	}
 	return resp
 }
 
 // classifyHTTPError maps HTTP status codes to normalized provider errors.
-func classifyHTTPError(providerName string, statusCode int, body []byte) *provider.ProviderError {
+func classifyHTTPError(providerName string, statusCode int, header http.Header, body []byte) *provider.ProviderError {
 	// Try to parse the OpenAI error body.
 	var apiErr apiError
 	_ = json.Unmarshal(body, &apiErr)
@@ -444,6 +516,7 @@ func classifyHTTPError(providerName string, statusCode int, body []byte) *provid
 		Provider:   providerName,
 		Message:    msg,
 		StatusCode: statusCode,
+		RetryAfter: provider.ParseRetryAfter(header),
 	}
 
 	switch {
@@ -483,6 +556,13 @@ func applyTokenParam(body *apiRequest, model string, maxTok int) {
 	body.MaxCompletionTokens = 0
 }
 
+func applyJSONMode(body *apiRequest, enabled bool) {
+	if body == nil || !enabled {
+		return
+	}
+	body.ResponseFormat = &apiResponseFormat{Type: "json_object"}
+}
+
 func usesMaxCompletionTokens(model string) bool {
 	m := strings.ToLower(strings.TrimSpace(model))
 	return strings.HasPrefix(m, "gpt-5")
@@ -116,6 +116,73 @@ func TestOpenAIComplete_EmptyAPIKey(t *testing.T) {
 	assert.ErrorIs(t, err, provider.ErrAuthentication)
 }
 
+func TestOpenAIComplete_JSONModeSetsResponseFormat(t *testing.T) {
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		bodies = append(bodies, body)
+		json.NewEncoder(w).Encode(apiResponse{
+			Choices: []apiChoice{{Message: apiMessage{Role: "assistant", Content: "{}"}, FinishReason: "stop"}},
+		})
+	}))
+	defer server.Close()
+
+	v := config.NewStore()
+	v.Set("api_key", "test-key")
+	v.Set("base_url", server.URL)
+	p, err := NewProvider(v)
+	require.NoError(t, err)
+	assert.True(t, p.Info().SupportsJSONMode)
+
+	msgs := []provider.Message{{Role: provider.RoleUser, Content: "Return JSON"}}
+	_, err = p.Complete(context.Background(), provider.CompletionRequest{Messages: msgs, JSONMode: true})
+	require.NoError(t, err)
+	_, err = p.Complete(context.Background(), provider.CompletionRequest{Messages: msgs})
+	require.NoError(t, err)
+
+	require.Len(t, bodies, 2)
+	assert.Equal(t, map[string]interface{}{"type": "json_object"}, bodies[0]["response_format"])
+	_, ok := bodies[1]["response_format"]
+	assert.False(t, ok)
+}
+
+func TestOpenAIComplete_ForwardsN(t *testing.T) {
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		bodies = append(bodies, body)
+		json.NewEncoder(w).Encode(apiResponse{
+			Choices: []apiChoice{
+				{Index: 0, Message: apiMessage{Role: "assistant", Content: "one"}, FinishReason: "stop"},
+				{Index: 1, Message: apiMessage{Role: "assistant", Content: "two"}, FinishReason: "stop"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	v := config.NewStore()
+	v.Set("api_key", "test-key")
+	v.Set("base_url", server.URL)
+	p, err := NewProvider(v)
+	require.NoError(t, err)
+	assert.True(t, p.Info().SupportsMultipleChoices)
+
+	msgs := []provider.Message{{Role: provider.RoleUser, Content: "Review"}}
+	resp, err := provider.CompleteChoices(context.Background(), p, provider.CompletionRequest{Messages: msgs, N: 2})
+	require.NoError(t, err)
+	require.Len(t, bodies, 1)
+	assert.Equal(t, float64(2), bodies[0]["n"])
+	require.Len(t, resp.Choices, 2)
+	assert.Equal(t, "two", resp.Choices[1].Content)
+
+	_, err = p.Complete(context.Background(), provider.CompletionRequest{Messages: msgs})
+	require.NoError(t, err)
+	_, ok := bodies[1]["n"]
+	assert.False(t, ok)
+}
+
 func TestOpenAIInfo(t *testing.T) {
 	v := config.NewStore()
 	v.Set("api_key", "test")
@@ -165,6 +232,36 @@ func TestOpenAIComplete_GPT5UsesMaxCompletionTokens(t *testing.T) {
 	assert.EqualValues(t, 123, got["max_completion_tokens"])
 }
 
+func TestOpenAIComplete_ClampsMaxTokensToContextWindow(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(b, &got)
+		resp := apiResponse{
+			ID: "chatcmpl-test", Model: "gpt-4",
+			Choices: []apiChoice{{Index: 0, Message: apiMessage{Role: "assistant", Content: "ok"}, FinishReason: "stop"}},
+		}
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	v := config.NewStore()
+	v.Set("api_key", "test-key")
+	v.Set("base_url", server.URL)
+	v.Set("model", "gpt-4")
+	v.Set("max_tokens", 16000)
+
+	p, err := NewProvider(v)
+	require.NoError(t, err)
+	_, err = p.Complete(context.Background(), provider.CompletionRequest{
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: strings.Repeat("x", 4000)}},
+	})
+	require.NoError(t, err)
+
+	assert.EqualValues(t, 8192-1000, got["max_tokens"])
+}
+
 func TestOpenAICompleteStream_UsesProviderClient(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/chat/completions" {
@@ -214,3 +311,187 @@ func TestOpenAICompleteStream_UsesProviderClient(t *testing.T) {
 	assert.GreaterOrEqual(t, calls.Load(), int32(1))
 	assert.Equal(t, "hello", content.String())
 }
+
+func TestOpenAIComplete_ToolCallsWithoutContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"id":"chatcmpl-tools","choices":[{"index":0,"finish_reason":"tool_calls",
+			"message":{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function",
+			"function":{"name":"report_review","arguments":"{\"summary\":\"ok\",\"findings\":[]}"}}]}}]}`)
+	}))
+	defer server.Close()
+
+	v := config.NewStore()
+	v.Set("api_key", "test-key")
+	v.Set("base_url", server.URL)
+	p, err := NewProvider(v)
+	require.NoError(t, err)
+
+	resp, err := p.Complete(context.Background(), provider.CompletionRequest{
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Review"}},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Content)
+	require.Len(t, resp.ToolCalls, 1)
+	assert.Equal(t, provider.ToolCall{ID: "call_1", Name: "report_review", Arguments: `{"summary":"ok","findings":[]}`}, resp.ToolCalls[0])
+	assert.Equal(t, `{"summary":"ok","findings":[]}`, resp.BestText())
+}
+
+func TestOpenAIComplete_ResolvesConfiguredModelAlias(t *testing.T) {
+	var models []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		models = append(models, body["model"])
+		json.NewEncoder(w).Encode(apiResponse{
+			Choices: []apiChoice{{Message: apiMessage{Role: "assistant", Content: "ok"}, FinishReason: "stop"}},
+		})
+	}))
+	defer server.Close()
+
+	v := config.NewStore()
+	v.Set("api_key", "test-key")
+	v.Set("base_url", server.URL)
+	v.Set("model", "fast")
+	v.Set("model_aliases.fast", "gpt-4.1-mini")
+	p, err := NewProvider(v)
+	require.NoError(t, err)
+
+	msgs := []provider.Message{{Role: provider.RoleUser, Content: "Hi"}}
+	_, err = p.Complete(context.Background(), provider.CompletionRequest{Messages: msgs})
+	require.NoError(t, err)
+	_, err = p.Complete(context.Background(), provider.CompletionRequest{Messages: msgs, Model: "GPT4"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []interface{}{"gpt-4.1-mini", "gpt-4o"}, models)
+}
+
+func TestClassifyHTTPError_RetryAfter(t *testing.T) {
+	h := http.Header{}
+	h.Set("Retry-After", "12")
+	pe := classifyHTTPError("openai", http.StatusTooManyRequests, h, []byte(`{"error":{"message":"slow down"}}`))
+	assert.Equal(t, provider.ErrCodeRateLimit, pe.Code)
+	assert.Equal(t, 12*time.Second, pe.RetryAfter)
+
+	pe = classifyHTTPError("openai", http.StatusInternalServerError, http.Header{}, nil)
+	assert.Equal(t, time.Duration(0), pe.RetryAfter)
+}
+
+func TestOpenAIComplete_ResponsesAPI(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/responses", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = io.WriteString(w, `{
+			"id": "resp_1", "model": "o4-mini", "status": "completed",
+			"output": [
+				{"type": "reasoning", "id": "rs_1", "summary": []},
+				{"type": "message", "content": [{"type": "output_text", "text": "Looks "}, {"type": "output_text", "text": "good"}]}
+			],
+			"usage": {"input_tokens": 40, "output_tokens": 300, "total_tokens": 340, "output_tokens_details": {"reasoning_tokens": 256}}
+		}`)
+	}))
+	defer server.Close()
+
+	v := config.NewStore()
+	v.Set("api_key", "test-key")
+	v.Set("base_url", server.URL)
+	v.Set("model", "o4-mini")
+	v.Set("api", "responses")
+	v.Set("reasoning_effort", "high")
+
+	p, err := NewProvider(v)
+	require.NoError(t, err)
+	assert.False(t, p.Info().SupportsMultipleChoices)
+
+	resp, err := p.Complete(context.Background(), provider.CompletionRequest{
+		Messages: []provider.Message{
+			{Role: provider.RoleSystem, Content: "be terse"},
+			{Role: provider.RoleUser, Content: "review"},
+		},
+		JSONMode: true,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Looks good", resp.Content)
+	assert.Equal(t, "stop", resp.FinishReason)
+	assert.Equal(t, provider.Usage{PromptTokens: 40, CompletionTokens: 300, TotalTokens: 340, ReasoningTokens: 256}, resp.Usage)
+
+	assert.Equal(t, "o4-mini", got["model"])
+	assert.Equal(t, map[string]interface{}{"effort": "high"}, got["reasoning"])
+	assert.Equal(t, map[string]interface{}{"format": map[string]interface{}{"type": "json_object"}}, got["text"])
+	assert.EqualValues(t, 1024, got["max_output_tokens"])
+	assert.NotContains(t, got, "messages")
+	input, ok := got["input"].([]interface{})
+	require.True(t, ok)
+	require.Len(t, input, 2)
+	assert.Equal(t, map[string]interface{}{"role": "system", "content": "be terse"}, input[0])
+}
+
+func TestOpenAIComplete_ResponsesAPIIncomplete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"status": "incomplete", "incomplete_details": {"reason": "max_output_tokens"}, "output": []}`)
+	}))
+	defer server.Close()
+
+	v := config.NewStore()
+	v.Set("api_key", "test-key")
+	v.Set("base_url", server.URL)
+	v.Set("api", "responses")
+
+	p, err := NewProvider(v)
+	require.NoError(t, err)
+	resp, err := p.Complete(context.Background(), provider.CompletionRequest{
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "review"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "length", resp.FinishReason)
+	assert.Empty(t, resp.Content)
+}
+
+func TestOpenAICompleteStream_ResponsesAPI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/responses", r.URL.Path)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, "event: response.created\ndata: {\"type\":\"response.created\",\"response\":{\"id\":\"resp_1\"}}\n\n")
+		_, _ = io.WriteString(w, "event: response.output_text.delta\ndata: {\"type\":\"response.output_text.delta\",\"delta\":\"hel\"}\n\n")
+		_, _ = io.WriteString(w, "event: response.output_text.delta\ndata: {\"type\":\"response.output_text.delta\",\"delta\":\"lo\"}\n\n")
+		_, _ = io.WriteString(w, "event: response.completed\ndata: {\"type\":\"response.completed\",\"response\":{\"status\":\"completed\",\"usage\":{\"input_tokens\":5,\"output_tokens\":20,\"total_tokens\":25,\"output_tokens_details\":{\"reasoning_tokens\":12}}}}\n\n")
+	}))
+	defer server.Close()
+
+	v := config.NewStore()
+	v.Set("api_key", "test-key")
+	v.Set("base_url", server.URL)
+	v.Set("api", "responses")
+
+	p, err := NewProvider(v)
+	require.NoError(t, err)
+
+	result := p.CompleteStream(context.Background(), provider.CompletionRequest{
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hello"}},
+		Stream:   true,
+	})
+
+	var content strings.Builder
+	var last provider.StreamChunk
+	for chunk := range result.Chunks {
+		content.WriteString(chunk.Content)
+		last = chunk
+	}
+	require.NoError(t, <-result.Err)
+	assert.Equal(t, "hello", content.String())
+	assert.True(t, last.Done)
+	require.NotNil(t, last.Usage)
+	assert.Equal(t, 12, last.Usage.ReasoningTokens)
+	assert.Equal(t, 25, last.Usage.TotalTokens)
+}
+
+func TestNewProvider_RejectsUnknownAPI(t *testing.T) {
+	v := config.NewStore()
+	v.Set("api", "assistants")
+
+	_, err := NewProvider(v)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "assistants")
+}
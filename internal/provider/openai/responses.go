@@ -0,0 +1,329 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/sanix-darker/prev/internal/provider"
+)
+
+// Values of the "api" config key.
+const (
+	apiChat      = "chat"
+	apiResponses = "responses"
+)
+
+// ---------------------------------------------------------------------------
+// Responses API types (request)
+// ---------------------------------------------------------------------------
+
+type responsesInput struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type responsesReasoning struct {
+	Effort string `json:"effort,omitempty"`
+}
+
+type responsesTextFormat struct {
+	Type string `json:"type"`
+}
+
+type responsesText struct {
+	Format responsesTextFormat `json:"format"`
+}
+
+type responsesRequest struct {
+	Model           string              `json:"model"`
+	Input           []responsesInput    `json:"input"`
+	MaxOutputTokens int                 `json:"max_output_tokens,omitempty"`
+	Temperature     *float64            `json:"temperature,omitempty"`
+	TopP            *float64            `json:"top_p,omitempty"`
+	Stream          bool                `json:"stream,omitempty"`
+	Reasoning       *responsesReasoning `json:"reasoning,omitempty"`
+	Text            *responsesText      `json:"text,omitempty"`
+}
+
+// ---------------------------------------------------------------------------
+// Responses API types (response)
+// ---------------------------------------------------------------------------
+
+type responsesContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type responsesOutput struct {
+	Type      string             `json:"type"`
+	ID        string             `json:"id"`
+	CallID    string             `json:"call_id"`
+	Name      string             `json:"name"`
+	Arguments string             `json:"arguments"`
+	Content   []responsesContent `json:"content"`
+}
+
+type responsesUsage struct {
+	InputTokens         int `json:"input_tokens"`
+	OutputTokens        int `json:"output_tokens"`
+	TotalTokens         int `json:"total_tokens"`
+	OutputTokensDetails struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+	} `json:"output_tokens_details"`
+}
+
+type responsesResponse struct {
+	ID                string            `json:"id"`
+	Model             string            `json:"model"`
+	Status            string            `json:"status"`
+	Output            []responsesOutput `json:"output"`
+	Usage             *responsesUsage   `json:"usage"`
+	IncompleteDetails *struct {
+		Reason string `json:"reason"`
+	} `json:"incomplete_details"`
+}
+
+// responsesEvent is one server-sent event of a streamed response.
+type responsesEvent struct {
+	Type     string             `json:"type"`
+	Delta    string             `json:"delta"`
+	Response *responsesResponse `json:"response"`
+	Message  string             `json:"message"`
+}
+
+// ---------------------------------------------------------------------------
+// Request building and decoding
+// ---------------------------------------------------------------------------
+
+// newResponsesRequest maps a completion request onto the Responses API.
+// Stop sequences and N have no equivalent there and are dropped.
+func (p *Provider) newResponsesRequest(req provider.CompletionRequest, stream bool) responsesRequest {
+	model := provider.ResolveModelAlias(p.aliases, req.Model)
+	if model == "" {
+		model = p.model
+	}
+	maxTok := req.MaxTokens
+	if maxTok == 0 {
+		maxTok = p.maxTok
+	}
+	if clamped, ok := provider.ClampResponseTokens(model, maxTok, req.Messages); ok {
+		p.clampNotice.Warn(model, maxTok, clamped)
+		maxTok = clamped
+	}
+
+	body := responsesRequest{
+		Model:           model,
+		Input:           make([]responsesInput, len(req.Messages)),
+		MaxOutputTokens: maxTok,
+		Temperature:     req.Temperature,
+		TopP:            req.TopP,
+		Stream:          stream,
+	}
+	for i, m := range req.Messages {
+		body.Input[i] = responsesInput{Role: string(m.Role), Content: m.Content}
+	}
+	if p.reasoningEffort != "" {
+		body.Reasoning = &responsesReasoning{Effort: p.reasoningEffort}
+	}
+	if req.JSONMode {
+		body.Text = &responsesText{Format: responsesTextFormat{Type: "json_object"}}
+	}
+	return body
+}
+
+// newResponsesHTTPRequest builds the POST to /responses.
+func (p *Provider) newResponsesHTTPRequest(ctx context.Context, body responsesRequest) (*http.Request, error) {
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return nil, &provider.ProviderError{
+			Code: provider.ErrCodeUnknown, Message: "failed to marshal request",
+			Provider: "openai", Cause: err,
+		}
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		p.baseURL+"/responses", bytes.NewReader(bodyBytes))
+	if err != nil {
+		return nil, &provider.ProviderError{
+			Code: provider.ErrCodeUnknown, Message: "failed to build request",
+			Provider: "openai", Cause: err,
+		}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	return httpReq, nil
+}
+
+func (p *Provider) doResponsesComplete(ctx context.Context, req provider.CompletionRequest) (*provider.CompletionResponse, error) {
+	httpReq, err := p.newResponsesHTTPRequest(ctx, p.newResponsesRequest(req, false))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, &provider.ProviderError{
+			Code:     provider.ErrCodeProviderUnavailable,
+			Message:  "HTTP request failed",
+			Provider: "openai",
+			Cause:    err,
+		}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &provider.ProviderError{
+			Code: provider.ErrCodeUnknown, Message: "failed to read response",
+			Provider: "openai", Cause: err,
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, classifyHTTPError("openai", resp.StatusCode, resp.Header, respBody)
+	}
+
+	var apiResp responsesResponse
+	if err := json.Unmarshal(respBody, &apiResp); err != nil {
+		return nil, &provider.ProviderError{
+			Code:     provider.ErrCodeUnknown,
+			Message:  "failed to decode response",
+			Provider: "openai",
+			Cause:    err,
+		}
+	}
+
+	return fromResponsesResponse(&apiResp), nil
+}
+
+// streamResponses runs a streamed request against /responses, decoding the
+// output_text delta events into chunks. It is called from CompleteStream's
+// goroutine, which owns and closes both channels.
+func (p *Provider) streamResponses(ctx context.Context, req provider.CompletionRequest, chunks chan<- provider.StreamChunk, errCh chan<- error) {
+	httpReq, err := p.newResponsesHTTPRequest(ctx, p.newResponsesRequest(req, true))
+	if err != nil {
+		errCh <- err
+		return
+	}
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	httpResp, err := p.client.Do(httpReq)
+	if err != nil {
+		errCh <- &provider.ProviderError{
+			Code: provider.ErrCodeProviderUnavailable, Message: "stream request failed",
+			Provider: "openai", Cause: err,
+		}
+		return
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		var buf [4096]byte
+		n, _ := httpResp.Body.Read(buf[:])
+		errCh <- classifyHTTPError("openai", httpResp.StatusCode, httpResp.Header, buf[:n])
+		return
+	}
+
+	scanner := provider.NewSSEScanner(httpResp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue // "event:" lines repeat the type carried in the data
+		}
+
+		var ev responsesEvent
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev); err != nil {
+			continue // skip malformed events
+		}
+
+		switch ev.Type {
+		case "response.output_text.delta":
+			if ev.Delta == "" {
+				continue
+			}
+			if !provider.SendStreamChunk(ctx, chunks, provider.StreamChunk{Content: ev.Delta}) {
+				errCh <- ctx.Err()
+				return
+			}
+		case "response.completed", "response.incomplete":
+			sc := provider.StreamChunk{Done: true, FinishReason: "stop"}
+			if ev.Response != nil {
+				final := fromResponsesResponse(ev.Response)
+				sc.FinishReason = final.FinishReason
+				if final.Usage.TotalTokens > 0 {
+					usage := final.Usage
+					sc.Usage = &usage
+				}
+			}
+			if !provider.SendStreamChunk(ctx, chunks, sc) {
+				errCh <- ctx.Err()
+			}
+			return
+		case "response.failed", "error":
+			msg := ev.Message
+			if msg == "" {
+				msg = "response failed"
+			}
+			errCh <- &provider.ProviderError{
+				Code: provider.ErrCodeUnknown, Message: msg, Provider: "openai",
+			}
+			return
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		errCh <- &provider.ProviderError{
+			Code: provider.ErrCodeUnknown, Message: "stream read error",
+			Provider: "openai", Cause: err,
+		}
+	}
+}
+
+// fromResponsesResponse converts a Responses API result. The text of every
+// output_text part is concatenated; reasoning items carry no text and are
+// only reflected in the reasoning token count.
+func fromResponsesResponse(r *responsesResponse) *provider.CompletionResponse {
+	resp := &provider.CompletionResponse{
+		ID:           r.ID,
+		Model:        r.Model,
+		FinishReason: "stop",
+	}
+	if r.Usage != nil {
+		resp.Usage = provider.Usage{
+			PromptTokens:     r.Usage.InputTokens,
+			CompletionTokens: r.Usage.OutputTokens,
+			TotalTokens:      r.Usage.TotalTokens,
+			ReasoningTokens:  r.Usage.OutputTokensDetails.ReasoningTokens,
+		}
+	}
+	if r.Status == "incomplete" {
+		resp.FinishReason = "length"
+		if r.IncompleteDetails != nil && r.IncompleteDetails.Reason == "content_filter" {
+			resp.FinishReason = "content_filter"
+		}
+	}
+
+	var text strings.Builder
+	for _, out := range r.Output {
+		switch out.Type {
+		case "message":
+			for _, c := range out.Content {
+				if c.Type == "output_text" {
+					text.WriteString(c.Text)
+				}
+			}
+		case "function_call":
+			resp.ToolCalls = append(resp.ToolCalls, provider.ToolCall{
+				ID:        out.CallID,
+				Name:      out.Name,
+				Arguments: out.Arguments,
+			})
+		}
+	}
+	resp.Content = text.String()
+	resp.Choices = []provider.Choice{{Content: resp.Content, FinishReason: resp.FinishReason}}
+	return resp
+}
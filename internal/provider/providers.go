@@ -23,6 +23,7 @@ package provider
 //   - "openai"        -> internal/provider/openai
 //   - "anthropic"     -> internal/provider/anthropic
 //   - "azure"         -> internal/provider/azure
+//   - "mistral"       -> internal/provider/mistral
 //   - "ollama"        -> internal/provider/compat (OpenAI-compatible)
 //   - "groq"          -> internal/provider/compat (OpenAI-compatible)
 //   - "together"      -> internal/provider/compat (OpenAI-compatible)
@@ -5,9 +5,74 @@ import (
 	"errors"
 	"math"
 	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
+// maxRetryAfter caps how long a server-supplied Retry-After can stall a
+// retry.
+const maxRetryAfter = 2 * time.Minute
+
+var (
+	retryRandMu sync.Mutex
+	retryRand   = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// SetRetryRandSource replaces the source WithRetry draws its jitter from,
+// so tests can make backoff delays deterministic.
+func SetRetryRandSource(src rand.Source) {
+	retryRandMu.Lock()
+	defer retryRandMu.Unlock()
+	retryRand = rand.New(src)
+}
+
+// jitter returns a random duration in [0, interval) (full jitter).
+func jitter(interval time.Duration) time.Duration {
+	if interval <= 0 {
+		return 0
+	}
+	retryRandMu.Lock()
+	defer retryRandMu.Unlock()
+	return time.Duration(retryRand.Int63n(int64(interval)))
+}
+
+// ParseRetryAfter returns the delay requested by a Retry-After header,
+// given either in seconds or as an HTTP date, capped at two minutes. It
+// returns 0 when the header is absent or malformed.
+func ParseRetryAfter(header http.Header) time.Duration {
+	if header == nil {
+		return 0
+	}
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return 0
+	}
+	var d time.Duration
+	if secs, err := strconv.Atoi(value); err == nil {
+		d = time.Duration(secs) * time.Second
+	} else if at, err := http.ParseTime(value); err == nil {
+		d = time.Until(at)
+	}
+	if d < 0 {
+		return 0
+	}
+	return min(d, maxRetryAfter)
+}
+
+// retryDelay returns how long to wait before retrying after err: a full
+// jitter draw from interval, raised to the server's Retry-After if longer.
+func retryDelay(err error, interval time.Duration) time.Duration {
+	sleep := jitter(interval)
+	var pe *ProviderError
+	if errors.As(err, &pe) && pe.RetryAfter > sleep {
+		sleep = pe.RetryAfter
+	}
+	return sleep
+}
+
 // ---------------------------------------------------------------------------
 // Retry helper
 // ---------------------------------------------------------------------------
@@ -29,8 +94,9 @@ func retryable(err error) bool {
 	}
 }
 
-// WithRetry wraps a function call with exponential backoff + jitter. If cfg
-// has MaxRetries == 0 the function is called exactly once.
+// WithRetry wraps a function call with exponential backoff and full jitter,
+// honoring a ProviderError's RetryAfter. If cfg has MaxRetries == 0 the
+// function is called exactly once.
 //
 // Usage:
 //
@@ -64,9 +130,7 @@ func WithRetry[T any](ctx context.Context, cfg RetryConfig, fn func() (T, error)
 			break
 		}
 
-		// Exponential backoff with jitter (full jitter strategy).
-		jitter := time.Duration(rand.Int63n(int64(interval)))
-		sleep := interval/2 + jitter
+		sleep := retryDelay(err, interval)
 
 		select {
 		case <-ctx.Done():
@@ -0,0 +1,90 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJitter_SeededSourceIsDeterministic(t *testing.T) {
+	t.Cleanup(func() { SetRetryRandSource(rand.NewSource(time.Now().UnixNano())) })
+
+	draw := func() []time.Duration {
+		SetRetryRandSource(rand.NewSource(42))
+		out := make([]time.Duration, 5)
+		for i := range out {
+			out[i] = jitter(time.Second)
+		}
+		return out
+	}
+	first, second := draw(), draw()
+	assert.Equal(t, first, second)
+	for _, d := range first {
+		assert.GreaterOrEqual(t, d, time.Duration(0))
+		assert.Less(t, d, time.Second)
+	}
+	assert.Equal(t, time.Duration(0), jitter(0))
+}
+
+func TestRetryDelay_HonorsRetryAfter(t *testing.T) {
+	pe := &ProviderError{Code: ErrCodeRateLimit, RetryAfter: 5 * time.Second}
+	assert.Equal(t, 5*time.Second, retryDelay(pe, time.Second))
+
+	plain := retryDelay(errors.New("connection reset"), time.Second)
+	assert.Less(t, plain, time.Second)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	h := http.Header{}
+	assert.Equal(t, time.Duration(0), ParseRetryAfter(nil))
+	assert.Equal(t, time.Duration(0), ParseRetryAfter(h))
+
+	h.Set("Retry-After", "7")
+	assert.Equal(t, 7*time.Second, ParseRetryAfter(h))
+
+	h.Set("Retry-After", "3600")
+	assert.Equal(t, maxRetryAfter, ParseRetryAfter(h))
+
+	h.Set("Retry-After", "-1")
+	assert.Equal(t, time.Duration(0), ParseRetryAfter(h))
+
+	h.Set("Retry-After", "soon")
+	assert.Equal(t, time.Duration(0), ParseRetryAfter(h))
+
+	h.Set("Retry-After", time.Now().Add(30*time.Second).UTC().Format(http.TimeFormat))
+	d := ParseRetryAfter(h)
+	assert.Greater(t, d, 25*time.Second)
+	assert.LessOrEqual(t, d, 30*time.Second)
+}
+
+func TestWithRetry_RetriesRetryableErrors(t *testing.T) {
+	cfg := RetryConfig{MaxRetries: 2, InitialInterval: time.Millisecond, MaxInterval: 2 * time.Millisecond, Multiplier: 2}
+	calls := 0
+	got, err := WithRetry(context.Background(), cfg, func() (string, error) {
+		calls++
+		if calls < 3 {
+			return "", &ProviderError{Code: ErrCodeRateLimit, RetryAfter: time.Millisecond}
+		}
+		return "ok", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", got)
+	assert.Equal(t, 3, calls)
+}
+
+func TestWithRetry_StopsOnNonRetryableError(t *testing.T) {
+	cfg := RetryConfig{MaxRetries: 3, InitialInterval: time.Millisecond, MaxInterval: time.Millisecond, Multiplier: 2}
+	calls := 0
+	_, err := WithRetry(context.Background(), cfg, func() (int, error) {
+		calls++
+		return 0, &ProviderError{Code: ErrCodeAuthentication}
+	})
+	require.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
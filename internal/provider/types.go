@@ -14,7 +14,9 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -70,6 +72,23 @@ type CompletionRequest struct {
 	// StopSequences optionally tells the model to stop generating upon
 	// encountering any of these strings.
 	StopSequences []string `json:"stop,omitempty"`
+
+	// JSONMode asks the provider to constrain output to a single JSON object.
+	// Providers without native support (see ProviderInfo.SupportsJSONMode)
+	// ignore it and rely on prompt instructions alone.
+	JSONMode bool `json:"json_mode,omitempty"`
+
+	// N asks for N independent choices in one call. Providers without
+	// native support (see ProviderInfo.SupportsMultipleChoices) return one;
+	// CompleteChoices tops the rest up with sequential calls. Streaming
+	// requests always produce a single choice.
+	N int `json:"n,omitempty"`
+
+	// AssistantPrefill starts the assistant's reply so the model continues
+	// from it (e.g. the opening of a JSON object). Providers that support it
+	// (Anthropic) include the prefill at the start of the returned content;
+	// the others ignore it.
+	AssistantPrefill string `json:"assistant_prefill,omitempty"`
 }
 
 // ---------------------------------------------------------------------------
@@ -101,9 +120,42 @@ type CompletionResponse struct {
 	// "max_tokens", "end_turn").
 	FinishReason string `json:"finish_reason"`
 
+	// ToolCalls holds tool/function calls from the first choice. Some
+	// endpoints answer with tool calls and null content even when no tools
+	// were requested.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+
 	// ProviderMeta carries any provider-specific metadata that does not fit
 	// into the normalized fields (e.g. Anthropic's stop_sequence value).
 	ProviderMeta map[string]interface{} `json:"provider_meta,omitempty"`
+
+	// Provider names the provider that served the request when a wrapper
+	// such as FallbackProvider picked it. Plain providers leave it empty.
+	Provider string `json:"provider,omitempty"`
+}
+
+// BestText returns the reply text, falling back to the first non-empty
+// choice and then to the tool-call arguments (newline-joined) when the
+// provider returned no text content.
+func (r *CompletionResponse) BestText() string {
+	if r == nil {
+		return ""
+	}
+	if strings.TrimSpace(r.Content) != "" {
+		return r.Content
+	}
+	for _, c := range r.Choices {
+		if strings.TrimSpace(c.Content) != "" {
+			return c.Content
+		}
+	}
+	var args []string
+	for _, tc := range r.ToolCalls {
+		if a := strings.TrimSpace(tc.Arguments); a != "" {
+			args = append(args, a)
+		}
+	}
+	return strings.Join(args, "\n")
 }
 
 // Choice represents a single completion choice from the provider.
@@ -113,11 +165,23 @@ type Choice struct {
 	FinishReason string `json:"finish_reason"`
 }
 
+// ToolCall is a tool/function invocation returned by the model. Arguments is
+// the raw argument string, usually JSON.
+type ToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
 // Usage tracks token consumption.
 type Usage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+
+	// ReasoningTokens is the part of CompletionTokens a reasoning model
+	// spent thinking. Providers that do not report it leave it zero.
+	ReasoningTokens int `json:"reasoning_tokens,omitempty"`
 }
 
 // ---------------------------------------------------------------------------
@@ -137,6 +201,10 @@ type StreamChunk struct {
 
 	// Usage is populated on the final chunk when the provider supports it.
 	Usage *Usage
+
+	// Provider names the provider that produced the chunk when a wrapper
+	// such as FallbackProvider picked it.
+	Provider string
 }
 
 // StreamResult bundles the two channels returned from CompleteStream.
@@ -175,11 +243,14 @@ const (
 // ProviderError is a structured error that carries both a normalized code
 // and the original provider-specific details. It implements the standard
 // error interface and supports errors.Is / errors.As unwrapping.
+// RetryAfter holds the server's Retry-After delay, if any; WithRetry waits
+// at least that long before the next attempt.
 type ProviderError struct {
 	Code       ErrorCode
 	Message    string
 	Provider   string
 	StatusCode int
+	RetryAfter time.Duration
 	Cause      error
 }
 
@@ -207,6 +278,19 @@ var (
 	ErrTimeout             = &ProviderError{Code: ErrCodeTimeout}
 )
 
+// ValidateTimeout bounds the live connectivity check made by Validate.
+const ValidateTimeout = 10 * time.Second
+
+// TransportError wraps a failed HTTP round trip. A context deadline is
+// reported as ErrCodeTimeout, anything else as ErrCodeProviderUnavailable.
+func TransportError(providerName, message string, err error) *ProviderError {
+	code := ErrCodeProviderUnavailable
+	if errors.Is(err, context.DeadlineExceeded) {
+		code = ErrCodeTimeout
+	}
+	return &ProviderError{Code: code, Message: message, Provider: providerName, Cause: err}
+}
+
 // Is allows errors.Is to match ProviderErrors by code.
 func (e *ProviderError) Is(target error) bool {
 	t, ok := target.(*ProviderError)
@@ -268,6 +352,14 @@ type ProviderInfo struct {
 
 	// SupportsStreaming indicates whether this provider supports streaming.
 	SupportsStreaming bool
+
+	// SupportsJSONMode indicates whether CompletionRequest.JSONMode is
+	// forwarded to the API as a native JSON response format.
+	SupportsJSONMode bool
+
+	// SupportsMultipleChoices indicates whether CompletionRequest.N is
+	// forwarded to the API.
+	SupportsMultipleChoices bool
 }
 
 // ---------------------------------------------------------------------------
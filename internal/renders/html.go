@@ -0,0 +1,157 @@
+package renders
+
+import (
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+)
+
+// HTMLReport is the data rendered by RenderHTMLReport.
+type HTMLReport struct {
+	Title        string
+	ProjectID    string
+	MRIID        int64
+	SourceBranch string
+	TargetBranch string
+	HeadSHA      string
+	Provider     string
+	Model        string
+	DryRun       bool
+	Summary      string
+	Findings     []HTMLFinding
+	Unplaced     []string
+}
+
+// HTMLFinding is one grouped review finding.
+type HTMLFinding struct {
+	File       string
+	Line       int
+	Severity   string
+	Message    string
+	Suggestion string
+	Status     string
+}
+
+type htmlFile struct {
+	Path     string
+	Findings []HTMLFinding
+}
+
+type htmlDiffLine struct {
+	Class string
+	Text  string
+}
+
+// severityOrder lists severities from most to least severe, as shown in the
+// report's counters.
+var severityOrder = []string{"CRITICAL", "HIGH", "MEDIUM", "LOW"}
+
+// RenderHTMLReport writes r as a self-contained HTML page: inline CSS,
+// no scripts, one collapsible section per file.
+func RenderHTMLReport(w io.Writer, r HTMLReport) error {
+	counts := make(map[string]int, len(severityOrder))
+	byFile := map[string]*htmlFile{}
+	var files []*htmlFile
+	for _, f := range r.Findings {
+		f.Severity = strings.ToUpper(strings.TrimSpace(f.Severity))
+		counts[f.Severity]++
+		file := byFile[f.File]
+		if file == nil {
+			file = &htmlFile{Path: f.File}
+			byFile[f.File] = file
+			files = append(files, file)
+		}
+		file.Findings = append(file.Findings, f)
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	type severityCount struct {
+		Severity string
+		Count    int
+	}
+	var totals []severityCount
+	for _, sev := range severityOrder {
+		if counts[sev] > 0 {
+			totals = append(totals, severityCount{Severity: sev, Count: counts[sev]})
+		}
+	}
+
+	return htmlReportTemplate.Execute(w, struct {
+		HTMLReport
+		Files  []*htmlFile
+		Totals []severityCount
+	}{r, files, totals})
+}
+
+// suggestionLines marks each suggestion line as an addition so the patch
+// reads like a diff.
+func suggestionLines(s string) []htmlDiffLine {
+	s = strings.Trim(s, "\n")
+	if s == "" {
+		return nil
+	}
+	var out []htmlDiffLine
+	for _, l := range strings.Split(s, "\n") {
+		out = append(out, htmlDiffLine{Class: "add", Text: "+ " + l})
+	}
+	return out
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"lower":      strings.ToLower,
+	"suggestion": suggestionLines,
+	"findingWord": func(n int) string {
+		if n == 1 {
+			return "finding"
+		}
+		return "findings"
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Review: {{.Title}}</title>
+<style>
+body{font:14px/1.5 -apple-system,"Segoe UI",Helvetica,Arial,sans-serif;color:#1f2328;max-width:1000px;margin:2rem auto;padding:0 1rem}
+h1{font-size:1.5rem;margin-bottom:.25rem}
+.meta{color:#59636e;margin-bottom:1.5rem}
+.meta code{background:#f6f8fa;padding:0 .25rem;border-radius:4px}
+.totals span{display:inline-block;margin-right:.5rem}
+.summary{white-space:pre-wrap;background:#f6f8fa;border:1px solid #d1d9e0;border-radius:6px;padding:1rem}
+details{border:1px solid #d1d9e0;border-radius:6px;margin:1rem 0}
+summary{cursor:pointer;padding:.5rem 1rem;background:#f6f8fa;font-family:ui-monospace,Menlo,monospace}
+.finding{border-top:1px solid #d1d9e0;padding:.75rem 1rem}
+.finding p{margin:.25rem 0;white-space:pre-wrap}
+.badge{display:inline-block;border-radius:2em;padding:0 .6rem;font-size:12px;font-weight:600;color:#fff}
+.sev-critical{background:#82071e}.sev-high{background:#cf222e}.sev-medium{background:#bf8700}.sev-low{background:#0969da}
+.status{color:#59636e;font-size:12px;margin-left:.5rem}
+pre.diff{background:#f6f8fa;border-radius:6px;padding:.5rem;overflow-x:auto;margin:.5rem 0 0}
+pre.diff .add{display:block;background:#dafbe1;color:#116329}
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="meta">
+{{.ProjectID}} !{{.MRIID}} &middot; <code>{{.SourceBranch}}</code> &rarr; <code>{{.TargetBranch}}</code>{{if .HeadSHA}} &middot; head <code>{{.HeadSHA}}</code>{{end}}<br>
+Reviewed by {{.Provider}}{{if .Model}} ({{.Model}}){{end}}{{if .DryRun}} &middot; dry run, nothing posted{{end}}
+</div>
+<div class="totals">{{range .Totals}}<span class="badge sev-{{lower .Severity}}">{{.Count}} {{.Severity}}</span>{{else}}No findings.{{end}}</div>
+{{if .Summary}}<h2>Summary</h2>
+<div class="summary">{{.Summary}}</div>{{end}}
+{{if .Files}}<h2>Findings</h2>{{end}}
+{{range .Files}}<details open>
+<summary>{{.Path}} &middot; {{len .Findings}} {{findingWord (len .Findings)}}</summary>
+{{range .Findings}}<div class="finding">
+<span class="badge sev-{{lower .Severity}}">{{.Severity}}</span> line {{.Line}}{{if .Status}}<span class="status">{{.Status}}</span>{{end}}
+<p>{{.Message}}</p>
+{{with suggestion .Suggestion}}<pre class="diff">{{range .}}<span class="{{.Class}}">{{.Text}}</span>{{end}}</pre>{{end}}
+</div>
+{{end}}</details>
+{{end}}
+{{if .Unplaced}}<h2>Unplaced findings</h2>
+<ul>{{range .Unplaced}}<li>{{.}}</li>{{end}}</ul>{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,51 @@
+package renders
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderHTMLReport_GroupsFindingsByFile(t *testing.T) {
+	var buf bytes.Buffer
+	err := RenderHTMLReport(&buf, HTMLReport{
+		Title:        "Add <login> flow",
+		ProjectID:    "grp/proj",
+		MRIID:        42,
+		SourceBranch: "feature",
+		TargetBranch: "main",
+		Provider:     "openai",
+		Summary:      "Looks mostly fine.",
+		Findings: []HTMLFinding{
+			{File: "b.go", Line: 3, Severity: "low", Message: "Naming."},
+			{File: "a.go", Line: 10, Severity: "HIGH", Message: "Missing nil check <script>alert(1)</script>", Suggestion: "if x == nil {\n\treturn nil\n}", Status: "posted"},
+			{File: "a.go", Line: 20, Severity: "critical", Message: "SQL injection."},
+		},
+		Unplaced: []string{"- c.go:5 [ISSUE/MEDIUM] Gone."},
+	})
+	require.NoError(t, err)
+	out := buf.String()
+
+	assert.True(t, strings.HasPrefix(out, "<!DOCTYPE html>"))
+	assert.Contains(t, out, "<title>Review: Add &lt;login&gt; flow</title>")
+	assert.NotContains(t, out, "<script>")
+	assert.Contains(t, out, "Missing nil check &lt;script&gt;")
+	assert.Less(t, strings.Index(out, "<summary>a.go"), strings.Index(out, "<summary>b.go"))
+	assert.Contains(t, out, "<summary>a.go &middot; 2 findings</summary>")
+	assert.Contains(t, out, "<summary>b.go &middot; 1 finding</summary>")
+	assert.Contains(t, out, `<span class="badge sev-critical">1 CRITICAL</span>`)
+	assert.Contains(t, out, `<span class="badge sev-low">LOW</span>`)
+	assert.Contains(t, out, `<span class="add">&#43; if x == nil {</span>`)
+	assert.Contains(t, out, "Gone.")
+}
+
+func TestRenderHTMLReport_NoFindings(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, RenderHTMLReport(&buf, HTMLReport{Title: "Docs", DryRun: true}))
+	assert.Contains(t, buf.String(), "No findings.")
+	assert.Contains(t, buf.String(), "dry run, nothing posted")
+	assert.NotContains(t, buf.String(), "<details")
+}
@@ -0,0 +1,143 @@
+// Package reviewcache stores AI review responses on disk so re-running a
+// review on an unchanged MR does not call the provider again.
+package reviewcache
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultTTL is used when no TTL is configured.
+const DefaultTTL = 24 * time.Hour
+
+// Entry is the on-disk representation of a cached review.
+type Entry struct {
+	Key       string    `json:"key"`
+	Signature string    `json:"signature"`
+	Outputs   []string  `json:"outputs"`
+	Samples   []string  `json:"samples,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Cache reads and writes entries as one JSON file per key under Dir.
+// A TTL <= 0 disables expiry.
+type Cache struct {
+	Dir string
+	TTL time.Duration
+
+	now func() time.Time
+}
+
+// New returns a cache rooted at dir.
+func New(dir string, ttl time.Duration) *Cache {
+	return &Cache{Dir: dir, TTL: ttl, now: time.Now}
+}
+
+// Key hashes the provider, model and final prompt into a cache key. Extra
+// parts (e.g. pass count) are folded in so differently shaped runs do not
+// share entries.
+func Key(providerName, model, prompt string, extra ...string) string {
+	h := sha256.New()
+	for _, part := range append([]string{providerName, model, prompt}, extra...) {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))[:32]
+}
+
+// Signature hashes per-file diff signatures into a single value. Entries are
+// invalidated when the signature of the current diff no longer matches.
+func Signature(fileSigs map[string]string) string {
+	paths := make([]string, 0, len(fileSigs))
+	for p := range fileSigs {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	h := sha256.New()
+	for _, p := range paths {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+		h.Write([]byte(fileSigs[p]))
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))[:32]
+}
+
+// Get returns the cached outputs for key. Expired entries and entries whose
+// signature differs are removed and reported as a miss.
+func (c *Cache) Get(key, signature string) ([]string, bool) {
+	outputs, _, ok := c.GetWithSamples(key, signature)
+	return outputs, ok
+}
+
+// GetWithSamples is Get that also returns the alternate samples stored with
+// the entry.
+func (c *Cache) GetWithSamples(key, signature string) ([]string, []string, bool) {
+	path := c.path(key)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, false
+	}
+	var e Entry
+	if err := json.Unmarshal(raw, &e); err != nil || e.Key != key {
+		_ = os.Remove(path)
+		return nil, nil, false
+	}
+	if e.Signature != signature || c.expired(e) || len(e.Outputs) == 0 {
+		_ = os.Remove(path)
+		return nil, nil, false
+	}
+	return e.Outputs, e.Samples, true
+}
+
+// Put stores outputs for key.
+func (c *Cache) Put(key, signature string, outputs []string) error {
+	return c.PutWithSamples(key, signature, outputs, nil)
+}
+
+// PutWithSamples stores outputs and the alternate samples of the final pass
+// for key, so a cache hit restores both.
+func (c *Cache) PutWithSamples(key, signature string, outputs, samples []string) error {
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create cache dir: %w", err)
+	}
+	raw, err := json.Marshal(Entry{
+		Key:       key,
+		Signature: signature,
+		Outputs:   outputs,
+		Samples:   samples,
+		CreatedAt: c.clock().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	tmp := c.path(key) + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return os.Rename(tmp, c.path(key))
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.Dir, strings.TrimSpace(key)+".json")
+}
+
+func (c *Cache) expired(e Entry) bool {
+	if c.TTL <= 0 {
+		return false
+	}
+	return c.clock().Sub(e.CreatedAt) > c.TTL
+}
+
+func (c *Cache) clock() time.Time {
+	if c.now == nil {
+		return time.Now()
+	}
+	return c.now()
+}
@@ -0,0 +1,69 @@
+package reviewcache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_PutGetRoundTrip(t *testing.T) {
+	c := New(t.TempDir(), time.Hour)
+	key := Key("openai", "gpt-4o", "PROMPT")
+
+	_, ok := c.Get(key, "sig")
+	assert.False(t, ok)
+
+	require.NoError(t, c.Put(key, "sig", []string{"first", "final"}))
+	out, ok := c.Get(key, "sig")
+	require.True(t, ok)
+	assert.Equal(t, []string{"first", "final"}, out)
+}
+
+func TestCache_PutWithSamplesRoundTrip(t *testing.T) {
+	c := New(t.TempDir(), time.Hour)
+	key := Key("openai", "gpt-4o", "PROMPT", "samples=2")
+
+	require.NoError(t, c.PutWithSamples(key, "sig", []string{"final"}, []string{"alt"}))
+	out, samples, ok := c.GetWithSamples(key, "sig")
+	require.True(t, ok)
+	assert.Equal(t, []string{"final"}, out)
+	assert.Equal(t, []string{"alt"}, samples)
+}
+
+func TestCache_SignatureMismatchInvalidates(t *testing.T) {
+	c := New(t.TempDir(), time.Hour)
+	key := Key("openai", "gpt-4o", "PROMPT")
+	require.NoError(t, c.Put(key, Signature(map[string]string{"a.go": "v1"}), []string{"review"}))
+
+	_, ok := c.Get(key, Signature(map[string]string{"a.go": "v2"}))
+	assert.False(t, ok)
+	_, ok = c.Get(key, Signature(map[string]string{"a.go": "v1"}))
+	assert.False(t, ok, "mismatched entry should have been evicted")
+}
+
+func TestCache_TTLExpiry(t *testing.T) {
+	c := New(t.TempDir(), time.Minute)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	key := Key("openai", "gpt-4o", "PROMPT")
+	require.NoError(t, c.Put(key, "sig", []string{"review"}))
+
+	now = now.Add(30 * time.Second)
+	_, ok := c.Get(key, "sig")
+	assert.True(t, ok)
+
+	now = now.Add(2 * time.Minute)
+	_, ok = c.Get(key, "sig")
+	assert.False(t, ok)
+}
+
+func TestKey_DependsOnProviderModelAndPrompt(t *testing.T) {
+	base := Key("openai", "gpt-4o", "PROMPT")
+	assert.Equal(t, base, Key("openai", "gpt-4o", "PROMPT"))
+	assert.NotEqual(t, base, Key("anthropic", "gpt-4o", "PROMPT"))
+	assert.NotEqual(t, base, Key("openai", "gpt-4o-mini", "PROMPT"))
+	assert.NotEqual(t, base, Key("openai", "gpt-4o", "PROMPT2"))
+	assert.NotEqual(t, base, Key("openai", "gpt-4o", "PROMPT", "passes=2"))
+}
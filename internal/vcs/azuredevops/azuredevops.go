@@ -0,0 +1,737 @@
+package azuredevops
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/sanix-darker/prev/internal/vcs"
+)
+
+const apiVersion = "7.1"
+
+// Provider implements vcs.VCSProvider for Azure DevOps Repos.
+//
+// baseURL is the organization URL (e.g. https://dev.azure.com/my-org) and
+// projectID is "<project>/<repository>".
+type Provider struct {
+	client  *http.Client
+	baseURL string
+	token   string
+}
+
+func init() {
+	vcs.Register("azuredevops", NewProvider)
+}
+
+// NewProvider creates an Azure DevOps VCSProvider authenticated with a PAT.
+func NewProvider(token, baseURL string) (vcs.VCSProvider, error) {
+	if token == "" {
+		return nil, fmt.Errorf("azuredevops: token is required")
+	}
+	if baseURL == "" {
+		return nil, fmt.Errorf("azuredevops: organization URL is required (e.g. https://dev.azure.com/my-org)")
+	}
+
+	return &Provider{
+		client:  &http.Client{Timeout: 30 * time.Second},
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+	}, nil
+}
+
+func (p *Provider) Info() vcs.ProviderInfo {
+	return vcs.ProviderInfo{Name: "azuredevops", BaseURL: p.baseURL}
+}
+
+// Validate checks that the token is accepted by fetching the connection
+// data of the organization.
+func (p *Provider) Validate() error {
+	if p.token == "" {
+		return fmt.Errorf("azuredevops: token is required")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), p.client.Timeout)
+	defer cancel()
+	if _, err := p.CurrentUser(ctx); err != nil {
+		return err
+	}
+	return nil
+}
+
+type identity struct {
+	DisplayName string `json:"displayName"`
+	UniqueName  string `json:"uniqueName"`
+}
+
+func (i identity) login() string {
+	if strings.TrimSpace(i.UniqueName) != "" {
+		return i.UniqueName
+	}
+	return i.DisplayName
+}
+
+type pullRequest struct {
+	PullRequestID int64    `json:"pullRequestId"`
+	Title         string   `json:"title"`
+	Description   string   `json:"description"`
+	CreatedBy     identity `json:"createdBy"`
+	SourceRefName string   `json:"sourceRefName"`
+	TargetRefName string   `json:"targetRefName"`
+	Status        string   `json:"status"`
+	Repository    struct {
+		Name    string `json:"name"`
+		Project struct {
+			Name string `json:"name"`
+		} `json:"project"`
+	} `json:"repository"`
+	LastMergeSourceCommit struct {
+		CommitID string `json:"commitId"`
+	} `json:"lastMergeSourceCommit"`
+	LastMergeTargetCommit struct {
+		CommitID string `json:"commitId"`
+	} `json:"lastMergeTargetCommit"`
+}
+
+func (p *Provider) toMergeRequest(project, repo string, pr pullRequest) *vcs.MergeRequest {
+	return &vcs.MergeRequest{
+		IID:          pr.PullRequestID,
+		Title:        pr.Title,
+		Description:  pr.Description,
+		Author:       pr.CreatedBy.login(),
+		SourceBranch: strings.TrimPrefix(pr.SourceRefName, "refs/heads/"),
+		TargetBranch: strings.TrimPrefix(pr.TargetRefName, "refs/heads/"),
+		State:        pr.Status,
+		WebURL: fmt.Sprintf("%s/%s/_git/%s/pullrequest/%d",
+			p.baseURL, url.PathEscape(project), url.PathEscape(repo), pr.PullRequestID),
+		DiffRefs: vcs.DiffRefs{
+			BaseSHA:  pr.LastMergeTargetCommit.CommitID,
+			HeadSHA:  pr.LastMergeSourceCommit.CommitID,
+			StartSHA: pr.LastMergeTargetCommit.CommitID,
+		},
+	}
+}
+
+func (p *Provider) FetchMR(ctx context.Context, projectID string, mrIID int64) (*vcs.MergeRequest, error) {
+	project, repo, err := splitProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	var pr pullRequest
+	if err := p.getJSON(ctx, repoEndpoint(project, repo, fmt.Sprintf("/pullRequests/%d", mrIID)), &pr); err != nil {
+		return nil, fmt.Errorf("azuredevops: failed to fetch PR %d: %w", mrIID, err)
+	}
+	return p.toMergeRequest(project, repo, pr), nil
+}
+
+// FetchMRDiffs returns the changes of the latest PR iteration. Azure DevOps
+// does not expose unified patches over REST, so each file's content is read
+// at the iteration's merge base and source commits and diffed locally.
+func (p *Provider) FetchMRDiffs(ctx context.Context, projectID string, mrIID int64) ([]vcs.FileDiff, error) {
+	project, repo, err := splitProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var iterations struct {
+		Value []struct {
+			ID              int64 `json:"id"`
+			SourceRefCommit struct {
+				CommitID string `json:"commitId"`
+			} `json:"sourceRefCommit"`
+			TargetRefCommit struct {
+				CommitID string `json:"commitId"`
+			} `json:"targetRefCommit"`
+			CommonRefCommit struct {
+				CommitID string `json:"commitId"`
+			} `json:"commonRefCommit"`
+		} `json:"value"`
+	}
+	if err := p.getJSON(ctx, repoEndpoint(project, repo, fmt.Sprintf("/pullRequests/%d/iterations", mrIID)), &iterations); err != nil {
+		return nil, fmt.Errorf("azuredevops: failed to list PR iterations: %w", err)
+	}
+	if len(iterations.Value) == 0 {
+		return nil, nil
+	}
+	latest := iterations.Value[len(iterations.Value)-1]
+	head := latest.SourceRefCommit.CommitID
+	base := latest.CommonRefCommit.CommitID
+	if base == "" {
+		base = latest.TargetRefCommit.CommitID
+	}
+	if head == "" || base == "" {
+		return nil, fmt.Errorf("azuredevops: PR iteration %d does not report its source and target commits", latest.ID)
+	}
+
+	entries, err := p.listIterationChanges(ctx, project, repo, mrIID, latest.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []vcs.FileDiff
+	for _, c := range entries {
+		if c.Item.IsFolder {
+			continue
+		}
+		newPath := strings.TrimPrefix(c.Item.Path, "/")
+		oldPath := strings.TrimPrefix(c.OriginalPath, "/")
+		if oldPath == "" {
+			oldPath = newPath
+		}
+		changeType := strings.ToLower(c.ChangeType)
+		fd := vcs.FileDiff{
+			OldPath:     oldPath,
+			NewPath:     newPath,
+			NewFile:     strings.Contains(changeType, "add"),
+			DeletedFile: strings.Contains(changeType, "delete"),
+			RenamedFile: strings.Contains(changeType, "rename"),
+		}
+		var before, after fileContent
+		if !fd.NewFile {
+			if before, err = p.fetchFileContent(ctx, project, repo, oldPath, base); err != nil {
+				return nil, err
+			}
+		}
+		if !fd.DeletedFile {
+			if after, err = p.fetchFileContent(ctx, project, repo, newPath, head); err != nil {
+				return nil, err
+			}
+		}
+		if before.Binary || after.Binary {
+			fd.Diff = fmt.Sprintf("Binary files a/%s and b/%s differ\n", oldPath, newPath)
+		} else if fd.Diff, err = unifiedDiff(before.Text, after.Text); err != nil {
+			return nil, fmt.Errorf("azuredevops: failed to diff %s: %w", newPath, err)
+		}
+		out = append(out, fd)
+	}
+	return out, nil
+}
+
+type changeEntry struct {
+	ChangeType   string `json:"changeType"`
+	OriginalPath string `json:"originalPath"`
+	Item         struct {
+		Path     string `json:"path"`
+		IsFolder bool   `json:"isFolder"`
+	} `json:"item"`
+}
+
+// listIterationChanges pages through the change entries of a PR iteration.
+func (p *Provider) listIterationChanges(ctx context.Context, project, repo string, mrIID, iteration int64) ([]changeEntry, error) {
+	var out []changeEntry
+	skip := 0
+	for {
+		var page struct {
+			ChangeEntries []changeEntry `json:"changeEntries"`
+			NextSkip      int           `json:"nextSkip"`
+			NextTop       int           `json:"nextTop"`
+		}
+		endpoint := repoEndpoint(project, repo, fmt.Sprintf("/pullRequests/%d/iterations/%d/changes", mrIID, iteration))
+		if skip > 0 {
+			endpoint += fmt.Sprintf("&$skip=%d", skip)
+		}
+		if err := p.getJSON(ctx, endpoint, &page); err != nil {
+			return nil, fmt.Errorf("azuredevops: failed to list PR changes: %w", err)
+		}
+		out = append(out, page.ChangeEntries...)
+		if page.NextTop <= 0 || page.NextSkip <= skip {
+			return out, nil
+		}
+		skip = page.NextSkip
+	}
+}
+
+type fileContent struct {
+	Text   string
+	Binary bool
+}
+
+// fetchFileContent reads path at commit through the items API.
+func (p *Provider) fetchFileContent(ctx context.Context, project, repo, path, commit string) (fileContent, error) {
+	var item struct {
+		Content         string `json:"content"`
+		ContentMetadata struct {
+			IsBinary bool `json:"isBinary"`
+		} `json:"contentMetadata"`
+	}
+	endpoint := repoEndpoint(project, repo, "/items") +
+		"&path=" + url.QueryEscape("/"+path) +
+		"&versionDescriptor.version=" + url.QueryEscape(commit) +
+		"&versionDescriptor.versionType=commit&includeContent=true"
+	if err := p.getJSON(ctx, endpoint, &item); err != nil {
+		return fileContent{}, fmt.Errorf("azuredevops: failed to fetch %s at %s: %w", path, commit, err)
+	}
+	return fileContent{Text: item.Content, Binary: item.ContentMetadata.IsBinary}, nil
+}
+
+// unifiedDiff renders the hunks turning before into after, without file
+// headers, the way GitLab reports a file's diff.
+func unifiedDiff(before, after string) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:       splitContentLines(before),
+		B:       splitContentLines(after),
+		Context: 3,
+	})
+}
+
+// splitContentLines splits content into newline-terminated lines, as the
+// diff output expects. A missing final newline is not reported.
+func splitContentLines(content string) []string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if content == "" {
+		return nil
+	}
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	lines := strings.SplitAfter(content, "\n")
+	return lines[:len(lines)-1]
+}
+
+// FetchMRRawDiff assembles a git-style diff from FetchMRDiffs.
+func (p *Provider) FetchMRRawDiff(ctx context.Context, projectID string, mrIID int64) (string, error) {
+	diffs, err := p.FetchMRDiffs(ctx, projectID, mrIID)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, d := range diffs {
+		fmt.Fprintf(&b, "diff --git a/%s b/%s\n", d.OldPath, d.NewPath)
+		if d.RenamedFile {
+			fmt.Fprintf(&b, "rename from %s\nrename to %s\n", d.OldPath, d.NewPath)
+		}
+		if strings.HasPrefix(d.Diff, "Binary files ") {
+			b.WriteString(d.Diff)
+			continue
+		}
+		if d.Diff == "" {
+			continue
+		}
+		oldName, newName := "a/"+d.OldPath, "b/"+d.NewPath
+		if d.NewFile {
+			oldName = "/dev/null"
+		}
+		if d.DeletedFile {
+			newName = "/dev/null"
+		}
+		fmt.Fprintf(&b, "--- %s\n+++ %s\n%s", oldName, newName, d.Diff)
+	}
+	return b.String(), nil
+}
+
+type thread struct {
+	ID            int64  `json:"id"`
+	Status        string `json:"status"`
+	IsDeleted     bool   `json:"isDeleted"`
+	ThreadContext *struct {
+		FilePath       string `json:"filePath"`
+		RightFileStart *struct {
+			Line int `json:"line"`
+		} `json:"rightFileStart"`
+		LeftFileStart *struct {
+			Line int `json:"line"`
+		} `json:"leftFileStart"`
+	} `json:"threadContext"`
+	Comments []struct {
+		ID          int64    `json:"id"`
+		Content     string   `json:"content"`
+		CommentType string   `json:"commentType"`
+		IsDeleted   bool     `json:"isDeleted"`
+		Author      identity `json:"author"`
+	} `json:"comments"`
+}
+
+func (t thread) anchor() (string, int, bool) {
+	if t.ThreadContext == nil {
+		return "", 0, false
+	}
+	line := 0
+	onDeleted := false
+	if t.ThreadContext.RightFileStart != nil {
+		line = t.ThreadContext.RightFileStart.Line
+	} else if t.ThreadContext.LeftFileStart != nil {
+		line = t.ThreadContext.LeftFileStart.Line
+		onDeleted = true
+	}
+	return strings.TrimPrefix(t.ThreadContext.FilePath, "/"), line, onDeleted
+}
+
+// threadResolved maps Azure thread status onto a resolved flag. Active and
+// pending threads are open; fixed, closed, byDesign and wontFix are resolved.
+func threadResolved(status string) bool {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "fixed", "closed", "bydesign", "wontfix":
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *Provider) listThreads(ctx context.Context, projectID string, mrIID int64) ([]thread, error) {
+	project, repo, err := splitProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Value []thread `json:"value"`
+	}
+	if err := p.getJSON(ctx, repoEndpoint(project, repo, fmt.Sprintf("/pullRequests/%d/threads", mrIID)), &resp); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(resp.Value, func(i, j int) bool { return resp.Value[i].ID < resp.Value[j].ID })
+	return resp.Value, nil
+}
+
+func (p *Provider) ListMRDiscussions(ctx context.Context, projectID string, mrIID int64) ([]vcs.MRDiscussion, error) {
+	threads, err := p.listThreads(ctx, projectID, mrIID)
+	if err != nil {
+		return nil, fmt.Errorf("azuredevops: failed to list PR threads: %w", err)
+	}
+
+	var out []vcs.MRDiscussion
+	for _, t := range threads {
+		if t.IsDeleted {
+			continue
+		}
+		path, line, onDeleted := t.anchor()
+		if path == "" {
+			continue
+		}
+		resolvable := strings.TrimSpace(t.Status) != ""
+		resolved := threadResolved(t.Status)
+		d := vcs.MRDiscussion{ID: strconv.FormatInt(t.ID, 10)}
+		for _, c := range t.Comments {
+			if c.IsDeleted || strings.EqualFold(c.CommentType, "system") {
+				continue
+			}
+			d.Notes = append(d.Notes, vcs.MRDiscussionNote{
+				ID:         c.ID,
+				Author:     c.Author.login(),
+				Body:       c.Content,
+				FilePath:   path,
+				Line:       line,
+				OnDeleted:  onDeleted,
+				Resolvable: resolvable,
+				Resolved:   resolved,
+			})
+		}
+		if len(d.Notes) > 0 {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+// ListMRNotes returns comments from threads without a file context, which is
+// how Azure DevOps models top-level PR comments.
+func (p *Provider) ListMRNotes(ctx context.Context, projectID string, mrIID int64) ([]vcs.MRNote, error) {
+	threads, err := p.listThreads(ctx, projectID, mrIID)
+	if err != nil {
+		return nil, fmt.Errorf("azuredevops: failed to list PR notes: %w", err)
+	}
+
+	var out []vcs.MRNote
+	for _, t := range threads {
+		if t.IsDeleted {
+			continue
+		}
+		if path, _, _ := t.anchor(); path != "" {
+			continue
+		}
+		for _, c := range t.Comments {
+			if c.IsDeleted || strings.EqualFold(c.CommentType, "system") {
+				continue
+			}
+			out = append(out, vcs.MRNote{
+				ID:     noteID(t.ID, c.ID),
+				Author: c.Author.login(),
+				Body:   c.Content,
+			})
+		}
+	}
+	return out, nil
+}
+
+// noteID packs a thread ID and a comment ID into one note ID. Azure thread
+// IDs are 32-bit and comment IDs 16-bit, so putting the thread ID in the
+// high half keeps note IDs unique across threads.
+func noteID(threadID, commentID int64) int64 {
+	return threadID<<32 | commentID&0xffffffff
+}
+
+func (p *Provider) ListOpenMRs(ctx context.Context, projectID string) ([]*vcs.MergeRequest, error) {
+	project, repo, err := splitProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Value []pullRequest `json:"value"`
+	}
+	endpoint := repoEndpoint(project, repo, "/pullrequests") + "&searchCriteria.status=active&$top=20"
+	if err := p.getJSON(ctx, endpoint, &resp); err != nil {
+		return nil, fmt.Errorf("azuredevops: failed to list PRs: %w", err)
+	}
+
+	var result []*vcs.MergeRequest
+	for _, pr := range resp.Value {
+		mr := p.toMergeRequest(project, repo, pr)
+		mr.Description = ""
+		mr.DiffRefs = vcs.DiffRefs{}
+		result = append(result, mr)
+	}
+	return result, nil
+}
+
+func (p *Provider) PostSummaryNote(ctx context.Context, projectID string, mrIID int64, body string) error {
+	project, repo, err := splitProjectID(projectID)
+	if err != nil {
+		return err
+	}
+	payload := map[string]interface{}{
+		"comments": []map[string]interface{}{
+			{"parentCommentId": 0, "content": body, "commentType": "text"},
+		},
+	}
+	if err := p.postJSON(ctx, repoEndpoint(project, repo, fmt.Sprintf("/pullRequests/%d/threads", mrIID)), payload, nil); err != nil {
+		return fmt.Errorf("azuredevops: failed to post PR summary: %w", err)
+	}
+	return nil
+}
+
+func (p *Provider) PostInlineComment(ctx context.Context, projectID string, mrIID int64, refs vcs.DiffRefs, comment vcs.InlineComment) error {
+	project, repo, err := splitProjectID(projectID)
+	if err != nil {
+		return err
+	}
+	// Removed lines are addressed on the left (base) side of the diff.
+	side, line := "right", comment.NewLine
+	if comment.OnDeleted {
+		side, line = "left", comment.OldLine
+	}
+	if line <= 0 {
+		return fmt.Errorf("azuredevops: invalid line number for inline comment")
+	}
+
+	payload := map[string]interface{}{
+		"comments": []map[string]interface{}{
+			{"parentCommentId": 0, "content": comment.Body, "commentType": "text"},
+		},
+		"status": "active",
+		"threadContext": map[string]interface{}{
+			"filePath":         "/" + strings.TrimPrefix(comment.FilePath, "/"),
+			side + "FileStart": map[string]int64{"line": line, "offset": 1},
+			side + "FileEnd":   map[string]int64{"line": line, "offset": 1},
+		},
+	}
+	if err := p.postJSON(ctx, repoEndpoint(project, repo, fmt.Sprintf("/pullRequests/%d/threads", mrIID)), payload, nil); err != nil {
+		return fmt.Errorf("azuredevops: failed to post inline comment: %w", err)
+	}
+	return nil
+}
+
+// StartMRDiscussion opens an active PR thread without a file context, which
+// reviewers can resolve like any inline thread.
+func (p *Provider) StartMRDiscussion(ctx context.Context, projectID string, mrIID int64, body string) error {
+	project, repo, err := splitProjectID(projectID)
+	if err != nil {
+		return err
+	}
+	payload := map[string]interface{}{
+		"comments": []map[string]interface{}{
+			{"parentCommentId": 0, "content": body, "commentType": "text"},
+		},
+		"status": "active",
+	}
+	if err := p.postJSON(ctx, repoEndpoint(project, repo, fmt.Sprintf("/pullRequests/%d/threads", mrIID)), payload, nil); err != nil {
+		return fmt.Errorf("azuredevops: failed to start PR thread: %w", err)
+	}
+	return nil
+}
+
+func (p *Provider) ReplyToMRDiscussion(ctx context.Context, projectID string, mrIID int64, discussionID, body string) error {
+	project, repo, err := splitProjectID(projectID)
+	if err != nil {
+		return err
+	}
+	threadID, err := strconv.ParseInt(strings.TrimSpace(discussionID), 10, 64)
+	if err != nil || threadID <= 0 {
+		return fmt.Errorf("azuredevops: invalid thread id %q for reply", discussionID)
+	}
+	// Replies hang off the thread's first comment, whose ID is not always 1.
+	var t thread
+	if err := p.getJSON(ctx, repoEndpoint(project, repo, fmt.Sprintf("/pullRequests/%d/threads/%d", mrIID, threadID)), &t); err != nil {
+		return fmt.Errorf("azuredevops: failed to read thread %s: %w", discussionID, err)
+	}
+	if len(t.Comments) == 0 {
+		return fmt.Errorf("azuredevops: thread %s has no comment to reply to", discussionID)
+	}
+	payload := map[string]interface{}{
+		"parentCommentId": t.Comments[0].ID,
+		"content":         body,
+		"commentType":     "text",
+	}
+	endpoint := repoEndpoint(project, repo, fmt.Sprintf("/pullRequests/%d/threads/%d/comments", mrIID, threadID))
+	if err := p.postJSON(ctx, endpoint, payload, nil); err != nil {
+		return fmt.Errorf("azuredevops: failed to reply to thread %s: %w", discussionID, err)
+	}
+	return nil
+}
+
+// ResolveMRDiscussion sets a pull request thread's status to fixed.
+func (p *Provider) ResolveMRDiscussion(ctx context.Context, projectID string, mrIID int64, discussionID string) error {
+	project, repo, err := splitProjectID(projectID)
+	if err != nil {
+		return err
+	}
+	threadID, err := strconv.ParseInt(strings.TrimSpace(discussionID), 10, 64)
+	if err != nil || threadID <= 0 {
+		return fmt.Errorf("azuredevops: invalid thread id %q to resolve", discussionID)
+	}
+	payload := map[string]interface{}{"status": "fixed"}
+	endpoint := repoEndpoint(project, repo, fmt.Sprintf("/pullRequests/%d/threads/%d", mrIID, threadID))
+	if err := p.sendJSON(ctx, http.MethodPatch, endpoint, payload, nil); err != nil {
+		return fmt.Errorf("azuredevops: failed to resolve thread %s: %w", discussionID, err)
+	}
+	return nil
+}
+
+// CurrentUser returns the account name (unique name or mail address) of the
+// token's owner from the organization's connection data. A response without
+// one is an anonymous connection, so the token is rejected.
+func (p *Provider) CurrentUser(ctx context.Context) (string, error) {
+	type property struct {
+		Value string `json:"$value"`
+	}
+	var data struct {
+		AuthenticatedUser struct {
+			UniqueName  string `json:"uniqueName"`
+			MailAddress string `json:"mailAddress"`
+			Properties  struct {
+				Account property `json:"Account"`
+				Mail    property `json:"Mail"`
+			} `json:"properties"`
+		} `json:"authenticatedUser"`
+	}
+	if err := p.getJSON(ctx, "/_apis/connectionData", &data); err != nil {
+		return "", fmt.Errorf("azuredevops: failed to fetch connection data: %w", err)
+	}
+	u := data.AuthenticatedUser
+	for _, login := range []string{u.UniqueName, u.MailAddress, u.Properties.Account.Value, u.Properties.Mail.Value} {
+		if login = strings.TrimSpace(login); login != "" {
+			return login, nil
+		}
+	}
+	return "", fmt.Errorf("azuredevops: connection data has no account name; the token was not accepted")
+}
+
+// FormatSuggestionBlock returns an Azure Repos suggestion code block.
+func (p *Provider) FormatSuggestionBlock(suggestion string) string {
+	return "```suggestion\n" + suggestion + "\n```"
+}
+
+// splitProjectID parses "<project>/<repository>". A single segment is used as
+// both project and repository name, matching Azure's default repository.
+func splitProjectID(projectID string) (string, string, error) {
+	projectID = strings.Trim(strings.TrimSpace(projectID), "/")
+	if projectID == "" {
+		return "", "", fmt.Errorf("azuredevops: project id is required (expected <project>/<repository>)")
+	}
+	parts := strings.SplitN(projectID, "/", 2)
+	if len(parts) == 1 {
+		return parts[0], parts[0], nil
+	}
+	if parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("azuredevops: invalid project id %q (expected <project>/<repository>)", projectID)
+	}
+	return parts[0], parts[1], nil
+}
+
+func repoEndpoint(project, repo, suffix string) string {
+	return fmt.Sprintf("/%s/_apis/git/repositories/%s%s?api-version=%s",
+		url.PathEscape(project), url.PathEscape(repo), suffix, apiVersion)
+}
+
+func (p *Provider) getJSON(ctx context.Context, endpoint string, out interface{}) error {
+	req, err := p.newRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := vcs.DoWithRetry(p.client, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return vcs.NewHTTPError("azuredevops", resp, body)
+	}
+
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+func (p *Provider) postJSON(ctx context.Context, endpoint string, payload interface{}, out interface{}) error {
+	return p.sendJSON(ctx, http.MethodPost, endpoint, payload, out)
+}
+
+func (p *Provider) sendJSON(ctx context.Context, method, endpoint string, payload interface{}, out interface{}) error {
+	var buf io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		buf = bytes.NewReader(data)
+	}
+
+	req, err := p.newRequest(ctx, method, endpoint, buf)
+	if err != nil {
+		return err
+	}
+	resp, err := vcs.DoWithRetry(p.client, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return vcs.NewHTTPError("azuredevops", resp, body)
+	}
+
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+func (p *Provider) newRequest(ctx context.Context, method, endpoint string, body io.Reader) (*http.Request, error) {
+	u, err := url.Parse(p.baseURL + endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "prev-cli")
+	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(":"+p.token)))
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
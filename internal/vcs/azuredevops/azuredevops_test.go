@@ -0,0 +1,283 @@
+package azuredevops
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sanix-darker/prev/internal/vcs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvider_FetchMRAndDiffs(t *testing.T) {
+	var gotAuth, gotAPIVersion string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotAPIVersion = r.URL.Query().Get("api-version")
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/acme/_apis/git/repositories/blog/pullRequests/42":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"pullRequestId":         42,
+				"title":                 "Add recipe endpoints",
+				"description":           "Adds API endpoints for posts.",
+				"createdBy":             map[string]interface{}{"displayName": "Octo Cat", "uniqueName": "octo@example.com"},
+				"sourceRefName":         "refs/heads/feature",
+				"targetRefName":         "refs/heads/main",
+				"status":                "active",
+				"lastMergeSourceCommit": map[string]interface{}{"commitId": "headsha"},
+				"lastMergeTargetCommit": map[string]interface{}{"commitId": "basesha"},
+			})
+		case "/acme/_apis/git/repositories/blog/pullRequests/42/iterations":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"value": []map[string]interface{}{
+					{"id": 1},
+					{
+						"id":              2,
+						"sourceRefCommit": map[string]interface{}{"commitId": "headsha"},
+						"targetRefCommit": map[string]interface{}{"commitId": "mainsha"},
+						"commonRefCommit": map[string]interface{}{"commitId": "basesha"},
+					},
+				},
+			})
+		case "/acme/_apis/git/repositories/blog/pullRequests/42/iterations/2/changes":
+			if r.URL.Query().Get("$skip") == "" {
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{
+					"changeEntries": []map[string]interface{}{
+						{"changeType": "edit", "item": map[string]interface{}{"path": "/src/app.go"}},
+						{"changeType": "edit", "item": map[string]interface{}{"path": "/src", "isFolder": true}},
+						{"changeType": "rename, edit", "originalPath": "/old.go", "item": map[string]interface{}{"path": "/new.go"}},
+					},
+					"nextSkip": 3,
+					"nextTop":  100,
+				})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"changeEntries": []map[string]interface{}{
+					{"changeType": "add", "item": map[string]interface{}{"path": "/added.go"}},
+				},
+			})
+		case "/acme/_apis/git/repositories/blog/items":
+			q := r.URL.Query()
+			assert.Equal(t, "commit", q.Get("versionDescriptor.versionType"))
+			contents := map[string]string{
+				"/src/app.go@basesha": "package app\n\nfunc Run() {}\n",
+				"/src/app.go@headsha": "package app\n\nfunc Run() error { return nil }\n",
+				"/old.go@basesha":     "package old\n",
+				"/new.go@headsha":     "package renamed\n",
+				"/added.go@headsha":   "package added\n",
+			}
+			content, ok := contents[q.Get("path")+"@"+q.Get("versionDescriptor.version")]
+			require.True(t, ok, "unexpected item %s@%s", q.Get("path"), q.Get("versionDescriptor.version"))
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"content": content})
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	p, err := NewProvider("pat-123", server.URL)
+	require.NoError(t, err)
+
+	mr, err := p.FetchMR(context.Background(), "acme/blog", 42)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), mr.IID)
+	assert.Equal(t, "Add recipe endpoints", mr.Title)
+	assert.Equal(t, "octo@example.com", mr.Author)
+	assert.Equal(t, "feature", mr.SourceBranch)
+	assert.Equal(t, "main", mr.TargetBranch)
+	assert.Equal(t, "headsha", mr.DiffRefs.HeadSHA)
+	assert.Equal(t, "basesha", mr.DiffRefs.BaseSHA)
+	assert.Equal(t, server.URL+"/acme/_git/blog/pullrequest/42", mr.WebURL)
+	assert.Equal(t, "Basic OnBhdC0xMjM=", gotAuth)
+	assert.Equal(t, apiVersion, gotAPIVersion)
+
+	diffs, err := p.FetchMRDiffs(context.Background(), "acme/blog", 42)
+	require.NoError(t, err)
+	require.Len(t, diffs, 3)
+	assert.Equal(t, "src/app.go", diffs[0].NewPath)
+	assert.Equal(t, "@@ -1,3 +1,3 @@\n package app\n \n-func Run() {}\n+func Run() error { return nil }\n", diffs[0].Diff)
+	assert.True(t, diffs[1].RenamedFile)
+	assert.Equal(t, "old.go", diffs[1].OldPath)
+	assert.Equal(t, "@@ -1 +1 @@\n-package old\n+package renamed\n", diffs[1].Diff)
+	assert.True(t, diffs[2].NewFile)
+	assert.Equal(t, "@@ -0,0 +1 @@\n+package added\n", diffs[2].Diff)
+
+	raw, err := p.FetchMRRawDiff(context.Background(), "acme/blog", 42)
+	require.NoError(t, err)
+	assert.Contains(t, raw, "diff --git a/added.go b/added.go\n--- /dev/null\n+++ b/added.go\n@@ -0,0 +1 @@\n+package added\n")
+	assert.Contains(t, raw, "rename from old.go\nrename to new.go\n")
+}
+
+func TestProvider_ValidateChecksToken(t *testing.T) {
+	status := http.StatusUnauthorized
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/_apis/connectionData", r.URL.Path)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"authenticatedUser": {"providerDisplayName": "Octo Cat", "properties": {"Account": {"$type": "System.String", "$value": "octo@example.com"}}}}`))
+	}))
+	defer server.Close()
+
+	p, err := NewProvider("pat", server.URL)
+	require.NoError(t, err)
+	assert.Error(t, p.Validate())
+
+	status = http.StatusOK
+	assert.NoError(t, p.Validate())
+	login, err := p.(vcs.AuthChecker).CurrentUser(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "octo@example.com", login, "the login, not the display name")
+}
+
+func TestProvider_CurrentUserPrefersUniqueName(t *testing.T) {
+	body := `{"authenticatedUser": {"providerDisplayName": "Octo Cat", "uniqueName": "octo@contoso.com", "mailAddress": "cat@contoso.com"}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	p, err := NewProvider("pat", server.URL)
+	require.NoError(t, err)
+	login, err := p.(vcs.AuthChecker).CurrentUser(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "octo@contoso.com", login)
+
+	body = `{"authenticatedUser": {"providerDisplayName": "Anonymous"}}`
+	_, err = p.(vcs.AuthChecker).CurrentUser(context.Background())
+	assert.Error(t, err, "an anonymous connection has no account name")
+}
+
+func TestNoteID_UniqueAcrossThreads(t *testing.T) {
+	assert.NotEqual(t, noteID(1, 1000), noteID(2, 0))
+	assert.NotEqual(t, noteID(1, 1), noteID(2, 1))
+	assert.Equal(t, noteID(3, 2), noteID(3, 2))
+}
+
+func TestProvider_ThreadsTranslateToDiscussionsAndNotes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/acme/_apis/git/repositories/blog/pullRequests/7/threads", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"value": []map[string]interface{}{
+				{
+					"id":     11,
+					"status": "fixed",
+					"threadContext": map[string]interface{}{
+						"filePath":       "/src/app.go",
+						"rightFileStart": map[string]interface{}{"line": 12, "offset": 1},
+					},
+					"comments": []map[string]interface{}{
+						{"id": 1, "content": "nil check missing", "commentType": "text", "author": map[string]interface{}{"uniqueName": "bot"}},
+						{"id": 2, "content": "status changed", "commentType": "system"},
+					},
+				},
+				{
+					"id":     12,
+					"status": "active",
+					"comments": []map[string]interface{}{
+						{"id": 1, "content": "summary", "commentType": "text", "author": map[string]interface{}{"displayName": "Reviewer"}},
+					},
+				},
+				{
+					"id":        13,
+					"isDeleted": true,
+					"threadContext": map[string]interface{}{
+						"filePath": "/src/app.go",
+					},
+					"comments": []map[string]interface{}{{"id": 1, "content": "gone"}},
+				},
+			},
+		})
+	}))
+	defer server.Close()
+
+	p, err := NewProvider("pat", server.URL)
+	require.NoError(t, err)
+
+	discussions, err := p.ListMRDiscussions(context.Background(), "acme/blog", 7)
+	require.NoError(t, err)
+	require.Len(t, discussions, 1)
+	assert.Equal(t, "11", discussions[0].ID)
+	require.Len(t, discussions[0].Notes, 1)
+	note := discussions[0].Notes[0]
+	assert.Equal(t, "src/app.go", note.FilePath)
+	assert.Equal(t, 12, note.Line)
+	assert.True(t, note.Resolvable)
+	assert.True(t, note.Resolved)
+	assert.Equal(t, "bot", note.Author)
+
+	notes, err := p.ListMRNotes(context.Background(), "acme/blog", 7)
+	require.NoError(t, err)
+	require.Len(t, notes, 1)
+	assert.Equal(t, "summary", notes[0].Body)
+	assert.Equal(t, "Reviewer", notes[0].Author)
+}
+
+func TestProvider_PostInlineCommentAndReply(t *testing.T) {
+	var inlinePayload map[string]interface{}
+	var replyPayload map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			require.Equal(t, "/acme/_apis/git/repositories/blog/pullRequests/42/threads/11", r.URL.Path)
+			_, _ = w.Write([]byte(`{"id": 11, "comments": [{"id": 7, "content": "Handle the error."}, {"id": 9, "content": "Why?"}]}`))
+			return
+		}
+		require.Equal(t, http.MethodPost, r.Method)
+		body, _ := io.ReadAll(r.Body)
+		defer r.Body.Close()
+		switch r.URL.Path {
+		case "/acme/_apis/git/repositories/blog/pullRequests/42/threads":
+			require.NoError(t, json.Unmarshal(body, &inlinePayload))
+		case "/acme/_apis/git/repositories/blog/pullRequests/42/threads/11/comments":
+			require.NoError(t, json.Unmarshal(body, &replyPayload))
+		default:
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"id": 1}`))
+	}))
+	defer server.Close()
+
+	p, err := NewProvider("pat", server.URL)
+	require.NoError(t, err)
+
+	err = p.PostInlineComment(context.Background(), "acme/blog", 42, vcs.DiffRefs{}, vcs.InlineComment{
+		FilePath: "src/app.go",
+		NewLine:  12,
+		Body:     "Handle the error.",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "active", inlinePayload["status"])
+	threadContext := inlinePayload["threadContext"].(map[string]interface{})
+	assert.Equal(t, "/src/app.go", threadContext["filePath"])
+	assert.Equal(t, float64(12), threadContext["rightFileStart"].(map[string]interface{})["line"])
+	comments := inlinePayload["comments"].([]interface{})
+	require.Len(t, comments, 1)
+	assert.Equal(t, "Handle the error.", comments[0].(map[string]interface{})["content"])
+
+	require.NoError(t, p.ReplyToMRDiscussion(context.Background(), "acme/blog", 42, "11", "Thanks, fixed."))
+	assert.Equal(t, "Thanks, fixed.", replyPayload["content"])
+	assert.Equal(t, float64(7), replyPayload["parentCommentId"], "replies go to the thread's first comment")
+}
+
+func TestSplitProjectID(t *testing.T) {
+	project, repo, err := splitProjectID("acme/blog")
+	require.NoError(t, err)
+	assert.Equal(t, "acme", project)
+	assert.Equal(t, "blog", repo)
+
+	project, repo, err = splitProjectID("acme")
+	require.NoError(t, err)
+	assert.Equal(t, "acme", project)
+	assert.Equal(t, "acme", repo)
+
+	_, _, err = splitProjectID("")
+	assert.Error(t, err)
+}
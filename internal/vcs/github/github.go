@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -103,9 +104,9 @@ func (p *Provider) FetchMRDiffs(ctx context.Context, projectID string, mrIID int
 	}
 
 	var all []vcs.FileDiff
-	page := 1
+	pager := vcs.NewPager("github", "PR files")
 	for {
-		endpoint := fmt.Sprintf("/repos/%s/pulls/%d/files?per_page=100&page=%d", projectID, mrIID, page)
+		endpoint := fmt.Sprintf("/repos/%s/pulls/%d/files?per_page=100&page=%d", projectID, mrIID, pager.Page)
 		var files []prFile
 		resp, err := p.getJSONWithResponse(ctx, endpoint, &files)
 		if err != nil {
@@ -130,10 +131,9 @@ func (p *Provider) FetchMRDiffs(ctx context.Context, projectID string, mrIID int
 			})
 		}
 
-		if !hasNextPage(resp.Header.Get("Link")) {
+		if !pager.Next(len(files), hasNextPage(resp.Header.Get("Link"))) {
 			break
 		}
-		page++
 	}
 
 	return all, nil
@@ -150,7 +150,7 @@ func (p *Provider) FetchMRRawDiff(ctx context.Context, projectID string, mrIID i
 	// Ask GitHub to return the raw diff.
 	req.Header.Set("Accept", "application/vnd.github.v3.diff")
 
-	resp, err := p.client.Do(req)
+	resp, err := vcs.DoWithRetry(p.client, req)
 	if err != nil {
 		return "", err
 	}
@@ -158,7 +158,7 @@ func (p *Provider) FetchMRRawDiff(ctx context.Context, projectID string, mrIID i
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("github: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		return "", vcs.NewHTTPError("github", resp, body)
 	}
 
 	raw, err := io.ReadAll(resp.Body)
@@ -168,7 +168,195 @@ func (p *Provider) FetchMRRawDiff(ctx context.Context, projectID string, mrIID i
 	return strings.TrimSpace(string(raw)), nil
 }
 
+// MergeBase resolves the merge-base of base and head through the compare API.
+func (p *Provider) MergeBase(ctx context.Context, projectID, baseSHA, headSHA string) (string, error) {
+	var cmp struct {
+		MergeBaseCommit struct {
+			SHA string `json:"sha"`
+		} `json:"merge_base_commit"`
+	}
+	endpoint := fmt.Sprintf("/repos/%s/compare/%s...%s", projectID, url.PathEscape(baseSHA), url.PathEscape(headSHA))
+	if err := p.getJSON(ctx, endpoint, &cmp); err != nil {
+		return "", fmt.Errorf("github: failed to compare %s...%s: %w", baseSHA, headSHA, err)
+	}
+	if cmp.MergeBaseCommit.SHA == "" {
+		return "", fmt.Errorf("github: compare %s...%s returned no merge base", baseSHA, headSHA)
+	}
+	return cmp.MergeBaseCommit.SHA, nil
+}
+
+// compareFileLimit is the most files GitHub lists for one comparison. The
+// files are not paginated, so a compare that reaches it is truncated.
+const compareFileLimit = 300
+
+// CompareDiffs returns the per-file patches between two commits from the
+// compare endpoint. GitHub omits the patch of binary and very large files;
+// those come back with an empty Diff. A comparison that reaches
+// compareFileLimit is an error rather than a silently partial diff.
+func (p *Provider) CompareDiffs(ctx context.Context, projectID, fromSHA, toSHA string) ([]vcs.FileDiff, error) {
+	var cmp struct {
+		Files []struct {
+			Filename         string `json:"filename"`
+			PreviousFilename string `json:"previous_filename"`
+			Status           string `json:"status"`
+			Patch            string `json:"patch"`
+		} `json:"files"`
+	}
+	endpoint := fmt.Sprintf("/repos/%s/compare/%s...%s", projectID, url.PathEscape(fromSHA), url.PathEscape(toSHA))
+	if err := p.getJSON(ctx, endpoint, &cmp); err != nil {
+		return nil, fmt.Errorf("github: failed to compare %s...%s: %w", fromSHA, toSHA, err)
+	}
+	if len(cmp.Files) >= compareFileLimit {
+		return nil, fmt.Errorf("github: compare %s...%s lists %d files, the most GitHub returns, so the comparison is truncated",
+			fromSHA, toSHA, len(cmp.Files))
+	}
+	out := make([]vcs.FileDiff, 0, len(cmp.Files))
+	for _, f := range cmp.Files {
+		oldPath := f.PreviousFilename
+		if oldPath == "" {
+			oldPath = f.Filename
+		}
+		status := strings.ToLower(f.Status)
+		out = append(out, vcs.FileDiff{
+			OldPath:     oldPath,
+			NewPath:     f.Filename,
+			Diff:        f.Patch,
+			NewFile:     status == "added",
+			DeletedFile: status == "removed",
+			RenamedFile: status == "renamed",
+		})
+	}
+	return out, nil
+}
+
+// ListMRDiscussions lists PR review threads with their real resolution state
+// from the GraphQL API. If that call fails it falls back to the REST review
+// comments endpoint, which has no resolution state: every thread is then
+// reported as resolvable and unresolved.
 func (p *Provider) ListMRDiscussions(ctx context.Context, projectID string, mrIID int64) ([]vcs.MRDiscussion, error) {
+	if owner, name, ok := strings.Cut(projectID, "/"); ok && owner != "" && name != "" {
+		out, err := p.listReviewThreads(ctx, owner, name, mrIID)
+		if err == nil {
+			return out, nil
+		}
+	}
+	return p.listReviewCommentThreads(ctx, projectID, mrIID)
+}
+
+// listReviewThreads reads review threads through the GraphQL reviewThreads
+// connection. Discussion IDs are the root comment's database ID, matching
+// the REST grouping so replies and resolution keep working.
+func (p *Provider) listReviewThreads(ctx context.Context, owner, name string, number int64) ([]vcs.MRDiscussion, error) {
+	const query = `query($owner: String!, $name: String!, $number: Int!, $cursor: String) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      reviewThreads(first: 100, after: $cursor) {
+        pageInfo { hasNextPage endCursor }
+        nodes {
+          isResolved
+          path
+          line
+          originalLine
+          diffSide
+          comments(first: 100) {
+            nodes { databaseId body path line originalLine author { login } }
+          }
+        }
+      }
+    }
+  }
+}`
+	vars := map[string]interface{}{"owner": owner, "name": name, "number": number}
+	var out []vcs.MRDiscussion
+	for {
+		var data struct {
+			Repository struct {
+				PullRequest struct {
+					ReviewThreads struct {
+						PageInfo struct {
+							HasNextPage bool   `json:"hasNextPage"`
+							EndCursor   string `json:"endCursor"`
+						} `json:"pageInfo"`
+						Nodes []struct {
+							IsResolved   bool   `json:"isResolved"`
+							Path         string `json:"path"`
+							Line         *int   `json:"line"`
+							OriginalLine *int   `json:"originalLine"`
+							DiffSide     string `json:"diffSide"`
+							Comments     struct {
+								Nodes []struct {
+									DatabaseID   int64  `json:"databaseId"`
+									Body         string `json:"body"`
+									Path         string `json:"path"`
+									Line         *int   `json:"line"`
+									OriginalLine *int   `json:"originalLine"`
+									Author       *struct {
+										Login string `json:"login"`
+									} `json:"author"`
+								} `json:"nodes"`
+							} `json:"comments"`
+						} `json:"nodes"`
+					} `json:"reviewThreads"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		}
+		if err := p.graphQL(ctx, query, vars, &data); err != nil {
+			return nil, err
+		}
+		threads := data.Repository.PullRequest.ReviewThreads
+		for _, t := range threads.Nodes {
+			if len(t.Comments.Nodes) == 0 {
+				continue
+			}
+			threadLine := firstPositiveLine(t.Line, t.OriginalLine)
+			notes := make([]vcs.MRDiscussionNote, 0, len(t.Comments.Nodes))
+			for _, c := range t.Comments.Nodes {
+				n := vcs.MRDiscussionNote{
+					ID:       c.DatabaseID,
+					Body:     c.Body,
+					FilePath: c.Path,
+					Line:     firstPositiveLine(c.Line, c.OriginalLine),
+					// LEFT is the base side: the thread is on a removed line.
+					OnDeleted: strings.EqualFold(t.DiffSide, "LEFT"),
+					// Every review thread can be resolved; only the state varies.
+					Resolvable: true,
+					Resolved:   t.IsResolved,
+				}
+				if c.Author != nil {
+					n.Author = c.Author.Login
+				}
+				if n.FilePath == "" {
+					n.FilePath = t.Path
+				}
+				if n.Line <= 0 {
+					n.Line = threadLine
+				}
+				notes = append(notes, n)
+			}
+			out = append(out, vcs.MRDiscussion{
+				ID:    strconv.FormatInt(notes[0].ID, 10),
+				Notes: notes,
+			})
+		}
+		if !threads.PageInfo.HasNextPage || threads.PageInfo.EndCursor == "" {
+			return out, nil
+		}
+		vars["cursor"] = threads.PageInfo.EndCursor
+	}
+}
+
+func firstPositiveLine(lines ...*int) int {
+	for _, l := range lines {
+		if l != nil && *l > 0 {
+			return *l
+		}
+	}
+	return 0
+}
+
+// listReviewCommentThreads groups REST review comments into threads by their
+// in_reply_to_id root.
+func (p *Provider) listReviewCommentThreads(ctx context.Context, projectID string, mrIID int64) ([]vcs.MRDiscussion, error) {
 	type reviewComment struct {
 		ID           int64  `json:"id"`
 		InReplyToID  *int64 `json:"in_reply_to_id"`
@@ -176,6 +364,7 @@ func (p *Provider) ListMRDiscussions(ctx context.Context, projectID string, mrII
 		Path         string `json:"path"`
 		Line         int    `json:"line"`
 		OriginalLine int    `json:"original_line"`
+		Side         string `json:"side"`
 		User         struct {
 			Login string `json:"login"`
 		} `json:"user"`
@@ -183,9 +372,9 @@ func (p *Provider) ListMRDiscussions(ctx context.Context, projectID string, mrII
 
 	threads := map[string][]vcs.MRDiscussionNote{}
 	order := make([]string, 0, 64)
-	page := 1
+	pager := vcs.NewPager("github", "PR review comments")
 	for {
-		endpoint := fmt.Sprintf("/repos/%s/pulls/%d/comments?per_page=100&page=%d", projectID, mrIID, page)
+		endpoint := fmt.Sprintf("/repos/%s/pulls/%d/comments?per_page=100&page=%d", projectID, mrIID, pager.Page)
 		var comments []reviewComment
 		resp, err := p.getJSONWithResponse(ctx, endpoint, &comments)
 		if err != nil {
@@ -211,15 +400,15 @@ func (p *Provider) ListMRDiscussions(ctx context.Context, projectID string, mrII
 				Body:       c.Body,
 				FilePath:   c.Path,
 				Line:       line,
+				OnDeleted:  strings.EqualFold(c.Side, "LEFT"),
 				Resolvable: true,
 				Resolved:   false,
 			})
 		}
 
-		if !hasNextPage(resp.Header.Get("Link")) {
+		if !pager.Next(len(comments), hasNextPage(resp.Header.Get("Link"))) {
 			break
 		}
-		page++
 	}
 
 	out := make([]vcs.MRDiscussion, 0, len(order))
@@ -242,9 +431,9 @@ func (p *Provider) ListMRNotes(ctx context.Context, projectID string, mrIID int6
 	}
 
 	var out []vcs.MRNote
-	page := 1
+	pager := vcs.NewPager("github", "PR notes")
 	for {
-		endpoint := fmt.Sprintf("/repos/%s/issues/%d/comments?per_page=100&page=%d", projectID, mrIID, page)
+		endpoint := fmt.Sprintf("/repos/%s/issues/%d/comments?per_page=100&page=%d", projectID, mrIID, pager.Page)
 		var notes []note
 		resp, err := p.getJSONWithResponse(ctx, endpoint, &notes)
 		if err != nil {
@@ -259,10 +448,49 @@ func (p *Provider) ListMRNotes(ctx context.Context, projectID string, mrIID int6
 			})
 		}
 
-		if !hasNextPage(resp.Header.Get("Link")) {
+		if !pager.Next(len(notes), hasNextPage(resp.Header.Get("Link"))) {
+			break
+		}
+	}
+
+	return out, nil
+}
+
+// FetchMRCommits lists the PR's commits, which GitHub returns oldest first.
+func (p *Provider) FetchMRCommits(ctx context.Context, projectID string, mrIID int64) ([]vcs.Commit, error) {
+	type apiCommit struct {
+		SHA    string `json:"sha"`
+		Commit struct {
+			Message string `json:"message"`
+			Author  struct {
+				Name string `json:"name"`
+			} `json:"author"`
+		} `json:"commit"`
+	}
+
+	var out []vcs.Commit
+	pager := vcs.NewPager("github", "PR commits")
+	for {
+		endpoint := fmt.Sprintf("/repos/%s/pulls/%d/commits?per_page=100&page=%d", projectID, mrIID, pager.Page)
+		var commits []apiCommit
+		resp, err := p.getJSONWithResponse(ctx, endpoint, &commits)
+		if err != nil {
+			return nil, fmt.Errorf("github: failed to list PR commits: %w", err)
+		}
+
+		for _, c := range commits {
+			title, _, _ := strings.Cut(c.Commit.Message, "\n")
+			out = append(out, vcs.Commit{
+				SHA:     c.SHA,
+				Title:   strings.TrimSpace(title),
+				Message: c.Commit.Message,
+				Author:  c.Commit.Author.Name,
+			})
+		}
+
+		if !pager.Next(len(commits), hasNextPage(resp.Header.Get("Link"))) {
 			break
 		}
-		page++
 	}
 
 	return out, nil
@@ -317,20 +545,37 @@ func (p *Provider) PostSummaryNote(ctx context.Context, projectID string, mrIID
 	return nil
 }
 
+func (p *Provider) CreateStatus(ctx context.Context, projectID, sha, state, description, name string) error {
+	payload := map[string]string{
+		"state":       state,
+		"context":     name,
+		"description": vcs.TruncateStatusDescription(description, 140),
+	}
+	if err := p.postJSON(ctx,
+		fmt.Sprintf("/repos/%s/statuses/%s", projectID, sha),
+		payload,
+		nil,
+	); err != nil {
+		return fmt.Errorf("github: failed to set commit status: %w", err)
+	}
+	return nil
+}
+
 func (p *Provider) PostInlineComment(ctx context.Context, projectID string, mrIID int64, refs vcs.DiffRefs, comment vcs.InlineComment) error {
 	if refs.HeadSHA == "" {
 		return fmt.Errorf("github: missing head SHA for inline comment")
 	}
-	if comment.NewLine <= 0 {
-		return fmt.Errorf("github: invalid line number for inline comment")
+	line, side, err := reviewCommentLine(comment)
+	if err != nil {
+		return err
 	}
 
 	payload := map[string]interface{}{
 		"body":      comment.Body,
 		"commit_id": refs.HeadSHA,
 		"path":      comment.FilePath,
-		"line":      comment.NewLine,
-		"side":      "RIGHT",
+		"line":      line,
+		"side":      side,
 	}
 
 	if err := p.postJSON(ctx,
@@ -343,6 +588,55 @@ func (p *Provider) PostInlineComment(ctx context.Context, projectID string, mrII
 	return nil
 }
 
+// reviewCommentLine returns the line and diff side for a review comment:
+// removed lines are addressed by their old line number on the LEFT side.
+func reviewCommentLine(comment vcs.InlineComment) (int64, string, error) {
+	if comment.OnDeleted {
+		if comment.OldLine <= 0 {
+			return 0, "", fmt.Errorf("github: invalid old line number for inline comment on %s", comment.FilePath)
+		}
+		return comment.OldLine, "LEFT", nil
+	}
+	if comment.NewLine <= 0 {
+		return 0, "", fmt.Errorf("github: invalid line number for inline comment on %s", comment.FilePath)
+	}
+	return comment.NewLine, "RIGHT", nil
+}
+
+// PostInlineComments submits comments as a single pull request review with
+// event COMMENT, so reviewers get one notification for the whole batch.
+func (p *Provider) PostInlineComments(ctx context.Context, projectID string, mrIID int64, refs vcs.DiffRefs, comments []vcs.InlineComment) error {
+	if refs.HeadSHA == "" {
+		return fmt.Errorf("github: missing head SHA for review")
+	}
+	reviewComments := make([]map[string]interface{}, 0, len(comments))
+	for _, comment := range comments {
+		line, side, err := reviewCommentLine(comment)
+		if err != nil {
+			return err
+		}
+		reviewComments = append(reviewComments, map[string]interface{}{
+			"path": comment.FilePath,
+			"line": line,
+			"side": side,
+			"body": comment.Body,
+		})
+	}
+	payload := map[string]interface{}{
+		"commit_id": refs.HeadSHA,
+		"event":     "COMMENT",
+		"comments":  reviewComments,
+	}
+	if err := p.postJSON(ctx,
+		fmt.Sprintf("/repos/%s/pulls/%d/reviews", projectID, mrIID),
+		payload,
+		nil,
+	); err != nil {
+		return fmt.Errorf("github: failed to submit review: %w", err)
+	}
+	return nil
+}
+
 func (p *Provider) ReplyToMRDiscussion(ctx context.Context, projectID string, mrIID int64, discussionID, body string) error {
 	parentID, err := strconv.ParseInt(strings.TrimSpace(discussionID), 10, 64)
 	if err != nil || parentID <= 0 {
@@ -362,7 +656,158 @@ func (p *Provider) ReplyToMRDiscussion(ctx context.Context, projectID string, mr
 	return nil
 }
 
+// UpdateInlineComment replaces the body of an existing review comment.
+func (p *Provider) UpdateInlineComment(ctx context.Context, projectID string, mrIID int64, noteID int64, body string) error {
+	if err := p.sendJSON(ctx, http.MethodPatch,
+		fmt.Sprintf("/repos/%s/pulls/comments/%d", projectID, noteID),
+		map[string]string{"body": body},
+		nil,
+	); err != nil {
+		return fmt.Errorf("github: failed to update review comment %d: %w", noteID, err)
+	}
+	return nil
+}
+
+// UpdateSummaryNote replaces the body of an existing pull request
+// conversation comment.
+func (p *Provider) UpdateSummaryNote(ctx context.Context, projectID string, mrIID int64, noteID int64, body string) error {
+	if err := p.sendJSON(ctx, http.MethodPatch,
+		fmt.Sprintf("/repos/%s/issues/comments/%d", projectID, noteID),
+		map[string]string{"body": body},
+		nil,
+	); err != nil {
+		return fmt.Errorf("github: failed to update PR comment %d: %w", noteID, err)
+	}
+	return nil
+}
+
+// SetMRLabels adds and removes pull request labels through the issues API.
+// Labels missing from the pull request are skipped on removal.
+func (p *Provider) SetMRLabels(ctx context.Context, projectID string, mrIID int64, add, remove []string) error {
+	for _, label := range remove {
+		err := p.sendJSON(ctx, http.MethodDelete,
+			fmt.Sprintf("/repos/%s/issues/%d/labels/%s", projectID, mrIID, url.PathEscape(label)),
+			nil, nil)
+		var he *vcs.HTTPError
+		if err != nil && !(errors.As(err, &he) && he.StatusCode == http.StatusNotFound) {
+			return fmt.Errorf("github: failed to remove label %q: %w", label, err)
+		}
+	}
+	if len(add) == 0 {
+		return nil
+	}
+	if err := p.sendJSON(ctx, http.MethodPost,
+		fmt.Sprintf("/repos/%s/issues/%d/labels", projectID, mrIID),
+		map[string][]string{"labels": add},
+		nil,
+	); err != nil {
+		return fmt.Errorf("github: failed to add labels: %w", err)
+	}
+	return nil
+}
+
+// UpdateMRDescription replaces the pull request body.
+func (p *Provider) UpdateMRDescription(ctx context.Context, projectID string, mrIID int64, description string) error {
+	if err := p.sendJSON(ctx, http.MethodPatch,
+		fmt.Sprintf("/repos/%s/pulls/%d", projectID, mrIID),
+		map[string]string{"body": description},
+		nil,
+	); err != nil {
+		return fmt.Errorf("github: failed to update pull request body: %w", err)
+	}
+	return nil
+}
+
+// ResolveMRDiscussion resolves the review thread rooted at discussionID (the
+// root review comment's ID). Review threads are only resolvable through the
+// GraphQL API, so the thread's node ID is looked up first.
+func (p *Provider) ResolveMRDiscussion(ctx context.Context, projectID string, mrIID int64, discussionID string) error {
+	rootID, err := strconv.ParseInt(strings.TrimSpace(discussionID), 10, 64)
+	if err != nil || rootID <= 0 {
+		return fmt.Errorf("github: invalid discussion id %q to resolve", discussionID)
+	}
+	owner, name, ok := strings.Cut(projectID, "/")
+	if !ok || owner == "" || name == "" {
+		return fmt.Errorf("github: invalid repository %q", projectID)
+	}
+
+	threadID, resolved, err := p.findReviewThread(ctx, owner, name, mrIID, rootID)
+	if err != nil {
+		return fmt.Errorf("github: failed to resolve discussion %s: %w", discussionID, err)
+	}
+	if resolved {
+		return nil
+	}
+	const mutation = `mutation($id: ID!) { resolveReviewThread(input: {threadId: $id}) { thread { isResolved } } }`
+	if err := p.graphQL(ctx, mutation, map[string]interface{}{"id": threadID}, nil); err != nil {
+		return fmt.Errorf("github: failed to resolve discussion %s: %w", discussionID, err)
+	}
+	return nil
+}
+
+// findReviewThread returns the node ID and resolved state of the pull request
+// review thread whose first comment has the given database ID.
+func (p *Provider) findReviewThread(ctx context.Context, owner, name string, number, rootID int64) (string, bool, error) {
+	const query = `query($owner: String!, $name: String!, $number: Int!, $cursor: String) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      reviewThreads(first: 100, after: $cursor) {
+        pageInfo { hasNextPage endCursor }
+        nodes { id isResolved comments(first: 1) { nodes { databaseId } } }
+      }
+    }
+  }
+}`
+	vars := map[string]interface{}{"owner": owner, "name": name, "number": number}
+	for {
+		var data struct {
+			Repository struct {
+				PullRequest struct {
+					ReviewThreads struct {
+						PageInfo struct {
+							HasNextPage bool   `json:"hasNextPage"`
+							EndCursor   string `json:"endCursor"`
+						} `json:"pageInfo"`
+						Nodes []struct {
+							ID         string `json:"id"`
+							IsResolved bool   `json:"isResolved"`
+							Comments   struct {
+								Nodes []struct {
+									DatabaseID int64 `json:"databaseId"`
+								} `json:"nodes"`
+							} `json:"comments"`
+						} `json:"nodes"`
+					} `json:"reviewThreads"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		}
+		if err := p.graphQL(ctx, query, vars, &data); err != nil {
+			return "", false, err
+		}
+		threads := data.Repository.PullRequest.ReviewThreads
+		for _, t := range threads.Nodes {
+			if len(t.Comments.Nodes) > 0 && t.Comments.Nodes[0].DatabaseID == rootID {
+				return t.ID, t.IsResolved, nil
+			}
+		}
+		if !threads.PageInfo.HasNextPage || threads.PageInfo.EndCursor == "" {
+			return "", false, fmt.Errorf("review thread for comment %d not found", rootID)
+		}
+		vars["cursor"] = threads.PageInfo.EndCursor
+	}
+}
+
 // FormatSuggestionBlock returns a GitHub-native suggestion code block.
+func (p *Provider) CurrentUser(ctx context.Context) (string, error) {
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := p.getJSON(ctx, "/user", &user); err != nil {
+		return "", fmt.Errorf("github: failed to fetch current user: %w", err)
+	}
+	return user.Login, nil
+}
+
 func (p *Provider) FormatSuggestionBlock(suggestion string) string {
 	return "```suggestion\n" + suggestion + "\n```"
 }
@@ -377,7 +822,7 @@ func (p *Provider) getJSONWithResponse(ctx context.Context, endpoint string, out
 	if err != nil {
 		return nil, err
 	}
-	resp, err := p.client.Do(req)
+	resp, err := vcs.DoWithRetry(p.client, req)
 	if err != nil {
 		return nil, err
 	}
@@ -385,7 +830,7 @@ func (p *Provider) getJSONWithResponse(ctx context.Context, endpoint string, out
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		return resp, fmt.Errorf("github: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		return resp, vcs.NewHTTPError("github", resp, body)
 	}
 
 	if out != nil {
@@ -398,6 +843,10 @@ func (p *Provider) getJSONWithResponse(ctx context.Context, endpoint string, out
 }
 
 func (p *Provider) postJSON(ctx context.Context, endpoint string, payload interface{}, out interface{}) error {
+	return p.sendJSON(ctx, http.MethodPost, endpoint, payload, out)
+}
+
+func (p *Provider) sendJSON(ctx context.Context, method, endpoint string, payload interface{}, out interface{}) error {
 	var buf io.Reader
 	if payload != nil {
 		data, err := json.Marshal(payload)
@@ -407,11 +856,11 @@ func (p *Provider) postJSON(ctx context.Context, endpoint string, payload interf
 		buf = bytes.NewReader(data)
 	}
 
-	req, err := p.newRequest(ctx, http.MethodPost, endpoint, buf)
+	req, err := p.newRequest(ctx, method, endpoint, buf)
 	if err != nil {
 		return err
 	}
-	resp, err := p.client.Do(req)
+	resp, err := vcs.DoWithRetry(p.client, req)
 	if err != nil {
 		return err
 	}
@@ -419,7 +868,7 @@ func (p *Provider) postJSON(ctx context.Context, endpoint string, payload interf
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("github: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		return vcs.NewHTTPError("github", resp, body)
 	}
 
 	if out != nil {
@@ -447,6 +896,57 @@ func (p *Provider) newRequest(ctx context.Context, method, endpoint string, body
 	return req, nil
 }
 
+// graphQLURL derives the GraphQL endpoint from the REST base URL:
+// https://api.github.com/graphql, or <host>/api/graphql on GitHub Enterprise.
+func (p *Provider) graphQLURL() string {
+	if strings.HasSuffix(p.baseURL, "/api/v3") {
+		return strings.TrimSuffix(p.baseURL, "/v3") + "/graphql"
+	}
+	return p.baseURL + "/graphql"
+}
+
+func (p *Provider) graphQL(ctx context.Context, query string, variables map[string]interface{}, out interface{}) error {
+	data, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.graphQLURL(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "prev-cli")
+	req.Header.Set("Authorization", "Bearer "+p.token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := vcs.DoWithRetry(p.client, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return vcs.NewHTTPError("github", resp, body)
+	}
+
+	var envelope struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return err
+	}
+	if len(envelope.Errors) > 0 {
+		return fmt.Errorf("github: graphql: %s", envelope.Errors[0].Message)
+	}
+	if out != nil && len(envelope.Data) > 0 {
+		return json.Unmarshal(envelope.Data, out)
+	}
+	return nil
+}
+
 func hasNextPage(linkHeader string) bool {
 	if linkHeader == "" {
 		return false
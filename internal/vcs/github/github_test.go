@@ -3,9 +3,11 @@ package github
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/sanix-darker/prev/internal/vcs"
@@ -113,6 +115,160 @@ func TestProvider_PostComments(t *testing.T) {
 	assert.Equal(t, "RIGHT", inlineBody["side"])
 }
 
+func TestProvider_PostInlineComment_DeletedLineUsesLeftSide(t *testing.T) {
+	var inlineBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/repos/acme/blog/pulls/42/comments", r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &inlineBody)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	p, err := NewProvider("token-123", server.URL)
+	require.NoError(t, err)
+
+	err = p.PostInlineComment(context.Background(), "acme/blog", 42, vcs.DiffRefs{HeadSHA: "headsha"}, vcs.InlineComment{
+		FilePath:  "auth.go",
+		OldLine:   31,
+		Body:      "This removed check guarded nil sessions.",
+		OnDeleted: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, float64(31), inlineBody["line"])
+	assert.Equal(t, "LEFT", inlineBody["side"])
+
+	err = p.PostInlineComment(context.Background(), "acme/blog", 42, vcs.DiffRefs{HeadSHA: "headsha"}, vcs.InlineComment{
+		FilePath: "auth.go", Body: "no line", OnDeleted: true,
+	})
+	assert.Error(t, err)
+}
+
+func TestProvider_PostInlineComments_SubmitsOneReview(t *testing.T) {
+	var requests int
+	var review struct {
+		CommitID string `json:"commit_id"`
+		Event    string `json:"event"`
+		Comments []struct {
+			Path string `json:"path"`
+			Line int64  `json:"line"`
+			Side string `json:"side"`
+			Body string `json:"body"`
+		} `json:"comments"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		require.Equal(t, "/repos/acme/blog/pulls/42/reviews", r.URL.Path)
+		require.Equal(t, http.MethodPost, r.Method)
+		body, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(body, &review))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	p, err := NewProvider("token-123", server.URL)
+	require.NoError(t, err)
+
+	batcher, ok := p.(vcs.InlineCommentBatcher)
+	require.True(t, ok)
+	err = batcher.PostInlineComments(context.Background(), "acme/blog", 42, vcs.DiffRefs{HeadSHA: "headsha"}, []vcs.InlineComment{
+		{FilePath: "a.go", NewLine: 3, Body: "first"},
+		{FilePath: "b.go", NewLine: 9, Body: "second"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, requests)
+	assert.Equal(t, "headsha", review.CommitID)
+	assert.Equal(t, "COMMENT", review.Event)
+	require.Len(t, review.Comments, 2)
+	assert.Equal(t, "a.go", review.Comments[0].Path)
+	assert.Equal(t, int64(3), review.Comments[0].Line)
+	assert.Equal(t, "RIGHT", review.Comments[0].Side)
+	assert.Equal(t, "second", review.Comments[1].Body)
+}
+
+func TestProvider_MergeBase_UsesCompareAPI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/repos/acme/blog/compare/stalebase...headsha", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"merge_base_commit": map[string]interface{}{"sha": "forkpoint"},
+		})
+	}))
+	defer server.Close()
+
+	p, err := NewProvider("token-123", server.URL)
+	require.NoError(t, err)
+	resolver, ok := p.(vcs.MergeBaseResolver)
+	require.True(t, ok)
+
+	sha, err := resolver.MergeBase(context.Background(), "acme/blog", "stalebase", "headsha")
+	require.NoError(t, err)
+	assert.Equal(t, "forkpoint", sha)
+}
+
+func TestProvider_FetchMRCommits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/repos/acme/blog/pulls/9/commits", r.URL.Path)
+		_, _ = w.Write([]byte(`[
+			{"sha":"aaa","commit":{"message":"Add cache\n\nBody text","author":{"name":"Bob"}}},
+			{"sha":"bbb","commit":{"message":"Fix nil check","author":{"name":"Ann"}}}
+		]`))
+	}))
+	defer server.Close()
+
+	p, err := NewProvider("token-123", server.URL)
+	require.NoError(t, err)
+
+	commits, err := p.(vcs.MRCommitLister).FetchMRCommits(context.Background(), "acme/blog", 9)
+	require.NoError(t, err)
+	assert.Equal(t, []vcs.Commit{
+		{SHA: "aaa", Title: "Add cache", Message: "Add cache\n\nBody text", Author: "Bob"},
+		{SHA: "bbb", Title: "Fix nil check", Message: "Fix nil check", Author: "Ann"},
+	}, commits)
+}
+
+func TestProvider_CompareDiffs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/repos/acme/blog/compare/base...head", r.URL.Path)
+		_, _ = w.Write([]byte(`{"files":[
+			{"filename":"a.go","status":"modified","patch":"@@ -1 +1,2 @@\n a\n+b"},
+			{"filename":"new.go","previous_filename":"old.go","status":"renamed","patch":""},
+			{"filename":"gone.go","status":"removed","patch":"@@ -1 +0,0 @@\n-x"}
+		]}`))
+	}))
+	defer server.Close()
+
+	p, err := NewProvider("token-123", server.URL)
+	require.NoError(t, err)
+
+	diffs, err := p.(vcs.CompareDiffer).CompareDiffs(context.Background(), "acme/blog", "base", "head")
+	require.NoError(t, err)
+	assert.Equal(t, []vcs.FileDiff{
+		{OldPath: "a.go", NewPath: "a.go", Diff: "@@ -1 +1,2 @@\n a\n+b"},
+		{OldPath: "old.go", NewPath: "new.go", RenamedFile: true},
+		{OldPath: "gone.go", NewPath: "gone.go", Diff: "@@ -1 +0,0 @@\n-x", DeletedFile: true},
+	}, diffs)
+}
+
+func TestProvider_CompareDiffs_TruncatedIsAnError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		files := make([]string, compareFileLimit)
+		for i := range files {
+			files[i] = fmt.Sprintf(`{"filename":"f%d.go","status":"modified","patch":"@@ -1 +1 @@\n-a\n+b"}`, i)
+		}
+		_, _ = w.Write([]byte(`{"files":[` + strings.Join(files, ",") + `]}`))
+	}))
+	defer server.Close()
+
+	p, err := NewProvider("token-123", server.URL)
+	require.NoError(t, err)
+
+	_, err = p.(vcs.CompareDiffer).CompareDiffs(context.Background(), "acme/blog", "base", "head")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "truncated")
+}
+
 func TestHasNextPage(t *testing.T) {
 	assert.True(t, hasNextPage(`<https://api.github.com/resource?page=2>; rel="next"`))
 	assert.False(t, hasNextPage(`<https://api.github.com/resource?page=2>; rel="prev"`))
@@ -120,6 +276,10 @@ func TestHasNextPage(t *testing.T) {
 
 func TestProvider_ListMRDiscussions_GroupsReviewThreads(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/graphql" {
+			http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+			return
+		}
 		if r.URL.Path != "/repos/acme/blog/pulls/42/comments" {
 			t.Fatalf("unexpected path: %s", r.URL.Path)
 		}
@@ -158,6 +318,54 @@ func TestProvider_ListMRDiscussions_GroupsReviewThreads(t *testing.T) {
 	assert.Equal(t, 31, discussions[0].Notes[0].Line)
 }
 
+func TestProvider_ListMRDiscussions_UsesReviewThreadState(t *testing.T) {
+	var cursors []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/graphql" {
+			t.Fatalf("REST fallback should not be used, got %s", r.URL.Path)
+		}
+		var req map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		vars, _ := req["variables"].(map[string]interface{})
+		cursors = append(cursors, vars["cursor"])
+		if vars["cursor"] == nil {
+			_, _ = w.Write([]byte(`{"data":{"repository":{"pullRequest":{"reviewThreads":{
+				"pageInfo":{"hasNextPage":true,"endCursor":"c1"},
+				"nodes":[{"isResolved":true,"path":"a.go","line":null,"originalLine":7,"comments":{"nodes":[
+					{"databaseId":100,"body":"[HIGH] Old finding","path":"a.go","line":null,"originalLine":7,"author":{"login":"bot"}},
+					{"databaseId":103,"body":"Fixed","path":"a.go","line":null,"originalLine":null,"author":{"login":"dev"}}]}}]}}}}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"repository":{"pullRequest":{"reviewThreads":{
+			"pageInfo":{"hasNextPage":false,"endCursor":""},
+			"nodes":[{"isResolved":false,"path":"b.go","line":12,"comments":{"nodes":[
+				{"databaseId":101,"body":"[LOW] New finding","path":"b.go","line":12,"author":null}]}}]}}}}}`))
+	}))
+	defer server.Close()
+
+	p, err := NewProvider("token-123", server.URL)
+	require.NoError(t, err)
+
+	discussions, err := p.ListMRDiscussions(context.Background(), "acme/blog", 42)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{nil, "c1"}, cursors)
+	require.Len(t, discussions, 2)
+
+	assert.Equal(t, "100", discussions[0].ID)
+	require.Len(t, discussions[0].Notes, 2)
+	for _, n := range discussions[0].Notes {
+		assert.True(t, n.Resolvable)
+		assert.True(t, n.Resolved)
+		assert.Equal(t, 7, n.Line)
+	}
+	assert.Equal(t, "dev", discussions[0].Notes[1].Author)
+
+	assert.Equal(t, "101", discussions[1].ID)
+	assert.False(t, discussions[1].Notes[0].Resolved)
+	assert.Equal(t, 12, discussions[1].Notes[0].Line)
+	assert.Equal(t, "b.go", discussions[1].Notes[0].FilePath)
+}
+
 func TestProvider_ReplyToMRDiscussion(t *testing.T) {
 	var payload map[string]interface{}
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -179,3 +387,187 @@ func TestProvider_ReplyToMRDiscussion(t *testing.T) {
 	assert.Equal(t, "reply body", payload["body"])
 	assert.Equal(t, float64(101), payload["in_reply_to"])
 }
+
+func TestProvider_UpdateInlineComment(t *testing.T) {
+	var method string
+	var payload map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/acme/blog/pulls/comments/101" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		method = r.Method
+		body, _ := io.ReadAll(r.Body)
+		defer r.Body.Close()
+		_ = json.Unmarshal(body, &payload)
+		_, _ = w.Write([]byte(`{"id":101}`))
+	}))
+	defer server.Close()
+
+	p, err := NewProvider("token-123", server.URL)
+	require.NoError(t, err)
+
+	err = p.(vcs.InlineCommentUpdater).UpdateInlineComment(context.Background(), "acme/blog", 42, 101, "better body")
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodPatch, method)
+	assert.Equal(t, "better body", payload["body"])
+}
+
+func TestProvider_UpdateSummaryNote(t *testing.T) {
+	var method string
+	var payload map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/acme/blog/issues/comments/202" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		method = r.Method
+		body, _ := io.ReadAll(r.Body)
+		defer r.Body.Close()
+		_ = json.Unmarshal(body, &payload)
+		_, _ = w.Write([]byte(`{"id":202}`))
+	}))
+	defer server.Close()
+
+	p, err := NewProvider("token-123", server.URL)
+	require.NoError(t, err)
+
+	err = p.(vcs.SummaryNoteUpdater).UpdateSummaryNote(context.Background(), "acme/blog", 42, 202, "fresh note")
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodPatch, method)
+	assert.Equal(t, "fresh note", payload["body"])
+}
+
+func TestProvider_SetMRLabelsAndDescription(t *testing.T) {
+	var calls []string
+	var added, body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.EscapedPath())
+		switch {
+		case r.Method == http.MethodDelete:
+			// The label is not on the pull request yet.
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"Label does not exist"}`))
+		case r.Method == http.MethodPost:
+			_ = json.NewDecoder(r.Body).Decode(&added)
+			_, _ = w.Write([]byte(`[]`))
+		default:
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			_, _ = w.Write([]byte(`{"number":42}`))
+		}
+	}))
+	defer server.Close()
+
+	p, err := NewProvider("token-123", server.URL)
+	require.NoError(t, err)
+	editor := p.(vcs.MRMetadataEditor)
+
+	require.NoError(t, editor.SetMRLabels(context.Background(), "acme/blog", 42, []string{"prev:looks-good"}, []string{"prev:needs-work"}))
+	require.NoError(t, editor.UpdateMRDescription(context.Background(), "acme/blog", 42, "new body"))
+
+	assert.Equal(t, []string{
+		"DELETE /repos/acme/blog/issues/42/labels/prev:needs-work",
+		"POST /repos/acme/blog/issues/42/labels",
+		"PATCH /repos/acme/blog/pulls/42",
+	}, calls)
+	assert.Equal(t, []interface{}{"prev:looks-good"}, added["labels"])
+	assert.Equal(t, "new body", body["body"])
+}
+
+func TestCreateStatus_TruncatesDescription(t *testing.T) {
+	var gotPath string
+	var gotReq map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&gotReq)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": 1})
+	}))
+	defer server.Close()
+
+	p, err := NewProvider("token-123", server.URL)
+	require.NoError(t, err)
+
+	err = p.(vcs.StatusReporter).CreateStatus(context.Background(), "owner/repo", "abc123", vcs.StatusSuccess, strings.Repeat("x", 200), "prev/review")
+	require.NoError(t, err)
+	assert.Equal(t, "/repos/owner/repo/statuses/abc123", gotPath)
+	assert.Equal(t, "success", gotReq["state"])
+	assert.Equal(t, "prev/review", gotReq["context"])
+	assert.Len(t, gotReq["description"], 140)
+	assert.True(t, strings.HasSuffix(gotReq["description"], "..."))
+}
+
+func TestProvider_ResolveMRDiscussion(t *testing.T) {
+	var queries []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/graphql" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		var req map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		queries = append(queries, req)
+		query, _ := req["query"].(string)
+		if strings.HasPrefix(query, "mutation") {
+			_, _ = w.Write([]byte(`{"data":{"resolveReviewThread":{"thread":{"isResolved":true}}}}`))
+			return
+		}
+		vars, _ := req["variables"].(map[string]interface{})
+		if vars["cursor"] == nil {
+			_, _ = w.Write([]byte(`{"data":{"repository":{"pullRequest":{"reviewThreads":{
+				"pageInfo":{"hasNextPage":true,"endCursor":"c1"},
+				"nodes":[{"id":"T_1","isResolved":false,"comments":{"nodes":[{"databaseId":100}]}}]}}}}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"repository":{"pullRequest":{"reviewThreads":{
+			"pageInfo":{"hasNextPage":false,"endCursor":""},
+			"nodes":[{"id":"T_2","isResolved":false,"comments":{"nodes":[{"databaseId":101}]}}]}}}}}`))
+	}))
+	defer server.Close()
+
+	p, err := NewProvider("token-123", server.URL)
+	require.NoError(t, err)
+
+	err = p.ResolveMRDiscussion(context.Background(), "acme/blog", 42, "101")
+	require.NoError(t, err)
+	require.Len(t, queries, 3)
+	vars, _ := queries[0]["variables"].(map[string]interface{})
+	assert.Equal(t, "acme", vars["owner"])
+	assert.Equal(t, "blog", vars["name"])
+	assert.Equal(t, float64(42), vars["number"])
+	mutationVars, _ := queries[2]["variables"].(map[string]interface{})
+	assert.Equal(t, "T_2", mutationVars["id"])
+}
+
+func TestProvider_ResolveMRDiscussionThreadNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"repository":{"pullRequest":{"reviewThreads":{"pageInfo":{"hasNextPage":false},"nodes":[]}}}}}`))
+	}))
+	defer server.Close()
+
+	p, err := NewProvider("token-123", server.URL)
+	require.NoError(t, err)
+
+	err = p.ResolveMRDiscussion(context.Background(), "acme/blog", 42, "101")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "review thread for comment 101 not found")
+}
+
+func TestProvider_GraphQLURL(t *testing.T) {
+	p := &Provider{baseURL: "https://api.github.com"}
+	assert.Equal(t, "https://api.github.com/graphql", p.graphQLURL())
+	p = &Provider{baseURL: "https://ghe.example.com/api/v3"}
+	assert.Equal(t, "https://ghe.example.com/api/graphql", p.graphQLURL())
+}
+
+func TestCurrentUser_Unauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/user", r.URL.Path)
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"message":"Bad credentials"}`))
+	}))
+	defer server.Close()
+
+	p, err := NewProvider("bad-token", server.URL)
+	require.NoError(t, err)
+
+	_, err = p.(vcs.AuthChecker).CurrentUser(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "HTTP 401")
+}
@@ -3,11 +3,16 @@ package gitlab
 import (
 	"bytes"
 	"context"
+	"crypto/sha1"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -106,10 +111,10 @@ func (p *Provider) FetchMRDiffs(ctx context.Context, projectID string, mrIID int
 	}
 
 	var allDiffs []vcs.FileDiff
-	page := 1
+	pager := vcs.NewPager("gitlab", "MR diffs")
 	for {
 		endpoint := fmt.Sprintf("/api/v4/projects/%s/merge_requests/%d/diffs?per_page=100&page=%d",
-			url.PathEscape(projectID), mrIID, page)
+			url.PathEscape(projectID), mrIID, pager.Page)
 		var diffs []apiDiff
 		resp, err := p.getJSONWithResponse(ctx, endpoint, &diffs)
 		if err != nil {
@@ -129,15 +134,46 @@ func (p *Provider) FetchMRDiffs(ctx context.Context, projectID string, mrIID int
 			})
 		}
 
-		if !hasNextPage(resp.Header.Get("X-Next-Page")) {
+		if !pager.Next(len(diffs), hasNextPage(resp.Header.Get("X-Next-Page"))) {
 			break
 		}
-		page++
 	}
 
 	return allDiffs, nil
 }
 
+// CompareDiffs returns the straight diff between two commits from the
+// repository compare endpoint.
+func (p *Provider) CompareDiffs(ctx context.Context, projectID, fromSHA, toSHA string) ([]vcs.FileDiff, error) {
+	var cmp struct {
+		Diffs []struct {
+			OldPath     string `json:"old_path"`
+			NewPath     string `json:"new_path"`
+			Diff        string `json:"diff"`
+			NewFile     bool   `json:"new_file"`
+			RenamedFile bool   `json:"renamed_file"`
+			DeletedFile bool   `json:"deleted_file"`
+		} `json:"diffs"`
+	}
+	endpoint := fmt.Sprintf("/api/v4/projects/%s/repository/compare?from=%s&to=%s&straight=true",
+		url.PathEscape(projectID), url.QueryEscape(fromSHA), url.QueryEscape(toSHA))
+	if err := p.getJSON(ctx, endpoint, &cmp); err != nil {
+		return nil, fmt.Errorf("gitlab: failed to compare %s..%s: %w", fromSHA, toSHA, err)
+	}
+	out := make([]vcs.FileDiff, 0, len(cmp.Diffs))
+	for _, d := range cmp.Diffs {
+		out = append(out, vcs.FileDiff{
+			OldPath:     d.OldPath,
+			NewPath:     d.NewPath,
+			Diff:        d.Diff,
+			NewFile:     d.NewFile,
+			RenamedFile: d.RenamedFile,
+			DeletedFile: d.DeletedFile,
+		})
+	}
+	return out, nil
+}
+
 func (p *Provider) FetchMRRawDiff(ctx context.Context, projectID string, mrIID int64) (string, error) {
 	endpoint := fmt.Sprintf("/api/v4/projects/%s/merge_requests/%d/raw_diffs",
 		url.PathEscape(projectID), mrIID)
@@ -147,7 +183,7 @@ func (p *Provider) FetchMRRawDiff(ctx context.Context, projectID string, mrIID i
 		return "", err
 	}
 
-	resp, err := p.client.Do(req)
+	resp, err := vcs.DoWithRetry(p.client, req)
 	if err != nil {
 		return "", err
 	}
@@ -155,7 +191,7 @@ func (p *Provider) FetchMRRawDiff(ctx context.Context, projectID string, mrIID i
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("gitlab: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		return "", vcs.NewHTTPError("gitlab", resp, body)
 	}
 
 	raw, err := io.ReadAll(resp.Body)
@@ -177,6 +213,7 @@ func (p *Provider) ListMRDiscussions(ctx context.Context, projectID string, mrII
 		Position *struct {
 			NewPath string `json:"new_path"`
 			NewLine int    `json:"new_line"`
+			OldLine int    `json:"old_line"`
 		} `json:"position"`
 	}
 	type apiDiscussion struct {
@@ -185,10 +222,10 @@ func (p *Provider) ListMRDiscussions(ctx context.Context, projectID string, mrII
 	}
 
 	var out []vcs.MRDiscussion
-	page := 1
+	pager := vcs.NewPager("gitlab", "MR discussions")
 	for {
 		endpoint := fmt.Sprintf("/api/v4/projects/%s/merge_requests/%d/discussions?per_page=100&page=%d",
-			url.PathEscape(projectID), mrIID, page)
+			url.PathEscape(projectID), mrIID, pager.Page)
 		var discussions []apiDiscussion
 		resp, err := p.getJSONWithResponse(ctx, endpoint, &discussions)
 		if err != nil {
@@ -208,16 +245,19 @@ func (p *Provider) ListMRDiscussions(ctx context.Context, projectID string, mrII
 				if n.Position != nil {
 					note.FilePath = n.Position.NewPath
 					note.Line = n.Position.NewLine
+					if note.Line <= 0 && n.Position.OldLine > 0 {
+						note.Line = n.Position.OldLine
+						note.OnDeleted = true
+					}
 				}
 				thread.Notes = append(thread.Notes, note)
 			}
 			out = append(out, thread)
 		}
 
-		if !hasNextPage(resp.Header.Get("X-Next-Page")) {
+		if !pager.Next(len(discussions), hasNextPage(resp.Header.Get("X-Next-Page"))) {
 			break
 		}
-		page++
 	}
 
 	return out, nil
@@ -233,10 +273,10 @@ func (p *Provider) ListMRNotes(ctx context.Context, projectID string, mrIID int6
 	}
 
 	var out []vcs.MRNote
-	page := 1
+	pager := vcs.NewPager("gitlab", "MR notes")
 	for {
 		endpoint := fmt.Sprintf("/api/v4/projects/%s/merge_requests/%d/notes?per_page=100&page=%d",
-			url.PathEscape(projectID), mrIID, page)
+			url.PathEscape(projectID), mrIID, pager.Page)
 		var notes []apiNote
 		resp, err := p.getJSONWithResponse(ctx, endpoint, &notes)
 		if err != nil {
@@ -249,11 +289,47 @@ func (p *Provider) ListMRNotes(ctx context.Context, projectID string, mrIID int6
 				Body:   n.Body,
 			})
 		}
-		if !hasNextPage(resp.Header.Get("X-Next-Page")) {
+		if !pager.Next(len(notes), hasNextPage(resp.Header.Get("X-Next-Page"))) {
+			break
+		}
+	}
+
+	return out, nil
+}
+
+// FetchMRCommits lists the MR's commits. GitLab returns them newest first;
+// they are reversed to match the MRCommitLister contract.
+func (p *Provider) FetchMRCommits(ctx context.Context, projectID string, mrIID int64) ([]vcs.Commit, error) {
+	type apiCommit struct {
+		ID         string `json:"id"`
+		Title      string `json:"title"`
+		Message    string `json:"message"`
+		AuthorName string `json:"author_name"`
+	}
+
+	var out []vcs.Commit
+	pager := vcs.NewPager("gitlab", "MR commits")
+	for {
+		endpoint := fmt.Sprintf("/api/v4/projects/%s/merge_requests/%d/commits?per_page=100&page=%d",
+			url.PathEscape(projectID), mrIID, pager.Page)
+		var commits []apiCommit
+		resp, err := p.getJSONWithResponse(ctx, endpoint, &commits)
+		if err != nil {
+			return nil, fmt.Errorf("gitlab: failed to list MR commits: %w", err)
+		}
+		for _, c := range commits {
+			out = append(out, vcs.Commit{
+				SHA:     c.ID,
+				Title:   c.Title,
+				Message: c.Message,
+				Author:  c.AuthorName,
+			})
+		}
+		if !pager.Next(len(commits), hasNextPage(resp.Header.Get("X-Next-Page"))) {
 			break
 		}
-		page++
 	}
+	slices.Reverse(out)
 
 	return out, nil
 }
@@ -306,7 +382,37 @@ func (p *Provider) PostSummaryNote(ctx context.Context, projectID string, mrIID
 	return nil
 }
 
+// PostInlineComment opens a diff discussion at comment's position. When
+// GitLab rejects the position (HTTP 400 about line_code or position) it
+// looks the line up in the MR diff and retries once with
+// fallbackInlinePosition. It returns a *vcs.InlinePositionError when the
+// line is not in the diff or the retry is rejected too.
 func (p *Provider) PostInlineComment(ctx context.Context, projectID string, mrIID int64, refs vcs.DiffRefs, comment vcs.InlineComment) error {
+	endpoint := fmt.Sprintf("/api/v4/projects/%s/merge_requests/%d/discussions", url.PathEscape(projectID), mrIID)
+	err := p.postJSON(ctx, endpoint, map[string]interface{}{
+		"body":     comment.Body,
+		"position": inlinePosition(refs, comment),
+	}, nil)
+	if isPositionRejected(err) {
+		oldPos, newPos, ok := p.diffLinePosition(ctx, projectID, mrIID, comment)
+		if !ok {
+			return &vcs.InlinePositionError{Provider: "gitlab", Err: err}
+		}
+		err = p.postJSON(ctx, endpoint, map[string]interface{}{
+			"body":     comment.Body,
+			"position": fallbackInlinePosition(refs, comment, oldPos, newPos),
+		}, nil)
+		if isPositionRejected(err) {
+			return &vcs.InlinePositionError{Provider: "gitlab", Err: err}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("gitlab: failed to post inline discussion: %w", err)
+	}
+	return nil
+}
+
+func inlinePosition(refs vcs.DiffRefs, comment vcs.InlineComment) map[string]interface{} {
 	oldPath := strings.TrimSpace(comment.OldPath)
 	if oldPath == "" {
 		oldPath = comment.FilePath
@@ -318,23 +424,144 @@ func (p *Provider) PostInlineComment(ctx context.Context, projectID string, mrII
 		"position_type": "text",
 		"new_path":      comment.FilePath,
 		"old_path":      oldPath,
-		"new_line":      comment.NewLine,
+	}
+	// A removed line only exists on the old side, so new_line must be absent.
+	if !comment.OnDeleted {
+		position["new_line"] = comment.NewLine
 	}
 	if comment.OldLine > 0 {
 		position["old_line"] = comment.OldLine
 	}
+	return position
+}
 
-	payload := map[string]interface{}{
-		"body":     comment.Body,
-		"position": position,
+// fallbackInlinePosition is the single retry after GitLab rejects a
+// position: an explicit one-line line_range carrying the line_code GitLab
+// failed to derive. oldPos and newPos are the line's running positions in
+// the diff (see lineDiffPositions).
+func fallbackInlinePosition(refs vcs.DiffRefs, comment vcs.InlineComment, oldPos, newPos int64) map[string]interface{} {
+	position := inlinePosition(refs, comment)
+	point := map[string]interface{}{
+		"line_code": lineCode(comment.FilePath, oldPos, newPos),
+		"type":      "new",
+		"new_line":  newPos,
+	}
+	if comment.OnDeleted {
+		point = map[string]interface{}{
+			"line_code": lineCode(comment.FilePath, oldPos, newPos),
+			"type":      "old",
+			"old_line":  oldPos,
+		}
+	} else if comment.OldLine > 0 {
+		point["old_line"] = comment.OldLine
+	}
+	position["line_range"] = map[string]interface{}{"start": point, "end": point}
+	return position
+}
+
+// lineCode builds GitLab's diff line code: the SHA-1 of the file path and
+// the line's running old and new positions in the diff.
+func lineCode(path string, oldPos, newPos int64) string {
+	return fmt.Sprintf("%x_%d_%d", sha1.Sum([]byte(path)), oldPos, newPos)
+}
+
+// diffLinePosition finds comment's file in the MR diff and returns the
+// running positions of its line (see lineDiffPositions).
+func (p *Provider) diffLinePosition(ctx context.Context, projectID string, mrIID int64, comment vcs.InlineComment) (int64, int64, bool) {
+	diffs, err := p.FetchMRDiffs(ctx, projectID, mrIID)
+	if err != nil {
+		return 0, 0, false
+	}
+	for _, d := range diffs {
+		if d.NewPath == comment.FilePath {
+			return lineDiffPositions(d.Diff, comment)
+		}
+	}
+	return 0, 0, false
+}
+
+// lineDiffPositions walks a unified diff the way GitLab's diff parser does
+// and returns the old and new positions of the line comment is anchored on.
+// GitLab keeps both counters running: an added line carries the old
+// position of the next unchanged line, and a removed line the new position
+// of the next unchanged line. Those pairs make up its line_code.
+func lineDiffPositions(diff string, comment vcs.InlineComment) (int64, int64, bool) {
+	var oldPos, newPos int64
+	inHunk := false
+	for _, line := range strings.Split(diff, "\n") {
+		if m := hunkHeaderPattern.FindStringSubmatch(line); m != nil {
+			oldPos, _ = strconv.ParseInt(m[1], 10, 64)
+			newPos, _ = strconv.ParseInt(m[2], 10, 64)
+			inHunk = true
+			continue
+		}
+		if !inHunk || line == "" || strings.HasPrefix(line, "\\") {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "+"):
+			if !comment.OnDeleted && newPos == comment.NewLine {
+				return oldPos, newPos, true
+			}
+			newPos++
+		case strings.HasPrefix(line, "-"):
+			if comment.OnDeleted && oldPos == comment.OldLine {
+				return oldPos, newPos, true
+			}
+			oldPos++
+		default:
+			if (!comment.OnDeleted && newPos == comment.NewLine) || (comment.OnDeleted && oldPos == comment.OldLine) {
+				return oldPos, newPos, true
+			}
+			oldPos++
+			newPos++
+		}
+	}
+	return 0, 0, false
+}
+
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// isPositionRejected reports whether err is GitLab refusing a discussion
+// position rather than a transport or permission failure.
+func isPositionRejected(err error) bool {
+	var he *vcs.HTTPError
+	if !errors.As(err, &he) || he.StatusCode != http.StatusBadRequest {
+		return false
 	}
+	body := strings.ToLower(he.Body)
+	return strings.Contains(body, "line_code") || strings.Contains(body, "position")
+}
 
+// StartMRDiscussion opens a resolvable discussion without a diff position.
+func (p *Provider) StartMRDiscussion(ctx context.Context, projectID string, mrIID int64, body string) error {
+	payload := map[string]string{"body": body}
 	if err := p.postJSON(ctx,
 		fmt.Sprintf("/api/v4/projects/%s/merge_requests/%d/discussions", url.PathEscape(projectID), mrIID),
 		payload,
 		nil,
 	); err != nil {
-		return fmt.Errorf("gitlab: failed to post inline discussion: %w", err)
+		return fmt.Errorf("gitlab: failed to start discussion: %w", err)
+	}
+	return nil
+}
+
+func (p *Provider) CreateStatus(ctx context.Context, projectID, sha, state, description, name string) error {
+	glState := state
+	if state == vcs.StatusFailure {
+		glState = "failed"
+	}
+	payload := map[string]string{
+		"state":       glState,
+		"name":        name,
+		"description": vcs.TruncateStatusDescription(description, 255),
+	}
+	if err := p.postJSON(ctx,
+		fmt.Sprintf("/api/v4/projects/%s/statuses/%s", url.PathEscape(projectID), url.PathEscape(sha)),
+		payload,
+		nil,
+	); err != nil {
+		return fmt.Errorf("gitlab: failed to set commit status: %w", err)
 	}
 	return nil
 }
@@ -350,7 +577,77 @@ func (p *Provider) ReplyToMRDiscussion(ctx context.Context, projectID string, mr
 	return nil
 }
 
+// UpdateInlineComment replaces the body of an existing merge request note.
+func (p *Provider) UpdateInlineComment(ctx context.Context, projectID string, mrIID int64, noteID int64, body string) error {
+	payload := map[string]string{"body": body}
+	endpoint := fmt.Sprintf("/api/v4/projects/%s/merge_requests/%d/notes/%d",
+		url.PathEscape(projectID), mrIID, noteID)
+
+	if err := p.sendJSON(ctx, http.MethodPut, endpoint, payload, nil); err != nil {
+		return fmt.Errorf("gitlab: failed to update note %d: %w", noteID, err)
+	}
+	return nil
+}
+
+// UpdateSummaryNote replaces the body of an existing top-level merge request
+// note. GitLab stores both kinds of note in one collection.
+func (p *Provider) UpdateSummaryNote(ctx context.Context, projectID string, mrIID int64, noteID int64, body string) error {
+	return p.UpdateInlineComment(ctx, projectID, mrIID, noteID, body)
+}
+
+// SetMRLabels adds and removes merge request labels.
+func (p *Provider) SetMRLabels(ctx context.Context, projectID string, mrIID int64, add, remove []string) error {
+	payload := map[string]string{}
+	if len(add) > 0 {
+		payload["add_labels"] = strings.Join(add, ",")
+	}
+	if len(remove) > 0 {
+		payload["remove_labels"] = strings.Join(remove, ",")
+	}
+	if len(payload) == 0 {
+		return nil
+	}
+	endpoint := fmt.Sprintf("/api/v4/projects/%s/merge_requests/%d", url.PathEscape(projectID), mrIID)
+
+	if err := p.sendJSON(ctx, http.MethodPut, endpoint, payload, nil); err != nil {
+		return fmt.Errorf("gitlab: failed to update MR labels: %w", err)
+	}
+	return nil
+}
+
+// UpdateMRDescription replaces the merge request description.
+func (p *Provider) UpdateMRDescription(ctx context.Context, projectID string, mrIID int64, description string) error {
+	payload := map[string]string{"description": description}
+	endpoint := fmt.Sprintf("/api/v4/projects/%s/merge_requests/%d", url.PathEscape(projectID), mrIID)
+
+	if err := p.sendJSON(ctx, http.MethodPut, endpoint, payload, nil); err != nil {
+		return fmt.Errorf("gitlab: failed to update MR description: %w", err)
+	}
+	return nil
+}
+
+// ResolveMRDiscussion marks a merge request discussion as resolved.
+func (p *Provider) ResolveMRDiscussion(ctx context.Context, projectID string, mrIID int64, discussionID string) error {
+	endpoint := fmt.Sprintf("/api/v4/projects/%s/merge_requests/%d/discussions/%s?resolved=true",
+		url.PathEscape(projectID), mrIID, discussionID)
+
+	if err := p.sendJSON(ctx, http.MethodPut, endpoint, nil, nil); err != nil {
+		return fmt.Errorf("gitlab: failed to resolve discussion %s: %w", discussionID, err)
+	}
+	return nil
+}
+
 // FormatSuggestionBlock returns a GitLab-native suggestion code block.
+func (p *Provider) CurrentUser(ctx context.Context) (string, error) {
+	var user struct {
+		Username string `json:"username"`
+	}
+	if err := p.getJSON(ctx, "/api/v4/user", &user); err != nil {
+		return "", fmt.Errorf("gitlab: failed to fetch current user: %w", err)
+	}
+	return user.Username, nil
+}
+
 func (p *Provider) FormatSuggestionBlock(suggestion string) string {
 	return "```suggestion:-0+0\n" + suggestion + "\n```"
 }
@@ -367,7 +664,7 @@ func (p *Provider) getJSONWithResponse(ctx context.Context, endpoint string, out
 	if err != nil {
 		return nil, err
 	}
-	resp, err := p.client.Do(req)
+	resp, err := vcs.DoWithRetry(p.client, req)
 	if err != nil {
 		return nil, err
 	}
@@ -375,7 +672,7 @@ func (p *Provider) getJSONWithResponse(ctx context.Context, endpoint string, out
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		return resp, fmt.Errorf("gitlab: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		return resp, vcs.NewHTTPError("gitlab", resp, body)
 	}
 
 	if out != nil {
@@ -388,6 +685,10 @@ func (p *Provider) getJSONWithResponse(ctx context.Context, endpoint string, out
 }
 
 func (p *Provider) postJSON(ctx context.Context, endpoint string, payload interface{}, out interface{}) error {
+	return p.sendJSON(ctx, http.MethodPost, endpoint, payload, out)
+}
+
+func (p *Provider) sendJSON(ctx context.Context, method, endpoint string, payload interface{}, out interface{}) error {
 	var buf io.Reader
 	if payload != nil {
 		data, err := json.Marshal(payload)
@@ -397,11 +698,11 @@ func (p *Provider) postJSON(ctx context.Context, endpoint string, payload interf
 		buf = bytes.NewReader(data)
 	}
 
-	req, err := p.newRequest(ctx, http.MethodPost, endpoint, buf)
+	req, err := p.newRequest(ctx, method, endpoint, buf)
 	if err != nil {
 		return err
 	}
-	resp, err := p.client.Do(req)
+	resp, err := vcs.DoWithRetry(p.client, req)
 	if err != nil {
 		return err
 	}
@@ -409,7 +710,7 @@ func (p *Provider) postJSON(ctx context.Context, endpoint string, payload interf
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("gitlab: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		return vcs.NewHTTPError("gitlab", resp, body)
 	}
 
 	if out != nil {
@@ -3,8 +3,10 @@ package gitlab
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"github.com/sanix-darker/prev/internal/vcs"
@@ -73,6 +75,26 @@ func TestFetchMRDiffs(t *testing.T) {
 	assert.Contains(t, diffs[0].Diff, "import")
 }
 
+func TestFetchMRDiffs_MaxPagesHaltsEndlessNextPage(t *testing.T) {
+	vcs.ConfigurePagination(3, false)
+	t.Cleanup(func() { vcs.ConfigurePagination(0, false) })
+
+	requests := 0
+	p := newTestProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		// Broken server: always advertises a next page.
+		w.Header().Set("X-Next-Page", "2")
+		json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"old_path": "main.go", "new_path": "main.go", "diff": "@@ -1 +1 @@\n-a\n+b\n"},
+		})
+	}))
+
+	diffs, err := p.FetchMRDiffs(context.Background(), "grp/proj", 42)
+	require.NoError(t, err)
+	assert.Equal(t, 3, requests)
+	assert.Len(t, diffs, 3)
+}
+
 func TestFetchMRRawDiff(t *testing.T) {
 	p := newTestProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		assert.Contains(t, r.URL.Path, "merge_requests/42/raw_diffs")
@@ -99,6 +121,23 @@ func TestPostSummaryNote(t *testing.T) {
 	assert.Equal(t, "Looks good!", gotBody)
 }
 
+func TestStartMRDiscussion_HasNoPosition(t *testing.T) {
+	var gotPath string
+	var gotReq map[string]interface{}
+	p := newTestProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&gotReq)
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": "disc-1"})
+	}))
+
+	err := p.(vcs.DiscussionStarter).StartMRDiscussion(context.Background(), "grp/proj", 42, "Unplaced findings")
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v4/projects/grp/proj/merge_requests/42/discussions", gotPath)
+	assert.Equal(t, "Unplaced findings", gotReq["body"])
+	_, hasPosition := gotReq["position"]
+	assert.False(t, hasPosition)
+}
+
 func TestPostInlineComment(t *testing.T) {
 	var gotReq map[string]interface{}
 	p := newTestProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -147,6 +186,171 @@ func TestPostInlineComment_UsesExplicitOldPath(t *testing.T) {
 	assert.Equal(t, "old/name.go", pos["old_path"])
 }
 
+func TestPostInlineComment_DeletedLineOmitsNewLine(t *testing.T) {
+	var gotReq map[string]interface{}
+	p := newTestProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&gotReq)
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": "disc-1"})
+	}))
+
+	refs := vcs.DiffRefs{BaseSHA: "aaa", HeadSHA: "bbb", StartSHA: "ccc"}
+	comment := vcs.InlineComment{FilePath: "main.go", OldLine: 14, Body: "Removed guard", OnDeleted: true}
+
+	err := p.PostInlineComment(context.Background(), "grp/proj", 42, refs, comment)
+	require.NoError(t, err)
+
+	pos, ok := gotReq["position"].(map[string]interface{})
+	require.True(t, ok)
+	_, hasNewLine := pos["new_line"]
+	assert.False(t, hasNewLine)
+	assert.Equal(t, float64(14), pos["old_line"])
+}
+
+// mainGoMRDiff is main.go's entry in the MR /diffs response.
+const mainGoMRDiff = "@@ -8,6 +8,7 @@ func main() {\n" +
+	" \tcfg := load()\n" +
+	" \tif cfg == nil {\n" +
+	"-\t\tpanic(\"no config\")\n" +
+	"+\t\tlog.Fatal(\"no config\")\n" +
+	"+\t\treturn\n" +
+	" \t}\n" +
+	" \trun(cfg)\n" +
+	" }\n"
+
+// rejectFirstPosition serves main.go's MR diff, rejects the first discussion
+// position and accepts the rest, recording every position it receives.
+func rejectFirstPosition(t *testing.T, rejection string) (vcs.VCSProvider, *[]map[string]interface{}) {
+	t.Helper()
+	var positions []map[string]interface{}
+	p := newTestProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			json.NewEncoder(w).Encode([]map[string]interface{}{
+				{"old_path": "main.go", "new_path": "main.go", "diff": mainGoMRDiff},
+			})
+			return
+		}
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		pos, _ := req["position"].(map[string]interface{})
+		positions = append(positions, pos)
+		if len(positions) == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(rejection))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": "disc-1"})
+	}))
+	return p, &positions
+}
+
+func TestLineDiffPositions_MatchesGitLabLineCodes(t *testing.T) {
+	// GitLab's diff parser keeps both counters running across added and
+	// removed lines; these are the line codes it renders for mainGoMRDiff.
+	const pathHash = "0607f785dfa3c3861b3239f6723eb276d8056461"
+	cases := []struct {
+		comment vcs.InlineComment
+		want    string
+	}{
+		{vcs.InlineComment{FilePath: "main.go", OldLine: 10, OnDeleted: true}, pathHash + "_10_10"},
+		{vcs.InlineComment{FilePath: "main.go", NewLine: 10}, pathHash + "_11_10"},
+		{vcs.InlineComment{FilePath: "main.go", NewLine: 11}, pathHash + "_11_11"},
+		{vcs.InlineComment{FilePath: "main.go", NewLine: 12, OldLine: 11}, pathHash + "_11_12"},
+		{vcs.InlineComment{FilePath: "main.go", NewLine: 8, OldLine: 8}, pathHash + "_8_8"},
+	}
+	for _, c := range cases {
+		oldPos, newPos, ok := lineDiffPositions(mainGoMRDiff, c.comment)
+		require.True(t, ok, c.want)
+		assert.Equal(t, c.want, lineCode(c.comment.FilePath, oldPos, newPos))
+	}
+
+	_, _, ok := lineDiffPositions(mainGoMRDiff, vcs.InlineComment{FilePath: "main.go", NewLine: 40})
+	assert.False(t, ok, "a line outside every hunk")
+}
+
+func TestPostInlineComment_RetriesRejectedPositionWithLineRange(t *testing.T) {
+	p, positions := rejectFirstPosition(t, `{"message":"400 Bad request - Note {:line_code=>[\"can't be blank\", \"must be a valid line code\"]}"}`)
+
+	refs := vcs.DiffRefs{BaseSHA: "aaa", HeadSHA: "bbb", StartSHA: "ccc"}
+	comment := vcs.InlineComment{FilePath: "main.go", NewLine: 10, Body: "Fix this"}
+
+	err := p.PostInlineComment(context.Background(), "grp/proj", 42, refs, comment)
+	require.NoError(t, err)
+	require.Len(t, *positions, 2)
+	_, firstHasRange := (*positions)[0]["line_range"]
+	assert.False(t, firstHasRange)
+
+	lineRange, ok := (*positions)[1]["line_range"].(map[string]interface{})
+	require.True(t, ok)
+	start, ok := lineRange["start"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "new", start["type"])
+	assert.Equal(t, float64(10), start["new_line"])
+	assert.Equal(t, lineCode("main.go", 11, 10), start["line_code"])
+	assert.Equal(t, start, lineRange["end"])
+}
+
+func TestPostInlineComment_RetriesRemovedLineOnTheOldSide(t *testing.T) {
+	p, positions := rejectFirstPosition(t, `{"message":"400 Bad request - Note {:position=>[\"is invalid\"]}"}`)
+
+	refs := vcs.DiffRefs{BaseSHA: "aaa", HeadSHA: "bbb", StartSHA: "ccc"}
+	comment := vcs.InlineComment{FilePath: "main.go", OldLine: 10, OnDeleted: true, Body: "Fix this"}
+
+	require.NoError(t, p.PostInlineComment(context.Background(), "grp/proj", 42, refs, comment))
+	require.Len(t, *positions, 2)
+	_, hasNewLine := (*positions)[1]["new_line"]
+	assert.False(t, hasNewLine)
+	start := (*positions)[1]["line_range"].(map[string]interface{})["start"].(map[string]interface{})
+	assert.Equal(t, "old", start["type"])
+	assert.Equal(t, float64(10), start["old_line"])
+	assert.Equal(t, lineCode("main.go", 10, 10), start["line_code"])
+}
+
+func TestPostInlineComment_RejectedTwiceReturnsPositionError(t *testing.T) {
+	posts := 0
+	p := newTestProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			json.NewEncoder(w).Encode([]map[string]interface{}{
+				{"old_path": "main.go", "new_path": "main.go", "diff": mainGoMRDiff},
+			})
+			return
+		}
+		posts++
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"400 Bad request - Note {:line_code=>[\"must be a valid line code\"]}"}`))
+	}))
+
+	refs := vcs.DiffRefs{BaseSHA: "aaa", HeadSHA: "bbb", StartSHA: "ccc"}
+	err := p.PostInlineComment(context.Background(), "grp/proj", 42, refs, vcs.InlineComment{FilePath: "main.go", NewLine: 10, Body: "x"})
+	require.Error(t, err)
+	var posErr *vcs.InlinePositionError
+	assert.True(t, errors.As(err, &posErr))
+	assert.Equal(t, 2, posts)
+}
+
+func TestPostInlineComment_LineMissingFromDiffIsNotRetried(t *testing.T) {
+	p, positions := rejectFirstPosition(t, `{"message":"400 Bad request - Note {:line_code=>[\"must be a valid line code\"]}"}`)
+
+	refs := vcs.DiffRefs{BaseSHA: "aaa", HeadSHA: "bbb", StartSHA: "ccc"}
+	err := p.PostInlineComment(context.Background(), "grp/proj", 42, refs, vcs.InlineComment{FilePath: "main.go", NewLine: 40, Body: "x"})
+	assert.True(t, errors.As(err, new(*vcs.InlinePositionError)))
+	assert.Len(t, *positions, 1)
+}
+
+func TestPostInlineComment_OtherErrorsAreNotRetried(t *testing.T) {
+	calls := 0
+	p := newTestProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message":"403 Forbidden"}`))
+	}))
+
+	refs := vcs.DiffRefs{BaseSHA: "aaa", HeadSHA: "bbb", StartSHA: "ccc"}
+	err := p.PostInlineComment(context.Background(), "grp/proj", 42, refs, vcs.InlineComment{FilePath: "main.go", NewLine: 12, Body: "x"})
+	require.Error(t, err)
+	assert.False(t, errors.As(err, new(*vcs.InlinePositionError)))
+	assert.Equal(t, 1, calls)
+}
+
 func TestListOpenMRs(t *testing.T) {
 	p := newTestProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode([]map[string]interface{}{
@@ -208,6 +412,34 @@ func TestListMRDiscussions(t *testing.T) {
 	assert.Equal(t, "bot", discussions[0].Notes[0].Author)
 	assert.Equal(t, "public/index.php", discussions[0].Notes[0].FilePath)
 	assert.Equal(t, 29, discussions[0].Notes[0].Line)
+	assert.False(t, discussions[0].Notes[0].OnDeleted)
+}
+
+func TestListMRDiscussions_RemovedLineNote(t *testing.T) {
+	p := newTestProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]map[string]interface{}{
+			{
+				"id": "d1",
+				"notes": []map[string]interface{}{
+					{
+						"id":   10,
+						"body": "[HIGH] removed guard",
+						"position": map[string]interface{}{
+							"new_path": "a.go",
+							"new_line": nil,
+							"old_line": 12,
+						},
+					},
+				},
+			},
+		})
+	}))
+
+	discussions, err := p.ListMRDiscussions(context.Background(), "grp/proj", 42)
+	require.NoError(t, err)
+	require.Len(t, discussions, 1)
+	assert.Equal(t, 12, discussions[0].Notes[0].Line)
+	assert.True(t, discussions[0].Notes[0].OnDeleted)
 }
 
 func TestListMRNotes(t *testing.T) {
@@ -257,3 +489,137 @@ func TestFormatSuggestionBlock(t *testing.T) {
 	result := p.FormatSuggestionBlock("fixed code here")
 	assert.Equal(t, "```suggestion:-0+0\nfixed code here\n```", result)
 }
+
+func TestCreateStatus_MapsFailureState(t *testing.T) {
+	var gotPath string
+	var gotReq map[string]string
+	p := newTestProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&gotReq)
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": 1})
+	}))
+
+	err := p.(vcs.StatusReporter).CreateStatus(context.Background(), "grp/proj", "abc123", vcs.StatusFailure, "1 CRITICAL", "prev/review")
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v4/projects/grp/proj/statuses/abc123", gotPath)
+	assert.Equal(t, "failed", gotReq["state"])
+	assert.Equal(t, "prev/review", gotReq["name"])
+	assert.Equal(t, "1 CRITICAL", gotReq["description"])
+}
+
+func TestResolveMRDiscussion(t *testing.T) {
+	var gotMethod, gotPath, gotResolved string
+	p := newTestProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotPath = r.URL.Path
+		gotResolved = r.URL.Query().Get("resolved")
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": "d1"})
+	}))
+
+	err := p.ResolveMRDiscussion(context.Background(), "grp/proj", 42, "d1")
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodPut, gotMethod)
+	assert.Equal(t, "/api/v4/projects/grp/proj/merge_requests/42/discussions/d1", gotPath)
+	assert.Equal(t, "true", gotResolved)
+}
+
+func TestUpdateInlineComment(t *testing.T) {
+	var gotMethod, gotPath, gotBody string
+	p := newTestProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotPath = r.URL.Path
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		gotBody, _ = req["body"].(string)
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": 77})
+	}))
+
+	err := p.(vcs.InlineCommentUpdater).UpdateInlineComment(context.Background(), "grp/proj", 42, 77, "better body")
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodPut, gotMethod)
+	assert.Equal(t, "/api/v4/projects/grp/proj/merge_requests/42/notes/77", gotPath)
+	assert.Equal(t, "better body", gotBody)
+}
+
+func TestFetchMRCommits_OldestFirst(t *testing.T) {
+	var gotPath string
+	p := newTestProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"id": "bbb", "title": "Fix nil check", "message": "Fix nil check\n\nGuards the cache lookup.", "author_name": "Ann"},
+			{"id": "aaa", "title": "Add cache", "message": "Add cache", "author_name": "Bob"},
+		})
+	}))
+
+	commits, err := p.(vcs.MRCommitLister).FetchMRCommits(context.Background(), "grp/proj", 7)
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v4/projects/grp/proj/merge_requests/7/commits", gotPath)
+	assert.Equal(t, []vcs.Commit{
+		{SHA: "aaa", Title: "Add cache", Message: "Add cache", Author: "Bob"},
+		{SHA: "bbb", Title: "Fix nil check", Message: "Fix nil check\n\nGuards the cache lookup.", Author: "Ann"},
+	}, commits)
+}
+
+func TestCompareDiffs(t *testing.T) {
+	var gotPath string
+	var gotQuery url.Values
+	p := newTestProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotQuery = r.URL.Query()
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"diffs": []map[string]interface{}{
+				{"old_path": "a.go", "new_path": "a.go", "diff": "@@ -1 +1,2 @@\n a\n+b\n"},
+				{"old_path": "", "new_path": "new.go", "diff": "@@ -0,0 +1 @@\n+n\n", "new_file": true},
+			},
+		})
+	}))
+
+	diffs, err := p.(vcs.CompareDiffer).CompareDiffs(context.Background(), "grp/proj", "base", "head")
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v4/projects/grp/proj/repository/compare", gotPath)
+	assert.Equal(t, "base", gotQuery.Get("from"))
+	assert.Equal(t, "head", gotQuery.Get("to"))
+	assert.Equal(t, "true", gotQuery.Get("straight"))
+	assert.Equal(t, []vcs.FileDiff{
+		{OldPath: "a.go", NewPath: "a.go", Diff: "@@ -1 +1,2 @@\n a\n+b\n"},
+		{NewPath: "new.go", Diff: "@@ -0,0 +1 @@\n+n\n", NewFile: true},
+	}, diffs)
+}
+
+func TestSetMRLabelsAndDescription(t *testing.T) {
+	var gotMethod, gotPath string
+	var got []map[string]interface{}
+	p := newTestProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotPath = r.URL.Path
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		got = append(got, req)
+		json.NewEncoder(w).Encode(map[string]interface{}{"iid": 42})
+	}))
+
+	editor := p.(vcs.MRMetadataEditor)
+	require.NoError(t, editor.SetMRLabels(context.Background(), "grp/proj", 42, []string{"prev:needs-work"}, []string{"prev:looks-good"}))
+	require.NoError(t, editor.UpdateMRDescription(context.Background(), "grp/proj", 42, "new description"))
+
+	assert.Equal(t, http.MethodPut, gotMethod)
+	assert.Equal(t, "/api/v4/projects/grp/proj/merge_requests/42", gotPath)
+	require.Len(t, got, 2)
+	assert.Equal(t, map[string]interface{}{"add_labels": "prev:needs-work", "remove_labels": "prev:looks-good"}, got[0])
+	assert.Equal(t, map[string]interface{}{"description": "new description"}, got[1])
+}
+
+func TestCurrentUser(t *testing.T) {
+	var gotPath, gotToken string
+	p := newTestProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotToken = r.Header.Get("PRIVATE-TOKEN")
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": 7, "username": "alice"})
+	}))
+
+	user, err := p.(vcs.AuthChecker).CurrentUser(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v4/user", gotPath)
+	assert.Equal(t, "test-token", gotToken)
+	assert.Equal(t, "alice", user)
+}
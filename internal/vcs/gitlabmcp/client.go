@@ -0,0 +1,357 @@
+// Package gitlabmcp fetches GitLab merge request metadata and diffs through a
+// GitLab MCP server (GITLAB_MCP_URL) over MCP's streamable HTTP transport.
+package gitlabmcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sanix-darker/prev/internal/vcs"
+)
+
+// Tool names exposed by GitLab MCP servers for merge request context.
+const (
+	toolGetMergeRequest      = "get_merge_request"
+	toolGetMergeRequestDiffs = "get_merge_request_diffs"
+)
+
+const sessionHeader = "Mcp-Session-Id"
+
+// Client talks JSON-RPC 2.0 to a GitLab MCP server. It implements
+// vcs.MRContextSource.
+type Client struct {
+	url    string
+	token  string
+	client *http.Client
+
+	mu          sync.Mutex
+	reqID       int
+	sessionID   string
+	initialized bool
+}
+
+type jsonRPCRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      *int        `json:"id,omitempty"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+type jsonRPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int             `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonRPCError   `json:"error,omitempty"`
+}
+
+type jsonRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type toolResult struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	IsError bool `json:"isError"`
+}
+
+// New returns a client for the MCP server at serverURL. token, when set, is
+// sent as a bearer token.
+func New(serverURL, token string) *Client {
+	return &Client{
+		url:    strings.TrimSpace(serverURL),
+		token:  strings.TrimSpace(token),
+		client: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Name identifies the source in status output.
+func (c *Client) Name() string { return "gitlab-mcp" }
+
+// URL returns the MCP server URL.
+func (c *Client) URL() string { return c.url }
+
+// FetchMR fetches merge request metadata via the get_merge_request tool.
+func (c *Client) FetchMR(ctx context.Context, projectID string, mrIID int64) (*vcs.MergeRequest, error) {
+	text, err := c.callTool(ctx, toolGetMergeRequest, map[string]interface{}{
+		"project_id":        projectID,
+		"merge_request_iid": fmt.Sprintf("%d", mrIID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gitlab-mcp: failed to fetch MR !%d: %w", mrIID, err)
+	}
+
+	var mr struct {
+		IID         int64  `json:"iid"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Author      struct {
+			Username string `json:"username"`
+		} `json:"author"`
+		SourceBranch string `json:"source_branch"`
+		TargetBranch string `json:"target_branch"`
+		State        string `json:"state"`
+		WebURL       string `json:"web_url"`
+		DiffRefs     struct {
+			BaseSha  string `json:"base_sha"`
+			HeadSha  string `json:"head_sha"`
+			StartSha string `json:"start_sha"`
+		} `json:"diff_refs"`
+	}
+	if err := json.Unmarshal([]byte(text), &mr); err != nil {
+		return nil, fmt.Errorf("gitlab-mcp: failed to parse MR !%d: %w", mrIID, err)
+	}
+	if mr.IID == 0 && mr.Title == "" {
+		return nil, fmt.Errorf("gitlab-mcp: empty MR !%d response", mrIID)
+	}
+
+	return &vcs.MergeRequest{
+		IID:          mr.IID,
+		Title:        mr.Title,
+		Description:  mr.Description,
+		Author:       mr.Author.Username,
+		SourceBranch: mr.SourceBranch,
+		TargetBranch: mr.TargetBranch,
+		State:        mr.State,
+		WebURL:       mr.WebURL,
+		DiffRefs: vcs.DiffRefs{
+			BaseSHA:  mr.DiffRefs.BaseSha,
+			HeadSHA:  mr.DiffRefs.HeadSha,
+			StartSHA: mr.DiffRefs.StartSha,
+		},
+	}, nil
+}
+
+// FetchMRDiffs fetches per-file diffs via the get_merge_request_diffs tool.
+// Servers return either a bare array or a {"changes": [...]} object.
+func (c *Client) FetchMRDiffs(ctx context.Context, projectID string, mrIID int64) ([]vcs.FileDiff, error) {
+	text, err := c.callTool(ctx, toolGetMergeRequestDiffs, map[string]interface{}{
+		"project_id":        projectID,
+		"merge_request_iid": fmt.Sprintf("%d", mrIID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gitlab-mcp: failed to fetch MR diffs: %w", err)
+	}
+
+	type apiDiff struct {
+		OldPath     string `json:"old_path"`
+		NewPath     string `json:"new_path"`
+		Diff        string `json:"diff"`
+		NewFile     bool   `json:"new_file"`
+		RenamedFile bool   `json:"renamed_file"`
+		DeletedFile bool   `json:"deleted_file"`
+		AMode       string `json:"a_mode"`
+		BMode       string `json:"b_mode"`
+	}
+	var diffs []apiDiff
+	trimmed := strings.TrimSpace(text)
+	if strings.HasPrefix(trimmed, "{") {
+		var wrapped struct {
+			Changes []apiDiff `json:"changes"`
+		}
+		if err := json.Unmarshal([]byte(trimmed), &wrapped); err != nil {
+			return nil, fmt.Errorf("gitlab-mcp: failed to parse MR diffs: %w", err)
+		}
+		diffs = wrapped.Changes
+	} else if err := json.Unmarshal([]byte(trimmed), &diffs); err != nil {
+		return nil, fmt.Errorf("gitlab-mcp: failed to parse MR diffs: %w", err)
+	}
+	if len(diffs) == 0 {
+		return nil, fmt.Errorf("gitlab-mcp: no diffs returned for MR !%d", mrIID)
+	}
+
+	out := make([]vcs.FileDiff, 0, len(diffs))
+	for _, d := range diffs {
+		out = append(out, vcs.FileDiff{
+			OldPath:     d.OldPath,
+			NewPath:     d.NewPath,
+			Diff:        d.Diff,
+			NewFile:     d.NewFile,
+			RenamedFile: d.RenamedFile,
+			DeletedFile: d.DeletedFile,
+			AMode:       d.AMode,
+			BMode:       d.BMode,
+		})
+	}
+	return out, nil
+}
+
+// callTool invokes an MCP tool and returns its concatenated text content.
+func (c *Client) callTool(ctx context.Context, name string, args map[string]interface{}) (string, error) {
+	if err := c.ensureInitialized(ctx); err != nil {
+		return "", err
+	}
+	raw, err := c.call(ctx, "tools/call", map[string]interface{}{
+		"name":      name,
+		"arguments": args,
+	})
+	if err != nil {
+		return "", err
+	}
+	var res toolResult
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return "", fmt.Errorf("failed to parse %s result: %w", name, err)
+	}
+	var sb strings.Builder
+	for _, part := range res.Content {
+		if part.Type == "text" {
+			sb.WriteString(part.Text)
+		}
+	}
+	text := sb.String()
+	if res.IsError {
+		return "", fmt.Errorf("tool %s failed: %s", name, strings.TrimSpace(text))
+	}
+	if strings.TrimSpace(text) == "" {
+		return "", fmt.Errorf("tool %s returned no text content", name)
+	}
+	return text, nil
+}
+
+func (c *Client) ensureInitialized(ctx context.Context) error {
+	c.mu.Lock()
+	done := c.initialized
+	c.mu.Unlock()
+	if done {
+		return nil
+	}
+
+	_, err := c.call(ctx, "initialize", map[string]interface{}{
+		"protocolVersion": "2025-03-26",
+		"capabilities":    map[string]interface{}{},
+		"clientInfo": map[string]string{
+			"name":    "prev",
+			"version": "1.0.0",
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("mcp handshake failed: %w", err)
+	}
+	if err := c.notify(ctx, "notifications/initialized"); err != nil {
+		return fmt.Errorf("mcp handshake failed: %w", err)
+	}
+
+	c.mu.Lock()
+	c.initialized = true
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Client) call(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	c.mu.Lock()
+	c.reqID++
+	id := c.reqID
+	c.mu.Unlock()
+
+	resp, err := c.post(ctx, jsonRPCRequest{JSONRPC: "2.0", ID: &id, Method: method, Params: params})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := readRPCBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	var rpcResp jsonRPCResponse
+	if err := json.Unmarshal(body, &rpcResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if rpcResp.Error != nil {
+		return nil, fmt.Errorf("mcp error %d: %s", rpcResp.Error.Code, rpcResp.Error.Message)
+	}
+	return rpcResp.Result, nil
+}
+
+func (c *Client) notify(ctx context.Context, method string) error {
+	resp, err := c.post(ctx, jsonRPCRequest{JSONRPC: "2.0", Method: method})
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return nil
+}
+
+func (c *Client) post(ctx context.Context, req jsonRPCRequest) (*http.Response, error) {
+	if c.url == "" {
+		return nil, fmt.Errorf("mcp server URL is empty")
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json, text/event-stream")
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	c.mu.Lock()
+	if c.sessionID != "" {
+		httpReq.Header.Set(sessionHeader, c.sessionID)
+	}
+	c.mu.Unlock()
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if sid := strings.TrimSpace(resp.Header.Get(sessionHeader)); sid != "" {
+		c.mu.Lock()
+		c.sessionID = sid
+		c.mu.Unlock()
+	}
+	return resp, nil
+}
+
+// readRPCBody returns the JSON-RPC payload of a response, reading the first
+// SSE data event when the server answers with text/event-stream.
+func readRPCBody(resp *http.Response) ([]byte, error) {
+	if !strings.HasPrefix(strings.ToLower(resp.Header.Get("Content-Type")), "text/event-stream") {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		return body, nil
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "data:") {
+			data.WriteString(strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+			continue
+		}
+		if line == "" && data.Len() > 0 {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read event stream: %w", err)
+	}
+	if data.Len() == 0 {
+		return nil, fmt.Errorf("event stream carried no data")
+	}
+	return []byte(data.String()), nil
+}
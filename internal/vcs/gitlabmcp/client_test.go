@@ -0,0 +1,121 @@
+package gitlabmcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMCPServer serves initialize/tools/call the way a GitLab MCP server does.
+// Tool results are keyed by tool name; sse switches responses to SSE framing.
+func newMCPServer(t *testing.T, tools map[string]string, sse bool) (*httptest.Server, *[]string) {
+	t.Helper()
+	var methods []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     *int   `json:"id"`
+			Method string `json:"method"`
+			Params struct {
+				Name      string            `json:"name"`
+				Arguments map[string]string `json:"arguments"`
+			} `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		methods = append(methods, req.Method)
+		if req.ID == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		if req.Method != "initialize" {
+			assert.Equal(t, "sess-1", r.Header.Get(sessionHeader))
+		}
+
+		var result interface{}
+		switch req.Method {
+		case "initialize":
+			w.Header().Set(sessionHeader, "sess-1")
+			result = map[string]interface{}{"protocolVersion": "2025-03-26"}
+		case "tools/call":
+			assert.Equal(t, "grp/proj", req.Params.Arguments["project_id"])
+			assert.Equal(t, "7", req.Params.Arguments["merge_request_iid"])
+			text, ok := tools[req.Params.Name]
+			result = map[string]interface{}{
+				"content": []map[string]string{{"type": "text", "text": text}},
+				"isError": !ok,
+			}
+		}
+		payload, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": *req.ID, "result": result})
+		require.NoError(t, err)
+		if sse {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", payload)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(payload)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &methods
+}
+
+func TestClient_FetchMRAndDiffs(t *testing.T) {
+	for _, sse := range []bool{false, true} {
+		t.Run(fmt.Sprintf("sse=%t", sse), func(t *testing.T) {
+			srv, methods := newMCPServer(t, map[string]string{
+				toolGetMergeRequest:      `{"iid":7,"title":"Add login","source_branch":"feat","target_branch":"main","author":{"username":"dev"},"diff_refs":{"base_sha":"b1","head_sha":"h1","start_sha":"s1"}}`,
+				toolGetMergeRequestDiffs: `{"changes":[{"old_path":"a.go","new_path":"a.go","diff":"@@ -1 +1,2 @@\n a\n+b\n"}]}`,
+			}, sse)
+			c := New(srv.URL, "")
+
+			mr, err := c.FetchMR(context.Background(), "grp/proj", 7)
+			require.NoError(t, err)
+			assert.Equal(t, int64(7), mr.IID)
+			assert.Equal(t, "Add login", mr.Title)
+			assert.Equal(t, "dev", mr.Author)
+			assert.Equal(t, "h1", mr.DiffRefs.HeadSHA)
+
+			diffs, err := c.FetchMRDiffs(context.Background(), "grp/proj", 7)
+			require.NoError(t, err)
+			require.Len(t, diffs, 1)
+			assert.Equal(t, "a.go", diffs[0].NewPath)
+
+			assert.Equal(t, []string{"initialize", "notifications/initialized", "tools/call", "tools/call"}, *methods)
+		})
+	}
+}
+
+func TestClient_FetchMRDiffsAcceptsBareArray(t *testing.T) {
+	srv, _ := newMCPServer(t, map[string]string{
+		toolGetMergeRequestDiffs: `[{"old_path":"a.go","new_path":"b.go","renamed_file":true,"diff":"@@ -1 +1 @@\n-a\n+b\n"}]`,
+	}, false)
+
+	diffs, err := New(srv.URL, "").FetchMRDiffs(context.Background(), "grp/proj", 7)
+	require.NoError(t, err)
+	require.Len(t, diffs, 1)
+	assert.True(t, diffs[0].RenamedFile)
+}
+
+func TestClient_ToolErrorIsReported(t *testing.T) {
+	srv, _ := newMCPServer(t, map[string]string{}, false)
+
+	_, err := New(srv.URL, "").FetchMR(context.Background(), "grp/proj", 7)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tool get_merge_request failed")
+}
+
+func TestClient_HTTPErrorIsReported(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	_, err := New(srv.URL, "bad").FetchMR(context.Background(), "grp/proj", 7)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "HTTP 401")
+}
@@ -4,6 +4,7 @@
 package init
 
 import (
+	_ "github.com/sanix-darker/prev/internal/vcs/azuredevops"
 	_ "github.com/sanix-darker/prev/internal/vcs/github"
 	_ "github.com/sanix-darker/prev/internal/vcs/gitlab"
 )
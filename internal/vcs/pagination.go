@@ -0,0 +1,79 @@
+package vcs
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// DefaultMaxPages caps pagination loops when vcs.max_pages is unset. At 100
+// items per page this still covers very large MRs.
+const DefaultMaxPages = 200
+
+var (
+	paginationMu       sync.RWMutex
+	paginationMaxPages = DefaultMaxPages
+	paginationDebug    bool
+	paginationLog      io.Writer = os.Stderr
+)
+
+// ConfigurePagination sets the page cap and debug logging used by provider
+// pagination loops. maxPages <= 0 restores DefaultMaxPages.
+func ConfigurePagination(maxPages int, debug bool) {
+	paginationMu.Lock()
+	defer paginationMu.Unlock()
+	if maxPages <= 0 {
+		maxPages = DefaultMaxPages
+	}
+	paginationMaxPages = maxPages
+	paginationDebug = debug
+}
+
+// Pager tracks a single pagination loop: it logs per-page progress in debug
+// mode and stops the loop with a warning once the page cap is reached.
+type Pager struct {
+	Provider string
+	Resource string
+	Page     int
+	Items    int
+
+	maxPages int
+	debug    bool
+	log      io.Writer
+}
+
+// NewPager starts a pagination loop at page 1.
+func NewPager(provider, resource string) *Pager {
+	paginationMu.RLock()
+	defer paginationMu.RUnlock()
+	return &Pager{
+		Provider: provider,
+		Resource: resource,
+		Page:     1,
+		maxPages: paginationMaxPages,
+		debug:    paginationDebug,
+		log:      paginationLog,
+	}
+}
+
+// Next records the items fetched on the current page and reports whether the
+// loop should request another page. It returns false when the API reports no
+// next page or when the page cap is hit.
+func (p *Pager) Next(items int, hasNext bool) bool {
+	p.Items += items
+	if p.debug {
+		fmt.Fprintf(p.log, "[debug] %s: %s page %d fetched %d items (total %d)\n",
+			p.Provider, p.Resource, p.Page, items, p.Items)
+	}
+	if !hasNext {
+		return false
+	}
+	if p.Page >= p.maxPages {
+		fmt.Fprintf(p.log, "Warning: %s: stopped fetching %s after %d pages (vcs.max_pages); results may be incomplete.\n",
+			p.Provider, p.Resource, p.maxPages)
+		return false
+	}
+	p.Page++
+	return true
+}
@@ -0,0 +1,30 @@
+package vcs
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPager_LogsPagesAndWarnsAtCap(t *testing.T) {
+	var buf bytes.Buffer
+	prevLog := paginationLog
+	paginationLog = &buf
+	ConfigurePagination(2, true)
+	t.Cleanup(func() {
+		paginationLog = prevLog
+		ConfigurePagination(0, false)
+	})
+
+	p := NewPager("gitlab", "MR notes")
+	assert.True(t, p.Next(100, true))
+	assert.Equal(t, 2, p.Page)
+	assert.False(t, p.Next(40, true))
+	assert.Equal(t, 140, p.Items)
+
+	out := buf.String()
+	assert.Contains(t, out, "[debug] gitlab: MR notes page 1 fetched 100 items (total 100)")
+	assert.Contains(t, out, "[debug] gitlab: MR notes page 2 fetched 40 items (total 140)")
+	assert.Contains(t, out, "Warning: gitlab: stopped fetching MR notes after 2 pages (vcs.max_pages)")
+}
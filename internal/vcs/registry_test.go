@@ -33,6 +33,9 @@ func (m *mockProvider) PostInlineComment(context.Context, string, int64, DiffRef
 func (m *mockProvider) ReplyToMRDiscussion(context.Context, string, int64, string, string) error {
 	return nil
 }
+func (m *mockProvider) ResolveMRDiscussion(context.Context, string, int64, string) error {
+	return nil
+}
 
 func mockFactory(token, baseURL string) (VCSProvider, error) {
 	return &mockProvider{}, nil
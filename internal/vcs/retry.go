@@ -0,0 +1,254 @@
+package vcs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxRetries is the number of retries for a VCS API call when
+// review.vcs_max_retries is unset.
+const DefaultMaxRetries = 3
+
+const (
+	retryInitialInterval = 500 * time.Millisecond
+	retryMaxInterval     = 30 * time.Second
+	retryMaxAfter        = 2 * time.Minute
+)
+
+var (
+	retryMu         sync.RWMutex
+	retryMaxRetries           = DefaultMaxRetries
+	retryLog        io.Writer = os.Stderr
+
+	// retrySleep waits for d or until ctx is done; replaced in tests.
+	retrySleep = func(ctx context.Context, d time.Duration) error {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+			return nil
+		}
+	}
+)
+
+// ConfigureRetry sets how many times VCS API calls are retried. maxRetries < 0
+// restores DefaultMaxRetries; 0 disables retries.
+func ConfigureRetry(maxRetries int) {
+	retryMu.Lock()
+	defer retryMu.Unlock()
+	if maxRetries < 0 {
+		maxRetries = DefaultMaxRetries
+	}
+	retryMaxRetries = maxRetries
+}
+
+// DoWithRetry sends req with client, retrying rate limits (429, or GitHub's
+// 403 rate limits; see rateLimitWait) and transient server errors with
+// exponential backoff.
+//
+// GET, HEAD, PUT and DELETE are retried on network errors and any 5xx.
+// POST and PATCH are not idempotent, so they are only retried when the server
+// signals it did not process the request (rate limit or 503); a response that was
+// received, successful or not, is never replayed otherwise.
+//
+// Requests with a body must be replayable via req.GetBody, which
+// http.NewRequest sets for bytes, strings and nil bodies.
+func DoWithRetry(client *http.Client, req *http.Request) (*http.Response, error) {
+	retryMu.RLock()
+	maxRetries := retryMaxRetries
+	log := retryLog
+	retryMu.RUnlock()
+
+	ctx := req.Context()
+	interval := retryInitialInterval
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.Body != nil && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		resp, err := client.Do(req)
+		wait, retry := retryDecision(req, resp, err, interval)
+		if !retry || attempt >= maxRetries || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+
+		reason := "network error"
+		if resp != nil {
+			reason = fmt.Sprintf("HTTP %d", resp.StatusCode)
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		fmt.Fprintf(log, "Warning: %s %s failed (%s); retrying in %s (%d/%d).\n",
+			req.Method, req.URL.Path, reason, wait.Round(time.Millisecond), attempt+1, maxRetries)
+		if err := retrySleep(ctx, wait); err != nil {
+			return nil, err
+		}
+		interval = minDuration(interval*2, retryMaxInterval)
+	}
+}
+
+// HTTPError is a non-2xx VCS API response. RetryAfter holds the server's
+// requested delay, if any, capped at retryMaxAfter. RateLimit marks a
+// response that is a rate limit despite not being a 429.
+type HTTPError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration
+	RateLimit  bool
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%s: HTTP %d: %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// NewHTTPError builds an HTTPError from resp and its already-read body.
+func NewHTTPError(provider string, resp *http.Response, body []byte) *HTTPError {
+	e := &HTTPError{Provider: provider, StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	if d, limited := rateLimitWait(resp, time.Now()); limited {
+		e.RetryAfter = d
+		e.RateLimit = resp.StatusCode != http.StatusTooManyRequests
+	} else if d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+		e.RetryAfter = d
+	}
+	return e
+}
+
+// RateLimited reports whether err is a rate-limit HTTPError and returns the
+// server's requested delay (0 when absent), capped at retryMaxAfter.
+func RateLimited(err error) (time.Duration, bool) {
+	var he *HTTPError
+	if !errors.As(err, &he) || (he.StatusCode != http.StatusTooManyRequests && !he.RateLimit) {
+		return 0, false
+	}
+	return minDuration(max(he.RetryAfter, 0), retryMaxAfter), true
+}
+
+// rateLimitWait reports whether resp is a rate limit and how long the server
+// asked to wait (0 when it did not say). Besides 429, GitHub answers both its
+// primary limit (x-ratelimit-remaining: 0, with the reset time in
+// x-ratelimit-reset) and its secondary limits (Retry-After) with a 403. The
+// wait is capped at retryMaxAfter so a bad header cannot stall the run.
+func rateLimitWait(resp *http.Response, now time.Time) (time.Duration, bool) {
+	retryAfter, hasRetryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), now)
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return retryAfter, true
+	case http.StatusForbidden:
+		if hasRetryAfter {
+			return retryAfter, true
+		}
+		if strings.TrimSpace(resp.Header.Get("X-RateLimit-Remaining")) != "0" {
+			return 0, false
+		}
+		if reset, err := strconv.ParseInt(strings.TrimSpace(resp.Header.Get("X-RateLimit-Reset")), 10, 64); err == nil {
+			return minDuration(max(time.Unix(reset, 0).Sub(now), 0), retryMaxAfter), true
+		}
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+// InlinePositionError reports that a provider rejected an inline comment's
+// diff position, including any provider-specific fallback placement. The
+// finding itself is fine; callers list it with the unplaced findings.
+type InlinePositionError struct {
+	Provider string
+	Err      error
+}
+
+func (e *InlinePositionError) Error() string {
+	return fmt.Sprintf("%s: inline position rejected: %v", e.Provider, e.Err)
+}
+
+func (e *InlinePositionError) Unwrap() error { return e.Err }
+
+// retryDecision reports whether the outcome of one attempt is retryable and
+// how long to wait first.
+func retryDecision(req *http.Request, resp *http.Response, err error, interval time.Duration) (time.Duration, bool) {
+	idempotent := isIdempotentMethod(req.Method)
+	if err != nil {
+		if req.Context().Err() != nil {
+			return 0, false
+		}
+		return backoff(interval), idempotent
+	}
+	if d, limited := rateLimitWait(resp, time.Now()); limited {
+		if d > 0 {
+			return d, true
+		}
+		return backoff(interval), true
+	}
+	switch {
+	case resp.StatusCode == http.StatusServiceUnavailable:
+		return backoff(interval), true
+	case resp.StatusCode >= 500:
+		return backoff(interval), idempotent
+	default:
+		return 0, false
+	}
+}
+
+func isIdempotentMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// backoff returns interval with +/-50% jitter.
+func backoff(interval time.Duration) time.Duration {
+	if interval <= 0 {
+		return 0
+	}
+	return interval/2 + time.Duration(rand.Int63n(int64(interval)))
+}
+
+// parseRetryAfter accepts delta-seconds or an HTTP date, capped at
+// retryMaxAfter.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	var d time.Duration
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		d = time.Duration(secs) * time.Second
+	} else if at, err := http.ParseTime(value); err == nil {
+		d = at.Sub(now)
+		if d < 0 {
+			d = 0
+		}
+	} else {
+		return 0, false
+	}
+	return minDuration(d, retryMaxAfter), true
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
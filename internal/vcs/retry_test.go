@@ -0,0 +1,217 @@
+package vcs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubRetry records sleeps instead of waiting and captures retry warnings.
+func stubRetry(t *testing.T, maxRetries int) (*[]time.Duration, *bytes.Buffer) {
+	t.Helper()
+	var sleeps []time.Duration
+	var buf bytes.Buffer
+	prevSleep, prevLog := retrySleep, retryLog
+	retrySleep = func(_ context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+	retryLog = &buf
+	ConfigureRetry(maxRetries)
+	t.Cleanup(func() {
+		retrySleep, retryLog = prevSleep, prevLog
+		ConfigureRetry(-1)
+	})
+	return &sleeps, &buf
+}
+
+// statusSequence serves the given statuses in order, then 200 with "ok".
+func statusSequence(t *testing.T, statuses []int, header http.Header) (*httptest.Server, *[]string) {
+	t.Helper()
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		if n := len(bodies); n <= len(statuses) {
+			for k, vs := range header {
+				w.Header()[k] = vs
+			}
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &bodies
+}
+
+func TestDoWithRetry_RetriesGETOnServerErrors(t *testing.T) {
+	sleeps, logBuf := stubRetry(t, 3)
+	srv, calls := statusSequence(t, []int{http.StatusBadGateway, http.StatusInternalServerError}, nil)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/api/v4/projects", nil)
+	require.NoError(t, err)
+	resp, err := DoWithRetry(srv.Client(), req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Len(t, *calls, 3)
+	require.Len(t, *sleeps, 2)
+	assert.Less(t, (*sleeps)[0], (*sleeps)[1]+retryInitialInterval, "backoff grows between attempts")
+	assert.Contains(t, logBuf.String(), "GET /api/v4/projects failed (HTTP 502); retrying")
+}
+
+func TestDoWithRetry_HonorsRetryAfterAndReplaysPOSTBody(t *testing.T) {
+	sleeps, _ := stubRetry(t, 3)
+	srv, bodies := statusSequence(t, []int{http.StatusTooManyRequests}, http.Header{"Retry-After": {"7"}})
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/notes", strings.NewReader(`{"body":"hi"}`))
+	require.NoError(t, err)
+	resp, err := DoWithRetry(srv.Client(), req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{`{"body":"hi"}`, `{"body":"hi"}`}, *bodies)
+	assert.Equal(t, []time.Duration{7 * time.Second}, *sleeps)
+}
+
+func TestDoWithRetry_DoesNotReplayPOSTOnServerError(t *testing.T) {
+	sleeps, _ := stubRetry(t, 3)
+	srv, bodies := statusSequence(t, []int{http.StatusInternalServerError}, nil)
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/notes", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	resp, err := DoWithRetry(srv.Client(), req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Len(t, *bodies, 1)
+	assert.Empty(t, *sleeps)
+}
+
+func TestDoWithRetry_StopsAfterMaxRetries(t *testing.T) {
+	sleeps, _ := stubRetry(t, 1)
+	srv, calls := statusSequence(t, []int{503, 503, 503}, nil)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	resp, err := DoWithRetry(srv.Client(), req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Len(t, *calls, 2)
+	assert.Len(t, *sleeps, 1)
+
+	ConfigureRetry(0)
+	req, err = http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	resp2, err := DoWithRetry(srv.Client(), req)
+	require.NoError(t, err)
+	defer resp2.Body.Close()
+	assert.Len(t, *calls, 3)
+}
+
+func TestDoWithRetry_ClientErrorsAreNotRetried(t *testing.T) {
+	sleeps, _ := stubRetry(t, 3)
+	srv, calls := statusSequence(t, []int{http.StatusNotFound}, nil)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	resp, err := DoWithRetry(srv.Client(), req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Len(t, *calls, 1)
+	assert.Empty(t, *sleeps)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	d, ok := parseRetryAfter("12", now)
+	assert.True(t, ok)
+	assert.Equal(t, 12*time.Second, d)
+
+	d, ok = parseRetryAfter(now.Add(30*time.Second).Format(http.TimeFormat), now)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, d)
+
+	d, ok = parseRetryAfter("3600", now)
+	assert.True(t, ok)
+	assert.Equal(t, retryMaxAfter, d)
+
+	_, ok = parseRetryAfter("soon", now)
+	assert.False(t, ok)
+	_, ok = parseRetryAfter("", now)
+	assert.False(t, ok)
+}
+
+func TestRateLimited_ReadsHTTPError(t *testing.T) {
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"4"}}}
+	err := fmt.Errorf("gitlab: failed to post inline discussion: %w", NewHTTPError("gitlab", resp, []byte(" slow down \n")))
+	assert.Equal(t, "gitlab: failed to post inline discussion: gitlab: HTTP 429: slow down", err.Error())
+
+	wait, limited := RateLimited(err)
+	assert.True(t, limited)
+	assert.Equal(t, 4*time.Second, wait)
+
+	_, limited = RateLimited(NewHTTPError("github", &http.Response{StatusCode: http.StatusBadGateway, Header: http.Header{}}, nil))
+	assert.False(t, limited)
+	_, limited = RateLimited(errors.New("HTTP 429"))
+	assert.False(t, limited)
+}
+
+func TestRateLimited_GitHubForbiddenRateLimits(t *testing.T) {
+	secondary := &http.Response{StatusCode: http.StatusForbidden, Header: http.Header{"Retry-After": []string{"60"}}}
+	wait, limited := RateLimited(NewHTTPError("github", secondary, []byte("You have exceeded a secondary rate limit")))
+	assert.True(t, limited)
+	assert.Equal(t, 60*time.Second, wait)
+
+	reset := strconv.FormatInt(time.Now().Add(3*time.Hour).Unix(), 10)
+	primary := &http.Response{StatusCode: http.StatusForbidden, Header: http.Header{
+		"X-Ratelimit-Remaining": []string{"0"},
+		"X-Ratelimit-Reset":     []string{reset},
+	}}
+	wait, limited = RateLimited(NewHTTPError("github", primary, nil))
+	assert.True(t, limited)
+	assert.Equal(t, retryMaxAfter, wait, "a reset hours away is capped")
+
+	denied := &http.Response{StatusCode: http.StatusForbidden, Header: http.Header{"X-Ratelimit-Remaining": []string{"4999"}}}
+	_, limited = RateLimited(NewHTTPError("github", denied, []byte("Resource not accessible by integration")))
+	assert.False(t, limited)
+
+	wait, limited = RateLimited(&HTTPError{Provider: "gitlab", StatusCode: http.StatusTooManyRequests, RetryAfter: 5 * time.Hour})
+	assert.True(t, limited)
+	assert.Equal(t, retryMaxAfter, wait)
+}
+
+func TestDoWithRetry_RetriesGitHubSecondaryRateLimit(t *testing.T) {
+	sleeps, _ := stubRetry(t, 3)
+	srv, bodies := statusSequence(t, []int{http.StatusForbidden}, http.Header{"Retry-After": []string{"2"}})
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("payload"))
+	require.NoError(t, err)
+	resp, err := DoWithRetry(srv.Client(), req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"payload", "payload"}, *bodies)
+	assert.Equal(t, []time.Duration{2 * time.Second}, *sleeps)
+}
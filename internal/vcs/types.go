@@ -1,6 +1,9 @@
 package vcs
 
-import "context"
+import (
+	"context"
+	"strings"
+)
 
 // VCSProvider abstracts version control system operations (GitLab, GitHub, etc.).
 type VCSProvider interface {
@@ -14,10 +17,109 @@ type VCSProvider interface {
 	PostSummaryNote(ctx context.Context, projectID string, mrIID int64, body string) error
 	PostInlineComment(ctx context.Context, projectID string, mrIID int64, refs DiffRefs, comment InlineComment) error
 	ReplyToMRDiscussion(ctx context.Context, projectID string, mrIID int64, discussionID, body string) error
+	ResolveMRDiscussion(ctx context.Context, projectID string, mrIID int64, discussionID string) error
 	FormatSuggestionBlock(suggestion string) string
 	Validate() error
 }
 
+// MergeBaseResolver is implemented by providers that can report the true
+// merge-base of two commits. It is used to correct DiffRefs for fork MRs whose
+// reported base lags behind the target branch.
+type MergeBaseResolver interface {
+	MergeBase(ctx context.Context, projectID, baseSHA, headSHA string) (string, error)
+}
+
+// CompareDiffer is implemented by providers that can return the per-file
+// diff between two commits through their compare API, so an MR diff with
+// exact line numbers can be built without a local checkout.
+type CompareDiffer interface {
+	CompareDiffs(ctx context.Context, projectID, fromSHA, toSHA string) ([]FileDiff, error)
+}
+
+// MRCommitLister is implemented by providers that can list the commits of
+// a merge request, so their messages can be checked against the diff.
+type MRCommitLister interface {
+	// FetchMRCommits returns the MR's commits, oldest first.
+	FetchMRCommits(ctx context.Context, projectID string, mrIID int64) ([]Commit, error)
+}
+
+// InlineCommentBatcher is implemented by providers that can submit several
+// inline comments in one request (a GitHub pull request review), so
+// reviewers get one notification instead of one per comment.
+type InlineCommentBatcher interface {
+	PostInlineComments(ctx context.Context, projectID string, mrIID int64, refs DiffRefs, comments []InlineComment) error
+}
+
+// DiscussionStarter is implemented by providers that can open a resolvable
+// discussion that is not anchored to a diff position.
+type DiscussionStarter interface {
+	StartMRDiscussion(ctx context.Context, projectID string, mrIID int64, body string) error
+}
+
+// InlineCommentUpdater is implemented by providers that can edit the body
+// of an existing inline comment, so a rerun can replace a finding's wording
+// in place instead of skipping it.
+type InlineCommentUpdater interface {
+	UpdateInlineComment(ctx context.Context, projectID string, mrIID int64, noteID int64, body string) error
+}
+
+// SummaryNoteUpdater is implemented by providers that can edit the body of
+// an existing top-level note (an MRNote ID), so a rerun can refresh a note
+// it posted earlier instead of leaving it stale.
+type SummaryNoteUpdater interface {
+	UpdateSummaryNote(ctx context.Context, projectID string, mrIID int64, noteID int64, body string) error
+}
+
+// MRMetadataEditor is implemented by providers that can change a merge
+// request's labels and description, so a review verdict can be recorded on
+// the MR itself instead of in a summary note.
+type MRMetadataEditor interface {
+	// SetMRLabels adds and removes labels in one call. Removing a label the
+	// MR does not carry is not an error.
+	SetMRLabels(ctx context.Context, projectID string, mrIID int64, add, remove []string) error
+	UpdateMRDescription(ctx context.Context, projectID string, mrIID int64, description string) error
+}
+
+// AuthChecker is implemented by providers that can make a cheap
+// authenticated call to confirm the token works. CurrentUser returns the
+// login of the token's owner.
+type AuthChecker interface {
+	CurrentUser(ctx context.Context) (string, error)
+}
+
+// Commit status states accepted by StatusReporter.CreateStatus.
+const (
+	StatusPending = "pending"
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+)
+
+// StatusReporter is implemented by providers that can set a commit status
+// (GitLab commit status, GitHub commit status) on a head SHA. state is one of
+// StatusPending, StatusSuccess or StatusFailure; name identifies the status.
+type StatusReporter interface {
+	CreateStatus(ctx context.Context, projectID, sha, state, description, name string) error
+}
+
+// TruncateStatusDescription shortens a status description to max runes,
+// ending with "..." when cut. Providers cap descriptions (GitHub: 140).
+func TruncateStatusDescription(s string, max int) string {
+	s = strings.TrimSpace(s)
+	r := []rune(s)
+	if max <= 3 || len(r) <= max {
+		return s
+	}
+	return string(r[:max-3]) + "..."
+}
+
+// MRContextSource is an alternate source for MR metadata and diffs, such as
+// an MCP server, tried before the provider's own API.
+type MRContextSource interface {
+	Name() string
+	FetchMR(ctx context.Context, projectID string, mrIID int64) (*MergeRequest, error)
+	FetchMRDiffs(ctx context.Context, projectID string, mrIID int64) ([]FileDiff, error)
+}
+
 // ProviderInfo describes a VCS provider.
 type ProviderInfo struct {
 	Name    string
@@ -56,6 +158,14 @@ type FileDiff struct {
 	BMode       string
 }
 
+// Commit is one commit of a merge request.
+type Commit struct {
+	SHA     string
+	Title   string
+	Message string
+	Author  string
+}
+
 // InlineComment holds data for posting an inline comment on a diff.
 type InlineComment struct {
 	FilePath string
@@ -63,6 +173,9 @@ type InlineComment struct {
 	NewLine  int64
 	OldLine  int64
 	Body     string
+	// OnDeleted marks a comment on a removed line: NewLine is 0 and OldLine
+	// is the line in the base version (the left side of the diff).
+	OnDeleted bool
 }
 
 // MRDiscussion represents one MR discussion thread.
@@ -73,11 +186,14 @@ type MRDiscussion struct {
 
 // MRDiscussionNote represents one note in an MR discussion.
 type MRDiscussionNote struct {
-	ID         int64
-	Author     string
-	Body       string
-	FilePath   string
-	Line       int
+	ID       int64
+	Author   string
+	Body     string
+	FilePath string
+	Line     int
+	// OnDeleted marks a note anchored to a removed line; Line is then the
+	// old-side line number.
+	OnDeleted  bool
 	Resolved   bool
 	Resolvable bool
 }
@@ -0,0 +1,333 @@
+// Package webhook turns GitLab and GitHub webhook deliveries into prev
+// actions. It verifies each delivery's secret, keeps only merge request
+// pushes and mention commands, and hands the result to a dispatcher.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const (
+	ProviderGitLab = "gitlab"
+	ProviderGitHub = "github"
+)
+
+// Commands carried by an Event.
+const (
+	CommandReview = "review"
+	CommandPause  = "pause"
+	CommandResume = "resume"
+)
+
+// maxBodyBytes caps the size of a webhook delivery.
+const maxBodyBytes = 5 << 20
+
+// prevMarkerPrefix starts every hidden marker prev embeds in the notes it
+// posts (<!-- prev:summary -->, <!-- prev:team-a:thread -->, ...).
+const prevMarkerPrefix = "<!-- prev:"
+
+var (
+	// ErrSecretMismatch is returned when a delivery's token or signature does
+	// not match the configured secret.
+	ErrSecretMismatch = errors.New("webhook secret mismatch")
+
+	// ErrSecretNotConfigured is returned for deliveries from a provider that
+	// has no secret configured; unsigned deliveries are never trusted.
+	ErrSecretNotConfigured = errors.New("webhook secret not configured for provider")
+)
+
+// Event is a verified delivery that asks prev to act on a merge request.
+type Event struct {
+	Provider  string
+	ProjectID string
+	MRIID     int64
+	Command   string
+	Author    string
+}
+
+// VerifyGitLab checks the X-Gitlab-Token header against secret.
+func VerifyGitLab(secret string, headers http.Header) error {
+	if strings.TrimSpace(secret) == "" {
+		return ErrSecretNotConfigured
+	}
+	token := strings.TrimSpace(headers.Get("X-Gitlab-Token"))
+	if subtle.ConstantTimeCompare([]byte(token), []byte(strings.TrimSpace(secret))) != 1 {
+		return ErrSecretMismatch
+	}
+	return nil
+}
+
+// VerifyGitHub checks the X-Hub-Signature-256 header, an HMAC-SHA256 of body
+// keyed with secret.
+func VerifyGitHub(secret string, headers http.Header, body []byte) error {
+	if strings.TrimSpace(secret) == "" {
+		return ErrSecretNotConfigured
+	}
+	sig, ok := strings.CutPrefix(strings.TrimSpace(headers.Get("X-Hub-Signature-256")), "sha256=")
+	if !ok {
+		return ErrSecretMismatch
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return ErrSecretMismatch
+	}
+	mac := hmac.New(sha256.New, []byte(strings.TrimSpace(secret)))
+	mac.Write(body)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return ErrSecretMismatch
+	}
+	return nil
+}
+
+type gitlabEvent struct {
+	ObjectKind string `json:"object_kind"`
+	User       struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+	MergeRequest struct {
+		IID int64 `json:"iid"`
+	} `json:"merge_request"`
+	ObjectAttributes struct {
+		IID          int64  `json:"iid"`
+		Action       string `json:"action"`
+		OldRev       string `json:"oldrev"`
+		Note         string `json:"note"`
+		NoteableType string `json:"noteable_type"`
+	} `json:"object_attributes"`
+}
+
+// ParseGitLab extracts an Event from a GitLab "Merge Request Hook" or
+// "Note Hook" delivery. ok is false for deliveries prev ignores: other event
+// types, MR updates without new commits, notes without a command, and notes
+// written by mentionHandle itself or carrying a prev marker.
+func ParseGitLab(headers http.Header, body []byte, mentionHandle string) (ev Event, ok bool, err error) {
+	kind := strings.TrimSpace(headers.Get("X-Gitlab-Event"))
+	if !strings.EqualFold(kind, "Merge Request Hook") && !strings.EqualFold(kind, "Note Hook") {
+		return Event{}, false, nil
+	}
+	var payload gitlabEvent
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return Event{}, false, fmt.Errorf("parse gitlab event: %w", err)
+	}
+	ev = Event{
+		Provider:  ProviderGitLab,
+		ProjectID: strings.TrimSpace(payload.Project.PathWithNamespace),
+		Author:    payload.User.Username,
+	}
+	switch strings.ToLower(payload.ObjectKind) {
+	case "merge_request":
+		switch payload.ObjectAttributes.Action {
+		case "open", "reopen":
+		case "update":
+			if payload.ObjectAttributes.OldRev == "" {
+				return Event{}, false, nil
+			}
+		default:
+			return Event{}, false, nil
+		}
+		ev.MRIID = payload.ObjectAttributes.IID
+		ev.Command = CommandReview
+	case "note":
+		if !strings.EqualFold(payload.ObjectAttributes.NoteableType, "MergeRequest") {
+			return Event{}, false, nil
+		}
+		cmd, found := mentionCommand(payload.ObjectAttributes.Note, mentionHandle)
+		if !found || isHandle(ev.Author, mentionHandle) || isPrevNote(payload.ObjectAttributes.Note) {
+			return Event{}, false, nil
+		}
+		ev.MRIID = payload.MergeRequest.IID
+		ev.Command = cmd
+	default:
+		return Event{}, false, nil
+	}
+	if ev.ProjectID == "" || ev.MRIID <= 0 {
+		return Event{}, false, errors.New("gitlab event missing merge request context")
+	}
+	return ev, true, nil
+}
+
+type githubEvent struct {
+	Action     string `json:"action"`
+	Number     int64  `json:"number"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	PullRequest *struct {
+		Number int64 `json:"number"`
+	} `json:"pull_request"`
+	Issue struct {
+		Number      int64           `json:"number"`
+		PullRequest json.RawMessage `json:"pull_request"`
+	} `json:"issue"`
+	Comment struct {
+		Body string `json:"body"`
+		User struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	} `json:"comment"`
+	Sender struct {
+		Login string `json:"login"`
+	} `json:"sender"`
+}
+
+// ParseGitHub extracts an Event from a GitHub "pull_request",
+// "issue_comment" or "pull_request_review_comment" delivery, with the same
+// filtering as ParseGitLab.
+func ParseGitHub(headers http.Header, body []byte, mentionHandle string) (ev Event, ok bool, err error) {
+	kind := strings.ToLower(strings.TrimSpace(headers.Get("X-GitHub-Event")))
+	if kind != "pull_request" && kind != "issue_comment" && kind != "pull_request_review_comment" {
+		return Event{}, false, nil
+	}
+	var payload githubEvent
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return Event{}, false, fmt.Errorf("parse github event: %w", err)
+	}
+	ev = Event{
+		Provider:  ProviderGitHub,
+		ProjectID: strings.TrimSpace(payload.Repository.FullName),
+		Author:    payload.Sender.Login,
+	}
+	switch kind {
+	case "pull_request":
+		switch payload.Action {
+		case "opened", "reopened", "synchronize", "ready_for_review":
+		default:
+			return Event{}, false, nil
+		}
+		ev.MRIID = payload.Number
+		ev.Command = CommandReview
+	case "issue_comment", "pull_request_review_comment":
+		if payload.Action != "created" {
+			return Event{}, false, nil
+		}
+		if kind == "issue_comment" {
+			// Plain issues share this event; only PR comments carry pull_request.
+			if len(payload.Issue.PullRequest) == 0 || string(payload.Issue.PullRequest) == "null" {
+				return Event{}, false, nil
+			}
+			ev.MRIID = payload.Issue.Number
+		} else if payload.PullRequest != nil {
+			ev.MRIID = payload.PullRequest.Number
+		}
+		if login := payload.Comment.User.Login; login != "" {
+			ev.Author = login
+		}
+		cmd, found := mentionCommand(payload.Comment.Body, mentionHandle)
+		if !found || isHandle(ev.Author, mentionHandle) || isPrevNote(payload.Comment.Body) {
+			return Event{}, false, nil
+		}
+		ev.Command = cmd
+	}
+	if ev.ProjectID == "" || ev.MRIID <= 0 {
+		return Event{}, false, errors.New("github event missing pull request context")
+	}
+	return ev, true, nil
+}
+
+// Handler serves webhook deliveries from both providers. Each provider's
+// deliveries are rejected unless its secret is set. Dispatch is called for
+// every accepted Event and must not block; the response is sent once it
+// returns.
+type Handler struct {
+	GitLabSecret  string
+	GitHubSecret  string
+	MentionHandle string
+	Dispatch      func(Event)
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	var (
+		ev Event
+		ok bool
+	)
+	switch {
+	case r.Header.Get("X-Gitlab-Event") != "":
+		if err := VerifyGitLab(h.GitLabSecret, r.Header); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		ev, ok, err = ParseGitLab(r.Header, body, h.MentionHandle)
+	case r.Header.Get("X-GitHub-Event") != "":
+		if err := VerifyGitHub(h.GitHubSecret, r.Header, body); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		ev, ok, err = ParseGitHub(r.Header, body, h.MentionHandle)
+	default:
+		http.Error(w, "unknown webhook source", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if h.Dispatch != nil {
+		h.Dispatch(ev)
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+var commandTokenRe = regexp.MustCompile(`[a-z0-9_-]+`)
+
+// mentionCommand returns the review, pause or resume command in body when
+// it also mentions handle, matching how `prev mr review` reads commands.
+func mentionCommand(body, handle string) (string, bool) {
+	handle = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
+	if handle == "" {
+		return "", false
+	}
+	hasHandle := false
+	command := ""
+	for _, tok := range commandTokenRe.FindAllString(strings.ToLower(body), -1) {
+		switch tok {
+		case handle:
+			hasHandle = true
+		case CommandReview, CommandPause, CommandResume:
+			if command == "" {
+				command = tok
+			}
+		}
+	}
+	return command, hasHandle && command != ""
+}
+
+func isHandle(author, handle string) bool {
+	author = strings.ToLower(strings.TrimSpace(author))
+	handle = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
+	return author != "" && author == handle
+}
+
+// isPrevNote reports whether body was posted by prev. Its summaries and
+// inline comments mention "prev" and "review", so without this check each
+// posted review would trigger another one whenever the bot account's login
+// differs from the mention handle.
+func isPrevNote(body string) bool {
+	return strings.Contains(strings.ToLower(body), prevMarkerPrefix)
+}
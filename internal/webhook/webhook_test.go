@@ -0,0 +1,183 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func githubSignature(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyGitLab(t *testing.T) {
+	h := http.Header{"X-Gitlab-Token": []string{"s3cret"}}
+	assert.NoError(t, VerifyGitLab("s3cret", h))
+	assert.ErrorIs(t, VerifyGitLab("other", h), ErrSecretMismatch)
+	assert.ErrorIs(t, VerifyGitLab("s3cret", http.Header{}), ErrSecretMismatch)
+	assert.ErrorIs(t, VerifyGitLab("", h), ErrSecretNotConfigured)
+}
+
+func TestVerifyGitHub(t *testing.T) {
+	body := []byte(`{"action":"opened"}`)
+	h := http.Header{"X-Hub-Signature-256": []string{githubSignature("s3cret", string(body))}}
+	assert.NoError(t, VerifyGitHub("s3cret", h, body))
+	assert.ErrorIs(t, VerifyGitHub("other", h, body), ErrSecretMismatch)
+	assert.ErrorIs(t, VerifyGitHub("s3cret", h, []byte(`{"action":"closed"}`)), ErrSecretMismatch)
+	assert.ErrorIs(t, VerifyGitHub("s3cret", http.Header{"X-Hub-Signature-256": []string{"sha256=zz"}}, body), ErrSecretMismatch)
+	assert.ErrorIs(t, VerifyGitHub("s3cret", http.Header{}, body), ErrSecretMismatch)
+	assert.ErrorIs(t, VerifyGitHub("", h, body), ErrSecretNotConfigured)
+}
+
+func TestParseGitLab_MergeRequestEvents(t *testing.T) {
+	h := http.Header{"X-Gitlab-Event": []string{"Merge Request Hook"}}
+	ev, ok, err := ParseGitLab(h, []byte(`{"object_kind":"merge_request","project":{"path_with_namespace":"grp/proj"},"object_attributes":{"iid":42,"action":"update","oldrev":"abc"}}`), "prev")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, Event{Provider: ProviderGitLab, ProjectID: "grp/proj", MRIID: 42, Command: CommandReview}, ev)
+
+	// Title edits arrive as updates without oldrev.
+	_, ok, err = ParseGitLab(h, []byte(`{"object_kind":"merge_request","project":{"path_with_namespace":"grp/proj"},"object_attributes":{"iid":42,"action":"update"}}`), "prev")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = ParseGitLab(h, []byte(`{"object_kind":"merge_request","project":{"path_with_namespace":"grp/proj"},"object_attributes":{"iid":42,"action":"merge"}}`), "prev")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestParseGitLab_NoteCommands(t *testing.T) {
+	h := http.Header{"X-Gitlab-Event": []string{"Note Hook"}}
+	note := func(author, text string) []byte {
+		return []byte(`{"object_kind":"note","user":{"username":"` + author + `"},"project":{"path_with_namespace":"grp/proj"},"merge_request":{"iid":7},"object_attributes":{"note":"` + text + `","noteable_type":"MergeRequest"}}`)
+	}
+	for text, want := range map[string]string{
+		"@prev review please": CommandReview,
+		"prev pause":          CommandPause,
+		"Prev, resume now":    CommandResume,
+	} {
+		ev, ok, err := ParseGitLab(h, note("alice", text), "prev")
+		require.NoError(t, err, text)
+		require.True(t, ok, text)
+		assert.Equal(t, want, ev.Command, text)
+		assert.EqualValues(t, 7, ev.MRIID)
+		assert.Equal(t, "alice", ev.Author)
+	}
+
+	_, ok, _ := ParseGitLab(h, note("alice", "looks good to me"), "prev")
+	assert.False(t, ok)
+	_, ok, _ = ParseGitLab(h, note("alice", "please review"), "prev")
+	assert.False(t, ok, "command without the mention handle")
+	_, ok, _ = ParseGitLab(h, note("prev", "prev review"), "prev")
+	assert.False(t, ok, "the bot's own notes never trigger it")
+}
+
+func TestParseGitLab_IgnoresPrevNotes(t *testing.T) {
+	h := http.Header{"X-Gitlab-Event": []string{"Note Hook"}}
+	for _, body := range []string{
+		// A summary note as prev posts it, from a bot account named differently
+		// from the mention handle.
+		"<!-- prev:summary -->\n## AI Code Review\n\nThe prev review found a missing nil check; please review `handler.go`.",
+		"[HIGH] Review the error path; prev cannot see a caller.\n\n<!-- prev:thread -->\n<!-- prev:fp:0a1b2c3d -->",
+		"<!-- prev:team-a:summary -->\nprev review",
+	} {
+		payload, err := json.Marshal(map[string]interface{}{
+			"object_kind":       "note",
+			"user":              map[string]string{"username": "project_42_bot"},
+			"project":           map[string]string{"path_with_namespace": "grp/proj"},
+			"merge_request":     map[string]int{"iid": 7},
+			"object_attributes": map[string]string{"note": body, "noteable_type": "MergeRequest"},
+		})
+		require.NoError(t, err)
+		_, ok, err := ParseGitLab(h, payload, "prev")
+		require.NoError(t, err)
+		assert.False(t, ok, body)
+	}
+
+	comment, err := json.Marshal(map[string]interface{}{
+		"action":     "created",
+		"issue":      map[string]interface{}{"number": 12, "pull_request": map[string]string{"url": "x"}},
+		"comment":    map[string]interface{}{"body": "<!-- prev:summary -->\n## AI Code Review\n\nprev review complete.", "user": map[string]string{"login": "github-actions[bot]"}},
+		"repository": map[string]string{"full_name": "org/repo"},
+	})
+	require.NoError(t, err)
+	_, ok, err := ParseGitHub(http.Header{"X-Github-Event": []string{"issue_comment"}}, comment, "prev")
+	require.NoError(t, err)
+	assert.False(t, ok, "github summary comment")
+}
+
+func TestParseGitHub_Events(t *testing.T) {
+	pr := http.Header{"X-Github-Event": []string{"pull_request"}}
+	ev, ok, err := ParseGitHub(pr, []byte(`{"action":"synchronize","number":12,"repository":{"full_name":"org/repo"},"sender":{"login":"bob"}}`), "prev")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, Event{Provider: ProviderGitHub, ProjectID: "org/repo", MRIID: 12, Command: CommandReview, Author: "bob"}, ev)
+
+	_, ok, _ = ParseGitHub(pr, []byte(`{"action":"closed","number":12,"repository":{"full_name":"org/repo"}}`), "prev")
+	assert.False(t, ok)
+
+	comment := http.Header{"X-Github-Event": []string{"issue_comment"}}
+	ev, ok, err = ParseGitHub(comment, []byte(`{"action":"created","issue":{"number":12,"pull_request":{"url":"x"}},"comment":{"body":"prev pause","user":{"login":"bob"}},"repository":{"full_name":"org/repo"}}`), "prev")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, CommandPause, ev.Command)
+	assert.EqualValues(t, 12, ev.MRIID)
+
+	_, ok, _ = ParseGitHub(comment, []byte(`{"action":"created","issue":{"number":3},"comment":{"body":"prev review","user":{"login":"bob"}},"repository":{"full_name":"org/repo"}}`), "prev")
+	assert.False(t, ok, "comments on plain issues are ignored")
+
+	review := http.Header{"X-Github-Event": []string{"pull_request_review_comment"}}
+	ev, ok, err = ParseGitHub(review, []byte(`{"action":"created","pull_request":{"number":9},"comment":{"body":"prev resume","user":{"login":"bob"}},"repository":{"full_name":"org/repo"}}`), "prev")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, CommandResume, ev.Command)
+	assert.EqualValues(t, 9, ev.MRIID)
+
+	_, ok, err = ParseGitHub(http.Header{"X-Github-Event": []string{"ping"}}, []byte(`{}`), "prev")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestHandler_VerifiesAndDispatches(t *testing.T) {
+	var got []Event
+	h := &Handler{GitLabSecret: "gl", GitHubSecret: "gh", MentionHandle: "prev", Dispatch: func(ev Event) { got = append(got, ev) }}
+
+	post := func(headers map[string]string, body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	ghBody := `{"action":"opened","number":5,"repository":{"full_name":"org/repo"}}`
+	assert.Equal(t, http.StatusAccepted, post(map[string]string{"X-GitHub-Event": "pull_request", "X-Hub-Signature-256": githubSignature("gh", ghBody)}, ghBody))
+	assert.Equal(t, http.StatusUnauthorized, post(map[string]string{"X-GitHub-Event": "pull_request", "X-Hub-Signature-256": githubSignature("wrong", ghBody)}, ghBody))
+
+	glBody := `{"object_kind":"note","user":{"username":"alice"},"project":{"path_with_namespace":"grp/proj"},"merge_request":{"iid":8},"object_attributes":{"note":"prev review","noteable_type":"MergeRequest"}}`
+	assert.Equal(t, http.StatusAccepted, post(map[string]string{"X-Gitlab-Event": "Note Hook", "X-Gitlab-Token": "gl"}, glBody))
+	assert.Equal(t, http.StatusUnauthorized, post(map[string]string{"X-Gitlab-Event": "Note Hook", "X-Gitlab-Token": "nope"}, glBody))
+	assert.Equal(t, http.StatusNoContent, post(map[string]string{"X-Gitlab-Event": "Push Hook", "X-Gitlab-Token": "gl"}, `{}`))
+	assert.Equal(t, http.StatusBadRequest, post(map[string]string{}, `{}`))
+
+	require.Len(t, got, 2)
+	assert.Equal(t, "org/repo", got[0].ProjectID)
+	assert.Equal(t, "grp/proj", got[1].ProjectID)
+
+	req := httptest.NewRequest(http.MethodGet, "/webhook", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}